@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/agentflow/agentflow/internal/acp"
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/permission"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/agentflow/agentflow/internal/skill"
+	"github.com/spf13/cobra"
+)
+
+var acpCmd = &cobra.Command{
+	Use:   "acp",
+	Short: "Run as an editor agent backend over stdio",
+	Long: `Speak JSON-RPC 2.0 on stdin/stdout so editors (Zed, Neovim, VS Code)
+can embed agentflow as their agent backend.
+
+Methods:
+  initialize       Handshake, returns protocol version and agent info
+  session/new      Start a session ({"cwd"}, or {"sessionId"} to resume)
+  session/prompt   Send a prompt; streams session/update notifications
+  session/cancel   Cancel the running prompt
+
+Before running a tool that changes things, the agent calls
+session/request_permission on the editor unless a rule in
+.agentflow/permissions.json allows the call. Logs go to stderr; stdout
+carries protocol messages only.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		spec := modelSpec
+		if spec == "" {
+			spec = cfg.Defaults.Main
+		}
+		registry := cfg.BuildRegistry()
+		provider, modelName, ok := registry.ResolveModel(spec)
+		if !ok {
+			return fmt.Errorf("unknown model: %s", spec)
+		}
+
+		skillLoader := cfg.SkillLoader()
+		if err := skillLoader.Load(); err != nil {
+			return fmt.Errorf("load skills: %w", err)
+		}
+
+		workdir, _ := os.Getwd()
+		perms, err := permission.Load(workdir)
+		if err != nil {
+			return err
+		}
+
+		srv := acp.New(acp.Config{
+			NewAgent: func() *agent.Agent {
+				return agent.New(agent.Config{
+					Provider:     provider,
+					Model:        modelName,
+					Skills:       skillLoader,
+					ResolveModel: cfg.ModelResolver(registry),
+					SystemPrompt: cfg.Language.AnswerInstruction(),
+					Tools:        cfg.BuildTools(),
+					Budget:       cfg.ContextBudget(),
+					Guard:        cfg.InjectionGuard(),
+					MaxCost:      cfg.Defaults.MaxCost,
+					Audit:        auditLog(cfg, ""),
+					Params:       cfg.GenerationParams(),
+					SkillStats:   skill.NewStats(""),
+				})
+			},
+			Skills:      skillLoader,
+			Sessions:    session.NewManager(""),
+			Permissions: perms,
+			Provider:    provider.Name(),
+			Model:       modelName,
+			Version:     version,
+		})
+
+		fmt.Fprintf(os.Stderr, "agentflow acp: serving %s on stdio\n", spec)
+		return srv.Serve(ctx, os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(acpCmd)
+}
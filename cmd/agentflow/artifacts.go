@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/agentflow/agentflow/internal/artifact"
+	"github.com/spf13/cobra"
+)
+
+var artifactsCmd = &cobra.Command{
+	Use:   "artifacts",
+	Short: "List and open the outputs saved by sessions",
+	Long: `Plans, reports and generated files the model saves with its
+save_artifact tool are kept in .agentflow/artifacts/<session>/ with an
+index, so they outlive the chat.`,
+}
+
+var artifactsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the project's artifacts, latest first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workdir, _ := os.Getwd()
+		all, err := artifact.List(workdir)
+		if err != nil {
+			return err
+		}
+		if session, _ := cmd.Flags().GetString("session"); session != "" {
+			var matching []artifact.Artifact
+			for _, a := range all {
+				if a.Session == session {
+					matching = append(matching, a)
+				}
+			}
+			all = matching
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(all)
+		}
+		if len(all) == 0 {
+			fmt.Println("No artifacts")
+			return nil
+		}
+		for _, a := range all {
+			fmt.Println(a.Describe())
+		}
+		return nil
+	},
+}
+
+var artifactsOpenCmd = &cobra.Command{
+	Use:   "open <name|session/name>",
+	Short: "Open an artifact in $EDITOR, or print it",
+	Long: `Open an artifact in $VISUAL or $EDITOR. Without an editor, or with
+--print, its contents are written to stdout; --path prints where it is.
+A bare name picks the latest artifact called that.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workdir, _ := os.Getwd()
+		a, err := artifact.Find(workdir, args[0])
+		if err != nil {
+			return err
+		}
+		if path, _ := cmd.Flags().GetBool("path"); path {
+			fmt.Println(a.Path)
+			return nil
+		}
+
+		editor := os.Getenv("VISUAL")
+		if editor == "" {
+			editor = os.Getenv("EDITOR")
+		}
+		if toStdout, _ := cmd.Flags().GetBool("print"); toStdout || editor == "" {
+			data, err := os.ReadFile(a.Path)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+
+		c := exec.Command("sh", "-c", editor+` "$1"`, "sh", a.Path)
+		c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+		return c.Run()
+	},
+}
+
+func init() {
+	artifactsListCmd.Flags().String("session", "", "only this session's artifacts")
+	artifactsListCmd.Flags().Bool("json", false, "print the list as JSON")
+	artifactsOpenCmd.Flags().Bool("print", false, "print the contents instead of opening an editor")
+	artifactsOpenCmd.Flags().Bool("path", false, "print the artifact's path")
+
+	artifactsCmd.AddCommand(artifactsListCmd)
+	artifactsCmd.AddCommand(artifactsOpenCmd)
+	rootCmd.AddCommand(artifactsCmd)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/clipboard"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/agentflow/agentflow/internal/tui"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var askCmd = &cobra.Command{
+	Use:   "ask [question]",
+	Short: "Ask one quick question and print the answer",
+	Long: `Ask the model one question, print the answer and exit. Without a
+question, a one-line prompt asks for it, or it is read from stdin when
+piped. Nothing is saved unless --save is given.
+
+Made for a window manager keybinding that opens a small terminal:
+
+  # sway / i3
+  bindsym $mod+a exec alacritty --class agentflow-ask -e agentflow ask --wait
+
+Example:
+  agentflow ask "tar flags to extract a .tar.zst"
+  agentflow ask --copy "a regex for ISO 8601 dates"`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		setupLocale(cfg)
+		a, err := newAgent(cfg, modelSpec)
+		if err != nil {
+			return err
+		}
+
+		question := strings.Join(args, " ")
+		if question == "" && !term.IsTerminal(os.Stdin.Fd()) {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			question = strings.TrimSpace(string(data))
+		}
+		if question == "" {
+			var ok bool
+			if question, ok, err = tui.AskQuestion(a.Model()); err != nil || !ok {
+				return err
+			}
+		}
+
+		chunks, err := a.Stream(ctx, question)
+		if err != nil {
+			return err
+		}
+		for chunk := range chunks {
+			if chunk.Error != nil {
+				return chunk.Error
+			}
+			fmt.Print(chunk.Content)
+		}
+		fmt.Println()
+
+		_, answer, _ := a.LastExchange()
+		if copyAnswer, _ := cmd.Flags().GetBool("copy"); copyAnswer && answer != "" {
+			if err := clipboard.WriteText(answer); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: copy: %v\n", err)
+			}
+		}
+
+		if save, _ := cmd.Flags().GetBool("save"); save {
+			workdir, _ := os.Getwd()
+			spec := modelSpec
+			if spec == "" {
+				spec = cfg.Defaults.Main
+			}
+			providerName, _, _ := strings.Cut(spec, "/")
+			sess := session.New(workdir, providerName, a.Model())
+			sess.Messages = a.Messages()
+			sess.SetActivity(a.Activity())
+			sess.UpdatedAt = time.Now()
+			if err := session.NewManager("").Save(sess); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Saved as session %s; agentflow -r %s continues it\n", sess.ID, sess.ID)
+		}
+
+		// A terminal opened just for the question would close on the answer
+		if wait, _ := cmd.Flags().GetBool("wait"); wait && term.IsTerminal(os.Stdin.Fd()) {
+			fmt.Fprint(os.Stderr, "Press Enter to close")
+			bufio.NewReader(os.Stdin).ReadString('\n')
+		}
+		return nil
+	},
+}
+
+func init() {
+	askCmd.Flags().Bool("save", false, "save the question and answer as a session")
+	askCmd.Flags().Bool("copy", false, "copy the answer to the clipboard")
+	askCmd.Flags().Bool("wait", false, "wait for Enter before exiting, for terminals opened just to ask")
+
+	rootCmd.AddCommand(askCmd)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/agentflow/agentflow/internal/audit"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the audit log of agents' actions",
+	Long: `With audit.enabled in the config, every provider call, tool run, shell
+command and file change is appended to ~/.agentflow/audit/<session>.jsonl
+(or audit.dir). Each entry holds the hash of the one before, so changing,
+removing or reordering entries is detected when the log is shown.`,
+}
+
+var auditListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the sessions with an audit log, latest first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sessions, err := audit.List(auditDir())
+		if err != nil {
+			return err
+		}
+		if len(sessions) == 0 {
+			fmt.Println("No audit logs")
+			return nil
+		}
+		for _, s := range sessions {
+			fmt.Printf("%-24s %s  %s\n", s.ID, s.Modified.Format("Jan 2 15:04"), session.FormatSize(s.Size))
+		}
+		return nil
+	},
+}
+
+var auditShowCmd = &cobra.Command{
+	Use:   "show <session>",
+	Short: "Print a session's audit log and check its hash chain",
+	Long: `Print a session's audit log, one action a line, and check that its
+hash chain is unbroken. Exits with an error when an entry was changed,
+removed or moved.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := filepath.Join(auditDir(), filepath.Base(args[0])+".jsonl")
+		entries, err := audit.Read(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no audit log for session %s (agentflow audit list shows them)", args[0])
+		}
+		if err != nil {
+			return err
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(entries); err != nil {
+				return err
+			}
+		} else {
+			for _, e := range entries {
+				fmt.Printf("%4d %s %-8s %s", e.Seq, e.Time.Local().Format("2006-01-02 15:04:05"), e.Kind, e.Action)
+				if e.Detail != "" {
+					fmt.Printf(" · %s", e.Detail)
+				}
+				if e.Error != "" {
+					fmt.Printf(" ✗ %s", e.Error)
+				}
+				fmt.Println()
+			}
+		}
+
+		if err := audit.Verify(entries); err != nil {
+			return fmt.Errorf("audit log tampered with: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "✓ %d entries, hash chain intact\n", len(entries))
+		return nil
+	},
+}
+
+// auditDir is where audit logs are kept: audit.dir from the config, or
+// the default
+func auditDir() string {
+	if cfg, err := loadConfig(); err == nil && cfg.Audit.Dir != "" {
+		return cfg.Audit.Dir
+	}
+	return audit.Dir()
+}
+
+func init() {
+	auditShowCmd.Flags().Bool("json", false, "print the entries as JSON")
+
+	auditCmd.AddCommand(auditListCmd)
+	auditCmd.AddCommand(auditShowCmd)
+	rootCmd.AddCommand(auditCmd)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/bridge"
+	"github.com/agentflow/agentflow/internal/config"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var bridgeCmd = &cobra.Command{
+	Use:   "bridge slack|discord",
+	Short: "Relay a Slack or Discord bot to the agent",
+	Long: `Connect a bot to chat channels and relay messages to the agent so a team can
+share one agentflow instance. Each Slack thread or Discord channel maps to its
+own saved session, and answers stream in by editing the bot's reply.
+
+Configure the token and channel IDs in config:
+
+  bridge:
+    slack:
+      token: ${SLACK_BOT_TOKEN}
+      channels: [C0123456789]
+    discord:
+      token: ${DISCORD_BOT_TOKEN}
+      channels: ["112233445566778899"]`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"slack", "discord"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		var bot config.BotConfig
+		switch args[0] {
+		case "slack":
+			bot = cfg.Bridge.Slack
+		case "discord":
+			bot = cfg.Bridge.Discord
+		default:
+			return fmt.Errorf("unknown platform: %s (expected slack or discord)", args[0])
+		}
+
+		if channels, _ := cmd.Flags().GetStringSlice("channel"); len(channels) > 0 {
+			bot.Channels = channels
+		}
+		if bot.Token == "" {
+			bot.Token = os.Getenv(strings.ToUpper(args[0]) + "_BOT_TOKEN")
+		}
+		if bot.Token == "" {
+			return fmt.Errorf("no %s bot token: set bridge.%s.token or %s_BOT_TOKEN", args[0], args[0], strings.ToUpper(args[0]))
+		}
+		if len(bot.Channels) == 0 {
+			return fmt.Errorf("no channels configured: set bridge.%s.channels or pass --channel", args[0])
+		}
+
+		var platform bridge.Platform
+		if args[0] == "slack" {
+			platform = bridge.NewSlack(bot.Token, bot.Channels)
+		} else {
+			platform = bridge.NewDiscord(bot.Token, bot.Channels)
+		}
+
+		spec := modelSpec
+		if spec == "" {
+			spec = cfg.Defaults.Main
+		}
+		registry := cfg.BuildRegistry()
+		provider, modelName, ok := registry.ResolveModel(spec)
+		if !ok {
+			return fmt.Errorf("unknown model: %s", spec)
+		}
+
+		skillLoader := cfg.SkillLoader()
+		if err := skillLoader.Load(); err != nil {
+			return fmt.Errorf("load skills: %w", err)
+		}
+
+		workdir, _ := os.Getwd()
+		b := bridge.New(bridge.Config{
+			Platform: platform,
+			NewAgent: func() *agent.Agent {
+				return agent.New(agent.Config{
+					Provider:     provider,
+					Model:        modelName,
+					Skills:       skillLoader,
+					ResolveModel: cfg.ModelResolver(registry),
+					SystemPrompt: cfg.Language.AnswerInstruction(),
+					Tools:        cfg.BuildTools(),
+					Budget:       cfg.ContextBudget(),
+					Guard:        cfg.InjectionGuard(),
+					MaxCost:      cfg.Defaults.MaxCost,
+					Audit:        auditLog(cfg, ""),
+					Params:       cfg.GenerationParams(),
+				})
+			},
+			Sessions: session.NewManager(""),
+			Workdir:  workdir,
+			Provider: provider.Name(),
+			Model:    modelName,
+		})
+
+		fmt.Fprintf(os.Stderr, "🔌 Relaying %s channels %s to %s (Ctrl+C to stop)\n",
+			args[0], strings.Join(bot.Channels, ", "), spec)
+		return b.Run(ctx)
+	},
+}
+
+func init() {
+	bridgeCmd.Flags().StringSlice("channel", nil, "channel ID to relay (repeatable, overrides config)")
+
+	rootCmd.AddCommand(bridgeCmd)
+}
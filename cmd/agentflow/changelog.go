@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/agentflow/agentflow/internal/changelog"
+	"github.com/agentflow/agentflow/internal/github"
+	"github.com/spf13/cobra"
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Draft release notes from the commits since a tag",
+	Long: `Collect the commits and pull requests between two refs, group them by
+conventional commit type, and have the reviewer model draft release notes.
+The markdown is printed to stdout, ready for a GitHub release.
+
+Example:
+  agentflow changelog --from v1.2.0
+  agentflow changelog --from v1.2.0 --to v1.3.0 --raw > NOTES.md`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		if from == "" {
+			tag, err := gitOutput("describe", "--tags", "--abbrev=0", to)
+			if err != nil {
+				return fmt.Errorf("no --from given and no tag found: %w", err)
+			}
+			from = tag
+		}
+
+		commits, err := changelog.Collect("", from, to)
+		if err != nil {
+			return err
+		}
+		if len(commits) == 0 {
+			return fmt.Errorf("no commits between %s and %s", from, to)
+		}
+		sections := changelog.Group(commits)
+
+		// Links are a nicety; a repo without a GitHub remote still gets notes
+		repo, _ := cmd.Flags().GetString("repo")
+		if repo == "" {
+			repo, _ = github.CurrentRepo()
+		}
+
+		if raw, _ := cmd.Flags().GetBool("raw"); raw {
+			fmt.Print(changelog.Markdown(sections, repo))
+			return nil
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		model := modelSpec
+		if model == "" {
+			model = cfg.Defaults.Reviewer
+		}
+		a, err := newAgent(cfg, model)
+		if err != nil {
+			return err
+		}
+
+		version, _ := cmd.Flags().GetString("version")
+		if version == "" && to != "HEAD" {
+			version = to
+		}
+
+		fmt.Fprintf(os.Stderr, "Drafting release notes for %d commits since %s...\n", len(commits), from)
+		resp, err := a.Run(ctx, changelog.Prompt(version, sections, repo))
+		if err != nil {
+			return err
+		}
+		fmt.Println(strings.TrimSpace(resp.Content))
+		return nil
+	},
+}
+
+func init() {
+	changelogCmd.Flags().String("from", "", "start of the range, exclusive (default: latest tag)")
+	changelogCmd.Flags().String("to", "HEAD", "end of the range")
+	changelogCmd.Flags().String("version", "", "version named in the notes (default: --to when it is a tag)")
+	changelogCmd.Flags().String("repo", "", "repository as owner/name for pull request links (default: origin remote)")
+	changelogCmd.Flags().Bool("raw", false, "print the grouped commits without asking the model")
+
+	rootCmd.AddCommand(changelogCmd)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/clipboard"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// defaultClipboardQuestion is asked when --from-clipboard is given no message
+const defaultClipboardQuestion = "What's wrong here, and how do I fix it?"
+
+// withClipboard attaches the clipboard to the agent's next message: images
+// as attachments, text inlined into the prompt. It returns the prompt to send.
+func withClipboard(a *agent.Agent, message string) (string, error) {
+	if message == "" {
+		message = defaultClipboardQuestion
+	}
+
+	if img, err := clipboard.ReadImage(); err == nil {
+		a.Attach(types.Attachment{Type: "image", MimeType: "image/png", Name: "clipboard", Data: img})
+		return message, nil
+	}
+
+	text, err := clipboard.ReadText()
+	if errors.Is(err, clipboard.ErrEmpty) {
+		return "", fmt.Errorf("clipboard is empty")
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Clipboard contents:\n```\n%s\n```\n\n%s", text, message), nil
+}
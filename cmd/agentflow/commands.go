@@ -0,0 +1,679 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/artifact"
+	"github.com/agentflow/agentflow/internal/bundle"
+	"github.com/agentflow/agentflow/internal/clipboard"
+	"github.com/agentflow/agentflow/internal/config"
+	"github.com/agentflow/agentflow/internal/instance"
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/internal/repomap"
+	"github.com/agentflow/agentflow/internal/router"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/agentflow/agentflow/pkg/tokens"
+)
+
+// agentCommands handles the slash commands that act on the agent rather
+// than the UI. saveFeedback stores /good and /bad ratings in the session;
+// nil when there is none.
+func agentCommands(cfg *config.Config, ag *agent.Agent, saveFeedback func(session.Feedback) error) func(cmd string, args []string) (string, bool) {
+	return func(cmd string, args []string) (string, bool) {
+		switch cmd {
+		case "/good", "/bad":
+			return feedbackCommand(ag, saveFeedback, cmd == "/good", strings.Join(args, " ")), true
+
+		case "/template":
+			return templateCommand(cfg, ag, args), true
+
+		case "/examples":
+			if len(args) > 0 {
+				ag.SetExamplesEnabled(args[0] != "off")
+			}
+			state := "off"
+			if ag.ExamplesEnabled() {
+				state = "on"
+			}
+			return fmt.Sprintf("Few-shot examples: %s (%d loaded)", state, len(ag.Examples())), true
+
+		case "/pin":
+			return pinCommand(ag, args, true), true
+
+		case "/unpin":
+			return pinCommand(ag, args, false), true
+
+		case "/refresh":
+			return refreshCommand(ag, args), true
+
+		case "/context":
+			return contextCommand(ag, args), true
+
+		case "/skill":
+			return skillCommand(ag, args), true
+
+		case "/preview":
+			p, err := ag.Preview("", strings.Join(args, " "))
+			if err != nil {
+				return err.Error(), true
+			}
+			return strings.TrimRight(p.String(), "\n"), true
+
+		case "/tokens":
+			return tokensCommand(ag, args), true
+
+		case "/swap":
+			answer, ok := ag.SwapAnswer()
+			if !ok {
+				return "No other answer to swap in (it comes from the speculative model)", true
+			}
+			return "🔁 Swapped the last answer for:\n\n" + answer, true
+
+		case "/set":
+			if len(args) != 2 {
+				return fmt.Sprintf("Usage: /set <%s> <value|default>", strings.Join(agent.ParamNames, "|")), true
+			}
+			if err := ag.SetParam(args[0], args[1]); err != nil {
+				return err.Error(), true
+			}
+			return settingsSummary(ag), true
+
+		case "/settings":
+			return settingsSummary(ag), true
+
+		case "/style":
+			return styleCommand(cfg, ag, args), true
+
+		case "/concise", "/verbose":
+			name := agent.StyleConcise
+			if cmd == "/verbose" {
+				name = agent.StyleDetailed
+			}
+			if ag.Style().Name == name {
+				name = agent.StyleNormal
+			}
+			return styleCommand(cfg, ag, []string{name}), true
+
+		case "/system":
+			return systemCommand(ag, args), true
+
+		case "/who":
+			return whoSummary(), true
+
+		case "/artifacts":
+			return artifactsSummary(), true
+
+		case "/show":
+			return messageCommand(ag, args, false), true
+
+		case "/copy-msg":
+			return messageCommand(ag, args, true), true
+
+		case "/plan":
+			return planCommand(ag, args), true
+
+		case "/map":
+			m, err := addProjectMap(ag)
+			if err != nil {
+				return err.Error(), true
+			}
+			return fmt.Sprintf("🗺 Added the project map (%d files) to context:\n%s", len(m.Files), m), true
+		}
+		return "", false
+	}
+}
+
+// pinCommand pins or unpins a file or a message. Messages are numbered
+// from 1 in history order; "last" is the most recent one.
+func pinCommand(ag *agent.Agent, args []string, pin bool) string {
+	if len(args) == 0 {
+		if !pin {
+			return "Usage: /unpin <file|n|all>"
+		}
+		if summary := pinnedSummary(ag); summary != "" {
+			return summary
+		}
+		return "Nothing pinned. Usage: /pin <file|n|last>"
+	}
+
+	target := strings.Join(args, " ")
+	if !pin && target == "all" {
+		for _, path := range ag.PinnedFiles() {
+			ag.UnpinFile(path)
+		}
+		for _, i := range ag.PinnedMessages() {
+			ag.PinMessage(i, false)
+		}
+		return "Unpinned everything"
+	}
+
+	index, isMessage := len(ag.Messages())-1, target == "last"
+	if n, err := strconv.Atoi(target); err == nil {
+		index, isMessage = n-1, true
+	}
+	if isMessage {
+		if err := ag.PinMessage(index, pin); err != nil {
+			return err.Error()
+		}
+		if pin {
+			return fmt.Sprintf("📌 Pinned message %d", index+1)
+		}
+		return fmt.Sprintf("Unpinned message %d", index+1)
+	}
+
+	if !pin {
+		if ag.UnpinFile(target) {
+			return "Unpinned " + target
+		}
+		return "Not pinned: " + target
+	}
+	if err := ag.PinFile(target); err != nil {
+		return err.Error()
+	}
+	return "📌 Pinned " + target + " (sent with every message)"
+}
+
+// messageCommand prints message n of the history in full for /show, or
+// copies it to the clipboard for /copy-msg; n counts as /pin does, and
+// the last message is used without one
+func messageCommand(ag *agent.Agent, args []string, toClipboard bool) string {
+	msgs := ag.Messages()
+	index := len(msgs) - 1
+	if len(args) > 0 && args[0] != "last" {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return "Usage: /show|/copy-msg [n|last]"
+		}
+		index = n - 1
+	}
+	if index < 0 || index >= len(msgs) {
+		return fmt.Sprintf("No message %d (history has %d)", index+1, len(msgs))
+	}
+	msg := msgs[index]
+
+	if toClipboard {
+		if err := clipboard.WriteText(msg.Content); err != nil {
+			return err.Error()
+		}
+		return fmt.Sprintf("📋 Copied message %d (%d characters)", index+1, len(msg.Content))
+	}
+	header := fmt.Sprintf("#%d %s", index+1, msg.Role)
+	if !msg.Timestamp.IsZero() {
+		header += " · " + msg.Timestamp.Format("Jan 2 15:04")
+	}
+	return header + "\n\n" + msg.Content
+}
+
+// tokensCommand counts tokens with the model's tokenizer: in the text
+// given, the last message, and the conversation
+func tokensCommand(ag *agent.Agent, args []string) string {
+	model := ag.Model()
+	how := "estimated at 4 characters per token"
+	if enc := tokens.Encoding(model); enc != "" {
+		how = enc
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🔢 Tokens for %s (%s)", model, how)
+	if text := strings.Join(args, " "); text != "" {
+		fmt.Fprintf(&sb, "\nInput:         %d", tokens.Tokens(model, text))
+	}
+	msgs := ag.Messages()
+	if len(msgs) > 0 {
+		last := msgs[len(msgs)-1]
+		fmt.Fprintf(&sb, "\nLast message:  %d (%s)", tokens.Tokens(model, last.Content), last.Role)
+	}
+	fmt.Fprintf(&sb, "\nConversation:  %d in %d messages", tokens.Messages(model, msgs), len(msgs))
+
+	// What the next request sends also has pinned files and examples, and
+	// the history as cut to its budgets
+	if p, err := ag.Preview("", ""); err == nil {
+		request := tokens.Messages(model, p.Request.Messages)
+		if window := ag.ModelInfo().ContextWindow; window > 0 {
+			fmt.Fprintf(&sb, "\nNext request:  %d of the %d-token window (%.0f%%)", request, window, 100*float64(request)/float64(window))
+		} else {
+			fmt.Fprintf(&sb, "\nNext request:  %d", request)
+		}
+	}
+	return sb.String()
+}
+
+// refreshCommand re-sends the given file, or every file that changed on
+// disk since it was added to context
+func refreshCommand(ag *agent.Agent, args []string) string {
+	paths := args
+	if len(paths) == 0 {
+		paths = ag.ChangedFiles()
+	}
+	if len(paths) == 0 {
+		return "No files in context have changed"
+	}
+
+	var sb strings.Builder
+	for _, path := range paths {
+		if err := ag.RefreshFile(path); err != nil {
+			sb.WriteString(fmt.Sprintf("✗ %s: %v\n", path, err))
+			continue
+		}
+		sb.WriteString("↻ Refreshed " + displayPath(path) + "\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// contextCommand saves, loads or lists the named context bundles of the
+// working directory
+func contextCommand(ag *agent.Agent, args []string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err.Error()
+	}
+
+	if len(args) == 0 || args[0] == "list" {
+		names, err := bundle.List(wd)
+		if err != nil {
+			return err.Error()
+		}
+		if len(names) == 0 {
+			return "No saved contexts. Usage: /context save|load <name>"
+		}
+		var sb strings.Builder
+		sb.WriteString("Contexts:")
+		for _, name := range names {
+			sb.WriteString("\n• " + name)
+			if b, err := bundle.Load(wd, name); err == nil {
+				sb.WriteString(" (" + b.Summary() + ")")
+			}
+		}
+		return sb.String()
+	}
+	if len(args) < 2 {
+		return "Usage: /context save|load <name>"
+	}
+
+	switch args[0] {
+	case "save":
+		b, err := bundle.Capture(ag, wd, args[1])
+		if err != nil {
+			return err.Error()
+		}
+		if err := bundle.Save(wd, b); err != nil {
+			return err.Error()
+		}
+		return fmt.Sprintf("💾 Saved context %s (%s)", b.Name, b.Summary())
+	case "load":
+		b, err := bundle.Load(wd, args[1])
+		if err != nil {
+			return err.Error()
+		}
+		msg := fmt.Sprintf("📦 Loaded context %s (%s)", b.Name, b.Summary())
+		if missing := bundle.Apply(ag, wd, b); len(missing) > 0 {
+			msg += "\n⚠ No longer on disk: " + strings.Join(missing, ", ")
+		}
+		return msg
+	}
+	return "Usage: /context save|load <name>"
+}
+
+// displayPath shortens a path relative to the working directory
+func displayPath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
+
+// addProjectMap adds a map of the working directory to the conversation
+func addProjectMap(ag *agent.Agent) (*repomap.Map, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	m, err := repomap.Update(wd)
+	if err != nil {
+		return nil, err
+	}
+	ag.AddContext(agent.SourceRetrieved, "Project map", "Files with sizes, languages and exported Go symbols:\n```\n"+m.String()+"\n```")
+	return m, nil
+}
+
+// autoProjectMap adds the project map at the start of a new session in
+// a small repository, returning a notice for the user, or ""
+func autoProjectMap(ag *agent.Agent) string {
+	wd, err := os.Getwd()
+	if err != nil || !repomap.IsSmall(wd) {
+		return ""
+	}
+	m, err := addProjectMap(ag)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("🗺 Project map (%d files) added to context; /map refreshes it", len(m.Files))
+}
+
+// pinnedSummary describes pinned files and messages for /pin and /status
+func pinnedSummary(ag *agent.Agent) string {
+	files, msgs := ag.PinnedFiles(), ag.PinnedMessages()
+	if len(files) == 0 && len(msgs) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Pinned\n──────")
+	for _, path := range files {
+		sb.WriteString("\n• " + path)
+	}
+	history := ag.Messages()
+	for _, i := range msgs {
+		preview := strings.Join(strings.Fields(history[i].Content), " ")
+		if len(preview) > 50 {
+			preview = preview[:50] + "..."
+		}
+		sb.WriteString(fmt.Sprintf("\n• message %d (%s): %s", i+1, history[i].Role, preview))
+	}
+	return sb.String()
+}
+
+// metricsSummary describes live provider requests for /status
+func metricsSummary() string {
+	all := provider.AllMetrics()
+	if len(all) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Providers\n─────────")
+	for _, m := range all {
+		sb.WriteString(fmt.Sprintf("\n• %s: %d in flight, %d queued • %d requests, %d errors, %d rate limited",
+			m.Provider, m.InFlight, m.Queued, m.Requests, m.Errors, m.RateLimited))
+		if m.Latency > 0 {
+			sb.WriteString(fmt.Sprintf(" • avg %s", m.Latency.Round(100*time.Millisecond)))
+		}
+	}
+	return sb.String()
+}
+
+// routerSummary describes the auto router's latest decisions for /status
+func routerSummary() string {
+	recent := router.Recent()
+	if len(recent) == 0 {
+		return ""
+	}
+	recent = recent[max(len(recent)-5, 0):]
+
+	var sb strings.Builder
+	sb.WriteString("Router\n──────")
+	for _, d := range recent {
+		how := "classified"
+		if d.Heuristic {
+			how = "keywords"
+		}
+		sb.WriteString(fmt.Sprintf("\n• %s %s → %s (%s, %s in %s)",
+			d.Time.Format("15:04:05"), d.Task, d.Model, d.Tier, how, d.Classify.Round(10*time.Millisecond)))
+	}
+	return sb.String()
+}
+
+// settingsSummary lists the effective generation parameters for
+// /settings
+func settingsSummary(ag *agent.Agent) string {
+	p := ag.Params()
+	value := func(set bool, v any) string {
+		if !set {
+			return "provider default"
+		}
+		return fmt.Sprint(v)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Settings\n────────")
+	sb.WriteString("\n• model: " + ag.Model())
+	sb.WriteString("\n• temperature: " + value(p.Temperature != 0, p.Temperature))
+	if p.MaxTokens == 0 && ag.Style().MaxTokens > 0 {
+		sb.WriteString(fmt.Sprintf("\n• max_tokens: %d (%s style)", ag.Style().MaxTokens, ag.Style().Name))
+	} else {
+		sb.WriteString("\n• max_tokens: " + value(p.MaxTokens != 0, p.MaxTokens))
+	}
+	sb.WriteString("\n• top_p: " + value(p.TopP != 0, p.TopP))
+	if p.Seed != nil {
+		sb.WriteString(fmt.Sprintf("\n• seed: %d", *p.Seed))
+	} else {
+		sb.WriteString("\n• seed: " + value(false, nil))
+	}
+	sb.WriteString(fmt.Sprintf("\n• system prompt: %d characters (/system show)", len(ag.SystemPrompt())))
+	sb.WriteString("\n• style: " + value(ag.Style().Name != "", ag.Style().Name))
+	return sb.String()
+}
+
+// styleCommand lists the response styles, or switches to one, for /style
+func styleCommand(cfg *config.Config, ag *agent.Agent, args []string) string {
+	if len(args) == 0 {
+		var sb strings.Builder
+		sb.WriteString("Response styles:")
+		for _, name := range cfg.StyleNames() {
+			mark := " "
+			if name == ag.Style().Name {
+				mark = "•"
+			}
+			sb.WriteString(fmt.Sprintf("\n%s %s", mark, name))
+			if s, _ := cfg.Style(name); s.MaxTokens > 0 {
+				sb.WriteString(fmt.Sprintf(" (max %d tokens)", s.MaxTokens))
+			}
+		}
+		return sb.String()
+	}
+
+	s, ok := cfg.Style(args[0])
+	if !ok {
+		return fmt.Sprintf("Unknown style %s (one of %s)", args[0], strings.Join(cfg.StyleNames(), ", "))
+	}
+	ag.SetStyle(s)
+	return fmt.Sprintf("📏 Answers are now %s from the next message", s.Name)
+}
+
+// systemCommand shows or changes the system prompt for /system
+func systemCommand(ag *agent.Agent, args []string) string {
+	action := "show"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	text := strings.Join(args[min(len(args), 1):], " ")
+
+	switch action {
+	case "show":
+		if ag.SystemPrompt() == "" {
+			return "No system prompt; set one with /system set <prompt>"
+		}
+		return "System prompt:\n\n" + ag.SystemPrompt()
+	case "set", "append":
+		if text == "" {
+			return fmt.Sprintf("Usage: /system %s <text>", action)
+		}
+		if prompt := ag.SystemPrompt(); action == "append" && prompt != "" {
+			text = prompt + "\n\n" + text
+		}
+		ag.SetSystemPrompt(text)
+		return "✏️  System prompt updated; it applies from the next message:\n\n" + text
+	default:
+		return "Usage: /system [show|set <prompt>|append <text>]"
+	}
+}
+
+// whoSummary lists the running instances for /who
+func whoSummary() string {
+	all, err := instance.List(instance.DefaultDir())
+	if err != nil {
+		return err.Error()
+	}
+	if len(all) == 0 {
+		return "No agentflow instances registered"
+	}
+
+	workdir, _ := os.Getwd()
+	var sb strings.Builder
+	sb.WriteString("Instances (* in this directory)\n───────────────────────────────")
+	for _, inst := range all {
+		mark := " "
+		if inst.Workdir == workdir {
+			mark = "*"
+		}
+		sb.WriteString(fmt.Sprintf("\n%s %s", mark, inst.Describe()))
+		if inst.PID == os.Getpid() {
+			sb.WriteString(" (this one)")
+		} else if inst.Workdir != workdir {
+			sb.WriteString(" • " + inst.Workdir)
+		}
+	}
+	return sb.String()
+}
+
+// templateCommand lists templates or applies one to the agent
+func templateCommand(cfg *config.Config, ag *agent.Agent, args []string) string {
+	if len(args) == 0 {
+		if len(cfg.Templates) == 0 {
+			return "No templates configured (add them under templates: in config.yaml)"
+		}
+		names := make([]string, 0, len(cfg.Templates))
+		for name := range cfg.Templates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var sb strings.Builder
+		sb.WriteString("Templates:")
+		for _, name := range names {
+			sb.WriteString(fmt.Sprintf("\n• %s (%d examples)", name, len(cfg.Templates[name].Examples)))
+			if desc := cfg.Templates[name].Description; desc != "" {
+				sb.WriteString(" — " + desc)
+			}
+		}
+		return sb.String()
+	}
+
+	if args[0] == "off" {
+		ag.SetExamples(nil)
+		return "Template cleared"
+	}
+
+	if err := applyTemplate(cfg, ag, args[0]); err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("Using template %s (%d examples)", args[0], len(ag.Examples()))
+}
+
+// applyTemplate sets a configured template's examples on the agent
+func applyTemplate(cfg *config.Config, ag *agent.Agent, name string) error {
+	tmpl, ok := cfg.Templates[name]
+	if !ok {
+		return fmt.Errorf("unknown template: %s", name)
+	}
+	ag.SetExamples(tmpl.Examples)
+	ag.SetExamplesEnabled(true)
+	return nil
+}
+
+// skillCommand overrides trigger-based skill activation
+func skillCommand(ag *agent.Agent, args []string) string {
+	if len(args) == 0 {
+		switch forced, off := ag.SkillOverride(); {
+		case off:
+			return "Skills are off. Usage: /skill use <name>|off|auto"
+		case forced != "":
+			return fmt.Sprintf("Using %s for every message. Usage: /skill use <name>|off|auto", forced)
+		}
+		return "Skills are activated by their triggers. Usage: /skill use <name>|off|auto"
+	}
+
+	switch args[0] {
+	case "use":
+		if len(args) < 2 {
+			return "Usage: /skill use <name>"
+		}
+		if err := ag.UseSkill(args[1]); err != nil {
+			return err.Error()
+		}
+		return fmt.Sprintf("⚡ Using %s for every message; /skill auto to go back to triggers", args[1])
+	case "off":
+		ag.SkillsOff()
+		return "Skills off; /skill auto to turn them back on"
+	case "auto":
+		ag.AutoSkills()
+		return "Skills are activated by their triggers"
+	}
+	return "Usage: /skill use <name>|off|auto"
+}
+
+// feedbackCommand rates the last exchange for the session's eval set and
+// the active skills' stats
+func feedbackCommand(ag *agent.Agent, saveFeedback func(session.Feedback) error, good bool, note string) string {
+	prompt, response, ok := ag.LastExchange()
+	if !ok {
+		return "Nothing to rate yet"
+	}
+
+	f := session.Feedback{
+		Rating:   session.RatingBad,
+		Note:     note,
+		Prompt:   prompt,
+		Response: response,
+		Skills:   ag.RateSkills(good),
+		Model:    ag.Model(),
+		At:       time.Now(),
+	}
+	icon := "👎"
+	if good {
+		f.Rating, icon = session.RatingGood, "👍"
+	}
+
+	if saveFeedback == nil {
+		return icon + " Noted for the active skills; this session isn't saved, so the rating isn't kept"
+	}
+	if err := saveFeedback(f); err != nil {
+		return "Rating not saved: " + err.Error()
+	}
+	return fmt.Sprintf("%s Rated the last answer %s; export with agentflow sessions feedback", icon, f.Rating)
+}
+
+// planCommand turns plan mode on or off for /plan; without an argument
+// it toggles
+func planCommand(ag *agent.Agent, args []string) string {
+	on := !ag.PlanMode()
+	if len(args) > 0 {
+		on = args[0] != "off"
+	}
+	ag.SetPlanMode(on)
+	if !on {
+		return "Plan mode off; every tool is available again"
+	}
+	return fmt.Sprintf("📋 Plan mode: the model explores with read-only tools and ends with a plan, saved as %s. /execute carries it out; /plan off leaves without it.", agent.PlanArtifact)
+}
+
+// artifactsSummary lists the project's latest artifacts for /artifacts
+func artifactsSummary() string {
+	workdir, _ := os.Getwd()
+	all, err := artifact.List(workdir)
+	if err != nil {
+		return err.Error()
+	}
+	if len(all) == 0 {
+		return "No artifacts yet; the model saves plans, reports and generated files with save_artifact"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Artifacts (latest first)\n────────────────────────")
+	for i, a := range all {
+		if i == 20 {
+			sb.WriteString(fmt.Sprintf("\n... %d more; agentflow artifacts list shows them all", len(all)-20))
+			break
+		}
+		sb.WriteString("\n" + a.Describe())
+	}
+	return sb.String()
+}
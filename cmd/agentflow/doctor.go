@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/agentflow/agentflow/internal/config"
+	"github.com/agentflow/agentflow/internal/doctor"
+	"github.com/agentflow/agentflow/internal/history"
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/skills"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the configuration, skills and data directories for problems",
+	Long: `Check that skill paths exist, that no two skills share a name (the
+last one loaded silently wins), that every environment variable the config
+file uses is set, and that the session and history directories are
+writable.
+
+With --fix, create missing skill paths and directories and restore write
+permission on the ones agentflow owns.
+
+Example:
+  agentflow doctor
+  agentflow doctor --fix`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		configPath := cfgFile
+		if configPath == "" && config.ConfigSource != "(default - no config file found)" {
+			configPath = config.ConfigSource
+		}
+		opts := doctor.Options{
+			ConfigPath: configPath,
+			SkillPaths: cfg.Skills.Paths,
+		}
+		if cfg.Skills.BuiltinEnabled() {
+			opts.Builtin = skills.Starter
+		}
+		opts.Keys = make(map[string][]provider.Key)
+		for name, p := range cfg.Providers {
+			opts.Keys[name] = p.Keys
+		}
+		if home, err := os.UserHomeDir(); err == nil {
+			opts.Dirs = map[string]string{
+				"sessions": filepath.Join(home, ".agentflow", "sessions"),
+				"history":  filepath.Join(home, history.HistoryDir),
+			}
+		}
+
+		fix, _ := cmd.Flags().GetBool("fix")
+		failed := 0
+		for _, r := range doctor.Run(opts) {
+			fmt.Printf("%s %-9s %s\n", r.Status.Symbol(), r.Check, r.Detail)
+			if r.Status == doctor.OK {
+				continue
+			}
+			if fix && r.Fix != nil {
+				if err := r.Fix(); err != nil {
+					fmt.Printf("  fix failed: %v\n", err)
+				} else {
+					fmt.Println("  fixed")
+					continue
+				}
+			}
+			if r.Status == doctor.Fail {
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d problem(s) found", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	doctorCmd.Flags().Bool("fix", false, "repair the problems that can be repaired")
+
+	rootCmd.AddCommand(doctorCmd)
+}
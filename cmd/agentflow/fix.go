@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/fix"
+	"github.com/spf13/cobra"
+)
+
+var fixCmd = &cobra.Command{
+	Use:   "fix",
+	Short: "Run build and test commands and let the agent fix failures",
+	Long: `Run the project's checks, send the first failure and the code it points
+at to the model, apply the diff it proposes, and repeat until the checks
+pass or the rounds run out.
+
+Commands come from --cmd, then fix.commands in the config, then the
+project's build files (go.mod, Cargo.toml, package.json, ...).
+
+Example:
+  agentflow fix --cmd 'go test ./...' --rounds 5`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if cfg.ReadOnly {
+			return errors.New("fix applies changes, which a read-only session refuses")
+		}
+
+		registry := cfg.BuildRegistry()
+
+		model := modelSpec
+		if model == "" {
+			model = cfg.Defaults.Main
+		}
+
+		provider, modelName, ok := registry.ResolveModel(model)
+		if !ok {
+			return fmt.Errorf("unknown model: %s", model)
+		}
+
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+
+		commands, _ := cmd.Flags().GetStringArray("cmd")
+		if len(commands) == 0 {
+			commands = cfg.Fix.Commands
+		}
+		if len(commands) == 0 {
+			commands = fix.DetectCommands(wd)
+		}
+		if len(commands) == 0 {
+			return fmt.Errorf("no check commands: pass --cmd or set fix.commands in the config")
+		}
+
+		rounds, _ := cmd.Flags().GetInt("rounds")
+		if rounds == 0 {
+			rounds = cfg.Fix.Rounds
+		}
+
+		a := agent.New(agent.Config{
+			Provider:     provider,
+			Model:        modelName,
+			SystemPrompt: cfg.Language.AnswerInstruction(),
+			Tools:        cfg.BuildTools(),
+			Budget:       cfg.ContextBudget(),
+			Guard:        cfg.InjectionGuard(),
+			MaxCost:      cfg.Defaults.MaxCost,
+			Audit:        auditLog(cfg, ""),
+			Params:       cfg.GenerationParams(),
+		})
+
+		result, err := fix.Run(ctx, fix.Config{
+			Root:     wd,
+			Commands: commands,
+			Rounds:   rounds,
+			Agent:    a,
+			Out:      os.Stderr,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, path := range result.Changed {
+			fmt.Println(path)
+		}
+		if !result.Passed {
+			return fmt.Errorf("checks still fail after %d round(s)", result.Rounds)
+		}
+		return nil
+	},
+}
+
+func init() {
+	fixCmd.Flags().StringArray("cmd", nil, "check command to run (repeatable; default from config or project files)")
+	fixCmd.Flags().Int("rounds", 0, fmt.Sprintf("fix attempts before giving up (default %d)", fix.DefaultRounds))
+
+	rootCmd.AddCommand(fixCmd)
+}
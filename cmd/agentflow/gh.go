@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/agentflow/agentflow/internal/config"
+	"github.com/agentflow/agentflow/internal/github"
+	"github.com/agentflow/agentflow/internal/guard"
+	"github.com/spf13/cobra"
+)
+
+var ghCmd = &cobra.Command{
+	Use:   "gh",
+	Short: "GitHub integration (issues and pull requests)",
+}
+
+var ghIssueCmd = &cobra.Command{
+	Use:   "issue <number> [message]",
+	Short: "Pull an issue into context, optionally asking the agent about it",
+	Long: `Fetch a GitHub issue with its comments. Without a message the issue is
+printed as markdown; with a message the agent answers using the issue as context.
+
+Example:
+  agentflow gh issue 42 "triage this issue"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil {
+			return fmt.Errorf("invalid issue number: %s", args[0])
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		repo, err := ghRepo(cmd)
+		if err != nil {
+			return err
+		}
+
+		issue, err := ghClient(cfg).GetIssue(ctx, repo, number)
+		if err != nil {
+			return err
+		}
+		issueContext := github.FormatIssue(issue)
+
+		if len(args) == 1 {
+			fmt.Print(issueContext)
+			return nil
+		}
+
+		a, err := newAgent(cfg, modelSpec)
+		if err != nil {
+			return err
+		}
+
+		// The issue is written by anyone who can open one
+		source := fmt.Sprintf("The issue #%d", number)
+		issueContext, findings := a.Guard().Screen(source, issueContext)
+		if len(findings) > 0 {
+			fmt.Fprintln(os.Stderr, guard.Summary(source, findings))
+		}
+
+		message := fmt.Sprintf("%s\n\n---\n\n%s", issueContext, strings.Join(args[1:], " "))
+		chunks, err := a.Stream(ctx, message)
+		if err != nil {
+			return err
+		}
+		for chunk := range chunks {
+			if chunk.Error != nil {
+				return chunk.Error
+			}
+			fmt.Print(chunk.Content)
+		}
+		fmt.Println()
+		return nil
+	},
+}
+
+var ghPRCreateCmd = &cobra.Command{
+	Use:   "pr-create",
+	Short: "Open a pull request with a generated title and body",
+	Long: `Open a pull request for the current branch. The title and body are drafted
+by the main model from the commits and diff against the base branch.
+The branch must already be pushed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		repo, err := ghRepo(cmd)
+		if err != nil {
+			return err
+		}
+		client := ghClient(cfg)
+
+		base, _ := cmd.Flags().GetString("base")
+		if base == "" {
+			if base, err = client.DefaultBranch(ctx, repo); err != nil {
+				return err
+			}
+		}
+
+		head, err := gitOutput("rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil {
+			return err
+		}
+		if head == base {
+			return fmt.Errorf("current branch is the base branch (%s)", base)
+		}
+
+		log, err := gitOutput("log", "--oneline", base+"..HEAD")
+		if err != nil {
+			return err
+		}
+		if log == "" {
+			return fmt.Errorf("no commits between %s and %s", base, head)
+		}
+		diff, err := gitOutput("diff", base+"...HEAD")
+		if err != nil {
+			return err
+		}
+
+		a, err := newAgent(cfg, modelSpec)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(os.Stderr, "Drafting pull request description...")
+		resp, err := a.Run(ctx, prDescriptionPrompt(log, diff))
+		if err != nil {
+			return err
+		}
+		title, body := splitTitleBody(resp.Content)
+
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			fmt.Printf("%s\n\n%s\n", title, body)
+			return nil
+		}
+
+		draft, _ := cmd.Flags().GetBool("draft")
+		created, err := client.CreatePullRequest(ctx, repo, github.PullRequest{
+			Title: title,
+			Body:  body,
+			Head:  head,
+			Base:  base,
+			Draft: draft,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created pull request #%d: %s\n", created.Number, created.HTMLURL)
+		return nil
+	},
+}
+
+// maxDiffChars keeps generated descriptions within small-model context windows
+const maxDiffChars = 12000
+
+// cutDiff keeps the first maxDiffChars characters of a diff
+func cutDiff(diff string) string {
+	if runes := []rune(diff); len(runes) > maxDiffChars {
+		return string(runes[:maxDiffChars]) + "\n... (diff truncated)"
+	}
+	return diff
+}
+
+// prDescriptionPrompt asks the model for a title line followed by a markdown body
+func prDescriptionPrompt(log, diff string) string {
+	diff = cutDiff(diff)
+	return fmt.Sprintf(`Write a pull request title and description for these changes.
+
+Respond with the title on the first line (imperative mood, under 72 characters),
+then a blank line, then a markdown body with a short summary and a bullet list of changes.
+Do not wrap the response in a code block.
+
+Commits:
+%s
+
+Diff:
+%s`, log, diff)
+}
+
+// splitTitleBody splits a model response into its first line and the rest
+func splitTitleBody(content string) (string, string) {
+	content = strings.TrimSpace(content)
+	title, body, _ := strings.Cut(content, "\n")
+	title = strings.Trim(strings.TrimSpace(title), "#* ")
+	title = strings.TrimPrefix(title, "Title: ")
+	return title, strings.TrimSpace(body)
+}
+
+// ghClient creates a GitHub client from configuration
+func ghClient(cfg *config.Config) *github.Client {
+	return github.NewClient(github.Config{
+		APIURL: cfg.GitHub.APIURL,
+		Token:  github.ResolveToken(cfg.GitHub.Token),
+	})
+}
+
+// ghRepo returns the --repo flag or the repository of the origin remote
+func ghRepo(cmd *cobra.Command) (string, error) {
+	if repo, _ := cmd.Flags().GetString("repo"); repo != "" {
+		return repo, nil
+	}
+	return github.CurrentRepo()
+}
+
+// gitOutput runs git and returns its trimmed stdout
+func gitOutput(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func init() {
+	ghCmd.PersistentFlags().String("repo", "", "repository as owner/name (default: origin remote)")
+
+	ghPRCreateCmd.Flags().String("base", "", "base branch (default: repository default branch)")
+	ghPRCreateCmd.Flags().Bool("draft", false, "open as a draft pull request")
+	ghPRCreateCmd.Flags().Bool("dry-run", false, "print the generated title and body without creating the pull request")
+
+	ghCmd.AddCommand(ghIssueCmd)
+	ghCmd.AddCommand(ghPRCreateCmd)
+
+	rootCmd.AddCommand(ghCmd)
+}
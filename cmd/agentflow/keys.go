@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Show the API keys of providers",
+	Long: `A provider can have several labelled keys besides api_key:
+
+  providers:
+    groq:
+      keys:
+        - {label: personal, key: "${GROQ_KEY_PERSONAL}", added: 2026-01-10}
+        - {label: work, key: "${GROQ_KEY_WORK}", expires: 2026-12-31}
+
+Requests use the first key that hasn't expired, and move to the next when
+it is rate limited. agentflow keys list and agentflow doctor remind you of
+keys that expire soon or are older than 90 days.`,
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List providers' keys with their age, last use and reminders",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		usage, err := provider.KeyUsage()
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(cfg.Providers))
+		for name := range cfg.Providers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		now := time.Now()
+		found := false
+		for _, name := range names {
+			p := cfg.Providers[name]
+			keys := provider.Config{APIKey: p.APIKey, Keys: p.Keys}.AllKeys()
+			if len(keys) == 0 {
+				continue
+			}
+			found = true
+			fmt.Printf("%s:\n", name)
+			for _, k := range keys {
+				fmt.Printf("  %-12s %s", k.Label, maskKey(k.Key))
+				if added, err := k.AddedAt(); err == nil && !added.IsZero() {
+					fmt.Printf("  added %s", since(now, added))
+				}
+				if use, ok := usage[name+"/"+k.Label]; ok {
+					fmt.Printf("  used %s", since(now, use.LastUsed))
+					if !use.LastRateLimited.IsZero() {
+						fmt.Printf(", rate limited %s", since(now, use.LastRateLimited))
+					}
+				} else if len(p.Keys) > 0 {
+					fmt.Print("  never used")
+				}
+				if k.Expires != "" && !k.Expired(now) {
+					fmt.Printf("  expires %s", k.Expires)
+				}
+				if reminder := k.Reminder(now); reminder != "" {
+					fmt.Printf("  ⚠ %s", reminder)
+				}
+				fmt.Println()
+			}
+		}
+		if !found {
+			fmt.Println("No API keys configured")
+		}
+		return nil
+	},
+}
+
+// maskKey shows only the end of a key
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return strings.Repeat("•", len(key))
+	}
+	return "••••" + key[len(key)-4:]
+}
+
+// since renders how long before now t was, in the largest whole unit
+func since(now, t time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%d days ago", int(d.Hours()/24))
+	case d >= 2*time.Hour:
+		return fmt.Sprintf("%d hours ago", int(d.Hours()))
+	case d >= 2*time.Minute:
+		return fmt.Sprintf("%d minutes ago", int(d.Minutes()))
+	default:
+		return "just now"
+	}
+}
+
+func init() {
+	keysCmd.AddCommand(keysListCmd)
+	rootCmd.AddCommand(keysCmd)
+}
@@ -2,15 +2,28 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/artifact"
+	"github.com/agentflow/agentflow/internal/audit"
 	"github.com/agentflow/agentflow/internal/config"
+	"github.com/agentflow/agentflow/internal/fetch"
+	"github.com/agentflow/agentflow/internal/guard"
+	"github.com/agentflow/agentflow/internal/i18n"
+	"github.com/agentflow/agentflow/internal/instance"
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/internal/repl"
+	"github.com/agentflow/agentflow/internal/router"
 	"github.com/agentflow/agentflow/internal/session"
 	"github.com/agentflow/agentflow/internal/skill"
 	"github.com/agentflow/agentflow/internal/subagent"
@@ -26,10 +39,39 @@ var (
 	continueFlag bool
 	resumeID     string
 	forkSession  bool
+	sharedMode   string
+	noTUI        bool
+	seed         seedFlag
+	readOnly     bool
 )
 
+// seedFlag is --seed, which is unset rather than 0 when not given
+type seedFlag struct{ value *int }
+
+func (f *seedFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return strconv.Itoa(*f.value)
+}
+
+func (f *seedFlag) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return fmt.Errorf("not a seed: %s", s)
+	}
+	f.value = &n
+	return nil
+}
+
+func (f *seedFlag) Type() string { return "int" }
+
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if loadedConfig != nil {
+		loadedConfig.CloseTools()
+	}
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -57,6 +99,22 @@ func startREPL() error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	setupLocale(cfg)
+
+	// First run: set up a provider rather than assume a model is there
+	if config.ConfigSource == "(default - no config file found)" && !noTUI && os.Getenv("ACCESSIBLE") == "" && isTerminal() {
+		onboarded, err := onboard()
+		if err != nil {
+			return fmt.Errorf("onboarding: %w", err)
+		}
+		if onboarded != nil {
+			if cfg, err = applyOverrides(onboarded); err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			setupLocale(cfg)
+		}
+	}
+
 	// Show config source if verbose or if using default
 	if config.ConfigSource == "(default - no config file found)" {
 		fmt.Printf("⚠️  No config file found. Using defaults (ollama/llama3.3:latest)\n")
@@ -65,6 +123,10 @@ func startREPL() error {
 		fmt.Printf("📁 Config loaded from: %s\n\n", config.ConfigSource)
 	}
 
+	if noTUI || os.Getenv("ACCESSIBLE") != "" {
+		return startPlain(cfg)
+	}
+
 	// Get provider and model from "provider/model" format
 	defaultModel := cfg.Defaults.Main
 	if defaultModel == "" {
@@ -77,10 +139,22 @@ func startREPL() error {
 	if parts := strings.Split(defaultModel, "/"); len(parts) >= 2 {
 		providerName = parts[0]
 		modelName = strings.Join(parts[1:], "/")
+	} else if defaultModel == router.Name {
+		providerName, modelName = router.Name, "routed"
+	} else if defaultModel == router.SpeculativeName {
+		providerName, modelName = router.SpeculativeName, cfg.Speculative.Fast+"+"+cfg.Speculative.Strong
 	}
 
 	// Create TUI
 	tuiModel := tui.New(providerName, modelName)
+	tuiModel.SetReadTimeout(cfg.Timeouts(defaultModel).Read)
+	tuiModel.SetVim(cfg.Input.Vim)
+	tuiModel.SetVoice(cfg.Voice)
+	tuiModel.SetGuard(cfg.InjectionGuard())
+	tuiModel.SetReadOnly(cfg.ReadOnly)
+	if check := updateCheck(cfg); check != nil {
+		tuiModel.SetUpdateCheck(check)
+	}
 
 	// Create provider and agent for callbacks
 	registry := cfg.BuildRegistry()
@@ -90,62 +164,85 @@ func startREPL() error {
 		provider, model, _ = registry.ResolveModel(modelName)
 	}
 
-	skillLoader := skill.NewLoader(cfg.Skills.Paths)
+	skillLoader := cfg.SkillLoader()
 	if err := skillLoader.Load(); err != nil {
 		return fmt.Errorf("load skills: %w", err)
 	}
 
 	ag := agent.New(agent.Config{
-		Provider: provider,
-		Model:    model,
-		Skills:   skillLoader,
+		Provider:     provider,
+		Model:        model,
+		Skills:       skillLoader,
+		ResolveModel: cfg.ModelResolver(registry),
+		SystemPrompt: cfg.Language.AnswerInstruction(),
+		Tools:        cfg.BuildTools(),
+		Budget:       cfg.ContextBudget(),
+		Guard:        cfg.InjectionGuard(),
+		MaxCost:      cfg.Defaults.MaxCost,
+		Audit:        auditLog(cfg, ""),
+		Params:       cfg.GenerationParams(),
+		SkillStats:   skill.NewStats(""),
+		Style:        cfg.DefaultStyle(),
+		Verify:       cfg.Verifier(),
 	})
 
-	// Set up submit callback
-	tuiModel.SetOnSubmit(func(input string) tea.Cmd {
-		return func() tea.Msg {
-			ctx := context.Background()
-			
-			// Check for skill match
-			matchedSkills := skillLoader.Match(input)
-			if len(matchedSkills) > 0 {
-				// Send skill matched message
-				tui.SendSkillMatched(matchedSkills[0].Name)
-			}
+	workdir, _ := os.Getwd()
+	ag.SetArtifacts(artifact.Open(workdir, ""))
 
-			// Stream response
-			chunks, err := ag.Stream(ctx, input)
-			if err != nil {
-				return tui.SendError(err)()
-			}
-
-			// Process chunks in goroutine
-			go func() {
-				for chunk := range chunks {
-					if chunk.Error != nil {
-						// Handle error
-						continue
-					}
-					// This won't work directly - need program reference
-					// For now, simplified version
-				}
-			}()
+	tuiModel.SetOnCommand(agentCommands(cfg, ag, nil))
+	var notices []tui.ChatMessage
+	if notice := autoProjectMap(ag); notice != "" {
+		notices = append(notices, tui.ChatMessage{Role: "system", Content: notice, Timestamp: time.Now()})
+	}
 
-			return nil
-		}
-	})
+	if others := instance.Others(instance.DefaultDir(), workdir); len(others) > 0 {
+		notices = append(notices, tui.ChatMessage{Role: "system", Content: fmt.Sprintf("%d other agentflow running in this directory; /who lists them.", len(others)), Timestamp: time.Now()})
+	}
+	if len(notices) > 0 {
+		tuiModel.LoadHistory(notices)
+	}
+	handle, _ := instance.Register(instance.DefaultDir(), instance.Instance{Command: "tui", Workdir: workdir, Model: defaultModel})
+	defer handle.Close()
 
 	// Run TUI
-	p := tea.NewProgram(tuiModel, tea.WithAltScreen())
-	_, err = p.Run()
-	return err
+	return runTUI(tuiModel, ag, nil, nil, tea.WithAltScreen())
+}
+
+// startPlain runs the interactive session as line-oriented text, for
+// screen readers and terminals without full-screen support
+func startPlain(cfg *config.Config) error {
+	r, err := repl.NewWithOptions(cfg, repl.Options{
+		ContinueLast: continueFlag,
+		ResumeID:     resumeID,
+		ForkSession:  forkSession,
+		Shared:       sharedMode,
+		Plain:        true,
+	})
+	if err != nil {
+		return err
+	}
+	r.SetOnCommand(func(cmd string, args []string) (string, bool) {
+		return agentCommands(cfg, r.Agent(), r.AddFeedback)(cmd, args)
+	})
+	if len(r.Agent().Messages()) == 0 {
+		if notice := autoProjectMap(r.Agent()); notice != "" {
+			fmt.Println(notice)
+		}
+	}
+
+	// The REPL handles Ctrl+C itself
+	return r.Run(context.Background())
 }
 
 var runCmd = &cobra.Command{
 	Use:   "run [message]",
 	Short: "Run a single agent interaction",
-	Args:  cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		fromClipboard, _ := cmd.Flags().GetBool("from-clipboard")
+		if len(args) == 0 && !fromClipboard {
+			return fmt.Errorf("requires a message (or --from-clipboard)")
+		}
+
 		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
 
@@ -168,23 +265,54 @@ var runCmd = &cobra.Command{
 		}
 
 		// Load skills
-		skillLoader := skill.NewLoader(cfg.Skills.Paths)
+		skillLoader := cfg.SkillLoader()
 		if err := skillLoader.Load(); err != nil {
 			return fmt.Errorf("load skills: %w", err)
 		}
 
 		// Create agent
+		think, _ := cmd.Flags().GetBool("think")
 		a := agent.New(agent.Config{
-			Provider: provider,
-			Model:    modelName,
-			Skills:   skillLoader,
+			Provider:     provider,
+			Model:        modelName,
+			Skills:       skillLoader,
+			ResolveModel: cfg.ModelResolver(registry),
+			Think:        think,
+			SystemPrompt: cfg.Language.AnswerInstruction(),
+			Tools:        cfg.BuildTools(),
+			Budget:       cfg.ContextBudget(),
+			Guard:        cfg.InjectionGuard(),
+			MaxCost:      cfg.Defaults.MaxCost,
+			Audit:        auditLog(cfg, ""),
+			Params:       cfg.GenerationParams(),
 		})
 
+		if tmpl, _ := cmd.Flags().GetString("template"); tmpl != "" {
+			if err := applyTemplate(cfg, a, tmpl); err != nil {
+				return err
+			}
+		}
+
 		message := strings.Join(args, " ")
+		if fromClipboard {
+			if message, err = withClipboard(a, message); err != nil {
+				return err
+			}
+		}
+
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			p, err := a.Preview("", message)
+			if err != nil {
+				return err
+			}
+			fmt.Print(p)
+			return nil
+		}
 		
 		// Check for streaming flag
 		stream, _ := cmd.Flags().GetBool("stream")
 		if stream {
+			stats := agent.NewStreamStats()
 			chunks, err := a.Stream(ctx, message)
 			if err != nil {
 				return err
@@ -193,9 +321,18 @@ var runCmd = &cobra.Command{
 				if chunk.Error != nil {
 					return chunk.Error
 				}
+				stats.Observe(chunk)
+				if think && chunk.Reasoning != "" {
+					fmt.Fprint(os.Stderr, chunk.Reasoning)
+				}
 				fmt.Print(chunk.Content)
 			}
+			stats.Finish()
 			fmt.Println()
+
+			if showStats, _ := cmd.Flags().GetBool("stats"); showStats {
+				fmt.Fprintf(os.Stderr, "⏱  %s\n", stats)
+			}
 		} else {
 			resp, err := a.Run(ctx, message)
 			if err != nil {
@@ -222,7 +359,7 @@ var skillListCmd = &cobra.Command{
 			return err
 		}
 
-		loader := skill.NewLoader(cfg.Skills.Paths)
+		loader := cfg.SkillLoader()
 		if err := loader.Load(); err != nil {
 			return err
 		}
@@ -235,13 +372,24 @@ var skillListCmd = &cobra.Command{
 
 		fmt.Printf("Found %d skill(s):\n\n", len(skills))
 		for _, s := range skills {
-			fmt.Printf("• %s\n", s.Name)
+			if s.Builtin() {
+				fmt.Printf("• %s (built-in)\n", s.Name)
+			} else {
+				fmt.Printf("• %s\n", s.Name)
+			}
 			if s.Description != "" {
 				fmt.Printf("  %s\n", s.Description)
 			}
 			if len(s.Tags) > 0 {
 				fmt.Printf("  Tags: %s\n", strings.Join(s.Tags, ", "))
 			}
+			if len(s.Triggers) > 0 {
+				manual := ""
+				if !s.AutoActivates() {
+					manual = " (auto: false; use /skill use " + s.Name + ")"
+				}
+				fmt.Printf("  Triggers: %s%s\n", strings.Join(s.Triggers, ", "), manual)
+			}
 			fmt.Println()
 		}
 
@@ -274,20 +422,37 @@ var skillRunCmd = &cobra.Command{
 			return fmt.Errorf("unknown model: %s", model)
 		}
 
-		skillLoader := skill.NewLoader(cfg.Skills.Paths)
+		skillLoader := cfg.SkillLoader()
 		if err := skillLoader.Load(); err != nil {
 			return err
 		}
 
 		a := agent.New(agent.Config{
-			Provider: provider,
-			Model:    modelName,
-			Skills:   skillLoader,
+			Provider:     provider,
+			Model:        modelName,
+			Skills:       skillLoader,
+			ResolveModel: cfg.ModelResolver(registry),
+			SystemPrompt: cfg.Language.AnswerInstruction(),
+			Tools:        cfg.BuildTools(),
+			Budget:       cfg.ContextBudget(),
+			Guard:        cfg.InjectionGuard(),
+			MaxCost:      cfg.Defaults.MaxCost,
+			Audit:        auditLog(cfg, ""),
+			Params:       cfg.GenerationParams(),
 		})
 
 		skillName := args[0]
 		message := strings.Join(args[1:], " ")
 
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			p, err := a.Preview(skillName, message)
+			if err != nil {
+				return err
+			}
+			fmt.Print(p)
+			return nil
+		}
+
 		resp, err := a.RunWithSkill(ctx, skillName, message)
 		if err != nil {
 			return err
@@ -312,6 +477,33 @@ var configShowCmd = &cobra.Command{
 			return err
 		}
 
+		// With --origin, each setting says where it came from when that
+		// wasn't the config file
+		showOrigin, _ := cmd.Flags().GetBool("origin")
+		origin := func(key string) string {
+			if !showOrigin {
+				return ""
+			}
+			if o := cfg.Origin(key); o != "" {
+				return "  (" + o + ")"
+			}
+			if strings.HasPrefix(config.ConfigSource, "(default") {
+				return "  (default)"
+			}
+			return "  (" + config.ConfigSource + ")"
+		}
+
+		if showOrigin {
+			fmt.Printf("Config: %s\n", config.ConfigSource)
+			if team := cfg.TeamConfig(); team != "" {
+				fmt.Printf("Team: %s\n", team)
+			}
+			if p := cfg.Policy(); p != nil {
+				fmt.Printf("Policy: %s\n", p.Path())
+			}
+			fmt.Println()
+		}
+
 		fmt.Println("Providers:")
 		for name, p := range cfg.Providers {
 			fmt.Printf("  %s:\n", name)
@@ -322,11 +514,40 @@ var configShowCmd = &cobra.Command{
 				fmt.Printf("    Models: %s\n", strings.Join(p.Models, ", "))
 			}
 		}
+		for name, reason := range cfg.Forbidden {
+			fmt.Printf("  %s: removed, %s%s\n", name, reason, origin("providers."+name))
+		}
 
 		fmt.Println("\nDefaults:")
-		fmt.Printf("  Main: %s\n", cfg.Defaults.Main)
-		fmt.Printf("  Subagent: %s\n", cfg.Defaults.Subagent)
-		fmt.Printf("  Reviewer: %s\n", cfg.Defaults.Reviewer)
+		fmt.Printf("  Main: %s%s\n", cfg.Defaults.Main, origin("defaults.main"))
+		fmt.Printf("  Subagent: %s%s\n", cfg.Defaults.Subagent, origin("defaults.subagent"))
+		fmt.Printf("  Reviewer: %s%s\n", cfg.Defaults.Reviewer, origin("defaults.reviewer"))
+		if cfg.Defaults.Seed != nil {
+			fmt.Printf("  Seed: %d%s\n", *cfg.Defaults.Seed, origin("defaults.seed"))
+		}
+		if cfg.Defaults.MaxCost > 0 {
+			fmt.Printf("  Max cost: $%.2f%s\n", cfg.Defaults.MaxCost, origin("defaults.max_cost"))
+		}
+
+		if p := cfg.Policy(); p != nil || showOrigin || cfg.ReadOnly {
+			fmt.Println("\nTools:")
+			if cfg.Tools.Disabled {
+				fmt.Printf("  Disabled%s\n", origin("tools.disabled"))
+			} else if p != nil && (len(p.Tools.Allow) > 0 || len(p.Tools.Deny) > 0 || p.Offline) {
+				fmt.Printf("  Offered: %s%s\n", strings.Join(cfg.BuildTools().List(), ", "), origin("tools"))
+			}
+			urls := cfg.Tools.URLs
+			if urls == "" {
+				urls = fetch.Ask
+			}
+			fmt.Printf("  URLs: %s%s\n", urls, origin("tools.urls"))
+			if p != nil && p.Offline {
+				fmt.Printf("  Offline: only local providers, no update checks%s\n", origin("update.check"))
+			}
+			if cfg.ReadOnly {
+				fmt.Printf("  Read-only: tools and shell commands that change things are refused%s\n", origin("read_only"))
+			}
+		}
 
 		return nil
 	},
@@ -358,8 +579,14 @@ var configInitCmd = &cobra.Command{
 var subagentCmd = &cobra.Command{
 	Use:   "subagent [task]",
 	Short: "Spawn a subagent for a task",
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Spawn a subagent for a task. Tasks interrupted with Ctrl+C or SIGTERM
+are saved to ~/.agentflow/pending-tasks.json; --pending runs them again.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		pending, _ := cmd.Flags().GetBool("pending")
+		if len(args) == 0 && !pending {
+			return fmt.Errorf("requires a task (or --pending)")
+		}
+
 		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
 
@@ -380,7 +607,7 @@ var subagentCmd = &cobra.Command{
 			return fmt.Errorf("unknown model: %s", model)
 		}
 
-		skillLoader := skill.NewLoader(cfg.Skills.Paths)
+		skillLoader := cfg.SkillLoader()
 		if err := skillLoader.Load(); err != nil {
 			return err
 		}
@@ -389,28 +616,60 @@ var subagentCmd = &cobra.Command{
 			Provider:  provider,
 			Model:     modelName,
 			Skills:    skillLoader,
-			MaxAgents: 5,
+			MaxAgents: cfg.Subagents.MaxAgents,
+			Adaptive:  cfg.Subagents.Adaptive,
 		})
 
-		task := subagent.Task{
-			ID:          "task-1",
-			Description: "Execute user task",
-			Message:     strings.Join(args, " "),
+		var tasks []subagent.Task
+		pendingPath := subagent.DefaultPendingPath()
+		if pending {
+			if tasks, err = subagent.LoadPending(pendingPath); err != nil {
+				return err
+			}
+			if len(tasks) == 0 && len(args) == 0 {
+				fmt.Println("No pending tasks.")
+				return nil
+			}
+			// Those interrupted again are saved anew
+			os.Remove(pendingPath)
 		}
-
-		result, err := pool.Spawn(ctx, task)
-		if err != nil {
-			return err
+		if len(args) > 0 {
+			tasks = append(tasks, subagent.Task{
+				ID:          fmt.Sprintf("task-%d", len(tasks)+1),
+				Description: "Execute user task",
+				Message:     strings.Join(args, " "),
+			})
 		}
 
-		if result.Error != nil {
-			return result.Error
-		}
+		results := pool.SpawnBatch(ctx, tasks)
 
-		fmt.Printf("Agent: %s\n", result.AgentID)
-		fmt.Printf("Duration: %v\n", result.Duration)
-		fmt.Printf("\n%s\n", result.Response.Content)
+		var firstErr error
+		for i, result := range results {
+			if len(results) > 1 {
+				fmt.Printf("── %s ──\n", tasks[i].ID)
+			}
+			if result.Error != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n\n", result.Error)
+				}
+				firstErr = cmp.Or(firstErr, result.Error)
+				continue
+			}
+			fmt.Printf("Agent: %s\n", result.AgentID)
+			fmt.Printf("Duration: %v\n", result.Duration)
+			fmt.Printf("\n%s\n\n", result.Response.Content)
+		}
 
+		if interrupted := pool.Interrupted(); len(interrupted) > 0 {
+			if err := subagent.SavePending(pendingPath, interrupted); err != nil {
+				return fmt.Errorf("save pending tasks: %w", err)
+			}
+			fmt.Printf("\n%d unfinished task(s) saved to %s; run them again with agentflow subagent --pending\n", len(interrupted), pendingPath)
+			return nil
+		}
+		if len(results) == 1 {
+			return firstErr
+		}
 		return nil
 	},
 }
@@ -432,13 +691,23 @@ var providersCmd = &cobra.Command{
 			return nil
 		}
 
+		verbose, _ := cmd.Flags().GetBool("verbose")
 		fmt.Println("Configured providers:")
 		for _, name := range providers {
 			p, _ := registry.Get(name)
+			if c, ok := p.(provider.Cataloger); ok && verbose {
+				if _, err := c.Catalog(cmd.Context()); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %s catalog: %v\n", name, err)
+				}
+			}
 			models := p.Models()
 			fmt.Printf("  %s: %d model(s)\n", name, len(models))
 			for _, m := range models {
-				fmt.Printf("    - %s/%s\n", name, m)
+				if verbose {
+					fmt.Printf("    - %-40s %s\n", name+"/"+m, describeModel(provider.InfoFor(p, m)))
+				} else {
+					fmt.Printf("    - %s/%s\n", name, m)
+				}
 			}
 		}
 
@@ -446,23 +715,56 @@ var providersCmd = &cobra.Command{
 	},
 }
 
+// describeModel summarizes a model's capabilities for providers --verbose
+func describeModel(info provider.ModelInfo) string {
+	flag := func(b *bool) string {
+		if b == nil {
+			return "?"
+		}
+		if *b {
+			return "yes"
+		}
+		return "no"
+	}
+	window := "?"
+	if info.ContextWindow > 0 {
+		window = fmt.Sprintf("%dk", info.ContextWindow/1024)
+	}
+	price := "free/unknown"
+	if info.InputPrice > 0 || info.OutputPrice > 0 {
+		price = fmt.Sprintf("$%.2f/$%.2f per 1M", info.InputPrice, info.OutputPrice)
+	}
+	return fmt.Sprintf("context %-6s tools %-3s vision %-3s %s", window, flag(info.Tools), flag(info.Vision), price)
+}
+
 var sessionsCmd = &cobra.Command{
 	Use:   "sessions",
 	Short: "List saved sessions",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		mgr := session.NewManager("")
-		sessions, err := mgr.List()
+		list, kind := mgr.List, "Sessions"
+		if archived, _ := cmd.Flags().GetBool("archived"); archived {
+			list, kind = mgr.ListArchived, "Archived sessions"
+		}
+		sessions, err := list()
 		if err != nil {
 			return err
 		}
+		if query, _ := cmd.Flags().GetString("search"); query != "" {
+			sessions = searchSessions(sessions, query)
+		}
 
 		if len(sessions) == 0 {
 			fmt.Println("No saved sessions")
 			return nil
 		}
 
+		var total int64
+		for _, s := range sessions {
+			total += mgr.Size(s.ID)
+		}
 		workdir, _ := os.Getwd()
-		fmt.Printf("Sessions (%d total):\n\n", len(sessions))
+		fmt.Printf("%s (%d total, %s):\n\n", kind, len(sessions), session.FormatSize(total))
 
 		for _, s := range sessions {
 			marker := " "
@@ -472,8 +774,9 @@ var sessionsCmd = &cobra.Command{
 
 			name := s.DisplayName()
 			fmt.Printf("%s [%s] %s\n", marker, s.ID, name)
-			fmt.Printf("    %d msgs | %s | %s\n",
+			fmt.Printf("    %d msgs | %s | %s | %s\n",
 				len(s.Messages),
+				session.FormatSize(mgr.Size(s.ID)),
 				s.Workdir,
 				s.UpdatedAt.Format("Jan 2 15:04"))
 		}
@@ -500,33 +803,137 @@ var sessionDeleteCmd = &cobra.Command{
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file path")
 	rootCmd.PersistentFlags().StringVarP(&modelSpec, "model", "m", "", "model to use (provider/model)")
+	rootCmd.PersistentFlags().StringVar(&sharedMode, "shared", instance.SharedFork, "when the session is open in another agentflow: fork, read-only or warn")
+	rootCmd.PersistentFlags().BoolVar(&readOnly, "read-only", false, "refuse tools and shell commands that change things, to explore safely")
+	rootCmd.PersistentFlags().Var(&seed, "seed", "sampling seed sent with every request, for repeatable answers (Ollama, OpenAI-compatible)")
 
 	// Session flags
 	rootCmd.Flags().BoolVarP(&continueFlag, "continue", "c", false, "continue last session for current directory")
 	rootCmd.Flags().StringVarP(&resumeID, "resume", "r", "", "resume a specific session by ID or name")
 	rootCmd.Flags().BoolVar(&forkSession, "fork-session", false, "fork the session instead of continuing")
+	rootCmd.Flags().BoolVar(&noTUI, "no-tui", false, "plain line-oriented output for screen readers (also ACCESSIBLE=1)")
 
 	runCmd.Flags().BoolP("stream", "s", false, "stream the response")
+	runCmd.Flags().String("template", "", "prepend a configured few-shot template")
+	runCmd.Flags().Bool("think", false, "ask reasoning models to think first (shown on stderr with --stream)")
+	runCmd.Flags().Bool("stats", false, "with --stream, print TTFT, tokens/sec and duration to stderr")
+	runCmd.Flags().Bool("from-clipboard", false, "include the clipboard (text or image) in the prompt")
+	runCmd.Flags().Bool("dry-run", false, "print the request that would be sent, with token counts, without calling the model")
+	skillRunCmd.Flags().Bool("dry-run", false, "print the request that would be sent, with token counts, without calling the model")
 
 	skillCmd.AddCommand(skillListCmd)
 	skillCmd.AddCommand(skillRunCmd)
 
+	configShowCmd.Flags().Bool("origin", false, "show where each setting comes from: the config file, the system policy or a flag")
 	configCmd.AddCommand(configShowCmd)
 	configCmd.AddCommand(configInitCmd)
 
+	sessionsCmd.Flags().Bool("archived", false, "list archived sessions instead")
+	sessionsCmd.Flags().String("search", "", "only list sessions whose name, directory or messages contain this text")
 	sessionsCmd.AddCommand(sessionDeleteCmd)
 
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(skillCmd)
 	rootCmd.AddCommand(configCmd)
+	subagentCmd.Flags().Bool("pending", false, "run the tasks saved when earlier runs were interrupted")
 	rootCmd.AddCommand(subagentCmd)
+	providersCmd.Flags().BoolP("verbose", "v", false, "show each model's context window, tool and vision support, and prices")
 	rootCmd.AddCommand(providersCmd)
 	rootCmd.AddCommand(sessionsCmd)
 }
 
+// loadedConfig is the last config loaded, so main can stop the language
+// servers its tools started
+var loadedConfig *config.Config
+
 func loadConfig() (*config.Config, error) {
+	var cfg *config.Config
+	var err error
 	if cfgFile != "" {
-		return config.Load(cfgFile)
+		cfg, err = config.Load(cfgFile)
+	} else {
+		cfg, err = config.LoadDefault()
+	}
+	if err != nil {
+		return cfg, err
 	}
-	return config.LoadDefault()
+	return applyOverrides(cfg)
+}
+
+// applyOverrides checks a loaded config and applies the flags and the
+// system policy over it, as every config the commands use needs
+func applyOverrides(cfg *config.Config) (*config.Config, error) {
+	if _, err := guard.New(cfg.Guard); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if seed.value != nil {
+		cfg.Defaults.Seed = seed.value
+		cfg.SetOrigin("defaults.seed", "--seed")
+	}
+	if readOnly {
+		cfg.ReadOnly = true
+		cfg.SetOrigin("read_only", "--read-only")
+	}
+	policy, err := config.LoadPolicy(config.PolicyPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ApplyPolicy(policy)
+	loadedConfig = cfg
+	return cfg, nil
+}
+
+// setupLocale selects the TUI/REPL language from the config, loading
+// any user locale files from ~/.agentflow/locales
+func setupLocale(cfg *config.Config) {
+	if home, err := os.UserHomeDir(); err == nil {
+		if err := i18n.LoadDir(filepath.Join(home, ".agentflow", "locales")); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	i18n.SetLocale(cfg.Language.UI)
+}
+
+// auditLog opens the audit log of a session, or of a new one when session
+// is empty, warning when it can't
+func auditLog(cfg *config.Config, session string) *audit.Log {
+	log, err := cfg.AuditLog(session)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return log
+}
+
+// newAgent resolves a model spec (falling back to the main default) and
+// creates an agent with the configured skills loaded
+func newAgent(cfg *config.Config, spec string) (*agent.Agent, error) {
+	if spec == "" {
+		spec = cfg.Defaults.Main
+	}
+
+	registry := cfg.BuildRegistry()
+	provider, modelName, ok := registry.ResolveModel(spec)
+	if !ok {
+		return nil, fmt.Errorf("unknown model: %s", spec)
+	}
+
+	skillLoader := cfg.SkillLoader()
+	if err := skillLoader.Load(); err != nil {
+		return nil, fmt.Errorf("load skills: %w", err)
+	}
+
+	return agent.New(agent.Config{
+		Provider:     provider,
+		Model:        modelName,
+		Skills:       skillLoader,
+		ResolveModel: cfg.ModelResolver(registry),
+		SystemPrompt: cfg.Language.AnswerInstruction(),
+		Tools:        cfg.BuildTools(),
+		Budget:       cfg.ContextBudget(),
+		Guard:        cfg.InjectionGuard(),
+		MaxCost:      cfg.Defaults.MaxCost,
+		Audit:        auditLog(cfg, ""),
+		Params:       cfg.GenerationParams(),
+		Style:        cfg.DefaultStyle(),
+	}), nil
 }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/agentflow/agentflow/internal/config"
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/internal/tui"
+	"github.com/agentflow/agentflow/skills"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// onboardingProviders are the providers offered on first run
+var onboardingProviders = []tui.OnboardingProvider{
+	{
+		Name:      "ollama",
+		Label:     "Ollama (local)",
+		BaseURL:   "http://localhost:11434",
+		Suggested: []string{"llama3.3:latest", "qwen2.5-coder:7b", "llama3.2:3b"},
+	},
+	{Name: "groq", Label: "Groq", BaseURL: "https://api.groq.com/openai/v1", KeyEnv: "GROQ_API_KEY"},
+	{Name: "together", Label: "Together", BaseURL: "https://api.together.xyz/v1", KeyEnv: "TOGETHER_API_KEY"},
+	{Name: "mistral", Label: "Mistral", BaseURL: "https://api.mistral.ai/v1", KeyEnv: "MISTRAL_API_KEY", Suggested: []string{"mistral-large-latest", "codestral-latest"}},
+	{Name: "deepseek", Label: "DeepSeek", BaseURL: "https://api.deepseek.com/v1", KeyEnv: "DEEPSEEK_API_KEY", Suggested: []string{"deepseek-chat", "deepseek-reasoner"}},
+	{Name: "xai", Label: "xAI (Grok)", BaseURL: "https://api.x.ai/v1", KeyEnv: "XAI_API_KEY", Suggested: []string{"grok-4", "grok-code-fast-1"}},
+	{Name: "cerebras", Label: "Cerebras", BaseURL: "https://api.cerebras.ai/v1", KeyEnv: "CEREBRAS_API_KEY", Suggested: []string{"llama-3.3-70b", "qwen-3-32b"}},
+	{Name: "openrouter", Label: "OpenRouter", BaseURL: "https://openrouter.ai/api/v1", KeyEnv: "OPENROUTER_API_KEY"},
+	{Name: "openai", Label: "OpenAI-compatible", BaseURL: "http://localhost:8000/v1", KeyEnv: "OPENAI_API_KEY"},
+}
+
+// onboardingProvider creates the provider being set up
+func onboardingProvider(p tui.OnboardingProvider, baseURL, apiKey string) provider.Provider {
+	cfg := provider.Config{BaseURL: baseURL, APIKey: apiKey}
+	if p.Name == "ollama" {
+		return provider.NewOllama(cfg)
+	}
+	return provider.NewOpenAICompat(p.Name, cfg)
+}
+
+var onboardingHooks = tui.OnboardingHooks{
+	ListModels: func(ctx context.Context, p tui.OnboardingProvider, baseURL, apiKey string) ([]string, error) {
+		lister, ok := onboardingProvider(p, baseURL, apiKey).(provider.ModelLister)
+		if !ok {
+			return nil, fmt.Errorf("%s can't list models", p.Label)
+		}
+		return lister.ListModels(ctx)
+	},
+	Pull: func(ctx context.Context, baseURL, model string, progress func(string)) error {
+		ollama := provider.NewOllama(provider.Config{BaseURL: baseURL})
+		return ollama.Pull(ctx, model, func(p provider.PullProgress) {
+			if p.Total > 0 {
+				progress(fmt.Sprintf("%s %d%%", p.Status, p.Completed*100/p.Total))
+			} else {
+				progress(p.Status)
+			}
+		})
+	},
+}
+
+// isTerminal reports whether stdin is an interactive terminal
+func isTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// onboard runs the first-run flow and writes ~/.agentflow/config.yaml from
+// it. It returns nil when the user quit before the end.
+func onboard() (*config.Config, error) {
+	final, err := tea.NewProgram(tui.NewOnboarding(onboardingProviders, onboardingHooks)).Run()
+	if err != nil {
+		return nil, err
+	}
+	result := final.(tui.Onboarding).Result()
+	if !result.Done {
+		return nil, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("find home directory: %w", err)
+	}
+	dir := filepath.Join(home, ".agentflow")
+
+	cfg := onboardingConfig(result)
+	path := filepath.Join(dir, "config.yaml")
+	if err := cfg.Save(path); err != nil {
+		return nil, err
+	}
+	fmt.Printf("✓ Created %s\n", path)
+
+	if result.InstallSkills {
+		installed, err := skills.Install(filepath.Join(dir, "skills"))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("✓ Installed %d starter skills in %s\n", len(installed), filepath.Join(dir, "skills"))
+	}
+	fmt.Println()
+
+	// Reload it so keys saved as ${VAR} are expanded
+	config.ConfigSource = path
+	return config.Load(path)
+}
+
+// onboardingConfig builds the configuration for the choices made. An API
+// key read from its environment variable is saved as a reference to it.
+func onboardingConfig(result tui.OnboardingResult) *config.Config {
+	apiKey := result.APIKey
+	if env := result.Provider.KeyEnv; env != "" && apiKey != "" && apiKey == os.Getenv(env) {
+		apiKey = "${" + env + "}"
+	}
+	spec := result.Provider.Name + "/" + result.Model
+
+	return &config.Config{
+		Providers: map[string]config.ProviderConfig{
+			result.Provider.Name: {
+				BaseURL: result.BaseURL,
+				APIKey:  apiKey,
+				Models:  []string{result.Model},
+			},
+		},
+		Defaults: config.DefaultsConfig{Main: spec, Subagent: spec, Reviewer: spec},
+		Skills: config.SkillsConfig{
+			Paths: []string{"skills", ".agentflow/skills", "~/.agentflow/skills"},
+		},
+	}
+}
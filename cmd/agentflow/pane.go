@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/artifact"
+	"github.com/agentflow/agentflow/internal/i18n"
+	"github.com/agentflow/agentflow/internal/instance"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/agentflow/agentflow/internal/skill"
+	"github.com/agentflow/agentflow/internal/tmux"
+	"github.com/agentflow/agentflow/internal/tui"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+var paneCmd = &cobra.Command{
+	Use:   "pane",
+	Short: "Run a compact TUI for a tmux side pane",
+	Long: `Run a slimmed-down TUI suited to a narrow tmux side pane: one-line
+header and status, wrapped output, and the current session of this project
+(the most recent one for the working directory) loaded and kept saved.
+
+Use /pane [target] inside it, or 'agentflow pane send', to add the visible
+contents of another tmux pane to the conversation.
+
+  tmux split-window -h -l 60 agentflow pane`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		setupLocale(cfg)
+
+		spec := modelSpec
+		if spec == "" {
+			spec = cfg.Defaults.Main
+		}
+		registry := cfg.BuildRegistry()
+		provider, modelName, ok := registry.ResolveModel(spec)
+		if !ok {
+			return fmt.Errorf("unknown model: %s", spec)
+		}
+
+		skillLoader := cfg.SkillLoader()
+		if err := skillLoader.Load(); err != nil {
+			return fmt.Errorf("load skills: %w", err)
+		}
+
+		ag := agent.New(agent.Config{
+			Provider:     provider,
+			Model:        modelName,
+			Skills:       skillLoader,
+			ResolveModel: cfg.ModelResolver(registry),
+			SystemPrompt: cfg.Language.AnswerInstruction(),
+			Tools:        cfg.BuildTools(),
+			Budget:       cfg.ContextBudget(),
+			Guard:        cfg.InjectionGuard(),
+			MaxCost:      cfg.Defaults.MaxCost,
+			Audit:        auditLog(cfg, ""),
+			Params:       cfg.GenerationParams(),
+			SkillStats:   skill.NewStats(""),
+			Style:        cfg.DefaultStyle(),
+			Verify:       cfg.Verifier(),
+		})
+
+		workdir, _ := os.Getwd()
+		mgr := session.NewManager("")
+		sess, err := mgr.GetLatest(workdir)
+		readOnly, notice := false, ""
+		if err != nil {
+			sess = session.New(workdir, provider.Name(), modelName)
+		} else {
+			sess, readOnly, notice = instance.Claim(instance.DefaultDir(), sess, sharedMode)
+		}
+		ag.SetAudit(auditLog(cfg, sess.ID))
+		handle, _ := instance.Register(instance.DefaultDir(), instance.Instance{
+			Command:  "pane",
+			Workdir:  workdir,
+			Session:  sess.ID,
+			Model:    spec,
+			ReadOnly: readOnly,
+		})
+		defer handle.Close()
+		save := func() error {
+			if readOnly {
+				return nil
+			}
+			return mgr.Save(sess)
+		}
+
+		m := tui.New(provider.Name(), modelName)
+		m.SetCompact(true)
+		m.SetReadTimeout(cfg.Timeouts(spec).Read)
+		m.SetVim(cfg.Input.Vim)
+		m.SetVoice(cfg.Voice)
+		m.SetGuard(cfg.InjectionGuard())
+		m.SetReadOnly(cfg.ReadOnly)
+
+		if prompt := sess.SystemPrompt(); prompt != "" {
+			ag.SetSystemPrompt(prompt)
+		}
+		params := sess.Params()
+		if params.Seed == nil {
+			params.Seed = ag.Params().Seed // From --seed or the config
+		}
+		ag.SetParams(params)
+		ag.SetArtifacts(artifact.Open(workdir, sess.ID))
+		if style, ok := cfg.Style(sess.Style()); ok {
+			ag.SetStyle(style)
+		}
+		var history []tui.ChatMessage
+		for _, msg := range sess.Messages {
+			if msg.Role == "system" && msg.Content == ag.SystemPrompt() {
+				continue
+			}
+			ag.AddMessage(msg.Role, msg.Content)
+			if msg.Role == "user" || msg.Role == "assistant" {
+				ts := msg.Timestamp
+				if ts.IsZero() {
+					ts = sess.UpdatedAt // Saved before messages had timestamps
+				}
+				history = append(history, tui.ChatMessage{Role: msg.Role, Content: msg.Content, ID: len(ag.Messages()), Timestamp: ts})
+			}
+		}
+		if len(sess.Messages) == 0 {
+			if notice := autoProjectMap(ag); notice != "" {
+				history = append(history, tui.ChatMessage{Role: "system", Content: notice, Timestamp: time.Now()})
+			}
+		}
+		if notice != "" {
+			history = append(history, tui.ChatMessage{Role: "system", Content: notice, Timestamp: time.Now()})
+		}
+		m.LoadHistory(history)
+		ag.SetActivity(sess.Activity())
+		m.LoadActivity(sess.Activity())
+		m.SetOnCommand(agentCommands(cfg, ag, func(f session.Feedback) error {
+			sess.AddFeedback(f)
+			return save()
+		}))
+
+		onSkill := func(act agent.SkillActivation) {
+			sess.RecordSkill(act.Skill, act.Reason)
+		}
+		if err := runTUI(m, ag, onSkill, func() {
+			sess.Messages = ag.Messages()
+			sess.SetActivity(ag.Activity())
+			sess.UpdatedAt = time.Now()
+			sess.SetParams(ag.Params())
+			sess.SetStyle(ag.Style().Name)
+			if prompt := ag.SystemPrompt(); prompt != cfg.Language.AnswerInstruction() {
+				sess.SetSystemPrompt(prompt)
+			} else {
+				sess.SetSystemPrompt("")
+			}
+			save()
+		}, tea.WithAltScreen()); err != nil {
+			return err
+		}
+		if len(sess.Messages) > 0 && !readOnly {
+			fmt.Println(i18n.T("msg.session_saved_to", mgr.Path(sess.ID)))
+		}
+		return nil
+	},
+}
+
+var paneSendCmd = &cobra.Command{
+	Use:   "send [message]",
+	Short: "Send a tmux pane's visible contents into the conversation",
+	Long: `Capture the visible contents of a tmux pane (the last active pane by
+default) and add them to the current session of this project as context.
+
+With a message, the agent answers it with the pane as context:
+
+  agentflow pane send "why did this test fail?"`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		target, _ := cmd.Flags().GetString("target")
+		content, err := tmux.Capture(target)
+		if err != nil {
+			return err
+		}
+		paneContext := tmux.FormatContext(target, content)
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		a, err := newAgent(cfg, modelSpec)
+		if err != nil {
+			return err
+		}
+
+		workdir, _ := os.Getwd()
+		mgr := session.NewManager("")
+		sess, err := mgr.GetLatest(workdir)
+		if err != nil {
+			providerName, _, _ := strings.Cut(cfg.Defaults.Main, "/")
+			if modelSpec != "" {
+				providerName, _, _ = strings.Cut(modelSpec, "/")
+			}
+			sess = session.New(workdir, providerName, a.Model())
+		}
+		a.SetAudit(auditLog(cfg, sess.ID))
+		for _, msg := range sess.Messages {
+			a.AddMessage(msg.Role, msg.Content)
+		}
+		a.SetActivity(sess.Activity())
+
+		message := strings.Join(args, " ")
+		if message == "" {
+			a.AddMessage("user", paneContext)
+			fmt.Fprintf(os.Stderr, "📋 Added %d lines to session %s\n", strings.Count(content, "\n")+1, sess.ID)
+		} else {
+			chunks, err := a.Stream(ctx, paneContext+"\n\n"+message)
+			if err != nil {
+				return err
+			}
+			for chunk := range chunks {
+				if chunk.Error != nil {
+					return chunk.Error
+				}
+				fmt.Print(chunk.Content)
+			}
+			fmt.Println()
+		}
+
+		sess.Messages = a.Messages()
+		sess.SetActivity(a.Activity())
+		sess.UpdatedAt = time.Now()
+		return mgr.Save(sess)
+	},
+}
+
+func init() {
+	paneSendCmd.Flags().StringP("target", "t", "", "tmux pane to capture (default: last active pane)")
+
+	paneCmd.AddCommand(paneSendCmd)
+	rootCmd.AddCommand(paneCmd)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/agentflow/agentflow/internal/clipboard"
+	"github.com/agentflow/agentflow/internal/github"
+	"github.com/spf13/cobra"
+)
+
+var prDescribeCmd = &cobra.Command{
+	Use:   "pr-describe",
+	Short: "Describe the current branch's changes for a pull request",
+	Long: `Diff the current branch against its base and draft a pull request
+description: a summary, a bullet per changed file, and a test plan.
+
+The description is printed; --copy puts it on the clipboard and --post
+updates the branch's open pull request, or opens one.
+
+Example:
+  agentflow pr-describe --base main --copy`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		base, _ := cmd.Flags().GetString("base")
+		if base == "" {
+			base = defaultBase()
+		}
+
+		head, err := gitOutput("rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil {
+			return err
+		}
+
+		log, err := gitOutput("log", "--oneline", base+"..HEAD")
+		if err != nil {
+			return err
+		}
+		if log == "" {
+			return fmt.Errorf("no commits between %s and %s", base, head)
+		}
+		stat, err := gitOutput("diff", "--stat", base+"...HEAD")
+		if err != nil {
+			return err
+		}
+		diff, err := gitOutput("diff", base+"...HEAD")
+		if err != nil {
+			return err
+		}
+
+		a, err := newAgent(cfg, modelSpec)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Describing %s against %s...\n", head, base)
+		resp, err := a.Run(ctx, prDescribePrompt(log, stat, diff))
+		if err != nil {
+			return err
+		}
+		title, body := splitTitleBody(resp.Content)
+		fmt.Printf("%s\n\n%s\n", title, body)
+
+		if copyOut, _ := cmd.Flags().GetBool("copy"); copyOut {
+			if err := clipboard.WriteText(title + "\n\n" + body); err != nil {
+				return fmt.Errorf("copy to clipboard: %w", err)
+			}
+			fmt.Fprintln(os.Stderr, "✓ Copied to clipboard")
+		}
+
+		if post, _ := cmd.Flags().GetBool("post"); post {
+			repo, err := ghRepo(cmd)
+			if err != nil {
+				return err
+			}
+			client := ghClient(cfg)
+
+			existing, err := client.FindPullRequest(ctx, repo, head)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				if err := client.UpdatePullRequest(ctx, repo, existing.Number, title, body); err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "✓ Updated pull request #%d: %s\n", existing.Number, existing.HTMLURL)
+				return nil
+			}
+
+			created, err := client.CreatePullRequest(ctx, repo, github.PullRequest{
+				Title: title,
+				Body:  body,
+				Head:  head,
+				Base:  strings.TrimPrefix(base, "origin/"),
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "✓ Created pull request #%d: %s\n", created.Number, created.HTMLURL)
+		}
+		return nil
+	},
+}
+
+// defaultBase returns the branch origin's HEAD points at, else main
+func defaultBase() string {
+	if ref, err := gitOutput("symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil && ref != "" {
+		return ref
+	}
+	return "main"
+}
+
+// prDescribePrompt asks for a title line and a body with a summary,
+// per-file bullets and a test plan
+func prDescribePrompt(log, stat, diff string) string {
+	diff = cutDiff(diff)
+	return fmt.Sprintf(`Write a pull request title and description for these changes.
+
+Respond with the title on the first line (imperative mood, under 72 characters),
+then a blank line, then a markdown body with these sections:
+
+## Summary
+Two or three sentences on what changes and why.
+
+## Changes
+One bullet per changed file from the stat below: the path in backticks, then what changed in it.
+
+## Test plan
+Bullets saying how to verify the change: tests to run and behavior to check by hand.
+
+Do not wrap the response in a code block.
+
+Commits:
+%s
+
+Files:
+%s
+
+Diff:
+%s`, log, stat, diff)
+}
+
+func init() {
+	prDescribeCmd.Flags().String("base", "", "base branch (default: origin's HEAD, else main)")
+	prDescribeCmd.Flags().Bool("copy", false, "copy the description to the clipboard")
+	prDescribeCmd.Flags().Bool("post", false, "update the branch's open pull request, or open one")
+	prDescribeCmd.Flags().String("repo", "", "repository as owner/name (default: origin remote)")
+
+	rootCmd.AddCommand(prDescribeCmd)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/server"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/agentflow/agentflow/internal/skill"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the agent over HTTP and WebSocket",
+	Long: `Start an HTTP server exposing the agent for web UIs and integrations.
+
+Endpoints:
+  GET /                   Web chat UI (with --ui)
+  GET /health             Server status
+  GET /ws                 WebSocket streaming API (?session=<id> to resume)
+  GET /api/sessions       Saved sessions
+  GET /api/sessions/{id}  Session with messages
+  GET /api/skills         Loaded skills
+
+WebSocket frames are JSON objects with a "type" field. Send
+{"type":"message","content":"..."} or {"type":"cancel"}; receive
+session, skill, chunk, done (with token count), and error frames.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		spec := modelSpec
+		if spec == "" {
+			spec = cfg.Defaults.Main
+		}
+		registry := cfg.BuildRegistry()
+		provider, modelName, ok := registry.ResolveModel(spec)
+		if !ok {
+			return fmt.Errorf("unknown model: %s", spec)
+		}
+
+		skillLoader := cfg.SkillLoader()
+		if err := skillLoader.Load(); err != nil {
+			return fmt.Errorf("load skills: %w", err)
+		}
+
+		addr, _ := cmd.Flags().GetString("addr")
+		ui, _ := cmd.Flags().GetBool("ui")
+		workdir, _ := os.Getwd()
+
+		srv := server.New(server.Config{
+			Addr: addr,
+			NewAgent: func() *agent.Agent {
+				return agent.New(agent.Config{
+					Provider:     provider,
+					Model:        modelName,
+					Skills:       skillLoader,
+					ResolveModel: cfg.ModelResolver(registry),
+					SystemPrompt: cfg.Language.AnswerInstruction(),
+					Tools:        cfg.BuildTools(),
+					Budget:       cfg.ContextBudget(),
+					Guard:        cfg.InjectionGuard(),
+					MaxCost:      cfg.Defaults.MaxCost,
+					Audit:        auditLog(cfg, ""),
+					Params:       cfg.GenerationParams(),
+					SkillStats:   skill.NewStats(""),
+				})
+			},
+			Skills:   skillLoader,
+			Sessions: session.NewManager(""),
+			Workdir:  workdir,
+			Provider: provider.Name(),
+			Model:    modelName,
+			UI:       ui,
+		})
+
+		fmt.Fprintf(os.Stderr, "🌐 Serving %s on http://%s (Ctrl+C to stop)\n", spec, srv.Addr())
+		return srv.ListenAndServe(ctx)
+	},
+}
+
+func init() {
+	serveCmd.Flags().String("addr", "127.0.0.1:8080", "address to listen on")
+	serveCmd.Flags().Bool("ui", false, "serve the web chat UI at /")
+
+	rootCmd.AddCommand(serveCmd)
+}
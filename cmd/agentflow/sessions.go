@@ -0,0 +1,465 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/github"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/agentflow/agentflow/internal/tui"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+var sessionShareCmd = &cobra.Command{
+	Use:   "share <id|name>",
+	Short: "Share a session as an HTML page or a secret gist",
+	Long: `Render a session to a self-contained HTML page with highlighted code and
+collapsible tool output, or post it as a secret GitHub gist with --gist.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sess, err := session.NewManager("").GetByNameOrID(args[0])
+		if err != nil {
+			return err
+		}
+
+		if gist, _ := cmd.Flags().GetBool("gist"); gist {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			public, _ := cmd.Flags().GetBool("public")
+			created, err := ghClient(cfg).CreateGist(context.Background(), github.Gist{
+				Description: "AgentFlow session: " + sess.DisplayName(),
+				Public:      public,
+				Files: map[string]github.GistFile{
+					"agentflow-session-" + sess.ID + ".md": {Content: session.RenderMarkdown(sess)},
+				},
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Println(created.HTMLURL)
+			return nil
+		}
+
+		page, err := session.RenderHTML(sess)
+		if err != nil {
+			return err
+		}
+
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			output = "agentflow-session-" + sess.ID + ".html"
+		}
+		if err := os.WriteFile(output, page, 0644); err != nil {
+			return fmt.Errorf("write page: %w", err)
+		}
+
+		if abs, err := filepath.Abs(output); err == nil {
+			output = abs
+		}
+		fmt.Println(output)
+		return nil
+	},
+}
+
+var sessionImportCmd = &cobra.Command{
+	Use:   "import <path>",
+	Short: "Import conversations from Claude Code or aider",
+	Long: `Convert another tool's conversation history into AgentFlow sessions.
+
+  --from claude-code  a transcript (.jsonl) or a directory of them,
+                      e.g. ~/.claude/projects/<project>
+  --from aider        a .aider.chat.history.md file or the repo containing it`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("from")
+		files, err := importFiles(format, args[0])
+		if err != nil {
+			return err
+		}
+
+		var imported []*session.Session
+		for _, path := range files {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			abs, _ := filepath.Abs(path)
+			sessions, err := session.Import(format, f, filepath.Dir(abs))
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			imported = append(imported, sessions...)
+		}
+		if len(imported) == 0 {
+			return fmt.Errorf("no conversations found in %s", args[0])
+		}
+
+		mgr := session.NewManager("")
+		count, err := mgr.Count()
+		if err != nil {
+			return err
+		}
+		// Don't let the import prune the sessions it just wrote
+		if total := count + len(imported); total > session.DefaultMaxSessions {
+			mgr.SetMaxSessions(total)
+			fmt.Printf("Note: %d sessions stored; only the newest %d are kept after the next save.\n\n",
+				total, session.DefaultMaxSessions)
+		}
+
+		for _, s := range imported {
+			if err := mgr.Save(s); err != nil {
+				return err
+			}
+			fmt.Printf("Imported [%s] %s (%d msgs)\n", s.ID, s.DisplayName(), len(s.Messages))
+		}
+		return nil
+	},
+}
+
+var sessionFeedbackCmd = &cobra.Command{
+	Use:   "feedback",
+	Short: "Export exchanges rated with /good and /bad",
+	Long: `Print every rated exchange of the saved sessions as one JSON object per
+line, with the session, rating, note, prompt, response, active skills and
+model: a starting point for eval sets.
+
+Example:
+  agentflow sessions feedback --rating bad > regressions.jsonl`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rating, _ := cmd.Flags().GetString("rating")
+		if rating != "" && rating != session.RatingGood && rating != session.RatingBad {
+			return fmt.Errorf("unknown rating: %s (want %s or %s)", rating, session.RatingGood, session.RatingBad)
+		}
+
+		sessions, err := session.NewManager("").List()
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		for _, s := range sessions {
+			for _, f := range s.Feedback() {
+				if rating != "" && f.Rating != rating {
+					continue
+				}
+				if err := enc.Encode(struct {
+					Session string `json:"session"`
+					session.Feedback
+				}{s.ID, f}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+}
+
+var sessionActivityCmd = &cobra.Command{
+	Use:   "activity <id|name>",
+	Short: "List the tool calls a session ran",
+	Long: `Print what the agent did in a saved session, one tool call per line:
+when, what kind (read, search, fetch, run or edit), on what, and whether
+it failed or was answered from the cache. An audit at a glance, without
+reading the transcript.
+
+Example:
+  agentflow sessions activity my-session --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sess, err := session.NewManager("").GetByNameOrID(args[0])
+		if err != nil {
+			return err
+		}
+		activity := sess.Activity()
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			for _, a := range activity {
+				if err := enc.Encode(a); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if len(activity) == 0 {
+			fmt.Println("No tool calls in this session.")
+			return nil
+		}
+		for _, a := range activity {
+			fmt.Printf("%s  %-16s %s\n", a.Time.Local().Format("2006-01-02 15:04:05"), a.Tool, a)
+		}
+		return nil
+	},
+}
+
+var sessionReplayCmd = &cobra.Command{
+	Use:   "replay <id|name>",
+	Short: "Play a saved session back in the TUI as it streamed",
+	Long: `Re-render a saved session in the TUI: user messages appear and answers
+stream over the time they originally took, from the message timestamps.
+Pauses between turns are cut to two seconds. Sessions saved before
+messages had timestamps stream at a steady pace.
+
+Example:
+  agentflow sessions replay my-session --speed 2x`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sess, err := session.NewManager("").GetByNameOrID(args[0])
+		if err != nil {
+			return err
+		}
+		value, _ := cmd.Flags().GetString("speed")
+		speed, err := strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64)
+		if err != nil || speed <= 0 {
+			return fmt.Errorf("invalid speed %q: use a factor such as 2x or 0.5x", value)
+		}
+
+		m := tui.New(sess.Provider, sess.Model)
+		m.SetOnSubmit(func(string) tea.Cmd {
+			return tui.SendError(errors.New("this is a replay; start agentflow --resume " + sess.ID + " to continue it"))
+		})
+		p := tea.NewProgram(m, tea.WithAltScreen())
+
+		go func() {
+			for _, step := range session.ReplaySteps(sess, speed) {
+				time.Sleep(step.Delay)
+				switch step.Kind {
+				case session.StepUser:
+					p.Send(tui.SendUserMessage(step.Content)())
+				case session.StepChunk:
+					p.Send(tui.SendStreamChunk(step.Content)())
+				case session.StepToolCalls:
+					p.Send(tui.SendToolCalls(describeCalls(step.ToolCalls))())
+				case session.StepDone:
+					p.Send(tui.SendStreamDone()())
+				}
+			}
+			p.Send(tui.SendNotice(fmt.Sprintf("End of %s (Esc to exit)", sess.DisplayName()))())
+		}()
+
+		_, err = p.Run()
+		return err
+	},
+}
+
+var sessionRerunCmd = &cobra.Command{
+	Use:   "rerun <id|name>",
+	Short: "Send a session's user messages to another model",
+	Long: `Replay the user messages of a saved session, in order, against the model
+given with -m (the main model by default), and save the conversation as a
+new session forked from the original. Compare the two with
+agentflow sessions share.
+
+Example:
+  agentflow sessions rerun my-session -m ollama/qwen2.5`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		mgr := session.NewManager("")
+		orig, err := mgr.GetByNameOrID(args[0])
+		if err != nil {
+			return err
+		}
+		var prompts []string
+		for _, m := range orig.Messages {
+			if m.Role == "user" {
+				prompts = append(prompts, m.Content)
+			}
+		}
+		if len(prompts) == 0 {
+			return fmt.Errorf("session %s has no user messages", orig.ID)
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		spec := modelSpec
+		if spec == "" {
+			spec = cfg.Defaults.Main
+		}
+		a, err := newAgent(cfg, spec)
+		if err != nil {
+			return err
+		}
+
+		rerun := orig.Clone()
+		rerun.Name = orig.DisplayName() + " (" + spec + ")"
+		rerun.Provider, _, _ = strings.Cut(spec, "/")
+		rerun.Model = a.Model()
+		rerun.Metadata["rerun_of"] = orig.ID
+		delete(rerun.Metadata, "feedback") // Ratings were of the other answers
+
+		done := 0
+		for _, prompt := range prompts {
+			fmt.Fprintf(os.Stderr, "\n[%d/%d] %s\n\n", done+1, len(prompts), truncateLine(prompt, 70))
+			if err = rerunTurn(ctx, a, rerun, prompt); err != nil {
+				break
+			}
+			done++
+		}
+
+		rerun.Messages = a.Messages()
+		rerun.SetActivity(a.Activity())
+		rerun.UpdatedAt = time.Now()
+		if saveErr := mgr.Save(rerun); saveErr != nil {
+			return saveErr
+		}
+		if err != nil {
+			return fmt.Errorf("stopped after %d of %d messages (saved as %s): %w", done, len(prompts), rerun.ID, err)
+		}
+		fmt.Fprintf(os.Stderr, "\n✓ Saved as session %s (from %s with %s)\n", rerun.ID, orig.ID, orig.Model)
+		return nil
+	},
+}
+
+// rerunTurn streams the answer to one replayed message
+func rerunTurn(ctx context.Context, a *agent.Agent, sess *session.Session, prompt string) error {
+	for _, act := range a.ActivateSkills(prompt) {
+		sess.RecordSkill(act.Skill, act.Reason)
+	}
+	chunks, err := a.Stream(ctx, prompt)
+	if err != nil {
+		return err
+	}
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			err = chunk.Error
+			continue // Drain so the provider goroutine can exit
+		}
+		fmt.Print(chunk.Content)
+	}
+	fmt.Println()
+	return err
+}
+
+// truncateLine shortens text to its first line, at most n bytes
+func truncateLine(text string, n int) string {
+	text, _, _ = strings.Cut(strings.TrimSpace(text), "\n")
+	if len(text) > n {
+		text = text[:n] + "..."
+	}
+	return text
+}
+
+// importFiles resolves the history files to import from a path, which
+// may be a file or a directory
+func importFiles(format, path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	switch format {
+	case session.FormatClaudeCode:
+		return filepath.Glob(filepath.Join(path, "*.jsonl"))
+	case session.FormatAider:
+		return []string{filepath.Join(path, ".aider.chat.history.md")}, nil
+	default:
+		return nil, fmt.Errorf("unknown import format: %s (want %s or %s)", format, session.FormatClaudeCode, session.FormatAider)
+	}
+}
+
+var sessionArchiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Gzip old sessions into the archive directory",
+	Long: `Compress the sessions not updated for --days into the archive
+subdirectory of ~/.agentflow/sessions. Archived sessions are left out of
+agentflow sessions (list them with --archived) but can still be resumed,
+shared or replayed by ID or name; resuming one makes it live again.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		days, _ := cmd.Flags().GetInt("days")
+		if days < 0 {
+			return fmt.Errorf("--days must not be negative")
+		}
+		mgr := session.NewManager("")
+
+		sessions, err := mgr.List()
+		if err != nil {
+			return err
+		}
+		sizes := make(map[string]int64, len(sessions))
+		for _, s := range sessions {
+			sizes[s.ID] = mgr.Size(s.ID)
+		}
+
+		ids, err := mgr.Archive(time.Duration(days) * 24 * time.Hour)
+		var before, after int64
+		for _, id := range ids {
+			before += sizes[id]
+			after += mgr.Size(id)
+		}
+		if len(ids) > 0 {
+			fmt.Printf("Archived %d session(s) older than %d days: %s → %s\n",
+				len(ids), days, session.FormatSize(before), session.FormatSize(after))
+		} else if err == nil {
+			fmt.Printf("No sessions older than %d days\n", days)
+		}
+		return err
+	},
+}
+
+// searchSessions keeps the sessions whose name, directory or messages
+// contain query, ignoring case
+func searchSessions(sessions []*session.Session, query string) []*session.Session {
+	query = strings.ToLower(query)
+	var found []*session.Session
+	for _, s := range sessions {
+		match := strings.Contains(strings.ToLower(s.DisplayName()), query) ||
+			strings.Contains(strings.ToLower(s.Workdir), query)
+		for _, msg := range s.Messages {
+			if match {
+				break
+			}
+			match = strings.Contains(strings.ToLower(msg.Content), query)
+		}
+		if match {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+func init() {
+	sessionArchiveCmd.Flags().Int("days", 30, "archive sessions not updated for this many days")
+
+	sessionShareCmd.Flags().StringP("output", "o", "", "HTML file to write (default: agentflow-session-<id>.html)")
+	sessionShareCmd.Flags().Bool("gist", false, "post as a GitHub gist instead and print its URL")
+	sessionShareCmd.Flags().Bool("public", false, "with --gist, make the gist public")
+
+	sessionImportCmd.Flags().String("from", "", "source tool: claude-code or aider")
+	sessionImportCmd.MarkFlagRequired("from")
+
+	sessionFeedbackCmd.Flags().String("rating", "", "only export good or bad ratings")
+
+	sessionReplayCmd.Flags().String("speed", "1x", "playback speed, e.g. 2x or 0.5x")
+
+	sessionActivityCmd.Flags().Bool("json", false, "print one JSON object per call")
+
+	sessionsCmd.AddCommand(sessionShareCmd)
+	sessionsCmd.AddCommand(sessionReplayCmd)
+	sessionsCmd.AddCommand(sessionRerunCmd)
+	sessionsCmd.AddCommand(sessionFeedbackCmd)
+	sessionsCmd.AddCommand(sessionActivityCmd)
+	sessionsCmd.AddCommand(sessionImportCmd)
+	sessionsCmd.AddCommand(sessionArchiveCmd)
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/config"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/agentflow/agentflow/internal/skill"
+	"github.com/spf13/cobra"
+)
+
+var skillLintCmd = &cobra.Command{
+	Use:   "lint [path...]",
+	Short: "Check skill files for mistakes",
+	Long: `Check skills for front-matter that doesn't parse or has unknown keys,
+missing names and descriptions, names used by more than one file, includes
+and links to files that don't exist, and oversized content.
+
+Paths default to the configured skill paths. The command fails when errors
+are found (or warnings, with --strict); --format json prints the issues
+for CI.
+
+Example:
+  agentflow skill lint ./skills --format json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths := args
+		if len(paths) == 0 {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			paths = cfg.Skills.Paths
+		}
+
+		maxTokens, _ := cmd.Flags().GetInt("max-tokens")
+		issues, err := skill.Lint(paths, maxTokens)
+		if err != nil {
+			return err
+		}
+
+		errors, warnings := 0, 0
+		for _, issue := range issues {
+			if issue.Severity == skill.SeverityError {
+				errors++
+			} else {
+				warnings++
+			}
+		}
+
+		switch format, _ := cmd.Flags().GetString("format"); format {
+		case "json":
+			if issues == nil {
+				issues = []skill.Issue{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(issues); err != nil {
+				return err
+			}
+		case "text":
+			for _, issue := range issues {
+				fmt.Println(issue)
+			}
+			fmt.Fprintf(os.Stderr, "%d error(s), %d warning(s)\n", errors, warnings)
+		default:
+			return fmt.Errorf("unknown format: %s (want text or json)", format)
+		}
+
+		strict, _ := cmd.Flags().GetBool("strict")
+		if errors > 0 || (strict && warnings > 0) {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("skill lint failed: %d error(s), %d warning(s)", errors, warnings)
+		}
+		return nil
+	},
+}
+
+var skillNewCmd = &cobra.Command{
+	Use:   "new <name>",
+	Short: "Create a skill from a template",
+	Long: `Create <dir>/<name>/SKILL.md with its front-matter filled in and
+sections to complete. With --draft, the main model writes a first version
+of the instructions from --description.
+
+The directory defaults to the first configured skill path.
+
+Example:
+  agentflow skill new code-review --description "Review a diff for bugs and style" --draft`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		name := args[0]
+		if !skill.ValidName(name) {
+			return fmt.Errorf("invalid skill name %q: use lowercase words joined by hyphens", name)
+		}
+		description, _ := cmd.Flags().GetString("description")
+		draft, _ := cmd.Flags().GetBool("draft")
+		if draft && description == "" {
+			return errors.New("--draft needs --description")
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		path, err := skillPath(cmd, cfg, name)
+		if err != nil {
+			return err
+		}
+
+		body := ""
+		if draft {
+			a, err := newAgent(cfg, modelSpec)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Drafting %s...\n", name)
+			resp, err := a.Run(ctx, skill.DraftPrompt(name, description))
+			if err != nil {
+				return err
+			}
+			body = resp.Content
+		}
+
+		if err := writeSkill(path, skill.Template(name, description, body)); err != nil {
+			return err
+		}
+		fmt.Printf("Created %s\n", path)
+		return nil
+	},
+}
+
+var skillDistillCmd = &cobra.Command{
+	Use:   "distill <session-id|name>",
+	Short: "Draft a skill from a session that went well",
+	Long: `Have the main model read a session and write down the workflow that
+worked, the decisions that mattered and the pitfalls it ran into, as a
+SKILL.md in the project skills directory. Review and edit the draft before
+relying on it; its triggers are only a starting point.
+
+Example:
+  agentflow skill distill 3f2a9c1e --name flaky-test-fix`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		name, _ := cmd.Flags().GetString("name")
+		if name != "" && !skill.ValidName(name) {
+			return fmt.Errorf("invalid skill name %q: use lowercase words joined by hyphens", name)
+		}
+		sess, err := session.NewManager("").GetByNameOrID(args[0])
+		if err != nil {
+			return err
+		}
+		if len(sess.Messages) == 0 {
+			return fmt.Errorf("session %s has no messages", sess.ID)
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		a, err := newAgent(cfg, modelSpec)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Distilling %s (%d messages)...\n", sess.DisplayName(), len(sess.Messages))
+		resp, err := a.Run(ctx, skill.DistillPrompt(session.RenderMarkdown(sess), name))
+		if err != nil {
+			return err
+		}
+		suggested, description, body, err := skill.ParseDistilled(resp.Content)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			name = suggested
+		}
+
+		path, err := skillPath(cmd, cfg, name)
+		if err != nil {
+			return err
+		}
+		if err := writeSkill(path, skill.Template(name, description, body)); err != nil {
+			return err
+		}
+		fmt.Printf("Drafted %s from session %s; review it before use\n", path, sess.ID)
+		return nil
+	},
+}
+
+// skillPath returns where the skill name is written: under --dir, or the
+// first configured skill path. Without --force, an existing skill is an
+// error.
+func skillPath(cmd *cobra.Command, cfg *config.Config, name string) (string, error) {
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir == "" {
+		dir = "./skills"
+		if len(cfg.Skills.Paths) > 0 {
+			dir = cfg.Skills.Paths[0]
+		}
+	}
+	if strings.HasPrefix(dir, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[1:])
+		}
+	}
+	path := filepath.Join(dir, name, "SKILL.md")
+	if force, _ := cmd.Flags().GetBool("force"); !force {
+		if _, err := os.Stat(path); err == nil {
+			return "", fmt.Errorf("%s already exists (use --force to overwrite)", path)
+		}
+	}
+	return path, nil
+}
+
+// writeSkill writes a SKILL.md, creating its directory
+func writeSkill(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+var skillStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show how often skills are used and how they work out",
+	Long: `Show each skill's activations, how often the user pushed back on the
+answer ("no", "still failing", ...), /good and /bad ratings, and the
+estimated tokens its instructions cost. Skills that are
+loaded but never used are listed last.
+
+Usage is recorded by interactive sessions in ~/.agentflow/skill-stats.json.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		usage, err := skill.NewStats("").Load()
+		if err != nil {
+			return err
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(usage)
+		}
+
+		names := make([]string, 0, len(usage))
+		for name := range usage {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if usage[names[i]].Activations != usage[names[j]].Activations {
+				return usage[names[i]].Activations > usage[names[j]].Activations
+			}
+			return names[i] < names[j]
+		})
+
+		if len(names) == 0 {
+			fmt.Println("No skill usage recorded yet")
+		} else {
+			fmt.Printf("%-28s %6s %10s %5s %5s %9s  %s\n", "SKILL", "USES", "RETRIES", "GOOD", "BAD", "TOKENS", "LAST USED")
+		}
+		for _, name := range names {
+			u := usage[name]
+			last := "-"
+			if !u.LastUsed.IsZero() {
+				last = u.LastUsed.Format(time.DateOnly)
+			}
+			fmt.Printf("%-28s %6d %10s %5d %5d %9d  %s\n", name, u.Activations, retryRate(u), u.Good, u.Bad, u.Tokens, last)
+		}
+
+		// Loaded skills with no usage may have triggers that never match
+		if cfg, err := loadConfig(); err == nil {
+			loader := cfg.SkillLoader()
+			if loader.Load() == nil {
+				var unused []string
+				for _, name := range loader.Names() {
+					if _, ok := usage[name]; !ok {
+						unused = append(unused, name)
+					}
+				}
+				sort.Strings(unused)
+				if len(unused) > 0 {
+					fmt.Printf("\nNever used: %s\n", strings.Join(unused, ", "))
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// retryRate formats retries with their share of activations
+func retryRate(u *skill.Usage) string {
+	if u.Activations == 0 {
+		return fmt.Sprint(u.Retries)
+	}
+	return fmt.Sprintf("%d (%d%%)", u.Retries, u.Retries*100/u.Activations)
+}
+
+func init() {
+	skillLintCmd.Flags().String("format", "text", "output format: text or json")
+	skillLintCmd.Flags().Int("max-tokens", skill.DefaultMaxTokens, "warn about skills larger than this")
+	skillLintCmd.Flags().Bool("strict", false, "fail on warnings too")
+
+	skillNewCmd.Flags().String("description", "", "one-line description for the front-matter")
+	skillNewCmd.Flags().Bool("draft", false, "have the main model write the instructions from the description")
+	skillNewCmd.Flags().String("dir", "", "directory to create the skill in (default: first skill path)")
+	skillNewCmd.Flags().Bool("force", false, "overwrite an existing skill")
+
+	skillDistillCmd.Flags().String("name", "", "name of the skill (default: the model's suggestion)")
+	skillDistillCmd.Flags().String("dir", "", "directory to write the skill in (default: first skill path)")
+	skillDistillCmd.Flags().Bool("force", false, "overwrite an existing skill")
+
+	skillStatsCmd.Flags().Bool("json", false, "print the raw usage as JSON")
+
+	skillCmd.AddCommand(skillLintCmd)
+	skillCmd.AddCommand(skillNewCmd)
+	skillCmd.AddCommand(skillDistillCmd)
+	skillCmd.AddCommand(skillStatsCmd)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentflow/agentflow/internal/config"
+	"github.com/agentflow/agentflow/internal/skill"
+	"github.com/agentflow/agentflow/internal/team"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Pull the team's shared config and skills",
+	Long: `Clone or fast-forward the team repository named by sync.repo into
+~/.agentflow/team. Its config.yaml is read below your config, key by key,
+and its skills/ below your skills, so what you set wins. The clone is
+read-only: change the team repository instead.
+
+After syncing, the team settings and skills you override are listed;
+--status only fetches and reports how far behind the clone is.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+		dir := config.TeamDir
+
+		if status, _ := cmd.Flags().GetBool("status"); status {
+			s, err := team.Check(ctx, dir)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Team repository at %s, commit %s\n", dir, shortCommit(s.Commit))
+			if s.Behind > 0 {
+				fmt.Printf("  %d commit(s) behind: run agentflow sync\n", s.Behind)
+			} else {
+				fmt.Println("  Up to date")
+			}
+			if len(s.Modified) > 0 {
+				fmt.Printf("  Changed locally, and ignored by sync until discarded: %s\n", strings.Join(s.Modified, ", "))
+			}
+		} else {
+			if cfg.Sync.Repo == "" {
+				return fmt.Errorf("no team repository: set sync.repo in your config")
+			}
+			r, err := team.Sync(ctx, dir, cfg.Sync.Repo, cfg.Sync.Branch)
+			if err != nil {
+				return err
+			}
+			switch {
+			case r.Cloned:
+				fmt.Printf("Cloned %s into %s (%s)\n", cfg.Sync.Repo, dir, shortCommit(r.After))
+			case r.Before == r.After:
+				fmt.Printf("Already up to date (%s)\n", shortCommit(r.After))
+			default:
+				fmt.Printf("Updated %s..%s, %d commit(s)\n", shortCommit(r.Before), shortCommit(r.After), r.Commits(ctx, dir))
+			}
+		}
+
+		return printDrift(cfg, dir)
+	},
+}
+
+// printDrift lists the team settings and skills the user's own override
+func printDrift(cfg *config.Config, dir string) error {
+	teamPath := team.ConfigPath(dir)
+	userPath := userConfigPath()
+	if teamPath != "" && userPath != "" {
+		teamData, err := os.ReadFile(teamPath)
+		if err != nil {
+			return err
+		}
+		userData, err := os.ReadFile(userPath)
+		if err != nil {
+			return err
+		}
+		teamSettings, err := team.Settings(teamData)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", teamPath, err)
+		}
+		userSettings, err := team.Settings(userData)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", userPath, err)
+		}
+		if drift := team.Drift(teamSettings, userSettings); len(drift) > 0 {
+			fmt.Printf("\nTeam settings overridden by %s:\n", userPath)
+			for _, d := range drift {
+				fmt.Printf("  %s: team %s, yours %s\n", d.Key, oneLine(d.Team), oneLine(d.User))
+			}
+		}
+	}
+
+	teamSkills := skill.NewLoader([]string{team.SkillsDir(dir)})
+	userSkills := skill.NewLoader(cfg.Skills.Paths)
+	if teamSkills.Load() != nil || userSkills.Load() != nil {
+		return nil // agentflow doctor reports broken skills
+	}
+	var shadowed []string
+	for _, name := range teamSkills.Names() {
+		if _, ok := userSkills.Get(name); ok {
+			shadowed = append(shadowed, name)
+		}
+	}
+	if len(shadowed) > 0 {
+		fmt.Printf("\nTeam skills overridden by your own: %s\n", strings.Join(shadowed, ", "))
+	}
+	return nil
+}
+
+// userConfigPath returns the config file read over the team config, or
+// "" when there is none
+func userConfigPath() string {
+	path := cfgFile
+	if path == "" {
+		path = config.ConfigSource
+	}
+	if _, err := os.Stat(path); err != nil || filepath.Dir(path) == config.TeamDir {
+		return ""
+	}
+	return path
+}
+
+func shortCommit(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// oneLine flattens a setting rendered over several lines, such as a list
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(s, "\n", " ")), " ")
+}
+
+func init() {
+	syncCmd.Flags().Bool("status", false, "fetch and report how far behind the team repository is, without pulling")
+
+	rootCmd.AddCommand(syncCmd)
+}
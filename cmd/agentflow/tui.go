@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/artifact"
+	"github.com/agentflow/agentflow/internal/config"
+	"github.com/agentflow/agentflow/internal/fetch"
+	"github.com/agentflow/agentflow/internal/input"
+	"github.com/agentflow/agentflow/internal/permission"
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/internal/statusline"
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/internal/tui"
+	"github.com/agentflow/agentflow/pkg/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runTUI runs a TUI model with submissions streamed through the agent.
+// onSkill, if set, is told of each skill activation, and afterTurn, if
+// set, runs once each response has finished streaming. Esc cancels the
+// response being streamed, as does quitting, which returns once it has
+// been cut short and afterTurn has run. /tab new opens side
+// conversations, each with an agent of its own.
+func runTUI(m tui.Model, ag *agent.Agent, onSkill func(agent.SkillActivation), afterTurn func(), opts ...tea.ProgramOption) error {
+	var p *tea.Program
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var turns sync.WaitGroup
+	sendTo := func(id int) func(tea.Msg) {
+		return func(msg tea.Msg) { p.Send(tui.ToTab(id, msg)) }
+	}
+
+	wireTab(ctx, &turns, &m, ag, sendTo(0), onSkill, afterTurn)
+	tabs := tui.NewTabs(m)
+	if loadedConfig != nil {
+		tabs.SetOnNewTab(func(id int, spec string) (tui.Model, error) {
+			return sideTab(ctx, &turns, spec, sendTo(id))
+		})
+	}
+
+	p = tea.NewProgram(tabs, opts...)
+	go watchContextFiles(ctx, ag, sendTo(0))
+
+	_, err := p.Run()
+	cancel()
+	turns.Wait()
+	if errors.Is(err, tea.ErrInterrupted) {
+		return nil
+	}
+	return err
+}
+
+// sideTab builds the conversation of a tab opened with /tab new: a new
+// agent on spec, or on the default model, that isn't saved
+func sideTab(ctx context.Context, turns *sync.WaitGroup, spec string, send func(tea.Msg)) (tui.Model, error) {
+	ag, err := newAgent(loadedConfig, spec)
+	if err != nil {
+		return tui.Model{}, err
+	}
+	if spec == "" {
+		spec = loadedConfig.Defaults.Main
+	}
+	workdir, _ := os.Getwd()
+	ag.SetArtifacts(artifact.Open(workdir, ""))
+	ag.SetVerify(loadedConfig.Verifier())
+
+	providerName, modelName, ok := strings.Cut(spec, "/")
+	if !ok {
+		providerName, modelName = spec, ag.Model()
+	}
+	m := tui.New(providerName, modelName)
+	m.SetReadTimeout(loadedConfig.Timeouts(spec).Read)
+	m.SetVim(loadedConfig.Input.Vim)
+	m.SetVoice(loadedConfig.Voice)
+	m.SetGuard(loadedConfig.InjectionGuard())
+	m.SetReadOnly(loadedConfig.ReadOnly)
+	m.SetOnCommand(agentCommands(loadedConfig, ag, nil))
+	wireTab(ctx, turns, &m, ag, send, nil, nil)
+	return m, nil
+}
+
+// wireTab streams a tab's submissions through its agent, sending what
+// comes back with send
+func wireTab(ctx context.Context, turns *sync.WaitGroup, m *tui.Model, ag *agent.Agent, send func(tea.Msg), onSkill func(agent.SkillActivation), afterTurn func()) {
+	var turnMu sync.Mutex
+	cancelTurn := func() {}
+	m.SetOnCancel(func() {
+		turnMu.Lock()
+		defer turnMu.Unlock()
+		cancelTurn()
+	})
+
+	m.SetAudit(ag.Audit())
+	askPermission(ag, send)
+	ag.SetOnActivity(func(a types.Activity) {
+		send(tui.SendActivity(a)())
+	})
+
+	meter := &sessionMeter{}
+	meter.measure(ag, nil)
+	wireStatusLine(m, meter, send)
+
+	m.SetOnContext(func(content string) {
+		ag.AddMessage("user", content)
+	})
+	m.SetOnShell(func(command string) {
+		if input.Writes(command) {
+			ag.MarkChanged()
+		}
+	})
+	m.SetOnAttach(func(att types.Attachment) {
+		ag.Attach(att)
+		if att.Type == "image" && !ag.ModelInfo().SupportsVision() {
+			// Called from Update, so the notice can't be sent synchronously
+			go send(tui.SendNotice(fmt.Sprintf("%s is not known to read images; switch to a vision model with /model or set vision: true in its model_info.", ag.Model()))())
+		}
+	})
+	m.SetOnStatus(func() string {
+		var sections []string
+		for _, section := range []string{pinnedSummary(ag), metricsSummary(), routerSummary()} {
+			if section != "" {
+				sections = append(sections, section)
+			}
+		}
+		return strings.Join(sections, "\n\n")
+	})
+	m.SetOnRewind(func(args []string) (string, error) {
+		retry, err := agent.ParseRetry(args, func(spec string) (provider.Provider, string, bool) {
+			if loadedConfig == nil {
+				return nil, "", false
+			}
+			return loadedConfig.BuildRegistry().ResolveModel(spec)
+		})
+		if err != nil {
+			return "", err
+		}
+		prompt, ok := ag.Rewind()
+		if !ok {
+			return "", errors.New("no message to take back yet")
+		}
+		ag.RetryWith(retry)
+		return prompt, nil
+	})
+	m.SetOnExecute(func() (string, error) {
+		wd, _ := os.Getwd()
+		return ag.ExecutePlan(wd)
+	})
+	m.SetOnModel(func(spec string) error {
+		if loadedConfig == nil {
+			return fmt.Errorf("unknown model: %s", spec)
+		}
+		if err := switchModel(loadedConfig, ag, spec); err != nil {
+			return err
+		}
+		meter.measure(ag, nil)
+		return nil
+	})
+	m.SetOnMentions(func(mentions []input.Mention) {
+		for _, mn := range mentions {
+			if !mn.Dir {
+				ag.TrackFile(mn.Path)
+			}
+		}
+	})
+	if mode := urlMode(); mode != fetch.Off {
+		m.SetOnFetch(mode == fetch.Ask, func(urls []string) []string {
+			return fetch.AddToContext(context.Background(), ag, urls)
+		})
+	}
+	m.SetOnSubmit(func(input string) tea.Cmd {
+		return func() tea.Msg {
+			if acts := ag.ActivateSkills(input); len(acts) > 0 {
+				send(tui.SendSkillsMatched(acts)())
+				if onSkill != nil {
+					for _, act := range acts {
+						onSkill(act)
+					}
+				}
+			}
+
+			turnCtx, stop := context.WithCancel(ctx)
+			turnMu.Lock()
+			cancelTurn = stop
+			turnMu.Unlock()
+
+			stats := agent.NewStreamStats()
+			userID := len(ag.Messages()) + 1
+			chunks, err := ag.Stream(turnCtx, input)
+			if err != nil {
+				stop()
+				return tui.SendError(err)()
+			}
+
+			// send lets chunks arrive as separate messages
+			turns.Add(1)
+			go func() {
+				defer turns.Done()
+				defer stop()
+				for chunk := range chunks {
+					if chunk.Error != nil {
+						if turnCtx.Err() == nil {
+							send(tui.SendError(chunk.Error)())
+						}
+						continue
+					}
+					if chunk.Notice != "" {
+						send(tui.SendNotice(chunk.Notice)())
+						continue
+					}
+					stats.Observe(chunk)
+					if chunk.Replace {
+						send(tui.SendStreamReplace(chunk.Content)())
+						continue
+					}
+					if len(chunk.ToolCalls) > 0 && !chunk.Done {
+						send(tui.SendStreamChunk(chunk.Content)())
+						send(tui.SendToolCalls(describeCalls(chunk.ToolCalls))())
+						continue
+					}
+					if chunk.Reasoning != "" {
+						send(tui.SendReasoningChunk(chunk.Reasoning)())
+					}
+					send(tui.SendStreamChunk(chunk.Content)())
+				}
+				stats.Finish()
+				meter.measure(ag, stats)
+				answerID := 0
+				if msgs := ag.Messages(); msgs[len(msgs)-1].Role == "assistant" {
+					answerID = len(msgs)
+				}
+				send(tui.SendMessageIDs(userID, answerID)())
+				if afterTurn != nil {
+					afterTurn()
+				}
+				send(tui.SendStreamStats(stats)())
+				send(tui.SendStreamDone()())
+			}()
+
+			return nil
+		}
+	})
+}
+
+// wireStatusLine shows the configured status line, if any, in a tab
+func wireStatusLine(m *tui.Model, meter *sessionMeter, send func(tea.Msg)) {
+	if loadedConfig == nil {
+		return
+	}
+	line, err := loadedConfig.StatusLiner()
+	if err != nil {
+		m.SetStatusLine(func(statusline.Info) string { return err.Error() })
+		return
+	}
+	if line == nil {
+		return
+	}
+	m.SetStatusLine(func(info statusline.Info) string {
+		meter.fill(&info)
+		return line.Render(info, func() { send(tui.SendRedraw()()) })
+	})
+}
+
+// sessionMeter keeps what the status line shows of an agent: the size
+// of its conversation against the model's window and what it has cost.
+// It is measured between turns, as the agent can't be read while it
+// streams.
+type sessionMeter struct {
+	mu            sync.Mutex
+	contextTokens int
+	window        int
+	cost          float64
+}
+
+// measure updates the meter after a turn, whose stats are added to the
+// cost, or after a change of model with nil stats
+func (s *sessionMeter) measure(ag *agent.Agent, stats *agent.StreamStats) {
+	var tokens int
+	for _, msg := range ag.Messages() {
+		tokens += agent.EstimateTokens(msg.Content)
+	}
+	info := ag.ModelInfo()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contextTokens, s.window = tokens, info.ContextWindow
+	if stats != nil {
+		s.cost += info.Cost(stats.PromptTokens, stats.Tokens)
+	}
+}
+
+// fill adds the meter's readings to info
+func (s *sessionMeter) fill(info *statusline.Info) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info.ContextTokens, info.ContextWindow, info.Cost = s.contextTokens, s.window, s.cost
+	if s.window > 0 {
+		info.Context = s.contextTokens * 100 / s.window
+	}
+}
+
+// describeCalls renders tool calls for display
+func describeCalls(calls []types.ToolCall) []string {
+	out := make([]string, len(calls))
+	for i, call := range calls {
+		out[i] = tool.Describe(call)
+	}
+	return out
+}
+
+// watchContextFiles tells the user when files whose contents are in
+// context change on disk, once per change, so the model isn't reasoning
+// about stale copies
+func watchContextFiles(ctx context.Context, ag *agent.Agent, send func(tea.Msg)) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	notified := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed := make(map[string]bool)
+		for _, path := range ag.ChangedFiles() {
+			changed[path] = true
+			if !notified[path] {
+				send(tui.SendNotice(fmt.Sprintf("⚠ %s changed on disk since it was added to context — /refresh to send the new version", displayPath(path)))())
+			}
+		}
+		notified = changed
+	}
+}
+
+// askPermission has the user allow each call of a tool that changes
+// things, unless the project's rules already do
+func askPermission(ag *agent.Agent, send func(tea.Msg)) {
+	workdir, _ := os.Getwd()
+	perms, err := permission.Load(workdir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return
+	}
+	ag.SetApprove(perms.Gate(func(ctx context.Context, call types.ToolCall, detail string) (permission.Answer, error) {
+		reply := make(chan permission.Answer, 1)
+		send(tui.SendPermissionRequest(call, detail, reply)())
+		select {
+		case answer := <-reply:
+			return answer, nil
+		case <-ctx.Done():
+			return permission.Answer{}, ctx.Err()
+		}
+	}))
+}
+
+// switchModel moves the conversation to another "provider/model" once
+// the provider confirms it has the model, keeping the history
+func switchModel(cfg *config.Config, ag *agent.Agent, spec string) error {
+	p, model, ok := cfg.BuildRegistry().ResolveModel(spec)
+	if !ok {
+		return fmt.Errorf("unknown model: %s", spec)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := provider.CheckModel(ctx, p, model); err != nil {
+		return err
+	}
+	ag.SetModel(p, model)
+	return nil
+}
+
+// urlMode returns what to do with URLs pasted in messages
+func urlMode() string {
+	if loadedConfig == nil {
+		return fetch.Ask
+	}
+	return loadedConfig.Tools.URLMode()
+}
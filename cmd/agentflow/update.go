@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/config"
+	"github.com/agentflow/agentflow/internal/github"
+	"github.com/agentflow/agentflow/internal/update"
+	"github.com/spf13/cobra"
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update agentflow to the latest release",
+	Long: `Check GitHub for the latest agentflow release and replace the running
+binary with it. The download is verified against the release's
+checksums.txt, and against its signature when update.public_key is set.
+
+The TUI also checks once a day and shows a notice in the footer when a
+newer release is out; set update.check to false to turn that off.
+
+Example:
+  agentflow update
+  agentflow update --check`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		client := releaseClient(cfg)
+
+		release, err := update.Latest(ctx, client, version)
+		if err != nil {
+			return err
+		}
+		if release == nil {
+			fmt.Printf("agentflow %s is up to date\n", version)
+			return nil
+		}
+		fmt.Printf("agentflow %s is available (you have %s): %s\n", release.Version, version, release.URL)
+		if check, _ := cmd.Flags().GetBool("check"); check {
+			return nil
+		}
+
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("find executable: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Downloading %s...\n", update.AssetName(release.Version, runtime.GOOS, runtime.GOARCH))
+		binary, err := update.Download(ctx, client, release, update.Options{PublicKey: cfg.Update.PublicKey})
+		if err != nil {
+			return err
+		}
+		if err := update.Replace(exe, binary); err != nil {
+			return err
+		}
+		fmt.Printf("Updated %s to %s\n", exe, release.Version)
+		return nil
+	},
+}
+
+// releaseClient creates a client for the public releases API. A token is
+// only used to avoid anonymous rate limits.
+func releaseClient(cfg *config.Config) *github.Client {
+	return github.NewClient(github.Config{Token: github.ResolveToken(cfg.GitHub.Token)})
+}
+
+// updateCheck returns the TUI's background release check, or nil when it
+// is turned off
+func updateCheck(cfg *config.Config) func() string {
+	if !cfg.Update.CheckEnabled() {
+		return nil
+	}
+	return func() string {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return update.Check(ctx, releaseClient(cfg), version, update.DefaultStatePath())
+	}
+}
+
+func init() {
+	updateCmd.Flags().Bool("check", false, "only report whether a newer release is available")
+
+	rootCmd.AddCommand(updateCmd)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch run [message]",
+	Short: "Re-run an agent task whenever matching files change",
+	Long: `Watch the current directory and re-run a prompt each time matching files change.
+
+Example:
+  agentflow watch --glob '**/*.go' run "fix failing tests"`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if args[0] != "run" {
+			return fmt.Errorf("unknown watch action: %s (expected \"run\")", args[0])
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		registry := cfg.BuildRegistry()
+
+		model := modelSpec
+		if model == "" {
+			model = cfg.Defaults.Main
+		}
+
+		provider, modelName, ok := registry.ResolveModel(model)
+		if !ok {
+			return fmt.Errorf("unknown model: %s", model)
+		}
+
+		skillLoader := cfg.SkillLoader()
+		if err := skillLoader.Load(); err != nil {
+			return fmt.Errorf("load skills: %w", err)
+		}
+
+		globs, _ := cmd.Flags().GetStringSlice("glob")
+		debounce, _ := cmd.Flags().GetDuration("debounce")
+
+		w := watch.New(watch.Config{
+			Patterns: globs,
+			Debounce: debounce,
+		})
+
+		changes, err := w.Run(ctx)
+		if err != nil {
+			return fmt.Errorf("watch: %w", err)
+		}
+
+		message := strings.Join(args[1:], " ")
+		fmt.Fprintf(os.Stderr, "👀 Watching %s (Ctrl+C to stop)\n", strings.Join(globs, ", "))
+
+		audited := auditLog(cfg, "") // One log for the whole watch
+		for batch := range changes {
+			fmt.Fprintf(os.Stderr, "\n🔄 %s changed: %s\n\n", time.Now().Format("15:04:05"), strings.Join(batch, ", "))
+
+			// Fresh agent per run so the loop doesn't accumulate stale context
+			a := agent.New(agent.Config{
+				Provider:     provider,
+				Model:        modelName,
+				Skills:       skillLoader,
+				ResolveModel: cfg.ModelResolver(registry),
+				SystemPrompt: cfg.Language.AnswerInstruction(),
+				Tools:        cfg.BuildTools(),
+				Budget:       cfg.ContextBudget(),
+				Guard:        cfg.InjectionGuard(),
+				MaxCost:      cfg.Defaults.MaxCost,
+				Audit:        audited,
+				Params:       cfg.GenerationParams(),
+			})
+
+			prompt := fmt.Sprintf("%s\n\nFiles changed since the last run:\n- %s", message, strings.Join(batch, "\n- "))
+			chunks, err := a.Stream(ctx, prompt)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				continue
+			}
+			for chunk := range chunks {
+				if chunk.Error != nil {
+					fmt.Fprintf(os.Stderr, "\nError: %v\n", chunk.Error)
+					break
+				}
+				fmt.Print(chunk.Content)
+			}
+			fmt.Println()
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	watchCmd.Flags().StringSlice("glob", []string{"**/*"}, "glob pattern of files to watch (repeatable)")
+	watchCmd.Flags().Duration("debounce", watch.DefaultDebounce, "quiet period before re-running")
+
+	rootCmd.AddCommand(watchCmd)
+}
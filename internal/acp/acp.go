@@ -0,0 +1,444 @@
+// Package acp implements an editor-facing JSON-RPC 2.0 protocol over stdio,
+// modeled on the Agent Client Protocol, so editors (Zed, Neovim, VS Code)
+// can embed agentflow as their agent backend.
+//
+// Messages are newline-delimited JSON objects. The editor calls:
+//
+//	initialize            → {protocolVersion, agentInfo}
+//	session/new           {cwd?, sessionId?} → {sessionId}
+//	session/prompt        {sessionId, prompt} → {stopReason}
+//	session/cancel        {sessionId} (notification)
+//
+// While a prompt runs the agent sends session/update notifications with
+// streamed content, and, given the project's permission rules, calls
+// session/request_permission on the editor before running a tool that
+// changes things which no rule allows.
+package acp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/permission"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/agentflow/agentflow/internal/skill"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// ProtocolVersion is the protocol revision this server speaks
+const ProtocolVersion = 1
+
+// JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Stop reasons returned by session/prompt
+const (
+	StopEndTurn   = "end_turn"
+	StopCancelled = "cancelled"
+)
+
+// message is any JSON-RPC 2.0 request, notification, or response
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// Config holds server configuration
+type Config struct {
+	NewAgent    func() *agent.Agent
+	Skills      *skill.Loader
+	Sessions    *session.Manager
+	Permissions *permission.Store // Rules for tools that change things, the editor is asked about others; nil runs them all
+	Provider    string
+	Model       string
+	Version     string
+}
+
+// Server handles one editor connection
+type Server struct {
+	newAgent    func() *agent.Agent
+	skills      *skill.Loader
+	sessions    *session.Manager
+	permissions *permission.Store
+	provider    string
+	model       string
+	version     string
+
+	writeMu sync.Mutex
+	enc     *json.Encoder
+
+	mu       sync.Mutex
+	active   map[string]*acpSession
+	pending  map[string]chan message // Outbound request ID -> response
+	nextID   int
+	inflight sync.WaitGroup
+}
+
+// acpSession is a conversation opened by the editor
+type acpSession struct {
+	mu      sync.Mutex // Serializes prompts
+	agent   *agent.Agent
+	session *session.Session
+	cancel  context.CancelFunc
+}
+
+// New creates a new protocol server
+func New(cfg Config) *Server {
+	return &Server{
+		newAgent:    cfg.NewAgent,
+		skills:      cfg.Skills,
+		sessions:    cfg.Sessions,
+		permissions: cfg.Permissions,
+		provider:    cfg.Provider,
+		model:       cfg.Model,
+		version:     cfg.Version,
+		active:      make(map[string]*acpSession),
+		pending:     make(map[string]chan message),
+	}
+}
+
+// Serve reads requests from r and writes responses to w until r is closed
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.enc = json.NewEncoder(w)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // Prompts can embed whole files
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			s.write(message{Error: &rpcError{Code: CodeParseError, Message: err.Error()}})
+			continue
+		}
+
+		// Responses to our own requests (e.g. permission prompts)
+		if msg.Method == "" && msg.ID != nil {
+			s.mu.Lock()
+			ch, ok := s.pending[string(msg.ID)]
+			delete(s.pending, string(msg.ID))
+			s.mu.Unlock()
+			if ok {
+				ch <- msg
+			}
+			continue
+		}
+
+		s.inflight.Add(1)
+		go func(m message) {
+			defer s.inflight.Done()
+			s.dispatch(ctx, m)
+		}(msg)
+	}
+
+	cancel()
+	s.inflight.Wait()
+	return scanner.Err()
+}
+
+// dispatch routes a request or notification to its handler
+func (s *Server) dispatch(ctx context.Context, msg message) {
+	var result any
+	var err error
+
+	switch msg.Method {
+	case "initialize":
+		result = map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"agentInfo": map[string]string{
+				"name":    "agentflow",
+				"version": s.version,
+			},
+			"agentCapabilities": map[string]bool{
+				"loadSession": s.sessions != nil,
+			},
+		}
+	case "session/new":
+		result, err = s.newSession(msg.Params)
+	case "session/prompt":
+		result, err = s.prompt(ctx, msg.Params)
+	case "session/cancel":
+		err = s.cancel(msg.Params)
+	default:
+		err = &rpcError{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+
+	// Notifications get no response
+	if msg.ID == nil {
+		return
+	}
+
+	resp := message{ID: msg.ID, Result: result}
+	if err != nil {
+		rpcErr, ok := err.(*rpcError)
+		if !ok {
+			rpcErr = &rpcError{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp = message{ID: msg.ID, Error: rpcErr}
+	} else if result == nil {
+		resp.Result = struct{}{}
+	}
+	s.write(resp)
+}
+
+type newSessionParams struct {
+	Cwd       string `json:"cwd"`
+	SessionID string `json:"sessionId"` // Resume a saved session
+}
+
+func (s *Server) newSession(raw json.RawMessage) (any, error) {
+	var params newSessionParams
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, &rpcError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+	}
+
+	sess := &acpSession{agent: s.newAgent()}
+	if params.SessionID != "" && s.sessions != nil {
+		saved, err := s.sessions.GetByNameOrID(params.SessionID)
+		if err != nil {
+			return nil, &rpcError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		sess.session = saved
+		for _, m := range saved.Messages {
+			sess.agent.AddMessage(m.Role, m.Content)
+		}
+		sess.agent.SetActivity(saved.Activity())
+	} else {
+		sess.session = session.New(params.Cwd, s.provider, s.model)
+	}
+	if s.permissions != nil {
+		sess.agent.SetApprove(s.permissions.Gate(s.asker(sess.session.ID)))
+	}
+
+	s.mu.Lock()
+	s.active[sess.session.ID] = sess
+	s.mu.Unlock()
+
+	return map[string]string{"sessionId": sess.session.ID}, nil
+}
+
+type promptParams struct {
+	SessionID string          `json:"sessionId"`
+	Prompt    json.RawMessage `json:"prompt"`
+}
+
+// contentBlock is one part of a structured prompt
+type contentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// promptText accepts either a plain string or an array of text content blocks
+func promptText(raw json.RawMessage) (string, error) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+
+	var blocks []contentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return "", fmt.Errorf("prompt must be a string or content blocks")
+	}
+	for _, b := range blocks {
+		if b.Type == "text" {
+			if text != "" {
+				text += "\n\n"
+			}
+			text += b.Text
+		}
+	}
+	return text, nil
+}
+
+func (s *Server) prompt(ctx context.Context, raw json.RawMessage) (any, error) {
+	var params promptParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, &rpcError{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	text, err := promptText(params.Prompt)
+	if err != nil {
+		return nil, &rpcError{Code: CodeInvalidParams, Message: err.Error()}
+	}
+
+	s.mu.Lock()
+	sess, ok := s.active[params.SessionID]
+	s.mu.Unlock()
+	if !ok {
+		return nil, &rpcError{Code: CodeInvalidParams, Message: "unknown session: " + params.SessionID}
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	turnCtx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	sess.cancel = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		sess.cancel = nil
+		s.mu.Unlock()
+		cancel()
+	}()
+
+	for _, act := range sess.agent.ActivateSkills(text) {
+		s.update(params.SessionID, map[string]any{"type": "skill", "skill": act.Skill, "reason": act.Reason})
+		sess.session.RecordSkill(act.Skill, act.Reason)
+	}
+
+	chunks, err := sess.agent.Stream(turnCtx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	var streamErr error
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			streamErr = chunk.Error
+			continue // Drain so the provider goroutine can exit
+		}
+		if chunk.Content != "" {
+			s.update(params.SessionID, map[string]any{"type": "agent_message_chunk", "content": contentBlock{Type: "text", Text: chunk.Content}})
+		}
+	}
+
+	if turnCtx.Err() != nil {
+		return map[string]string{"stopReason": StopCancelled}, nil
+	}
+	if streamErr != nil {
+		return nil, streamErr
+	}
+
+	if s.sessions != nil {
+		sess.session.Messages = sess.agent.Messages()
+		sess.session.SetActivity(sess.agent.Activity())
+		sess.session.UpdatedAt = time.Now()
+		s.sessions.Save(sess.session)
+	}
+
+	return map[string]string{"stopReason": StopEndTurn}, nil
+}
+
+func (s *Server) cancel(raw json.RawMessage) error {
+	var params struct {
+		SessionID string `json:"sessionId"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return &rpcError{Code: CodeInvalidParams, Message: err.Error()}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.active[params.SessionID]; ok && sess.cancel != nil {
+		sess.cancel()
+	}
+	return nil
+}
+
+// update sends a session/update notification to the editor
+func (s *Server) update(sessionID string, update any) {
+	params, _ := json.Marshal(map[string]any{"sessionId": sessionID, "update": update})
+	s.write(message{Method: "session/update", Params: params})
+}
+
+// RequestPermission asks the editor whether a tool may run, blocking until
+// it answers or ctx is done. detail is shown to the user (command, diff...).
+func (s *Server) RequestPermission(ctx context.Context, sessionID, tool, detail string) (bool, error) {
+	s.mu.Lock()
+	s.nextID++
+	id := json.RawMessage(strconv.Quote("agentflow-" + strconv.Itoa(s.nextID)))
+	ch := make(chan message, 1)
+	s.pending[string(id)] = ch
+	s.mu.Unlock()
+
+	params, _ := json.Marshal(map[string]any{
+		"sessionId": sessionID,
+		"toolCall":  map[string]string{"tool": tool, "detail": detail},
+		"options": []map[string]string{
+			{"optionId": "allow", "kind": "allow_once", "name": "Allow"},
+			{"optionId": "deny", "kind": "reject_once", "name": "Deny"},
+		},
+	})
+	s.write(message{ID: id, Method: "session/request_permission", Params: params})
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return false, resp.Error
+		}
+		data, _ := json.Marshal(resp.Result)
+		var result struct {
+			Outcome struct {
+				Outcome  string `json:"outcome"`
+				OptionID string `json:"optionId"`
+			} `json:"outcome"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return false, fmt.Errorf("decode permission response: %w", err)
+		}
+		return result.Outcome.Outcome == "selected" && result.Outcome.OptionID == "allow", nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		delete(s.pending, string(id))
+		s.mu.Unlock()
+		return false, ctx.Err()
+	}
+}
+
+// asker puts the calls of a session's agent that no rule allows to the
+// editor, allowing each once or denying it
+func (s *Server) asker(sessionID string) permission.Asker {
+	return func(ctx context.Context, call types.ToolCall, detail string) (permission.Answer, error) {
+		allowed, err := s.RequestPermission(ctx, sessionID, call.Name, detail)
+		if err != nil {
+			return permission.Answer{}, err
+		}
+		if !allowed {
+			return permission.Answer{Choice: permission.Deny}, nil
+		}
+		return permission.Answer{Choice: permission.Once}, nil
+	}
+}
+
+// write encodes one message; stdout is shared by all goroutines
+func (s *Server) write(msg message) {
+	msg.JSONRPC = "2.0"
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.enc.Encode(msg)
+}
@@ -0,0 +1,276 @@
+package acp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/permission"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/providertest"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// client drives a Server over in-memory pipes
+type client struct {
+	t   *testing.T
+	in  *io.PipeWriter
+	out *bufio.Scanner
+	s   *Server
+}
+
+func newClient(t *testing.T, p *providertest.Provider, mgr *session.Manager) *client {
+	t.Helper()
+	return connect(t, New(Config{
+		NewAgent: func() *agent.Agent { return agent.New(agent.Config{Provider: p, Model: "test-model"}) },
+		Sessions: mgr,
+		Model:    "test-model",
+		Version:  "test",
+	}))
+}
+
+// connect serves s over in-memory pipes
+func connect(t *testing.T, s *Server) *client {
+	t.Helper()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &client{t: t, in: inW, out: bufio.NewScanner(outR), s: s}
+	go func() {
+		s.Serve(context.Background(), inR, outW)
+		outW.Close()
+	}()
+	t.Cleanup(func() { inW.Close() })
+	return c
+}
+
+func (c *client) send(v string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.in, v+"\n"); err != nil {
+		c.t.Fatalf("write: %v", err)
+	}
+}
+
+func (c *client) recv() map[string]any {
+	c.t.Helper()
+	if !c.out.Scan() {
+		c.t.Fatalf("connection closed: %v", c.out.Err())
+	}
+	var m map[string]any
+	if err := json.Unmarshal(c.out.Bytes(), &m); err != nil {
+		c.t.Fatalf("decode %q: %v", c.out.Text(), err)
+	}
+	return m
+}
+
+func (c *client) newSession() string {
+	c.t.Helper()
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"session/new","params":{"cwd":"/tmp"}}`)
+	resp := c.recv()
+	result, _ := resp["result"].(map[string]any)
+	id, _ := result["sessionId"].(string)
+	if id == "" {
+		c.t.Fatalf("session/new response = %v", resp)
+	}
+	return id
+}
+
+func TestInitialize(t *testing.T) {
+	c := newClient(t, &providertest.Provider{}, nil)
+
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	resp := c.recv()
+	if resp["jsonrpc"] != "2.0" || resp["id"] != float64(1) {
+		t.Errorf("resp = %v", resp)
+	}
+	result := resp["result"].(map[string]any)
+	if result["protocolVersion"] != float64(ProtocolVersion) {
+		t.Errorf("protocolVersion = %v", result["protocolVersion"])
+	}
+	info := result["agentInfo"].(map[string]any)
+	if info["name"] != "agentflow" || info["version"] != "test" {
+		t.Errorf("agentInfo = %v", info)
+	}
+}
+
+func TestPromptStreams(t *testing.T) {
+	mgr := session.NewManager(t.TempDir())
+	c := newClient(t, providertest.Streamed(0, "Hello", " world"), mgr)
+	id := c.newSession()
+
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"session/prompt","params":{"sessionId":"` + id + `","prompt":[{"type":"text","text":"hi"}]}}`)
+
+	var content string
+	for {
+		m := c.recv()
+		if m["method"] == "session/update" {
+			update := m["params"].(map[string]any)["update"].(map[string]any)
+			if update["type"] == "agent_message_chunk" {
+				content += update["content"].(map[string]any)["text"].(string)
+			}
+			continue
+		}
+		result := m["result"].(map[string]any)
+		if result["stopReason"] != StopEndTurn {
+			t.Errorf("stopReason = %v", result["stopReason"])
+		}
+		break
+	}
+	if content != "Hello world" {
+		t.Errorf("content = %q", content)
+	}
+
+	saved, err := mgr.Get(id)
+	if err != nil {
+		t.Fatalf("session not saved: %v", err)
+	}
+	if len(saved.Messages) != 2 {
+		t.Errorf("saved messages = %d, want 2", len(saved.Messages))
+	}
+}
+
+func TestCancel(t *testing.T) {
+	c := newClient(t, providertest.Streamed(time.Second, "a", "b", "c"), nil)
+	id := c.newSession()
+
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"session/prompt","params":{"sessionId":"` + id + `","prompt":"hi"}}`)
+	time.Sleep(50 * time.Millisecond)
+	c.send(`{"jsonrpc":"2.0","method":"session/cancel","params":{"sessionId":"` + id + `"}}`)
+
+	resp := c.recv()
+	result, _ := resp["result"].(map[string]any)
+	if result["stopReason"] != StopCancelled {
+		t.Errorf("resp = %v", resp)
+	}
+}
+
+func TestUnknownMethod(t *testing.T) {
+	c := newClient(t, &providertest.Provider{}, nil)
+
+	c.send(`{"jsonrpc":"2.0","id":7,"method":"bogus"}`)
+	resp := c.recv()
+	rpcErr, _ := resp["error"].(map[string]any)
+	if rpcErr["code"] != float64(CodeMethodNotFound) {
+		t.Errorf("resp = %v", resp)
+	}
+}
+
+func TestUnknownSession(t *testing.T) {
+	c := newClient(t, &providertest.Provider{}, nil)
+
+	c.send(`{"jsonrpc":"2.0","id":3,"method":"session/prompt","params":{"sessionId":"nope","prompt":"hi"}}`)
+	resp := c.recv()
+	rpcErr, _ := resp["error"].(map[string]any)
+	if rpcErr["code"] != float64(CodeInvalidParams) {
+		t.Errorf("resp = %v", resp)
+	}
+}
+
+func TestRequestPermission(t *testing.T) {
+	c := newClient(t, &providertest.Provider{}, nil)
+	id := c.newSession()
+
+	allowed := make(chan bool, 1)
+	go func() {
+		ok, err := c.s.RequestPermission(context.Background(), id, "bash", "rm -rf build")
+		if err != nil {
+			t.Errorf("RequestPermission: %v", err)
+		}
+		allowed <- ok
+	}()
+
+	req := c.recv()
+	if req["method"] != "session/request_permission" {
+		t.Fatalf("req = %v", req)
+	}
+	reqID, _ := json.Marshal(req["id"])
+	c.send(`{"jsonrpc":"2.0","id":` + string(reqID) + `,"result":{"outcome":{"outcome":"selected","optionId":"allow"}}}`)
+
+	select {
+	case ok := <-allowed:
+		if !ok {
+			t.Error("expected permission to be granted")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for permission")
+	}
+}
+
+func TestPromptAsksPermission(t *testing.T) {
+	perms, err := permission.Load(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	perms.Remember(permission.Rule{Tool: "write_file", Pattern: "docs/*"})
+	var written []string
+	tools := tool.NewRegistry()
+	tools.Register(&tool.Func{
+		ToolName: "write_file",
+		Params:   tool.Object(nil),
+		Changes:  true,
+		Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+			written = append(written, string(args))
+			return "written", nil
+		},
+	})
+	p := &providertest.Provider{Responses: []providertest.Response{
+		{ToolCalls: []types.ToolCall{
+			{ID: "c1", Name: "write_file", Arguments: `{"path":"docs/a.md"}`},
+			{ID: "c2", Name: "write_file", Arguments: `{"path":"main.go"}`},
+			{ID: "c3", Name: "write_file", Arguments: `{"path":"go.mod"}`},
+		}},
+		{Content: "done"},
+	}}
+	c := connect(t, New(Config{
+		NewAgent:    func() *agent.Agent { return agent.New(agent.Config{Provider: p, Model: "test-model", Tools: tools}) },
+		Permissions: perms,
+		Model:       "test-model",
+	}))
+	id := c.newSession()
+
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"session/prompt","params":{"sessionId":"` + id + `","prompt":"write them"}}`)
+	var asked []string
+	for _, option := range []string{"allow", "deny"} {
+		var req map[string]any
+		for req = c.recv(); req["method"] == "session/update"; req = c.recv() {
+		}
+		if req["method"] != "session/request_permission" {
+			t.Fatalf("req = %v", req)
+		}
+		call := req["params"].(map[string]any)["toolCall"].(map[string]any)
+		asked = append(asked, call["detail"].(string))
+		reqID, _ := json.Marshal(req["id"])
+		c.send(`{"jsonrpc":"2.0","id":` + string(reqID) + `,"result":{"outcome":{"outcome":"selected","optionId":"` + option + `"}}}`)
+	}
+	for m := c.recv(); m["result"] == nil; m = c.recv() {
+	}
+
+	// docs/a.md is allowed by the rule, main.go by the editor; go.mod is denied
+	if len(asked) != 2 || !strings.Contains(asked[0], "main.go") || !strings.Contains(asked[1], "go.mod") {
+		t.Errorf("asked = %q", asked)
+	}
+	if got := strings.Join(written, " "); got != `{"path":"docs/a.md"} {"path":"main.go"}` {
+		t.Errorf("written = %s", got)
+	}
+}
+
+func TestPromptText(t *testing.T) {
+	text, err := promptText(json.RawMessage(`"plain"`))
+	if err != nil || text != "plain" {
+		t.Errorf("promptText(string) = %q, %v", text, err)
+	}
+
+	text, err = promptText(json.RawMessage(`[{"type":"text","text":"a"},{"type":"image"},{"type":"text","text":"b"}]`))
+	if err != nil || text != "a\n\nb" {
+		t.Errorf("promptText(blocks) = %q, %v", text, err)
+	}
+
+	if _, err := promptText(json.RawMessage(`42`)); err == nil {
+		t.Error("expected error for invalid prompt")
+	}
+}
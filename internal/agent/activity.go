@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// Activity returns the tool calls run so far, oldest first, as the
+// activity feed shows them
+func (a *Agent) Activity() []types.Activity {
+	return append([]types.Activity(nil), a.activity...)
+}
+
+// SetActivity replaces the activity, as when a session is resumed
+func (a *Agent) SetActivity(activity []types.Activity) {
+	a.activity = append([]types.Activity(nil), activity...)
+}
+
+// SetOnActivity sets what is told of each tool call as it is run, from
+// the goroutine running the turn; nil tells nothing
+func (a *Agent) SetOnActivity(fn func(types.Activity)) {
+	a.onActivity = fn
+}
+
+// report records a tool call's activity
+func (a *Agent) report(act types.Activity) {
+	a.activity = append(a.activity, act)
+	if a.onActivity != nil {
+		a.onActivity(act)
+	}
+}
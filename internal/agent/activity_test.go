@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+func TestAgent_Activity(t *testing.T) {
+	tools := tool.NewRegistry()
+	tools.Register(&tool.Func{
+		ToolName: "read_file",
+		Params:   tool.Object(nil),
+		Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+			return "contents", nil
+		},
+	})
+	tools.Register(&tool.Func{
+		ToolName: "write_file",
+		Params:   tool.Object(nil),
+		Changes:  true,
+		Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+			return "", errors.New("read-only file system")
+		},
+	})
+	p := toolRounds(
+		[]types.ToolCall{{ID: "c1", Name: "read_file", Arguments: `{"path":"a.go"}`}},
+		[]types.ToolCall{{ID: "c2", Name: "read_file", Arguments: `{"path":"a.go"}`}, {ID: "c3", Name: "write_file", Arguments: `{"path":"b.go"}`}},
+	)
+	a := New(Config{Provider: p, Model: "test-model", Tools: tools})
+	var told []string
+	a.SetOnActivity(func(act types.Activity) {
+		told = append(told, act.String())
+	})
+
+	if _, err := a.Run(context.Background(), "fix b.go"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := []string{"read a.go", "read a.go (cached)", "edit b.go (failed: read-only file system)"}
+	if len(told) != len(want) {
+		t.Fatalf("told %q, want %q", told, want)
+	}
+	for i := range want {
+		if told[i] != want[i] {
+			t.Errorf("activity %d = %q, want %q", i, told[i], want[i])
+		}
+	}
+	if got := a.Activity(); len(got) != 3 || got[2].Tool != "write_file" || got[0].Time.IsZero() {
+		t.Errorf("Activity() = %+v", got)
+	}
+
+	a.SetActivity(nil)
+	if len(a.Activity()) != 0 {
+		t.Error("SetActivity(nil) kept the activity")
+	}
+}
@@ -7,21 +7,67 @@ import (
 	"strings"
 	"time"
 
+	"github.com/agentflow/agentflow/internal/artifact"
+	"github.com/agentflow/agentflow/internal/audit"
+	"github.com/agentflow/agentflow/internal/guard"
 	"github.com/agentflow/agentflow/internal/provider"
 	"github.com/agentflow/agentflow/internal/skill"
+	"github.com/agentflow/agentflow/internal/tool"
 	"github.com/agentflow/agentflow/pkg/types"
 )
 
+// Interrupted ends a response kept in history after it was cut short
+const Interrupted = "\n\n[interrupted]"
+
 // Agent represents an AI agent with context and capabilities
 type Agent struct {
-	id          string
-	provider    provider.Provider
-	model       string
-	skills      *skill.Loader
-	messages    []types.Message
-	systemPrompt string
-	metadata    map[string]string
-	createdAt   time.Time
+	id            string
+	provider      provider.Provider
+	model         string
+	skills        *skill.Loader
+	messages      []types.Message
+	systemPrompt  string
+	metadata      map[string]string
+	pending       []types.Attachment // Sent with the next user message
+	examples      []types.Example    // Few-shot exchanges, kept out of history
+	noExamples    bool
+	pinnedFiles   []string // Re-read and sent with every request
+	context       []ContextItem
+	budget        *Budgets
+	fitLimit      int    // Tokens requests are cut to after one was too long for the model
+	alternative   string // Another answer to the last message, for SwapAnswer
+	next, turn    Retry  // Set by RetryWith for the next message; used for the current one
+	params        types.GenerationParams
+	style         Style
+	plan          bool      // Plan mode; see SetPlanMode
+	planSince     time.Time // When plan mode was last turned on
+	verify        Verify
+	verifyRound   int               // Failed checks sent back this turn
+	verifyFrom    string            // Verify.Changes as the turn started, or the checks last ran
+	changed       bool              // Files changed since the last checks, by a tool or as MarkChanged says
+	toolResults   map[string]string // Calls run this turn, by tool and arguments, to their IDs
+	results       []cutResult       // Tool results cut to fit, for ExpandTool
+	artifacts     *artifact.Store   // Keeps cut results in full; see SetArtifacts
+	forcedSkill   string            // Used for every message; see UseSkill
+	skillsOff     bool
+	skillStats    *skill.Stats
+	resolveModel  func(spec string) (provider.Provider, string, bool)
+	files         fileTracker
+	tools         *tool.Registry
+	noTools       bool // The model rejected tools; stop offering them
+	guard         *guard.Guard
+	audit         *audit.Log
+	auditErr      error // First failure to write the audit log; see auditNotice
+	auditTold     bool
+	approve       func(ctx context.Context, call types.ToolCall) (types.ToolCall, error)
+	activity      []types.Activity     // Tool calls run, as the activity feed shows them
+	onActivity    func(types.Activity) // Told of each call as it runs
+	maxCost       float64              // USD; see SetMaxCost
+	spent         float64
+	charging      charge // The request being answered
+	think         bool
+	keepReasoning bool
+	createdAt     time.Time
 }
 
 // Config holds agent configuration
@@ -32,6 +78,48 @@ type Config struct {
 	Skills       *skill.Loader
 	SystemPrompt string
 	Metadata     map[string]string
+
+	// Tools are offered to the model; its calls are run and the results
+	// sent back until it answers
+	Tools *tool.Registry
+
+	// Budget limits the tokens each context source may use; nil sends
+	// everything
+	Budget *Budgets
+
+	// SkillStats, if set, records how activated skills work out
+	SkillStats *skill.Stats
+	// ResolveModel resolves the models skills ask for; nil keeps every
+	// message on the agent's model
+	ResolveModel func(spec string) (provider.Provider, string, bool)
+
+	// Think asks reasoning models to think before answering (Ollama)
+	Think bool
+	// KeepReasoning sends reasoning back to the model in history; by
+	// default only the answer is kept
+	KeepReasoning bool
+
+	// Style shapes the answers' length; see Styles
+	Style Style
+	// Params are the sampling parameters sent with every request, until
+	// changed with SetParam
+	Params types.GenerationParams
+
+	// Verify runs checks after the model says a coding task is done,
+	// sending failures back to it; see SetVerify
+	Verify Verify
+
+	// Guard screens tool results for prompt injection; nil lets them
+	// through as they are
+	Guard *guard.Guard
+	// Audit records provider calls and tool runs; nil records nothing
+	Audit *audit.Log
+	// Approve is asked before a tool that changes things runs, and returns
+	// the call to run, its arguments possibly edited, or why not; nil runs
+	// them
+	Approve func(ctx context.Context, call types.ToolCall) (types.ToolCall, error)
+	// MaxCost caps what the conversation may cost in USD; see SetMaxCost
+	MaxCost float64
 }
 
 // New creates a new agent
@@ -44,13 +132,26 @@ func New(cfg Config) *Agent {
 	}
 
 	a := &Agent{
-		id:           cfg.ID,
-		provider:     cfg.Provider,
-		model:        cfg.Model,
-		skills:       cfg.Skills,
-		systemPrompt: cfg.SystemPrompt,
-		metadata:     cfg.Metadata,
-		createdAt:    time.Now(),
+		id:            cfg.ID,
+		provider:      cfg.Provider,
+		model:         cfg.Model,
+		skills:        cfg.Skills,
+		systemPrompt:  cfg.SystemPrompt,
+		metadata:      cfg.Metadata,
+		tools:         cfg.Tools,
+		budget:        cfg.Budget,
+		skillStats:    cfg.SkillStats,
+		resolveModel:  cfg.ResolveModel,
+		createdAt:     time.Now(),
+		think:         cfg.Think,
+		keepReasoning: cfg.KeepReasoning,
+		style:         cfg.Style,
+		verify:        cfg.Verify,
+		params:        cfg.Params,
+		guard:         cfg.Guard,
+		audit:         cfg.Audit,
+		approve:       cfg.Approve,
+		maxCost:       cfg.MaxCost,
 	}
 
 	// Add system prompt if provided
@@ -74,29 +175,200 @@ func (a *Agent) Model() string {
 	return a.model
 }
 
+// SetModel switches the provider and model the conversation continues
+// with, keeping its history
+func (a *Agent) SetModel(p provider.Provider, model string) {
+	a.provider, a.model = p, model
+	a.noTools, a.fitLimit, a.alternative = false, 0, ""
+}
+
+// ModelInfo returns what is known of the model: its context window, tool
+// and vision support, and prices
+func (a *Agent) ModelInfo() provider.ModelInfo {
+	return provider.InfoFor(a.provider, a.model)
+}
+
 // AddMessage adds a message to the conversation history
 func (a *Agent) AddMessage(role, content string) {
 	a.messages = append(a.messages, types.Message{
-		Role:    role,
-		Content: content,
+		Role:      role,
+		Content:   content,
+		Timestamp: time.Now(),
 	})
 }
 
+// Attach queues an attachment (e.g. an image) for the next user message
+func (a *Agent) Attach(att types.Attachment) {
+	a.pending = append(a.pending, att)
+}
+
+// addUserMessage adds a user message carrying any pending attachments
+func (a *Agent) addUserMessage(content string) {
+	a.messages = append(a.messages, types.Message{
+		Role:        "user",
+		Content:     content,
+		Timestamp:   time.Now(),
+		Attachments: a.pending,
+	})
+	a.pending = nil
+	a.turn, a.next = a.next, Retry{}
+	if a.turn.Provider == nil {
+		a.turn.Provider, a.turn.Model, _ = a.skillModel()
+	}
+	a.startVerify()
+	a.toolResults = nil
+}
+
+// SetExamples sets the few-shot exchanges prepended to every request.
+// They are not part of the history returned by Messages.
+func (a *Agent) SetExamples(examples []types.Example) {
+	a.examples = examples
+}
+
+// Examples returns the few-shot exchanges
+func (a *Agent) Examples() []types.Example {
+	return a.examples
+}
+
+// SetExamplesEnabled toggles sending few-shot examples
+func (a *Agent) SetExamplesEnabled(enabled bool) {
+	a.noExamples = !enabled
+}
+
+// ExamplesEnabled reports whether few-shot examples are sent
+func (a *Agent) ExamplesEnabled() bool {
+	return !a.noExamples
+}
+
+// section is a named part of a request, in the order it is sent
+type section struct {
+	name      string
+	source    string // Budgeted source, or "" for sections sent in full
+	messages  []types.Message
+	limit     int // Token budget; 0 when unbudgeted
+	truncated bool
+}
+
+// sections splits a request into the leading system messages, response
+// style, plan mode, active skills, pinned files, retrieved context, git state, few-shot examples
+// and the rest of the history, each cut to its budget
+func (a *Agent) sections() []section {
+	var examples []types.Example
+	if !a.noExamples {
+		examples = a.activeExamples()
+	}
+
+	n := 0
+	for n < len(a.messages) && a.messages[n].Role == "system" {
+		n++
+	}
+
+	out := []section{{name: "System prompt", messages: a.messages[:n]}}
+	if s, ok := a.styleSection(); ok {
+		out = append(out, s)
+	}
+	if s, ok := a.planSection(); ok {
+		out = append(out, s)
+	}
+	if s, ok := a.contextSection("Skills", SourceSkill, "Follow these skill instructions:"); ok {
+		out = append(out, s)
+	}
+	if pinned, ok := a.pinnedContext(); ok {
+		out = append(out, section{name: "Pinned files", source: SourcePinned, messages: []types.Message{pinned}})
+	}
+	if s, ok := a.contextSection("Retrieved", SourceRetrieved, "Reference material:"); ok {
+		out = append(out, s)
+	}
+	if s, ok := a.contextSection("Git", SourceGit, "Repository state:"); ok {
+		out = append(out, s)
+	}
+	if len(examples) > 0 {
+		ex := section{name: "Examples"}
+		for _, e := range examples {
+			ex.messages = append(ex.messages,
+				types.Message{Role: "user", Content: e.User},
+				types.Message{Role: "assistant", Content: e.Assistant})
+		}
+		out = append(out, ex)
+	}
+	out = append(out, section{name: "History", source: SourceHistory, messages: a.messages[n:]})
+	a.activeBudget().apply(out)
+	return out
+}
+
+// requestMessages returns the history with pinned files and few-shot
+// examples inserted after the leading system messages
+func (a *Agent) requestMessages() []types.Message {
+	var msgs []types.Message
+	for _, s := range a.sections() {
+		msgs = append(msgs, s.messages...)
+	}
+	return msgs
+}
+
 // Messages returns the conversation history
 func (a *Agent) Messages() []types.Message {
 	return a.messages
 }
 
-// ClearHistory clears the conversation history (keeps system prompt)
+// LastExchange returns the latest answer and the user message it
+// answers; ok is false before the first answer
+func (a *Agent) LastExchange() (prompt, response string, ok bool) {
+	for i := len(a.messages) - 1; i >= 0; i-- {
+		m := a.messages[i]
+		if m.Role == "assistant" && m.Content != "" && response == "" {
+			response = m.Content
+		}
+		if m.Role == "user" && response != "" {
+			return m.Content, response, true
+		}
+	}
+	return "", "", false
+}
+
+// SystemPrompt returns the system prompt
+func (a *Agent) SystemPrompt() string {
+	return a.systemPrompt
+}
+
+// SetSystemPrompt replaces the system prompt in place, keeping the rest
+// of the history; empty removes it
+func (a *Agent) SetSystemPrompt(prompt string) {
+	at := -1
+	for i, msg := range a.messages {
+		if a.systemPrompt != "" && msg.Role == "system" && msg.Content == a.systemPrompt {
+			at = i
+			break
+		}
+	}
+	switch {
+	case at < 0 && prompt != "":
+		a.messages = append([]types.Message{{Role: "system", Content: prompt}}, a.messages...)
+	case at >= 0 && prompt == "":
+		a.messages = append(a.messages[:at], a.messages[at+1:]...)
+	case at >= 0:
+		a.messages[at].Content = prompt
+	}
+	a.systemPrompt = prompt
+}
+
+// ClearHistory clears the conversation history (keeps the system prompt
+// and pinned messages)
 func (a *Agent) ClearHistory() {
+	var kept []types.Message
 	if a.systemPrompt != "" {
-		a.messages = []types.Message{{
+		kept = append(kept, types.Message{
 			Role:    "system",
 			Content: a.systemPrompt,
-		}}
-	} else {
-		a.messages = nil
+		})
+	}
+	for _, msg := range a.messages {
+		if msg.Pinned {
+			kept = append(kept, msg)
+		}
 	}
+	a.messages = kept
+	a.results = nil
 }
 
 // SetMetadata sets a metadata value
@@ -111,57 +383,76 @@ func (a *Agent) GetMetadata(key string) string {
 
 // Run sends a message and gets a response
 func (a *Agent) Run(ctx context.Context, message string) (*types.CompletionResponse, error) {
-	// Add user message
-	a.AddMessage("user", message)
+	return a.run(ctx, message)
+}
 
-	// Build request
-	req := types.CompletionRequest{
-		Model:    a.model,
-		Messages: a.messages,
-	}
+// run sends a message, running any tools the model calls before it
+// answers
+func (a *Agent) run(ctx context.Context, message string) (*types.CompletionResponse, error) {
+	// Add user message
+	a.addUserMessage(message)
 
-	// Get completion
-	resp, err := a.provider.Complete(ctx, req)
-	if err != nil {
-		return nil, fmt.Errorf("completion: %w", err)
-	}
+	for round := 0; ; round++ {
+		req := a.request(round < MaxToolRounds)
 
-	// Add assistant response to history
-	a.AddMessage("assistant", resp.Content)
+		// Get completion, cutting the context once if it is too long
+		resp, err := a.complete(ctx, req)
+		if fitted, _, ok := a.fit(req, err, round < MaxToolRounds); ok {
+			req = fitted
+			resp, err = a.complete(ctx, req)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("completion: %w", err)
+		}
 
-	return resp, nil
+		// Add assistant response to history
+		a.AddMessage("assistant", a.historyContent(resp.Content, resp.Reasoning))
+		if len(resp.ToolCalls) == 0 || len(req.Tools) == 0 {
+			if _, again := a.verifyTurn(ctx); again {
+				continue
+			}
+			return resp, nil
+		}
+		a.messages[len(a.messages)-1].ToolCalls = resp.ToolCalls
+		a.runTools(ctx, resp.ToolCalls)
+	}
 }
 
-// RunWithSkill runs a message with a specific skill context
+// RunWithSkill runs a message with a skill's instructions, which stay
+// in context for the rest of the conversation
 func (a *Agent) RunWithSkill(ctx context.Context, skillName, message string) (*types.CompletionResponse, error) {
-	if a.skills == nil {
-		return a.Run(ctx, message)
+	if err := a.useSkill(skillName); err != nil {
+		return nil, err
+	}
+	return a.run(ctx, message)
+}
+
+// useSkill adds a skill's instructions to the context, where its examples
+// are sent with the agent's; without a skill loader it does nothing
+func (a *Agent) useSkill(skillName string) error {
+	if a.skills == nil || skillName == "" {
+		return nil
 	}
 
 	sk, ok := a.skills.Get(skillName)
 	if !ok {
-		return nil, fmt.Errorf("skill not found: %s", skillName)
+		return fmt.Errorf("skill not found: %s", skillName)
 	}
 
-	// Prepend skill content to message
-	enhancedMessage := fmt.Sprintf("# Skill: %s\n\n%s\n\n---\n\n%s", sk.Name, sk.Content, message)
-	return a.Run(ctx, enhancedMessage)
+	a.AddContext(SourceSkill, sk.Name, sk.Content)
+	return nil
 }
 
-// Stream sends a message and streams the response
+// Stream sends a message and streams the response. When the model calls
+// tools, a chunk carrying the calls (not Done) is sent before they run,
+// and the answer that follows streams as usual.
 func (a *Agent) Stream(ctx context.Context, message string) (<-chan types.StreamChunk, error) {
 	// Add user message
-	a.AddMessage("user", message)
-
-	// Build request
-	req := types.CompletionRequest{
-		Model:    a.model,
-		Messages: a.messages,
-		Stream:   true,
-	}
+	a.addUserMessage(message)
 
 	// Get stream
-	chunks, err := a.provider.Stream(ctx, req)
+	req := a.request(true)
+	chunks, notice, err := a.streamFitted(ctx, req, true)
 	if err != nil {
 		return nil, fmt.Errorf("stream: %w", err)
 	}
@@ -170,17 +461,34 @@ func (a *Agent) Stream(ctx context.Context, message string) (<-chan types.Stream
 	output := make(chan types.StreamChunk)
 	go func() {
 		defer close(output)
-		var fullContent strings.Builder
-		for chunk := range chunks {
-			if chunk.Error != nil {
-				output <- chunk
+		if notice != "" {
+			output <- types.StreamChunk{Notice: notice}
+		}
+		for round := 1; ; round++ {
+			calls, ok := a.collect(ctx, chunks, output, len(req.Tools) > 0)
+			if !ok {
 				return
 			}
-			fullContent.WriteString(chunk.Content)
-			output <- chunk
-			if chunk.Done {
-				// Add complete response to history
-				a.AddMessage("assistant", fullContent.String())
+			if len(calls) == 0 {
+				notice, again := a.verifyTurn(ctx)
+				if notice != "" {
+					output <- types.StreamChunk{Notice: notice}
+				}
+				if !again {
+					return
+				}
+			}
+			if notice := a.runTools(ctx, calls); notice != "" {
+				output <- types.StreamChunk{Notice: notice}
+			}
+
+			req = a.request(round < MaxToolRounds)
+			if chunks, notice, err = a.streamFitted(ctx, req, round < MaxToolRounds); err != nil {
+				output <- types.StreamChunk{Error: fmt.Errorf("stream: %w", err)}
+				return
+			}
+			if notice != "" {
+				output <- types.StreamChunk{Notice: notice}
 			}
 		}
 	}()
@@ -188,6 +496,75 @@ func (a *Agent) Stream(ctx context.Context, message string) (<-chan types.Stream
 	return output, nil
 }
 
+// collect forwards a provider stream and adds the response to history.
+// When tools were offered and the model called some, they are returned
+// and the final chunk is forwarded without Done, as the stream goes on.
+// A response cut short by cancelling ctx is kept, marked Interrupted.
+func (a *Agent) collect(ctx context.Context, chunks <-chan types.StreamChunk, output chan<- types.StreamChunk, withTools bool) ([]types.ToolCall, bool) {
+	var fullContent, reasoning strings.Builder
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			if ctx.Err() != nil && fullContent.Len() > 0 {
+				a.AddMessage("assistant", a.historyContent(fullContent.String()+Interrupted, reasoning.String()))
+			}
+			output <- chunk
+			return nil, false
+		}
+		if chunk.Replace {
+			fullContent.Reset()
+			reasoning.Reset()
+		}
+		fullContent.WriteString(chunk.Content)
+		reasoning.WriteString(chunk.Reasoning)
+		if !chunk.Done {
+			output <- chunk
+			continue
+		}
+
+		// Add complete response to history
+		a.AddMessage("assistant", a.historyContent(fullContent.String(), reasoning.String()))
+		a.alternative = chunk.Alternative
+		last := &a.messages[len(a.messages)-1]
+		if chunk.Usage != nil {
+			last.TokenCount = chunk.Usage.CompletionTokens
+		}
+		a.finishCharge(chunk.Usage, fullContent.String()+reasoning.String())
+		if !withTools || len(chunk.ToolCalls) == 0 {
+			chunk.ToolCalls = nil
+			output <- chunk
+			return nil, true
+		}
+		last.ToolCalls = chunk.ToolCalls
+		chunk.Done = false
+		output <- chunk
+		return chunk.ToolCalls, true
+	}
+	return nil, true
+}
+
+// SwapAnswer replaces the last answer with the other answer the provider
+// gave to the same message, keeping the replaced one to swap back. ok is
+// false when there is none.
+func (a *Agent) SwapAnswer() (answer string, ok bool) {
+	last := len(a.messages) - 1
+	if a.alternative == "" || last < 0 || a.messages[last].Role != "assistant" {
+		return "", false
+	}
+	answer = a.alternative
+	a.alternative = a.messages[last].Content
+	a.messages[last].Content = answer
+	return answer, true
+}
+
+// historyContent returns the assistant message to keep in history,
+// dropping reasoning unless the agent is configured to keep it
+func (a *Agent) historyContent(content, reasoning string) string {
+	if !a.keepReasoning || reasoning == "" {
+		return content
+	}
+	return "<think>" + reasoning + "</think>\n\n" + content
+}
+
 // Clone creates a new agent with the same configuration but fresh history
 func (a *Agent) Clone(newID string) *Agent {
 	if newID == "" {
@@ -195,13 +572,31 @@ func (a *Agent) Clone(newID string) *Agent {
 	}
 
 	clone := &Agent{
-		id:           newID,
-		provider:     a.provider,
-		model:        a.model,
-		skills:       a.skills,
-		systemPrompt: a.systemPrompt,
-		metadata:     make(map[string]string),
-		createdAt:    time.Now(),
+		id:            newID,
+		provider:      a.provider,
+		model:         a.model,
+		skills:        a.skills,
+		systemPrompt:  a.systemPrompt,
+		metadata:      make(map[string]string),
+		tools:         a.tools,
+		noTools:       a.noTools,
+		createdAt:     time.Now(),
+		think:         a.think,
+		keepReasoning: a.keepReasoning,
+		examples:      a.examples,
+		noExamples:    a.noExamples,
+		pinnedFiles:   append([]string(nil), a.pinnedFiles...),
+		context:       append([]ContextItem(nil), a.context...),
+		budget:        a.budget,
+		fitLimit:      a.fitLimit,
+		forcedSkill:   a.forcedSkill,
+		skillsOff:     a.skillsOff,
+		skillStats:    a.skillStats,
+		resolveModel:  a.resolveModel,
+		guard:         a.guard,
+		audit:         a.audit,
+		approve:       a.approve,
+		maxCost:       a.maxCost,
 	}
 
 	// Copy metadata
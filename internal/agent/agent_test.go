@@ -2,45 +2,28 @@ package agent
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/internal/skill"
+	"github.com/agentflow/agentflow/pkg/providertest"
 	"github.com/agentflow/agentflow/pkg/types"
 )
 
-// mockProvider implements provider.Provider for testing
-type mockProvider struct {
-	name     string
-	response string
-	err      error
-}
-
-func (m *mockProvider) Name() string { return m.name }
-func (m *mockProvider) Models() []string { return []string{"test-model"} }
-func (m *mockProvider) SupportsModel(model string) bool { return true }
-
-func (m *mockProvider) Complete(ctx context.Context, req types.CompletionRequest) (*types.CompletionResponse, error) {
-	if m.err != nil {
-		return nil, m.err
+// lastRequest returns the last request p received
+func lastRequest(p *providertest.Provider) types.CompletionRequest {
+	reqs := p.Requests()
+	if len(reqs) == 0 {
+		return types.CompletionRequest{}
 	}
-	return &types.CompletionResponse{
-		Content:      m.response,
-		Model:        req.Model,
-		FinishReason: "stop",
-		TokensUsed:   100,
-	}, nil
-}
-
-func (m *mockProvider) Stream(ctx context.Context, req types.CompletionRequest) (<-chan types.StreamChunk, error) {
-	ch := make(chan types.StreamChunk)
-	go func() {
-		ch <- types.StreamChunk{Content: m.response, Done: true}
-		close(ch)
-	}()
-	return ch, nil
+	return reqs[len(reqs)-1]
 }
 
 func TestNew(t *testing.T) {
-	p := &mockProvider{name: "test"}
+	p := providertest.New()
 	a := New(Config{
 		Provider: p,
 		Model:    "test-model",
@@ -60,7 +43,7 @@ func TestNew(t *testing.T) {
 }
 
 func TestAgent_WithSystemPrompt(t *testing.T) {
-	p := &mockProvider{name: "test"}
+	p := providertest.New()
 	a := New(Config{
 		Provider:     p,
 		Model:        "test-model",
@@ -78,7 +61,7 @@ func TestAgent_WithSystemPrompt(t *testing.T) {
 }
 
 func TestAgent_AddMessage(t *testing.T) {
-	p := &mockProvider{name: "test"}
+	p := providertest.New()
 	a := New(Config{Provider: p, Model: "test"})
 
 	a.AddMessage("user", "Hello")
@@ -95,7 +78,7 @@ func TestAgent_AddMessage(t *testing.T) {
 }
 
 func TestAgent_ClearHistory(t *testing.T) {
-	p := &mockProvider{name: "test"}
+	p := providertest.New()
 	a := New(Config{
 		Provider:     p,
 		Model:        "test",
@@ -116,8 +99,41 @@ func TestAgent_ClearHistory(t *testing.T) {
 	}
 }
 
+func TestAgent_SetSystemPrompt(t *testing.T) {
+	a := New(Config{Provider: providertest.New("ok"), Model: "test-model", SystemPrompt: "Be terse."})
+	a.AddMessage("user", "hi")
+
+	a.SetSystemPrompt("Be thorough.")
+	msgs := a.Messages()
+	if len(msgs) != 2 || msgs[0].Content != "Be thorough." || msgs[1].Content != "hi" || a.SystemPrompt() != "Be thorough." {
+		t.Errorf("messages = %+v", msgs)
+	}
+
+	a.SetSystemPrompt("")
+	if msgs := a.Messages(); len(msgs) != 1 || msgs[0].Role != "user" {
+		t.Errorf("after removing: %+v", msgs)
+	}
+	a.SetSystemPrompt("Be kind.")
+	if msgs := a.Messages(); len(msgs) != 2 || msgs[0].Role != "system" {
+		t.Errorf("after adding: %+v", msgs)
+	}
+}
+
+func TestAgent_SetModel(t *testing.T) {
+	own := providertest.New("own")
+	other := providertest.New("other")
+	a := New(Config{Provider: own, Model: "small"})
+	a.Run(context.Background(), "hello")
+
+	a.SetModel(other, "large")
+	a.Run(context.Background(), "again")
+	if a.Model() != "large" || lastRequest(other).Model != "large" || len(lastRequest(other).Messages) != 3 {
+		t.Errorf("model %s, request %+v", a.Model(), lastRequest(other))
+	}
+}
+
 func TestAgent_Run(t *testing.T) {
-	p := &mockProvider{name: "test", response: "Hello, human!"}
+	p := providertest.New("Hello, human!")
 	a := New(Config{Provider: p, Model: "test-model"})
 
 	resp, err := a.Run(context.Background(), "Hello")
@@ -143,7 +159,7 @@ func TestAgent_Run(t *testing.T) {
 }
 
 func TestAgent_Metadata(t *testing.T) {
-	p := &mockProvider{name: "test"}
+	p := providertest.New()
 	a := New(Config{
 		Provider: p,
 		Model:    "test",
@@ -161,7 +177,7 @@ func TestAgent_Metadata(t *testing.T) {
 }
 
 func TestAgent_Clone(t *testing.T) {
-	p := &mockProvider{name: "test"}
+	p := providertest.New()
 	a := New(Config{
 		ID:           "original",
 		Provider:     p,
@@ -201,7 +217,7 @@ func TestAgent_Clone(t *testing.T) {
 }
 
 func TestAgent_Stream(t *testing.T) {
-	p := &mockProvider{name: "test", response: "Streamed response"}
+	p := providertest.New("Streamed response")
 	a := New(Config{Provider: p, Model: "test"})
 
 	chunks, err := a.Stream(context.Background(), "Test message")
@@ -221,3 +237,298 @@ func TestAgent_Stream(t *testing.T) {
 		t.Errorf("content = %q", content)
 	}
 }
+
+func TestAgent_Attach(t *testing.T) {
+	p := providertest.New("A stack trace")
+	a := New(Config{Provider: p, Model: "test-model"})
+
+	a.Attach(types.Attachment{Type: "image", MimeType: "image/png", Data: []byte("png")})
+	if _, err := a.Run(context.Background(), "What's wrong?"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if _, err := a.Run(context.Background(), "And now?"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	messages := a.Messages()
+	if len(messages[0].Attachments) != 1 {
+		t.Errorf("expected attachment on first message, got %d", len(messages[0].Attachments))
+	}
+	if len(messages[2].Attachments) != 0 {
+		t.Errorf("attachment leaked into later message: %d", len(messages[2].Attachments))
+	}
+}
+
+func TestAgent_StreamReasoning(t *testing.T) {
+	p := &providertest.Provider{Responses: []providertest.Response{{Reasoning: "2+2 is 4", Content: "4"}}}
+	a := New(Config{Provider: p, Model: "test-model", Think: true})
+
+	chunks, err := a.Stream(context.Background(), "2+2?")
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	for range chunks {
+	}
+
+	if !lastRequest(p).Think {
+		t.Error("expected Think to be sent")
+	}
+	if got := a.Messages()[1].Content; got != "4" {
+		t.Errorf("history content = %q, want reasoning excluded", got)
+	}
+
+	a = New(Config{Provider: p, Model: "test-model", KeepReasoning: true})
+	chunks, _ = a.Stream(context.Background(), "2+2?")
+	for range chunks {
+	}
+	if got := a.Messages()[1].Content; got != "<think>2+2 is 4</think>\n\n4" {
+		t.Errorf("history content = %q, want reasoning kept", got)
+	}
+}
+
+// stallingProvider streams one chunk, then waits to be cancelled
+type stallingProvider struct {
+	providertest.Provider
+}
+
+func (m *stallingProvider) Stream(ctx context.Context, req types.CompletionRequest) (<-chan types.StreamChunk, error) {
+	ch := make(chan types.StreamChunk)
+	go func() {
+		defer close(ch)
+		ch <- types.StreamChunk{Content: "Half an"}
+		<-ctx.Done()
+		ch <- types.StreamChunk{Error: ctx.Err()}
+	}()
+	return ch, nil
+}
+
+func TestAgent_StreamInterrupted(t *testing.T) {
+	a := New(Config{Provider: &stallingProvider{}, Model: "test-model"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chunks, err := a.Stream(ctx, "explain")
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	<-chunks
+	cancel()
+	for range chunks {
+	}
+
+	msgs := a.Messages()
+	if len(msgs) != 2 || msgs[1].Content != "Half an"+Interrupted {
+		t.Errorf("messages = %+v, want the partial answer kept", msgs)
+	}
+}
+
+func TestAgent_Examples(t *testing.T) {
+	p := providertest.New("ok")
+	a := New(Config{Provider: p, Model: "test-model", SystemPrompt: "Be terse."})
+	a.SetExamples([]types.Example{{User: "2+2?", Assistant: "4"}})
+
+	if _, err := a.Run(context.Background(), "3+3?"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var roles []string
+	for _, m := range lastRequest(p).Messages {
+		roles = append(roles, m.Role+":"+m.Content)
+	}
+	want := []string{"system:Be terse.", "user:2+2?", "assistant:4", "user:3+3?"}
+	if strings.Join(roles, "|") != strings.Join(want, "|") {
+		t.Errorf("request messages = %v, want %v", roles, want)
+	}
+
+	// Examples are not part of the history
+	if len(a.Messages()) != 3 {
+		t.Errorf("history has %d messages, want 3", len(a.Messages()))
+	}
+
+	a.SetExamplesEnabled(false)
+	a.Run(context.Background(), "4+4?")
+	if len(lastRequest(p).Messages) != 4 {
+		t.Errorf("disabled examples still sent: %d messages", len(lastRequest(p).Messages))
+	}
+}
+
+func TestAgent_ActivateSkills(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "plan.md"), []byte("---\nname: plan\ntriggers: [plan]\npriority: 90\n---\n\nPlan first.\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "tasks.md"), []byte("---\nname: tasks\ntriggers: [plan, tasks]\n---\n\nSmall tasks.\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "big.md"), []byte("---\nname: big\ntriggers: [plan]\n---\n\n"+strings.Repeat("word ", 100)+"\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "review.md"), []byte("---\nname: review\n---\n\nReview carefully.\n"), 0644)
+	loader := skill.NewLoader([]string{dir})
+	if err := loader.Load(); err != nil {
+		t.Fatal(err)
+	}
+	a := New(Config{Provider: providertest.New(), Model: "test-model", Skills: loader, Budget: &Budgets{Skills: 20}})
+	skillNames := func() string {
+		var names []string
+		for _, item := range a.ContextItems(SourceSkill) {
+			names = append(names, item.Name)
+		}
+		return strings.Join(names, " ")
+	}
+
+	if acts := a.ActivateSkills("hello"); acts != nil {
+		t.Errorf("no trigger matches hello: %+v", acts)
+	}
+
+	// big doesn't fit in the budget after the others
+	acts := a.ActivateSkills("write a plan")
+	if len(acts) != 2 || acts[0] != (SkillActivation{Skill: "plan", Reason: `matched trigger "plan"`}) || acts[1].Skill != "tasks" {
+		t.Errorf("activations = %+v", acts)
+	}
+	if skillNames() != "plan tasks" {
+		t.Errorf("skill context = %s", skillNames())
+	}
+
+	// A new match replaces the skills; no match keeps them
+	a.ActivateSkills("list the tasks")
+	a.ActivateSkills("thanks")
+	if skillNames() != "tasks" {
+		t.Errorf("skill context = %s", skillNames())
+	}
+
+	if err := a.UseSkill("review"); err != nil {
+		t.Fatal(err)
+	}
+	if acts := a.ActivateSkills("write a plan"); len(acts) != 1 || acts[0].Skill != "review" || skillNames() != "review" {
+		t.Errorf("forced activation = %+v, context %s", acts, skillNames())
+	}
+
+	a.SkillsOff()
+	if acts := a.ActivateSkills("write a plan"); acts != nil || skillNames() != "" {
+		t.Error("skills should be off")
+	}
+	a.AutoSkills()
+	if acts := a.ActivateSkills("plan"); len(acts) != 2 {
+		t.Errorf("auto activation = %+v", acts)
+	}
+}
+
+func TestAgent_SkillStats(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "plan.md"), []byte("---\nname: plan\ntriggers: [plan]\n---\n\nPlan first.\n"), 0644)
+	loader := skill.NewLoader([]string{dir})
+	if err := loader.Load(); err != nil {
+		t.Fatal(err)
+	}
+	stats := skill.NewStats(filepath.Join(dir, "stats.json"))
+	a := New(Config{Provider: providertest.New(), Model: "test-model", Skills: loader, SkillStats: stats})
+
+	a.ActivateSkills("make a plan")
+	a.ActivateSkills("no, that's wrong")
+	a.ActivateSkills("nothing else")
+
+	usage, err := stats.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The skill stays in context, so each message pays for it
+	if u := usage["plan"]; u == nil || u.Activations != 1 || u.Retries != 1 || u.Tokens != 3*EstimateTokens("Plan first.") {
+		t.Errorf("usage = %+v", u)
+	}
+}
+
+func TestAgent_LastExchange(t *testing.T) {
+	a := New(Config{Provider: providertest.New(), Model: "test-model"})
+	if _, _, ok := a.LastExchange(); ok {
+		t.Error("no exchange yet")
+	}
+	a.AddMessage("user", "first")
+	a.AddMessage("assistant", "one")
+	a.AddMessage("user", "second")
+	a.AddMessage("assistant", "")
+	a.AddMessage("tool", "result")
+	a.AddMessage("assistant", "two")
+	a.AddMessage("user", "unanswered")
+
+	if prompt, response, ok := a.LastExchange(); !ok || prompt != "second" || response != "two" {
+		t.Errorf("LastExchange = %q, %q, %v", prompt, response, ok)
+	}
+}
+
+func TestAgent_SkillModel(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "debug.md"), []byte("---\nname: debug\ntriggers: [bug]\nmodel: strong\n---\n\nFind the cause.\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "docs.md"), []byte("---\nname: docs\ntriggers: [docs]\nmodel: nowhere/x\n---\n\nWrite docs.\n"), 0644)
+	loader := skill.NewLoader([]string{dir})
+	if err := loader.Load(); err != nil {
+		t.Fatal(err)
+	}
+	own := &providertest.Provider{ProviderName: "local", Responses: []providertest.Response{{Content: "own"}}}
+	strong := &providertest.Provider{ProviderName: "remote", Responses: []providertest.Response{{Content: "strong"}}}
+	resolve := func(spec string) (provider.Provider, string, bool) {
+		if spec == "strong" {
+			return strong, "large", true
+		}
+		return nil, "", false
+	}
+	a := New(Config{Provider: own, Model: "small", Skills: loader, ResolveModel: resolve})
+
+	acts := a.ActivateSkills("fix this bug")
+	if len(acts) != 1 || acts[0].Model != "remote/large" {
+		t.Errorf("activations = %+v", acts)
+	}
+	if resp, _ := a.Run(context.Background(), "fix this bug"); resp.Content != "strong" || lastRequest(strong).Model != "large" {
+		t.Errorf("skill's model not used: %q", resp.Content)
+	}
+	if a.Model() != "small" {
+		t.Errorf("agent model = %q, want small", a.Model())
+	}
+
+	// A model that isn't configured falls back to the agent's
+	if acts := a.ActivateSkills("update the docs"); acts[0].Model != "" {
+		t.Errorf("activations = %+v", acts)
+	}
+	if resp, _ := a.Run(context.Background(), "update the docs"); resp.Content != "own" {
+		t.Errorf("fallback went to %q", resp.Content)
+	}
+
+	// A model chosen for the message wins over the skill's
+	a.ActivateSkills("another bug")
+	a.RetryWith(Retry{Provider: own, Model: "small"})
+	if resp, _ := a.Run(context.Background(), "another bug"); resp.Content != "own" {
+		t.Errorf("retry went to %q", resp.Content)
+	}
+}
+
+func TestAgent_SkillExamples(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "sql.md"), []byte("---\nname: sql\ntriggers: [query]\nexamples:\n  - user: count users\n    assistant: SELECT count(*) FROM users;\n---\n\nWrite SQL.\n"), 0644)
+	loader := skill.NewLoader([]string{dir})
+	if err := loader.Load(); err != nil {
+		t.Fatal(err)
+	}
+	p := providertest.New()
+	a := New(Config{Provider: p, Model: "test-model", Skills: loader})
+	a.SetExamples([]types.Example{{User: "2+2?", Assistant: "4"}})
+	examples := func() string {
+		var users []string
+		for _, m := range lastRequest(p).Messages {
+			if m.Role == "user" {
+				users = append(users, m.Content)
+			}
+		}
+		return strings.Join(users, "|")
+	}
+
+	a.ActivateSkills("write a query")
+	chunks, err := a.Stream(context.Background(), "write a query")
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	for range chunks {
+	}
+	if got := examples(); got != "2+2?|count users|write a query" {
+		t.Errorf("streamed request users = %s", got)
+	}
+
+	// The skill's examples leave with it
+	a.SkillsOff()
+	a.Run(context.Background(), "thanks")
+	if got := examples(); got != "2+2?|write a query|thanks" {
+		t.Errorf("request users after SkillsOff = %s", got)
+	}
+}
@@ -0,0 +1,359 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// Context sources. Each is sent as its own section of the request and
+// can be given a share of the token budget.
+const (
+	SourceHistory   = "history"   // The conversation
+	SourceRetrieved = "retrieved" // Docs, mentioned files, project maps
+	SourcePinned    = "pinned"    // Pinned files
+	SourceGit       = "git"       // Repository state
+	SourceSkill     = "skill"     // Active skill instructions; never truncated
+)
+
+// Truncation strategies for a source over its budget
+const (
+	TruncateHead    = "head"    // Keep the beginning
+	TruncateTail    = "tail"    // Keep the end
+	TruncateSummary = "summary" // Keep an outline of what doesn't fit
+)
+
+// Budget limits one context source
+type Budget struct {
+	Share    int    `yaml:"share"`              // Percent of the room left by unbudgeted sections
+	Truncate string `yaml:"truncate,omitempty"` // head, tail or summary
+}
+
+// Budgets divides a request's token limit among context sources. The
+// system prompt, skills, examples and the new message are always sent in
+// full; sources share what is left, and a source that needs less than its
+// share leaves the rest to those that need more.
+type Budgets struct {
+	MaxTokens int
+	Sources   map[string]Budget
+	Skills    int // Tokens of skills one message may activate; DefaultSkillTokens when 0
+	// ToolResult is how many tokens of a tool result are sent before it
+	// is cut; DefaultToolResultTokens when 0, and in full when negative
+	ToolResult int
+
+	// Overflow is how the history is cut when the provider says a
+	// request is too long: a truncation strategy (summary when empty) or
+	// OverflowOff to fail instead
+	Overflow string
+}
+
+// DefaultBudgets returns the default split of maxTokens: half for the
+// conversation, the rest for retrieved context, pinned files and git
+func DefaultBudgets(maxTokens int) *Budgets {
+	return &Budgets{
+		MaxTokens: maxTokens,
+		Sources: map[string]Budget{
+			SourceHistory:   {Share: 50, Truncate: TruncateSummary},
+			SourceRetrieved: {Share: 20, Truncate: TruncateHead},
+			SourcePinned:    {Share: 20, Truncate: TruncateHead},
+			SourceGit:       {Share: 10, Truncate: TruncateTail},
+		},
+	}
+}
+
+// ContextItem is text sent with every request from a source other than
+// the history, replaced by name
+type ContextItem struct {
+	Source  string
+	Name    string
+	Content string
+}
+
+// AddContext sends content with every request under source, replacing
+// an item of the same source and name
+func (a *Agent) AddContext(source, name, content string) {
+	for i, item := range a.context {
+		if item.Source == source && item.Name == name {
+			a.context[i].Content = content
+			return
+		}
+	}
+	a.context = append(a.context, ContextItem{Source: source, Name: name, Content: content})
+}
+
+// RemoveContext stops sending an item; it reports whether it existed
+func (a *Agent) RemoveContext(source, name string) bool {
+	for i, item := range a.context {
+		if item.Source == source && item.Name == name {
+			a.context = append(a.context[:i], a.context[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ContextItems returns the items of a source, or all items when source
+// is ""
+func (a *Agent) ContextItems(source string) []ContextItem {
+	var items []ContextItem
+	for _, item := range a.context {
+		if source == "" || item.Source == source {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// skillTokens returns the skill budget
+func (b *Budgets) skillTokens() int {
+	if b == nil || b.Skills <= 0 {
+		return DefaultSkillTokens
+	}
+	return b.Skills
+}
+
+// SetBudget sets the token budgets; nil sends everything
+func (a *Agent) SetBudget(b *Budgets) {
+	a.budget = b
+}
+
+// Budget returns the token budgets, or nil
+func (a *Agent) Budget() *Budgets {
+	return a.budget
+}
+
+// contextSection renders a source's items as one system message
+func (a *Agent) contextSection(name, source, heading string) (section, bool) {
+	items := a.ContextItems(source)
+	if len(items) == 0 {
+		return section{}, false
+	}
+	var sb strings.Builder
+	sb.WriteString(heading)
+	for _, item := range items {
+		fmt.Fprintf(&sb, "\n\n## %s\n%s", item.Name, strings.TrimRight(item.Content, "\n"))
+	}
+	return section{name: name, source: source, messages: []types.Message{{Role: "system", Content: sb.String()}}}, true
+}
+
+// apply truncates the budgeted sections that exceed their share
+func (b *Budgets) apply(sections []section) {
+	if b == nil || b.MaxTokens <= 0 {
+		return
+	}
+
+	room := b.MaxTokens
+	need := make([]int, len(sections))
+	for i, s := range sections {
+		need[i] = messageTokens(s.messages)
+		if _, ok := b.Sources[s.source]; !ok {
+			room -= need[i]
+		}
+	}
+	room = max(room, 0)
+
+	// Shares of absent sources and unused parts of present ones go to
+	// the sections over their share, in proportion to their shares
+	spare, overShares := room, 0
+	for i, s := range sections {
+		budget, ok := b.Sources[s.source]
+		if !ok {
+			continue
+		}
+		sections[i].limit = room * budget.Share / 100
+		if need[i] > sections[i].limit {
+			spare -= sections[i].limit
+			overShares += budget.Share
+		} else {
+			spare -= need[i]
+		}
+	}
+	for i, s := range sections {
+		budget, ok := b.Sources[s.source]
+		if !ok || need[i] <= sections[i].limit {
+			continue
+		}
+		if overShares > 0 && spare > 0 {
+			sections[i].limit += spare * budget.Share / overShares
+		}
+		if need[i] > sections[i].limit {
+			sections[i].messages = truncate(s.messages, sections[i].limit, budget.Truncate, s.source == SourceHistory)
+			sections[i].truncated = true
+		}
+	}
+}
+
+// truncate cuts messages down to limit tokens
+func truncate(msgs []types.Message, limit int, strategy string, history bool) []types.Message {
+	if history {
+		return truncateHistory(msgs, limit, strategy)
+	}
+	// Text sections are a single message; several are cut evenly
+	out := make([]types.Message, len(msgs))
+	for i, m := range msgs {
+		m.Content = truncateText(m.Content, limit/len(msgs), strategy)
+		out[i] = m
+	}
+	return out
+}
+
+// truncateText cuts text to about limit tokens at line boundaries
+func truncateText(text string, limit int, strategy string) string {
+	if EstimateTokens(text) <= limit {
+		return text
+	}
+	maxChars := limit * 4
+
+	switch strategy {
+	case TruncateTail:
+		cut := len(text) - maxChars
+		if i := strings.IndexByte(text[cut:], '\n'); i >= 0 {
+			cut += i + 1
+		}
+		return fmt.Sprintf("[... %d earlier tokens omitted]\n", EstimateTokens(text[:cut])) + text[cut:]
+	case TruncateSummary:
+		// Unindented lines — headings, declarations, top-level keys —
+		// outline the text
+		var outline []string
+		for _, line := range strings.Split(text, "\n") {
+			if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+				outline = append(outline, line)
+			}
+		}
+		text = "[Outline; full text omitted to fit the context budget]\n" + strings.Join(outline, "\n")
+		if EstimateTokens(text) <= limit {
+			return closeFences(text)
+		}
+	}
+
+	cut := min(maxChars, len(text))
+	if i := strings.LastIndexByte(text[:cut], '\n'); i > 0 {
+		cut = i
+	}
+	return closeFences(text[:cut]) + fmt.Sprintf("\n[... %d more tokens omitted]", EstimateTokens(text[cut:]))
+}
+
+// closeFences closes a code block left open by a cut
+func closeFences(text string) string {
+	if strings.Count(text, "```")%2 == 1 {
+		return text + "\n```"
+	}
+	return text
+}
+
+// maxSummaryTopics caps the dropped user messages listed by a summary
+const maxSummaryTopics = 10
+
+// truncateHistory keeps the newest (tail, summary) or oldest (head)
+// messages that fit in limit. Pinned messages and the last message are
+// always kept, and tool results are kept only with the call they answer.
+// The summary strategy replaces the dropped messages with a note listing
+// what the user asked in them.
+func truncateHistory(msgs []types.Message, limit int, strategy string) []types.Message {
+	if len(msgs) == 0 {
+		return msgs
+	}
+
+	// A summary's note takes a fifth of the budget
+	selectLimit := limit
+	if strategy == TruncateSummary {
+		selectLimit -= limit / 5
+	}
+
+	keep := make([]bool, len(msgs))
+	used := 0
+	for i, m := range msgs {
+		if m.Pinned || i == len(msgs)-1 {
+			keep[i] = true
+			used += EstimateTokens(m.Content)
+		}
+	}
+	order := make([]int, 0, len(msgs))
+	for i := range msgs {
+		if strategy == TruncateHead {
+			order = append(order, i)
+		} else {
+			order = append(order, len(msgs)-1-i)
+		}
+	}
+	for _, i := range order {
+		if keep[i] {
+			continue
+		}
+		t := EstimateTokens(msgs[i].Content)
+		if used+t > selectLimit {
+			break
+		}
+		keep[i] = true
+		used += t
+	}
+
+	var out []types.Message
+	var dropped []string
+	noted := false
+	for i, m := range msgs {
+		// A tool result without its call, or a call without its results,
+		// is rejected by providers
+		if keep[i] && m.Role == "tool" && !callKept(msgs, keep, i) {
+			keep[i] = false
+		}
+		if !keep[i] {
+			if m.Role == "user" {
+				topic, _, _ := strings.Cut(strings.TrimSpace(m.Content), "\n")
+				if len(topic) > 80 {
+					topic = topic[:80] + "..."
+				}
+				dropped = append(dropped, topic)
+			}
+			continue
+		}
+		if strategy == TruncateSummary && !noted && i > 0 && !keep[i-1] {
+			out = append(out, types.Message{Role: "system", Content: historyNote(dropped, limit-used)})
+			noted = true
+		}
+		if len(m.ToolCalls) > 0 && (i+1 >= len(msgs) || !keep[i+1]) {
+			m.ToolCalls = nil
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// callKept reports whether the assistant message a tool result at i
+// answers is kept
+func callKept(msgs []types.Message, keep []bool, i int) bool {
+	for j := i - 1; j >= 0; j-- {
+		if msgs[j].Role != "tool" {
+			return keep[j] && len(msgs[j].ToolCalls) > 0
+		}
+	}
+	return false
+}
+
+// historyNote summarizes dropped messages by the user's latest requests
+// in them that fit in room tokens
+func historyNote(topics []string, room int) string {
+	note := "Earlier messages were omitted to fit the context budget."
+	var listed []string
+	for i := len(topics) - 1; i >= 0 && len(listed) < maxSummaryTopics; i-- {
+		candidate := append([]string{topics[i]}, listed...)
+		if EstimateTokens(note+" In them the user asked:\n- "+strings.Join(candidate, "\n- ")) > room {
+			break
+		}
+		listed = candidate
+	}
+	if len(listed) > 0 {
+		note += " In them the user asked:\n- " + strings.Join(listed, "\n- ")
+	}
+	return note
+}
+
+// messageTokens estimates the tokens in messages
+func messageTokens(msgs []types.Message) int {
+	n := 0
+	for _, m := range msgs {
+		n += EstimateTokens(m.Content)
+	}
+	return n
+}
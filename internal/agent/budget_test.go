@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/pkg/providertest"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+func TestAgent_Context(t *testing.T) {
+	a := New(Config{Provider: providertest.New(), Model: "test-model", SystemPrompt: "Be brief."})
+	a.AddContext(SourceRetrieved, "api.md", "GET /users")
+	a.AddContext(SourceGit, "status", "M main.go")
+	a.AddContext(SourceRetrieved, "api.md", "GET /v2/users")
+	a.AddMessage("user", "hi")
+
+	msgs := a.requestMessages()
+	if len(msgs) != 4 || msgs[1].Content != "Reference material:\n\n## api.md\nGET /v2/users" || msgs[2].Content != "Repository state:\n\n## status\nM main.go" {
+		t.Errorf("messages = %+v", msgs)
+	}
+
+	if !a.RemoveContext(SourceGit, "status") || a.RemoveContext(SourceGit, "status") {
+		t.Error("RemoveContext should report whether the item existed")
+	}
+	if len(a.ContextItems("")) != 1 {
+		t.Errorf("items = %+v", a.ContextItems(""))
+	}
+}
+
+func TestBudgets_Apply(t *testing.T) {
+	a := New(Config{Provider: providertest.New(), Model: "test-model", Budget: DefaultBudgets(1000)})
+	a.AddContext(SourceRetrieved, "small.md", "tiny")
+	for i := 0; i < 40; i++ {
+		a.AddMessage("user", strings.Repeat("question ", 20))
+		a.AddMessage("assistant", strings.Repeat("answer ", 20))
+	}
+	a.AddMessage("user", "latest")
+
+	sections := a.sections()
+	var history section
+	for _, s := range sections {
+		if s.source == SourceHistory {
+			history = s
+		}
+	}
+	// The retrieved section's unused share goes to the history
+	if !history.truncated || history.limit <= 500 || history.limit > 1000 {
+		t.Errorf("history limit = %d, truncated = %v", history.limit, history.truncated)
+	}
+	if got := messageTokens(history.messages); got > history.limit {
+		t.Errorf("history tokens = %d, limit %d", got, history.limit)
+	}
+	if last := history.messages[len(history.messages)-1]; last.Content != "latest" {
+		t.Errorf("last message = %q", last.Content)
+	}
+	if !strings.HasPrefix(history.messages[0].Content, "Earlier messages were omitted") {
+		t.Errorf("first message = %q", history.messages[0].Content)
+	}
+}
+
+func TestTruncateHistory(t *testing.T) {
+	msgs := []types.Message{
+		{Role: "user", Content: "pinned note", Pinned: true},
+		{Role: "user", Content: "first question"},
+		{Role: "assistant", Content: "", ToolCalls: []types.ToolCall{{ID: "1", Name: "go_package"}}},
+		{Role: "tool", Content: strings.Repeat("x", 400)},
+		{Role: "assistant", Content: "answer"},
+		{Role: "user", Content: "second question"},
+	}
+
+	// Only the last answer and question fit after the pinned note; the
+	// tool result goes with its call
+	got := truncateHistory(msgs, 15, TruncateTail)
+	var contents []string
+	for _, m := range got {
+		contents = append(contents, m.Content)
+	}
+	if strings.Join(contents, "|") != "pinned note|answer|second question" {
+		t.Errorf("tail = %q", contents)
+	}
+
+	got = truncateHistory(msgs, 60, TruncateSummary)
+	if len(got) != 4 || got[1].Role != "system" || !strings.Contains(got[1].Content, "- first question") {
+		t.Errorf("summary = %+v", got)
+	}
+
+	// Head keeps the oldest, but never a call whose results were dropped
+	got = truncateHistory(msgs, 15, TruncateHead)
+	if len(got) != 4 || got[2].Content != "" || got[2].ToolCalls != nil || got[3].Content != "second question" {
+		t.Errorf("head = %+v", got)
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	body := strings.Repeat("\treturn\n", 10)
+	text := "func A() {\n" + body + "}\n\nfunc B() {\n" + body + "}\n"
+
+	if got := truncateText(text, 100, TruncateHead); got != text {
+		t.Errorf("text under the limit changed: %q", got)
+	}
+	if got := truncateText(text, 10, TruncateHead); !strings.HasPrefix(got, "func A() {") || !strings.Contains(got, "more tokens omitted") {
+		t.Errorf("head = %q", got)
+	}
+	if got := truncateText(text, 10, TruncateTail); !strings.HasPrefix(got, "[... ") || !strings.HasSuffix(got, "}\n") {
+		t.Errorf("tail = %q", got)
+	}
+	if got := truncateText(text, 25, TruncateSummary); !strings.Contains(got, "func A() {\n}\nfunc B() {") || strings.Contains(got, "return") {
+		t.Errorf("summary = %q", got)
+	}
+	if got := truncateText("```go\n"+strings.Repeat("x := 1\n", 50)+"```", 10, TruncateHead); strings.Count(got, "```")%2 != 0 {
+		t.Errorf("fence left open: %q", got)
+	}
+}
+
+func TestAgent_ContextTooLongRecovery(t *testing.T) {
+	p := &providertest.Provider{ContextWindow: 400}
+	a := New(Config{Provider: p, Model: "small"})
+	for i := range 20 {
+		a.AddMessage("user", fmt.Sprintf("question %d %s", i, strings.Repeat("word ", 40)))
+		a.AddMessage("assistant", strings.Repeat("answer ", 40))
+	}
+
+	chunks, err := a.Stream(context.Background(), "latest question")
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var notice, content string
+	for chunk := range chunks {
+		notice += chunk.Notice
+		content += chunk.Content
+	}
+	if content != "ok" || !strings.Contains(notice, "too long for small") {
+		t.Errorf("content = %q, notice = %q", content, notice)
+	}
+
+	// The window is remembered: the next message fits the first time
+	before := p.Calls()
+	if _, err := a.Run(context.Background(), "another"); err != nil || p.Calls()-before != 1 {
+		t.Errorf("err = %v, requests = %d", err, p.Calls()-before)
+	}
+}
+
+func TestAgent_ContextTooLongOff(t *testing.T) {
+	p := &providertest.Provider{ContextWindow: 10}
+	a := New(Config{Provider: p, Model: "small", Budget: &Budgets{Overflow: OverflowOff}})
+	a.AddMessage("user", strings.Repeat("word ", 100))
+	if _, err := a.Run(context.Background(), "hi"); !errors.Is(err, provider.ErrContextTooLong) || p.Calls() != 1 {
+		t.Errorf("err = %v, requests = %d", err, p.Calls())
+	}
+}
+
+func TestAgent_KeepsWithinKnownWindow(t *testing.T) {
+	// llava has a 4096 token window; without context.max_tokens requests
+	// are cut to fit it before the provider refuses them
+	p := &providertest.Provider{ContextWindow: 4096}
+	a := New(Config{Provider: p, Model: "llava:13b"})
+	for i := range 60 {
+		a.AddMessage("user", fmt.Sprintf("question %d %s", i, strings.Repeat("word ", 40)))
+		a.AddMessage("assistant", strings.Repeat("answer ", 40))
+	}
+	if _, err := a.Run(context.Background(), "latest question"); err != nil || p.Calls() != 1 {
+		t.Errorf("err = %v, requests = %d", err, p.Calls())
+	}
+}
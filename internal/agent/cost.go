@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// ErrCostCap is returned for requests once a conversation has cost as
+// much as its cap
+var ErrCostCap = errors.New("cost cap reached")
+
+// request in flight, to charge once it is answered
+type charge struct {
+	info   provider.ModelInfo
+	prompt int // Estimated tokens, for providers that don't report usage
+}
+
+// MaxCost returns the cap on what the conversation may cost in USD, 0
+// for none
+func (a *Agent) MaxCost() float64 {
+	return a.maxCost
+}
+
+// SetMaxCost caps what the conversation may cost in USD, priced with the
+// models' known prices; past it, requests fail with ErrCostCap. 0 lifts
+// the cap.
+func (a *Agent) SetMaxCost(usd float64) {
+	a.maxCost = usd
+}
+
+// Spent returns what the conversation has cost so far in USD, as far as
+// the models' prices are known
+func (a *Agent) Spent() float64 {
+	return a.spent
+}
+
+// startCharge refuses a request once the cap is reached, and otherwise
+// notes what it will be charged at
+func (a *Agent) startCharge(p provider.Provider, req types.CompletionRequest) error {
+	if a.maxCost > 0 && a.spent >= a.maxCost {
+		return fmt.Errorf("%w: $%.2f spent of $%.2f", ErrCostCap, a.spent, a.maxCost)
+	}
+	prompt := 0
+	for _, msg := range req.Messages {
+		prompt += EstimateTokens(msg.Content)
+	}
+	a.charging = charge{info: provider.InfoFor(p, req.Model), prompt: prompt}
+	return nil
+}
+
+// finishCharge adds an answer to what was spent, with the usage the
+// provider reported, else estimated
+func (a *Agent) finishCharge(usage *types.Usage, answer string) {
+	prompt, completion := a.charging.prompt, EstimateTokens(answer)
+	if usage != nil && usage.PromptTokens+usage.CompletionTokens > 0 {
+		prompt, completion = usage.PromptTokens, usage.CompletionTokens
+	}
+	a.spent += a.charging.info.Cost(prompt, completion)
+	a.charging = charge{}
+}
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/agentflow/agentflow/pkg/providertest"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+func TestAgent_MaxCost(t *testing.T) {
+	// gpt-4o: $2.50 per million prompt tokens, $10 per million answer tokens
+	p := providertest.New()
+	p.Responses = []providertest.Response{{Content: "ok", Usage: &types.Usage{PromptTokens: 200000, CompletionTokens: 50000}}}
+	a := New(Config{Provider: p, Model: "gpt-4o", MaxCost: 1})
+
+	chunks, err := a.Stream(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	for range chunks {
+	}
+	if math.Abs(a.Spent()-1) > 1e-9 {
+		t.Fatalf("spent = %v, want 1", a.Spent())
+	}
+
+	if _, err := a.Run(context.Background(), "again"); !errors.Is(err, ErrCostCap) {
+		t.Errorf("over the cap: err = %v", err)
+	}
+	if p.Calls() != 1 {
+		t.Errorf("calls = %d; the capped request shouldn't be sent", p.Calls())
+	}
+
+	a.SetMaxCost(0)
+	if _, err := a.Run(context.Background(), "again"); err != nil {
+		t.Errorf("without a cap: %v", err)
+	}
+}
+
+func TestAgent_SpentEstimated(t *testing.T) {
+	// Without reported usage, tokens are estimated at four characters each
+	a := New(Config{Provider: providertest.New("12345678"), Model: "gpt-4o"})
+	if _, err := a.Run(context.Background(), "abcd"); err != nil {
+		t.Fatal(err)
+	}
+	want := (1*2.50 + 2*10.00) / 1e6
+	if math.Abs(a.Spent()-want) > 1e-12 {
+		t.Errorf("spent = %v, want %v", a.Spent(), want)
+	}
+}
@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fileState is what a file looked like when its contents entered context
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// fileTracker records files whose contents were injected into the
+// conversation, so stale copies can be detected. It is safe for
+// concurrent use: the UI polls it while turns are running.
+type fileTracker struct {
+	mu    sync.Mutex
+	files map[string]fileState
+}
+
+// TrackFile records that path's current contents were added to context
+// (by an @ mention or a tool); later edits on disk show up in ChangedFiles
+func (a *Agent) TrackFile(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return fmt.Errorf("track file: %w", err)
+	}
+
+	a.files.mu.Lock()
+	defer a.files.mu.Unlock()
+	if a.files.files == nil {
+		a.files.files = make(map[string]fileState)
+	}
+	a.files.files[abs] = fileState{modTime: info.ModTime(), size: info.Size()}
+	return nil
+}
+
+// TrackedFiles returns the tracked paths, sorted
+func (a *Agent) TrackedFiles() []string {
+	a.files.mu.Lock()
+	defer a.files.mu.Unlock()
+	paths := make([]string, 0, len(a.files.files))
+	for path := range a.files.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ChangedFiles returns tracked files modified or deleted on disk since
+// their contents were added to context, sorted
+func (a *Agent) ChangedFiles() []string {
+	a.files.mu.Lock()
+	defer a.files.mu.Unlock()
+
+	var changed []string
+	for path, state := range a.files.files {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(state.modTime) || info.Size() != state.size {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// RefreshFile adds the current contents of a tracked file to the
+// conversation, superseding the stale copy. A deleted file is reported
+// to the model and no longer tracked.
+func (a *Agent) RefreshFile(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(abs)
+	if os.IsNotExist(err) {
+		a.files.mu.Lock()
+		delete(a.files.files, abs)
+		a.files.mu.Unlock()
+		a.AddMessage("user", fmt.Sprintf("Note: %s has been deleted since it was added to context.", abs))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("refresh file: %w", err)
+	}
+
+	a.AddMessage("user", fmt.Sprintf("%s changed on disk; its contents are now:\n```\n%s\n```\nThis supersedes any earlier copy.",
+		abs, strings.TrimRight(string(data), "\n")))
+	return a.TrackFile(abs)
+}
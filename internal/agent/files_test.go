@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentflow/agentflow/pkg/providertest"
+)
+
+func TestAgent_ChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	gone := filepath.Join(dir, "old.go")
+	os.WriteFile(path, []byte("package main"), 0644)
+	os.WriteFile(gone, []byte("package main"), 0644)
+
+	a := New(Config{Provider: providertest.New(), Model: "m"})
+	if err := a.TrackFile(path); err != nil {
+		t.Fatalf("TrackFile() error = %v", err)
+	}
+	a.TrackFile(gone)
+	if changed := a.ChangedFiles(); len(changed) != 0 {
+		t.Fatalf("ChangedFiles() = %v, want none", changed)
+	}
+
+	os.WriteFile(path, []byte("package main\n\nfunc main() {}"), 0644)
+	os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	os.Remove(gone)
+	if changed := a.ChangedFiles(); len(changed) != 2 {
+		t.Fatalf("ChangedFiles() = %v, want both files", changed)
+	}
+
+	if err := a.RefreshFile(path); err != nil {
+		t.Fatalf("RefreshFile() error = %v", err)
+	}
+	a.RefreshFile(gone)
+
+	if changed := a.ChangedFiles(); len(changed) != 0 {
+		t.Errorf("ChangedFiles() after refresh = %v", changed)
+	}
+	if tracked := a.TrackedFiles(); len(tracked) != 1 {
+		t.Errorf("deleted file should no longer be tracked, got %v", tracked)
+	}
+
+	msgs := a.Messages()
+	if len(msgs) != 2 || !strings.Contains(msgs[0].Content, "func main() {}") || !strings.Contains(msgs[1].Content, "deleted") {
+		t.Errorf("messages = %+v", msgs)
+	}
+}
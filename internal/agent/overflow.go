@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// OverflowOff turns off cutting the context when the provider says a
+// request is too long; other Budgets.Overflow values are truncation
+// strategies for the history
+const OverflowOff = "off"
+
+// contextLimit matches the window providers name when refusing a request,
+// e.g. "maximum context length is 8192 tokens" or "context window of 32768"
+var contextLimit = regexp.MustCompile(`(?i)(?:context(?: length| window| size)?|limit)\D{0,20}?(\d{3,7})\s*tokens?`)
+
+// fitTokens returns the token limit to cut a request of used tokens to
+// after the provider refused it as too long: three quarters of the window
+// the provider named, leaving room for the answer, or half the request
+func fitTokens(err error, used int) int {
+	var apiErr *provider.APIError
+	if errors.As(err, &apiErr) {
+		if m := contextLimit.FindStringSubmatch(apiErr.Message); m != nil {
+			if window, err := strconv.Atoi(m[1]); err == nil && window*3/4 < used {
+				return window * 3 / 4
+			}
+		}
+	}
+	return used / 2
+}
+
+// activeBudget returns the budgets requests are cut to: the configured
+// ones, tightened to the limit learned from a too-long error. Without a
+// configured limit, requests are kept within the model's known context
+// window, leaving a quarter of it for the answer.
+func (a *Agent) activeBudget() *Budgets {
+	limit := a.fitLimit
+	if limit == 0 && (a.budget == nil || a.budget.MaxTokens == 0) {
+		limit = a.ModelInfo().ContextWindow * 3 / 4
+	}
+	if limit == 0 {
+		return a.budget
+	}
+	b := DefaultBudgets(limit)
+	if a.budget != nil {
+		b.Skills = a.budget.Skills
+		if len(a.budget.Sources) > 0 {
+			b.Sources = a.budget.Sources
+		}
+		if a.budget.MaxTokens > 0 {
+			b.MaxTokens = min(a.budget.MaxTokens, limit)
+		}
+		if a.budget.Overflow != "" {
+			history := b.Sources[SourceHistory]
+			history.Truncate = a.budget.Overflow
+			b.Sources = withSource(b.Sources, SourceHistory, history)
+		}
+	}
+	return b
+}
+
+// withSource returns a copy of sources with one budget replaced
+func withSource(sources map[string]Budget, source string, budget Budget) map[string]Budget {
+	out := make(map[string]Budget, len(sources)+1)
+	for k, v := range sources {
+		out[k] = v
+	}
+	out[source] = budget
+	return out
+}
+
+// streamFitted streams a request, cutting the context and retrying once
+// when it is too long; the notice says what was cut, or that the audit
+// log failed
+func (a *Agent) streamFitted(ctx context.Context, req types.CompletionRequest, withTools bool) (<-chan types.StreamChunk, string, error) {
+	chunks, err := a.stream(ctx, req)
+	fitted, notice, ok := a.fit(req, err, withTools)
+	if ok {
+		chunks, err = a.stream(ctx, fitted)
+	}
+	if failed := a.auditNotice(); failed != "" {
+		notice = strings.TrimPrefix(notice+"\n"+failed, "\n")
+	}
+	return chunks, notice, err
+}
+
+// fit cuts the next request down after the provider refused req as too
+// long, and returns it with a notice of what was cut. ok is false when
+// the policy is off or nothing more can be cut.
+func (a *Agent) fit(req types.CompletionRequest, err error, withTools bool) (types.CompletionRequest, string, bool) {
+	if !errors.Is(err, provider.ErrContextTooLong) || (a.budget != nil && a.budget.Overflow == OverflowOff) {
+		return req, "", false
+	}
+	used := messageTokens(req.Messages)
+	previous := a.fitLimit
+	a.fitLimit = fitTokens(err, used)
+
+	var cut []string
+	sections := a.sections()
+	for _, s := range sections {
+		if s.truncated {
+			cut = append(cut, strings.ToLower(s.name))
+		}
+	}
+	fitted := a.request(withTools)
+	if len(cut) == 0 || messageTokens(fitted.Messages) >= used {
+		a.fitLimit = previous
+		return req, "", false
+	}
+	return fitted, fmt.Sprintf("The conversation was too long for %s: cut %s from ~%d to ~%d tokens and retried. Use /compact or /clear to start lighter.",
+		a.model, strings.Join(cut, ", "), used, messageTokens(fitted.Messages)), true
+}
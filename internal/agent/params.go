@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// ParamNames are the parameters SetParam accepts
+var ParamNames = []string{"temperature", "max_tokens", "top_p", "seed"}
+
+// Params returns the sampling parameters sent with every request
+func (a *Agent) Params() types.GenerationParams {
+	return a.params
+}
+
+// SetParams replaces the sampling parameters, such as when a session is
+// resumed
+func (a *Agent) SetParams(p types.GenerationParams) {
+	a.params = p
+}
+
+// SetParam checks and sets one sampling parameter by name; "default"
+// goes back to the provider's default
+func (a *Agent) SetParam(name, value string) error {
+	p := a.params
+	reset := value == "default"
+	switch name {
+	case "temperature":
+		if reset {
+			p.Temperature = 0
+			break
+		}
+		t, err := strconv.ParseFloat(value, 64)
+		if err != nil || t <= 0 || t > 2 {
+			return fmt.Errorf("temperature must be above 0 and at most 2, not %s", value)
+		}
+		p.Temperature = t
+	case "max_tokens":
+		if reset {
+			p.MaxTokens = 0
+			break
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("max_tokens must be a positive number, not %s", value)
+		}
+		p.MaxTokens = n
+	case "top_p":
+		if reset {
+			p.TopP = 0
+			break
+		}
+		t, err := strconv.ParseFloat(value, 64)
+		if err != nil || t <= 0 || t > 1 {
+			return fmt.Errorf("top_p must be above 0 and at most 1, not %s", value)
+		}
+		p.TopP = t
+	case "seed":
+		if reset {
+			p.Seed = nil
+			break
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("seed must be a number from 0, not %s", value)
+		}
+		p.Seed = &n
+	default:
+		return fmt.Errorf("unknown parameter %s (one of %v)", name, ParamNames)
+	}
+	a.params = p
+	return nil
+}
@@ -0,0 +1,35 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agentflow/agentflow/pkg/providertest"
+)
+
+func TestAgent_SetParam(t *testing.T) {
+	p := providertest.New("ok")
+	a := New(Config{Provider: p, Model: "test-model"})
+
+	for name, value := range map[string]string{"temperature": "0.2", "max_tokens": "2048", "top_p": "0.9", "seed": "0"} {
+		if err := a.SetParam(name, value); err != nil {
+			t.Fatalf("SetParam(%s, %s): %v", name, value, err)
+		}
+	}
+	a.Run(context.Background(), "hi")
+	if req := lastRequest(p); req.Temperature != 0.2 || req.MaxTokens != 2048 || req.TopP != 0.9 || req.Seed == nil || *req.Seed != 0 {
+		t.Errorf("request = %+v", req)
+	}
+
+	for name, value := range map[string]string{"temperature": "3", "max_tokens": "-1", "top_p": "1.5", "seed": "-1", "top_k": "1"} {
+		if err := a.SetParam(name, value); err == nil {
+			t.Errorf("SetParam(%s, %s) accepted", name, value)
+		}
+	}
+
+	a.SetParam("temperature", "default")
+	a.SetParam("seed", "default")
+	if got := a.Params(); got.Temperature != 0 || got.MaxTokens != 2048 || got.Seed != nil {
+		t.Errorf("after reset: %+v", got)
+	}
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// PinFile keeps a file in context: its current contents are sent with
+// every request, so the model never sees a stale or summarized copy
+func (a *Agent) PinFile(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return fmt.Errorf("pin file: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("pin file: %s is a directory", path)
+	}
+
+	for _, p := range a.pinnedFiles {
+		if p == abs {
+			return nil
+		}
+	}
+	a.pinnedFiles = append(a.pinnedFiles, abs)
+	return nil
+}
+
+// UnpinFile stops sending a pinned file; it reports whether it was pinned
+func (a *Agent) UnpinFile(path string) bool {
+	abs, _ := filepath.Abs(path)
+	for i, p := range a.pinnedFiles {
+		if p == abs || p == path {
+			a.pinnedFiles = append(a.pinnedFiles[:i], a.pinnedFiles[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// PinnedFiles returns the absolute paths of pinned files
+func (a *Agent) PinnedFiles() []string {
+	return a.pinnedFiles
+}
+
+// PinMessage marks the message at index (0-based, as in Messages) as
+// pinned so it survives compaction, truncation and ClearHistory
+func (a *Agent) PinMessage(index int, pinned bool) error {
+	if index < 0 || index >= len(a.messages) {
+		return fmt.Errorf("no message %d (history has %d)", index+1, len(a.messages))
+	}
+	a.messages[index].Pinned = pinned
+	return nil
+}
+
+// PinnedMessages returns the indexes of pinned messages
+func (a *Agent) PinnedMessages() []int {
+	var idx []int
+	for i, msg := range a.messages {
+		if msg.Pinned {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// pinnedContext returns a system message holding the current contents of
+// the pinned files, or false when nothing is pinned
+func (a *Agent) pinnedContext() (types.Message, bool) {
+	if len(a.pinnedFiles) == 0 {
+		return types.Message{}, false
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Pinned files (always current):")
+	for _, path := range a.pinnedFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(&sb, "\n\n## %s\n(unreadable: %v)", path, err)
+			continue
+		}
+		fmt.Fprintf(&sb, "\n\n## %s\n```\n%s\n```", path, strings.TrimRight(string(data), "\n"))
+	}
+	return types.Message{Role: "system", Content: sb.String()}, true
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentflow/agentflow/pkg/providertest"
+)
+
+func TestAgent_PinFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.md")
+	os.WriteFile(path, []byte("v1"), 0644)
+
+	a := New(Config{Provider: providertest.New(), Model: "m", SystemPrompt: "sys"})
+	if err := a.PinFile(path); err != nil {
+		t.Fatalf("PinFile() error = %v", err)
+	}
+	if err := a.PinFile(path); err != nil || len(a.PinnedFiles()) != 1 {
+		t.Errorf("pinning twice should be a no-op, got %v", a.PinnedFiles())
+	}
+	a.AddMessage("user", "hi")
+
+	// Contents are read at request time, after the system prompt
+	os.WriteFile(path, []byte("v2"), 0644)
+	msgs := a.requestMessages()
+	if len(msgs) != 3 || msgs[1].Role != "system" || !strings.Contains(msgs[1].Content, "v2") {
+		t.Fatalf("request messages = %+v", msgs)
+	}
+	if len(a.Messages()) != 2 {
+		t.Error("pinned files should not be added to history")
+	}
+
+	if !a.UnpinFile(path) || len(a.requestMessages()) != 2 {
+		t.Error("UnpinFile() should stop sending the file")
+	}
+	if err := a.PinFile(filepath.Dir(path)); err == nil {
+		t.Error("expected error pinning a directory")
+	}
+}
+
+func TestAgent_PinMessage(t *testing.T) {
+	a := New(Config{Provider: providertest.New(), Model: "m"})
+	a.AddMessage("user", "the spec")
+	a.AddMessage("user", "chatter")
+
+	if err := a.PinMessage(0, true); err != nil {
+		t.Fatalf("PinMessage() error = %v", err)
+	}
+	if err := a.PinMessage(5, true); err == nil {
+		t.Error("expected error for out-of-range message")
+	}
+	if got := a.PinnedMessages(); len(got) != 1 || got[0] != 0 {
+		t.Errorf("PinnedMessages() = %v", got)
+	}
+
+	a.ClearHistory()
+	if msgs := a.Messages(); len(msgs) != 1 || msgs[0].Content != "the spec" {
+		t.Errorf("ClearHistory() should keep pinned messages, got %+v", msgs)
+	}
+}
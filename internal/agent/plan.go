@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/artifact"
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// PlanArtifact is the artifact plan mode asks the model to save its
+// plan as
+const PlanArtifact = "plan.md"
+
+// planInstruction is sent after the system prompt in plan mode
+const planInstruction = `You are in plan mode: explore and think, but change nothing. Only read-only tools are available; don't write code meant to be applied yet.
+
+Finish with a plan, saved with save_artifact as ` + PlanArtifact + ` when that tool is available and written out in your answer otherwise, with these sections:
+## Goal
+## Findings (what you learned, with file:line references)
+## Steps (numbered; each names the files it changes and how)
+## Risks
+## Verification (the commands or checks that show it worked)
+
+The user reviews the plan and runs /execute to have you carry it out.`
+
+// PlanMode reports whether the agent is restricted to planning
+func (a *Agent) PlanMode() bool {
+	return a.plan
+}
+
+// PlanSince returns when plan mode was last turned on
+func (a *Agent) PlanSince() time.Time {
+	return a.planSince
+}
+
+// SetPlanMode turns plan mode on or off. In plan mode only tools that
+// don't change anything, and save_artifact for the PlanArtifact, are
+// offered, calls to others are refused, and the model is asked for a
+// structured plan instead of changes.
+func (a *Agent) SetPlanMode(on bool) {
+	if on && !a.plan {
+		a.planSince = time.Now()
+	}
+	a.plan = on
+}
+
+// ExecutePlan ends plan mode and returns the message handing the plan
+// over for execution: the PlanArtifact saved under root since plan mode
+// began, or else the last answer
+func (a *Agent) ExecutePlan(root string) (string, error) {
+	if !a.plan {
+		return "", errors.New("not in plan mode; /plan starts one")
+	}
+	plan, ok := artifact.Since(root, PlanArtifact, a.planSince)
+	if !ok {
+		var found bool
+		if _, plan, found = a.LastExchange(); !found || plan == "" {
+			return "", errors.New("no plan yet; ask for one first")
+		}
+	}
+	a.plan = false
+	return fmt.Sprintf("The plan below is approved. Carry it out step by step, now with every tool available, and check the result as its Verification section says. Stop and ask if a step turns out to be wrong.\n\n%s", plan), nil
+}
+
+// planSection returns the plan mode instruction, or false outside plan
+// mode
+func (a *Agent) planSection() (section, bool) {
+	if !a.plan {
+		return section{}, false
+	}
+	return section{name: "Plan mode", messages: []types.Message{{Role: "system", Content: planInstruction}}}, true
+}
+
+// activeTools returns the tools the model may call now, or nil
+func (a *Agent) activeTools() *tool.Registry {
+	if a.tools == nil || !a.plan {
+		return a.tools
+	}
+	tools := a.tools.ReadOnly()
+	if save, ok := a.tools.Get(artifact.ToolName); ok {
+		tools.Register(save)
+	}
+	return tools
+}
+
+// callTool runs one tool call, answering ExpandTool itself, refusing
+// tools that change things in plan mode, but for saving the plan, and
+// asking for approval of them otherwise
+func (a *Agent) callTool(ctx context.Context, call types.ToolCall) types.Message {
+	if call.Name == ExpandTool && len(a.results) > 0 {
+		return a.expandResult(call)
+	}
+	if t, ok := a.tools.Get(call.Name); ok && a.plan && tool.Writes(t) && !artifact.Saves(call, PlanArtifact) {
+		content := fmt.Sprintf("error: %s changes things and is not available in plan mode; describe the change in the plan instead", call.Name)
+		if call.Name == artifact.ToolName {
+			content = fmt.Sprintf("error: in plan mode %s only saves the plan, as %s", call.Name, PlanArtifact)
+		}
+		return types.Message{
+			Role:       "tool",
+			Name:       call.Name,
+			ToolCallID: call.ID,
+			Content:    content,
+			Timestamp:  time.Now(),
+		}
+	}
+	if t, ok := a.tools.Get(call.Name); ok && tool.Writes(t) && a.approve != nil {
+		approved, err := a.approve(ctx, call)
+		if err != nil {
+			return types.Message{
+				Role:       "tool",
+				Name:       call.Name,
+				ToolCallID: call.ID,
+				Content:    fmt.Sprintf("error: not run: %v", err),
+				Timestamp:  time.Now(),
+			}
+		}
+		call.Arguments = approved.Arguments
+	}
+	if t, ok := a.tools.Get(call.Name); ok && tool.Writes(t) && !a.plan {
+		a.changed = true
+	}
+	return a.tools.Call(ctx, call)
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/agentflow/agentflow/internal/artifact"
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/providertest"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+func TestAgent_PlanMode(t *testing.T) {
+	tools := addTools()
+	tools.Register(&tool.Func{ToolName: "write", Params: tool.Object(nil), Changes: true})
+	p := addProvider()
+	a := New(Config{Provider: p, Model: "test-model", Tools: tools})
+	a.SetPlanMode(true)
+
+	if _, err := a.Run(context.Background(), "what is 2+3?"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	req := p.Requests()[0]
+	if len(req.Tools) != 1 || req.Tools[0].Name != "add" {
+		t.Errorf("plan mode offered %+v", req.Tools)
+	}
+	if !strings.Contains(req.Messages[0].Content, "plan mode") {
+		t.Errorf("first message = %q", req.Messages[0].Content)
+	}
+
+	msg := a.callTool(context.Background(), types.ToolCall{ID: "call_2", Name: "write"})
+	if !strings.Contains(msg.Content, "not available in plan mode") || msg.ToolCallID != "call_2" {
+		t.Errorf("write in plan mode = %+v", msg)
+	}
+}
+
+func TestAgent_ExecutePlan(t *testing.T) {
+	root := t.TempDir()
+	a := New(Config{Provider: providertest.New("1. Do it"), Model: "test-model"})
+	if _, err := a.ExecutePlan(root); err == nil {
+		t.Error("expected an error outside plan mode")
+	}
+
+	a.SetPlanMode(true)
+	if _, err := a.ExecutePlan(root); err == nil {
+		t.Error("expected an error before any plan")
+	}
+
+	// Without a saved plan, the last answer is the plan
+	a.Run(context.Background(), "plan it")
+	prompt, err := a.ExecutePlan(root)
+	if err != nil || !strings.HasSuffix(prompt, "\n\n1. Do it") || a.PlanMode() {
+		t.Errorf("ExecutePlan = %q, %v, plan mode %v", prompt, err, a.PlanMode())
+	}
+
+	a.SetPlanMode(true)
+	artifact.Open(root, "s1").Save(PlanArtifact, "", "## Steps\n1. Saved")
+	if prompt, _ := a.ExecutePlan(root); !strings.HasSuffix(prompt, "1. Saved") {
+		t.Errorf("ExecutePlan = %q", prompt)
+	}
+}
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// EstimateTokens approximates the tokens in text at four characters per
+// token, close enough for English and code to compare prompt sections
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// PreviewSection is one part of a previewed request
+type PreviewSection struct {
+	Name      string
+	Messages  []types.Message
+	Tokens    int
+	Limit     int  // Token budget; 0 when sent in full
+	Truncated bool // Cut to fit the budget
+}
+
+// Preview is the request a message would send, split into sections
+type Preview struct {
+	Request  types.CompletionRequest
+	Sections []PreviewSection
+	Tokens   int
+}
+
+// Preview assembles the request that sending message (with skillName,
+// when set) would make, without calling the provider or changing history
+func (a *Agent) Preview(skillName, message string) (*Preview, error) {
+	savedMessages, savedContext := a.messages, a.context
+	a.context = append([]ContextItem(nil), a.context...)
+	defer func() { a.messages, a.context = savedMessages, savedContext }()
+
+	if err := a.useSkill(skillName); err != nil {
+		return nil, err
+	}
+	hasMessage := message != "" || len(a.pending) > 0
+	if hasMessage {
+		// Capacity is capped so appending never writes into the history
+		a.messages = append(a.messages[:len(a.messages):len(a.messages)],
+			types.Message{Role: "user", Content: message, Attachments: a.pending})
+	}
+
+	req := a.request(true)
+	p := &Preview{Request: req}
+	for _, s := range a.sections() {
+		if hasMessage && s.source == SourceHistory {
+			// The new message is shown on its own, after the history
+			last := len(s.messages) - 1
+			p.add(s.name, s.messages[:last], s.limit, s.truncated)
+			p.add("Message", s.messages[last:], 0, false)
+			continue
+		}
+		p.add(s.name, s.messages, s.limit, s.truncated)
+	}
+	if len(req.Tools) > 0 {
+		data, _ := json.Marshal(req.Tools)
+		p.Sections = append(p.Sections, PreviewSection{Name: fmt.Sprintf("Tools (%d)", len(req.Tools)), Tokens: EstimateTokens(string(data))})
+		p.Tokens += EstimateTokens(string(data))
+	}
+	return p, nil
+}
+
+// add appends a non-empty section, counting its tokens
+func (p *Preview) add(name string, msgs []types.Message, limit int, truncated bool) {
+	if len(msgs) == 0 {
+		return
+	}
+	s := PreviewSection{Name: name, Messages: msgs, Tokens: messageTokens(msgs), Limit: limit, Truncated: truncated}
+	p.Sections = append(p.Sections, s)
+	p.Tokens += s.Tokens
+}
+
+// Summary lists the sections with their estimated token counts
+func (p *Preview) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Request to %s: ~%d tokens\n", p.Request.Model, p.Tokens)
+	for _, s := range p.Sections {
+		pct := 0
+		if p.Tokens > 0 {
+			pct = s.Tokens * 100 / p.Tokens
+		}
+		fmt.Fprintf(&sb, "  %-16s %7d tokens %3d%%", s.Name, s.Tokens, pct)
+		if len(s.Messages) > 1 {
+			fmt.Fprintf(&sb, "  (%d messages)", len(s.Messages))
+		}
+		if s.Limit > 0 {
+			fmt.Fprintf(&sb, "  budget %d", s.Limit)
+		}
+		if s.Truncated {
+			sb.WriteString(", truncated")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// String renders the summary followed by every message as it will be sent
+func (p *Preview) String() string {
+	var sb strings.Builder
+	sb.WriteString(p.Summary())
+	for _, s := range p.Sections {
+		for _, m := range s.Messages {
+			fmt.Fprintf(&sb, "\n── %s · %s ──\n%s\n", s.Name, m.Role, m.Content)
+			for _, att := range m.Attachments {
+				fmt.Fprintf(&sb, "[%s attachment: %s, %d bytes]\n", att.Type, att.MimeType, len(att.Data))
+			}
+			for _, call := range m.ToolCalls {
+				fmt.Fprintf(&sb, "[tool call: %s %s]\n", call.Name, call.Arguments)
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentflow/agentflow/pkg/providertest"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+func TestAgent_Preview(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	os.WriteFile(path, []byte("remember the milk"), 0644)
+
+	a := New(Config{Provider: providertest.New(), Model: "test-model", SystemPrompt: "Be brief."})
+	a.PinFile(path)
+	a.SetExamples([]types.Example{{User: "hi", Assistant: "hello"}})
+	a.AddMessage("user", "earlier question")
+	a.AddMessage("assistant", "earlier answer")
+
+	p, err := a.Preview("", "what's on the list?")
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+
+	var names []string
+	total := 0
+	for _, s := range p.Sections {
+		names = append(names, s.Name)
+		total += s.Tokens
+	}
+	if got := strings.Join(names, ","); got != "System prompt,Pinned files,Examples,History,Message" {
+		t.Errorf("sections = %s", got)
+	}
+	if total != p.Tokens || p.Tokens == 0 {
+		t.Errorf("tokens = %d, sections sum to %d", p.Tokens, total)
+	}
+
+	msgs := p.Request.Messages
+	if len(msgs) != 7 || msgs[len(msgs)-1].Content != "what's on the list?" {
+		t.Errorf("request messages = %+v", msgs)
+	}
+	if len(a.Messages()) != 3 {
+		t.Errorf("preview changed history: %d messages", len(a.Messages()))
+	}
+
+	out := p.String()
+	for _, want := range []string{"Request to test-model", "remember the milk", "── Message · user ──"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("preview missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens(""); got != 0 {
+		t.Errorf("EstimateTokens(\"\") = %d", got)
+	}
+	if got := EstimateTokens("12345"); got != 2 {
+		t.Errorf("EstimateTokens(5 chars) = %d, want 2", got)
+	}
+}
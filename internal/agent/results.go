@@ -0,0 +1,179 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/artifact"
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// DefaultToolResultTokens is how many tokens of a tool result are sent
+// when the budget doesn't say; longer results are cut, and the model
+// reads the rest with ExpandTool
+const DefaultToolResultTokens = 4000
+
+// ExpandTool is the tool offered once a tool result was cut, which
+// returns more of it
+const ExpandTool = "expand_result"
+
+// toolResultTokens returns the tool result budget; negative sends results
+// in full
+func (b *Budgets) toolResultTokens() int {
+	if b == nil || b.ToolResult == 0 {
+		return DefaultToolResultTokens
+	}
+	return b.ToolResult
+}
+
+// cutResult is a tool result cut to fit, kept in full: in the artifact
+// at path, or in text when there is no artifact store
+type cutResult struct {
+	path string
+	text string
+}
+
+// content returns the whole result
+func (r cutResult) content() (string, error) {
+	if r.path == "" {
+		return r.text, nil
+	}
+	data, err := os.ReadFile(r.path)
+	return string(data), err
+}
+
+// SetArtifacts has the agent keep its artifacts in store, as when a
+// session starts: the model is offered save_artifact when it has tools,
+// and tool results cut to fit are kept there in full instead of in memory
+func (a *Agent) SetArtifacts(store *artifact.Store) {
+	a.artifacts = store
+	if a.tools != nil {
+		a.tools.Register(store.Tool())
+	}
+}
+
+// truncateResult cuts a tool result over the budget to the lines that fit,
+// keeping it in full for ExpandTool, and says how to read the rest
+func (a *Agent) truncateResult(content string) string {
+	limit := a.budget.toolResultTokens()
+	if limit < 0 || EstimateTokens(content) <= limit {
+		return content
+	}
+	id := fmt.Sprintf("r%d", len(a.results)+1)
+	kept := cutResult{text: content}
+	if a.artifacts != nil {
+		if saved, err := a.artifacts.Save("tool-result-"+id+".txt", "A tool result cut to fit, in full", content); err == nil {
+			kept = cutResult{path: saved.Path}
+		}
+	}
+	a.results = append(a.results, kept)
+	lines := splitLines(content)
+	shown, n := fitLines(lines, limit)
+	return shown + fmt.Sprintf("\n[agentflow: result %s cut to fit, lines 1-%d of %d shown. Call %s with id %q and lines \"%d-\" for more.]", id, n, len(lines), ExpandTool, id, n+1)
+}
+
+// expandResult answers a call to ExpandTool with the lines it asks for of
+// a result cut earlier, as many as fit in the budget
+func (a *Agent) expandResult(call types.ToolCall) types.Message {
+	msg := types.Message{Role: "tool", Name: call.Name, ToolCallID: call.ID, Timestamp: time.Now()}
+	var in struct {
+		ID    string `json:"id"`
+		Lines string `json:"lines"`
+	}
+	if err := json.Unmarshal([]byte(call.Arguments), &in); err != nil {
+		msg.Content = "error: invalid arguments: " + err.Error()
+		return msg
+	}
+	i, err := strconv.Atoi(strings.TrimPrefix(in.ID, "r"))
+	if err != nil || i < 1 || i > len(a.results) {
+		msg.Content = fmt.Sprintf("error: no result %q; only results cut in this conversation can be expanded", in.ID)
+		return msg
+	}
+	content, err := a.results[i-1].content()
+	if err != nil {
+		msg.Content = fmt.Sprintf("error: result %s is gone: %v", in.ID, err)
+		return msg
+	}
+	lines := splitLines(content)
+	from, to, err := parseLines(in.Lines, len(lines))
+	if err != nil {
+		msg.Content = "error: " + err.Error()
+		return msg
+	}
+
+	limit := a.budget.toolResultTokens()
+	if limit < 0 {
+		limit = EstimateTokens(content)
+	}
+	shown, n := fitLines(lines[from-1:to], limit)
+	msg.Content = shown
+	if last := from + n - 1; last < len(lines) {
+		msg.Content += fmt.Sprintf("\n[agentflow: lines %d-%d of %d shown; ask for \"%d-\" for more.]", from, last, len(lines), last+1)
+	}
+	return msg
+}
+
+// expandDefinition describes ExpandTool for a completion request
+func expandDefinition() types.ToolDefinition {
+	return types.ToolDefinition{
+		Name:        ExpandTool,
+		Description: "Read more of a tool result that was cut to fit, by the id its note gives, as a range of lines.",
+		Parameters: tool.Object(map[string]any{
+			"id":    tool.String("The id of the cut result, e.g. r1"),
+			"lines": tool.String(`Lines to read, from-to (e.g. 120-300) or from- to read as far as fits`),
+		}, "id", "lines"),
+	}
+}
+
+// splitLines splits text into lines, each keeping its newline
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// fitLines returns the leading lines that fit in limit tokens, and how
+// many there are; a first line too long to fit on its own is cut
+func fitLines(lines []string, limit int) (string, int) {
+	var sb strings.Builder
+	n := 0
+	for _, line := range lines {
+		if (sb.Len()+len(line)+3)/4 > limit {
+			break
+		}
+		sb.WriteString(line)
+		n++
+	}
+	if n == 0 && len(lines) > 0 {
+		return lines[0][:min(len(lines[0]), limit*4)] + "...\n", 1
+	}
+	return sb.String(), n
+}
+
+// parseLines reads a 1-based line range, from-to or from-, within total
+// lines
+func parseLines(spec string, total int) (from, to int, err error) {
+	start, end, _ := strings.Cut(strings.TrimSpace(spec), "-")
+	from, to = 1, total
+	if start != "" {
+		if from, err = strconv.Atoi(start); err != nil {
+			return 0, 0, fmt.Errorf("invalid line range %q", spec)
+		}
+	}
+	if end != "" {
+		if to, err = strconv.Atoi(end); err != nil {
+			return 0, 0, fmt.Errorf("invalid line range %q", spec)
+		}
+	}
+	if from < 1 || from > total || to < from {
+		return 0, 0, fmt.Errorf("line range %q is outside the result's %d lines", spec, total)
+	}
+	return from, min(to, total), nil
+}
@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/agentflow/agentflow/internal/artifact"
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/providertest"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+func TestAgent_TruncatesToolResults(t *testing.T) {
+	var file strings.Builder
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&file, "line %03d\n", i) // 9 bytes
+	}
+	tools := tool.NewRegistry()
+	tools.Register(&tool.Func{
+		ToolName: "read_file",
+		Params:   tool.Object(nil),
+		Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+			return file.String(), nil
+		},
+	})
+	p := toolRounds(
+		[]types.ToolCall{{ID: "c1", Name: "read_file", Arguments: `{}`}},
+		[]types.ToolCall{{ID: "c2", Name: ExpandTool, Arguments: `{"id":"r1","lines":"91-"}`}},
+		[]types.ToolCall{{ID: "c3", Name: ExpandTool, Arguments: `{"id":"r1","lines":"95-200"}`}},
+		[]types.ToolCall{{ID: "c4", Name: ExpandTool, Arguments: `{"id":"r9","lines":"1-"}`}},
+	)
+	// 20 tokens hold 8 lines
+	a := New(Config{Provider: p, Model: "test-model", Tools: tools, Budget: &Budgets{ToolResult: 20}})
+	if _, err := a.Run(context.Background(), "read it"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var results []string
+	for _, m := range a.Messages() {
+		if m.Role == "tool" {
+			results = append(results, m.Content)
+		}
+	}
+	if len(results) != 4 {
+		t.Fatalf("results = %q", results)
+	}
+	if !strings.HasPrefix(results[0], "line 001\n") || !strings.Contains(results[0], "line 008\n\n[agentflow: result r1 cut to fit, lines 1-8 of 100 shown") || strings.Contains(results[0], "line 009") {
+		t.Errorf("cut result = %q", results[0])
+	}
+	if !strings.HasPrefix(results[1], "line 091\n") || !strings.HasSuffix(results[1], `lines 91-98 of 100 shown; ask for "99-" for more.]`) {
+		t.Errorf("expanded = %q", results[1])
+	}
+	if results[2] != "line 095\nline 096\nline 097\nline 098\nline 099\nline 100\n" {
+		t.Errorf("expanded to the end = %q", results[2])
+	}
+	if !strings.HasPrefix(results[3], "error: no result") {
+		t.Errorf("unknown id = %q", results[3])
+	}
+}
+
+func TestAgent_ExpandOffered(t *testing.T) {
+	a := New(Config{Provider: providertest.New(), Model: "test-model", Tools: addTools()})
+	hasExpand := func() bool {
+		for _, def := range a.request(true).Tools {
+			if def.Name == ExpandTool {
+				return true
+			}
+		}
+		return false
+	}
+	if hasExpand() {
+		t.Error("expand_result offered before any result was cut")
+	}
+	a.truncateResult(strings.Repeat("x\n", 10000))
+	if !hasExpand() {
+		t.Error("expand_result not offered")
+	}
+}
+
+func TestAgent_CutResultsAsArtifacts(t *testing.T) {
+	root := t.TempDir()
+	a := New(Config{Provider: providertest.New(), Model: "test-model", Tools: addTools(), Budget: &Budgets{ToolResult: 20}})
+	a.SetArtifacts(artifact.Open(root, "s1"))
+	if _, ok := a.Tools().Get(artifact.ToolName); !ok {
+		t.Error("save_artifact not offered")
+	}
+
+	full := strings.Repeat("a line\n", 100)
+	a.truncateResult(full)
+	saved, err := artifact.Find(root, "s1/tool-result-r1.txt")
+	if err != nil {
+		t.Fatalf("cut result not saved: %v", err)
+	}
+	if data, _ := os.ReadFile(saved.Path); string(data) != full || a.results[0].text != "" {
+		t.Errorf("artifact holds %d bytes, memory %d", len(data), len(a.results[0].text))
+	}
+	expand := types.ToolCall{ID: "c1", Name: ExpandTool, Arguments: `{"id":"r1","lines":"100-"}`}
+	if got := a.expandResult(expand).Content; got != "a line\n" {
+		t.Errorf("expanded = %q", got)
+	}
+
+	// Clearing the conversation forgets its results
+	a.ClearHistory()
+	if got := a.expandResult(expand).Content; !strings.HasPrefix(got, "error: no result") {
+		t.Errorf("expanded after clearing = %q", got)
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	tests := []struct {
+		spec     string
+		from, to int
+		ok       bool
+	}{
+		{"10-20", 10, 20, true},
+		{"10-", 10, 50, true},
+		{"40-80", 40, 50, true},
+		{"-5", 1, 5, true},
+		{"0-5", 0, 0, false},
+		{"60-", 0, 0, false},
+		{"20-10", 0, 0, false},
+		{"a-b", 0, 0, false},
+	}
+	for _, tt := range tests {
+		from, to, err := parseLines(tt.spec, 50)
+		if from != tt.from || to != tt.to || (err == nil) != tt.ok {
+			t.Errorf("parseLines(%q) = %d, %d, %v", tt.spec, from, to, err)
+		}
+	}
+}
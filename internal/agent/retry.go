@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/agentflow/agentflow/internal/provider"
+)
+
+// Retry changes what the next message is sent with
+type Retry struct {
+	Provider    provider.Provider // nil keeps the agent's
+	Model       string            // Empty keeps the agent's
+	Temperature float64           // 0 keeps the agent's
+}
+
+// ParseRetry reads the arguments of /retry: a "provider/model" spec
+// resolved with resolve, a temperature, or both, in any order
+func ParseRetry(args []string, resolve func(spec string) (provider.Provider, string, bool)) (Retry, error) {
+	var r Retry
+	for _, arg := range args {
+		if t, err := strconv.ParseFloat(arg, 64); err == nil {
+			if t < 0 || t > 2 {
+				return Retry{}, fmt.Errorf("temperature %s is not between 0 and 2", arg)
+			}
+			r.Temperature = t
+			continue
+		}
+		p, model, ok := resolve(arg)
+		if !ok {
+			return Retry{}, fmt.Errorf("unknown model: %s", arg)
+		}
+		r.Provider, r.Model = p, model
+	}
+	return r, nil
+}
+
+// Rewind removes the last exchange: the latest user message and the
+// answers and tool results that followed it. It returns that message,
+// whose attachments are queued again, for Run or Stream to send anew;
+// ok is false before the first message.
+func (a *Agent) Rewind() (message string, ok bool) {
+	for i := len(a.messages) - 1; i >= 0; i-- {
+		m := a.messages[i]
+		if m.Role != "user" {
+			continue
+		}
+		a.messages = a.messages[:i]
+		a.pending = append(m.Attachments, a.pending...)
+		a.alternative = ""
+		return m.Content, true
+	}
+	return "", false
+}
+
+// RetryWith sends the next message, with its tool rounds, to another
+// model or at another temperature; later messages go back to the agent's
+func (a *Agent) RetryWith(r Retry) {
+	a.next = r
+}
+
+// target returns the provider and model the current message goes to
+func (a *Agent) target() (provider.Provider, string) {
+	if a.turn.Provider != nil {
+		return a.turn.Provider, a.turn.Model
+	}
+	return a.provider, a.model
+}
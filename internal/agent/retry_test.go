@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/pkg/providertest"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+func TestAgent_Rewind(t *testing.T) {
+	a := New(Config{Provider: providertest.New("first"), Model: "test-model", SystemPrompt: "Be terse."})
+	if _, ok := a.Rewind(); ok {
+		t.Fatal("Rewind before any message")
+	}
+
+	a.Run(context.Background(), "hello")
+	a.Attach(types.Attachment{Type: "image", Name: "later"})
+	a.messages[1].Attachments = []types.Attachment{{Type: "image", Name: "shot"}}
+
+	message, ok := a.Rewind()
+	if !ok || message != "hello" {
+		t.Fatalf("Rewind = %q, %v", message, ok)
+	}
+	if len(a.Messages()) != 1 || a.Messages()[0].Role != "system" {
+		t.Errorf("history = %+v", a.Messages())
+	}
+	if len(a.pending) != 2 || a.pending[0].Name != "shot" {
+		t.Errorf("pending = %+v", a.pending)
+	}
+}
+
+func TestAgent_RetryWith(t *testing.T) {
+	own := providertest.New("own")
+	other := providertest.New("other")
+	a := New(Config{Provider: own, Model: "small"})
+	a.Run(context.Background(), "hello")
+
+	message, _ := a.Rewind()
+	a.RetryWith(Retry{Provider: other, Model: "large", Temperature: 0.2})
+	resp, err := a.Run(context.Background(), message)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if resp.Content != "other" || lastRequest(other).Model != "large" || lastRequest(other).Temperature != 0.2 {
+		t.Errorf("retry went to %q with %+v", resp.Content, lastRequest(other))
+	}
+	if len(a.Messages()) != 2 {
+		t.Errorf("history has %d messages, want 2", len(a.Messages()))
+	}
+
+	// The next message goes back to the agent's model
+	if resp, _ := a.Run(context.Background(), "again"); resp.Content != "own" || lastRequest(own).Temperature != 0 {
+		t.Errorf("next message went to %q", resp.Content)
+	}
+}
+
+func TestParseRetry(t *testing.T) {
+	p := &providertest.Provider{ProviderName: "remote"}
+	resolve := func(spec string) (provider.Provider, string, bool) {
+		if spec == "remote/large" {
+			return p, "large", true
+		}
+		return nil, "", false
+	}
+
+	r, err := ParseRetry([]string{"0.9", "remote/large"}, resolve)
+	if err != nil || r.Provider != p || r.Model != "large" || r.Temperature != 0.9 {
+		t.Errorf("ParseRetry = %+v, %v", r, err)
+	}
+	if _, err := ParseRetry([]string{"nowhere/x"}, resolve); err == nil {
+		t.Error("expected an error for an unknown model")
+	}
+	if _, err := ParseRetry([]string{"3"}, resolve); err == nil {
+		t.Error("expected an error for temperature 3")
+	}
+}
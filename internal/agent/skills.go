@@ -0,0 +1,219 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/internal/skill"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// SkillActivation records a skill used for a message and why
+type SkillActivation struct {
+	Skill  string `json:"skill"`
+	Reason string `json:"reason"`
+	Model  string `json:"model,omitempty"` // The model the skill asked for, when it answers
+}
+
+func (s SkillActivation) String() string {
+	if s.Model != "" {
+		return fmt.Sprintf("%s (%s, on %s)", s.Skill, s.Reason, s.Model)
+	}
+	return fmt.Sprintf("%s (%s)", s.Skill, s.Reason)
+}
+
+// DefaultSkillTokens is how many tokens of skill instructions a message
+// may activate when the budget doesn't say
+const DefaultSkillTokens = 6000
+
+// ActivateSkills picks the skills for a message about to be sent and adds
+// their instructions to the context, returning them in priority order.
+// By default skills are picked by their triggers, as many as fit in the
+// skill token budget (the first always does), and replace those the last
+// match activated; UseSkill and SkillsOff override that. A message that
+// matches nothing keeps the active skills and returns nil. The message
+// goes to the model the first skill asking for one wants; see skillModel.
+func (a *Agent) ActivateSkills(input string) []SkillActivation {
+	active := a.ContextItems(SourceSkill)
+	acts := a.activateSkills(input)
+	a.recordSkills(input, active, acts)
+	if p, model, name := a.skillModel(); p != nil {
+		for i := range acts {
+			if acts[i].Skill == name {
+				acts[i].Model = p.Name()
+				if model != "" {
+					acts[i].Model += "/" + model
+				}
+			}
+		}
+	}
+	return acts
+}
+
+// skillModel returns the model asked for by the skills in context: the
+// first, in priority order, whose model resolves, with its name. p is nil
+// when none does, or a model was chosen for the message with RetryWith,
+// and the agent's model answers.
+func (a *Agent) skillModel() (p provider.Provider, model, name string) {
+	if a.resolveModel == nil || a.skills == nil || a.next.Provider != nil {
+		return nil, "", ""
+	}
+	for _, item := range a.ContextItems(SourceSkill) {
+		sk, ok := a.skills.Get(item.Name)
+		if !ok || sk.Model == "" {
+			continue
+		}
+		if p, model, ok := a.resolveModel(sk.Model); ok {
+			return p, model, sk.Name
+		}
+	}
+	return nil, "", ""
+}
+
+func (a *Agent) activateSkills(input string) []SkillActivation {
+	if a.skills == nil || a.skillsOff {
+		return nil
+	}
+	if a.forcedSkill != "" {
+		if err := a.useSkill(a.forcedSkill); err != nil {
+			return nil
+		}
+		return []SkillActivation{{Skill: a.forcedSkill, Reason: "selected with /skill use"}}
+	}
+
+	matched := a.skills.Select(input)
+	if len(matched) == 0 {
+		return nil
+	}
+	a.dropSkills()
+
+	limit := a.budget.skillTokens()
+	used := 0
+	var acts []SkillActivation
+	for _, sk := range matched {
+		tokens := EstimateTokens(sk.Content)
+		if len(acts) > 0 && used+tokens > limit {
+			continue
+		}
+		used += tokens
+		a.AddContext(SourceSkill, sk.Name, sk.Content)
+		acts = append(acts, SkillActivation{Skill: sk.Name, Reason: fmt.Sprintf("matched trigger %q", sk.Trigger(input))})
+	}
+	return acts
+}
+
+// UseSkill uses a skill for every message until SkillsOff or AutoSkills,
+// replacing the skills already in context
+func (a *Agent) UseSkill(name string) error {
+	if a.skills == nil {
+		return fmt.Errorf("no skills loaded")
+	}
+	if _, ok := a.skills.Get(name); !ok {
+		return fmt.Errorf("skill not found: %s", name)
+	}
+	a.dropSkills()
+	a.forcedSkill, a.skillsOff = name, false
+	return a.useSkill(name)
+}
+
+// SkillsOff stops activating skills and removes those in context
+func (a *Agent) SkillsOff() {
+	a.dropSkills()
+	a.forcedSkill, a.skillsOff = "", true
+}
+
+// AutoSkills goes back to activating skills by their triggers
+func (a *Agent) AutoSkills() {
+	a.forcedSkill, a.skillsOff = "", false
+}
+
+// SkillOverride returns the skill chosen with UseSkill, and whether
+// skills were turned off
+func (a *Agent) SkillOverride() (string, bool) {
+	return a.forcedSkill, a.skillsOff
+}
+
+// activeExamples returns the agent's few-shot examples followed by those
+// of the skills in context
+func (a *Agent) activeExamples() []types.Example {
+	examples := a.examples
+	if a.skills == nil {
+		return examples
+	}
+	for _, item := range a.ContextItems(SourceSkill) {
+		if sk, ok := a.skills.Get(item.Name); ok && len(sk.Examples) > 0 {
+			examples = append(examples[:len(examples):len(examples)], sk.Examples...)
+		}
+	}
+	return examples
+}
+
+func (a *Agent) dropSkills() {
+	for _, item := range a.ContextItems(SourceSkill) {
+		a.RemoveContext(SourceSkill, item.Name)
+	}
+}
+
+// retryPrefixes start messages that push back on the last answer
+var retryPrefixes = []string{
+	"no", "nope", "wrong", "that's wrong", "that's not", "not what", "try again",
+	"still", "doesn't work", "didn't work", "it fails", "same error",
+}
+
+// isRetry reports whether a message pushes back on the last answer
+func isRetry(input string) bool {
+	input = strings.ToLower(strings.TrimSpace(input))
+	for _, prefix := range retryPrefixes {
+		if rest, ok := strings.CutPrefix(input, prefix); ok && (rest == "" || !isLetter(rest[0])) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLetter(b byte) bool {
+	return b >= 'a' && b <= 'z'
+}
+
+// recordSkills counts a message's activations, the tokens of the skills
+// it sends, and, when it pushes back, a retry for the skills that answered
+// the last one. Stats are best-effort; errors are ignored.
+func (a *Agent) recordSkills(input string, previous []ContextItem, acts []SkillActivation) {
+	if a.skillStats == nil {
+		return
+	}
+	sent := a.ContextItems(SourceSkill)
+	if len(sent) == 0 && len(previous) == 0 {
+		return
+	}
+	a.skillStats.Update(func(usage map[string]*skill.Usage) {
+		if isRetry(input) {
+			for _, item := range previous {
+				skill.Get(usage, item.Name).Retries++
+			}
+		}
+		for _, act := range acts {
+			u := skill.Get(usage, act.Skill)
+			u.Activations++
+			u.LastUsed = time.Now()
+		}
+		for _, item := range sent {
+			skill.Get(usage, item.Name).Tokens += EstimateTokens(item.Content)
+		}
+	})
+}
+
+// RateSkills records a thumbs up or down for the skills in context and
+// returns their names
+func (a *Agent) RateSkills(good bool) []string {
+	var names []string
+	for _, item := range a.ContextItems(SourceSkill) {
+		names = append(names, item.Name)
+	}
+	if a.skillStats != nil && len(names) > 0 {
+		a.skillStats.Rate(names, good)
+	}
+	return names
+}
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// StreamStats measures the performance of a streamed response
+type StreamStats struct {
+	Start      time.Time
+	FirstToken time.Time
+	End        time.Time
+	Tokens     int // Generated tokens: reported usage, else one per chunk
+
+	// PromptTokens is the prompt size when the provider reports usage
+	PromptTokens int
+}
+
+// NewStreamStats starts timing a stream
+func NewStreamStats() *StreamStats {
+	return &StreamStats{Start: time.Now()}
+}
+
+// Observe records a received chunk
+func (s *StreamStats) Observe(chunk types.StreamChunk) {
+	if chunk.Content != "" || chunk.Reasoning != "" {
+		if s.FirstToken.IsZero() {
+			s.FirstToken = time.Now()
+		}
+		s.Tokens++
+	}
+	if chunk.Usage != nil {
+		s.PromptTokens = chunk.Usage.PromptTokens
+		s.Tokens = chunk.Usage.CompletionTokens
+	}
+}
+
+// Finish marks the end of the stream
+func (s *StreamStats) Finish() {
+	s.End = time.Now()
+}
+
+// TTFT returns the time to first token
+func (s *StreamStats) TTFT() time.Duration {
+	if s.FirstToken.IsZero() {
+		return 0
+	}
+	return s.FirstToken.Sub(s.Start)
+}
+
+// Duration returns the total stream duration
+func (s *StreamStats) Duration() time.Duration {
+	if s.End.IsZero() {
+		return time.Since(s.Start)
+	}
+	return s.End.Sub(s.Start)
+}
+
+// TokensPerSecond returns generation throughput, measured from the first
+// token so prompt processing time doesn't skew it
+func (s *StreamStats) TokensPerSecond() float64 {
+	if s.FirstToken.IsZero() || s.End.IsZero() {
+		return 0
+	}
+	elapsed := s.End.Sub(s.FirstToken).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Tokens) / elapsed
+}
+
+// String formats the stats on one line
+func (s *StreamStats) String() string {
+	return fmt.Sprintf("TTFT %s • %.1f tok/s • %d tokens • %s",
+		s.TTFT().Round(time.Millisecond),
+		s.TokensPerSecond(),
+		s.Tokens,
+		s.Duration().Round(time.Millisecond))
+}
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+func TestStreamStats(t *testing.T) {
+	start := time.Now()
+	s := &StreamStats{Start: start}
+
+	s.Observe(types.StreamChunk{Done: false}) // Empty chunks aren't tokens
+	if !s.FirstToken.IsZero() || s.Tokens != 0 {
+		t.Errorf("empty chunk counted: %+v", s)
+	}
+
+	for i := 0; i < 10; i++ {
+		s.Observe(types.StreamChunk{Content: "tok"})
+	}
+	s.FirstToken = start.Add(500 * time.Millisecond)
+	s.End = start.Add(2500 * time.Millisecond)
+
+	if s.TTFT() != 500*time.Millisecond {
+		t.Errorf("TTFT = %v", s.TTFT())
+	}
+	if s.Duration() != 2500*time.Millisecond {
+		t.Errorf("Duration = %v", s.Duration())
+	}
+	if s.TokensPerSecond() != 5 {
+		t.Errorf("TokensPerSecond = %v", s.TokensPerSecond())
+	}
+	if got := s.String(); !strings.Contains(got, "5.0 tok/s") || !strings.Contains(got, "TTFT 500ms") {
+		t.Errorf("String = %q", got)
+	}
+}
+
+func TestStreamStats_NoTokens(t *testing.T) {
+	s := NewStreamStats()
+	s.Finish()
+	if s.TTFT() != 0 || s.TokensPerSecond() != 0 {
+		t.Errorf("expected zero stats, got TTFT %v, %v tok/s", s.TTFT(), s.TokensPerSecond())
+	}
+}
+
+func TestStreamStats_Usage(t *testing.T) {
+	s := NewStreamStats()
+	s.Observe(types.StreamChunk{Content: "a"})
+	s.Observe(types.StreamChunk{Content: "b"})
+	s.Observe(types.StreamChunk{Done: true, Usage: &types.Usage{PromptTokens: 40, CompletionTokens: 7}})
+
+	if s.Tokens != 7 || s.PromptTokens != 40 {
+		t.Errorf("Tokens = %d, PromptTokens = %d; want reported usage", s.Tokens, s.PromptTokens)
+	}
+}
@@ -0,0 +1,51 @@
+package agent
+
+import "github.com/agentflow/agentflow/pkg/types"
+
+// The built-in response styles; config can change them and add others
+const (
+	StyleConcise  = "concise"
+	StyleNormal   = "normal"
+	StyleDetailed = "detailed"
+)
+
+// Style shapes answers with an instruction sent after the system prompt
+// and a max_tokens used unless one is set with SetParam
+type Style struct {
+	Name        string `yaml:"-"`
+	Instruction string `yaml:"instruction,omitempty"`
+	MaxTokens   int    `yaml:"max_tokens,omitempty"`
+}
+
+// Styles are the built-in response styles
+var Styles = map[string]Style{
+	StyleConcise: {
+		Name:        StyleConcise,
+		Instruction: "Keep answers short: a few sentences or a brief list, with no preamble or recap.",
+		MaxTokens:   512,
+	},
+	StyleNormal: {Name: StyleNormal},
+	StyleDetailed: {
+		Name:        StyleDetailed,
+		Instruction: "Answer thoroughly: explain your reasoning and include examples where they help.",
+	},
+}
+
+// Style returns the response style
+func (a *Agent) Style() Style {
+	return a.style
+}
+
+// SetStyle changes the response style from the next message
+func (a *Agent) SetStyle(s Style) {
+	a.style = s
+}
+
+// styleSection returns the style instruction, or false when the style
+// has none
+func (a *Agent) styleSection() (section, bool) {
+	if a.style.Instruction == "" {
+		return section{}, false
+	}
+	return section{name: "Response style", messages: []types.Message{{Role: "system", Content: a.style.Instruction}}}, true
+}
@@ -0,0 +1,33 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agentflow/agentflow/pkg/providertest"
+)
+
+func TestAgent_Style(t *testing.T) {
+	p := providertest.New("ok")
+	a := New(Config{Provider: p, Model: "test-model", SystemPrompt: "Be kind.", Style: Styles[StyleConcise]})
+
+	a.Run(context.Background(), "hi")
+	msgs := lastRequest(p).Messages
+	if len(msgs) != 3 || msgs[1].Content != Styles[StyleConcise].Instruction || lastRequest(p).MaxTokens != 512 {
+		t.Errorf("request = %+v", lastRequest(p))
+	}
+
+	// A max_tokens set by hand wins over the style's
+	a.SetParam("max_tokens", "100")
+	a.Run(context.Background(), "again")
+	if lastRequest(p).MaxTokens != 100 {
+		t.Errorf("max_tokens = %d", lastRequest(p).MaxTokens)
+	}
+
+	a.SetStyle(Styles[StyleNormal])
+	a.SetParam("max_tokens", "default")
+	a.Run(context.Background(), "once more")
+	if lastRequest(p).MaxTokens != 0 || lastRequest(p).Messages[1].Role != "user" {
+		t.Errorf("normal style request = %+v", lastRequest(p))
+	}
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// CachedPrefix starts the result of a tool call repeating an earlier one
+const CachedPrefix = "(cached)"
+
+// cachedResult answers a call to a tool that doesn't change anything
+// with the same arguments as one already run this turn, since nothing
+// changed in between, pointing the model at the earlier result instead of
+// sending it again. ok is false when the call has to run.
+func (a *Agent) cachedResult(call types.ToolCall) (types.Message, bool) {
+	earlier, ok := a.toolResults[toolCallKey(call)]
+	if !ok {
+		return types.Message{}, false
+	}
+	return types.Message{
+		Role:       "tool",
+		Name:       call.Name,
+		ToolCallID: call.ID,
+		Content:    fmt.Sprintf("%s Same call as %s earlier this turn, and nothing changed since: its result above still holds.", CachedPrefix, earlier),
+		Timestamp:  time.Now(),
+	}, true
+}
+
+// cacheResult remembers a call's result for the rest of the turn, or
+// forgets every result when the tool may have changed something
+func (a *Agent) cacheResult(call types.ToolCall, result types.Message) {
+	t, ok := a.tools.Get(call.Name)
+	if !ok {
+		return
+	}
+	if tool.Writes(t) {
+		a.toolResults = nil
+		return
+	}
+	if strings.HasPrefix(result.Content, "error: ") {
+		return
+	}
+	if a.toolResults == nil {
+		a.toolResults = make(map[string]string)
+	}
+	a.toolResults[toolCallKey(call)] = call.ID
+}
+
+// toolCallKey identifies a call by its tool and arguments, ignoring how
+// the arguments are spaced and ordered
+func toolCallKey(call types.ToolCall) string {
+	args := []byte(call.Arguments)
+	var v any
+	if json.Unmarshal(args, &v) == nil {
+		args, _ = json.Marshal(v)
+	}
+	return call.Name + " " + string(bytes.TrimSpace(args))
+}
@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/agentflow/agentflow/internal/artifact"
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/providertest"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// toolRounds scripts a provider calling tools, a round per request, then
+// answering "done"
+func toolRounds(rounds ...[]types.ToolCall) *providertest.Provider {
+	p := &providertest.Provider{}
+	for _, calls := range rounds {
+		p.Responses = append(p.Responses, providertest.Response{ToolCalls: calls})
+	}
+	p.Responses = append(p.Responses, providertest.Response{Content: "done"})
+	return p
+}
+
+func TestAgent_CachesToolResults(t *testing.T) {
+	reads := 0
+	tools := tool.NewRegistry()
+	tools.Register(&tool.Func{
+		ToolName: "read_file",
+		Params:   tool.Object(nil),
+		Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+			reads++
+			return "contents", nil
+		},
+	})
+	tools.Register(&tool.Func{
+		ToolName: "write_file",
+		Params:   tool.Object(nil),
+		Changes:  true,
+		Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+			return "written", nil
+		},
+	})
+	read := func(id, args string) types.ToolCall {
+		return types.ToolCall{ID: id, Name: "read_file", Arguments: args}
+	}
+	p := toolRounds(
+		[]types.ToolCall{read("c1", `{"path":"a.go","limit":10}`)},
+		[]types.ToolCall{read("c2", `{ "limit": 10, "path": "a.go" }`), read("c3", `{"path":"b.go"}`)},
+		[]types.ToolCall{{ID: "c4", Name: "write_file", Arguments: `{"path":"a.go"}`}},
+		[]types.ToolCall{read("c5", `{"path":"a.go","limit":10}`)},
+	)
+	a := New(Config{Provider: p, Model: "test-model", Tools: tools})
+
+	if _, err := a.Run(context.Background(), "look at a.go"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	// The same arguments in another order are cached; a write clears the cache
+	if reads != 3 {
+		t.Errorf("read_file ran %d times, want 3", reads)
+	}
+	var results []string
+	for _, m := range a.Messages() {
+		if m.Role == "tool" {
+			results = append(results, m.ToolCallID+" "+strings.Fields(m.Content)[0])
+		}
+	}
+	if got := strings.Join(results, ", "); got != "c1 contents, c2 (cached), c3 contents, c4 written, c5 contents" {
+		t.Errorf("results = %s", got)
+	}
+	if c2 := a.Messages()[4].Content; !strings.Contains(c2, "c1") {
+		t.Errorf("cached result = %q", c2)
+	}
+
+	// The next message runs its calls again
+	p.Responses = append(p.Responses, providertest.Response{ToolCalls: []types.ToolCall{read("c6", `{"path":"b.go"}`)}}, providertest.Response{Content: "done"})
+	a.Run(context.Background(), "and b.go?")
+	if reads != 4 {
+		t.Errorf("read_file ran %d times, want 4", reads)
+	}
+}
+
+func TestAgent_SaveArtifactClearsCache(t *testing.T) {
+	root := t.TempDir()
+	tools := tool.NewRegistry()
+	tools.Register(tool.ReadFiles(root))
+	artifact.Offer(tools, root, "s1")
+	save := func(id, content string) types.ToolCall {
+		return types.ToolCall{ID: id, Name: artifact.ToolName, Arguments: `{"name":"plan.md","content":"` + content + `"}`}
+	}
+	read := types.ToolCall{Name: "read_files", Arguments: `{"paths":[".agentflow/artifacts/s1/plan.md"]}`}
+	first, second := read, read
+	first.ID, second.ID = "c2", "c4"
+	a := New(Config{Provider: toolRounds(
+		[]types.ToolCall{save("c1", "one")},
+		[]types.ToolCall{first},
+		[]types.ToolCall{save("c3", "two")},
+		[]types.ToolCall{second},
+	), Model: "test-model", Tools: tools})
+	// Saving the plan in plan mode clears the cache as well
+	a.SetPlanMode(true)
+
+	if _, err := a.Run(context.Background(), "plan it"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if last := a.Messages()[len(a.Messages())-2]; last.ToolCallID != "c4" || !strings.Contains(last.Content, "two") {
+		t.Errorf("read after saving = %q", last.Content)
+	}
+}
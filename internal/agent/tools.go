@@ -0,0 +1,243 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/audit"
+	"github.com/agentflow/agentflow/internal/guard"
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// MaxToolRounds bounds how many times one message can go back and forth
+// with tool calls; the last request is sent without tools so the model
+// has to answer
+const MaxToolRounds = 8
+
+// Tools returns the agent's tool registry, or nil
+func (a *Agent) Tools() *tool.Registry {
+	return a.tools
+}
+
+// SetTools sets the tools offered to the model
+func (a *Agent) SetTools(tools *tool.Registry) {
+	a.tools = tools
+	a.noTools = false
+}
+
+// Guard returns the guard screening content for prompt injection, or nil
+func (a *Agent) Guard() *guard.Guard {
+	return a.guard
+}
+
+// SetGuard sets the guard screening tool results for prompt injection;
+// nil lets them through as they are
+func (a *Agent) SetGuard(g *guard.Guard) {
+	a.guard = g
+}
+
+// SetApprove sets what is asked before a tool that changes things runs;
+// nil runs them without asking
+func (a *Agent) SetApprove(approve func(ctx context.Context, call types.ToolCall) (types.ToolCall, error)) {
+	a.approve = approve
+}
+
+// Audit returns the log recording the agent's actions, or nil
+func (a *Agent) Audit() *audit.Log {
+	return a.audit
+}
+
+// SetAudit sets the log recording provider calls and tool runs, as when
+// the conversation moves to another session; nil records nothing
+func (a *Agent) SetAudit(log *audit.Log) {
+	a.audit = log
+}
+
+// request builds a completion request for the current history, offering
+// tools unless withTools is false or the model has refused them or is
+// known not to support them
+func (a *Agent) request(withTools bool) types.CompletionRequest {
+	p, model := a.target()
+	req := types.CompletionRequest{
+		Model:       model,
+		Messages:    a.requestMessages(),
+		Think:       a.think,
+		Temperature: a.params.Temperature,
+		MaxTokens:   a.params.MaxTokens,
+		TopP:        a.params.TopP,
+		Seed:        a.params.Seed,
+	}
+	if req.MaxTokens == 0 {
+		req.MaxTokens = a.style.MaxTokens
+	}
+	if a.turn.Temperature != 0 {
+		req.Temperature = a.turn.Temperature
+	}
+	if tools := a.activeTools(); withTools && tools != nil && !a.noTools && provider.InfoFor(p, model).SupportsTools() {
+		req.Tools = tools.Definitions()
+		if len(a.results) > 0 {
+			req.Tools = append(req.Tools, expandDefinition())
+		}
+	}
+	return req
+}
+
+// MaxRateLimitWait is the longest rate limit a request waits out before
+// retrying; longer ones fail the request
+const MaxRateLimitWait = 30 * time.Second
+
+// complete sends a request, retrying without tools when the model does
+// not support them, and once after a short rate limit
+func (a *Agent) complete(ctx context.Context, req types.CompletionRequest) (*types.CompletionResponse, error) {
+	p, _ := a.target()
+	if err := a.startCharge(p, req); err != nil {
+		return nil, err
+	}
+	resp, err := waitRateLimit(ctx, func() (*types.CompletionResponse, error) {
+		return p.Complete(ctx, req)
+	})
+	if err != nil && len(req.Tools) > 0 && toolsUnsupported(err) {
+		a.noTools = true
+		req.Tools = nil
+		resp, err = p.Complete(ctx, req)
+	}
+	a.auditRequest(p, req, err)
+	if err == nil {
+		a.finishCharge(nil, resp.Content+resp.Reasoning)
+	}
+	return resp, err
+}
+
+// stream is complete for streamed requests
+func (a *Agent) stream(ctx context.Context, req types.CompletionRequest) (<-chan types.StreamChunk, error) {
+	req.Stream = true
+	p, _ := a.target()
+	if err := a.startCharge(p, req); err != nil {
+		return nil, err
+	}
+	chunks, err := waitRateLimit(ctx, func() (<-chan types.StreamChunk, error) {
+		return p.Stream(ctx, req)
+	})
+	if err != nil && len(req.Tools) > 0 && toolsUnsupported(err) {
+		a.noTools = true
+		req.Tools = nil
+		chunks, err = p.Stream(ctx, req)
+	}
+	a.auditRequest(p, req, err)
+	return chunks, err
+}
+
+// auditRequest records a request sent to a provider
+func (a *Agent) auditRequest(p provider.Provider, req types.CompletionRequest, err error) {
+	if a.audit == nil {
+		return
+	}
+	e := audit.Entry{
+		Kind:   audit.Provider,
+		Action: p.Name() + "/" + req.Model,
+		Detail: fmt.Sprintf("messages: %d, tools offered: %d", len(req.Messages), len(req.Tools)),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	a.record(e)
+}
+
+// waitRateLimit sends a request, and when the provider rate limits it for
+// at most MaxRateLimitWait, waits and sends it once more
+func waitRateLimit[T any](ctx context.Context, send func() (T, error)) (T, error) {
+	v, err := send()
+	wait, ok := provider.RetryAfter(err)
+	if !ok || wait > MaxRateLimitWait {
+		return v, err
+	}
+	select {
+	case <-time.After(wait):
+	case <-ctx.Done():
+		return v, err
+	}
+	return send()
+}
+
+// toolsUnsupported reports whether a provider error says the model can't
+// call tools (Ollama: "does not support tools"; OpenAI-compatible
+// servers word it variously)
+func toolsUnsupported(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "does not support tools") ||
+		strings.Contains(msg, "tools are not supported") ||
+		strings.Contains(msg, "tool use is not supported") ||
+		strings.Contains(msg, "\"auto\" tool choice requires")
+}
+
+// runTools runs tool calls in order and adds their results to history,
+// screened by the guard and cut to the tool result budget, reporting each
+// as activity. Calls repeating one already run this turn are answered
+// from the cache instead. It returns a notice for the user
+// naming the cached calls and the results that looked like prompt
+// injection, or "".
+func (a *Agent) runTools(ctx context.Context, calls []types.ToolCall) string {
+	var notices []string
+	for _, call := range calls {
+		t, _ := a.tools.Get(call.Name)
+		if result, ok := a.cachedResult(call); ok {
+			notices = append(notices, tool.Describe(call)+" "+CachedPrefix)
+			a.messages = append(a.messages, result)
+			act := tool.Report(t, call, result)
+			act.Cached = true
+			a.report(act)
+			continue
+		}
+		result := a.callTool(ctx, call)
+		a.cacheResult(call, result)
+		a.auditTool(call, result)
+		a.report(tool.Report(t, call, result))
+		var findings []guard.Finding
+		source := fmt.Sprintf("The result of %s", call.Name)
+		if result.Content, findings = a.guard.Screen(source, result.Content); len(findings) > 0 {
+			notices = append(notices, guard.Summary(source, findings))
+		}
+		if call.Name != ExpandTool {
+			result.Content = a.truncateResult(result.Content)
+		}
+		a.messages = append(a.messages, result)
+	}
+	if failed := a.auditNotice(); failed != "" {
+		notices = append(notices, failed)
+	}
+	return strings.Join(notices, "\n")
+}
+
+// record writes an entry to the audit log, keeping the first failure
+// for auditNotice
+func (a *Agent) record(e audit.Entry) {
+	if err := a.audit.Record(e); err != nil && a.auditErr == nil {
+		a.auditErr = err
+	}
+}
+
+// auditNotice tells the user, once, that the audit log failed to record
+// an action, so it no longer has every one
+func (a *Agent) auditNotice() string {
+	if a.auditErr == nil || a.auditTold {
+		return ""
+	}
+	a.auditTold = true
+	return fmt.Sprintf("⚠ The audit log is missing actions of this session: %v", a.auditErr)
+}
+
+// auditTool records a tool call and whether it failed
+func (a *Agent) auditTool(call types.ToolCall, result types.Message) {
+	if a.audit == nil {
+		return
+	}
+	e := audit.Entry{Kind: audit.Tool, Action: call.Name, Detail: call.Arguments}
+	if msg, failed := strings.CutPrefix(result.Content, "error: "); failed {
+		e.Error = msg
+	}
+	a.record(e)
+}
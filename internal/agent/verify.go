@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultVerifyRounds is how many times failed checks are sent back to
+// the model in one turn when Verify.Rounds is unset
+const DefaultVerifyRounds = 2
+
+// maxVerifyOutput is how much of a failing check's output is sent, from
+// the end
+const maxVerifyOutput = 8000
+
+// Verify runs a project's checks, such as its build, tests and lint,
+// when the model says a coding task is done
+type Verify struct {
+	// Check returns the first failing command and its output, or "" when
+	// all pass
+	Check func(ctx context.Context) (failed, output string, err error)
+	// Changes returns a fingerprint of the files the checks cover, such
+	// as git's view of the worktree; when they changed during a turn the
+	// checks run, however they were changed. Nil leaves only the agent's
+	// tools and MarkChanged to say so.
+	Changes func(ctx context.Context) string
+	// Rounds caps how often failures reopen one turn
+	Rounds int
+}
+
+// doneClaim matches answers saying the work is finished
+var doneClaim = regexp.MustCompile(`(?i)\b(done|fixed|implemented|completed?|finished|resolved|all (tests|checks) pass|(should|now) (work|pass|compile|build)s?|is ready)\b`)
+
+// ClaimsDone reports whether an answer says a task is finished; answers
+// ending in a question are waiting on the user instead
+func ClaimsDone(answer string) bool {
+	answer = strings.TrimSpace(answer)
+	return answer != "" && !strings.HasSuffix(answer, "?") && doneClaim.MatchString(answer)
+}
+
+// SetVerify sets the checks run after the model says a task is done; a
+// zero Verify turns them off
+func (a *Agent) SetVerify(v Verify) {
+	a.verify = v
+}
+
+// MarkChanged has the checks run the next time the model says a task is
+// done, for files changed outside the agent's tools, such as by a shell
+// command the user ran
+func (a *Agent) MarkChanged() {
+	a.changed = true
+}
+
+// startVerify takes the fingerprint of the files the checks cover as a
+// turn starts
+func (a *Agent) startVerify() {
+	a.verifyRound = 0
+	if a.verify.Check != nil && a.verify.Changes != nil {
+		a.verifyFrom = a.verify.Changes(context.Background())
+	}
+}
+
+// verifyTurn runs the checks when the last answer says the task is done
+// and files changed since the last checks (by a tool, a shell command or
+// anything else the worktree shows this turn), or an earlier check
+// failed. It returns a notice for the user, and true after adding the
+// failure to history for the model to fix.
+func (a *Agent) verifyTurn(ctx context.Context) (string, bool) {
+	last := len(a.messages) - 1
+	if a.verify.Check == nil || a.plan || last < 0 || a.messages[last].Role != "assistant" {
+		return "", false
+	}
+	if !ClaimsDone(a.messages[last].Content) {
+		return "", false
+	}
+	changed := a.changed || a.verifyRound > 0
+	if a.verify.Changes != nil {
+		now := a.verify.Changes(ctx)
+		changed = changed || now != a.verifyFrom
+		a.verifyFrom = now
+	}
+	if !changed {
+		return "", false
+	}
+	a.changed = false
+
+	failed, output, err := a.verify.Check(ctx)
+	if err != nil {
+		return fmt.Sprintf("Checks not run: %v", err), false
+	}
+	if failed == "" {
+		return "✓ Verified: all checks pass", false
+	}
+	rounds := a.verify.Rounds
+	if rounds <= 0 {
+		rounds = DefaultVerifyRounds
+	}
+	if a.verifyRound >= rounds {
+		return fmt.Sprintf("✗ `%s` still fails after %d rounds", failed, rounds), false
+	}
+	a.verifyRound++
+
+	if len(output) > maxVerifyOutput {
+		output = "..." + output[len(output)-maxVerifyOutput:]
+	}
+	a.AddMessage("user", fmt.Sprintf("The task isn't done: `%s` fails.\n\n```\n%s\n```\n\nFind the cause and fix it, then say when it's done.", failed, strings.TrimRight(output, "\n")))
+	return fmt.Sprintf("✗ `%s` fails; sent back to the model (round %d/%d)", failed, a.verifyRound, rounds), true
+}
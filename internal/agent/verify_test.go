@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/providertest"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// editProvider scripts a provider calling the write tool, then saying it
+// is done, rounds times
+func editProvider(rounds int) *providertest.Provider {
+	p := &providertest.Provider{}
+	for range rounds {
+		p.Responses = append(p.Responses,
+			providertest.Response{ToolCalls: []types.ToolCall{{ID: "call_1", Name: "write", Arguments: `{}`}}},
+			providertest.Response{Content: "Done: the bug is fixed."})
+	}
+	return p
+}
+
+func writeTools() *tool.Registry {
+	r := tool.NewRegistry()
+	r.Register(&tool.Func{
+		ToolName: "write",
+		Params:   tool.Object(nil),
+		Changes:  true,
+		Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+			return "written", nil
+		},
+	})
+	return r
+}
+
+func TestClaimsDone(t *testing.T) {
+	for answer, want := range map[string]bool{
+		"Done: the bug is fixed.":         true,
+		"I've implemented the endpoint.":  true,
+		"The build should now pass.":      true,
+		"Here is how the parser works.":   false,
+		"Should I also fix the tests?":    false,
+		"":                                false,
+		"The function is finished below.": true,
+	} {
+		if got := ClaimsDone(answer); got != want {
+			t.Errorf("ClaimsDone(%q) = %v, want %v", answer, got, want)
+		}
+	}
+}
+
+func TestAgent_Verify(t *testing.T) {
+	fails := 1
+	checks := 0
+	check := func(ctx context.Context) (string, string, error) {
+		checks++
+		if checks <= fails {
+			return "go test ./...", "--- FAIL: TestX\nx_test.go:12: wrong", nil
+		}
+		return "", "", nil
+	}
+
+	p := editProvider(4)
+	a := New(Config{Provider: p, Model: "test-model", Tools: writeTools(), Verify: Verify{Check: check}})
+	resp, err := a.Run(context.Background(), "fix the bug")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if checks != 2 || p.Calls() != 4 || !strings.HasPrefix(resp.Content, "Done") {
+		t.Errorf("checks = %d, requests = %d, answer %q", checks, p.Calls(), resp.Content)
+	}
+	var reopened bool
+	for _, msg := range a.Messages() {
+		if msg.Role == "user" && strings.Contains(msg.Content, "`go test ./...` fails") && strings.Contains(msg.Content, "x_test.go:12") {
+			reopened = true
+		}
+	}
+	if !reopened {
+		t.Error("the failure was not sent back to the model")
+	}
+
+	// Rounds bound the retries
+	fails, checks = 10, 0
+	a.SetVerify(Verify{Check: check, Rounds: 1})
+	if _, err := a.Run(context.Background(), "fix it again"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if checks != 2 || p.Calls() != 8 {
+		t.Errorf("with 1 round: checks = %d, requests = %d", checks, p.Calls()-4)
+	}
+
+	// Answers without changes aren't checked
+	checks = 0
+	if _, err := New(Config{Provider: providertest.New("Done."), Model: "test-model", Verify: Verify{Check: check}}).Run(context.Background(), "hi"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if checks != 0 {
+		t.Errorf("checked %d times without changes", checks)
+	}
+}
+
+func TestAgent_VerifyShellEdits(t *testing.T) {
+	checks := 0
+	check := func(ctx context.Context) (string, string, error) {
+		checks++
+		return "", "", nil
+	}
+	file := filepath.Join(t.TempDir(), "main.go")
+	os.WriteFile(file, []byte("package main\n"), 0644)
+	fingerprint := func(ctx context.Context) string {
+		data, _ := os.ReadFile(file)
+		return string(data)
+	}
+
+	// A tool that doesn't say it changes things edits a file with the shell
+	tools := tool.NewRegistry()
+	tools.Register(&tool.Func{
+		ToolName: "shell",
+		Params:   tool.Object(nil),
+		Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+			return "", exec.CommandContext(ctx, "sh", "-c", "echo 'func main() {}' >> "+file).Run()
+		},
+	})
+	p := &providertest.Provider{Responses: []providertest.Response{
+		{ToolCalls: []types.ToolCall{{ID: "call_1", Name: "shell", Arguments: `{}`}}},
+		{Content: "Done: main is implemented."},
+	}}
+	a := New(Config{Provider: p, Model: "test-model", Tools: tools, Verify: Verify{Check: check, Changes: fingerprint}})
+	if _, err := a.Run(context.Background(), "add main"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if checks != 1 {
+		t.Errorf("checks after a shell edit = %d, want 1", checks)
+	}
+
+	// Nothing changed this turn: no checks
+	a = New(Config{Provider: providertest.New("Done."), Model: "test-model", Verify: Verify{Check: check, Changes: fingerprint}})
+	if _, err := a.Run(context.Background(), "is it done?"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if checks != 1 {
+		t.Errorf("checks without changes = %d, want 1", checks)
+	}
+
+	// A command the user ran with ! before the message counts too
+	a.MarkChanged()
+	if _, err := a.Run(context.Background(), "I ran sed -i, is it done?"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if checks != 2 {
+		t.Errorf("checks after MarkChanged = %d, want 2", checks)
+	}
+}
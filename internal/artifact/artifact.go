@@ -0,0 +1,287 @@
+// Package artifact keeps the named outputs of a session — plans, reports,
+// generated files — on disk under the project, with an index, instead of
+// only inside chat text
+package artifact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// Dir is where artifacts are stored, relative to the project root, in a
+// directory per session
+const Dir = ".agentflow/artifacts"
+
+// ToolName is the name of the tool that saves artifacts
+const ToolName = "save_artifact"
+
+// indexFile lists a session's artifacts
+const indexFile = "index.json"
+
+// ErrNotFound is returned when no artifact matches a reference
+var ErrNotFound = errors.New("artifact not found")
+
+// Artifact is one named output
+type Artifact struct {
+	Name        string    `json:"name"` // File name within the session directory
+	Description string    `json:"description,omitempty"`
+	Size        int64     `json:"size"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+	Session     string    `json:"session,omitempty"` // Filled in when listed
+	Path        string    `json:"path,omitempty"`
+}
+
+// Store holds the artifacts of one session
+type Store struct {
+	dir     string
+	session string
+	mu      sync.Mutex
+}
+
+// Open returns the store of a session's artifacts under root. Without a
+// session, as in a TUI that isn't saving one, the run gets its own
+// directory named after its start time.
+func Open(root, session string) *Store {
+	if session == "" {
+		session = time.Now().Format("20060102-150405")
+	}
+	return &Store{dir: filepath.Join(root, Dir, session), session: session}
+}
+
+// Session returns the session the store belongs to
+func (s *Store) Session() string {
+	return s.session
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fileName turns an artifact name into a file name, adding .md when it
+// has no extension
+func fileName(name string) (string, error) {
+	name = strings.Trim(unsafeChars.ReplaceAllString(strings.TrimSpace(name), "-"), "-.")
+	if name == "" || name == indexFile {
+		return "", errors.New("invalid artifact name")
+	}
+	if filepath.Ext(name) == "" {
+		name += ".md"
+	}
+	return name, nil
+}
+
+// Save writes an artifact, replacing any of the same name
+func (s *Store) Save(name, description, content string) (*Artifact, error) {
+	file, err := fileName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("create artifacts dir: %w", err)
+	}
+	path := filepath.Join(s.dir, file)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return nil, fmt.Errorf("write artifact: %w", err)
+	}
+
+	index, _ := readIndex(s.dir)
+	now := time.Now()
+	a := Artifact{Name: file, Description: description, Size: int64(len(content)), Created: now, Updated: now}
+	replaced := false
+	for i, old := range index {
+		if old.Name == file {
+			a.Created = old.Created
+			if description == "" {
+				a.Description = old.Description
+			}
+			index[i] = a
+			replaced = true
+		}
+	}
+	if !replaced {
+		index = append(index, a)
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, indexFile), data, 0644); err != nil {
+		return nil, fmt.Errorf("write artifact index: %w", err)
+	}
+
+	a.Session, a.Path = s.session, path
+	return &a, nil
+}
+
+// List returns the store's artifacts, oldest first
+func (s *Store) List() ([]Artifact, error) {
+	return listSession(filepath.Dir(s.dir), s.session)
+}
+
+// readIndex reads the index of a session directory
+func readIndex(dir string) ([]Artifact, error) {
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if err != nil {
+		return nil, err
+	}
+	var index []Artifact
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("read artifact index: %w", err)
+	}
+	return index, nil
+}
+
+// listSession returns the artifacts of one session directory under base
+func listSession(base, session string) ([]Artifact, error) {
+	index, err := readIndex(filepath.Join(base, session))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range index {
+		index[i].Session = session
+		index[i].Path = filepath.Join(base, session, index[i].Name)
+	}
+	return index, nil
+}
+
+// List returns every artifact under root, most recently updated first
+func List(root string) ([]Artifact, error) {
+	base := filepath.Join(root, Dir)
+	entries, err := os.ReadDir(base)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read artifacts dir: %w", err)
+	}
+
+	var all []Artifact
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		index, err := listSession(base, entry.Name())
+		if err != nil {
+			continue
+		}
+		all = append(all, index...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Updated.After(all[j].Updated)
+	})
+	return all, nil
+}
+
+// Find returns the artifact a reference names: "session/name", or a name
+// (with or without its .md) for the latest artifact called that
+func Find(root, ref string) (*Artifact, error) {
+	all, err := List(root)
+	if err != nil {
+		return nil, err
+	}
+	session, name, ok := strings.Cut(ref, "/")
+	if !ok {
+		session, name = "", ref
+	}
+	for _, a := range all {
+		if session != "" && a.Session != session {
+			continue
+		}
+		if a.Name == name || a.Name == name+".md" {
+			return &a, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, ref)
+}
+
+// Since returns the contents of the latest artifact called name saved
+// at or after since, such as the plan written in plan mode
+func Since(root, name string, since time.Time) (string, bool) {
+	a, err := Find(root, name)
+	if err != nil || a.Updated.Before(since) {
+		return "", false
+	}
+	data, err := os.ReadFile(a.Path)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Tool returns the save_artifact tool, which writes to the store
+func (s *Store) Tool() tool.Tool {
+	return &tool.Func{
+		ToolName: ToolName,
+		Desc:     "Save a named output, such as a plan, a report or a generated file, as a file the user can open later, instead of only writing it in the chat. Saving under the same name replaces it.",
+		Params: tool.Object(map[string]any{
+			"name":        tool.String("File name, e.g. plan.md or migration.sql; .md is added when there is no extension"),
+			"content":     tool.String("The full contents"),
+			"description": tool.String("One line saying what it is"),
+		}, "name", "content"),
+		Changes:  true,
+		Activity: tool.KindEdit,
+		Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+			var in struct {
+				Name        string `json:"name"`
+				Content     string `json:"content"`
+				Description string `json:"description"`
+			}
+			if err := json.Unmarshal(args, &in); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			a, err := s.Save(in.Name, in.Description, in.Content)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Saved artifact %s (%d bytes) to %s", a.Name, a.Size, a.Path), nil
+		},
+	}
+}
+
+// Saves reports whether call saves the artifact called name, with or
+// without its .md
+func Saves(call types.ToolCall, name string) bool {
+	var in struct {
+		Name string `json:"name"`
+	}
+	if call.Name != ToolName || json.Unmarshal([]byte(call.Arguments), &in) != nil {
+		return false
+	}
+	file, err := fileName(in.Name)
+	want, _ := fileName(name)
+	return err == nil && file == want
+}
+
+// Describe formats an artifact for listings
+func (a Artifact) Describe() string {
+	line := fmt.Sprintf("%s/%s  %d bytes  %s", a.Session, a.Name, a.Size, a.Updated.Format("Jan 2 15:04"))
+	if a.Description != "" {
+		line += " — " + a.Description
+	}
+	return line
+}
+
+// Offer registers the save_artifact tool of a session's store, under
+// root, in tools, which is nil when tools are disabled
+func Offer(tools *tool.Registry, root, session string) {
+	if tools != nil {
+		tools.Register(Open(root, session).Tool())
+	}
+}
@@ -0,0 +1,70 @@
+package artifact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStore_SaveAndList(t *testing.T) {
+	root := t.TempDir()
+	s := Open(root, "abc")
+
+	a, err := s.Save("Refactor plan", "How to split the agent", "1. Split")
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if a.Name != "Refactor-plan.md" || a.Session != "abc" {
+		t.Errorf("artifact = %+v", a)
+	}
+	if data, _ := os.ReadFile(a.Path); string(data) != "1. Split" {
+		t.Errorf("contents = %q", data)
+	}
+
+	// Saving again replaces it, keeping the description
+	if _, err := s.Save("Refactor plan", "", "1. Split\n2. Test"); err != nil {
+		t.Fatal(err)
+	}
+	Open(root, "def").Save("schema.sql", "", "CREATE TABLE x;")
+
+	list, _ := s.List()
+	if len(list) != 1 || list[0].Description != "How to split the agent" || list[0].Size != 16 {
+		t.Errorf("List = %+v", list)
+	}
+	all, err := List(root)
+	if err != nil || len(all) != 2 || all[0].Name != "schema.sql" {
+		t.Errorf("List(root) = %+v, %v", all, err)
+	}
+
+	if _, err := s.Save("../", "", "x"); err == nil {
+		t.Error("expected an error for an empty name")
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	Open(root, "abc").Save("plan", "", "old")
+	Open(root, "def").Save("plan", "", "new")
+
+	if a, err := Find(root, "plan"); err != nil || a.Session != "def" {
+		t.Errorf("Find(plan) = %+v, %v", a, err)
+	}
+	if a, err := Find(root, "abc/plan.md"); err != nil || a.Session != "abc" {
+		t.Errorf("Find(abc/plan.md) = %+v, %v", a, err)
+	}
+	if _, err := Find(root, "report"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Find(report) error = %v", err)
+	}
+}
+
+func TestStore_Tool(t *testing.T) {
+	s := Open(t.TempDir(), "abc")
+	args, _ := json.Marshal(map[string]string{"name": "report", "content": "All green"})
+	out, err := s.Tool().Run(context.Background(), args)
+	if err != nil || !strings.Contains(out, "Saved artifact report.md") {
+		t.Errorf("Run = %q, %v", out, err)
+	}
+}
@@ -0,0 +1,224 @@
+// Package audit keeps an append-only log of what agents do — provider
+// calls, tool runs, shell commands and file changes — one JSON line per
+// action and one file per session. Each entry carries the hash of the one
+// before, so editing, removing or reordering entries breaks the chain and
+// Verify tells.
+package audit
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of actions
+const (
+	Provider = "provider" // A request to a model
+	Tool     = "tool"     // A tool the model called
+	Bash     = "bash"     // A shell command
+	File     = "file"     // A file written or removed
+)
+
+// maxDetail is how many characters of an entry's detail are kept
+const maxDetail = 4000
+
+// Entry is one action
+type Entry struct {
+	Seq    int       `json:"seq"` // From 1
+	Time   time.Time `json:"time"`
+	Kind   string    `json:"kind"`
+	Action string    `json:"action"`           // The model, tool, command or file
+	Detail string    `json:"detail,omitempty"` // Arguments, sizes, outcome
+	Error  string    `json:"error,omitempty"`
+	Prev   string    `json:"prev"` // Hash of the entry before, "" for the first
+	Hash   string    `json:"hash"` // Of this entry with Hash empty, chained to Prev
+}
+
+// Log appends entries to a session's log. A nil Log records nothing.
+type Log struct {
+	path    string
+	session string
+
+	mu   sync.Mutex
+	seq  int
+	prev string
+}
+
+// Dir is where logs are kept, ~/.agentflow/audit
+func Dir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".agentflow", "audit")
+}
+
+// Open returns the log of a session in dir, continuing its chain when it
+// has one; an empty session starts a new one. The file is created with
+// the first entry.
+func Open(dir, session string) (*Log, error) {
+	if session == "" {
+		session = NewSession()
+	}
+	if strings.ContainsAny(session, `/\`) || session == "." || session == ".." {
+		return nil, fmt.Errorf("audit: invalid session %q", session)
+	}
+	l := &Log{path: filepath.Join(dir, session+".jsonl"), session: session}
+	entries, err := Read(l.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if n := len(entries); n > 0 {
+		l.seq, l.prev = entries[n-1].Seq, entries[n-1].Hash
+	}
+	return l, nil
+}
+
+// NewSession returns an ID for a log without a saved session, sorting by
+// when it started
+func NewSession() string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+// Session returns the session the log belongs to
+func (l *Log) Session() string {
+	if l == nil {
+		return ""
+	}
+	return l.session
+}
+
+// Path returns the log's file
+func (l *Log) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// Record appends an entry, filling in its sequence, time and hashes
+func (l *Log) Record(e Entry) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.Seq, e.Prev = l.seq+1, l.prev
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Time = e.Time.UTC()
+	if runes := []rune(e.Detail); len(runes) > maxDetail {
+		e.Detail = string(runes[:maxDetail]) + "… (cut)"
+	}
+	e.Hash = hash(e)
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	l.seq, l.prev = e.Seq, e.Hash
+	return nil
+}
+
+// hash is the hash of an entry with Hash empty: SHA-256 over its JSON,
+// which includes Prev
+func hash(e Entry) string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Read returns the entries of a log file
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*maxDetail+64*1024)
+	for n := 1; scanner.Scan(); n++ {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Verify checks that entries form an unbroken chain, returning an error
+// naming the first entry that was changed, removed or moved
+func Verify(entries []Entry) error {
+	prev := ""
+	for i, e := range entries {
+		switch {
+		case e.Seq != i+1:
+			return fmt.Errorf("entry %d has sequence %d: entries were removed or reordered", i+1, e.Seq)
+		case e.Prev != prev:
+			return fmt.Errorf("entry %d doesn't follow entry %d", e.Seq, i)
+		case hash(e) != e.Hash:
+			return fmt.Errorf("entry %d was modified", e.Seq)
+		}
+		prev = e.Hash
+	}
+	return nil
+}
+
+// Session is a session's log, as List finds it
+type Session struct {
+	ID       string
+	Path     string
+	Modified time.Time
+	Size     int64
+}
+
+// List returns the logs in dir, most recent first
+func List(dir string) ([]Session, error) {
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sessions []Session
+	for _, f := range files {
+		id, ok := strings.CutSuffix(f.Name(), ".jsonl")
+		info, err := f.Info()
+		if !ok || f.IsDir() || err != nil {
+			continue
+		}
+		sessions = append(sessions, Session{ID: id, Path: filepath.Join(dir, f.Name()), Modified: info.ModTime(), Size: info.Size()})
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Modified.After(sessions[j].Modified) })
+	return sessions, nil
+}
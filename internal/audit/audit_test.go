@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLog(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(l.Path()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the file should wait for the first entry: %v", err)
+	}
+
+	l.Record(Entry{Kind: Provider, Action: "ollama/llama3", Detail: "2 messages"})
+	l.Record(Entry{Kind: Tool, Action: "read_files", Detail: `{"paths":["go.mod"]}`})
+
+	// A new process continues the chain
+	l, err = Open(dir, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Record(Entry{Kind: Bash, Action: "ls", Error: "exit status 2"})
+
+	entries, err := Read(l.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[2].Seq != 3 || entries[2].Prev != entries[1].Hash || entries[0].Prev != "" {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if err := Verify(entries); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	if info, _ := os.Stat(l.Path()); info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v", info.Mode())
+	}
+
+	// Long details are cut between characters
+	l.Record(Entry{Kind: Tool, Action: "write_file", Detail: strings.Repeat("é", maxDetail+10)})
+	entries, _ = Read(l.Path())
+	if detail := entries[3].Detail; !utf8.ValidString(detail) || detail != strings.Repeat("é", maxDetail)+"… (cut)" {
+		t.Errorf("cut detail ends with %q", detail[len(detail)-20:])
+	}
+
+	var none *Log
+	if err := none.Record(Entry{Kind: Bash}); err != nil {
+		t.Errorf("nil log: %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	l, _ := Open(t.TempDir(), "")
+	for _, action := range []string{"a", "b", "c"} {
+		l.Record(Entry{Kind: Bash, Action: action})
+	}
+	fresh := func() []Entry {
+		entries, _ := Read(l.Path())
+		return entries
+	}
+
+	tests := []struct {
+		name   string
+		tamper func([]Entry) []Entry
+		want   string
+	}{
+		{"modified", func(e []Entry) []Entry { e[1].Action = "rm -rf /"; return e }, "entry 2 was modified"},
+		{"removed", func(e []Entry) []Entry { return append(e[:1], e[2:]...) }, "entry 2 has sequence 3"},
+		{"rehashed", func(e []Entry) []Entry { e[1].Action = "x"; e[1].Hash = hash(e[1]); return e }, "entry 3 doesn't follow"},
+		{"truncated start", func(e []Entry) []Entry { return e[1:] }, "entry 1 has sequence 2"},
+	}
+	for _, tt := range tests {
+		err := Verify(tt.tamper(fresh()))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestOpen_InvalidSession(t *testing.T) {
+	if _, err := Open(t.TempDir(), "../x"); err == nil {
+		t.Error("a session with a path should fail")
+	}
+}
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	for _, id := range []string{"a", "b"} {
+		l, _ := Open(dir, id)
+		l.Record(Entry{Kind: Bash, Action: "true"})
+	}
+	os.WriteFile(dir+"/notes.txt", nil, 0644)
+	sessions, err := List(dir)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("sessions = %+v, err = %v", sessions, err)
+	}
+	if sessions, err := List(dir + "/missing"); sessions != nil || err != nil {
+		t.Errorf("missing dir = %v, %v", sessions, err)
+	}
+}
@@ -0,0 +1,261 @@
+// Package bridge relays chat platform messages (Slack, Discord) to agents,
+// mapping each channel or thread to its own persistent session
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/session"
+)
+
+// Message is an incoming chat message
+type Message struct {
+	Channel string // Channel ID
+	Thread  string // Thread ID, empty when the platform maps threads to channels
+	ID      string // Message ID
+	User    string // Author display name or ID
+	Text    string
+}
+
+// Platform is a chat service the bridge relays through
+type Platform interface {
+	// Name returns the platform name (e.g., "slack", "discord")
+	Name() string
+
+	// Poll returns messages posted since the previous poll, oldest first
+	Poll(ctx context.Context) ([]Message, error)
+
+	// Reply posts a response to msg and returns the new message ID
+	Reply(ctx context.Context, msg Message, text string) (string, error)
+
+	// Edit replaces the text of a message posted by the bot
+	Edit(ctx context.Context, msg Message, id, text string) error
+}
+
+// Config holds bridge configuration
+type Config struct {
+	Platform     Platform
+	NewAgent     func() *agent.Agent // Creates an agent for a new conversation
+	Sessions     *session.Manager
+	Workdir      string
+	Provider     string
+	Model        string
+	PollInterval time.Duration // How often to poll for new messages
+	EditInterval time.Duration // Minimum time between streaming edits
+}
+
+// Bridge relays messages between a chat platform and agents
+type Bridge struct {
+	platform     Platform
+	newAgent     func() *agent.Agent
+	sessions     *session.Manager
+	workdir      string
+	provider     string
+	model        string
+	pollInterval time.Duration
+	editInterval time.Duration
+
+	mu            sync.Mutex
+	conversations map[string]*conversation
+	queues        map[string][]Message // Messages waiting for their turn, by key, while a worker handles the key's
+	wg            sync.WaitGroup
+}
+
+// conversation is the agent and session bound to one channel or thread
+type conversation struct {
+	mu      sync.Mutex // Serializes turns within a conversation
+	agent   *agent.Agent
+	session *session.Session
+}
+
+// New creates a new bridge
+func New(cfg Config) *Bridge {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 2 * time.Second
+	}
+	if cfg.EditInterval <= 0 {
+		cfg.EditInterval = time.Second
+	}
+	return &Bridge{
+		platform:      cfg.Platform,
+		newAgent:      cfg.NewAgent,
+		sessions:      cfg.Sessions,
+		workdir:       cfg.Workdir,
+		provider:      cfg.Provider,
+		model:         cfg.Model,
+		pollInterval:  cfg.PollInterval,
+		editInterval:  cfg.EditInterval,
+		conversations: make(map[string]*conversation),
+		queues:        make(map[string][]Message),
+	}
+}
+
+// Run polls the platform until ctx is cancelled, handling the messages
+// of each channel or thread in the order they were posted, in the
+// background so one long answer doesn't block other channels
+func (b *Bridge) Run(ctx context.Context) error {
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		msgs, err := b.platform.Poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("%s: poll: %v", b.platform.Name(), err)
+		}
+		for _, msg := range msgs {
+			b.enqueue(ctx, msg)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+			continue
+		}
+		break
+	}
+
+	b.wg.Wait()
+	return nil
+}
+
+// enqueue queues msg behind the other messages of its channel or thread,
+// starting a worker for the key when none is handling it
+func (b *Bridge) enqueue(ctx context.Context, msg Message) {
+	key := b.Key(msg)
+	b.mu.Lock()
+	queue, busy := b.queues[key]
+	b.queues[key] = append(queue, msg)
+	b.mu.Unlock()
+	if busy {
+		return
+	}
+	b.wg.Add(1)
+	go b.work(ctx, key)
+}
+
+// work handles the messages queued for key one at a time, oldest first,
+// until none are left or ctx is cancelled
+func (b *Bridge) work(ctx context.Context, key string) {
+	defer b.wg.Done()
+	for {
+		b.mu.Lock()
+		queue := b.queues[key]
+		if len(queue) == 0 || ctx.Err() != nil {
+			delete(b.queues, key)
+			b.mu.Unlock()
+			return
+		}
+		msg := queue[0]
+		b.queues[key] = queue[1:]
+		b.mu.Unlock()
+
+		if err := b.Handle(ctx, msg); err != nil {
+			log.Printf("%s: %v", b.platform.Name(), err)
+		}
+	}
+}
+
+// Key returns the session key for a message's channel or thread
+func (b *Bridge) Key(msg Message) string {
+	key := b.platform.Name() + ":" + msg.Channel
+	if msg.Thread != "" {
+		key += ":" + msg.Thread
+	}
+	return key
+}
+
+// Handle relays one message to its conversation's agent, streaming the
+// answer into a reply that is edited as chunks arrive
+func (b *Bridge) Handle(ctx context.Context, msg Message) error {
+	text := strings.TrimSpace(msg.Text)
+	if text == "" {
+		return nil
+	}
+
+	conv := b.conversation(b.Key(msg))
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+
+	replyID, err := b.platform.Reply(ctx, msg, "…")
+	if err != nil {
+		return fmt.Errorf("reply: %w", err)
+	}
+
+	chunks, err := conv.agent.Stream(ctx, text)
+	if err != nil {
+		b.platform.Edit(ctx, msg, replyID, fmt.Sprintf("Error: %v", err))
+		return fmt.Errorf("stream: %w", err)
+	}
+
+	var resp strings.Builder
+	lastEdit := time.Now()
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			b.platform.Edit(ctx, msg, replyID, fmt.Sprintf("Error: %v", chunk.Error))
+			return fmt.Errorf("stream: %w", chunk.Error)
+		}
+		resp.WriteString(chunk.Content)
+		if time.Since(lastEdit) >= b.editInterval && resp.Len() > 0 {
+			b.platform.Edit(ctx, msg, replyID, resp.String()+" …")
+			lastEdit = time.Now()
+		}
+	}
+
+	final := resp.String()
+	if final == "" {
+		final = "(empty response)"
+	}
+	if err := b.platform.Edit(ctx, msg, replyID, final); err != nil {
+		return fmt.Errorf("edit: %w", err)
+	}
+
+	return b.save(conv)
+}
+
+// conversation returns the conversation for key, restoring a saved session if one exists
+func (b *Bridge) conversation(key string) *conversation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if conv, ok := b.conversations[key]; ok {
+		return conv
+	}
+
+	conv := &conversation{agent: b.newAgent()}
+	if b.sessions != nil {
+		if sess, err := b.sessions.GetByNameOrID(key); err == nil && sess.Name == key {
+			conv.session = sess
+			for _, m := range sess.Messages {
+				conv.agent.AddMessage(m.Role, m.Content)
+			}
+			conv.agent.SetActivity(sess.Activity())
+		}
+	}
+	if conv.session == nil {
+		conv.session = session.New(b.workdir, b.provider, b.model)
+		conv.session.Name = key
+	}
+
+	b.conversations[key] = conv
+	return conv
+}
+
+// save syncs the agent history into the session and persists it
+func (b *Bridge) save(conv *conversation) error {
+	if b.sessions == nil {
+		return nil
+	}
+	conv.session.Messages = append(conv.session.Messages[:0], conv.agent.Messages()...)
+	conv.session.SetActivity(conv.agent.Activity())
+	conv.session.UpdatedAt = time.Now()
+	return b.sessions.Save(conv.session)
+}
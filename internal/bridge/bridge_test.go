@@ -0,0 +1,237 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/agentflow/agentflow/pkg/providertest"
+)
+
+// fakePlatform records replies and edits
+type fakePlatform struct {
+	mu      sync.Mutex
+	replies []string
+	edits   map[string]string
+}
+
+func (f *fakePlatform) Name() string { return "fake" }
+
+func (f *fakePlatform) Poll(ctx context.Context) ([]Message, error) { return nil, nil }
+
+func (f *fakePlatform) Reply(ctx context.Context, msg Message, text string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := "reply-" + msg.ID
+	f.replies = append(f.replies, id)
+	return id, nil
+}
+
+func (f *fakePlatform) Edit(ctx context.Context, msg Message, id, text string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.edits == nil {
+		f.edits = make(map[string]string)
+	}
+	f.edits[id] = text
+	return nil
+}
+
+func TestBridge_Handle(t *testing.T) {
+	platform := &fakePlatform{}
+	mgr := session.NewManager(t.TempDir())
+	p := providertest.Streamed(0, "Hello", ", ", "team!")
+
+	b := New(Config{
+		Platform: platform,
+		NewAgent: func() *agent.Agent { return agent.New(agent.Config{Provider: p, Model: "test-model"}) },
+		Sessions: mgr,
+		Model:    "test-model",
+	})
+
+	msg := Message{Channel: "C1", Thread: "T1", ID: "m1", Text: "hi"}
+	if err := b.Handle(context.Background(), msg); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+
+	if got := platform.edits["reply-m1"]; got != "Hello, team!" {
+		t.Errorf("final edit = %q, want %q", got, "Hello, team!")
+	}
+
+	// The thread maps to a named, persisted session
+	sess, err := mgr.GetByNameOrID("fake:C1:T1")
+	if err != nil {
+		t.Fatalf("session not saved: %v", err)
+	}
+	if len(sess.Messages) != 2 {
+		t.Errorf("session messages = %d, want 2", len(sess.Messages))
+	}
+
+	// A second message in the same thread continues the conversation
+	b.Handle(context.Background(), Message{Channel: "C1", Thread: "T1", ID: "m2", Text: "again"})
+	if conv := b.conversation("fake:C1:T1"); len(conv.agent.Messages()) != 4 {
+		t.Errorf("agent messages = %d, want 4", len(conv.agent.Messages()))
+	}
+
+	// A different thread gets its own conversation
+	b.Handle(context.Background(), Message{Channel: "C1", Thread: "T2", ID: "m3", Text: "new"})
+	if conv := b.conversation("fake:C1:T2"); len(conv.agent.Messages()) != 2 {
+		t.Errorf("new thread messages = %d, want 2", len(conv.agent.Messages()))
+	}
+}
+
+// queuedPlatform returns its messages on the first poll and cancels the
+// run once they all have a reply
+type queuedPlatform struct {
+	fakePlatform
+	msgs   []Message
+	cancel context.CancelFunc
+	polled bool
+}
+
+func (q *queuedPlatform) Poll(ctx context.Context) ([]Message, error) {
+	if !q.polled {
+		q.polled = true
+		return q.msgs, nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.replies) == len(q.msgs) && len(q.edits) == len(q.msgs) {
+		q.cancel()
+	}
+	return nil, nil
+}
+
+func TestBridge_RunInOrder(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	platform := &queuedPlatform{cancel: cancel}
+	for _, id := range []string{"m1", "m2", "m3", "m4", "m5"} {
+		platform.msgs = append(platform.msgs, Message{Channel: "C1", Thread: "T1", ID: id, Text: id})
+	}
+	p := providertest.New("ok")
+	p.Latency = 5 * time.Millisecond
+
+	b := New(Config{
+		Platform:     platform,
+		NewAgent:     func() *agent.Agent { return agent.New(agent.Config{Provider: p, Model: "test-model"}) },
+		Model:        "test-model",
+		PollInterval: time.Millisecond,
+	})
+	b.Run(ctx)
+
+	want := []string{"reply-m1", "reply-m2", "reply-m3", "reply-m4", "reply-m5"}
+	if strings.Join(platform.replies, " ") != strings.Join(want, " ") {
+		t.Errorf("replies = %v, want %v", platform.replies, want)
+	}
+	var asked []string
+	for _, m := range b.conversation("fake:C1:T1").agent.Messages() {
+		if m.Role == "user" {
+			asked = append(asked, m.Content)
+		}
+	}
+	if got := strings.Join(asked, " "); got != "m1 m2 m3 m4 m5" {
+		t.Errorf("turns = %q, want in the order posted", got)
+	}
+	if len(b.queues) != 0 {
+		t.Errorf("queues left = %v", b.queues)
+	}
+}
+
+func TestSlack_PollAndReply(t *testing.T) {
+	var posted map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/conversations.history":
+			w.Write([]byte(`{"ok":true,"messages":[
+				{"user":"U2","text":"second","ts":"9999999999.000002"},
+				{"bot_id":"B1","text":"bot says","ts":"9999999999.000003"},
+				{"user":"U1","text":"first","ts":"9999999999.000001"}
+			]}`))
+		case "/conversations.replies":
+			w.Write([]byte(`{"ok":true,"messages":[]}`))
+		case "/chat.postMessage":
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.Write([]byte(`{"ok":true,"ts":"9999999999.000010"}`))
+		default:
+			w.Write([]byte(`{"ok":false,"error":"unknown_method"}`))
+		}
+	}))
+	defer server.Close()
+
+	s := NewSlack("xoxb-test", []string{"C1"})
+	s.SetBaseURL(server.URL)
+
+	msgs, err := s.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("messages = %d, want 2 (bot message skipped)", len(msgs))
+	}
+	if msgs[0].Text != "first" || msgs[0].Thread != msgs[0].ID {
+		t.Errorf("msgs[0] = %+v", msgs[0])
+	}
+
+	id, err := s.Reply(context.Background(), msgs[0], "answer")
+	if err != nil {
+		t.Fatalf("Reply: %v", err)
+	}
+	if id != "9999999999.000010" {
+		t.Errorf("reply id = %q", id)
+	}
+	if posted["thread_ts"] != msgs[0].ID {
+		t.Errorf("reply not threaded: %v", posted)
+	}
+
+	// Already-seen messages are not relayed again
+	msgs, _ = s.Poll(context.Background())
+	if len(msgs) != 0 {
+		t.Errorf("second poll returned %d messages, want 0", len(msgs))
+	}
+}
+
+func TestDiscord_Poll(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bot test-token" {
+			t.Errorf("auth = %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`[
+			{"id":"99999999999999999999","content":"newer","author":{"username":"bob"}},
+			{"id":"99999999999999999998","content":"from bot","author":{"username":"agentflow","bot":true}},
+			{"id":"9999999999999999999","content":"older","author":{"username":"alice"}}
+		]`))
+	}))
+	defer server.Close()
+
+	d := NewDiscord("test-token", []string{"123"})
+	d.SetBaseURL(server.URL)
+
+	msgs, err := d.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("messages = %d, want 2", len(msgs))
+	}
+	if msgs[0].Text != "older" || msgs[1].Text != "newer" {
+		t.Errorf("messages out of order: %+v", msgs)
+	}
+}
+
+func TestClampContent(t *testing.T) {
+	long := strings.Repeat("a", discordMaxContent+10)
+	if got := []rune(clampContent(long)); len(got) != discordMaxContent {
+		t.Errorf("clamped length = %d, want %d", len(got), discordMaxContent)
+	}
+	if clampContent("short") != "short" {
+		t.Error("short content should be unchanged")
+	}
+}
@@ -0,0 +1,179 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DiscordAPIURL is the Discord REST API endpoint
+	DiscordAPIURL = "https://discord.com/api/v10"
+
+	// discordMaxContent is Discord's per-message character limit
+	discordMaxContent = 2000
+
+	// discordEpoch is the first millisecond of 2015, the snowflake epoch
+	discordEpoch = 1420070400000
+)
+
+// Discord polls channels through the Discord REST API using a bot token.
+// Each channel (threads are channels in Discord) is one conversation.
+// The bot needs the Message Content intent to read message text.
+type Discord struct {
+	baseURL  string
+	token    string
+	channels []string
+	client   *http.Client
+
+	mu      sync.Mutex
+	cursors map[string]string // channel -> newest seen message ID
+}
+
+// NewDiscord creates a Discord platform for the given channel IDs
+func NewDiscord(token string, channels []string) *Discord {
+	return &Discord{
+		baseURL:  DiscordAPIURL,
+		token:    token,
+		channels: channels,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		cursors:  make(map[string]string),
+	}
+}
+
+// SetBaseURL overrides the API endpoint (used in tests)
+func (d *Discord) SetBaseURL(u string) {
+	d.baseURL = strings.TrimSuffix(u, "/")
+}
+
+func (d *Discord) Name() string {
+	return "discord"
+}
+
+type discordMessage struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+	Author  struct {
+		Username string `json:"username"`
+		Bot      bool   `json:"bot"`
+	} `json:"author"`
+}
+
+func (d *Discord) Poll(ctx context.Context) ([]Message, error) {
+	var out []Message
+	for _, ch := range d.channels {
+		d.mu.Lock()
+		after, ok := d.cursors[ch]
+		if !ok {
+			// Start from now; don't replay channel history
+			after = strconv.FormatInt((time.Now().UnixMilli()-discordEpoch)<<22, 10)
+			d.cursors[ch] = after
+		}
+		d.mu.Unlock()
+
+		q := url.Values{"after": {after}, "limit": {"50"}}
+		var msgs []discordMessage
+		if err := d.call(ctx, "GET", "/channels/"+ch+"/messages?"+q.Encode(), nil, &msgs); err != nil {
+			return out, err
+		}
+
+		// Discord returns newest first; relay oldest first
+		sort.Slice(msgs, func(i, j int) bool { return snowflakeLess(msgs[i].ID, msgs[j].ID) })
+
+		for _, m := range msgs {
+			d.mu.Lock()
+			if snowflakeLess(d.cursors[ch], m.ID) {
+				d.cursors[ch] = m.ID
+			}
+			d.mu.Unlock()
+
+			if m.Author.Bot {
+				continue
+			}
+			out = append(out, Message{Channel: ch, ID: m.ID, User: m.Author.Username, Text: m.Content})
+		}
+	}
+	return out, nil
+}
+
+func (d *Discord) Reply(ctx context.Context, msg Message, text string) (string, error) {
+	body := map[string]any{
+		"content":           clampContent(text),
+		"message_reference": map[string]string{"message_id": msg.ID},
+	}
+	var created discordMessage
+	if err := d.call(ctx, "POST", "/channels/"+msg.Channel+"/messages", body, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+func (d *Discord) Edit(ctx context.Context, msg Message, id, text string) error {
+	body := map[string]string{"content": clampContent(text)}
+	return d.call(ctx, "PATCH", "/channels/"+msg.Channel+"/messages/"+id, body, nil)
+}
+
+func (d *Discord) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, d.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bot "+d.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("discord error %d: %s", resp.StatusCode, string(respBody))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// snowflakeLess compares two snowflake IDs numerically
+func snowflakeLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// clampContent keeps the tail of long answers within Discord's limit,
+// since the newest text is what a streaming reader is waiting for
+func clampContent(text string) string {
+	runes := []rune(text)
+	if len(runes) <= discordMaxContent {
+		return text
+	}
+	return "…" + string(runes[len(runes)-discordMaxContent+1:])
+}
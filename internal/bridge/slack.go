@@ -0,0 +1,185 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SlackAPIURL is the Slack Web API endpoint
+const SlackAPIURL = "https://slack.com/api"
+
+// Slack polls channels through the Slack Web API using a bot token.
+// Every top-level message starts a thread; replies in that thread
+// continue the same conversation.
+type Slack struct {
+	baseURL  string
+	token    string
+	channels []string
+	client   *http.Client
+
+	mu      sync.Mutex
+	cursors map[string]string          // channel -> newest seen ts
+	threads map[string]map[string]bool // channel -> active thread ts
+}
+
+// NewSlack creates a Slack platform for the given channel IDs
+func NewSlack(token string, channels []string) *Slack {
+	return &Slack{
+		baseURL:  SlackAPIURL,
+		token:    token,
+		channels: channels,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		cursors:  make(map[string]string),
+		threads:  make(map[string]map[string]bool),
+	}
+}
+
+// SetBaseURL overrides the API endpoint (used in tests)
+func (s *Slack) SetBaseURL(u string) {
+	s.baseURL = strings.TrimSuffix(u, "/")
+}
+
+func (s *Slack) Name() string {
+	return "slack"
+}
+
+type slackMessage struct {
+	User     string `json:"user"`
+	Text     string `json:"text"`
+	TS       string `json:"ts"`
+	ThreadTS string `json:"thread_ts"`
+	BotID    string `json:"bot_id"`
+	Subtype  string `json:"subtype"`
+}
+
+type slackResponse struct {
+	OK       bool           `json:"ok"`
+	Error    string         `json:"error"`
+	TS       string         `json:"ts"`
+	Messages []slackMessage `json:"messages"`
+}
+
+func (s *Slack) Poll(ctx context.Context) ([]Message, error) {
+	var out []Message
+	for _, ch := range s.channels {
+		s.mu.Lock()
+		oldest, ok := s.cursors[ch]
+		if !ok {
+			// Start from now; don't replay channel history
+			oldest = strconv.FormatInt(time.Now().Unix(), 10) + ".000000"
+			s.cursors[ch] = oldest
+		}
+		threads := make([]string, 0, len(s.threads[ch]))
+		for ts := range s.threads[ch] {
+			threads = append(threads, ts)
+		}
+		s.mu.Unlock()
+
+		q := url.Values{"channel": {ch}, "oldest": {oldest}, "limit": {"100"}}
+		var resp slackResponse
+		if err := s.call(ctx, "GET", "conversations.history?"+q.Encode(), nil, &resp); err != nil {
+			return out, err
+		}
+		msgs := resp.Messages
+
+		for _, thread := range threads {
+			q := url.Values{"channel": {ch}, "ts": {thread}, "oldest": {oldest}}
+			var replies slackResponse
+			if err := s.call(ctx, "GET", "conversations.replies?"+q.Encode(), nil, &replies); err != nil {
+				return out, err
+			}
+			msgs = append(msgs, replies.Messages...)
+		}
+
+		// Slack returns newest first; relay oldest first
+		sort.Slice(msgs, func(i, j int) bool { return msgs[i].TS < msgs[j].TS })
+
+		seen := make(map[string]bool)
+		for _, m := range msgs {
+			if m.TS <= oldest || seen[m.TS] || m.BotID != "" || m.Subtype != "" {
+				continue
+			}
+			seen[m.TS] = true
+
+			thread := m.ThreadTS
+			if thread == "" {
+				thread = m.TS
+			}
+			out = append(out, Message{Channel: ch, Thread: thread, ID: m.TS, User: m.User, Text: m.Text})
+
+			s.mu.Lock()
+			if m.TS > s.cursors[ch] {
+				s.cursors[ch] = m.TS
+			}
+			if s.threads[ch] == nil {
+				s.threads[ch] = make(map[string]bool)
+			}
+			s.threads[ch][thread] = true
+			s.mu.Unlock()
+		}
+	}
+	return out, nil
+}
+
+func (s *Slack) Reply(ctx context.Context, msg Message, text string) (string, error) {
+	var resp slackResponse
+	body := map[string]string{"channel": msg.Channel, "thread_ts": msg.Thread, "text": text}
+	if err := s.call(ctx, "POST", "chat.postMessage", body, &resp); err != nil {
+		return "", err
+	}
+	return resp.TS, nil
+}
+
+func (s *Slack) Edit(ctx context.Context, msg Message, id, text string) error {
+	body := map[string]string{"channel": msg.Channel, "ts": id, "text": text}
+	return s.call(ctx, "POST", "chat.update", body, &slackResponse{})
+}
+
+// call invokes a Web API method; Slack reports errors in the body with HTTP 200
+func (s *Slack) call(ctx context.Context, method, path string, in any, out *slackResponse) error {
+	var body *bytes.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	} else {
+		body = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+"/"+path, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack error: status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if !out.OK {
+		return fmt.Errorf("slack error: %s", out.Error)
+	}
+	return nil
+}
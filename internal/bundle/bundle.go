@@ -0,0 +1,254 @@
+// Package bundle saves the context assembled in a session — pinned files,
+// files mentioned in the conversation, fetched docs, pinned messages and
+// the git state — under a name, so it can be loaded into any later
+// session instead of being assembled again.
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"gopkg.in/yaml.v3"
+)
+
+// Dir is where bundles are stored, relative to the project root
+const Dir = ".agentflow/contexts"
+
+// Bundle is a named set of context. Paths are relative to the project root.
+type Bundle struct {
+	Name     string    `yaml:"name"`
+	Saved    time.Time `yaml:"saved"`
+	Pinned   []string  `yaml:"pinned,omitempty"`   // Files sent with every request
+	Files    []string  `yaml:"files,omitempty"`    // Files whose contents were added once
+	Docs     []Doc     `yaml:"docs,omitempty"`     // Retrieved context other than files
+	Messages []Message `yaml:"messages,omitempty"` // Pinned messages
+	Git      *GitState `yaml:"git,omitempty"`
+}
+
+// Doc is a named piece of retrieved context
+type Doc struct {
+	Name    string `yaml:"name"`
+	Content string `yaml:"content"`
+}
+
+// Message is a pinned message
+type Message struct {
+	Role    string `yaml:"role"`
+	Content string `yaml:"content"`
+}
+
+// GitState records where the repository was when a bundle was saved
+type GitState struct {
+	Branch string `yaml:"branch"`
+	Commit string `yaml:"commit"`
+	Status string `yaml:"status,omitempty"` // git status --short
+}
+
+// ErrNotFound is returned when no bundle has the requested name
+var ErrNotFound = errors.New("context bundle not found")
+
+var nameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Capture collects an agent's context into a bundle
+func Capture(ag *agent.Agent, root, name string) (*Bundle, error) {
+	if !nameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid bundle name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+
+	b := &Bundle{Name: name, Saved: time.Now(), Git: currentGit(root)}
+	seen := make(map[string]bool)
+	for _, path := range ag.PinnedFiles() {
+		b.Pinned = append(b.Pinned, relPath(root, path))
+		seen[path] = true
+	}
+	for _, path := range ag.TrackedFiles() {
+		if !seen[path] {
+			b.Files = append(b.Files, relPath(root, path))
+			seen[relPath(root, path)] = true
+		}
+	}
+	for _, item := range ag.ContextItems(agent.SourceRetrieved) {
+		if !seen[item.Name] {
+			b.Docs = append(b.Docs, Doc{Name: item.Name, Content: item.Content})
+		}
+	}
+	history := ag.Messages()
+	for _, i := range ag.PinnedMessages() {
+		b.Messages = append(b.Messages, Message{Role: history[i].Role, Content: history[i].Content})
+	}
+
+	if len(b.Pinned) == 0 && len(b.Files) == 0 && len(b.Docs) == 0 && len(b.Messages) == 0 {
+		return nil, errors.New("nothing to save: pin files or messages, or mention files first")
+	}
+	return b, nil
+}
+
+// Save writes a bundle under root, replacing one of the same name
+func Save(root string, b *Bundle) error {
+	dir := filepath.Join(root, Dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create contexts dir: %w", err)
+	}
+	data, err := yaml.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("marshal bundle: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, b.Name+".yaml"), data, 0644)
+}
+
+// Load reads the named bundle from root
+func Load(root, name string) (*Bundle, error) {
+	if !nameRegex.MatchString(name) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	data, err := os.ReadFile(filepath.Join(root, Dir, name+".yaml"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var b Bundle
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parse bundle %s: %w", name, err)
+	}
+	return &b, nil
+}
+
+// List returns the names of the bundles saved under root, sorted
+func List(root string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(root, Dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".yaml"); ok && !e.IsDir() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Apply loads a bundle into an agent: files are pinned again, mentioned
+// files are re-read from disk, docs and pinned messages are restored, and
+// the model is told how the repository moved since the bundle was saved.
+// Files that no longer exist are returned rather than failing the load.
+func Apply(ag *agent.Agent, root string, b *Bundle) (missing []string) {
+	for _, path := range b.Pinned {
+		if err := ag.PinFile(absPath(root, path)); err != nil {
+			missing = append(missing, path)
+		}
+	}
+
+	for _, path := range b.Files {
+		full := absPath(root, path)
+		data, err := os.ReadFile(full)
+		if err != nil {
+			missing = append(missing, path)
+			continue
+		}
+		ag.AddContext(agent.SourceRetrieved, path, "```\n"+strings.TrimRight(string(data), "\n")+"\n```")
+		ag.TrackFile(full)
+	}
+	for _, doc := range b.Docs {
+		ag.AddContext(agent.SourceRetrieved, doc.Name, doc.Content)
+	}
+
+	for _, msg := range b.Messages {
+		ag.AddMessage(msg.Role, msg.Content)
+		ag.PinMessage(len(ag.Messages())-1, true)
+	}
+
+	if note := gitNote(root, b); note != "" {
+		ag.AddContext(agent.SourceGit, "Context bundle "+b.Name, note)
+	}
+	return missing
+}
+
+// Summary describes a bundle in one line
+func (b *Bundle) Summary() string {
+	parts := []string{fmt.Sprintf("%d pinned", len(b.Pinned)), fmt.Sprintf("%d files", len(b.Files)), fmt.Sprintf("%d docs", len(b.Docs)), fmt.Sprintf("%d messages", len(b.Messages))}
+	summary := strings.Join(parts, ", ")
+	if b.Git != nil {
+		summary += fmt.Sprintf(" — %s@%s", b.Git.Branch, shortHash(b.Git.Commit))
+	}
+	return summary
+}
+
+// gitNote tells the model where the bundle was saved and what moved
+// since, or "" outside a repository
+func gitNote(root string, b *Bundle) string {
+	if b.Git == nil {
+		return ""
+	}
+	note := fmt.Sprintf("Context bundle %q was saved on branch %s at commit %s", b.Name, b.Git.Branch, shortHash(b.Git.Commit))
+	if b.Git.Status != "" {
+		note += " with uncommitted changes:\n" + b.Git.Status
+	} else {
+		note += "."
+	}
+
+	now := currentGit(root)
+	if now == nil || now.Commit == b.Git.Commit {
+		return note
+	}
+	note += fmt.Sprintf("\nThe repository is now on %s at %s.", now.Branch, shortHash(now.Commit))
+	if log, err := git(root, "log", "--oneline", "-20", b.Git.Commit+"..HEAD"); err == nil && log != "" {
+		note += " Commits since:\n" + log
+	}
+	return note
+}
+
+// currentGit returns the repository state at root, or nil outside one
+func currentGit(root string) *GitState {
+	commit, err := git(root, "rev-parse", "HEAD")
+	if err != nil {
+		return nil
+	}
+	branch, _ := git(root, "rev-parse", "--abbrev-ref", "HEAD")
+	status, _ := git(root, "status", "--short")
+	return &GitState{Branch: branch, Commit: commit, Status: status}
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// absPath resolves a bundle path against root
+func absPath(root, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(root, filepath.FromSlash(path))
+}
+
+// relPath shortens path relative to root when it is inside it
+func relPath(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
@@ -0,0 +1,86 @@
+package bundle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentflow/agentflow/internal/agent"
+)
+
+func TestSaveLoadApply(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "api.go"), []byte("package api\n"), 0644)
+	os.WriteFile(filepath.Join(root, "notes.md"), []byte("old notes\n"), 0644)
+
+	ag := agent.New(agent.Config{})
+	if err := ag.PinFile(filepath.Join(root, "api.go")); err != nil {
+		t.Fatal(err)
+	}
+	ag.TrackFile(filepath.Join(root, "notes.md"))
+	ag.AddContext(agent.SourceRetrieved, "notes.md", "old notes")
+	ag.AddContext(agent.SourceRetrieved, "https://example.com/api", "v2 reference")
+	ag.AddMessage("user", "Docs: the v2 API uses cursors")
+	ag.PinMessage(0, true)
+	ag.AddMessage("assistant", "ok")
+
+	b, err := Capture(ag, root, "api-refactor")
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	if err := Save(root, b); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if names, _ := List(root); len(names) != 1 || names[0] != "api-refactor" {
+		t.Errorf("List = %v", names)
+	}
+
+	loaded, err := Load(root, "api-refactor")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(loaded.Pinned) != 1 || loaded.Pinned[0] != "api.go" || len(loaded.Files) != 1 || loaded.Files[0] != "notes.md" ||
+		len(loaded.Docs) != 1 || loaded.Docs[0].Name != "https://example.com/api" {
+		t.Errorf("loaded = %+v", loaded)
+	}
+
+	// Mentioned files are re-read, so edits since saving are picked up
+	os.WriteFile(filepath.Join(root, "notes.md"), []byte("new notes\n"), 0644)
+	fresh := agent.New(agent.Config{})
+	if missing := Apply(fresh, root, loaded); len(missing) != 0 {
+		t.Errorf("missing = %v", missing)
+	}
+	if pinned := fresh.PinnedFiles(); len(pinned) != 1 || pinned[0] != filepath.Join(root, "api.go") {
+		t.Errorf("pinned = %v", pinned)
+	}
+	items := fresh.ContextItems(agent.SourceRetrieved)
+	if len(items) != 2 || !strings.Contains(items[0].Content, "new notes") || items[1].Content != "v2 reference" {
+		t.Errorf("retrieved = %+v", items)
+	}
+	msgs := fresh.Messages()
+	if len(msgs) != 1 || msgs[0].Content != "Docs: the v2 API uses cursors" || !msgs[0].Pinned {
+		t.Errorf("messages = %+v", msgs)
+	}
+}
+
+func TestApply_Missing(t *testing.T) {
+	root := t.TempDir()
+	missing := Apply(agent.New(agent.Config{}), root, &Bundle{Name: "x", Pinned: []string{"gone.go"}, Files: []string{"gone.md"}})
+	if len(missing) != 2 {
+		t.Errorf("missing = %v", missing)
+	}
+}
+
+func TestCapture_Errors(t *testing.T) {
+	if _, err := Capture(agent.New(agent.Config{}), t.TempDir(), "empty"); err == nil {
+		t.Error("expected an error for an empty context")
+	}
+	if _, err := Capture(agent.New(agent.Config{}), t.TempDir(), "../escape"); err == nil {
+		t.Error("expected an error for an invalid name")
+	}
+	if _, err := Load(t.TempDir(), "nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load err = %v, want ErrNotFound", err)
+	}
+}
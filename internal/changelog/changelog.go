@@ -0,0 +1,191 @@
+// Package changelog collects the commits between two git refs and groups
+// them by conventional commit type for release notes
+package changelog
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Commit is one change in the range
+type Commit struct {
+	Hash     string
+	Type     string // Conventional type ("feat", "fix", ...); "" when the subject has none
+	Scope    string
+	Subject  string // Without the type prefix and PR suffix
+	Breaking bool
+	PR       int // Pull request number, 0 when unknown
+}
+
+// Section is a group of commits under one heading
+type Section struct {
+	Title   string
+	Commits []Commit
+}
+
+// sections orders headings; types not listed fall under "Other changes"
+var sections = []struct {
+	title string
+	types []string
+}{
+	{"Features", []string{"feat", "feature"}},
+	{"Bug fixes", []string{"fix", "bugfix"}},
+	{"Performance", []string{"perf"}},
+	{"Documentation", []string{"docs", "doc"}},
+	{"Maintenance", []string{"refactor", "chore", "build", "ci", "test", "style", "deps"}},
+}
+
+var (
+	conventionalRegex = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+	prSuffixRegex     = regexp.MustCompile(`\s*\(#(\d+)\)$`)
+	mergeRegex        = regexp.MustCompile(`^Merge pull request #(\d+) from \S+`)
+)
+
+// ParseCommit reads a commit from its subject and body. Merge commits of
+// pull requests take their subject from the body's first line.
+func ParseCommit(hash, subject, body string) Commit {
+	c := Commit{Hash: hash}
+	if m := mergeRegex.FindStringSubmatch(subject); m != nil {
+		c.PR, _ = strconv.Atoi(m[1])
+		subject, _, _ = strings.Cut(strings.TrimSpace(body), "\n")
+	}
+	if m := prSuffixRegex.FindStringSubmatch(subject); m != nil {
+		c.PR, _ = strconv.Atoi(m[1])
+		subject = subject[:len(subject)-len(m[0])]
+	}
+	if m := conventionalRegex.FindStringSubmatch(subject); m != nil {
+		c.Type, c.Scope, c.Breaking, subject = strings.ToLower(m[1]), m[2], m[3] == "!", m[4]
+	}
+	if strings.Contains(body, "BREAKING CHANGE") {
+		c.Breaking = true
+	}
+	c.Subject = strings.TrimSpace(subject)
+	return c
+}
+
+// Collect returns the commits in from..to, oldest first, skipping merges
+// that aren't pull requests and the commits a pull request merge brought in
+func Collect(dir, from, to string) ([]Commit, error) {
+	if to == "" {
+		to = "HEAD"
+	}
+	// Unit and record separators keep multi-line bodies intact
+	cmd := exec.Command("git", "log", "--reverse", "--first-parent", "--format=%H%x1f%s%x1f%b%x1e", from+".."+to)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git log: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(string(out), "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(record), "\x1f", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		body := ""
+		if len(fields) == 3 {
+			body = fields[2]
+		}
+		if strings.HasPrefix(fields[1], "Merge ") && !mergeRegex.MatchString(fields[1]) {
+			continue
+		}
+		commits = append(commits, ParseCommit(fields[0], fields[1], body))
+	}
+	return commits, nil
+}
+
+// Group sorts commits into sections, breaking changes first, dropping
+// empty sections
+func Group(commits []Commit) []Section {
+	breaking := Section{Title: "Breaking changes"}
+	grouped := make([]Section, len(sections))
+	other := Section{Title: "Other changes"}
+	for i, s := range sections {
+		grouped[i].Title = s.title
+	}
+
+	for _, c := range commits {
+		if c.Breaking {
+			breaking.Commits = append(breaking.Commits, c)
+			continue
+		}
+		placed := false
+		for i, s := range sections {
+			for _, t := range s.types {
+				if c.Type == t {
+					grouped[i].Commits = append(grouped[i].Commits, c)
+					placed = true
+				}
+			}
+		}
+		if !placed {
+			other.Commits = append(other.Commits, c)
+		}
+	}
+
+	var out []Section
+	for _, s := range append(append([]Section{breaking}, grouped...), other) {
+		if len(s.Commits) > 0 {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Markdown renders sections as release notes. With repo ("owner/name")
+// pull request numbers become links.
+func Markdown(sections []Section, repo string) string {
+	var sb strings.Builder
+	for i, s := range sections {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("## " + s.Title + "\n\n")
+		for _, c := range s.Commits {
+			sb.WriteString("- " + Line(c, repo) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// Line renders one commit as a bullet's text
+func Line(c Commit, repo string) string {
+	line := c.Subject
+	if c.Scope != "" {
+		line = fmt.Sprintf("**%s:** %s", c.Scope, line)
+	}
+	switch {
+	case c.PR > 0 && repo != "":
+		line += fmt.Sprintf(" ([#%d](https://github.com/%s/pull/%d))", c.PR, repo, c.PR)
+	case c.PR > 0:
+		line += fmt.Sprintf(" (#%d)", c.PR)
+	case len(c.Hash) >= 7:
+		line += " (" + c.Hash[:7] + ")"
+	}
+	return line
+}
+
+// Prompt asks a model to turn grouped commits into release notes
+func Prompt(version string, sections []Section, repo string) string {
+	heading := "this release"
+	if version != "" {
+		heading = version
+	}
+	return fmt.Sprintf(`Write release notes for %s from the changes below, grouped by type.
+
+Respond with markdown ready for a GitHub release: a two or three sentence
+summary of the highlights, then the sections below as "## " headings with
+one bullet per user-visible change. Rewrite commit subjects so users
+understand them, merge duplicates, keep the pull request links, and drop
+purely internal changes from every section except "Breaking changes".
+Do not wrap the response in a code block.
+
+%s`, heading, Markdown(sections, repo))
+}
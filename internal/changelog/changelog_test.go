@@ -0,0 +1,91 @@
+package changelog
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestParseCommit(t *testing.T) {
+	cases := []struct {
+		subject, body string
+		want          Commit
+	}{
+		{"feat(tui): add tabs (#42)", "", Commit{Type: "feat", Scope: "tui", Subject: "add tabs", PR: 42}},
+		{"fix!: drop the v1 config", "", Commit{Type: "fix", Subject: "drop the v1 config", Breaking: true}},
+		{"refactor: split agent", "BREAKING CHANGE: Run takes a context", Commit{Type: "refactor", Subject: "split agent", Breaking: true}},
+		{"Merge pull request #7 from ann/retry", "Retry failed requests\n\nDetails", Commit{Subject: "Retry failed requests", PR: 7}},
+		{"Update README", "", Commit{Subject: "Update README"}},
+	}
+	for _, tc := range cases {
+		got := ParseCommit("", tc.subject, tc.body)
+		if got != tc.want {
+			t.Errorf("ParseCommit(%q) = %+v, want %+v", tc.subject, got, tc.want)
+		}
+	}
+}
+
+func TestGroupMarkdown(t *testing.T) {
+	commits := []Commit{
+		{Hash: "aaaaaaa1", Type: "fix", Subject: "handle empty replies"},
+		{Hash: "bbbbbbb2", Type: "feat", Scope: "cli", Subject: "add changelog", PR: 12},
+		{Hash: "ccccccc3", Type: "feat", Subject: "rename flags", Breaking: true},
+		{Hash: "ddddddd4", Subject: "Tidy up"},
+	}
+	got := Markdown(Group(commits), "acme/app")
+	want := `## Breaking changes
+
+- rename flags (ccccccc)
+
+## Features
+
+- **cli:** add changelog ([#12](https://github.com/acme/app/pull/12))
+
+## Bug fixes
+
+- handle empty replies (aaaaaaa)
+
+## Other changes
+
+- Tidy up (ddddddd)
+`
+	if got != want {
+		t.Errorf("Markdown =\n%s", got)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@t", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "chore: initial")
+	git("tag", "v1.0.0")
+	git("commit", "-q", "--allow-empty", "-m", "feat: add export (#3)")
+	git("commit", "-q", "--allow-empty", "-m", "fix: escape quotes", "-m", "Line one\nline two")
+
+	commits, err := Collect(dir, "v1.0.0", "")
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	var subjects []string
+	for _, c := range commits {
+		subjects = append(subjects, c.Type+":"+c.Subject)
+	}
+	if got := strings.Join(subjects, ","); got != "feat:add export,fix:escape quotes" {
+		t.Errorf("commits = %s", got)
+	}
+
+	if _, err := Collect(dir, "v9.9.9", ""); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+}
@@ -0,0 +1,242 @@
+// Package clipboard reads text and images from the system clipboard and
+// writes text to it using platform tools, falling back to OSC 52 terminal
+// escapes for text when no tool is available (e.g. over SSH).
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+)
+
+var (
+	// ErrEmpty is returned when the clipboard holds nothing of the requested kind
+	ErrEmpty = errors.New("clipboard is empty")
+	// ErrUnavailable is returned when no clipboard tool is available
+	ErrUnavailable = errors.New("no clipboard tool available (install wl-clipboard or xclip)")
+)
+
+// osc52Timeout bounds how long to wait for a terminal to answer an OSC 52 query
+const osc52Timeout = 500 * time.Millisecond
+
+// ReadText returns the clipboard's text contents
+func ReadText() (string, error) {
+	for _, args := range textCommands() {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		out, err := exec.Command(args[0], args[1:]...).Output()
+		if err != nil {
+			continue
+		}
+		text := strings.TrimRight(string(out), "\r\n")
+		if text == "" {
+			return "", ErrEmpty
+		}
+		return text, nil
+	}
+
+	return readOSC52()
+}
+
+// WriteText puts text on the clipboard, asking the terminal to do it with
+// OSC 52 when no clipboard tool is installed
+func WriteText(text string) error {
+	for _, args := range writeCommands() {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return ErrUnavailable
+	}
+	defer tty.Close()
+	_, err = tty.WriteString(osc52Write(text))
+	return err
+}
+
+// osc52Write returns the escape sequence that sets the clipboard to text
+func osc52Write(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+}
+
+// ReadImage returns the clipboard's image as PNG bytes
+func ReadImage() ([]byte, error) {
+	if runtime.GOOS == "darwin" {
+		return readImageDarwin()
+	}
+
+	cmds := imageCommands()
+	for _, args := range cmds {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		out, err := exec.Command(args[0], args[1:]...).Output()
+		if err != nil || len(out) == 0 {
+			return nil, ErrEmpty
+		}
+		if runtime.GOOS == "windows" {
+			if out, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(out))); err != nil {
+				return nil, ErrEmpty
+			}
+		}
+		if !IsPNG(out) {
+			return nil, ErrEmpty
+		}
+		return out, nil
+	}
+
+	return nil, ErrUnavailable
+}
+
+// IsPNG reports whether data starts with the PNG signature
+func IsPNG(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n"))
+}
+
+func textCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbpaste"}}
+	case "windows":
+		return [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"}}
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		return [][]string{{"wl-paste", "--no-newline"}, {"xclip", "-selection", "clipboard", "-o"}}
+	}
+	return [][]string{{"xclip", "-selection", "clipboard", "-o"}, {"xsel", "--clipboard", "--output"}}
+}
+
+func writeCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip"}}
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		return [][]string{{"wl-copy"}, {"xclip", "-selection", "clipboard", "-i"}}
+	}
+	return [][]string{{"xclip", "-selection", "clipboard", "-i"}, {"xsel", "--clipboard", "--input"}}
+}
+
+func imageCommands() [][]string {
+	if runtime.GOOS == "windows" {
+		// Print the image as base64 PNG; PowerShell would mangle raw bytes
+		script := `Add-Type -AssemblyName System.Windows.Forms; ` +
+			`$img = [System.Windows.Forms.Clipboard]::GetImage(); ` +
+			`if ($img) { $ms = New-Object System.IO.MemoryStream; ` +
+			`$img.Save($ms, [System.Drawing.Imaging.ImageFormat]::Png); ` +
+			`[Convert]::ToBase64String($ms.ToArray()) }`
+		return [][]string{{"powershell", "-NoProfile", "-Command", script}}
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		return [][]string{{"wl-paste", "--type", "image/png"}, {"xclip", "-selection", "clipboard", "-t", "image/png", "-o"}}
+	}
+	return [][]string{{"xclip", "-selection", "clipboard", "-t", "image/png", "-o"}}
+}
+
+// readImageDarwin asks AppleScript for the clipboard as PNG, which needs
+// no extra tools
+func readImageDarwin() ([]byte, error) {
+	out, err := exec.Command("osascript", "-e", "the clipboard as «class PNGf»").Output()
+	if err != nil {
+		return nil, ErrEmpty
+	}
+	return parseAppleScriptData(string(out))
+}
+
+var appleScriptData = regexp.MustCompile(`«data PNGf([0-9A-Fa-f]+)»`)
+
+// parseAppleScriptData decodes osascript's «data PNGf89504E...» output
+func parseAppleScriptData(out string) ([]byte, error) {
+	m := appleScriptData.FindStringSubmatch(out)
+	if m == nil {
+		return nil, ErrEmpty
+	}
+	data, err := hex.DecodeString(m[1])
+	if err != nil {
+		return nil, fmt.Errorf("decode clipboard image: %w", err)
+	}
+	return data, nil
+}
+
+// readOSC52 asks the terminal for the clipboard with an OSC 52 query.
+// Many terminals disable clipboard reads, so silence means unavailable.
+func readOSC52() (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", ErrUnavailable
+	}
+	defer tty.Close()
+
+	state, err := term.MakeRaw(tty.Fd())
+	if err != nil {
+		return "", ErrUnavailable
+	}
+	defer term.Restore(tty.Fd(), state)
+
+	if _, err := tty.WriteString("\x1b]52;c;?\x07"); err != nil {
+		return "", ErrUnavailable
+	}
+
+	resp := make(chan []byte, 1)
+	go func() {
+		var buf []byte
+		b := make([]byte, 1024)
+		for {
+			n, err := tty.Read(b)
+			buf = append(buf, b[:n]...)
+			if err != nil || bytes.HasSuffix(buf, []byte("\x07")) || bytes.HasSuffix(buf, []byte("\x1b\\")) {
+				resp <- buf
+				return
+			}
+		}
+	}()
+
+	select {
+	case buf := <-resp:
+		return parseOSC52(string(buf))
+	case <-time.After(osc52Timeout):
+		return "", ErrUnavailable
+	}
+}
+
+// parseOSC52 decodes a terminal's "ESC ] 52 ; c ; <base64> BEL" reply
+func parseOSC52(reply string) (string, error) {
+	i := strings.Index(reply, "]52;")
+	if i < 0 {
+		return "", ErrUnavailable
+	}
+	payload := reply[i+len("]52;"):]
+	if j := strings.IndexByte(payload, ';'); j >= 0 {
+		payload = payload[j+1:]
+	}
+	payload = strings.TrimSuffix(strings.TrimSuffix(payload, "\x07"), "\x1b\\")
+	if payload == "" {
+		return "", ErrEmpty
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("decode OSC 52 reply: %w", err)
+	}
+	return string(data), nil
+}
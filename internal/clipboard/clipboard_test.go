@@ -0,0 +1,49 @@
+package clipboard
+
+import (
+	"testing"
+)
+
+func TestParseOSC52(t *testing.T) {
+	got, err := parseOSC52("\x1b]52;c;aGVsbG8gd29ybGQ=\x07")
+	if err != nil || got != "hello world" {
+		t.Errorf("parseOSC52(BEL) = %q, %v", got, err)
+	}
+
+	got, err = parseOSC52("\x1b]52;c;aGk=\x1b\\")
+	if err != nil || got != "hi" {
+		t.Errorf("parseOSC52(ST) = %q, %v", got, err)
+	}
+
+	if _, err := parseOSC52("\x1b]52;c;\x07"); err != ErrEmpty {
+		t.Errorf("empty reply error = %v, want ErrEmpty", err)
+	}
+	if _, err := parseOSC52("garbage"); err != ErrUnavailable {
+		t.Errorf("garbage reply error = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestOSC52Write(t *testing.T) {
+	seq := osc52Write("hello world")
+	if seq != "\x1b]52;c;aGVsbG8gd29ybGQ=\x07" {
+		t.Errorf("osc52Write = %q", seq)
+	}
+	// What we write is what a terminal would echo back
+	if got, err := parseOSC52(seq); err != nil || got != "hello world" {
+		t.Errorf("round trip = %q, %v", got, err)
+	}
+}
+
+func TestParseAppleScriptData(t *testing.T) {
+	data, err := parseAppleScriptData("«data PNGf89504E470D0A1A0A»\n")
+	if err != nil {
+		t.Fatalf("parseAppleScriptData: %v", err)
+	}
+	if !IsPNG(data) {
+		t.Errorf("expected PNG signature, got %x", data)
+	}
+
+	if _, err := parseAppleScriptData("missing value"); err != ErrEmpty {
+		t.Errorf("error = %v, want ErrEmpty", err)
+	}
+}
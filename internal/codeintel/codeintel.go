@@ -0,0 +1,584 @@
+// Package codeintel answers questions about the Go code in a module —
+// where a symbol is defined, where it is used, what a package exports —
+// from its syntax trees, so the agent can look things up without reading
+// whole files. Resolution is by name, not by type checking: references
+// to a method match every selector with that name.
+package codeintel
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/agentflow/agentflow/internal/watch"
+)
+
+// Module is a parsed Go module
+type Module struct {
+	Root     string // Directory containing go.mod
+	Path     string // Module path from go.mod
+	Packages []*Package
+
+	fset    *token.FileSet
+	symbols []*Symbol
+}
+
+// Package is the Go files of one package in a directory. A directory's
+// external test package (foo_test) is a separate Package.
+type Package struct {
+	Name       string
+	Dir        string // Relative to the module root, "." for the root
+	ImportPath string
+	Files      []*ast.File
+}
+
+// Location is a position in the module
+type Location struct {
+	File string // Relative to the module root
+	Line int
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// Symbol is a declared identifier: a package-level func, type, var or
+// const, a method, or a struct field
+type Symbol struct {
+	Location
+	Name      string
+	Kind      string // func, method, type, var, const, field
+	Recv      string // Receiver or struct type for methods and fields
+	Package   *Package
+	Signature string // The declaration without body or comments
+	Doc       string // First sentence of the doc comment
+
+	pos token.Pos
+}
+
+// Qualified returns the symbol as pkg.Name or pkg.Type.Name
+func (s *Symbol) Qualified() string {
+	if s.Recv != "" {
+		return s.Package.Name + "." + s.Recv + "." + s.Name
+	}
+	return s.Package.Name + "." + s.Name
+}
+
+// matches reports whether a query (Name, Type.Name, pkg.Name or
+// pkg.Type.Name) names the symbol
+func (s *Symbol) matches(query string) bool {
+	if query == s.Name || query == s.Qualified() {
+		return true
+	}
+	if s.Recv != "" {
+		return query == s.Recv+"."+s.Name
+	}
+	return false
+}
+
+// FindRoot returns the directory of the go.mod governing dir
+func FindRoot(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Load parses every Go file in the module at root, skipping hidden,
+// vendored and testdata directories and nested modules
+func Load(root string) (*Module, error) {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("read go.mod: %w", err)
+	}
+	m := &Module{Root: root, Path: modulePath(data), fset: token.NewFileSet()}
+
+	pkgs := make(map[string]*Package) // By dir and package name
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p == root {
+				return nil
+			}
+			if watch.SkipDir(d.Name()) || d.Name() == "testdata" {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(m.fset, p, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil && file == nil {
+			return nil
+		}
+		dir, _ := filepath.Rel(root, filepath.Dir(p))
+		key := dir + " " + file.Name.Name
+		pkg, ok := pkgs[key]
+		if !ok {
+			pkg = &Package{Name: file.Name.Name, Dir: filepath.ToSlash(dir), ImportPath: m.Path}
+			if pkg.Dir != "." {
+				pkg.ImportPath = path.Join(m.Path, pkg.Dir)
+			}
+			pkgs[key] = pkg
+			m.Packages = append(m.Packages, pkg)
+		}
+		pkg.Files = append(pkg.Files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pkg := range m.Packages {
+		for _, file := range pkg.Files {
+			m.addSymbols(pkg, file)
+		}
+	}
+	return m, nil
+}
+
+// modulePath returns the path from a go.mod's module line
+func modulePath(gomod []byte) string {
+	for _, line := range strings.Split(string(gomod), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// addSymbols indexes a file's declarations
+func (m *Module) addSymbols(pkg *Package, file *ast.File) {
+	add := func(name *ast.Ident, kind, recv, sig string, doc *ast.CommentGroup) {
+		m.symbols = append(m.symbols, &Symbol{
+			Location:  m.location(name.Pos()),
+			Name:      name.Name,
+			Kind:      kind,
+			Recv:      recv,
+			Package:   pkg,
+			Signature: sig,
+			Doc:       firstSentence(doc),
+			pos:       name.Pos(),
+		})
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil {
+				add(d.Name, "func", "", m.funcSignature(d), d.Doc)
+			} else {
+				add(d.Name, "method", recvType(d), m.funcSignature(d), d.Doc)
+			}
+
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				doc := d.Doc
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Doc != nil {
+						doc = s.Doc
+					}
+					add(s.Name, "type", "", m.typeSignature(s, false), doc)
+					if st, ok := s.Type.(*ast.StructType); ok {
+						for _, f := range st.Fields.List {
+							for _, name := range f.Names {
+								add(name, "field", s.Name.Name, name.Name+" "+types.ExprString(f.Type), f.Doc)
+							}
+						}
+					}
+				case *ast.ValueSpec:
+					if s.Doc != nil {
+						doc = s.Doc
+					}
+					for i, name := range s.Names {
+						add(name, d.Tok.String(), "", valueSignature(d.Tok, s, i), doc)
+					}
+				}
+			}
+		}
+	}
+}
+
+// location converts a position to a module-relative location
+func (m *Module) location(pos token.Pos) Location {
+	p := m.fset.Position(pos)
+	rel, err := filepath.Rel(m.Root, p.Filename)
+	if err != nil {
+		rel = p.Filename
+	}
+	return Location{File: filepath.ToSlash(rel), Line: p.Line}
+}
+
+// funcSignature prints a function declaration without its body
+func (m *Module) funcSignature(d *ast.FuncDecl) string {
+	sig := *d
+	sig.Body, sig.Doc = nil, nil
+	var buf bytes.Buffer
+	printer.Fprint(&buf, m.fset, &sig)
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// typeSignature prints a type declaration. With exportedOnly, struct
+// fields are limited to exported ones, as in go doc.
+func (m *Module) typeSignature(s *ast.TypeSpec, exportedOnly bool) string {
+	st, ok := s.Type.(*ast.StructType)
+	if !ok {
+		var buf bytes.Buffer
+		printer.Fprint(&buf, m.fset, &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{s}})
+		return buf.String()
+	}
+
+	var sb strings.Builder
+	sb.WriteString("type " + s.Name.Name + typeParams(s) + " struct {")
+	shown, hidden := 0, false
+	for _, f := range st.Fields.List {
+		var names []string
+		for _, name := range f.Names {
+			if !exportedOnly || name.IsExported() {
+				names = append(names, name.Name)
+			}
+		}
+		typ := types.ExprString(f.Type)
+		if len(f.Names) == 0 {
+			// Embedded: keep it when the embedded type is exported
+			if !exportedOnly || ast.IsExported(strings.TrimLeft(typ[strings.LastIndex(typ, ".")+1:], "*")) {
+				names = []string{typ}
+				typ = ""
+			} else {
+				hidden = true
+			}
+		} else if len(names) < len(f.Names) {
+			hidden = true
+		}
+		if len(names) > 0 {
+			sb.WriteString("\n\t" + strings.TrimSpace(strings.Join(names, ", ")+" "+typ))
+			shown++
+		}
+	}
+	if hidden {
+		sb.WriteString("\n\t// unexported fields")
+	}
+	if shown == 0 && !hidden {
+		return "type " + s.Name.Name + typeParams(s) + " struct{}"
+	}
+	sb.WriteString("\n}")
+	return sb.String()
+}
+
+// typeParams prints a generic type's parameters, e.g. "[K comparable, V any]"
+func typeParams(s *ast.TypeSpec) string {
+	if s.TypeParams == nil {
+		return ""
+	}
+	var params []string
+	for _, f := range s.TypeParams.List {
+		for _, name := range f.Names {
+			params = append(params, name.Name+" "+types.ExprString(f.Type))
+		}
+	}
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+// valueSignature prints the i'th name of a var or const spec
+func valueSignature(tok token.Token, s *ast.ValueSpec, i int) string {
+	sig := tok.String() + " " + s.Names[i].Name
+	if s.Type != nil {
+		sig += " " + types.ExprString(s.Type)
+	}
+	if i < len(s.Values) {
+		value := types.ExprString(s.Values[i])
+		if len(value) > 80 {
+			value = value[:77] + "..."
+		}
+		sig += " = " + value
+	}
+	return sig
+}
+
+// recvType returns the receiver's type name without pointer or type
+// parameters
+func recvType(d *ast.FuncDecl) string {
+	typ := d.Recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	switch t := typ.(type) {
+	case *ast.IndexExpr:
+		typ = t.X
+	case *ast.IndexListExpr:
+		typ = t.X
+	}
+	if id, ok := typ.(*ast.Ident); ok {
+		return id.Name
+	}
+	return types.ExprString(typ)
+}
+
+// firstSentence returns the first sentence of a doc comment
+func firstSentence(doc *ast.CommentGroup) string {
+	text := strings.Join(strings.Fields(doc.Text()), " ")
+	if i := strings.Index(text, ". "); i >= 0 {
+		text = text[:i+1]
+	}
+	return text
+}
+
+// Definitions returns the symbols a query names: Name, Type.Name,
+// pkg.Name or pkg.Type.Name
+func (m *Module) Definitions(query string) []*Symbol {
+	var defs []*Symbol
+	for _, s := range m.symbols {
+		if s.matches(query) {
+			defs = append(defs, s)
+		}
+	}
+	return defs
+}
+
+// Reference is a use of a symbol
+type Reference struct {
+	Location
+	Text string // The source line, trimmed
+}
+
+// References returns the uses of the identifiers a query names, not
+// counting declarations. A package-level symbol only matches in its own
+// package and through its package name elsewhere; methods and fields
+// match any selector with their name.
+func (m *Module) References(query string) []Reference {
+	name := query[strings.LastIndex(query, ".")+1:]
+	defs := m.Definitions(query)
+	declared := make(map[token.Pos]bool)
+	for _, d := range defs {
+		declared[d.pos] = true
+	}
+
+	// A single package-level symbol can be matched precisely
+	var home *Package
+	if len(defs) == 1 && defs[0].Recv == "" && defs[0].Kind != "method" {
+		home = defs[0].Package
+	}
+	selectorOnly := false
+	if parts := strings.Split(query, "."); len(parts) > 1 && home == nil {
+		selectorOnly = true
+	}
+
+	lines := make(map[string][]string)
+	var refs []Reference
+	for _, pkg := range m.Packages {
+		for _, file := range pkg.Files {
+			var visit func(n ast.Node) bool
+			visit = func(n ast.Node) bool {
+				switch x := n.(type) {
+				case *ast.SelectorExpr:
+					if x.Sel.Name != name {
+						return true
+					}
+					// Visit the operand but never Sel as a bare identifier
+					ast.Inspect(x.X, visit)
+					if home != nil {
+						pkgIdent, ok := x.X.(*ast.Ident)
+						if !ok || pkgIdent.Name != home.Name || pkg.ImportPath == home.ImportPath || !imports(file, home.ImportPath) {
+							return false
+						}
+					}
+					loc := m.location(x.Sel.Pos())
+					refs = append(refs, Reference{Location: loc, Text: sourceLine(m.Root, loc, lines)})
+					return false
+
+				case *ast.Ident:
+					if x.Name != name || selectorOnly || declared[x.Pos()] {
+						return true
+					}
+					if home != nil && pkg.ImportPath != home.ImportPath {
+						return true
+					}
+					loc := m.location(x.Pos())
+					refs = append(refs, Reference{Location: loc, Text: sourceLine(m.Root, loc, lines)})
+				}
+				return true
+			}
+			ast.Inspect(file, visit)
+		}
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].File != refs[j].File {
+			return refs[i].File < refs[j].File
+		}
+		return refs[i].Line < refs[j].Line
+	})
+	return dedupe(refs)
+}
+
+// imports reports whether a file imports the package without renaming it
+func imports(file *ast.File, importPath string) bool {
+	for _, imp := range file.Imports {
+		if strings.Trim(imp.Path.Value, `"`) == importPath && imp.Name == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceLine returns a location's line, trimmed, caching file contents
+func sourceLine(root string, loc Location, cache map[string][]string) string {
+	lines, ok := cache[loc.File]
+	if !ok {
+		data, _ := os.ReadFile(filepath.Join(root, loc.File))
+		lines = strings.Split(string(data), "\n")
+		cache[loc.File] = lines
+	}
+	if loc.Line-1 >= len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[loc.Line-1])
+}
+
+// dedupe drops repeated references on the same line
+func dedupe(refs []Reference) []Reference {
+	out := refs[:0]
+	for i, r := range refs {
+		if i > 0 && r.Location == refs[i-1].Location {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// FindPackage resolves a package by import path, module-relative
+// directory ("./internal/agent", "internal/agent") or unique name.
+// Test-only packages are not matched.
+func (m *Module) FindPackage(query string) (*Package, error) {
+	query = strings.TrimSuffix(query, "/")
+	dir := strings.TrimPrefix(filepath.ToSlash(query), "./")
+	if dir == "" {
+		dir = "."
+	}
+
+	var byName []*Package
+	for _, pkg := range m.Packages {
+		if strings.HasSuffix(pkg.Name, "_test") {
+			continue
+		}
+		if pkg.ImportPath == query || pkg.Dir == dir {
+			return pkg, nil
+		}
+		if pkg.Name == query {
+			byName = append(byName, pkg)
+		}
+	}
+
+	switch len(byName) {
+	case 0:
+		return nil, fmt.Errorf("no package %q in module %s", query, m.Path)
+	case 1:
+		return byName[0], nil
+	}
+	var dirs []string
+	for _, pkg := range byName {
+		dirs = append(dirs, "./"+pkg.Dir)
+	}
+	return nil, fmt.Errorf("%q is ambiguous: %s", query, strings.Join(dirs, ", "))
+}
+
+// API renders a package's exported declarations, go doc style: consts
+// and vars, funcs, then each type followed by its methods
+func (m *Module) API(pkg *Package) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("package %s // import %q\n", pkg.Name, pkg.ImportPath))
+
+	var values, funcs, typeNames []string
+	typeDecls := make(map[string]string)
+	methods := make(map[string][]string)
+	for _, file := range pkg.Files {
+		if strings.HasSuffix(m.fset.Position(file.Package).Filename, "_test.go") {
+			continue
+		}
+		if file.Doc != nil && !strings.Contains(sb.String(), "\n\n") {
+			sb.WriteString("\n" + firstSentence(file.Doc) + "\n")
+		}
+
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if !d.Name.IsExported() {
+					continue
+				}
+				if d.Recv == nil {
+					funcs = append(funcs, m.funcSignature(d))
+					continue
+				}
+				if recv := recvType(d); ast.IsExported(recv) {
+					methods[recv] = append(methods[recv], m.funcSignature(d))
+				}
+
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						if s.Name.IsExported() {
+							typeNames = append(typeNames, s.Name.Name)
+							typeDecls[s.Name.Name] = m.typeSignature(s, true)
+						}
+					case *ast.ValueSpec:
+						for i, name := range s.Names {
+							if name.IsExported() {
+								values = append(values, valueSignature(d.Tok, s, i))
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if len(values)+len(funcs)+len(typeNames) == 0 {
+		sb.WriteString("\nNo exported declarations.\n")
+		return sb.String()
+	}
+
+	sb.WriteString("\n")
+	for _, v := range values {
+		sb.WriteString(v + "\n")
+	}
+	for _, f := range funcs {
+		sb.WriteString(f + "\n")
+	}
+	for _, name := range typeNames {
+		sb.WriteString(typeDecls[name] + "\n")
+		for _, method := range methods[name] {
+			sb.WriteString("    " + method + "\n")
+		}
+	}
+	return sb.String()
+}
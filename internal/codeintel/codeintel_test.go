@@ -0,0 +1,184 @@
+package codeintel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeModule creates a small module: package shapes and a main that
+// uses it
+func writeModule(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/demo\n\ngo 1.22\n",
+		"shapes/shapes.go": `// Package shapes has geometry helpers
+package shapes
+
+// Square is a square. It has sides.
+type Square struct {
+	Side  float64
+	color string
+}
+
+// NewSquare returns a square with the given side
+func NewSquare(side float64) *Square {
+	return &Square{Side: side}
+}
+
+// Area returns the area
+func (s *Square) Area() float64 { return s.Side * s.Side }
+
+func helper() *Square { return NewSquare(1) }
+
+const Sides = 4
+`,
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/demo/shapes"
+)
+
+type Circle struct{ R float64 }
+
+func (c Circle) Area() float64 { return 3 * c.R * c.R }
+
+func main() {
+	sq := shapes.NewSquare(2)
+	fmt.Println(sq.Area(), Circle{1}.Area())
+}
+`,
+		"testdata/ignored.go": "package ignored\n\nfunc NewSquare() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestFindRoot(t *testing.T) {
+	root := writeModule(t)
+	got, ok := FindRoot(filepath.Join(root, "shapes"))
+	if !ok || got != root {
+		t.Errorf("FindRoot = %q, %v; want %q", got, ok, root)
+	}
+}
+
+func TestDefinitions(t *testing.T) {
+	m, err := Load(writeModule(t))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	defs := m.Definitions("NewSquare")
+	if len(defs) != 1 {
+		t.Fatalf("NewSquare definitions = %d, want 1 (testdata skipped)", len(defs))
+	}
+	d := defs[0]
+	if d.File != "shapes/shapes.go" || d.Line != 11 || d.Kind != "func" {
+		t.Errorf("definition = %s %s", d.Location, d.Kind)
+	}
+	if d.Signature != "func NewSquare(side float64) *Square" || d.Doc != "NewSquare returns a square with the given side" {
+		t.Errorf("signature = %q, doc = %q", d.Signature, d.Doc)
+	}
+
+	if defs := m.Definitions("Area"); len(defs) != 2 {
+		t.Errorf("Area definitions = %d, want both methods", len(defs))
+	}
+	if defs := m.Definitions("Square.Area"); len(defs) != 1 || defs[0].Kind != "method" {
+		t.Errorf("Square.Area definitions = %+v", defs)
+	}
+	if defs := m.Definitions("shapes.Square"); len(defs) != 1 || defs[0].Doc != "Square is a square." {
+		t.Errorf("shapes.Square definitions = %+v", defs)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	m, err := Load(writeModule(t))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	refs := m.References("NewSquare")
+	if len(refs) != 2 {
+		t.Fatalf("NewSquare references = %+v, want helper and main", refs)
+	}
+	if refs[0].File != "main.go" || refs[0].Text != "sq := shapes.NewSquare(2)" {
+		t.Errorf("first reference = %+v", refs[0])
+	}
+	if refs[1].File != "shapes/shapes.go" || refs[1].Line != 18 {
+		t.Errorf("second reference = %+v", refs[1])
+	}
+
+	// Methods match by selector name only; both calls share a line
+	if refs := m.References("Square.Area"); len(refs) != 1 || refs[0].Line != 15 {
+		t.Errorf("Square.Area references = %+v", refs)
+	}
+}
+
+func TestAPI(t *testing.T) {
+	m, err := Load(writeModule(t))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	pkg, err := m.FindPackage("./shapes")
+	if err != nil {
+		t.Fatalf("FindPackage: %v", err)
+	}
+	if p, _ := m.FindPackage("example.com/demo/shapes"); p != pkg {
+		t.Error("import path should resolve to the same package")
+	}
+
+	api := m.API(pkg)
+	for _, want := range []string{
+		`package shapes // import "example.com/demo/shapes"`,
+		"Package shapes has geometry helpers",
+		"const Sides = 4",
+		"func NewSquare(side float64) *Square",
+		"type Square struct {\n\tSide float64\n\t// unexported fields\n}",
+		"    func (s *Square) Area() float64",
+	} {
+		if !strings.Contains(api, want) {
+			t.Errorf("API missing %q:\n%s", want, api)
+		}
+	}
+	if strings.Contains(api, "func helper") || strings.Contains(api, "color") {
+		t.Errorf("API shows unexported declarations:\n%s", api)
+	}
+
+	if _, err := m.FindPackage("nope"); err == nil {
+		t.Error("expected an error for an unknown package")
+	}
+}
+
+func TestTools(t *testing.T) {
+	root := writeModule(t)
+	tools := Tools(root)
+	if len(tools) != 3 {
+		t.Fatalf("got %d tools", len(tools))
+	}
+
+	out, err := tools[0].Run(context.Background(), []byte(`{"symbol":"shapes.NewSquare"}`))
+	if err != nil || !strings.HasPrefix(out, "shapes/shapes.go:11 shapes.NewSquare (func in example.com/demo/shapes)") {
+		t.Errorf("go_definition = %q, %v", out, err)
+	}
+
+	out, err = tools[1].Run(context.Background(), []byte(`{"symbol":"NewSquare"}`))
+	if err != nil || !strings.HasPrefix(out, "2 references to NewSquare:\nmain.go\n  14: sq := shapes.NewSquare(2)") {
+		t.Errorf("go_references = %q, %v", out, err)
+	}
+
+	if _, err := tools[0].Run(context.Background(), []byte(`{}`)); err == nil {
+		t.Error("expected an error without a symbol")
+	}
+}
@@ -0,0 +1,140 @@
+package codeintel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/agentflow/agentflow/internal/tool"
+)
+
+// maxReferences caps the references returned to the model
+const maxReferences = 60
+
+// Tools returns the code intelligence tools for the module at root. The
+// module is parsed afresh for every call, so results follow edits.
+func Tools(root string) []tool.Tool {
+	symbol := tool.Object(map[string]any{
+		"symbol": tool.String("Name, Type.Method, pkg.Name or pkg.Type.Method, e.g. \"New\", \"Agent.Run\" or \"agent.New\""),
+	}, "symbol")
+
+	return []tool.Tool{
+		&tool.Func{
+			ToolName: "go_definition",
+			Desc:     "Find where a Go symbol in the current module is declared. Returns file:line, the signature and doc summary for each match, without reading whole files.",
+			Params:   symbol,
+			Activity: tool.KindSearch,
+			Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+				query, m, err := symbolArgs(root, args)
+				if err != nil {
+					return "", err
+				}
+				return Definition(m, query), nil
+			},
+		},
+		&tool.Func{
+			ToolName: "go_references",
+			Desc:     "List where a Go symbol in the current module is used, as file:line with the source line. Matching is by name: methods and fields match any selector with that name.",
+			Params:   symbol,
+			Activity: tool.KindSearch,
+			Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+				query, m, err := symbolArgs(root, args)
+				if err != nil {
+					return "", err
+				}
+				return ReferenceList(m, query), nil
+			},
+		},
+		&tool.Func{
+			ToolName: "go_package",
+			Desc:     "Show a Go package's exported API (consts, vars, funcs, types and methods with signatures), like go doc. Accepts an import path, a directory such as ./internal/agent, or a package name.",
+			Params: tool.Object(map[string]any{
+				"package": tool.String("Import path, module-relative directory or package name"),
+			}, "package"),
+			Activity: tool.KindSearch,
+			Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var in struct {
+					Package string `json:"package"`
+				}
+				if err := json.Unmarshal(args, &in); err != nil {
+					return "", fmt.Errorf("invalid arguments: %w", err)
+				}
+				m, err := Load(root)
+				if err != nil {
+					return "", err
+				}
+				pkg, err := m.FindPackage(in.Package)
+				if err != nil {
+					return "", err
+				}
+				return m.API(pkg), nil
+			},
+		},
+	}
+}
+
+// symbolArgs decodes a symbol argument and loads the module
+func symbolArgs(root string, args json.RawMessage) (string, *Module, error) {
+	var in struct {
+		Symbol string `json:"symbol"`
+	}
+	if err := json.Unmarshal(args, &in); err != nil {
+		return "", nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	query := strings.TrimSpace(in.Symbol)
+	if query == "" {
+		return "", nil, errors.New("symbol is required")
+	}
+	m, err := Load(root)
+	if err != nil {
+		return "", nil, err
+	}
+	return query, m, nil
+}
+
+// Definition renders the symbols a query names
+func Definition(m *Module, query string) string {
+	defs := m.Definitions(query)
+	if len(defs) == 0 {
+		return fmt.Sprintf("No declaration of %s in module %s", query, m.Path)
+	}
+
+	var sb strings.Builder
+	for i, d := range defs {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("%s %s (%s in %s)\n", d.Location, d.Qualified(), d.Kind, d.Package.ImportPath))
+		if d.Doc != "" {
+			sb.WriteString("// " + d.Doc + "\n")
+		}
+		sb.WriteString(d.Signature + "\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// ReferenceList renders the uses of a query grouped by file
+func ReferenceList(m *Module, query string) string {
+	refs := m.References(query)
+	if len(refs) == 0 {
+		return fmt.Sprintf("No references to %s in module %s", query, m.Path)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d references to %s:", len(refs), query))
+	file := ""
+	for i, r := range refs {
+		if i == maxReferences {
+			sb.WriteString(fmt.Sprintf("\n... %d more", len(refs)-maxReferences))
+			break
+		}
+		if r.File != file {
+			file = r.File
+			sb.WriteString("\n" + file)
+		}
+		sb.WriteString(fmt.Sprintf("\n  %d: %s", r.Line, r.Text))
+	}
+	return sb.String()
+}
@@ -2,63 +2,372 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/audit"
+	"github.com/agentflow/agentflow/internal/codeintel"
+	"github.com/agentflow/agentflow/internal/fetch"
+	"github.com/agentflow/agentflow/internal/fix"
+	"github.com/agentflow/agentflow/internal/guard"
+	"github.com/agentflow/agentflow/internal/lsp"
 	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/internal/router"
+	"github.com/agentflow/agentflow/internal/skill"
+	"github.com/agentflow/agentflow/internal/statusline"
+	"github.com/agentflow/agentflow/internal/team"
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/internal/voice"
+	"github.com/agentflow/agentflow/pkg/types"
+	"github.com/agentflow/agentflow/skills"
 	"gopkg.in/yaml.v3"
 )
 
 // Config is the main configuration structure
 type Config struct {
-	Providers map[string]ProviderConfig `yaml:"providers"`
-	Defaults  DefaultsConfig            `yaml:"defaults"`
-	Skills    SkillsConfig              `yaml:"skills"`
+	Providers   map[string]ProviderConfig   `yaml:"providers"`
+	Defaults    DefaultsConfig              `yaml:"defaults"`
+	Skills      SkillsConfig                `yaml:"skills"`
+	GitHub      GitHubConfig                `yaml:"github,omitempty"`
+	Bridge      BridgeConfig                `yaml:"bridge,omitempty"`
+	Templates   map[string]TemplateConfig   `yaml:"templates,omitempty"`
+	Language    LanguageConfig              `yaml:"language,omitempty"`
+	Tools       ToolsConfig                 `yaml:"tools,omitempty"`
+	LSP         map[string]lsp.ServerConfig `yaml:"lsp,omitempty"` // Language servers by name
+	Fix         FixConfig                   `yaml:"fix,omitempty"`
+	Verify      VerifyConfig                `yaml:"verify,omitempty"`
+	Context     ContextConfig               `yaml:"context,omitempty"`
+	Subagents   SubagentsConfig             `yaml:"subagents,omitempty"`
+	Update      UpdateConfig                `yaml:"update,omitempty"`
+	Router      RouterConfig                `yaml:"router,omitempty"`
+	Speculative SpeculativeConfig           `yaml:"speculative,omitempty"`
+	Styles      map[string]agent.Style      `yaml:"styles,omitempty"` // Response styles over the built-in concise, normal and detailed
+	StatusLine  StatusLineConfig            `yaml:"statusline,omitempty"`
+	Input       InputConfig                 `yaml:"input,omitempty"`
+	Voice       voice.Config                `yaml:"voice,omitempty"` // How /voice records and transcribes
+	Guard       guard.Config                `yaml:"guard,omitempty"` // Prompt injection screening of pages, files and command output
+	Audit       AuditConfig                 `yaml:"audit,omitempty"`
+	Sync        SyncConfig                  `yaml:"sync,omitempty"`      // The team repository agentflow sync pulls
+	ReadOnly    bool                        `yaml:"read_only,omitempty"` // Refuse tools and shell commands that change things
+
+	// Forbidden holds the providers the system policy removed, and why
+	Forbidden map[string]string `yaml:"-"`
+
+	lspManager *lsp.Manager      // Shared by every agent's tools
+	policy     *Policy           // Restrictions from the system policy file
+	teamConfig string            // The team config read below the config file
+	origins    map[string]string // Where settings overridden after loading came from
+}
+
+// SyncConfig names a team repository of shared config and skills
+type SyncConfig struct {
+	Repo   string `yaml:"repo,omitempty"`   // Git URL
+	Branch string `yaml:"branch,omitempty"` // The remote's default branch when empty
 }
 
 // ProviderConfig holds provider-specific configuration
 type ProviderConfig struct {
-	BaseURL string   `yaml:"base_url"`
-	APIKey  string   `yaml:"api_key"`
-	Models  []string `yaml:"models"`
+	BaseURL       string                        `yaml:"base_url"`
+	APIKey        string                        `yaml:"api_key"`
+	Keys          []provider.Key                `yaml:"keys,omitempty"` // Labelled keys, used in turn when one is rate limited
+	Models        []string                      `yaml:"models"`
+	Timeouts      provider.Timeouts             `yaml:"timeouts,omitempty"`
+	ModelTimeouts map[string]provider.Timeouts  `yaml:"model_timeouts,omitempty"` // Overrides for slow or fast models
+	ModelInfo     map[string]provider.ModelInfo `yaml:"model_info,omitempty"`     // Context window, tools, vision and prices, over the built-in table
+
+	// Extra headers and query parameters for gateways (OpenAI-compatible only)
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Query   map[string]string `yaml:"query,omitempty"`
+
+	Routing map[string]any `yaml:"routing,omitempty"` // OpenRouter provider routing preferences
+
+	Pool provider.Pool `yaml:"pool,omitempty"` // Connection reuse and HTTP/2
+
+	// Corporate proxies and self-hosted TLS endpoints
+	HTTPProxy          string `yaml:"http_proxy,omitempty"`
+	CACert             string `yaml:"ca_cert,omitempty"`
+	ClientCert         string `yaml:"client_cert,omitempty"`
+	ClientKey          string `yaml:"client_key,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"`
+}
+
+// providerConfig converts the configuration for one provider
+func (p ProviderConfig) providerConfig() provider.Config {
+	return provider.Config{
+		BaseURL:       p.BaseURL,
+		APIKey:        p.APIKey,
+		Keys:          p.Keys,
+		Models:        p.Models,
+		Timeouts:      p.Timeouts,
+		ModelTimeouts: p.ModelTimeouts,
+		ModelInfo:     p.ModelInfo,
+		Headers:       p.Headers,
+		Query:         p.Query,
+		Routing:       p.Routing,
+
+		Pool:               p.Pool,
+		HTTPProxy:          p.HTTPProxy,
+		CACert:             p.CACert,
+		ClientCert:         p.ClientCert,
+		ClientKey:          p.ClientKey,
+		InsecureSkipVerify: p.InsecureSkipVerify,
+	}
 }
 
 // DefaultsConfig holds default model assignments
 type DefaultsConfig struct {
-	Main     string `yaml:"main"`
-	Subagent string `yaml:"subagent"`
-	Reviewer string `yaml:"reviewer"`
+	Main     string  `yaml:"main"`
+	Subagent string  `yaml:"subagent"`
+	Reviewer string  `yaml:"reviewer"`
+	Style    string  `yaml:"style,omitempty"`    // Response style interactive sessions start with (default normal)
+	Seed     *int    `yaml:"seed,omitempty"`     // Sent with every request, for repeatable answers where supported
+	MaxCost  float64 `yaml:"max_cost,omitempty"` // USD a session may spend before requests are refused (0 for no cap)
 }
 
 // SkillsConfig holds skill-related configuration
 type SkillsConfig struct {
-	Paths []string `yaml:"paths"`
+	Paths   []string `yaml:"paths"`
+	Builtin *bool    `yaml:"builtin,omitempty"` // Load the starter skills embedded in the binary (default true)
+}
+
+// BuiltinEnabled reports whether the built-in starter skills are loaded
+func (s SkillsConfig) BuiltinEnabled() bool {
+	return s.Builtin == nil || *s.Builtin
+}
+
+// GitHubConfig holds GitHub integration settings
+type GitHubConfig struct {
+	Token  string `yaml:"token,omitempty"`   // Falls back to GITHUB_TOKEN or `gh auth token`
+	APIURL string `yaml:"api_url,omitempty"` // For GitHub Enterprise
+}
+
+// TemplateConfig is a named persona defined by few-shot examples
+type TemplateConfig struct {
+	Description string          `yaml:"description,omitempty"`
+	Examples    []types.Example `yaml:"examples"`
+}
+
+// LanguageConfig holds localization settings
+type LanguageConfig struct {
+	UI     string `yaml:"ui,omitempty"`     // TUI/REPL locale ("en", "fr", ...); "auto" or empty detects it from LANG
+	Answer string `yaml:"answer,omitempty"` // Language the model answers in, e.g. "French"; "auto" matches the user
+}
+
+// AnswerInstruction returns the system prompt line asking the model to
+// answer in the configured language, or "" when none is set
+func (l LanguageConfig) AnswerInstruction() string {
+	switch strings.ToLower(l.Answer) {
+	case "":
+		return ""
+	case "auto":
+		return "Always answer in the language the user writes in."
+	default:
+		return fmt.Sprintf("Always answer in %s, whatever language the question or code is in.", l.Answer)
+	}
+}
+
+// ToolsConfig holds settings for the tools offered to the model
+type ToolsConfig struct {
+	Disabled bool   `yaml:"disabled,omitempty"` // Never offer tools
+	URLs     string `yaml:"urls,omitempty"`     // URLs pasted in messages: ask (default), auto or off
+}
+
+// URLMode returns what to do with URLs pasted in messages
+func (t ToolsConfig) URLMode() string {
+	switch t.URLs {
+	case fetch.Auto, fetch.Off:
+		return t.URLs
+	}
+	return fetch.Ask
+}
+
+// ContextConfig limits how many tokens each request may use and how
+// each context source is cut to fit
+type ContextConfig struct {
+	MaxTokens  int                     `yaml:"max_tokens,omitempty"`  // 0 sends everything
+	Budgets    map[string]agent.Budget `yaml:"budgets,omitempty"`     // By source (history, retrieved, pinned, git); defaults when empty
+	Skills     int                     `yaml:"skills,omitempty"`      // Tokens of skills one message may activate
+	Overflow   string                  `yaml:"overflow,omitempty"`    // How history is cut when the model says it is too long: summary, tail or off
+	ToolResult int                     `yaml:"tool_result,omitempty"` // Tokens of a tool result sent before it is cut (default 4000; -1 for no limit)
+}
+
+// FixConfig holds settings for agentflow fix
+type FixConfig struct {
+	Commands []string `yaml:"commands,omitempty"` // Checks run in order; detected from the project when empty
+	Rounds   int      `yaml:"rounds,omitempty"`   // Fix attempts before giving up
+}
+
+// VerifyConfig holds the checks interactive sessions run when the model
+// says a coding task is done after its tools changed files
+type VerifyConfig struct {
+	Disabled bool     `yaml:"disabled,omitempty"` // Never run them
+	Commands []string `yaml:"commands,omitempty"` // Falls back to fix.commands, then to those detected from the project
+	Rounds   int      `yaml:"rounds,omitempty"`   // Times failures are sent back in one turn (default 2)
+}
+
+// AuditConfig holds the audit log of agents' actions
+type AuditConfig struct {
+	Enabled bool   `yaml:"enabled,omitempty"` // Log provider calls, tools, shell commands and file changes
+	Dir     string `yaml:"dir,omitempty"`     // ~/.agentflow/audit when empty
+}
+
+// InputConfig holds settings for typing in the TUI
+type InputConfig struct {
+	Vim bool `yaml:"vim,omitempty"` // Modal editing, and j/k, gg/G and / in the transcript
+}
+
+// StatusLineConfig replaces the TUI's status bar with a template or the
+// output of a command
+type StatusLineConfig struct {
+	Format  string `yaml:"format,omitempty"`  // text/template over the session, as in "{{.Model}} on {{.Branch}} · {{.Context}}%"
+	Command string `yaml:"command,omitempty"` // Run with sh, given the session as JSON on stdin; the first line it prints is shown
+}
+
+// SubagentsConfig holds settings for subagent pools
+type SubagentsConfig struct {
+	MaxAgents int  `yaml:"max_agents,omitempty"` // Subagents running at once (default 5)
+	Adaptive  bool `yaml:"adaptive,omitempty"`   // Size the pool from rate limits and latencies, up to max_agents
+}
+
+// UpdateConfig holds settings for release checks and agentflow update
+type UpdateConfig struct {
+	Check     *bool  `yaml:"check,omitempty"`      // Show a footer notice when a newer release is out (default true)
+	PublicKey string `yaml:"public_key,omitempty"` // Base64 ed25519 key release checksums must be signed with
+}
+
+// CheckEnabled reports whether the TUI looks for newer releases
+func (u UpdateConfig) CheckEnabled() bool {
+	return u.Check == nil || *u.Check
+}
+
+// RouterConfig sets up the "auto" provider, which classifies each request
+// and sends it to the model of a tier
+type RouterConfig struct {
+	Classifier string            `yaml:"classifier,omitempty"` // Cheap "provider/model" that classifies requests; the fast tier when empty
+	Tiers      map[string]string `yaml:"tiers,omitempty"`      // fast, cheap, strong → "provider/model"
+	Routes     map[string]string `yaml:"routes,omitempty"`     // Task (qa, code, summarize) → tier
+}
+
+// SpeculativeConfig sets up the "speculative" provider, which asks a fast
+// and a strong model at once
+type SpeculativeConfig struct {
+	Fast   string        `yaml:"fast,omitempty"`   // "provider/model" streamed at once, usually local
+	Strong string        `yaml:"strong,omitempty"` // "provider/model" asked at the same time, usually remote
+	Mode   string        `yaml:"mode,omitempty"`   // swap (default) replaces the fast answer; offer keeps it and offers /swap
+	Wait   time.Duration `yaml:"wait,omitempty"`   // How long a finished fast answer waits for the strong one (default 30s)
+}
+
+// BridgeConfig holds chat bot bridge settings
+type BridgeConfig struct {
+	Slack   BotConfig `yaml:"slack,omitempty"`
+	Discord BotConfig `yaml:"discord,omitempty"`
+}
+
+// BotConfig holds a bot token and the channels it relays
+type BotConfig struct {
+	Token    string   `yaml:"token,omitempty"`
+	Channels []string `yaml:"channels,omitempty"`
 }
 
 // Load reads configuration from the given path
 func Load(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	return load(team.ConfigPath(TeamDir), path)
+}
+
+// TeamDir is where a team's shared config and skills are synced (see
+// package team). Its config.yaml is read below the config file.
+var TeamDir = team.Dir()
+
+// load reads the config file at path over the team config at teamPath,
+// when there is one. Settings are merged key by key, so a team provider
+// keeps the settings the user doesn't change; lists are replaced whole.
+func load(teamPath, path string) (*Config, error) {
+	data, err := readExpanded(path)
 	if err != nil {
 		return nil, fmt.Errorf("read config: %w", err)
 	}
 
-	// Expand environment variables
-	expanded := os.ExpandEnv(string(data))
+	var teamData, userData []byte
+	if teamPath != "" && teamPath != path {
+		userData = data
+		if teamData, err = readExpanded(teamPath); err != nil {
+			return nil, fmt.Errorf("read team config: %w", err)
+		}
+		var base, over map[string]any
+		if err := yaml.Unmarshal(teamData, &base); err != nil {
+			return nil, fmt.Errorf("parse team config %s: %w", teamPath, err)
+		}
+		if err := yaml.Unmarshal(data, &over); err != nil {
+			return nil, fmt.Errorf("parse config: %w", err)
+		}
+		if data, err = yaml.Marshal(merge(base, over)); err != nil {
+			return nil, fmt.Errorf("merge team config: %w", err)
+		}
+	}
 
 	var cfg Config
-	if err := yaml.Unmarshal([]byte(expanded), &cfg); err != nil {
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
 
+	if teamData != nil {
+		cfg.teamConfig = teamPath
+		teamSettings, _ := team.Settings(teamData)
+		userSettings, _ := team.Settings(userData)
+		for key := range teamSettings {
+			if _, mine := userSettings[key]; !mine {
+				cfg.SetOrigin(key, "team "+teamPath)
+			}
+		}
+	}
 	return &cfg, nil
 }
 
+// readExpanded reads a config file, expanding environment variables
+func readExpanded(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(os.ExpandEnv(string(data))), nil
+}
+
+// merge returns over merged into base: maps key by key, anything else
+// replaced
+func merge(base, over map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(over))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range over {
+		b, bok := merged[k].(map[string]any)
+		o, ook := v.(map[string]any)
+		if bok && ook {
+			merged[k] = merge(b, o)
+		} else {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// TeamConfig returns the team config the config was read over, or ""
+func (c *Config) TeamConfig() string {
+	return c.teamConfig
+}
+
 // ConfigSource tracks where configuration was loaded from
 var ConfigSource string = ""
 
-// LoadDefault loads configuration from default locations
+// LoadDefault loads configuration from default locations, over the team
+// config when one was synced
 func LoadDefault() (*Config, error) {
 	// Check locations in order
 	locations := []string{
@@ -74,6 +383,9 @@ func LoadDefault() (*Config, error) {
 			filepath.Join(home, ".config", "agentflow", "config.yaml"),
 		)
 	}
+	if teamPath := team.ConfigPath(TeamDir); teamPath != "" {
+		locations = append(locations, teamPath)
+	}
 
 	for _, loc := range locations {
 		if _, err := os.Stat(loc); err == nil {
@@ -126,16 +438,15 @@ func (c *Config) Save(path string) error {
 	return nil
 }
 
-// BuildRegistry creates a provider registry from configuration
+// BuildRegistry creates a provider registry from configuration. The
+// models of OpenAI-compatible providers configured without any are asked
+// of their servers.
 func (c *Config) BuildRegistry() *provider.Registry {
 	registry := provider.NewRegistry()
+	var providers []provider.Provider
 
 	for name, cfg := range c.Providers {
-		provCfg := provider.Config{
-			BaseURL: cfg.BaseURL,
-			APIKey:  cfg.APIKey,
-			Models:  cfg.Models,
-		}
+		provCfg := cfg.providerConfig()
 
 		var p provider.Provider
 		switch strings.ToLower(name) {
@@ -145,12 +456,242 @@ func (c *Config) BuildRegistry() *provider.Registry {
 			p = provider.NewGroq(provCfg)
 		case "together":
 			p = provider.NewTogether(provCfg)
+		case "openrouter":
+			p = provider.NewOpenRouter(provCfg)
+		case "mistral":
+			p = provider.NewMistral(provCfg)
+		case "deepseek":
+			p = provider.NewDeepSeek(provCfg)
+		case "xai":
+			p = provider.NewXAI(provCfg)
+		case "cerebras":
+			p = provider.NewCerebras(provCfg)
 		default:
 			// Generic OpenAI-compatible
 			p = provider.NewOpenAICompat(name, provCfg)
 		}
 		registry.Register(p)
+		providers = append(providers, p)
 	}
+	provider.DiscoverModels(providers)
+	if len(c.Router.Tiers) > 0 {
+		registry.Register(router.New(router.Config{
+			Classifier: c.Router.Classifier,
+			Tiers:      c.Router.Tiers,
+			Routes:     c.Router.Routes,
+		}, registry.ResolveModel))
+	}
+	if c.Speculative.Fast != "" && c.Speculative.Strong != "" {
+		registry.Register(router.NewSpeculative(router.SpeculativeConfig{
+			Fast:   c.Speculative.Fast,
+			Strong: c.Speculative.Strong,
+			Mode:   c.Speculative.Mode,
+			Wait:   c.Speculative.Wait,
+		}, registry.ResolveModel))
+	}
+
+	return registry
+}
+
+// ModelResolver returns registry's ResolveModel, which also takes the
+// names of router tiers (fast, cheap, strong) and of the default models
+// (main, subagent, reviewer), as skills name the model they want
+func (c *Config) ModelResolver(registry *provider.Registry) func(spec string) (provider.Provider, string, bool) {
+	return func(spec string) (provider.Provider, string, bool) {
+		aliases := map[string]string{"main": c.Defaults.Main, "subagent": c.Defaults.Subagent, "reviewer": c.Defaults.Reviewer}
+		if tier, ok := c.Router.Tiers[spec]; ok {
+			spec = tier
+		} else if model, ok := aliases[spec]; ok && model != "" {
+			spec = model
+		}
+		return registry.ResolveModel(spec)
+	}
+}
+
+// SkillLoader creates a loader for the configured skill paths, on top of
+// the team's synced skills and the built-in starter skills unless they
+// are turned off
+func (c *Config) SkillLoader() *skill.Loader {
+	loader := skill.NewLoader(append([]string{team.SkillsDir(TeamDir)}, c.Skills.Paths...))
+	if c.Skills.BuiltinEnabled() {
+		loader.SetBuiltin(skills.Starter)
+	}
+	return loader
+}
+
+// Timeouts returns the timeouts for a "provider/model" spec
+func (c *Config) Timeouts(spec string) provider.Timeouts {
+	name, model, _ := strings.Cut(spec, "/")
+	return c.Providers[name].providerConfig().TimeoutsFor(model)
+}
 
+// ModelInfo returns what is known of the model of a "provider/model" spec
+func (c *Config) ModelInfo(spec string) provider.ModelInfo {
+	name, model, _ := strings.Cut(spec, "/")
+	return c.Providers[name].providerConfig().InfoFor(model)
+}
+
+// Style returns a response style by name: one from styles, or a
+// built-in one
+func (c *Config) Style(name string) (agent.Style, bool) {
+	s, ok := c.Styles[name]
+	if !ok {
+		s, ok = agent.Styles[name]
+	}
+	s.Name = name
+	return s, ok
+}
+
+// StyleNames returns the names of the response styles, sorted
+func (c *Config) StyleNames() []string {
+	var names []string
+	for name := range agent.Styles {
+		names = append(names, name)
+	}
+	for name := range c.Styles {
+		if _, ok := agent.Styles[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultStyle returns the response style interactive sessions start
+// with
+func (c *Config) DefaultStyle() agent.Style {
+	if s, ok := c.Style(c.Defaults.Style); ok {
+		return s
+	}
+	s, _ := c.Style(agent.StyleNormal)
+	return s
+}
+
+// Verifier returns the checks interactive agents run after the model
+// says a task is done, or a zero Verify when disabled or none are known
+func (c *Config) Verifier() agent.Verify {
+	wd, err := os.Getwd()
+	if c.Verify.Disabled || err != nil {
+		return agent.Verify{}
+	}
+	commands := c.Verify.Commands
+	if len(commands) == 0 {
+		commands = c.Fix.Commands
+	}
+	if len(commands) == 0 {
+		commands = fix.DetectCommands(wd)
+	}
+	if len(commands) == 0 {
+		return agent.Verify{}
+	}
+	return agent.Verify{
+		Check: func(ctx context.Context) (string, string, error) {
+			return fix.Check(ctx, wd, commands, io.Discard)
+		},
+		Changes: func(ctx context.Context) string {
+			return fix.Fingerprint(ctx, wd)
+		},
+		Rounds: c.Verify.Rounds,
+	}
+}
+
+// StatusLiner returns the TUI's custom status line, or nil for the
+// default status bar
+func (c *Config) StatusLiner() (*statusline.Line, error) {
+	if c.StatusLine.Format == "" && c.StatusLine.Command == "" {
+		return nil, nil
+	}
+	return statusline.New(c.StatusLine.Format, c.StatusLine.Command)
+}
+
+// GenerationParams returns the sampling parameters agents start with
+func (c *Config) GenerationParams() types.GenerationParams {
+	return types.GenerationParams{Seed: c.Defaults.Seed}
+}
+
+// InjectionGuard returns the guard screening the pages, files, command
+// output and tool results added to conversations, or nil when it is off.
+// Settings it can't use fall back to the defaults; loading the config
+// reports them.
+func (c *Config) InjectionGuard() *guard.Guard {
+	g, err := guard.New(c.Guard)
+	if err != nil {
+		g, _ = guard.New(guard.Config{})
+	}
+	return g
+}
+
+// AuditLog returns the audit log of a session, a new one when session is
+// empty, or nil when auditing is off
+func (c *Config) AuditLog(session string) (*audit.Log, error) {
+	if !c.Audit.Enabled {
+		return nil, nil
+	}
+	dir := c.Audit.Dir
+	if dir == "" {
+		dir = audit.Dir()
+	}
+	return audit.Open(dir, session)
+}
+
+// ContextBudget returns the token budgets for agents, or nil when no
+// limit is configured
+func (c *Config) ContextBudget() *agent.Budgets {
+	if c.Context.MaxTokens <= 0 {
+		if c.Context.Skills > 0 || c.Context.Overflow != "" || c.Context.ToolResult != 0 {
+			return &agent.Budgets{Skills: c.Context.Skills, Overflow: c.Context.Overflow, ToolResult: c.Context.ToolResult}
+		}
+		return nil
+	}
+	b := &agent.Budgets{MaxTokens: c.Context.MaxTokens, Sources: c.Context.Budgets, Skills: c.Context.Skills, Overflow: c.Context.Overflow, ToolResult: c.Context.ToolResult}
+	if len(b.Sources) == 0 {
+		b.Sources = agent.DefaultBudgets(c.Context.MaxTokens).Sources
+	}
+	return b
+}
+
+// CloseTools stops any language servers started by the tools
+func (c *Config) CloseTools() {
+	if c.lspManager != nil {
+		c.lspManager.Close()
+	}
+}
+
+// BuildTools creates the tool registry offered to the model: fetch_url,
+// read_files for the working directory, the Go code intelligence tools when it is in
+// a Go module, and the LSP tools when language servers are configured. It
+// returns nil when tools are disabled. In read-only mode tools that change
+// things are refused, including those registered on it later.
+func (c *Config) BuildTools() *tool.Registry {
+	if c.Tools.Disabled {
+		return nil
+	}
+
+	registry := tool.NewRegistry()
+	if c.policy != nil {
+		registry.Restrict(c.policy.allowsTool)
+	}
+	if c.ReadOnly {
+		registry.RefuseWrites()
+	}
+	registry.Register(fetch.Tool())
+	wd, err := os.Getwd()
+	if err != nil {
+		return registry
+	}
+	registry.Register(tool.ReadFiles(wd))
+	if root, ok := codeintel.FindRoot(wd); ok {
+		for _, t := range codeintel.Tools(root) {
+			registry.Register(t)
+		}
+	}
+	if len(c.LSP) > 0 {
+		if c.lspManager == nil {
+			c.lspManager = lsp.NewManager(wd, c.LSP)
+		}
+		for _, t := range lsp.Tools(c.lspManager) {
+			registry.Register(t)
+		}
+	}
 	return registry
 }
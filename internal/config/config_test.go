@@ -1,9 +1,18 @@
 package config
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/artifact"
+	"github.com/agentflow/agentflow/internal/lsp"
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/providertest"
+	"github.com/agentflow/agentflow/pkg/types"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -90,6 +99,46 @@ skills:
 	}
 }
 
+func TestLoad_Templates(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `
+templates:
+  reviewer:
+    description: Terse reviewer
+    examples:
+      - user: "func add(a, b int) int { return a - b }"
+        assistant: "Bug: subtracts instead of adds."
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tmpl, ok := cfg.Templates["reviewer"]
+	if !ok {
+		t.Fatal("expected reviewer template")
+	}
+	if len(tmpl.Examples) != 1 || tmpl.Examples[0].Assistant != "Bug: subtracts instead of adds." {
+		t.Errorf("examples = %+v", tmpl.Examples)
+	}
+}
+
+func TestLanguageConfig_AnswerInstruction(t *testing.T) {
+	if got := (LanguageConfig{}).AnswerInstruction(); got != "" {
+		t.Errorf("empty config should add no instruction, got %q", got)
+	}
+	if got := (LanguageConfig{Answer: "French"}).AnswerInstruction(); !strings.Contains(got, "in French") {
+		t.Errorf("AnswerInstruction() = %q", got)
+	}
+	if got := (LanguageConfig{Answer: "auto"}).AnswerInstruction(); !strings.Contains(got, "language the user writes in") {
+		t.Errorf("AnswerInstruction() = %q", got)
+	}
+}
+
 func TestLoad_EnvExpansion(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "config-env-test")
 	if err != nil {
@@ -233,3 +282,186 @@ func TestLoadDefault_NoConfig(t *testing.T) {
 		t.Error("expected default main model")
 	}
 }
+
+func TestConfig_BuildTools(t *testing.T) {
+	// The working directory is inside this module
+	tools := DefaultConfig().BuildTools()
+	if tools == nil {
+		t.Fatal("expected a tool registry")
+	}
+	if _, ok := tools.Get("go_definition"); !ok {
+		t.Errorf("expected Go tools in a Go module, got %v", tools.List())
+	}
+
+	if _, ok := tools.Get("lsp_hover"); ok {
+		t.Error("LSP tools offered without configured servers")
+	}
+
+	cfg := DefaultConfig()
+	cfg.LSP = map[string]lsp.ServerConfig{"python": {Command: "pyright-langserver", Args: []string{"--stdio"}, Extensions: []string{".py"}}}
+	if _, ok := cfg.BuildTools().Get("lsp_diagnostics"); !ok {
+		t.Error("expected LSP tools with a configured server")
+	}
+
+	cfg.Tools.Disabled = true
+	if cfg.BuildTools() != nil {
+		t.Error("expected no tools when disabled")
+	}
+}
+
+func TestConfig_ContextBudget(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	os.WriteFile(path, []byte(`
+context:
+  max_tokens: 8000
+  budgets:
+    history: {share: 70, truncate: tail}
+    pinned: {share: 30, truncate: summary}
+`), 0644)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	b := cfg.ContextBudget()
+	if b == nil || b.MaxTokens != 8000 || b.Sources["history"].Truncate != "tail" || b.Sources["pinned"].Share != 30 {
+		t.Errorf("budget = %+v", b)
+	}
+
+	if DefaultConfig().ContextBudget() != nil {
+		t.Error("expected no budget without max_tokens")
+	}
+	cfg.Context.Budgets = nil
+	if b := cfg.ContextBudget(); len(b.Sources) != 4 || b.Sources["history"].Share != 50 {
+		t.Errorf("default budget = %+v", b)
+	}
+}
+
+func TestConfig_Styles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	os.WriteFile(path, []byte(`
+defaults:
+  style: review
+styles:
+  concise:
+    max_tokens: 128
+  review:
+    instruction: Numbered findings only.
+`), 0644)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if s := cfg.DefaultStyle(); s.Name != "review" || s.Instruction != "Numbered findings only." {
+		t.Errorf("default style = %+v", s)
+	}
+	if s, ok := cfg.Style("concise"); !ok || s.MaxTokens != 128 {
+		t.Errorf("concise = %+v", s)
+	}
+	if got := strings.Join(cfg.StyleNames(), ","); got != "concise,detailed,normal,review" {
+		t.Errorf("StyleNames = %s", got)
+	}
+	if _, ok := cfg.Style("chatty"); ok {
+		t.Error("unknown style found")
+	}
+	if s := DefaultConfig().DefaultStyle(); s.Name != "normal" {
+		t.Errorf("default config style = %+v", s)
+	}
+}
+
+func TestLoad_TeamConfig(t *testing.T) {
+	dir := t.TempDir()
+	teamPath := filepath.Join(dir, "team.yaml")
+	os.WriteFile(teamPath, []byte(`
+providers:
+  gpu:
+    base_url: http://gpu:11434
+    models: [llama3, qwen]
+defaults:
+  main: gpu/llama3
+  reviewer: gpu/qwen
+`), 0644)
+	userPath := filepath.Join(dir, "config.yaml")
+	os.WriteFile(userPath, []byte(`
+providers:
+  gpu:
+    models: [llama3]
+defaults:
+  main: gpu/qwen
+`), 0644)
+
+	cfg, err := load(teamPath, userPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gpu := cfg.Providers["gpu"]
+	if gpu.BaseURL != "http://gpu:11434" || len(gpu.Models) != 1 {
+		t.Errorf("gpu = %+v: settings merge key by key, lists are replaced", gpu)
+	}
+	if cfg.Defaults.Main != "gpu/qwen" || cfg.Defaults.Reviewer != "gpu/qwen" {
+		t.Errorf("defaults = %+v", cfg.Defaults)
+	}
+	if cfg.TeamConfig() != teamPath || cfg.Origin("defaults.reviewer") != "team "+teamPath || cfg.Origin("defaults.main") != "" {
+		t.Errorf("origins: reviewer %q, main %q", cfg.Origin("defaults.reviewer"), cfg.Origin("defaults.main"))
+	}
+
+	if cfg, err := load("", userPath); err != nil || cfg.TeamConfig() != "" || cfg.Providers["gpu"].BaseURL != "" {
+		t.Errorf("without a team config = %+v, %v", cfg, err)
+	}
+}
+
+func TestConfig_ModelResolver(t *testing.T) {
+	cfg := &Config{
+		Providers: map[string]ProviderConfig{"ollama": {}},
+		Defaults:  DefaultsConfig{Main: "ollama/small", Reviewer: "ollama/reviewer"},
+		Router:    RouterConfig{Tiers: map[string]string{"strong": "ollama/large"}},
+	}
+	resolve := cfg.ModelResolver(cfg.BuildRegistry())
+	for spec, want := range map[string]string{"strong": "large", "reviewer": "reviewer", "ollama/other": "other"} {
+		if p, model, ok := resolve(spec); !ok || p.Name() != "ollama" || model != want {
+			t.Errorf("resolve(%q) = %v, %q, %v", spec, p, model, ok)
+		}
+	}
+	if _, _, ok := resolve("subagent"); ok {
+		t.Error("subagent has no default model")
+	}
+}
+
+func TestConfig_BuildTools_PlanMode(t *testing.T) {
+	root := t.TempDir()
+	tools := DefaultConfig().BuildTools()
+	artifact.Offer(tools, root, "s1")
+	p := &providertest.Provider{Responses: []providertest.Response{
+		{ToolCalls: []types.ToolCall{{ID: "c1", Name: artifact.ToolName, Arguments: `{"name":"notes.md","content":"x"}`}}},
+		{ToolCalls: []types.ToolCall{{ID: "c2", Name: artifact.ToolName, Arguments: `{"name":"plan","content":"## Steps\n1. Do it"}`}}},
+		{Content: "done"},
+	}}
+	a := agent.New(agent.Config{Provider: p, Model: "test-model", Tools: tools})
+	a.SetPlanMode(true)
+	if _, err := a.Run(context.Background(), "plan it"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// Of the tools that change things, only save_artifact is offered
+	var offered bool
+	for _, def := range p.Requests()[0].Tools {
+		tl, _ := tools.Get(def.Name)
+		if def.Name == artifact.ToolName {
+			offered = true
+		} else if tool.Writes(tl) {
+			t.Errorf("plan mode offered %s", def.Name)
+		}
+	}
+	if !offered {
+		t.Error("plan mode didn't offer save_artifact")
+	}
+	if _, err := artifact.Find(root, "s1/notes"); err == nil {
+		t.Error("plan mode saved an artifact other than the plan")
+	}
+	if plan, err := a.ExecutePlan(root); err != nil || !strings.HasSuffix(plan, "1. Do it") {
+		t.Errorf("ExecutePlan = %q, %v", plan, err)
+	}
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/agentflow/agentflow/internal/fetch"
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyPath is the system policy file. It is read-only to agentflow and
+// takes precedence over the user's and the project's config, for
+// administrators to restrict what agentflow may do on a machine.
+var PolicyPath = defaultPolicyPath()
+
+func defaultPolicyPath() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramData"), "agentflow", "policy.yaml")
+	}
+	return "/etc/agentflow/policy.yaml"
+}
+
+// Policy holds the restrictions of a system policy file
+type Policy struct {
+	ForbiddenProviders []string    `yaml:"forbidden_providers,omitempty"` // Provider names, or hosts their base_url may not point at
+	Offline            bool        `yaml:"offline,omitempty"`             // Only providers on this machine or network; no fetching or update checks
+	MaxCost            float64     `yaml:"max_cost,omitempty"`            // USD a session may spend at most
+	ReadOnly           bool        `yaml:"read_only,omitempty"`           // Nothing may be changed: tools and shell commands that would are refused
+	Tools              ToolsPolicy `yaml:"tools,omitempty"`
+
+	path string
+}
+
+// ToolsPolicy locks which tools the model is offered
+type ToolsPolicy struct {
+	Disabled bool     `yaml:"disabled,omitempty"` // No tools at all
+	Allow    []string `yaml:"allow,omitempty"`    // Only these, when set
+	Deny     []string `yaml:"deny,omitempty"`     // Never these
+	URLs     string   `yaml:"urls,omitempty"`     // What to do with pasted URLs: ask, auto or off
+}
+
+// LoadPolicy reads the policy file at path, or returns nil when there is
+// none. Unknown settings are errors, so a misspelled restriction isn't
+// silently left out.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+
+	p := &Policy{path: path}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse policy %s: %w", path, err)
+	}
+	switch p.Tools.URLs {
+	case "", fetch.Ask, fetch.Auto, fetch.Off:
+	default:
+		return nil, fmt.Errorf("policy %s: tools.urls must be ask, auto or off, not %q", path, p.Tools.URLs)
+	}
+	if p.MaxCost < 0 {
+		return nil, fmt.Errorf("policy %s: max_cost can't be negative", path)
+	}
+	return p, nil
+}
+
+// Path returns the file the policy was read from
+func (p *Policy) Path() string {
+	return p.path
+}
+
+// ApplyPolicy enforces a policy over the config, removing the providers
+// it forbids and overriding the settings it locks; a nil policy changes
+// nothing
+func (c *Config) ApplyPolicy(p *Policy) {
+	if p == nil {
+		return
+	}
+	c.policy = p
+	origin := "policy " + p.path
+
+	for name, pc := range c.Providers {
+		if reason := p.forbids(name, pc); reason != "" {
+			delete(c.Providers, name)
+			if c.Forbidden == nil {
+				c.Forbidden = make(map[string]string)
+			}
+			c.Forbidden[name] = reason
+			c.SetOrigin("providers."+name, origin)
+		}
+	}
+	if p.MaxCost > 0 && (c.Defaults.MaxCost <= 0 || c.Defaults.MaxCost > p.MaxCost) {
+		c.Defaults.MaxCost = p.MaxCost
+		c.SetOrigin("defaults.max_cost", origin)
+	}
+	if p.ReadOnly {
+		c.ReadOnly = true
+		c.SetOrigin("read_only", origin)
+	}
+	if p.Tools.Disabled {
+		c.Tools.Disabled = true
+		c.SetOrigin("tools.disabled", origin)
+	}
+	if p.Tools.URLs != "" {
+		c.Tools.URLs = p.Tools.URLs
+		c.SetOrigin("tools.urls", origin)
+	}
+	if len(p.Tools.Allow) > 0 || len(p.Tools.Deny) > 0 || p.Offline {
+		c.SetOrigin("tools", origin)
+	}
+	if p.Offline {
+		c.Tools.URLs = fetch.Off
+		c.SetOrigin("tools.urls", origin)
+		check := false
+		c.Update.Check = &check
+		c.SetOrigin("update.check", origin)
+	}
+}
+
+// Policy returns the policy applied to the config, or nil
+func (c *Config) Policy() *Policy {
+	return c.policy
+}
+
+// Origin returns where a setting, named as in the config file
+// ("defaults.main"), was set from when it wasn't the config file: the
+// policy, or a command-line flag. "" means the config file or the
+// defaults.
+func (c *Config) Origin(key string) string {
+	return c.origins[key]
+}
+
+// SetOrigin records where a setting was overridden from
+func (c *Config) SetOrigin(key, origin string) {
+	if c.origins == nil {
+		c.origins = make(map[string]string)
+	}
+	c.origins[key] = origin
+}
+
+// allowsTool reports whether the policy lets the model be offered a tool
+func (p *Policy) allowsTool(name string) bool {
+	if p.Offline && name == "fetch_url" {
+		return false
+	}
+	if slices.Contains(p.Tools.Deny, name) {
+		return false
+	}
+	return len(p.Tools.Allow) == 0 || slices.Contains(p.Tools.Allow, name)
+}
+
+// forbids returns why the policy forbids a provider, or ""
+func (p *Policy) forbids(name string, pc ProviderConfig) string {
+	host := ""
+	if u, err := url.Parse(pc.BaseURL); err == nil {
+		host = strings.ToLower(u.Hostname())
+	}
+	for _, forbidden := range p.ForbiddenProviders {
+		forbidden = strings.ToLower(forbidden)
+		if strings.EqualFold(name, forbidden) || (host != "" && (host == forbidden || strings.HasSuffix(host, "."+forbidden))) {
+			return "forbidden by policy"
+		}
+	}
+	if p.Offline && !localEndpoint(name, host) {
+		return "not local, and the policy requires offline use"
+	}
+	return ""
+}
+
+// localEndpoint reports whether a provider's host is on this machine or
+// the local network: a loopback or private address, localhost, or a
+// name without a public domain. Providers without a base_url use their
+// cloud API, except Ollama.
+func localEndpoint(name, host string) bool {
+	if host == "" {
+		return strings.EqualFold(name, "ollama")
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast()
+	}
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return true
+	}
+	for _, suffix := range []string{".localhost", ".local", ".lan", ".internal", ".home.arpa"} {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
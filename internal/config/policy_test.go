@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/agentflow/agentflow/internal/artifact"
+	"github.com/agentflow/agentflow/internal/fetch"
+	"github.com/agentflow/agentflow/internal/tool"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	if p, err := LoadPolicy(filepath.Join(dir, "missing.yaml")); p != nil || err != nil {
+		t.Errorf("missing policy = %v, %v; want nil, nil", p, err)
+	}
+
+	path := filepath.Join(dir, "policy.yaml")
+	os.WriteFile(path, []byte("forbidden_providers: [openai]\nmax_cost: 5\ntools:\n  deny: [fetch_url]\n"), 0644)
+	p, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Path() != path || p.MaxCost != 5 || !slices.Equal(p.ForbiddenProviders, []string{"openai"}) {
+		t.Errorf("policy = %+v", p)
+	}
+
+	for _, bad := range []string{"forbiden_providers: [openai]\n", "tools:\n  urls: sometimes\n", "max_cost: -1\n"} {
+		os.WriteFile(path, []byte(bad), 0644)
+		if _, err := LoadPolicy(path); err == nil {
+			t.Errorf("LoadPolicy(%q) should fail", bad)
+		}
+	}
+}
+
+func TestConfig_ApplyPolicy(t *testing.T) {
+	cfg := &Config{
+		Providers: map[string]ProviderConfig{
+			"ollama":  {},
+			"gpu":     {BaseURL: "http://192.168.1.20:11434"},
+			"openai":  {},
+			"gateway": {BaseURL: "https://llm.corp.example.com/v1"},
+			"groq":    {BaseURL: "https://api.groq.com/openai/v1"},
+		},
+		Defaults: DefaultsConfig{MaxCost: 20},
+	}
+	cfg.ApplyPolicy(&Policy{
+		ForbiddenProviders: []string{"corp.example.com"},
+		Offline:            true,
+		MaxCost:            5,
+		Tools:              ToolsPolicy{Deny: []string{"read_files"}},
+		path:               "/etc/agentflow/policy.yaml",
+	})
+
+	for _, name := range []string{"openai", "gateway", "groq"} {
+		if _, ok := cfg.Providers[name]; ok || cfg.Forbidden[name] == "" {
+			t.Errorf("%s should be removed: %v", name, cfg.Forbidden)
+		}
+	}
+	if cfg.Forbidden["gateway"] != "forbidden by policy" {
+		t.Errorf("gateway removed because %q", cfg.Forbidden["gateway"])
+	}
+	if len(cfg.Providers) != 2 {
+		t.Errorf("providers = %v, want ollama and gpu", cfg.Providers)
+	}
+	if cfg.Defaults.MaxCost != 5 || cfg.Origin("defaults.max_cost") != "policy /etc/agentflow/policy.yaml" {
+		t.Errorf("max cost = %v from %q", cfg.Defaults.MaxCost, cfg.Origin("defaults.max_cost"))
+	}
+	if cfg.Tools.URLs != fetch.Off || cfg.Update.Check == nil || *cfg.Update.Check {
+		t.Errorf("offline should turn off fetching and update checks: %+v %+v", cfg.Tools, cfg.Update)
+	}
+	if cfg.Origin("defaults.main") != "" {
+		t.Errorf("untouched settings have no origin")
+	}
+
+	tools := cfg.BuildTools()
+	for _, name := range []string{"fetch_url", "read_files"} {
+		if _, ok := tools.Get(name); ok {
+			t.Errorf("%s should be locked out", name)
+		}
+	}
+}
+
+func TestConfig_ApplyPolicy_LowerCapWins(t *testing.T) {
+	cfg := &Config{Defaults: DefaultsConfig{MaxCost: 2}}
+	cfg.ApplyPolicy(&Policy{MaxCost: 5})
+	if cfg.Defaults.MaxCost != 2 || cfg.Origin("defaults.max_cost") != "" {
+		t.Errorf("a lower user cap should stay: %v", cfg.Defaults.MaxCost)
+	}
+
+	cfg.ApplyPolicy(nil)
+	if cfg.Policy() == nil {
+		t.Error("a nil policy shouldn't drop the one applied")
+	}
+}
+
+func TestConfig_ApplyPolicy_ReadOnly(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyPolicy(&Policy{ReadOnly: true, path: "/etc/agentflow/policy.yaml"})
+	if !cfg.ReadOnly || cfg.Origin("read_only") != "policy /etc/agentflow/policy.yaml" {
+		t.Errorf("read only = %v from %q", cfg.ReadOnly, cfg.Origin("read_only"))
+	}
+	tools := cfg.BuildTools()
+	tools.Register(&tool.Func{ToolName: "write_file", Changes: true})
+	artifact.Offer(tools, t.TempDir(), "s1")
+	for _, name := range []string{"write_file", artifact.ToolName} {
+		if _, ok := tools.Get(name); ok {
+			t.Errorf("%s changes things but was registered in read-only mode", name)
+		}
+	}
+}
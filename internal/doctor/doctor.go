@@ -0,0 +1,274 @@
+// Package doctor checks an agentflow setup for common problems, such as
+// missing skill paths or unset config variables, and repairs the ones it
+// can
+package doctor
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/internal/skill"
+)
+
+// Status is the outcome of a check
+type Status int
+
+const (
+	OK Status = iota
+	Warn
+	Fail
+)
+
+// Symbol returns the mark printed before a result
+func (s Status) Symbol() string {
+	switch s {
+	case Warn:
+		return "!"
+	case Fail:
+		return "✗"
+	default:
+		return "✓"
+	}
+}
+
+// Result is one finding
+type Result struct {
+	Check  string // "config", "skills", "sessions", ...
+	Status Status
+	Detail string
+	Fix    func() error // Repairs the problem; nil when it needs a person
+}
+
+// Options describe the setup to check
+type Options struct {
+	ConfigPath string                    // Config file in use; empty when running on defaults
+	SkillPaths []string                  // Configured skill paths
+	Builtin    fs.FS                     // Built-in skills; nil when turned off
+	Dirs       map[string]string         // Directories agentflow writes to, by check name
+	Keys       map[string][]provider.Key // API keys by provider, for rotation reminders
+}
+
+// Run performs every check
+func Run(opts Options) []Result {
+	var results []Result
+	results = append(results, checkConfig(opts.ConfigPath)...)
+	results = append(results, checkSkillPaths(opts.SkillPaths)...)
+	results = append(results, checkSkillNames(opts.SkillPaths, opts.Builtin)...)
+
+	results = append(results, checkKeys(opts.Keys, time.Now())...)
+
+	names := make([]string, 0, len(opts.Dirs))
+	for name := range opts.Dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		results = append(results, checkDir(name, opts.Dirs[name]))
+	}
+	return results
+}
+
+// checkKeys warns about API keys that expired, expire soon or are due
+// for rotation
+func checkKeys(keys map[string][]provider.Key, now time.Time) []Result {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var results []Result
+	for _, name := range names {
+		for _, k := range keys[name] {
+			if reminder := k.Reminder(now); reminder != "" {
+				results = append(results, Result{Check: "keys", Status: Warn, Detail: fmt.Sprintf("%s key %s: %s", name, k.Label, reminder)})
+			}
+		}
+	}
+	return results
+}
+
+// envRef matches $VAR and ${VAR}, as expanded by the config loader
+var envRef = regexp.MustCompile(`\$\{(\w+)\}|\$(\w+)`)
+
+// checkConfig reports environment variables the config uses but that are
+// not set, which silently expand to nothing
+func checkConfig(path string) []Result {
+	if path == "" {
+		return []Result{{Check: "config", Status: Warn, Detail: "no config file; run agentflow to set one up"}}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []Result{{Check: "config", Status: Fail, Detail: err.Error()}}
+	}
+
+	var results []Result
+	seen := make(map[string]bool)
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		for _, m := range envRef.FindAllStringSubmatch(line, -1) {
+			name := m[1] + m[2]
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if _, ok := os.LookupEnv(name); !ok {
+				results = append(results, Result{
+					Check:  "config",
+					Status: Warn,
+					Detail: fmt.Sprintf("%s:%d: $%s is not set and expands to nothing", path, i+1, name),
+				})
+			}
+		}
+	}
+	if len(results) == 0 {
+		results = append(results, Result{Check: "config", Status: OK, Detail: path})
+	}
+	return results
+}
+
+// expandHome expands a leading ~ the way skill paths do
+func expandHome(p string) string {
+	if strings.HasPrefix(p, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[1:])
+		}
+	}
+	return p
+}
+
+// checkSkillPaths reports skill paths that don't exist or can't be read
+func checkSkillPaths(paths []string) []Result {
+	var results []Result
+	for _, p := range paths {
+		dir := expandHome(p)
+		info, err := os.Stat(dir)
+		switch {
+		case os.IsNotExist(err) && strings.HasSuffix(dir, ".md"):
+			results = append(results, Result{Check: "skills", Status: Warn, Detail: fmt.Sprintf("skill file %s does not exist", p)})
+		case os.IsNotExist(err):
+			results = append(results, Result{
+				Check:  "skills",
+				Status: Warn,
+				Detail: fmt.Sprintf("skill path %s does not exist", p),
+				Fix:    func() error { return os.MkdirAll(dir, 0755) },
+			})
+		case err != nil:
+			results = append(results, Result{Check: "skills", Status: Fail, Detail: err.Error()})
+		case info.IsDir():
+			if _, err := os.ReadDir(dir); err != nil {
+				results = append(results, Result{Check: "skills", Status: Fail, Detail: err.Error()})
+			} else {
+				results = append(results, Result{Check: "skills", Status: OK, Detail: p})
+			}
+		}
+	}
+	return results
+}
+
+// checkSkillNames reports skills that fail to parse and skill names
+// defined more than once, where the last one loaded wins
+func checkSkillNames(paths []string, builtin fs.FS) []Result {
+	var results []Result
+	sources := make(map[string][]string)
+
+	if builtin != nil {
+		entries, _ := fs.ReadDir(builtin, ".")
+		for _, entry := range entries {
+			name := path.Join(entry.Name(), "SKILL.md")
+			data, err := fs.ReadFile(builtin, name)
+			if err != nil {
+				continue
+			}
+			if s, err := skill.Parse(string(data)); err == nil {
+				sources[s.Name] = append(sources[s.Name], skill.BuiltinPrefix+name)
+			}
+		}
+	}
+
+	files, err := skill.Discover(paths)
+	if err != nil {
+		return []Result{{Check: "skills", Status: Fail, Detail: err.Error()}}
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			results = append(results, Result{Check: "skills", Status: Fail, Detail: err.Error()})
+			continue
+		}
+		s, err := skill.Parse(string(data))
+		if err != nil {
+			results = append(results, Result{Check: "skills", Status: Fail, Detail: fmt.Sprintf("%s: %v", file, err)})
+			continue
+		}
+		sources[s.Name] = append(sources[s.Name], file)
+	}
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		srcs := sources[name]
+		if len(srcs) < 2 {
+			continue
+		}
+		winner := srcs[len(srcs)-1]
+		shadowed := srcs[:len(srcs)-1]
+		status := Warn
+		if len(shadowed) == 1 && strings.HasPrefix(shadowed[0], skill.BuiltinPrefix) {
+			// Replacing a built-in skill is how they are customized
+			status = OK
+		}
+		results = append(results, Result{
+			Check:  "skills",
+			Status: status,
+			Detail: fmt.Sprintf("skill %q in %s shadows %s", name, winner, strings.Join(shadowed, ", ")),
+		})
+	}
+	return results
+}
+
+// checkDir reports a directory agentflow can't write to, creating it or
+// restoring the owner's permissions as the fix
+func checkDir(name, dir string) Result {
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return Result{
+			Check:  name,
+			Status: Warn,
+			Detail: fmt.Sprintf("%s does not exist", dir),
+			Fix:    func() error { return os.MkdirAll(dir, 0755) },
+		}
+	}
+	if err != nil {
+		return Result{Check: name, Status: Fail, Detail: err.Error()}
+	}
+	if !info.IsDir() {
+		return Result{Check: name, Status: Fail, Detail: fmt.Sprintf("%s is not a directory", dir)}
+	}
+
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return Result{
+			Check:  name,
+			Status: Fail,
+			Detail: fmt.Sprintf("%s is not writable", dir),
+			Fix:    func() error { return os.Chmod(dir, info.Mode().Perm()|0700) },
+		}
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return Result{Check: name, Status: OK, Detail: dir}
+}
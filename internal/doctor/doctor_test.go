@@ -0,0 +1,95 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/provider"
+)
+
+func findResult(results []Result, check, detail string) (Result, bool) {
+	for _, r := range results {
+		if r.Check == check && strings.Contains(r.Detail, detail) {
+			return r, true
+		}
+	}
+	return Result{}, false
+}
+
+func TestCheckConfig_UnsetVariables(t *testing.T) {
+	t.Setenv("DOCTOR_SET_KEY", "x")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte("# uses $DOCTOR_COMMENTED\nproviders:\n  groq:\n    api_key: ${DOCTOR_SET_KEY}\n  together:\n    api_key: $DOCTOR_UNSET_KEY\n"), 0644)
+
+	results := checkConfig(path)
+	if len(results) != 1 || results[0].Status != Warn {
+		t.Fatalf("results = %+v", results)
+	}
+	if !strings.Contains(results[0].Detail, ":6: $DOCTOR_UNSET_KEY") {
+		t.Errorf("detail = %q", results[0].Detail)
+	}
+}
+
+func TestCheckSkillPaths_FixCreatesMissing(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "skills")
+	results := checkSkillPaths([]string{missing})
+	if len(results) != 1 || results[0].Status != Warn || results[0].Fix == nil {
+		t.Fatalf("results = %+v", results)
+	}
+	if err := results[0].Fix(); err != nil {
+		t.Fatalf("Fix: %v", err)
+	}
+	if r := checkSkillPaths([]string{missing}); r[0].Status != OK {
+		t.Errorf("after fix: %+v", r)
+	}
+}
+
+func TestCheckSkillNames_Duplicates(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+	write := func(dir, name, file string) {
+		os.WriteFile(filepath.Join(dir, file), []byte("---\nname: "+name+"\n---\nBody"), 0644)
+	}
+	write(a, "review", "review.md")
+	write(b, "review", "code-review.md")
+	write(b, "debugging", "debugging.md")
+	builtin := fstest.MapFS{"debugging/SKILL.md": {Data: []byte("---\nname: debugging\n---\nBody")}}
+
+	results := checkSkillNames([]string{a, b}, builtin)
+
+	r, ok := findResult(results, "skills", `"review"`)
+	if !ok || r.Status != Warn || !strings.Contains(r.Detail, "code-review.md shadows") {
+		t.Errorf("duplicate not reported: %+v", results)
+	}
+	r, ok = findResult(results, "skills", `"debugging"`)
+	if !ok || r.Status != OK {
+		t.Errorf("override of a built-in should be OK: %+v", results)
+	}
+}
+
+func TestCheckDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	r := checkDir("sessions", dir)
+	if r.Status != Warn || r.Fix == nil {
+		t.Fatalf("missing dir: %+v", r)
+	}
+	if err := r.Fix(); err != nil {
+		t.Fatalf("Fix: %v", err)
+	}
+	if r := checkDir("sessions", dir); r.Status != OK {
+		t.Errorf("after fix: %+v", r)
+	}
+}
+
+func TestCheckKeys(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.Local)
+	results := checkKeys(map[string][]provider.Key{
+		"groq": {{Label: "work", Added: "2025-01-01"}, {Label: "fresh", Added: "2026-05-01"}},
+	}, now)
+	if len(results) != 1 || results[0].Status != Warn || !strings.Contains(results[0].Detail, "groq key work: 516 days old") {
+		t.Errorf("results = %+v", results)
+	}
+}
@@ -0,0 +1,266 @@
+// Package fetch downloads web pages as readable text, for URLs pasted in
+// messages and for the fetch_url tool
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/guard"
+	"github.com/agentflow/agentflow/internal/tool"
+)
+
+// What to do with URLs pasted in a message
+const (
+	Ask  = "ask"  // Ask before fetching them (default)
+	Auto = "auto" // Fetch them and add their text to context
+	Off  = "off"  // Leave them as text
+)
+
+// MaxTokens caps the text kept from one page; longer pages are cut
+const MaxTokens = 8000
+
+// maxBytes caps what is downloaded of one page
+const maxBytes = 2 << 20
+
+var client = &http.Client{Timeout: 20 * time.Second}
+
+// ErrNotPublic is returned when the fetch_url tool is pointed at an
+// address that isn't on the public internet
+var ErrNotPublic = errors.New("not a public address")
+
+// publicClient is the client of the fetch_url tool. It only connects to
+// public addresses, checked once the host is resolved and again on every
+// redirect, so that a page the model read can't point it at this
+// machine's services, the local network or a cloud metadata endpoint.
+// It doesn't go through a proxy, which would hide the address.
+var publicClient = &http.Client{
+	Timeout: 20 * time.Second,
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 10 * time.Second, Control: dialPublic}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return checkPublicHost(req.URL.Hostname())
+	},
+}
+
+// dialPublic refuses connections to addresses that aren't public
+func dialPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	return checkPublicHost(host)
+}
+
+// checkPublicHost refuses localhost and IP addresses that are loopback,
+// private, link-local (169.254.169.254 included), multicast or
+// unspecified; other names are checked by dialPublic once resolved
+func checkPublicHost(host string) error {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return fmt.Errorf("%s: %w", host, ErrNotPublic)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%s: %w", host, ErrNotPublic)
+	}
+	return nil
+}
+
+// Page is the readable text of a fetched URL
+type Page struct {
+	URL       string
+	Title     string // Empty when the page has none
+	Text      string
+	Truncated bool // Cut to MaxTokens
+}
+
+// Get downloads url and extracts its text. HTML is reduced to its
+// headings, paragraphs, lists and code; plain text, Markdown and JSON
+// are kept as they are.
+func Get(ctx context.Context, url string) (*Page, error) {
+	return get(ctx, client, url)
+}
+
+// get is Get with the client to download with
+func get(ctx context.Context, client *http.Client, url string) (*Page, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	req.Header.Set("User-Agent", "agentflow")
+	req.Header.Set("Accept", "text/html, text/plain, text/markdown, application/json;q=0.9, */*;q=0.1")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: status %d", url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", url, err)
+	}
+
+	page := &Page{URL: url}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		page.Title, page.Text = Readable(string(data))
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" || mediaType == "":
+		page.Text = strings.TrimSpace(string(data))
+	default:
+		return nil, fmt.Errorf("fetch %s: %s is not text", url, mediaType)
+	}
+	if page.Text == "" {
+		return nil, fmt.Errorf("fetch %s: no readable text", url)
+	}
+
+	if limit := MaxTokens * 4; len(page.Text) > limit {
+		cut := page.Text[:limit]
+		if i := strings.LastIndex(cut, "\n"); i > 0 {
+			cut = cut[:i]
+		}
+		page.Text = cut + "\n... (cut)"
+		page.Truncated = true
+	}
+	return page, nil
+}
+
+// Context renders a page as the context block added to a conversation
+func (p *Page) Context() string {
+	if p.Title != "" {
+		return fmt.Sprintf("Fetched from %s (%s):\n\n%s", p.URL, p.Title, p.Text)
+	}
+	return fmt.Sprintf("Fetched from %s:\n\n%s", p.URL, p.Text)
+}
+
+// Chip is the compact form a fetched page is shown as
+func (p *Page) Chip() string {
+	name := p.URL
+	if p.Title != "" {
+		name = p.Title
+	}
+	chip := fmt.Sprintf("🌐 %s · ~%d tokens", name, agent.EstimateTokens(p.Text))
+	if p.Truncated {
+		chip += " (cut)"
+	}
+	return chip
+}
+
+// suspicious says, on a chip, what happened to a page's lines that looked
+// like prompt injection, by guard mode
+var suspicious = map[string]string{guard.Flag: "flagged", guard.Quarantine: "withheld", guard.Ask: "withheld"}
+
+// AddToContext fetches each URL into the agent's context as retrieved
+// text, screened by the agent's guard, returning a chip for each page, or
+// for why it couldn't be fetched
+func AddToContext(ctx context.Context, ag *agent.Agent, urls []string) []string {
+	var chips []string
+	for _, url := range urls {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		page, err := Get(ctx, url)
+		cancel()
+		if err != nil {
+			chips = append(chips, "⚠ "+err.Error())
+			continue
+		}
+		content, findings := ag.Guard().Screen("The page "+page.URL, page.Context())
+		ag.AddContext(agent.SourceRetrieved, page.URL, content)
+		chip := page.Chip()
+		if len(findings) > 0 {
+			chip += fmt.Sprintf(" · ⚠ %d suspicious line(s) %s", len(findings), suspicious[ag.Guard().Mode()])
+		}
+		chips = append(chips, chip)
+	}
+	return chips
+}
+
+var (
+	titleTag   = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	hiddenTags = regexp.MustCompile(`(?is)<(script|style|noscript|svg|head|title|nav|footer|template)\b.*?</(script|style|noscript|svg|head|title|nav|footer|template)>`)
+	comments   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	headings   = regexp.MustCompile(`(?i)<h([1-6])[^>]*>`)
+	listItems  = regexp.MustCompile(`(?i)<li[^>]*>`)
+	preTags    = regexp.MustCompile(`(?i)</?pre[^>]*>`)
+	blockTags  = regexp.MustCompile(`(?i)</?(p|div|br|tr|table|section|article|header|ul|ol|dl|dt|dd|blockquote|h[1-6])\b[^>]*>`)
+	anyTag     = regexp.MustCompile(`(?s)<[^>]*>`)
+	spaces     = regexp.MustCompile(`[ \t]+`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// Readable reduces an HTML document to its title and text, keeping
+// headings as Markdown and list items as bullets
+func Readable(doc string) (title, text string) {
+	if m := titleTag.FindStringSubmatch(doc); m != nil {
+		title = strings.TrimSpace(html.UnescapeString(anyTag.ReplaceAllString(m[1], "")))
+	}
+
+	doc = comments.ReplaceAllString(doc, "")
+	doc = hiddenTags.ReplaceAllString(doc, "")
+	doc = headings.ReplaceAllStringFunc(doc, func(tag string) string {
+		return "\n\n" + strings.Repeat("#", int(tag[2]-'0')) + " "
+	})
+	doc = listItems.ReplaceAllString(doc, "\n- ")
+	doc = preTags.ReplaceAllString(doc, "\n```\n")
+	doc = blockTags.ReplaceAllString(doc, "\n")
+	doc = html.UnescapeString(anyTag.ReplaceAllString(doc, ""))
+
+	lines := strings.Split(doc, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spaces.ReplaceAllString(line, " "))
+	}
+	text = blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return title, strings.TrimSpace(text)
+}
+
+// Tool returns the fetch_url tool, which reads a web page on the public
+// internet
+func Tool() tool.Tool {
+	return &tool.Func{
+		ToolName: "fetch_url",
+		Desc:     "Fetch a web page, such as documentation or an issue, and return its readable text. Long pages are cut.",
+		Params: tool.Object(map[string]any{
+			"url": tool.String("The http or https URL to fetch"),
+		}, "url"),
+		Activity: tool.KindFetch,
+		Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+			var in struct {
+				URL string `json:"url"`
+			}
+			if err := json.Unmarshal(args, &in); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			if !strings.HasPrefix(in.URL, "http://") && !strings.HasPrefix(in.URL, "https://") {
+				return "", errors.New("url must start with http:// or https://")
+			}
+			page, err := get(ctx, publicClient, in.URL)
+			if err != nil {
+				return "", err
+			}
+			return page.Context(), nil
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadable(t *testing.T) {
+	doc := `<html><head><title>Guide &amp; API</title><style>p{}</style></head>
+<body><nav>Home | Docs</nav><h2>Install</h2><p>Run   the <b>installer</b>.</p>
+<ul><li>one</li><li>two</li></ul><pre>go get x</pre><script>alert(1)</script></body></html>`
+	title, text := Readable(doc)
+	if title != "Guide & API" {
+		t.Errorf("title = %q", title)
+	}
+	want := "## Install\n\nRun the installer.\n\n- one\n- two\n\n```\ngo get x\n```"
+	if text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+}
+
+func TestGet(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<title>Docs</title><p>Hello</p>"))
+		case "/long":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(strings.Repeat("line of text\n", MaxTokens)))
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89, 'P', 'N', 'G'})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	page, err := Get(context.Background(), srv.URL+"/page")
+	if err != nil || page.Title != "Docs" || page.Text != "Hello" {
+		t.Fatalf("Get = %+v, %v", page, err)
+	}
+	if !strings.Contains(page.Context(), "(Docs):\n\nHello") || !strings.HasPrefix(page.Chip(), "🌐 Docs") {
+		t.Errorf("Context = %q, Chip = %q", page.Context(), page.Chip())
+	}
+
+	page, err = Get(context.Background(), srv.URL+"/long")
+	if err != nil || !page.Truncated || len(page.Text) > MaxTokens*4+20 {
+		t.Errorf("long page: truncated %v, %d bytes, %v", page.Truncated, len(page.Text), err)
+	}
+	if _, err := Get(context.Background(), srv.URL+"/image"); err == nil {
+		t.Error("expected an error for an image")
+	}
+	if _, err := Get(context.Background(), srv.URL+"/missing"); err == nil {
+		t.Error("expected an error for a 404")
+	}
+}
+
+func TestTool_PublicOnly(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	defer srv.Close()
+
+	args, _ := json.Marshal(map[string]string{"url": srv.URL})
+	if _, err := Tool().Run(context.Background(), args); !errors.Is(err, ErrNotPublic) {
+		t.Errorf("fetching a loopback server: %v", err)
+	}
+
+	for host, public := range map[string]bool{
+		"example.com":     true,
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"localhost":       false,
+		"127.0.0.1":       false,
+		"10.0.0.2":        false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"::1":             false,
+		"fe80::1":         false,
+		"0.0.0.0":         false,
+	} {
+		if err := checkPublicHost(host); (err == nil) != public {
+			t.Errorf("checkPublicHost(%s) = %v", host, err)
+		}
+	}
+	redirect, _ := http.NewRequest("GET", "http://169.254.169.254/latest/meta-data/", nil)
+	if err := publicClient.CheckRedirect(redirect, nil); !errors.Is(err, ErrNotPublic) {
+		t.Errorf("redirect to the metadata endpoint: %v", err)
+	}
+}
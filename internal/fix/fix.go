@@ -0,0 +1,294 @@
+// Package fix runs a project's build, test and lint commands and asks the
+// agent to fix what fails: the failure output and the code it points at
+// go to the model, the diff it replies with is applied, and the commands
+// run again until they pass or the rounds run out.
+package fix
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/audit"
+	"github.com/agentflow/agentflow/internal/patch"
+)
+
+// DefaultRounds is how many fix attempts are made when none is configured
+const DefaultRounds = 3
+
+const (
+	maxOutput   = 8000 // Bytes of failure output sent, from the end
+	maxExcerpts = 6    // Files quoted per round
+	excerptPad  = 8    // Lines of context around each referenced line
+)
+
+// Config holds fix loop settings
+type Config struct {
+	Root     string
+	Commands []string // Run in order with sh -c; the first failure is fixed
+	Rounds   int
+	Agent    *agent.Agent
+	Out      io.Writer // Progress and the model's replies
+}
+
+// Result reports how the loop ended
+type Result struct {
+	Passed  bool
+	Rounds  int      // Fix attempts made
+	Changed []string // Files patched, relative to the root
+}
+
+// DetectCommands returns check commands for the project at root from
+// its build files, or nil when none is recognized
+func DetectCommands(root string) []string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(root, name))
+		return err == nil
+	}
+	switch {
+	case exists("go.mod"):
+		return []string{"go build ./...", "go vet ./...", "go test ./..."}
+	case exists("Cargo.toml"):
+		return []string{"cargo build", "cargo test"}
+	case exists("package.json"):
+		return []string{"npm test"}
+	case exists("pyproject.toml"), exists("setup.py"):
+		return []string{"python -m pytest"}
+	case exists("Makefile"):
+		return []string{"make"}
+	}
+	return nil
+}
+
+// Run runs the fix loop
+func Run(ctx context.Context, cfg Config) (*Result, error) {
+	if len(cfg.Commands) == 0 {
+		return nil, errors.New("no check commands configured")
+	}
+	if cfg.Rounds <= 0 {
+		cfg.Rounds = DefaultRounds
+	}
+	if cfg.Out == nil {
+		cfg.Out = io.Discard
+	}
+
+	result := &Result{}
+	changed := make(map[string]bool)
+	applyErr := ""
+	for {
+		failed, output, err := Check(ctx, cfg.Root, cfg.Commands, cfg.Out)
+		if err != nil {
+			return result, err
+		}
+		checked := audit.Entry{Kind: audit.Bash, Action: strings.Join(cfg.Commands, "; "), Detail: "checks passed"}
+		if failed != "" {
+			checked.Detail, checked.Error = "checks failed", failed+" failed"
+		}
+		cfg.Agent.Audit().Record(checked)
+		if failed == "" {
+			result.Passed = true
+			break
+		}
+		if result.Rounds == cfg.Rounds {
+			break
+		}
+		result.Rounds++
+		fmt.Fprintf(cfg.Out, "\n🔧 Round %d/%d: asking for a fix\n\n", result.Rounds, cfg.Rounds)
+
+		reply, err := ask(ctx, cfg, prompt(cfg.Root, failed, output, applyErr))
+		if err != nil {
+			return result, err
+		}
+
+		applyErr = ""
+		patches, err := patch.Parse(reply)
+		if err == nil {
+			var written []string
+			if written, err = patch.Apply(cfg.Root, patches); err == nil {
+				for _, path := range written {
+					changed[path] = true
+					cfg.Agent.Audit().Record(audit.Entry{Kind: audit.File, Action: filepath.Join(cfg.Root, path), Detail: fmt.Sprintf("patched in fix round %d", result.Rounds)})
+				}
+				fmt.Fprintf(cfg.Out, "\n✓ Patched %s\n", strings.Join(written, ", "))
+			}
+		}
+		if err != nil {
+			applyErr = err.Error()
+			fmt.Fprintf(cfg.Out, "\n✗ Patch not applied: %v\n", err)
+		}
+	}
+
+	for path := range changed {
+		result.Changed = append(result.Changed, path)
+	}
+	sort.Strings(result.Changed)
+	return result, nil
+}
+
+// Check runs the commands in order in root, returning the first failing
+// command and its output, or "" when all pass. Progress goes to out.
+func Check(ctx context.Context, root string, commands []string, out io.Writer) (string, string, error) {
+	for _, command := range commands {
+		fmt.Fprintf(out, "$ %s\n", command)
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Dir = root
+		output, err := cmd.CombinedOutput()
+		if ctx.Err() != nil {
+			return "", "", ctx.Err()
+		}
+		if err != nil {
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				return "", "", fmt.Errorf("run %s: %w", command, err)
+			}
+			fmt.Fprintf(out, "✗ failed (exit %d)\n", exitErr.ExitCode())
+			return command, string(output), nil
+		}
+	}
+	fmt.Fprintln(out, "✓ all checks pass")
+	return "", "", nil
+}
+
+// Fingerprint returns a digest of the files under root as git sees them,
+// their status, diffs and untracked contents, which changes when one of
+// them does; "" outside a git repository
+func Fingerprint(ctx context.Context, root string) string {
+	git := func(args ...string) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = root
+		return cmd.Output()
+	}
+	status, err := git("status", "--porcelain", "-z", "-uall")
+	if err != nil {
+		return ""
+	}
+	h := sha256.New()
+	h.Write(status)
+	for _, args := range [][]string{{"diff", "--binary", "--no-ext-diff"}, {"diff", "--cached", "--binary", "--no-ext-diff"}} {
+		out, _ := git(args...)
+		h.Write(out)
+	}
+	untracked, _ := git("ls-files", "--others", "--exclude-standard", "-z")
+	for _, path := range strings.Split(string(untracked), "\x00") {
+		if data, err := os.ReadFile(filepath.Join(root, path)); err == nil && path != "" {
+			h.Write(data)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ask streams the agent's reply to the prompt
+func ask(ctx context.Context, cfg Config, prompt string) (string, error) {
+	chunks, err := cfg.Agent.Stream(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	var reply strings.Builder
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			return "", chunk.Error
+		}
+		fmt.Fprint(cfg.Out, chunk.Content)
+		reply.WriteString(chunk.Content)
+	}
+	fmt.Fprintln(cfg.Out)
+	return reply.String(), nil
+}
+
+// prompt describes a failure to the model with excerpts of the files
+// its output points at
+func prompt(root, command, output, applyErr string) string {
+	if len(output) > maxOutput {
+		output = "..." + output[len(output)-maxOutput:]
+	}
+
+	var sb strings.Builder
+	if applyErr != "" {
+		sb.WriteString(fmt.Sprintf("Your last patch was not applied: %s\n\n", applyErr))
+	}
+	sb.WriteString(fmt.Sprintf("The command `%s` fails:\n\n```\n%s\n```\n", command, strings.TrimRight(output, "\n")))
+	if excerpts := Excerpts(root, output); excerpts != "" {
+		sb.WriteString("\nCurrent code at the reported locations:\n\n" + excerpts)
+	}
+	sb.WriteString("\nFind the cause and fix it. Reply with a short explanation and one unified diff " +
+		"in a ```diff block, with paths relative to the project root and a few lines of context. " +
+		"Change tests only if they are wrong.")
+	return sb.String()
+}
+
+// locationRegex matches file:line references in tool output
+var locationRegex = regexp.MustCompile(`([A-Za-z0-9_./\\-]+\.[A-Za-z0-9]+):(\d+)`)
+
+// Excerpts quotes the lines around file:line references in output that
+// name files under root
+func Excerpts(root, output string) string {
+	lines := make(map[string][]int) // File -> referenced lines
+	var files []string
+	for _, m := range locationRegex.FindAllStringSubmatch(output, -1) {
+		path := m[1]
+		if filepath.IsAbs(path) {
+			rel, err := filepath.Rel(root, path)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			path = rel
+		}
+		path = filepath.Clean(path)
+		if info, err := os.Stat(filepath.Join(root, path)); err != nil || info.IsDir() {
+			continue
+		}
+		n, _ := strconv.Atoi(m[2])
+		if _, ok := lines[path]; !ok {
+			if len(files) == maxExcerpts {
+				continue
+			}
+			files = append(files, path)
+		}
+		lines[path] = append(lines[path], n)
+	}
+
+	var sb strings.Builder
+	for _, path := range files {
+		data, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			continue
+		}
+		src := strings.Split(string(data), "\n")
+		for _, r := range mergeRanges(lines[path], len(src)) {
+			sb.WriteString(fmt.Sprintf("%s (lines %d-%d):\n```%s\n", path, r[0], r[1], strings.TrimPrefix(filepath.Ext(path), ".")))
+			sb.WriteString(strings.Join(src[r[0]-1:r[1]], "\n") + "\n")
+			sb.WriteString("```\n\n")
+		}
+	}
+	return sb.String()
+}
+
+// mergeRanges pads referenced lines into 1-based inclusive ranges,
+// merging those that overlap
+func mergeRanges(refs []int, total int) [][2]int {
+	sort.Ints(refs)
+	var ranges [][2]int
+	for _, n := range refs {
+		if n < 1 || n > total {
+			continue
+		}
+		start, end := max(1, n-excerptPad), min(total, n+excerptPad)
+		if len(ranges) > 0 && start <= ranges[len(ranges)-1][1]+1 {
+			ranges[len(ranges)-1][1] = max(ranges[len(ranges)-1][1], end)
+			continue
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges
+}
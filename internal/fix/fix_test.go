@@ -0,0 +1,112 @@
+package fix
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/pkg/providertest"
+)
+
+// setup writes a project whose check fails while status.txt says broken
+func setup(t *testing.T, response string) (Config, *providertest.Provider) {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "status.txt"), []byte("ok\nbroken\nok\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := providertest.New(response)
+	return Config{
+		Root:     root,
+		Commands: []string{"true", "! grep -Hn broken status.txt"},
+		Rounds:   2,
+		Agent:    agent.New(agent.Config{Provider: p, Model: "test-model"}),
+	}, p
+}
+
+func TestRun_Fixes(t *testing.T) {
+	cfg, p := setup(t, "Replace it.\n\n```diff\n--- a/status.txt\n+++ b/status.txt\n@@ -1,3 +1,3 @@\n ok\n-broken\n+fixed\n ok\n```")
+
+	result, err := Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !result.Passed || result.Rounds != 1 || len(result.Changed) != 1 || result.Changed[0] != "status.txt" {
+		t.Errorf("result = %+v", result)
+	}
+
+	prompt := p.Prompts()[0]
+	for _, want := range []string{"`! grep -Hn broken status.txt` fails", "status.txt:2:broken", "status.txt (lines 1-4):\n```txt\nok\nbroken\nok\n"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestRun_GivesUp(t *testing.T) {
+	cfg, p := setup(t, "```diff\n--- a/status.txt\n+++ b/status.txt\n@@ -1 +1 @@\n-missing\n+fixed\n```")
+
+	result, err := Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Passed || result.Rounds != 2 || len(result.Changed) != 0 {
+		t.Errorf("result = %+v", result)
+	}
+	// The second attempt is told why the first wasn't applied
+	prompts := p.Prompts()
+	if len(prompts) != 2 || !strings.HasPrefix(prompts[1], "Your last patch was not applied: status.txt: hunk 1") {
+		t.Errorf("prompts = %q", prompts)
+	}
+}
+
+func TestDetectCommands(t *testing.T) {
+	root := t.TempDir()
+	if cmds := DetectCommands(root); cmds != nil {
+		t.Errorf("empty dir commands = %v", cmds)
+	}
+	os.WriteFile(filepath.Join(root, "go.mod"), []byte("module x\n"), 0644)
+	if cmds := DetectCommands(root); len(cmds) != 3 || cmds[2] != "go test ./..." {
+		t.Errorf("Go commands = %v", cmds)
+	}
+}
+
+func TestMergeRanges(t *testing.T) {
+	got := mergeRanges([]int{30, 5, 12, 99}, 40)
+	if len(got) != 2 || got[0] != [2]int{1, 20} || got[1] != [2]int{22, 38} {
+		t.Errorf("ranges = %v", got)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	if Fingerprint(ctx, root) != "" {
+		t.Error("fingerprint outside a git repository")
+	}
+	if err := exec.Command("git", "init", "-q", root).Run(); err != nil {
+		t.Skipf("git: %v", err)
+	}
+	file := filepath.Join(root, "main.go")
+
+	// Every edit changes it, to an untracked or a staged file alike
+	seen := map[string]bool{}
+	for i, content := range []string{"package main\n", "package main\n\nfunc main() {}\n", "package main\n\nfunc main() { panic(1) }\n"} {
+		os.WriteFile(file, []byte(content), 0644)
+		if i == 2 {
+			exec.Command("git", "-C", root, "add", ".").Run()
+		}
+		fp := Fingerprint(ctx, root)
+		if fp == "" || seen[fp] {
+			t.Errorf("fingerprint of %q = %q, seen before %v", content, fp, seen[fp])
+		}
+		seen[fp] = true
+	}
+	if fp := Fingerprint(ctx, root); !seen[fp] {
+		t.Error("fingerprint changed without an edit")
+	}
+}
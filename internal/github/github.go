@@ -0,0 +1,365 @@
+// Package github is a minimal GitHub REST API client for pulling issues
+// into context and opening pull requests
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the public GitHub API endpoint
+const DefaultAPIURL = "https://api.github.com"
+
+// Client talks to the GitHub REST API
+type Client struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// Config holds client configuration
+type Config struct {
+	APIURL string
+	Token  string
+}
+
+// NewClient creates a new GitHub client
+func NewClient(cfg Config) *Client {
+	baseURL := cfg.APIURL
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   cfg.Token,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// ResolveToken finds a token from, in order: the configured value, the
+// GITHUB_TOKEN / GH_TOKEN environment variables, and the gh CLI's keychain
+func ResolveToken(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	for _, env := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+	}
+	if out, err := exec.Command("gh", "auth", "token").Output(); err == nil {
+		return strings.TrimSpace(string(out))
+	}
+	return ""
+}
+
+// Issue is a GitHub issue with its comments
+type Issue struct {
+	Number   int       `json:"number"`
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	State    string    `json:"state"`
+	HTMLURL  string    `json:"html_url"`
+	User     User      `json:"user"`
+	Labels   []Label   `json:"labels"`
+	Comments []Comment `json:"-"`
+}
+
+// User is a GitHub account
+type User struct {
+	Login string `json:"login"`
+}
+
+// Label is an issue label
+type Label struct {
+	Name string `json:"name"`
+}
+
+// Comment is an issue comment
+type Comment struct {
+	User      User      `json:"user"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PullRequest describes a pull request to create
+type PullRequest struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Draft bool   `json:"draft,omitempty"`
+}
+
+// CreatedPullRequest is the API's response to a created pull request
+type CreatedPullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+}
+
+// Gist describes a gist to create
+type Gist struct {
+	Description string              `json:"description,omitempty"`
+	Public      bool                `json:"public"`
+	Files       map[string]GistFile `json:"files"`
+}
+
+// GistFile is a file in a gist
+type GistFile struct {
+	Content string `json:"content"`
+}
+
+// CreatedGist is the API's response to a created gist
+type CreatedGist struct {
+	ID      string `json:"id"`
+	HTMLURL string `json:"html_url"`
+}
+
+// Release is a published GitHub release
+type Release struct {
+	TagName    string    `json:"tag_name"`
+	HTMLURL    string    `json:"html_url"`
+	Prerelease bool      `json:"prerelease"`
+	Assets     []Asset   `json:"assets"`
+	Published  time.Time `json:"published_at"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	DownloadURL string `json:"browser_download_url"`
+}
+
+// Asset returns the release's asset called name
+func (r *Release) Asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// LatestRelease returns the newest non-prerelease release of "owner/repo"
+func (c *Client) LatestRelease(ctx context.Context, repo string) (*Release, error) {
+	var release Release
+	if err := c.do(ctx, "GET", fmt.Sprintf("/repos/%s/releases/latest", repo), nil, &release); err != nil {
+		return nil, fmt.Errorf("get latest release: %w", err)
+	}
+	return &release, nil
+}
+
+// Download fetches a release asset, up to limit bytes
+func (c *Client) Download(ctx context.Context, asset Asset, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", asset.DownloadURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	// Assets can take longer than API calls
+	client := *c.client
+	client.Timeout = 5 * time.Minute
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", asset.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: status %d", asset.Name, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", asset.Name, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("download %s: larger than %d bytes", asset.Name, limit)
+	}
+	return data, nil
+}
+
+// GetIssue fetches an issue and its comments from "owner/repo"
+func (c *Client) GetIssue(ctx context.Context, repo string, number int) (*Issue, error) {
+	var issue Issue
+	if err := c.do(ctx, "GET", fmt.Sprintf("/repos/%s/issues/%d", repo, number), nil, &issue); err != nil {
+		return nil, fmt.Errorf("get issue: %w", err)
+	}
+
+	var comments []Comment
+	if err := c.do(ctx, "GET", fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=100", repo, number), nil, &comments); err != nil {
+		return nil, fmt.Errorf("get comments: %w", err)
+	}
+	issue.Comments = comments
+
+	return &issue, nil
+}
+
+// DefaultBranch returns the repository's default branch
+func (c *Client) DefaultBranch(ctx context.Context, repo string) (string, error) {
+	var info struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := c.do(ctx, "GET", "/repos/"+repo, nil, &info); err != nil {
+		return "", fmt.Errorf("get repo: %w", err)
+	}
+	return info.DefaultBranch, nil
+}
+
+// CreatePullRequest opens a pull request in "owner/repo"
+func (c *Client) CreatePullRequest(ctx context.Context, repo string, pr PullRequest) (*CreatedPullRequest, error) {
+	var created CreatedPullRequest
+	if err := c.do(ctx, "POST", fmt.Sprintf("/repos/%s/pulls", repo), pr, &created); err != nil {
+		return nil, fmt.Errorf("create pull request: %w", err)
+	}
+	return &created, nil
+}
+
+// FindPullRequest returns the open pull request from branch head in
+// "owner/repo", or nil when there is none
+func (c *Client) FindPullRequest(ctx context.Context, repo, head string) (*CreatedPullRequest, error) {
+	owner, _, _ := strings.Cut(repo, "/")
+	var prs []CreatedPullRequest
+	path := fmt.Sprintf("/repos/%s/pulls?state=open&head=%s", repo, url.QueryEscape(owner+":"+head))
+	if err := c.do(ctx, "GET", path, nil, &prs); err != nil {
+		return nil, fmt.Errorf("list pull requests: %w", err)
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	return &prs[0], nil
+}
+
+// UpdatePullRequest replaces a pull request's title and body; empty
+// fields are left unchanged
+func (c *Client) UpdatePullRequest(ctx context.Context, repo string, number int, title, body string) error {
+	update := map[string]string{}
+	if title != "" {
+		update["title"] = title
+	}
+	if body != "" {
+		update["body"] = body
+	}
+	if err := c.do(ctx, "PATCH", fmt.Sprintf("/repos/%s/pulls/%d", repo, number), update, nil); err != nil {
+		return fmt.Errorf("update pull request: %w", err)
+	}
+	return nil
+}
+
+// CreateGist creates a gist; it is secret unless Public is set
+func (c *Client) CreateGist(ctx context.Context, gist Gist) (*CreatedGist, error) {
+	var created CreatedGist
+	if err := c.do(ctx, "POST", "/gists", gist, &created); err != nil {
+		return nil, fmt.Errorf("create gist: %w", err)
+	}
+	return &created, nil
+}
+
+// do performs an API request, decoding the JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("github error %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// remoteRegex matches both SSH and HTTPS GitHub remote URLs
+var remoteRegex = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// ParseRemote extracts "owner/repo" from a git remote URL
+func ParseRemote(url string) (string, bool) {
+	m := remoteRegex.FindStringSubmatch(strings.TrimSpace(url))
+	if m == nil {
+		return "", false
+	}
+	return m[1] + "/" + m[2], true
+}
+
+// CurrentRepo returns "owner/repo" for the origin remote of the current directory
+func CurrentRepo() (string, error) {
+	out, err := exec.Command("git", "remote", "get-url", "origin").Output()
+	if err != nil {
+		return "", fmt.Errorf("read origin remote: %w", err)
+	}
+	repo, ok := ParseRemote(string(out))
+	if !ok {
+		return "", fmt.Errorf("origin is not a GitHub remote: %s", strings.TrimSpace(string(out)))
+	}
+	return repo, nil
+}
+
+// FormatIssue renders an issue as markdown suitable for the conversation context
+func FormatIssue(issue *Issue) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("# Issue #%d: %s\n\n", issue.Number, issue.Title))
+	sb.WriteString(fmt.Sprintf("State: %s | Author: @%s | %s\n", issue.State, issue.User.Login, issue.HTMLURL))
+	if len(issue.Labels) > 0 {
+		names := make([]string, len(issue.Labels))
+		for i, l := range issue.Labels {
+			names[i] = l.Name
+		}
+		sb.WriteString(fmt.Sprintf("Labels: %s\n", strings.Join(names, ", ")))
+	}
+
+	sb.WriteString("\n")
+	if issue.Body != "" {
+		sb.WriteString(strings.TrimSpace(issue.Body))
+		sb.WriteString("\n")
+	}
+
+	for _, c := range issue.Comments {
+		sb.WriteString(fmt.Sprintf("\n## @%s (%s)\n\n", c.User.Login, c.CreatedAt.Format("2006-01-02")))
+		sb.WriteString(strings.TrimSpace(c.Body))
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
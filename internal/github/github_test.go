@@ -0,0 +1,176 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		url  string
+		repo string
+		ok   bool
+	}{
+		{"git@github.com:agentflow/agentflow.git", "agentflow/agentflow", true},
+		{"https://github.com/agentflow/agentflow.git", "agentflow/agentflow", true},
+		{"https://github.com/agentflow/agentflow\n", "agentflow/agentflow", true},
+		{"https://gitlab.com/agentflow/agentflow.git", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			repo, ok := ParseRemote(tt.url)
+			if ok != tt.ok || repo != tt.repo {
+				t.Errorf("ParseRemote(%q) = %q, %v; want %q, %v", tt.url, repo, ok, tt.repo, tt.ok)
+			}
+		})
+	}
+}
+
+func TestClient_GetIssue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("missing auth header")
+		}
+		switch r.URL.Path {
+		case "/repos/owner/repo/issues/42":
+			w.Write([]byte(`{"number":42,"title":"Crash on start","body":"It panics.","state":"open","user":{"login":"alice"},"labels":[{"name":"bug"}]}`))
+		case "/repos/owner/repo/issues/42/comments":
+			w.Write([]byte(`[{"user":{"login":"bob"},"body":"Same here","created_at":"2024-01-02T00:00:00Z"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(Config{APIURL: server.URL, Token: "test-token"})
+	issue, err := c.GetIssue(context.Background(), "owner/repo", 42)
+	if err != nil {
+		t.Fatalf("GetIssue: %v", err)
+	}
+
+	if issue.Title != "Crash on start" {
+		t.Errorf("title = %q", issue.Title)
+	}
+	if len(issue.Comments) != 1 {
+		t.Fatalf("comments = %d, want 1", len(issue.Comments))
+	}
+
+	formatted := FormatIssue(issue)
+	for _, want := range []string{"# Issue #42: Crash on start", "Labels: bug", "It panics.", "@bob"} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("formatted issue missing %q:\n%s", want, formatted)
+		}
+	}
+}
+
+func TestClient_CreatePullRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/repos/owner/repo/pulls" {
+			http.NotFound(w, r)
+			return
+		}
+		var pr PullRequest
+		json.NewDecoder(r.Body).Decode(&pr)
+		if pr.Head != "feature" || pr.Base != "main" {
+			t.Errorf("pr = %+v", pr)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"number":7,"html_url":"https://github.com/owner/repo/pull/7"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(Config{APIURL: server.URL})
+	created, err := c.CreatePullRequest(context.Background(), "owner/repo", PullRequest{
+		Title: "Add feature",
+		Head:  "feature",
+		Base:  "main",
+	})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if created.Number != 7 {
+		t.Errorf("number = %d, want 7", created.Number)
+	}
+}
+
+func TestClient_FindAndUpdatePullRequest(t *testing.T) {
+	var patched map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/repo/pulls":
+			if r.URL.Query().Get("head") == "owner:feature" {
+				w.Write([]byte(`[{"number":7,"html_url":"https://github.com/owner/repo/pull/7"}]`))
+			} else {
+				w.Write([]byte(`[]`))
+			}
+		case r.Method == "PATCH" && r.URL.Path == "/repos/owner/repo/pulls/7":
+			json.NewDecoder(r.Body).Decode(&patched)
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(Config{APIURL: server.URL})
+	ctx := context.Background()
+	pr, err := c.FindPullRequest(ctx, "owner/repo", "feature")
+	if err != nil || pr == nil || pr.Number != 7 {
+		t.Fatalf("FindPullRequest = %+v, %v", pr, err)
+	}
+	if pr, err := c.FindPullRequest(ctx, "owner/repo", "other"); err != nil || pr != nil {
+		t.Errorf("FindPullRequest(other) = %+v, %v", pr, err)
+	}
+
+	if err := c.UpdatePullRequest(ctx, "owner/repo", 7, "", "New body"); err != nil {
+		t.Fatalf("UpdatePullRequest: %v", err)
+	}
+	if len(patched) != 1 || patched["body"] != "New body" {
+		t.Errorf("patched = %v", patched)
+	}
+}
+
+func TestClient_CreateGist(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/gists" {
+			http.NotFound(w, r)
+			return
+		}
+		var gist Gist
+		json.NewDecoder(r.Body).Decode(&gist)
+		if gist.Public || gist.Files["session.html"].Content != "<html></html>" {
+			t.Errorf("gist = %+v", gist)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"abc","html_url":"https://gist.github.com/abc"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(Config{APIURL: server.URL})
+	created, err := c.CreateGist(context.Background(), Gist{
+		Files: map[string]GistFile{"session.html": {Content: "<html></html>"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateGist: %v", err)
+	}
+	if created.HTMLURL != "https://gist.github.com/abc" {
+		t.Errorf("url = %q", created.HTMLURL)
+	}
+}
+
+func TestClient_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c := NewClient(Config{APIURL: server.URL})
+	if _, err := c.GetIssue(context.Background(), "owner/repo", 1); err == nil {
+		t.Error("expected error for 404")
+	}
+}
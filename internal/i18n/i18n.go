@@ -0,0 +1,153 @@
+// Package i18n translates user-facing TUI and REPL strings. Locales are
+// YAML files mapping message keys to text; English is built in and used
+// for any key a locale is missing.
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed locales/*.yaml
+var localeFS embed.FS
+
+// DefaultLocale is the fallback for missing locales and keys
+const DefaultLocale = "en"
+
+var (
+	mu       sync.RWMutex
+	current  = DefaultLocale
+	catalogs = map[string]map[string]string{}
+)
+
+func init() {
+	entries, _ := localeFS.ReadDir("locales")
+	for _, e := range entries {
+		data, err := localeFS.ReadFile("locales/" + e.Name())
+		if err != nil {
+			continue
+		}
+		load(strings.TrimSuffix(e.Name(), ".yaml"), data)
+	}
+}
+
+// load merges a locale file into the catalogs
+func load(lang string, data []byte) error {
+	var msgs map[string]string
+	if err := yaml.Unmarshal(data, &msgs); err != nil {
+		return fmt.Errorf("parse locale %s: %w", lang, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if catalogs[lang] == nil {
+		catalogs[lang] = map[string]string{}
+	}
+	for k, v := range msgs {
+		catalogs[lang][k] = v
+	}
+	return nil
+}
+
+// LoadDir loads <lang>.yaml files from dir, adding locales or overriding
+// built-in messages. A missing directory is not an error.
+func LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return fmt.Errorf("read locale: %w", err)
+		}
+		if err := load(strings.TrimSuffix(filepath.Base(f), ".yaml"), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Detect returns the user's language from LC_ALL, LC_MESSAGES or LANG
+// (e.g. "fr" for fr_FR.UTF-8), or DefaultLocale
+func Detect() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(env); v != "" {
+			return normalize(v)
+		}
+	}
+	return DefaultLocale
+}
+
+// normalize turns "fr_FR.UTF-8" or "pt-BR" into "fr" or "pt"
+func normalize(locale string) string {
+	locale = strings.ToLower(locale)
+	if i := strings.IndexAny(locale, "_-.@"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "" || locale == "c" || locale == "posix" {
+		return DefaultLocale
+	}
+	return locale
+}
+
+// SetLocale selects the UI language; "" or "auto" detects it from the
+// environment. Unknown languages fall back to English.
+func SetLocale(lang string) {
+	if lang == "" || lang == "auto" {
+		lang = Detect()
+	}
+	lang = normalize(lang)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := catalogs[lang]; !ok {
+		lang = DefaultLocale
+	}
+	current = lang
+}
+
+// Locale returns the active language
+func Locale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Available returns the languages with a catalog
+func Available() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// T returns the message for key in the active language, formatted with
+// args. Missing translations fall back to English, then to the key.
+func T(key string, args ...any) string {
+	mu.RLock()
+	msg, ok := catalogs[current][key]
+	if !ok {
+		msg, ok = catalogs[DefaultLocale][key]
+	}
+	mu.RUnlock()
+
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
@@ -0,0 +1,83 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"fr_FR.UTF-8": "fr",
+		"pt-BR":       "pt",
+		"es":          "es",
+		"de_DE@euro":  "de",
+		"C":           "en",
+		"POSIX":       "en",
+		"":            "en",
+	}
+	for in, want := range tests {
+		if got := normalize(in); got != want {
+			t.Errorf("normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "es_ES.UTF-8")
+	if got := Detect(); got != "es" {
+		t.Errorf("Detect() = %q, want es", got)
+	}
+
+	t.Setenv("LC_ALL", "fr_FR.UTF-8")
+	if got := Detect(); got != "fr" {
+		t.Errorf("LC_ALL should win, got %q", got)
+	}
+}
+
+func TestT(t *testing.T) {
+	defer SetLocale(DefaultLocale)
+
+	SetLocale("fr")
+	if got := T("msg.model_current", "llama3"); got != "Modèle actuel : llama3" {
+		t.Errorf("T() = %q", got)
+	}
+
+	SetLocale("xx")
+	if Locale() != DefaultLocale {
+		t.Errorf("unknown locale should fall back to English, got %q", Locale())
+	}
+	if got := T("no.such.key"); got != "no.such.key" {
+		t.Errorf("missing key should return the key, got %q", got)
+	}
+}
+
+func TestLocalesHaveEnglishKeys(t *testing.T) {
+	for _, lang := range Available() {
+		for key := range catalogs[lang] {
+			if _, ok := catalogs[DefaultLocale][key]; !ok {
+				t.Errorf("%s: key %q missing from en.yaml", lang, key)
+			}
+		}
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	defer SetLocale(DefaultLocale)
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "de.yaml"), []byte(`msg.cleared: "Unterhaltung gelöscht."`), 0644)
+	if err := LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+
+	SetLocale("de_DE.UTF-8")
+	if got := T("msg.cleared"); got != "Unterhaltung gelöscht." {
+		t.Errorf("T() = %q", got)
+	}
+	if got := T("msg.no_history"); got != "No conversation history." {
+		t.Errorf("missing key should fall back to English, got %q", got)
+	}
+}
@@ -42,7 +42,45 @@ func NewCompleter() *Completer {
 			{Value: "/skills", Display: "/skills", Description: "List available skills", Type: CompletionCommand},
 			{Value: "/status", Display: "/status", Description: "Show session status", Type: CompletionCommand},
 			{Value: "/history", Display: "/history", Description: "Show conversation stats", Type: CompletionCommand},
+			{Value: "/less", Display: "/less [all]", Description: "Page the last answer or the transcript", Type: CompletionCommand},
+			{Value: "/find", Display: "/find [text]", Description: "Search the conversation", Type: CompletionCommand},
+			{Value: "/voice", Display: "/voice", Description: "Dictate a message: record, then transcribe into the input", Type: CompletionCommand},
+			{Value: "/speak", Display: "/speak [on|off]", Description: "Speak answers aloud as they stream", Type: CompletionCommand},
+			{Value: "/expand", Display: "/expand [n|all]", Description: "Expand or collapse a context or bash block", Type: CompletionCommand},
 			{Value: "/compact", Display: "/compact", Description: "Compact conversation", Type: CompletionCommand},
+			{Value: "/pane", Display: "/pane", Description: "Add a tmux pane to context", Type: CompletionCommand},
+			{Value: "/paste-image", Display: "/paste-image", Description: "Attach clipboard image", Type: CompletionCommand},
+			{Value: "/thinking", Display: "/thinking", Description: "Expand/collapse reasoning", Type: CompletionCommand},
+			{Value: "/template", Display: "/template", Description: "Use a few-shot template", Type: CompletionCommand},
+			{Value: "/examples", Display: "/examples", Description: "Toggle few-shot examples", Type: CompletionCommand},
+			{Value: "/pin", Display: "/pin", Description: "Keep a file or message in context", Type: CompletionCommand},
+			{Value: "/unpin", Display: "/unpin", Description: "Unpin a file or message", Type: CompletionCommand},
+			{Value: "/refresh", Display: "/refresh", Description: "Re-send files changed on disk", Type: CompletionCommand},
+			{Value: "/map", Display: "/map", Description: "Add a project map to context", Type: CompletionCommand},
+			{Value: "/swap", Display: "/swap", Description: "Swap the last answer for the other model's", Type: CompletionCommand},
+			{Value: "/retry", Display: "/retry", Description: "Regenerate the last answer", Type: CompletionCommand},
+			{Value: "/edit-last", Display: "/edit-last", Description: "Edit the last message and resend it", Type: CompletionCommand},
+			{Value: "/system", Display: "/system", Description: "Show or change the system prompt", Type: CompletionCommand},
+			{Value: "/set", Display: "/set", Description: "Set temperature, max_tokens or top_p", Type: CompletionCommand},
+			{Value: "/settings", Display: "/settings", Description: "Show the generation parameters", Type: CompletionCommand},
+			{Value: "/style", Display: "/style", Description: "Choose how long answers are", Type: CompletionCommand},
+			{Value: "/concise", Display: "/concise", Description: "Toggle short answers", Type: CompletionCommand},
+			{Value: "/verbose", Display: "/verbose", Description: "Toggle detailed answers", Type: CompletionCommand},
+			{Value: "/plan", Display: "/plan [on|off]", Description: "Plan with read-only tools before changing anything", Type: CompletionCommand},
+			{Value: "/execute", Display: "/execute", Description: "Carry out the plan made in plan mode", Type: CompletionCommand},
+			{Value: "/tab", Display: "/tab [new|close|n]", Description: "Side conversations in tabs", Type: CompletionCommand},
+			{Value: "/ids", Display: "/ids", Description: "Number messages in the transcript", Type: CompletionCommand},
+			{Value: "/activity", Display: "/activity", Description: "Show or hide the feed of tool calls", Type: CompletionCommand},
+			{Value: "/show", Display: "/show [n]", Description: "Print message n in full", Type: CompletionCommand},
+			{Value: "/copy-msg", Display: "/copy-msg [n]", Description: "Copy message n to the clipboard", Type: CompletionCommand},
+			{Value: "/artifacts", Display: "/artifacts", Description: "List saved plans, reports and files", Type: CompletionCommand},
+			{Value: "/who", Display: "/who", Description: "List the agentflow instances running", Type: CompletionCommand},
+			{Value: "/preview", Display: "/preview", Description: "Show the next request without sending", Type: CompletionCommand},
+			{Value: "/tokens", Display: "/tokens [text]", Description: "Count tokens with the model's tokenizer", Type: CompletionCommand},
+			{Value: "/context", Display: "/context", Description: "Save or load a named context bundle", Type: CompletionCommand},
+			{Value: "/skill", Display: "/skill", Description: "Choose the skill or turn skills off", Type: CompletionCommand},
+			{Value: "/good", Display: "/good", Description: "Rate the last answer as good", Type: CompletionCommand},
+			{Value: "/bad", Display: "/bad", Description: "Rate the last answer as bad", Type: CompletionCommand},
 		},
 	}
 }
@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -117,3 +119,120 @@ func FormatBashResultForContext(result BashResult) string {
 
 	return sb.String()
 }
+
+// readingPrograms only look, unless given one of their writingFlags
+var readingPrograms = map[string]bool{
+	"ls": true, "cat": true, "head": true, "tail": true, "grep": true, "egrep": true, "fgrep": true,
+	"rg": true, "ag": true, "wc": true, "file": true, "stat": true, "du": true, "df": true,
+	"pwd": true, "cd": true, "echo": true, "printf": true, "which": true, "whereis": true, "type": true,
+	"tree": true, "find": true, "diff": true, "cmp": true, "sort": true, "cut": true, "tr": true,
+	"nl": true, "tac": true, "rev": true, "column": true, "fold": true, "paste": true, "comm": true,
+	"basename": true, "dirname": true, "realpath": true, "readlink": true, "jq": true,
+	"md5sum": true, "sha1sum": true, "sha256sum": true, "cksum": true, "od": true, "hexdump": true, "strings": true,
+	"date": true, "whoami": true, "id": true, "uname": true, "uptime": true,
+	"printenv": true, "ps": true, "free": true, "nproc": true, "true": true, "false": true, "test": true, "[": true,
+}
+
+// writingFlags make a reading program or subcommand write to a file,
+// change settings or run commands
+var writingFlags = map[string][]string{
+	"sort":     {"-o", "--output", "--compress-program"},
+	"tree":     {"-o"},
+	"find":     {"-delete", "-exec", "-execdir", "-ok", "-okdir", "-fprint", "-fprint0", "-fprintf", "-fls"},
+	"date":     {"-s", "--set"},
+	"rg":       {"--pre", "--pre-glob"},
+	"git":      {"--output", "-c", "--config-env"},
+	"git grep": {"-O", "--open-files-in-pager"},
+	"go env":   {"-w", "-u"},
+	"go vet":   {"-vettool", "--vettool", "-toolexec", "--toolexec"},
+	"go list":  {"-toolexec", "--toolexec"},
+}
+
+// readingSubcommands are the subcommands of tools that only look
+var readingSubcommands = map[string]map[string]bool{
+	"git": {"status": true, "diff": true, "log": true, "show": true, "blame": true, "grep": true,
+		"ls-files": true, "ls-tree": true, "rev-parse": true, "rev-list": true, "cat-file": true,
+		"describe": true, "shortlog": true},
+	"go":      {"doc": true, "list": true, "env": true, "version": true, "vet": true},
+	"docker":  {"ps": true, "images": true, "logs": true, "inspect": true, "version": true, "info": true},
+	"kubectl": {"get": true, "describe": true, "logs": true, "version": true, "explain": true, "top": true},
+	"npm":     {"ls": true, "list": true, "view": true, "outdated": true},
+}
+
+// listingSubcommands only list when given none but listingFlags, and
+// create, rename or delete otherwise, like git branch
+var listingSubcommands = map[string]map[string]bool{
+	"git": {"branch": true, "tag": true, "remote": true, "reflog": true},
+}
+
+// listingFlags change what a listing subcommand shows, not what it does
+var listingFlags = map[string]bool{
+	"-a": true, "--all": true, "-r": true, "--remotes": true, "-v": true, "-vv": true, "--verbose": true,
+	"-l": true, "--list": true, "--show-current": true,
+}
+
+var (
+	redirectPattern  = regexp.MustCompile(`>+\|?\s*(&?[^\s|;&<>()]*)`)
+	fdPattern        = regexp.MustCompile(`^&(\d+|-)$`)
+	commandSeparator = regexp.MustCompile(`&&|\|\|?|;|&|\n`)
+)
+
+// Writes reports whether a shell command may change files or other
+// state. Only commands made of programs known to only look pass: ls,
+// cat, grep and the like, and the subcommands of git, go, docker,
+// kubectl and npm that only look, without a flag that writes. Anything
+// else — another program, a redirect to a file, sh -c, xargs, $(...) or
+// backticks — counts as writing. Commands are split on pipes and ;, &&
+// and ||. It is a check for read-only sessions, not a sandbox.
+func Writes(command string) bool {
+	if strings.Contains(command, "$(") || strings.Contains(command, "`") || strings.Contains(command, "<(") {
+		return true
+	}
+	for _, redirect := range redirectPattern.FindAllStringSubmatch(command, -1) {
+		if target := redirect[1]; target != "/dev/null" && !fdPattern.MatchString(target) {
+			return true
+		}
+	}
+	// Redirects are checked; what's left are the commands
+	command = redirectPattern.ReplaceAllString(command, " ")
+	for _, part := range commandSeparator.Split(command, -1) {
+		fields := strings.Fields(part)
+		for len(fields) > 0 && (strings.Contains(fields[0], "=") || fields[0] == "env" || fields[0] == "time" || fields[0] == "nice") {
+			fields = fields[1:] // Variable assignments and wrappers
+		}
+		if len(fields) > 0 && !reads(filepath.Base(fields[0]), fields[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// reads reports whether program, run with args, only looks
+func reads(program string, args []string) bool {
+	sub, rest := "", []string(nil)
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			sub, rest = arg, args[i+1:]
+			break
+		}
+	}
+	for _, arg := range args {
+		for _, flag := range append(writingFlags[program], writingFlags[program+" "+sub]...) {
+			if arg == flag || strings.HasPrefix(arg, flag+"=") || len(flag) == 2 && strings.HasPrefix(arg, flag) {
+				return false
+			}
+		}
+	}
+	switch {
+	case readingPrograms[program]:
+		return true
+	case listingSubcommands[program][sub]:
+		for _, arg := range rest {
+			if !listingFlags[arg] {
+				return false
+			}
+		}
+		return true
+	}
+	return readingSubcommands[program][sub]
+}
@@ -0,0 +1,67 @@
+package input
+
+import "testing"
+
+func TestWrites(t *testing.T) {
+	for command, want := range map[string]bool{
+		"ls -la":                            false,
+		"cat go.mod | grep module":          false,
+		"go vet ./... 2>&1":                 false,
+		"grep -r foo . > /dev/null":         false,
+		"git status && git diff HEAD~1":     false,
+		"GOFLAGS=-mod=mod go vet ./...":     false,
+		"find . -name '*.go'":               false,
+		"kubectl get pods -n prod":          false,
+		"git branch -a":                     false,
+		"git remote -v":                     false,
+		"go env GOPATH":                     false,
+		"sort -u names.txt | head":          false,
+		"echo hi > notes.txt":               true,
+		"sort a >> b":                       true,
+		"ls 2>errors.txt":                   true,
+		"ls &>out.txt":                      true,
+		"rm -rf build":                      true,
+		"ls && /bin/mv a b":                 true,
+		"sed -i s/a/b/ main.go":             true,
+		"find . -name '*.tmp' -delete":      true,
+		"git commit -m wip":                 true,
+		"git branch -D old":                 true,
+		"git remote add up https://x.org":   true,
+		"git remote remove origin":          true,
+		"git diff --output=patch.diff":      true,
+		"go install ./cmd/agentflow":        true,
+		"go env -w GOFLAGS=-mod=mod":        true,
+		"sort -o sorted.txt names.txt":      true,
+		"sudo systemctl restart nginx":      true,
+		"kubectl delete pod web-1":          true,
+		"cat config.yaml | tee copy.yaml":   true,
+		"bash -c 'rm -rf build'":            true,
+		"sh -c ls":                          true,
+		"ls | xargs rm":                     true,
+		"apt-get install nginx":             true,
+		"pip install x":                     true,
+		"make install":                      true,
+		"curl -o out https://example.com":   true,
+		"python3 -c 'os.remove(\"a\")'":     true,
+		"echo $(rm a)":                      true,
+		"echo `rm a`":                       true,
+		"ls & rm a":                         true,
+		"some-unknown-tool --dry-run":       true,
+		"rg --pre ./run.sh foo":             true,
+		"rg --pre-glob '*.gz' foo":          true,
+		"sort --compress-program=sh a":      true,
+		"go vet -vettool=/tmp/x ./...":      true,
+		"go vet -toolexec /tmp/x ./...":     true,
+		"go list -toolexec=/tmp/x ./...":    true,
+		"git -c core.pager=sh log":          true,
+		"git --config-env=core.pager=X log": true,
+		"git grep -O foo":                   true,
+		"git diff --output patch.diff":      true,
+		"rg foo --type go":                  false,
+		"go vet ./...":                      false,
+	} {
+		if got := Writes(command); got != want {
+			t.Errorf("Writes(%q) = %v, want %v", command, got, want)
+		}
+	}
+}
@@ -56,6 +56,10 @@ type Model struct {
 	completionIndex   int
 	savedInput        string // Input saved before entering search mode
 	multilineEnabled  bool
+	composing         bool // Enter and the arrows edit; Ctrl+S sends
+	vim               bool   // Modal editing; see SetVim
+	vimNormal         bool   // In vim's normal mode
+	vimPending        string // Operator waiting for its motion, as the first d of dd
 	width             int
 }
 
@@ -136,6 +140,9 @@ func (m Model) handleKey(msg tea.KeyMsg) (Model, tea.Cmd) {
 	case ModeAutocomplete:
 		return m.handleAutocompleteKey(msg)
 	default:
+		if m.vim {
+			return m.handleVimKey(msg)
+		}
 		return m.handleNormalKey(msg)
 	}
 }
@@ -144,6 +151,14 @@ func (m Model) handleKey(msg tea.KeyMsg) (Model, tea.Cmd) {
 func (m Model) handleNormalKey(msg tea.KeyMsg) (Model, tea.Cmd) {
 	key := msg.String()
 
+	// A draft being composed takes Enter as a new line and the arrows
+	// as moves within it
+	if m.composing && (key == "enter" || key == "up" || key == "down") {
+		var cmd tea.Cmd
+		m.textarea, cmd = m.textarea.Update(msg)
+		return m, cmd
+	}
+
 	switch key {
 	case "ctrl+r":
 		// Enter reverse search mode
@@ -155,6 +170,13 @@ func (m Model) handleNormalKey(msg tea.KeyMsg) (Model, tea.Cmd) {
 		return m, nil
 
 	case "enter":
+		// Backslash before the cursor continues on the next line
+		if strings.HasSuffix(m.beforeCursor(), "\\") {
+			m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+			m.textarea.InsertRune('\n')
+			return m, nil
+		}
+
 		// Submit on Enter (single line behavior)
 		value := strings.TrimSpace(m.textarea.Value())
 		if value != "" {
@@ -334,6 +356,17 @@ func (m Model) getCursorPosition() int {
 	return pos
 }
 
+// beforeCursor returns the text of the cursor's line before the cursor
+func (m Model) beforeCursor() string {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	if m.textarea.Line() >= len(lines) {
+		return ""
+	}
+	line := []rune(lines[m.textarea.Line()])
+	info := m.textarea.LineInfo()
+	return string(line[:min(info.StartColumn+info.ColumnOffset, len(line))])
+}
+
 // applyCompletion applies a completion to the input
 func (m *Model) applyCompletion(comp Completion) {
 	input := m.textarea.Value()
@@ -389,14 +422,16 @@ func (m Model) submit() (Model, tea.Cmd) {
 	m.history.Add(input)
 	m.history.Reset()
 
-	// Check if it's a bash command
-	isBash := strings.HasPrefix(input, "!")
+	// Check if it's a bash command, rather than a message starting with
+	// a !`command` substitution
+	isBash := strings.HasPrefix(input, "!") && !strings.HasPrefix(input, "!`")
 	if isBash {
 		input = strings.TrimPrefix(input, "!")
 	}
 
 	m.textarea.Reset()
 	m.cancelMode()
+	m.vimNormal = false
 
 	return m, func() tea.Msg {
 		return SubmitMsg{Value: input, IsBash: isBash}
@@ -483,6 +518,12 @@ func (m *Model) SetHeight(h int) {
 	m.textarea.SetHeight(h)
 }
 
+// SetComposing switches to editing a long draft: Enter adds a line, the
+// arrows move through the draft instead of history, and Ctrl+S sends
+func (m *Model) SetComposing(on bool) {
+	m.composing = on
+}
+
 // Focus focuses the input
 func (m *Model) Focus() {
 	m.textarea.Focus()
@@ -1,7 +1,10 @@
 package input
 
 import (
+	"context"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -93,6 +96,25 @@ func TestInput(t *testing.T) {
 		}
 	})
 
+	t.Run("MultilineInputAtCursor", func(t *testing.T) {
+		m := New("/test/workdir")
+		m.textarea.SetValue("line1\\ line2")
+		m.textarea.SetCursor(6)
+
+		// Enter right after a backslash in the middle of the line
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if value := m.Value(); value != "line1\n line2" {
+			t.Errorf("Expected 'line1\\n line2', got %q", value)
+		}
+
+		// A backslash at the end of the text but not before the cursor submits
+		m.textarea.SetValue("dir C:\\")
+		m.textarea.SetCursor(0)
+		if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd == nil {
+			t.Error("Expected Enter to submit")
+		}
+	})
+
 	t.Run("BashMode", func(t *testing.T) {
 		m := New("/test/workdir")
 		m.textarea.SetValue("!echo hello")
@@ -188,3 +210,113 @@ func TestBashExecution(t *testing.T) {
 		}
 	})
 }
+
+func TestSubstitutions(t *testing.T) {
+	prompt := "explain: !`echo boom; exit 3` and !`printf ok`"
+	commands := Substitutions(prompt)
+	if len(commands) != 2 || commands[0] != "echo boom; exit 3" || commands[1] != "printf ok" {
+		t.Fatalf("Substitutions = %q", commands)
+	}
+
+	expanded := ExpandSubstitutions(context.Background(), prompt)
+	for _, want := range []string{"explain: \n```\n$ echo boom; exit 3\nboom\n(exit code 3)\n```", "$ printf ok\nok\n```"} {
+		if !strings.Contains(expanded, want) {
+			t.Errorf("expanded = %q, missing %q", expanded, want)
+		}
+	}
+	if Substitutions("plain `code` and !bang") != nil {
+		t.Error("found a substitution in plain text")
+	}
+}
+
+func TestExpandMentions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	os.WriteFile(path, []byte("one\ntwo\nthree\nfour\n"), 0644)
+	big := filepath.Join(dir, "big.txt")
+	os.WriteFile(big, []byte(strings.Repeat("a long line of text\n", MaxMentionTokens)), 0644)
+
+	msg := "look at @" + path + ":2-3 and @" + dir + "/missing.go, mail a@b.c"
+	expanded, mentions := ExpandMentions(msg)
+	if len(mentions) != 1 || mentions[0].From != 2 || mentions[0].To != 3 || mentions[0].Lines != 2 {
+		t.Fatalf("mentions = %+v", mentions)
+	}
+	if !strings.HasPrefix(expanded, msg) || !strings.Contains(expanded, "(lines 2-3)\n```\ntwo\nthree\n```") {
+		t.Errorf("expanded = %q", expanded)
+	}
+
+	_, mentions = ExpandMentions("@" + big)
+	if len(mentions) != 1 || !mentions[0].Truncated || mentions[0].Tokens > MaxMentionTokens+10 {
+		t.Errorf("big file = %+v", mentions)
+	}
+	if !strings.HasSuffix(mentions[0].Chip(), "(cut)") {
+		t.Errorf("Chip = %q", mentions[0].Chip())
+	}
+
+	if out, mentions := ExpandMentions("nothing @here"); out != "nothing @here" || mentions != nil {
+		t.Errorf("no files: %q %+v", out, mentions)
+	}
+}
+
+func TestExpandMentions_Directory(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Demo"), 0644)
+	os.WriteFile(filepath.Join(dir, "huge.go"), []byte(strings.Repeat("// filler\n", MaxMentionTokens)), 0644)
+
+	expanded, mentions := ExpandMentions("what is in @" + dir + "/ ?")
+	if len(mentions) != 1 || !mentions[0].Dir || mentions[0].Files != 2 || mentions[0].Included != 1 {
+		t.Fatalf("mentions = %+v", mentions)
+	}
+	for _, want := range []string{"(directory summary)", "huge.go", "README.md\n```\n# Demo\n```", "call read_files"} {
+		if !strings.Contains(expanded, want) {
+			t.Errorf("expanded = %q, missing %q", expanded, want)
+		}
+	}
+	if !strings.HasPrefix(mentions[0].Chip(), "📁") {
+		t.Errorf("Chip = %q", mentions[0].Chip())
+	}
+}
+
+func TestURLs(t *testing.T) {
+	got := URLs("see https://go.dev/doc/effective_go. Also (https://en.wikipedia.org/wiki/Go_(language)), and https://go.dev/doc/effective_go again")
+	want := []string{"https://go.dev/doc/effective_go", "https://en.wikipedia.org/wiki/Go_(language)"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("URLs = %q, want %q", got, want)
+	}
+	if URLs("no links, just http:// talk") != nil {
+		t.Error("found a URL in plain text")
+	}
+}
+
+func TestVim(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := New(t.TempDir())
+	m.SetVim(true)
+	keys := func(s string) {
+		for _, r := range s {
+			m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	keys("first line")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if !m.VimNormal() {
+		t.Fatal("Esc didn't switch to normal mode")
+	}
+
+	// Letters are commands in normal mode
+	keys("0xoadded")
+	if got := m.Value(); got != "irst line\nadded" {
+		t.Errorf("after 0, x and o: %q", got)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	keys("dd")
+	if got := m.Value(); got != "added" {
+		t.Errorf("after dd: %q", got)
+	}
+	keys("A!")
+	if got := m.Value(); got != "added!" || !m.VimInsert() {
+		t.Errorf("after A: %q, insert %v", got, m.VimInsert())
+	}
+}
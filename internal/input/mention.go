@@ -0,0 +1,196 @@
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/repomap"
+)
+
+// MaxMentionTokens caps the tokens one @ mention adds to a message;
+// longer files are cut and say so, and directories are summarized
+const MaxMentionTokens = 8000
+
+// mention matches @path, @path:10 and @path:10-80 at the start of a word
+var mention = regexp.MustCompile(`(^|\s)@([^\s:@]+)(?::(\d+)(?:-(\d+))?)?`)
+
+// Mention is a file mentioned in a message
+type Mention struct {
+	Path      string // As written
+	From, To  int    // Line range, 1-based; 0 for the whole file
+	Lines     int    // Lines included
+	Tokens    int    // Estimated tokens included
+	Truncated bool   // Cut to MaxMentionTokens
+	Dir       bool   // A directory, sent as a summary
+	Files     int    // For a directory, the files in it...
+	Included  int    // ...and those whose contents were sent
+}
+
+// Chip is the compact form a mention is shown as
+func (m Mention) Chip() string {
+	if m.Dir {
+		return fmt.Sprintf("📁 %s · %d files · %d included · ~%d tokens", m.Path, m.Files, m.Included, m.Tokens)
+	}
+	name := m.Path
+	if m.From > 0 {
+		name += fmt.Sprintf(":%d-%d", m.From, m.To)
+	}
+	chip := fmt.Sprintf("📎 %s · %d lines · ~%d tokens", name, m.Lines, m.Tokens)
+	if m.Truncated {
+		chip += " (cut)"
+	}
+	return chip
+}
+
+// ExpandMentions reads the files a message mentions as @path or
+// @path:10-80 and appends their contents to it. A directory is sent as a
+// summary: its tree and the key files that fit, the model reading others
+// with the read_files tool. Mentions of missing or binary files are left
+// as they are.
+func ExpandMentions(message string) (string, []Mention) {
+	var mentions []Mention
+	var blocks strings.Builder
+	seen := make(map[string]bool)
+	for _, m := range mention.FindAllStringSubmatch(message, -1) {
+		if seen[m[0]] {
+			continue
+		}
+		seen[m[0]] = true
+
+		mn := Mention{Path: m[2]}
+		mn.From, _ = strconv.Atoi(m[3])
+		mn.To, _ = strconv.Atoi(m[4])
+		content, ok := readMention(&mn)
+		if !ok {
+			continue
+		}
+		mentions = append(mentions, mn)
+
+		fmt.Fprintf(&blocks, "\n\n## %s", mn.Path)
+		if mn.Dir {
+			fmt.Fprintf(&blocks, " (directory summary)\n%s", content)
+			continue
+		}
+		if mn.From > 0 {
+			fmt.Fprintf(&blocks, " (lines %d-%d)", mn.From, mn.To)
+		}
+		fmt.Fprintf(&blocks, "\n```\n%s\n```", content)
+	}
+	if len(mentions) == 0 {
+		return message, nil
+	}
+	return message + "\n\nMentioned files:" + blocks.String(), mentions
+}
+
+// readMention reads the lines a mention asks for, filling in its range
+// and size
+func readMention(m *Mention) (string, bool) {
+	info, err := os.Stat(m.Path)
+	if err != nil {
+		return "", false
+	}
+	if info.IsDir() {
+		return readDirMention(m)
+	}
+	data, err := os.ReadFile(filepath.Clean(m.Path))
+	if err != nil || bytes.IndexByte(data, 0) >= 0 {
+		return "", false
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if m.From > 0 {
+		if m.To < m.From {
+			m.To = m.From
+		}
+		m.From = min(m.From, len(lines))
+		m.To = min(m.To, len(lines))
+		lines = lines[m.From-1 : m.To]
+	}
+
+	content := strings.Join(lines, "\n")
+	if agent.EstimateTokens(content) > MaxMentionTokens {
+		content = content[:MaxMentionTokens*4]
+		content = content[:strings.LastIndex(content, "\n")+1] + fmt.Sprintf("... (cut at ~%d tokens)", MaxMentionTokens)
+		m.Truncated = true
+	}
+	m.Lines = strings.Count(content, "\n") + 1
+	m.Tokens = agent.EstimateTokens(content)
+	return content, true
+}
+
+// readDirMention summarizes a directory: its tree, then the contents of
+// its key files, most telling first, while they fit in MaxMentionTokens
+func readDirMention(m *Mention) (string, bool) {
+	dir, err := repomap.Build(m.Path, nil)
+	if err != nil || len(dir.Files) == 0 {
+		return "", false
+	}
+	m.Dir, m.From, m.To = true, 0, 0
+	m.Files = len(dir.Files)
+
+	// The tree gets at most half the budget
+	tree := dir.String()
+	if limit := MaxMentionTokens * 2; len(tree) > limit {
+		cut := tree[:strings.LastIndex(tree[:limit], "\n")]
+		tree = cut + fmt.Sprintf("\n... (%d more lines)", strings.Count(tree[len(cut):], "\n"))
+		m.Truncated = true
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "```\n%s\n```", tree)
+	budget := MaxMentionTokens - agent.EstimateTokens(sb.String())
+	for _, f := range dir.KeyFiles() {
+		if f.Size/4 > int64(budget) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(m.Path, filepath.FromSlash(f.Path)))
+		if err != nil || bytes.IndexByte(data, 0) >= 0 {
+			continue
+		}
+		content := strings.TrimRight(string(data), "\n")
+		tokens := agent.EstimateTokens(content)
+		if tokens > budget {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n\n### %s\n```\n%s\n```", filepath.ToSlash(filepath.Join(m.Path, f.Path)), content)
+		budget -= tokens
+		m.Included++
+	}
+	if m.Included < m.Files {
+		fmt.Fprintf(&sb, "\n\nOnly %d of the %d files are included; call read_files with the paths of others you need, under %s.", m.Included, m.Files, m.Path)
+	}
+
+	content := sb.String()
+	m.Tokens = agent.EstimateTokens(content)
+	m.Lines = strings.Count(content, "\n") + 1
+	return content, true
+}
+
+// url matches http and https URLs
+var url = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+`)
+
+// URLs returns the http and https URLs in a message, in order and
+// without repeats, leaving out punctuation that ends the sentence around
+// them
+func URLs(message string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, u := range url.FindAllString(message, -1) {
+		u = strings.TrimRight(u, ".,;:!?'\"]}")
+		// Keep a closing parenthesis only when the URL opened one
+		for strings.HasSuffix(u, ")") && strings.Count(u, "(") < strings.Count(u, ")") {
+			u = strings.TrimSuffix(u, ")")
+		}
+		if !seen[u] && len(u) > len("https://") {
+			seen[u] = true
+			out = append(out, u)
+		}
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package input
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// substitution matches a command embedded in a prompt as !`command`
+var substitution = regexp.MustCompile("!`([^`]+)`")
+
+// maxSubstitution caps the output one command puts in a prompt
+const maxSubstitution = 4000
+
+// Substitutions returns the commands embedded in a prompt as !`command`
+func Substitutions(prompt string) []string {
+	var commands []string
+	for _, m := range substitution.FindAllStringSubmatch(prompt, -1) {
+		commands = append(commands, m[1])
+	}
+	return commands
+}
+
+// ExpandSubstitutions runs the commands embedded in a prompt and puts
+// their output in their place, in a fenced block
+func ExpandSubstitutions(ctx context.Context, prompt string) string {
+	return substitution.ReplaceAllStringFunc(prompt, func(match string) string {
+		result := ExecuteBash(ctx, substitution.FindStringSubmatch(match)[1])
+		output := strings.TrimRight(result.Output+result.Error, "\n")
+		if len(output) > maxSubstitution {
+			output = output[len(output)-maxSubstitution:] + fmt.Sprintf("\n... (kept the last %d of %d bytes)", maxSubstitution, len(result.Output+result.Error))
+		}
+		block := fmt.Sprintf("\n```\n$ %s\n%s\n", result.Command, output)
+		if result.ExitCode != 0 {
+			block += fmt.Sprintf("(exit code %d)\n", result.ExitCode)
+		}
+		return block + "```\n"
+	})
+}
@@ -0,0 +1,120 @@
+package input
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SetVim turns on modal editing: Esc switches to normal mode, where
+// letters move and edit as in vim instead of typing, and i, a, o and the
+// like go back to insert mode. Input starts in insert mode.
+func (m *Model) SetVim(on bool) {
+	m.vim = on
+	m.vimNormal = false
+	m.vimPending = ""
+}
+
+// VimInsert reports whether vim mode is on and typing inserts text
+func (m Model) VimInsert() bool {
+	return m.vim && !m.vimNormal
+}
+
+// VimNormal reports whether vim mode is on and keys are commands. The
+// host handles those this doesn't, such as scrolling the transcript.
+func (m Model) VimNormal() bool {
+	return m.vim && m.vimNormal
+}
+
+// handleVimKey handles keys in vim mode outside search and completion:
+// Esc leaves insert mode, and normal mode runs commands
+func (m Model) handleVimKey(msg tea.KeyMsg) (Model, tea.Cmd) {
+	if !m.vimNormal {
+		if msg.Type == tea.KeyEsc {
+			m.vimNormal = true
+			m.vimPending = ""
+			return m, nil
+		}
+		return m.handleNormalKey(msg)
+	}
+
+	// Enter, the arrows, Tab and Ctrl keys work as in insert mode
+	if (msg.Type != tea.KeyRunes && msg.Type != tea.KeySpace) || msg.Paste {
+		m.vimPending = ""
+		return m.handleNormalKey(msg)
+	}
+
+	key := msg.String()
+	if m.vimPending != "" {
+		key, m.vimPending = m.vimPending+key, ""
+	}
+	switch key {
+	case "i":
+		m.vimNormal = false
+	case "a":
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyRight})
+		m.vimNormal = false
+	case "I":
+		m.textarea.CursorStart()
+		m.vimNormal = false
+	case "A":
+		m.textarea.CursorEnd()
+		m.vimNormal = false
+	case "o":
+		m.textarea.CursorEnd()
+		m.textarea.InsertString("\n")
+		m.vimNormal = false
+	case "O":
+		m.textarea.CursorStart()
+		m.textarea.InsertString("\n")
+		m.textarea.CursorUp()
+		m.vimNormal = false
+
+	case "h":
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	case "l":
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyRight})
+	case "w", "e":
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyRight, Alt: true})
+	case "b":
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyLeft, Alt: true})
+	case "0", "^":
+		m.textarea.CursorStart()
+	case "$":
+		m.textarea.CursorEnd()
+
+	case "x":
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyDelete})
+	case "D":
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+	case "C":
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+		m.vimNormal = false
+	case "dw", "cw":
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}, Alt: true})
+		m.vimNormal = key == "dw"
+	case "dd":
+		m.deleteLine()
+	case "cc", "S":
+		m.textarea.CursorStart()
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+		m.vimNormal = false
+	case "d", "c":
+		m.vimPending = key
+	}
+	// Other keys do nothing rather than type
+	return m, nil
+}
+
+// deleteLine removes the line under the cursor, leaving the cursor at
+// the start of the line that takes its place
+func (m *Model) deleteLine() {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	row := m.textarea.Line()
+	lines = append(lines[:row], lines[row+1:]...)
+	m.textarea.SetValue(strings.Join(lines, "\n"))
+	for m.textarea.Line() > min(row, len(lines)-1) {
+		m.textarea.CursorUp()
+	}
+	m.textarea.CursorStart()
+}
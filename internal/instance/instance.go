@@ -0,0 +1,219 @@
+// Package instance keeps track of the agentflow processes running on
+// this machine, so that two of them don't write the same session
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/session"
+)
+
+// Dir is where running instances register, under the home directory
+const Dir = ".agentflow/instances"
+
+// What to do when a session is already open in another instance
+const (
+	SharedFork     = "fork"      // Continue in a copy of the session (default)
+	SharedReadOnly = "read-only" // Load it but never save it
+	SharedWarn     = "warn"      // Say so and carry on; conflicting saves fail
+)
+
+// Instance is one running agentflow process
+type Instance struct {
+	PID      int       `json:"pid"`
+	Command  string    `json:"command"` // "tui", "repl", "pane", ...
+	Workdir  string    `json:"workdir"`
+	Session  string    `json:"session,omitempty"` // ID of the session it saves to
+	Model    string    `json:"model,omitempty"`
+	ReadOnly bool      `json:"read_only,omitempty"`
+	Started  time.Time `json:"started"`
+}
+
+// DefaultDir returns the directory instances register in
+func DefaultDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, Dir)
+}
+
+// Handle is this process's registration
+type Handle struct {
+	dir  string
+	mu   sync.Mutex
+	self Instance
+}
+
+// Register records this process as a running instance. Close removes
+// the record; records of processes that died are ignored and cleaned up
+// by List.
+func Register(dir string, inst Instance) (*Handle, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create instances dir: %w", err)
+	}
+	inst.PID = os.Getpid()
+	if inst.Started.IsZero() {
+		inst.Started = time.Now()
+	}
+	h := &Handle{dir: dir, self: inst}
+	return h, h.write()
+}
+
+func (h *Handle) path() string {
+	return filepath.Join(h.dir, strconv.Itoa(h.self.PID)+".json")
+}
+
+func (h *Handle) write() error {
+	data, err := json.Marshal(h.self)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(h.path(), data, 0644); err != nil {
+		return fmt.Errorf("register instance: %w", err)
+	}
+	return nil
+}
+
+// Update changes this instance's record, such as when it switches
+// session
+func (h *Handle) Update(fn func(*Instance)) error {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fn(&h.self)
+	return h.write()
+}
+
+// Close removes this instance's record
+func (h *Handle) Close() {
+	if h != nil {
+		os.Remove(h.path())
+	}
+}
+
+// List returns the running instances, oldest first
+func List(dir string) ([]Instance, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read instances dir: %w", err)
+	}
+
+	var out []Instance
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var inst Instance
+		if json.Unmarshal(data, &inst) != nil || !alive(inst.PID) {
+			os.Remove(path) // Left behind by a crash
+			continue
+		}
+		out = append(out, inst)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Started.Before(out[j].Started)
+	})
+	return out, nil
+}
+
+// Others returns the other running instances in workdir
+func Others(dir, workdir string) []Instance {
+	all, _ := List(dir)
+	var out []Instance
+	for _, inst := range all {
+		if inst.PID != os.Getpid() && inst.Workdir == workdir {
+			out = append(out, inst)
+		}
+	}
+	return out
+}
+
+// Holding returns the other instance saving to a session, if any
+func Holding(dir, sessionID string) (Instance, bool) {
+	all, _ := List(dir)
+	for _, inst := range all {
+		if inst.PID != os.Getpid() && inst.Session == sessionID && !inst.ReadOnly {
+			return inst, true
+		}
+	}
+	return Instance{}, false
+}
+
+// Claim decides how to open a session another instance may be saving
+// to, as shared says (SharedFork when empty). It returns the session to
+// use, a copy when forked, whether to keep it read-only, and a notice for
+// the user, empty when no other instance has the session open.
+func Claim(dir string, sess *session.Session, shared string) (*session.Session, bool, string) {
+	other, ok := Holding(dir, sess.ID)
+	if !ok {
+		return sess, false, ""
+	}
+	held := fmt.Sprintf("Session %s is open in another agentflow (%s, pid %d)", sess.ID, other.Command, other.PID)
+	switch shared {
+	case SharedReadOnly:
+		return sess, true, held + "; opened read-only, nothing here will be saved."
+	case SharedWarn:
+		return sess, false, held + "; saving over its changes will fail."
+	default:
+		fork := sess.Clone()
+		fork.Name = sess.Name
+		if fork.Name != "" {
+			fork.Name += " (branch)"
+		}
+		return fork, false, held + fmt.Sprintf("; continuing in a new branch, session %s. Use --shared read-only or warn to change this.", fork.ID)
+	}
+}
+
+// alive reports whether a process is running
+func alive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess only succeeds for running processes there
+		p.Release()
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// Describe formats an instance for /who
+func (i Instance) Describe() string {
+	parts := []string{fmt.Sprintf("pid %d", i.PID), i.Command}
+	if i.Model != "" {
+		parts = append(parts, i.Model)
+	}
+	if i.Session != "" {
+		s := "session " + i.Session
+		if i.ReadOnly {
+			s += " (read-only)"
+		}
+		parts = append(parts, s)
+	}
+	parts = append(parts, "since "+i.Started.Format("Jan 2 15:04"))
+	return strings.Join(parts, " • ")
+}
@@ -0,0 +1,92 @@
+package instance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentflow/agentflow/internal/session"
+)
+
+func TestRegister_ListAndClose(t *testing.T) {
+	dir := t.TempDir()
+	h, err := Register(dir, Instance{Command: "repl", Workdir: "/src/app", Session: "abc"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	all, err := List(dir)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(all) != 1 || all[0].PID != os.Getpid() || all[0].Session != "abc" {
+		t.Fatalf("List = %+v", all)
+	}
+
+	// This process doesn't count as another holder of its own session
+	if _, ok := Holding(dir, "abc"); ok {
+		t.Error("Holding found this process")
+	}
+	if others := Others(dir, "/src/app"); len(others) != 0 {
+		t.Errorf("Others = %+v", others)
+	}
+
+	h.Update(func(inst *Instance) { inst.Session = "def" })
+	if all, _ := List(dir); all[0].Session != "def" {
+		t.Errorf("after Update: %+v", all[0])
+	}
+
+	h.Close()
+	if all, _ := List(dir); len(all) != 0 {
+		t.Errorf("after Close: %+v", all)
+	}
+}
+
+func TestList_RemovesStaleRecords(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "999999999.json")
+	os.WriteFile(stale, []byte(`{"pid":999999999,"command":"tui"}`), 0644)
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0644)
+
+	all, err := List(dir)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(all) != 0 {
+		t.Errorf("List = %+v", all)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("stale record was kept")
+	}
+}
+
+func TestClaim(t *testing.T) {
+	dir := t.TempDir()
+	sess := session.New("/src/app", "ollama", "llama3")
+	sess.Name = "refactor"
+
+	got, readOnly, notice := Claim(dir, sess, "")
+	if got != sess || readOnly || notice != "" {
+		t.Errorf("unheld session: %v %v %q", got.ID, readOnly, notice)
+	}
+
+	// Pretend the parent process (alive, not us) holds it
+	other := Instance{PID: os.Getppid(), Command: "tui", Session: sess.ID}
+	h := &Handle{dir: dir, self: other}
+	if err := h.write(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, readOnly, notice = Claim(dir, sess, SharedFork)
+	if got.ID == sess.ID || got.Name != "refactor (branch)" || readOnly || notice == "" {
+		t.Errorf("fork: %s %q %v %q", got.ID, got.Name, readOnly, notice)
+	}
+	got, readOnly, _ = Claim(dir, sess, SharedReadOnly)
+	if got != sess || !readOnly {
+		t.Errorf("read-only: %s %v", got.ID, readOnly)
+	}
+	got, readOnly, notice = Claim(dir, sess, SharedWarn)
+	if got != sess || readOnly || notice == "" {
+		t.Errorf("warn: %s %v %q", got.ID, readOnly, notice)
+	}
+}
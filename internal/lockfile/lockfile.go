@@ -0,0 +1,52 @@
+// Package lockfile guards files shared by agentflow processes: an
+// advisory lock held while a file is read and rewritten, and writes that
+// readers never see half done
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Lock takes the advisory lock guarding the file at path, kept in a
+// .lock file next to it, and returns the function releasing it
+func Lock(path string) (func(), error) {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// WriteAtomic writes data to a temporary file in the same directory and
+// renames it over path, with perm
+func WriteAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
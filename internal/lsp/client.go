@@ -0,0 +1,482 @@
+// Package lsp is a minimal Language Server Protocol client. It starts the
+// language servers configured for a project and asks them for hover
+// information, definitions and diagnostics, which it exposes to the agent
+// as tools.
+//
+// Messages are JSON-RPC 2.0 framed by a Content-Length header, as the
+// protocol specifies; positions sent to servers are 0-based lines and
+// UTF-16 columns.
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// message is any JSON-RPC 2.0 request, notification, or response
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// Position is a 0-based line and UTF-16 character offset
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a span between two positions
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in a document
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// Diagnostic is an error or warning reported by a server
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity,omitempty"` // 1 error, 2 warning, 3 info, 4 hint
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+// SeverityName returns "error", "warning", "info" or "hint"
+func (d Diagnostic) SeverityName() string {
+	switch d.Severity {
+	case 2:
+		return "warning"
+	case 3:
+		return "info"
+	case 4:
+		return "hint"
+	default:
+		return "error"
+	}
+}
+
+// Client talks to one language server
+type Client struct {
+	w      io.Writer
+	closer func() error
+
+	writeMu sync.Mutex
+
+	mu          sync.Mutex
+	nextID      int
+	pending     map[string]chan message
+	open        map[string]openDoc       // URI -> open document
+	diagnostics map[string][]Diagnostic  // URI -> latest published
+	published   map[string]chan struct{} // URI -> closed on next publish
+	done        chan struct{}
+	err         error
+}
+
+// openDoc tracks a document sent to the server
+type openDoc struct {
+	version int
+	modTime time.Time
+}
+
+// NewClient creates a client reading server messages from r and writing
+// to w; closer, if set, runs on Close after the shutdown handshake
+func NewClient(r io.Reader, w io.Writer, closer func() error) *Client {
+	c := &Client{
+		w:           w,
+		closer:      closer,
+		pending:     make(map[string]chan message),
+		open:        make(map[string]openDoc),
+		diagnostics: make(map[string][]Diagnostic),
+		published:   make(map[string]chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go c.read(bufio.NewReader(r))
+	return c
+}
+
+// read dispatches server messages until the stream ends
+func (c *Client) read(r *bufio.Reader) {
+	defer close(c.done)
+	for {
+		body, err := readMessage(r)
+		if err != nil {
+			c.mu.Lock()
+			c.err = err
+			for id, ch := range c.pending {
+				close(ch)
+				delete(c.pending, id)
+			}
+			c.mu.Unlock()
+			return
+		}
+
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			continue
+		}
+		switch {
+		case msg.Method == "" && msg.ID != nil:
+			c.mu.Lock()
+			ch, ok := c.pending[string(msg.ID)]
+			delete(c.pending, string(msg.ID))
+			c.mu.Unlock()
+			if ok {
+				ch <- msg
+			}
+		case msg.Method == "textDocument/publishDiagnostics":
+			c.publish(msg.Params)
+		case msg.ID != nil:
+			// Reply off the read loop: the server may be blocked writing
+			// to us until we read its next message
+			go c.answer(msg)
+		}
+	}
+}
+
+// readMessage reads one Content-Length framed message
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(name, "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("bad Content-Length: %w", err)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length")
+	}
+	body := make([]byte, length)
+	_, err := io.ReadFull(r, body)
+	return body, err
+}
+
+// publish records diagnostics sent by the server
+func (c *Client) publish(params json.RawMessage) {
+	var p struct {
+		URI         string       `json:"uri"`
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	}
+	if json.Unmarshal(params, &p) != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diagnostics[p.URI] = p.Diagnostics
+	if ch, ok := c.published[p.URI]; ok {
+		close(ch)
+		delete(c.published, p.URI)
+	}
+}
+
+// answer replies to requests from the server. Servers ask for
+// configuration and permission to report progress; none is needed.
+func (c *Client) answer(msg message) {
+	result := json.RawMessage("null")
+	if msg.Method == "workspace/configuration" {
+		var p struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		json.Unmarshal(msg.Params, &p)
+		result = json.RawMessage("[" + strings.TrimSuffix(strings.Repeat("null,", len(p.Items)), ",") + "]")
+	}
+	c.write(message{JSONRPC: "2.0", ID: msg.ID, Result: result})
+}
+
+// write sends a framed message
+func (c *Client) write(msg message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+// Call sends a request and decodes its result into result, if non-nil
+func (c *Client) Call(ctx context.Context, method string, params, result any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return fmt.Errorf("language server stopped: %w", c.err)
+	}
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	ch := make(chan message, 1)
+	c.pending[string(id)] = ch
+	c.mu.Unlock()
+
+	if err := c.write(message{JSONRPC: "2.0", ID: id, Method: method, Params: raw}); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, string(id))
+		c.mu.Unlock()
+		return ctx.Err()
+	case resp, ok := <-ch:
+		if !ok {
+			return fmt.Errorf("language server stopped")
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil && len(resp.Result) > 0 {
+			return json.Unmarshal(resp.Result, result)
+		}
+		return nil
+	}
+}
+
+// Notify sends a notification
+func (c *Client) Notify(method string, params any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.write(message{JSONRPC: "2.0", Method: method, Params: raw})
+}
+
+// Initialize performs the initialize handshake for a workspace root
+func (c *Client) Initialize(ctx context.Context, root string) error {
+	params := map[string]any{
+		"processId": os.Getpid(),
+		"rootUri":   FileURI(root),
+		"workspaceFolders": []map[string]string{
+			{"uri": FileURI(root), "name": filepath.Base(root)},
+		},
+		"capabilities": map[string]any{
+			"textDocument": map[string]any{
+				"hover":              map[string]any{"contentFormat": []string{"markdown", "plaintext"}},
+				"definition":         map[string]any{"linkSupport": true},
+				"publishDiagnostics": map[string]any{},
+			},
+		},
+	}
+	if err := c.Call(ctx, "initialize", params, nil); err != nil {
+		return fmt.Errorf("initialize: %w", err)
+	}
+	return c.Notify("initialized", map[string]any{})
+}
+
+// Open sends a file's contents to the server, or its new contents when
+// it changed on disk since it was last sent
+func (c *Client) Open(path, languageID string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	uri := FileURI(path)
+
+	c.mu.Lock()
+	doc, isOpen := c.open[uri]
+	if isOpen && doc.modTime.Equal(info.ModTime()) {
+		c.mu.Unlock()
+		return nil
+	}
+	doc.version++
+	doc.modTime = info.ModTime()
+	c.open[uri] = doc
+	delete(c.diagnostics, uri) // Wait for diagnostics of the new contents
+	c.mu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !isOpen {
+		return c.Notify("textDocument/didOpen", map[string]any{
+			"textDocument": map[string]any{"uri": uri, "languageId": languageID, "version": doc.version, "text": string(data)},
+		})
+	}
+	return c.Notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": uri, "version": doc.version},
+		"contentChanges": []map[string]string{{"text": string(data)}},
+	})
+}
+
+// Hover returns the hover text at a position, or "" when there is none
+func (c *Client) Hover(ctx context.Context, path string, pos Position) (string, error) {
+	var result struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if err := c.Call(ctx, "textDocument/hover", positionParams(path, pos), &result); err != nil {
+		return "", err
+	}
+	return hoverText(result.Contents), nil
+}
+
+// hoverText flattens hover contents: MarkupContent, a MarkedString, or a
+// list of MarkedStrings
+func hoverText(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(raw, &list) == nil {
+		var parts []string
+		for _, item := range list {
+			if text := hoverText(item); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, "\n\n")
+	}
+	var obj struct {
+		Kind     string `json:"kind"`
+		Language string `json:"language"`
+		Value    string `json:"value"`
+	}
+	if json.Unmarshal(raw, &obj) == nil {
+		if obj.Language != "" {
+			return "```" + obj.Language + "\n" + obj.Value + "\n```"
+		}
+		return obj.Value
+	}
+	return ""
+}
+
+// Definition returns where the symbol at a position is defined
+func (c *Client) Definition(ctx context.Context, path string, pos Position) ([]Location, error) {
+	var raw json.RawMessage
+	if err := c.Call(ctx, "textDocument/definition", positionParams(path, pos), &raw); err != nil {
+		return nil, err
+	}
+	return parseLocations(raw), nil
+}
+
+// parseLocations accepts a Location, a list of Locations, or a list of
+// LocationLinks
+func parseLocations(raw json.RawMessage) []Location {
+	var one Location
+	if json.Unmarshal(raw, &one) == nil && one.URI != "" {
+		return []Location{one}
+	}
+	var items []struct {
+		Location
+		TargetURI            string `json:"targetUri"`
+		TargetSelectionRange Range  `json:"targetSelectionRange"`
+	}
+	if json.Unmarshal(raw, &items) != nil {
+		return nil
+	}
+	var locs []Location
+	for _, item := range items {
+		if item.TargetURI != "" {
+			locs = append(locs, Location{URI: item.TargetURI, Range: item.TargetSelectionRange})
+		} else if item.URI != "" {
+			locs = append(locs, item.Location)
+		}
+	}
+	return locs
+}
+
+// Diagnostics returns the diagnostics for a file, waiting up to wait for
+// the server to publish them if it hasn't since the file was last sent
+func (c *Client) Diagnostics(ctx context.Context, path string, wait time.Duration) []Diagnostic {
+	uri := FileURI(path)
+	c.mu.Lock()
+	diags, ok := c.diagnostics[uri]
+	if ok {
+		c.mu.Unlock()
+		return diags
+	}
+	ch, waiting := c.published[uri]
+	if !waiting {
+		ch = make(chan struct{})
+		c.published[uri] = ch
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-ch:
+	case <-time.After(wait):
+	case <-ctx.Done():
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.diagnostics[uri]
+}
+
+// Close shuts the server down
+func (c *Client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if c.Call(ctx, "shutdown", nil, nil) == nil {
+		c.Notify("exit", nil)
+	}
+	if c.closer != nil {
+		return c.closer()
+	}
+	return nil
+}
+
+// positionParams builds TextDocumentPositionParams
+func positionParams(path string, pos Position) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]string{"uri": FileURI(path)},
+		"position":     pos,
+	}
+}
+
+// FileURI converts an absolute path to a file:// URI
+func FileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// URIPath converts a file:// URI back to a path
+func URIPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
@@ -0,0 +1,172 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer answers initialize, hover and definition, and publishes a
+// diagnostic for every opened document. It asks the client for
+// configuration once, as real servers do.
+func fakeServer(t *testing.T, r io.Reader, w io.Writer, defURI string) {
+	br := bufio.NewReader(r)
+	send := func(msg map[string]any) {
+		body, _ := json.Marshal(msg)
+		fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	for {
+		body, err := readMessage(br)
+		if err != nil {
+			return
+		}
+		var msg message
+		json.Unmarshal(body, &msg)
+
+		switch msg.Method {
+		case "initialize":
+			send(map[string]any{"jsonrpc": "2.0", "id": 1000, "method": "workspace/configuration", "params": map[string]any{"items": []any{map[string]any{}}}})
+			send(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "result": map[string]any{"capabilities": map[string]any{}}})
+		case "textDocument/didOpen":
+			var p struct {
+				TextDocument struct{ URI string } `json:"textDocument"`
+			}
+			json.Unmarshal(msg.Params, &p)
+			send(map[string]any{"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": map[string]any{
+				"uri": p.TextDocument.URI,
+				"diagnostics": []any{map[string]any{
+					"range":    map[string]any{"start": map[string]int{"line": 1, "character": 6}, "end": map[string]int{"line": 1, "character": 7}},
+					"severity": 1, "source": "ts", "message": "Type 'string' is not assignable to type 'number'.",
+				}},
+			}})
+		case "textDocument/hover":
+			send(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "result": map[string]any{
+				"contents": map[string]string{"kind": "markdown", "value": "```ts\nfunction greet(name: string): string\n```"},
+			}})
+		case "textDocument/definition":
+			send(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "result": []any{map[string]any{
+				"targetUri":            defURI,
+				"targetRange":          map[string]any{"start": map[string]int{"line": 0, "character": 0}, "end": map[string]int{"line": 2, "character": 1}},
+				"targetSelectionRange": map[string]any{"start": map[string]int{"line": 0, "character": 9}, "end": map[string]int{"line": 0, "character": 14}},
+			}}})
+		case "shutdown":
+			send(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "result": nil})
+		case "":
+			if string(msg.ID) == "1000" && string(msg.Result) != "[null]" {
+				t.Errorf("configuration answer = %s", msg.Result)
+			}
+		}
+	}
+}
+
+// newTestManager returns a manager for a workspace with two TypeScript
+// files, connected to a fake server
+func newTestManager(t *testing.T) (*Manager, string) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "greet.ts"), []byte("function greet(name: string): string {\n  return `hi ${name}`\n}\n"), 0644)
+	os.WriteFile(filepath.Join(root, "main.ts"), []byte("import { greet } from './greet'\nconst n: number = greet('ü')\n"), 0644)
+
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	go fakeServer(t, serverR, serverW, FileURI(filepath.Join(root, "greet.ts")))
+
+	c := NewClient(clientR, clientW, func() error {
+		clientW.Close()
+		return serverW.Close()
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Initialize(ctx, root); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	m := NewManager(root, map[string]ServerConfig{"typescript": {Command: "unused", Extensions: []string{".ts"}}})
+	m.clients["typescript"] = c
+	t.Cleanup(m.Close)
+	return m, root
+}
+
+func TestFindPosition(t *testing.T) {
+	content := "package main\n\tx := ünïcode + greet()\n"
+	pos, err := FindPosition(content, 2, "greet")
+	if err != nil {
+		t.Fatalf("FindPosition: %v", err)
+	}
+	// Columns count UTF-16 units: the tab and 15 characters before greet
+	if pos.Line != 1 || pos.Character != 16 {
+		t.Errorf("position = %+v", pos)
+	}
+
+	if pos, _ := FindPosition(content, 2, ""); pos.Character != 1 {
+		t.Errorf("first non-blank = %+v", pos)
+	}
+	// The line isn't echoed: it may come from a file the model can't read
+	if _, err := FindPosition(content, 2, "missing"); err == nil || strings.Contains(err.Error(), "greet") {
+		t.Errorf("symbol not on the line: %v", err)
+	}
+	if _, err := FindPosition(content, 9, ""); err == nil {
+		t.Error("expected an error past the end of the file")
+	}
+}
+
+func TestTools(t *testing.T) {
+	m, root := newTestManager(t)
+	tools := Tools(m)
+	ctx := context.Background()
+
+	out, err := tools[0].Run(ctx, json.RawMessage(`{"file":"main.ts","line":2,"symbol":"greet"}`))
+	if err != nil || !strings.Contains(out, "function greet(name: string): string") {
+		t.Errorf("lsp_hover = %q, %v", out, err)
+	}
+
+	out, err = tools[1].Run(ctx, json.RawMessage(`{"file":"main.ts","line":2,"symbol":"greet"}`))
+	if err != nil || out != "greet.ts:1:10 function greet(name: string): string {" {
+		t.Errorf("lsp_definition = %q, %v", out, err)
+	}
+
+	out, err = tools[2].Run(ctx, json.RawMessage(`{"file":"main.ts"}`))
+	if err != nil || out != "main.ts:2:7 error: Type 'string' is not assignable to type 'number'. (ts)" {
+		t.Errorf("lsp_diagnostics = %q, %v", out, err)
+	}
+
+	if _, err := tools[0].Run(ctx, json.RawMessage(`{"file":"style.css","line":1,"symbol":"a"}`)); err == nil {
+		t.Error("expected an error for a file without a server")
+	}
+
+	// Files out of the project are refused, through symlinks too
+	secret := filepath.Join(t.TempDir(), "secret.ts")
+	os.WriteFile(secret, []byte("const token = 'hunter2'\n"), 0644)
+	os.Symlink(secret, filepath.Join(root, "link.ts"))
+	for _, file := range []string{secret, "../" + filepath.Base(filepath.Dir(secret)) + "/secret.ts", "link.ts"} {
+		for i, args := range []string{
+			`{"file":"` + file + `","line":1,"symbol":"x"}`,
+			`{"file":"` + file + `","line":1,"symbol":"x"}`,
+			`{"file":"` + file + `"}`,
+		} {
+			if out, err := tools[i].Run(ctx, json.RawMessage(args)); err == nil || strings.Contains(out+err.Error(), "hunter2") {
+				t.Errorf("%s %s = %q, %v", tools[i].Name(), file, out, err)
+			}
+		}
+	}
+}
+
+func TestHoverText(t *testing.T) {
+	cases := map[string]string{
+		`"plain"`:                          "plain",
+		`{"kind":"plaintext","value":"v"}`: "v",
+		`[{"language":"go","value":"func F()"},"doc"]`: "```go\nfunc F()\n```\n\ndoc",
+		`null`: "",
+	}
+	for raw, want := range cases {
+		if got := hoverText(json.RawMessage(raw)); got != want {
+			t.Errorf("hoverText(%s) = %q, want %q", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServerConfig describes a language server and the files it handles
+type ServerConfig struct {
+	Command    string   `yaml:"command"`
+	Args       []string `yaml:"args,omitempty"`
+	Extensions []string `yaml:"extensions"`            // e.g. [.ts, .tsx]
+	LanguageID string   `yaml:"language_id,omitempty"` // Defaults from the file extension
+}
+
+// languageIDs maps extensions to LSP language identifiers
+var languageIDs = map[string]string{
+	".c": "c", ".h": "c", ".cc": "cpp", ".cpp": "cpp", ".hpp": "cpp",
+	".cs": "csharp", ".go": "go", ".java": "java", ".kt": "kotlin",
+	".js": "javascript", ".jsx": "javascriptreact", ".ts": "typescript", ".tsx": "typescriptreact",
+	".lua": "lua", ".php": "php", ".py": "python", ".rb": "ruby", ".rs": "rust",
+	".scala": "scala", ".swift": "swift", ".zig": "zig",
+}
+
+// Manager starts language servers on demand and routes files to them
+type Manager struct {
+	root    string
+	servers map[string]ServerConfig
+
+	mu      sync.Mutex
+	clients map[string]*Client // By server name
+}
+
+// NewManager creates a manager for a workspace root. Servers start the
+// first time a file they handle is queried.
+func NewManager(root string, servers map[string]ServerConfig) *Manager {
+	return &Manager{
+		root:    root,
+		servers: servers,
+		clients: make(map[string]*Client),
+	}
+}
+
+// serverFor returns the name and config of the server handling a file
+func (m *Manager) serverFor(path string) (string, ServerConfig, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for name, cfg := range m.servers {
+		for _, e := range cfg.Extensions {
+			if strings.EqualFold(e, ext) || strings.EqualFold("."+e, ext) {
+				return name, cfg, true
+			}
+		}
+	}
+	return "", ServerConfig{}, false
+}
+
+// Client returns the running client for a file's server, starting it if
+// needed, and sends the file's current contents
+func (m *Manager) Client(ctx context.Context, path string) (*Client, error) {
+	name, cfg, ok := m.serverFor(path)
+	if !ok {
+		return nil, fmt.Errorf("no language server configured for %s files", filepath.Ext(path))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.clients[name]
+	if !ok {
+		var err error
+		if c, err = m.start(ctx, cfg); err != nil {
+			return nil, fmt.Errorf("start %s language server: %w", name, err)
+		}
+		m.clients[name] = c
+	}
+
+	languageID := cfg.LanguageID
+	if languageID == "" {
+		languageID = languageIDs[strings.ToLower(filepath.Ext(path))]
+	}
+	if err := c.Open(path, languageID); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// start launches a server process and initializes it
+func (m *Manager) start(ctx context.Context, cfg ServerConfig) (*Client, error) {
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Dir = m.root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	c := NewClient(stdout, stdin, func() error {
+		stdin.Close()
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(2 * time.Second):
+			return cmd.Process.Kill()
+		}
+	})
+
+	initCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if err := c.Initialize(initCtx, m.root); err != nil {
+		cmd.Process.Kill()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close shuts down every running server
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, c := range m.clients {
+		c.Close()
+		delete(m.clients, name)
+	}
+}
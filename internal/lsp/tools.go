@@ -0,0 +1,201 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/agentflow/agentflow/internal/tool"
+)
+
+// diagnosticsWait is how long to wait for a server to check a file
+const diagnosticsWait = 5 * time.Second
+
+// positionArgs locates a symbol by file, line and name, so the model
+// doesn't have to count columns
+type positionArgs struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`   // 1-based
+	Symbol string `json:"symbol"` // Name on the line; the first non-blank character when empty
+}
+
+// Tools returns the LSP tools backed by a manager
+func Tools(m *Manager) []tool.Tool {
+	position := tool.Object(map[string]any{
+		"file":   tool.String("File path, relative to the project root"),
+		"line":   map[string]any{"type": "integer", "description": "1-based line number"},
+		"symbol": tool.String("The identifier on that line to ask about"),
+	}, "file", "line", "symbol")
+
+	return []tool.Tool{
+		&tool.Func{
+			ToolName: "lsp_hover",
+			Desc:     "Ask the language server for the type, signature and documentation of a symbol at a file and line (any language with a configured server).",
+			Params:   position,
+			Activity: tool.KindSearch,
+			Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+				c, path, pos, err := m.resolve(ctx, args)
+				if err != nil {
+					return "", err
+				}
+				text, err := c.Hover(ctx, path, pos)
+				if err != nil {
+					return "", err
+				}
+				if text == "" {
+					return "No hover information", nil
+				}
+				return text, nil
+			},
+		},
+		&tool.Func{
+			ToolName: "lsp_definition",
+			Desc:     "Ask the language server where a symbol at a file and line is defined. Returns file:line:column and the source line.",
+			Params:   position,
+			Activity: tool.KindSearch,
+			Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+				c, path, pos, err := m.resolve(ctx, args)
+				if err != nil {
+					return "", err
+				}
+				locs, err := c.Definition(ctx, path, pos)
+				if err != nil {
+					return "", err
+				}
+				if len(locs) == 0 {
+					return "No definition found", nil
+				}
+				var lines []string
+				for _, loc := range locs {
+					lines = append(lines, m.describe(loc))
+				}
+				return strings.Join(lines, "\n"), nil
+			},
+		},
+		&tool.Func{
+			ToolName: "lsp_diagnostics",
+			Desc:     "Get the language server's errors and warnings for a file, as file:line:column severity: message.",
+			Params: tool.Object(map[string]any{
+				"file": tool.String("File path, relative to the project root"),
+			}, "file"),
+			Activity: tool.KindRun,
+			Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var in struct {
+					File string `json:"file"`
+				}
+				if err := json.Unmarshal(args, &in); err != nil {
+					return "", fmt.Errorf("invalid arguments: %w", err)
+				}
+				path, err := m.abs(in.File)
+				if err != nil {
+					return "", err
+				}
+				c, err := m.Client(ctx, path)
+				if err != nil {
+					return "", err
+				}
+				diags := c.Diagnostics(ctx, path, diagnosticsWait)
+				if len(diags) == 0 {
+					return "No diagnostics for " + in.File, nil
+				}
+				var lines []string
+				for _, d := range diags {
+					line := fmt.Sprintf("%s:%d:%d %s: %s", m.rel(path), d.Range.Start.Line+1, d.Range.Start.Character+1, d.SeverityName(), d.Message)
+					if d.Source != "" {
+						line += " (" + d.Source + ")"
+					}
+					lines = append(lines, line)
+				}
+				return strings.Join(lines, "\n"), nil
+			},
+		},
+	}
+}
+
+// resolve decodes position arguments, opens the file in its server and
+// finds the symbol's position
+func (m *Manager) resolve(ctx context.Context, args json.RawMessage) (*Client, string, Position, error) {
+	var in positionArgs
+	if err := json.Unmarshal(args, &in); err != nil {
+		return nil, "", Position{}, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if in.File == "" || in.Line < 1 {
+		return nil, "", Position{}, errors.New("file and a 1-based line are required")
+	}
+
+	path, err := m.abs(in.File)
+	if err != nil {
+		return nil, "", Position{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", Position{}, err
+	}
+	pos, err := FindPosition(string(data), in.Line, in.Symbol)
+	if err != nil {
+		return nil, "", Position{}, err
+	}
+
+	c, err := m.Client(ctx, path)
+	if err != nil {
+		return nil, "", Position{}, err
+	}
+	return c, path, pos, nil
+}
+
+// FindPosition returns the position of symbol on a 1-based line, or of
+// the line's first non-blank character when symbol is empty
+func FindPosition(content string, line int, symbol string) (Position, error) {
+	lines := strings.Split(content, "\n")
+	if line > len(lines) {
+		return Position{}, fmt.Errorf("line %d is past the end of the file (%d lines)", line, len(lines))
+	}
+	text := lines[line-1]
+
+	col := len(text) - len(strings.TrimLeft(text, " \t"))
+	if symbol != "" {
+		col = strings.Index(text, symbol)
+		if col < 0 {
+			return Position{}, fmt.Errorf("%q not found on line %d", symbol, line)
+		}
+	}
+	return Position{Line: line - 1, Character: len(utf16.Encode([]rune(text[:col])))}, nil
+}
+
+// abs resolves a path against the workspace root, refusing paths
+// outside it, as read_files does
+func (m *Manager) abs(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.root, path)
+	}
+	if _, err := tool.InProject(m.root, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// rel shortens a path relative to the workspace root
+func (m *Manager) rel(path string) string {
+	if rel, err := filepath.Rel(m.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// describe renders a location with its source line
+func (m *Manager) describe(loc Location) string {
+	path := URIPath(loc.URI)
+	out := fmt.Sprintf("%s:%d:%d", m.rel(path), loc.Range.Start.Line+1, loc.Range.Start.Character+1)
+	if data, err := os.ReadFile(path); err == nil {
+		if lines := strings.Split(string(data), "\n"); loc.Range.Start.Line < len(lines) {
+			out += " " + strings.TrimSpace(lines[loc.Range.Start.Line])
+		}
+	}
+	return out
+}
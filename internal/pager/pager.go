@@ -0,0 +1,25 @@
+// Package pager shows long text in the user's $PAGER
+package pager
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Command returns the command paging text through $PAGER, or less when
+// it is unset. less is asked to keep ANSI colors unless $LESS already
+// says how to behave. The caller connects its output to the terminal.
+func Command(text string) *exec.Cmd {
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less"
+	}
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Env = os.Environ()
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(cmd.Env, "LESS=R")
+	}
+	return cmd
+}
@@ -0,0 +1,288 @@
+// Package patch applies unified diffs proposed by a model to files on
+// disk. Models get line numbers wrong, so hunks are located by their
+// context and removed lines, searching outward from the stated line and
+// falling back to comparing lines without surrounding whitespace.
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FilePatch is the diff for one file
+type FilePatch struct {
+	OldPath string // "" for a new file
+	NewPath string // "" for a deleted file
+	Hunks   []Hunk
+}
+
+// Path returns the file the patch writes, or deletes
+func (p FilePatch) Path() string {
+	if p.NewPath != "" {
+		return p.NewPath
+	}
+	return p.OldPath
+}
+
+// Hunk is a contiguous change. Lines keep their ' ', '-' or '+' prefix.
+type Hunk struct {
+	OldStart int // 1-based, as stated in the header; a hint only
+	Lines    []string
+}
+
+// old returns the lines the hunk expects to find
+func (h Hunk) old() []string {
+	var lines []string
+	for _, l := range h.Lines {
+		if l[0] != '+' {
+			lines = append(lines, l[1:])
+		}
+	}
+	return lines
+}
+
+// new returns the lines the hunk leaves in place of old
+func (h Hunk) new() []string {
+	var lines []string
+	for _, l := range h.Lines {
+		if l[0] != '-' {
+			lines = append(lines, l[1:])
+		}
+	}
+	return lines
+}
+
+// ErrNoPatch is returned when text contains no diff
+var ErrNoPatch = errors.New("no unified diff found")
+
+var (
+	fenceRegex = regexp.MustCompile("(?s)```(?:diff|patch)\\s*\\n(.*?)```")
+	hunkRegex  = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+)
+
+// Parse extracts file patches from text: the contents of ```diff or
+// ```patch fences when present, else the whole text
+func Parse(text string) ([]FilePatch, error) {
+	if blocks := fenceRegex.FindAllStringSubmatch(text, -1); len(blocks) > 0 {
+		var parts []string
+		for _, b := range blocks {
+			parts = append(parts, b[1])
+		}
+		text = strings.Join(parts, "\n")
+	}
+
+	var patches []FilePatch
+	var cur *FilePatch
+	var hunk *Hunk
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			patches = append(patches, FilePatch{OldPath: diffPath(line[4:]), NewPath: diffPath(lines[i+1][4:])})
+			cur, hunk = &patches[len(patches)-1], nil
+			i++
+
+		case strings.HasPrefix(line, "@@"):
+			if cur == nil {
+				return nil, fmt.Errorf("hunk before a file header: %s", line)
+			}
+			start := 0
+			if m := hunkRegex.FindStringSubmatch(line); m != nil {
+				start, _ = strconv.Atoi(m[1])
+			}
+			cur.Hunks = append(cur.Hunks, Hunk{OldStart: start})
+			hunk = &cur.Hunks[len(cur.Hunks)-1]
+
+		case hunk != nil && line != "" && strings.ContainsRune(" -+", rune(line[0])):
+			hunk.Lines = append(hunk.Lines, line)
+
+		case hunk != nil && line == "":
+			// Editors and models drop the space on blank context lines
+			hunk.Lines = append(hunk.Lines, " ")
+
+		case strings.HasPrefix(line, `\ No newline`):
+		default:
+			hunk = nil
+		}
+	}
+
+	// Trailing blank "context" picked up after the last hunk line
+	for i := range patches {
+		for j := range patches[i].Hunks {
+			h := &patches[i].Hunks[j]
+			for len(h.Lines) > 0 && h.Lines[len(h.Lines)-1] == " " {
+				h.Lines = h.Lines[:len(h.Lines)-1]
+			}
+		}
+	}
+
+	if len(patches) == 0 {
+		return nil, ErrNoPatch
+	}
+	return patches, nil
+}
+
+// diffPath strips a/ b/ prefixes and timestamps from a header path;
+// /dev/null becomes ""
+func diffPath(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	if s == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		return s[2:]
+	}
+	return s
+}
+
+// Apply applies patches to files under root. Every hunk is checked
+// before anything is written, so a patch that doesn't apply leaves the
+// tree unchanged. It returns the paths written or deleted.
+func Apply(root string, patches []FilePatch) ([]string, error) {
+	results := make(map[string]*string) // nil: delete
+	var order []string
+	for _, p := range patches {
+		path := p.Path()
+		if path == "" {
+			return nil, errors.New("patch without a file name")
+		}
+		full, err := resolve(root, path)
+		if err != nil {
+			return nil, err
+		}
+
+		content, seen := results[full]
+		var text string
+		switch {
+		case seen && content != nil:
+			text = *content
+		case p.OldPath != "":
+			data, err := os.ReadFile(full)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			text = string(data)
+		}
+
+		if p.NewPath == "" {
+			results[full] = nil
+		} else {
+			out, err := applyHunks(text, p.Hunks)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			results[full] = &out
+		}
+		if !seen {
+			order = append(order, full)
+		}
+	}
+
+	var written []string
+	for _, full := range order {
+		rel, _ := filepath.Rel(root, full)
+		if results[full] == nil {
+			if err := os.Remove(full); err != nil {
+				return written, err
+			}
+		} else {
+			if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+				return written, err
+			}
+			mode := os.FileMode(0644)
+			if info, err := os.Stat(full); err == nil {
+				mode = info.Mode()
+			}
+			if err := os.WriteFile(full, []byte(*results[full]), mode); err != nil {
+				return written, err
+			}
+		}
+		written = append(written, rel)
+	}
+	return written, nil
+}
+
+// resolve joins a patch path to root, refusing paths that escape it
+func resolve(root, path string) (string, error) {
+	full := filepath.Join(root, filepath.FromSlash(path))
+	if rel, err := filepath.Rel(root, full); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside %s", path, root)
+	}
+	return full, nil
+}
+
+// applyHunks applies hunks in order to text
+func applyHunks(text string, hunks []Hunk) (string, error) {
+	trailingNewline := text == "" || strings.HasSuffix(text, "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if text == "" {
+		lines = nil
+	}
+
+	offset := 0 // Lines added minus removed by earlier hunks
+	for i, h := range hunks {
+		old := h.old()
+		at := find(lines, old, h.OldStart-1+offset)
+		if at < 0 {
+			return "", fmt.Errorf("hunk %d (@@ -%d) does not match the file", i+1, h.OldStart)
+		}
+		replaced := append(append(append([]string{}, lines[:at]...), h.new()...), lines[at+len(old):]...)
+		offset += len(replaced) - len(lines)
+		lines = replaced
+	}
+
+	out := strings.Join(lines, "\n")
+	if trailingNewline && len(lines) > 0 {
+		out += "\n"
+	}
+	return out, nil
+}
+
+// find returns where want occurs in lines, preferring the occurrence
+// nearest hint: exactly first, then ignoring surrounding whitespace
+func find(lines, want []string, hint int) int {
+	if len(want) == 0 {
+		// Pure insertion: trust the line number
+		return max(0, min(hint+1, len(lines)))
+	}
+	for _, equal := range []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		func(a, b string) bool { return strings.TrimSpace(a) == strings.TrimSpace(b) },
+	} {
+		best := -1
+		for i := 0; i+len(want) <= len(lines); i++ {
+			if matchAt(lines, want, i, equal) && (best < 0 || abs(i-hint) < abs(best-hint)) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			return best
+		}
+	}
+	return -1
+}
+
+func matchAt(lines, want []string, at int, equal func(a, b string) bool) bool {
+	for j, w := range want {
+		if !equal(lines[at+j], w) {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
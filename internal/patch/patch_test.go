@@ -0,0 +1,145 @@
+package patch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const source = `package calc
+
+// Add returns a + b
+func Add(a, b int) int {
+	return a - b
+}
+
+// Sub returns a - b
+func Sub(a, b int) int {
+	return a - b
+}
+`
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParse_Fenced(t *testing.T) {
+	reply := "The bug is the operator.\n\n```diff\n--- a/calc.go\n+++ b/calc.go\n@@ -4,3 +4,3 @@ func Add\n func Add(a, b int) int {\n-\treturn a - b\n+\treturn a + b\n }\n```\n"
+	patches, err := Parse(reply)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(patches) != 1 || patches[0].Path() != "calc.go" || len(patches[0].Hunks) != 1 {
+		t.Fatalf("patches = %+v", patches)
+	}
+	h := patches[0].Hunks[0]
+	if h.OldStart != 4 || len(h.Lines) != 4 {
+		t.Errorf("hunk = %+v", h)
+	}
+
+	if _, err := Parse("no diff here"); !errors.Is(err, ErrNoPatch) {
+		t.Errorf("err = %v, want ErrNoPatch", err)
+	}
+}
+
+func TestApply_WrongLineNumbers(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "calc.go", source)
+
+	// Line numbers are off and the model re-indented with spaces
+	patches, err := Parse("--- a/calc.go\n+++ b/calc.go\n@@ -40,3 +40,3 @@\n func Add(a, b int) int {\n-    return a - b\n+\treturn a + b\n }\n")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	written, err := Apply(dir, patches)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(written) != 1 || written[0] != "calc.go" {
+		t.Errorf("written = %v", written)
+	}
+
+	want := `package calc
+
+// Add returns a + b
+func Add(a, b int) int {
+	return a + b
+}
+
+// Sub returns a - b
+func Sub(a, b int) int {
+	return a - b
+}
+`
+	if got := readFile(t, dir, "calc.go"); got != want {
+		t.Errorf("calc.go =\n%s", got)
+	}
+}
+
+func TestApply_NearestMatch(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "calc.go", source)
+
+	// "return a - b" appears twice; the hunk's line picks Sub's
+	patches, _ := Parse("--- a/calc.go\n+++ b/calc.go\n@@ -10,1 +10,1 @@\n-\treturn a - b\n+\treturn b - a\n")
+	if _, err := Apply(dir, patches); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	got := readFile(t, dir, "calc.go")
+	if got != source[:len(source)-len("\treturn a - b\n}\n")]+"\treturn b - a\n}\n" {
+		t.Errorf("calc.go =\n%s", got)
+	}
+}
+
+func TestApply_NewAndDeletedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "old.txt", "bye\n")
+
+	patches, err := Parse("--- /dev/null\n+++ b/pkg/new.txt\n@@ -0,0 +1,2 @@\n+hello\n+world\n--- a/old.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-bye\n")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, err := Apply(dir, patches); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if got := readFile(t, dir, "pkg/new.txt"); got != "hello\nworld\n" {
+		t.Errorf("new.txt = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.txt")); !os.IsNotExist(err) {
+		t.Error("old.txt should be deleted")
+	}
+}
+
+func TestApply_AllOrNothing(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.txt", "one\n")
+	writeFile(t, dir, "b.txt", "two\n")
+
+	patches, _ := Parse("--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-one\n+ONE\n--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-three\n+THREE\n")
+	if _, err := Apply(dir, patches); err == nil {
+		t.Fatal("expected an error for a hunk that doesn't match")
+	}
+	if got := readFile(t, dir, "a.txt"); got != "one\n" {
+		t.Errorf("a.txt was written: %q", got)
+	}
+}
+
+func TestApply_OutsideRoot(t *testing.T) {
+	patches, _ := Parse("--- /dev/null\n+++ b/../escape.txt\n@@ -0,0 +1 @@\n+x\n")
+	if _, err := Apply(t.TempDir(), patches); err == nil {
+		t.Error("expected an error for a path outside the root")
+	}
+}
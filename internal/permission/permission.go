@@ -0,0 +1,264 @@
+// Package permission decides whether the model may run a tool that
+// changes things: calls a rule of the project allows run, others are put
+// to the user, whose "always" answers are kept as rules in
+// .agentflow/permissions.json
+package permission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// File is where a project's rules are kept, relative to its root
+const File = ".agentflow/permissions.json"
+
+// ErrDenied is returned for calls the user didn't allow
+var ErrDenied = errors.New("the user denied this call")
+
+// Choices the user has for a call
+const (
+	Once   = "once"   // Run this call
+	Always = "always" // Run it, and calls of the tool matching a pattern from now on
+	Deny   = "deny"   // Don't run it
+)
+
+// Answer is what the user decided about a call
+type Answer struct {
+	Choice  string
+	Pattern string         // For Always, the subjects the rule covers; "" for every call of the tool
+	Call    types.ToolCall // The call to run, with the arguments as the user edited them
+}
+
+// Rule allows the calls of a tool whose subject matches a pattern
+type Rule struct {
+	Tool    string `json:"tool"`
+	Pattern string `json:"pattern,omitempty"` // * matches anything, / included, but no further command; empty for every call
+}
+
+// Allows reports whether the rule covers a call
+func (r Rule) Allows(call types.ToolCall) bool {
+	if r.Tool != call.Name {
+		return false
+	}
+	if r.Pattern == "" {
+		return true
+	}
+	if subject, key := subjectOf(call); key == "command" {
+		return MatchCommand(r.Pattern, subject)
+	}
+	return Match(r.Pattern, Subject(call))
+}
+
+func (r Rule) String() string {
+	if r.Pattern == "" {
+		return r.Tool
+	}
+	return fmt.Sprintf("%s %s", r.Tool, r.Pattern)
+}
+
+// Asker puts a call to the user, with the detail to show them, and waits
+// for their answer
+type Asker func(ctx context.Context, call types.ToolCall, detail string) (Answer, error)
+
+// Store holds a project's rules
+type Store struct {
+	path  string
+	mu    sync.Mutex
+	rules []Rule
+}
+
+// Load reads the rules of the project at root; there are none until the
+// first "always" answer
+func Load(root string) (*Store, error) {
+	s := &Store{path: filepath.Join(root, File)}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Rules []Rule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	s.rules = file.Rules
+	return s, nil
+}
+
+// Rules returns the rules in the order they were added
+func (s *Store) Rules() []Rule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Rule(nil), s.rules...)
+}
+
+// Allows reports whether a rule allows a call
+func (s *Store) Allows(call types.ToolCall) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.rules {
+		if r.Allows(call) {
+			return true
+		}
+	}
+	return false
+}
+
+// Remember adds a rule and saves the rules
+func (s *Store) Remember(r Rule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, old := range s.rules {
+		if old == r {
+			return nil
+		}
+	}
+	s.rules = append(s.rules, r)
+	data, err := json.MarshalIndent(map[string][]Rule{"rules": s.rules}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// Gate returns what the agent asks before running a tool that changes
+// things: the call to run, as a rule allows it or the user answers, or
+// ErrDenied
+func (s *Store) Gate(ask Asker) func(ctx context.Context, call types.ToolCall) (types.ToolCall, error) {
+	return func(ctx context.Context, call types.ToolCall) (types.ToolCall, error) {
+		if s.Allows(call) {
+			return call, nil
+		}
+		answer, err := ask(ctx, call, Detail(call))
+		if err != nil {
+			return call, err
+		}
+		switch answer.Choice {
+		case Once:
+		case Always:
+			if err := s.Remember(Rule{Tool: call.Name, Pattern: answer.Pattern}); err != nil {
+				return call, fmt.Errorf("save permission: %w", err)
+			}
+		default:
+			return call, ErrDenied
+		}
+		if answer.Call.Name == "" {
+			return call, nil
+		}
+		return answer.Call, nil
+	}
+}
+
+// Subject returns what a call acts on, which rules' patterns match: its
+// command, files or the like, as the activity feed shows it, or its raw
+// arguments
+func Subject(call types.ToolCall) string {
+	subject, _ := subjectOf(call)
+	return subject
+}
+
+// subjectOf returns a call's subject and the argument it comes from, ""
+// for the raw arguments
+func subjectOf(call types.ToolCall) (string, string) {
+	if subject, key := tool.Subject(call); subject != "" {
+		return subject, key
+	}
+	return call.Arguments, ""
+}
+
+// Suggest returns the pattern offered for an "always" answer: the
+// command's program with any arguments, or anything in the file's
+// directory, or "" for every call
+func Suggest(call types.ToolCall) string {
+	subject, key := subjectOf(call)
+	switch key {
+	case "command":
+		program, _, _ := strings.Cut(subject, " ")
+		return program + " *"
+	case "path", "file":
+		if dir := path.Dir(filepath.ToSlash(subject)); dir != "." {
+			return dir + "/*"
+		}
+	}
+	return ""
+}
+
+// Detail renders a call in full for the user to decide on: the command
+// to run, the diff or the contents to write, then the other arguments
+func Detail(call types.ToolCall) string {
+	var args map[string]any
+	if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+		return call.Name + " " + call.Arguments
+	}
+	var sb strings.Builder
+	sb.WriteString(call.Name)
+	if command, ok := args["command"].(string); ok {
+		sb.WriteString("\n$ " + command)
+		delete(args, "command")
+	}
+	var long []string
+	keys := make([]string, 0, len(args))
+	for key, value := range args {
+		if s, ok := value.(string); ok && strings.Contains(s, "\n") {
+			long = append(long, key)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	sort.Strings(long)
+	for _, key := range keys {
+		value, _ := json.Marshal(args[key])
+		fmt.Fprintf(&sb, "\n%s: %s", key, value)
+	}
+	for _, key := range long {
+		fmt.Fprintf(&sb, "\n%s:\n%s", key, strings.TrimRight(args[key].(string), "\n"))
+	}
+	return sb.String()
+}
+
+// Match reports whether subject matches pattern, where * matches any
+// run of characters, / included
+func Match(pattern, subject string) bool {
+	return match(pattern, subject, ".*")
+}
+
+// commandRun is what * matches in a command: anything but the shell's
+// separators, pipes, redirections, substitutions and new lines
+const commandRun = `(?:[^;&|<>$\x60\n]|\$[^(])*`
+
+// MatchCommand is Match for commands, where * stops at ;, &&, |, $(,
+// backticks and the like, so that "git *" doesn't allow
+// "git status; rm -rf ~"
+func MatchCommand(pattern, subject string) bool {
+	return match(pattern, subject, commandRun)
+}
+
+// match reports whether subject matches pattern, with * matching what
+// the expression run does
+func match(pattern, subject, run string) bool {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	re, err := regexp.Compile("^" + strings.Join(parts, run) + "$")
+	return err == nil && re.MatchString(subject)
+}
@@ -0,0 +1,155 @@
+package permission
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+func TestGate(t *testing.T) {
+	root := t.TempDir()
+	s, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var asked []string
+	answers := []Answer{
+		{Choice: Always, Pattern: "go test *"},
+		{Choice: Deny},
+		{Choice: Once, Call: types.ToolCall{ID: "c3", Name: "run_command", Arguments: `{"command":"make lint"}`}},
+	}
+	gate := s.Gate(func(ctx context.Context, call types.ToolCall, detail string) (Answer, error) {
+		asked = append(asked, detail)
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	})
+	run := func(id, command string) (types.ToolCall, error) {
+		return gate(context.Background(), types.ToolCall{ID: id, Name: "run_command", Arguments: `{"command":"` + command + `"}`})
+	}
+
+	if _, err := run("c1", "go test ./..."); err != nil {
+		t.Fatalf("always: %v", err)
+	}
+	if _, err := run("c2", "rm -rf /"); !errors.Is(err, ErrDenied) {
+		t.Errorf("deny: %v", err)
+	}
+	if call, err := run("c3", "make"); err != nil || call.Arguments != `{"command":"make lint"}` {
+		t.Errorf("edited = %+v, %v", call, err)
+	}
+	// The rule answers without asking, and is kept for the project
+	if _, err := run("c4", "go test -run X ./agent"); err != nil || len(asked) != 3 {
+		t.Errorf("rule not applied: %v, asked %d times", err, len(asked))
+	}
+	if asked[0] != "run_command\n$ go test ./..." {
+		t.Errorf("detail = %q", asked[0])
+	}
+	reloaded, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules := reloaded.Rules(); len(rules) != 1 || rules[0] != (Rule{Tool: "run_command", Pattern: "go test *"}) {
+		t.Errorf("rules = %+v", rules)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		args string
+		want string
+	}{
+		{`{"command":"npm install left-pad"}`, "npm *"},
+		{`{"path":"internal/agent/agent.go","content":"x"}`, "internal/agent/*"},
+		{`{"path":"go.mod"}`, ""},
+		{`{"paths":["a/x.go","b/y.go"]}`, ""},
+		{`{"url":"https://example.com/a"}`, ""},
+		{`{"other":1}`, ""},
+	}
+	for _, tt := range tests {
+		if got := Suggest(types.ToolCall{Name: "t", Arguments: tt.args}); got != tt.want {
+			t.Errorf("Suggest(%s) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestDetail(t *testing.T) {
+	call := types.ToolCall{Name: "write_file", Arguments: `{"path":"a.go","content":"package a\n\nfunc A() {}\n","mode":420}`}
+	want := "write_file\nmode: 420\npath: \"a.go\"\ncontent:\npackage a\n\nfunc A() {}"
+	if got := Detail(call); got != want {
+		t.Errorf("Detail = %q, want %q", got, want)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, subject string
+		want             bool
+	}{
+		{"internal/*", "internal/agent/agent.go", true},
+		{"internal/*", "cmd/main.go", false},
+		{"go test *", "go test ./...", true},
+		{"go test *", "go vet ./...", false},
+		{"*.md", "docs/a.md", true},
+		{"a.go", "a.go", true},
+		{"a.go", "aago", false},
+	} {
+		if got := Match(tt.pattern, tt.subject); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v", tt.pattern, tt.subject, got)
+		}
+	}
+	if got := (Rule{Tool: "run_command", Pattern: "go test *"}).String() + ", " + (Rule{Tool: "save_artifact"}).String(); got != "run_command go test *, save_artifact" {
+		t.Errorf("rules = %s", got)
+	}
+}
+
+func TestRule_AllowsCommand(t *testing.T) {
+	rule := Rule{Tool: "run_command", Pattern: "git *"}
+	for _, tt := range []struct {
+		command string
+		want    bool
+	}{
+		{"git status", true},
+		{"git log --format=$HOME", true},
+		{"git status; rm -rf ~", false},
+		{"git status && rm -rf ~", false},
+		{"git status || rm -rf ~", false},
+		{"git log | sh", false},
+		{"git log $(rm -rf ~)", false},
+		{"git log `rm -rf ~`", false},
+		{"git log > go.mod", false},
+		{"git status\nrm -rf ~", false},
+	} {
+		call := types.ToolCall{Name: "run_command", Arguments: `{"command":"` + tt.command + `"}`}
+		if got := rule.Allows(call); got != tt.want {
+			t.Errorf("Allows(%q) = %v", tt.command, got)
+		}
+	}
+	// Paths aren't commands: * goes on matching anything
+	if !(Rule{Tool: "write_file", Pattern: "docs/*"}).Allows(types.ToolCall{Name: "write_file", Arguments: `{"path":"docs/a;b.md"}`}) {
+		t.Error("path with ; not allowed")
+	}
+}
+
+func TestSubject(t *testing.T) {
+	for _, tt := range []struct {
+		args string
+		want string
+	}{
+		{`{"command":"go test ./...","path":"x"}`, "go test ./..."},
+		{`{"paths":["a.go","b.go"]}`, "a.go, b.go"},
+		{`{"symbol":"agent.New"}`, "agent.New"},
+		{`{"other":1}`, `{"other":1}`},
+	} {
+		call := types.ToolCall{Name: "t", Arguments: tt.args}
+		if got := Subject(call); got != tt.want {
+			t.Errorf("Subject(%s) = %q, want %q", tt.args, got, tt.want)
+		}
+		// Rules match what the activity feed shows
+		if shown, _ := tool.Subject(call); shown != "" && shown != Subject(call) {
+			t.Errorf("Subject(%s) = %q, activity shows %q", tt.args, Subject(call), shown)
+		}
+	}
+}
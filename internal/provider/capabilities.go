@@ -0,0 +1,179 @@
+package provider
+
+import "strings"
+
+// ModelInfo records what a model can do and what it costs. Zero fields
+// are unknown.
+type ModelInfo struct {
+	ContextWindow int     `yaml:"context_window,omitempty"` // Tokens the model reads at most, prompt and answer
+	Tools         *bool   `yaml:"tools,omitempty"`          // Supports tool calling
+	Vision        *bool   `yaml:"vision,omitempty"`         // Reads images
+	InputPrice    float64 `yaml:"input_price,omitempty"`    // USD per million prompt tokens
+	OutputPrice   float64 `yaml:"output_price,omitempty"`   // USD per million answer tokens
+}
+
+// SupportsTools reports whether tools may be offered to the model; models
+// not known to refuse them are tried
+func (m ModelInfo) SupportsTools() bool {
+	return m.Tools == nil || *m.Tools
+}
+
+// SupportsVision reports whether the model is known to read images
+func (m ModelInfo) SupportsVision() bool {
+	return m.Vision != nil && *m.Vision
+}
+
+// Cost returns the price in USD of a request, or 0 when not known
+func (m ModelInfo) Cost(promptTokens, answerTokens int) float64 {
+	return (float64(promptTokens)*m.InputPrice + float64(answerTokens)*m.OutputPrice) / 1e6
+}
+
+// or fills the unknown fields of m from d
+func (m ModelInfo) or(d ModelInfo) ModelInfo {
+	if m.ContextWindow <= 0 {
+		m.ContextWindow = d.ContextWindow
+	}
+	if m.Tools == nil {
+		m.Tools = d.Tools
+	}
+	if m.Vision == nil {
+		m.Vision = d.Vision
+	}
+	if m.InputPrice <= 0 {
+		m.InputPrice = d.InputPrice
+	}
+	if m.OutputPrice <= 0 {
+		m.OutputPrice = d.OutputPrice
+	}
+	return m
+}
+
+var (
+	yes = func() *bool { b := true; return &b }()
+	no  = func() *bool { b := false; return &b }()
+)
+
+// builtinModels are the models agentflow knows, by name prefix without the
+// Ollama tag or the organization, lowercase. The longest matching prefix
+// wins, so families come with exceptions.
+var builtinModels = map[string]ModelInfo{
+	// Ollama
+	"llama3":          {ContextWindow: 8192, Tools: no, Vision: no},
+	"llama3.1":        {ContextWindow: 131072, Tools: yes, Vision: no},
+	"llama3.2":        {ContextWindow: 131072, Tools: yes, Vision: no},
+	"llama3.2-vision": {ContextWindow: 131072, Tools: no, Vision: yes},
+	"llama3.3":        {ContextWindow: 131072, Tools: yes, Vision: no},
+	"codellama":       {ContextWindow: 16384, Tools: no, Vision: no},
+	"qwen2.5":         {ContextWindow: 32768, Tools: yes, Vision: no},
+	"qwen2.5-coder":   {ContextWindow: 32768, Tools: yes, Vision: no},
+	"qwen3":           {ContextWindow: 40960, Tools: yes, Vision: no},
+	"mistral":         {ContextWindow: 32768, Tools: yes, Vision: no},
+	"mistral-nemo":    {ContextWindow: 131072, Tools: yes, Vision: no},
+	"mixtral":         {ContextWindow: 32768, Tools: yes, Vision: no},
+	"gemma2":          {ContextWindow: 8192, Tools: no, Vision: no},
+	"gemma3":          {ContextWindow: 131072, Tools: no, Vision: yes},
+	"deepseek-r1":     {ContextWindow: 131072, Tools: no, Vision: no},
+	"phi4":            {ContextWindow: 16384, Tools: no, Vision: no},
+	"llava":           {ContextWindow: 4096, Tools: no, Vision: yes},
+
+	// Groq
+	"llama-3.3-70b-versatile": {ContextWindow: 131072, Tools: yes, Vision: no, InputPrice: 0.59, OutputPrice: 0.79},
+	"llama-3.1-8b-instant":    {ContextWindow: 131072, Tools: yes, Vision: no, InputPrice: 0.05, OutputPrice: 0.08},
+	"mixtral-8x7b-32768":      {ContextWindow: 32768, Tools: yes, Vision: no, InputPrice: 0.24, OutputPrice: 0.24},
+	"gemma2-9b-it":            {ContextWindow: 8192, Tools: yes, Vision: no, InputPrice: 0.20, OutputPrice: 0.20},
+
+	// Together
+	"llama-3.3-70b-instruct-turbo":      {ContextWindow: 131072, Tools: yes, Vision: no, InputPrice: 0.88, OutputPrice: 0.88},
+	"meta-llama-3.1-8b-instruct-turbo":  {ContextWindow: 131072, Tools: yes, Vision: no, InputPrice: 0.18, OutputPrice: 0.18},
+	"meta-llama-3.1-70b-instruct-turbo": {ContextWindow: 131072, Tools: yes, Vision: no, InputPrice: 0.88, OutputPrice: 0.88},
+
+	// Mistral
+	"mistral-large":  {ContextWindow: 131072, Tools: yes, Vision: no, InputPrice: 2.00, OutputPrice: 6.00},
+	"mistral-medium": {ContextWindow: 131072, Tools: yes, Vision: yes, InputPrice: 0.40, OutputPrice: 2.00},
+	"mistral-small":  {ContextWindow: 131072, Tools: yes, Vision: yes, InputPrice: 0.10, OutputPrice: 0.30},
+	"codestral":      {ContextWindow: 262144, Tools: yes, Vision: no, InputPrice: 0.30, OutputPrice: 0.90},
+	"pixtral-large":  {ContextWindow: 131072, Tools: yes, Vision: yes, InputPrice: 2.00, OutputPrice: 6.00},
+
+	// DeepSeek
+	"deepseek-chat":     {ContextWindow: 65536, Tools: yes, Vision: no, InputPrice: 0.27, OutputPrice: 1.10},
+	"deepseek-reasoner": {ContextWindow: 65536, Tools: no, Vision: no, InputPrice: 0.55, OutputPrice: 2.19},
+
+	// xAI
+	"grok-4":         {ContextWindow: 256000, Tools: yes, Vision: yes, InputPrice: 3.00, OutputPrice: 15.00},
+	"grok-3":         {ContextWindow: 131072, Tools: yes, Vision: no, InputPrice: 3.00, OutputPrice: 15.00},
+	"grok-3-mini":    {ContextWindow: 131072, Tools: yes, Vision: no, InputPrice: 0.30, OutputPrice: 0.50},
+	"grok-code-fast": {ContextWindow: 256000, Tools: yes, Vision: no, InputPrice: 0.20, OutputPrice: 1.50},
+	"grok-2-vision":  {ContextWindow: 32768, Tools: yes, Vision: yes, InputPrice: 2.00, OutputPrice: 10.00},
+
+	// OpenAI
+	"gpt-4o":       {ContextWindow: 128000, Tools: yes, Vision: yes, InputPrice: 2.50, OutputPrice: 10.00},
+	"gpt-4o-mini":  {ContextWindow: 128000, Tools: yes, Vision: yes, InputPrice: 0.15, OutputPrice: 0.60},
+	"gpt-4.1":      {ContextWindow: 1047576, Tools: yes, Vision: yes, InputPrice: 2.00, OutputPrice: 8.00},
+	"gpt-4.1-mini": {ContextWindow: 1047576, Tools: yes, Vision: yes, InputPrice: 0.40, OutputPrice: 1.60},
+}
+
+// modelKey normalizes a model name for the tables: "meta-llama/Llama-3.3-70B"
+// becomes "llama-3.3-70b" and "llama3.3:70b" becomes "llama3.3"
+func modelKey(model string) string {
+	model = strings.ToLower(model)
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	model, _, _ = strings.Cut(model, ":")
+	return model
+}
+
+// lookup returns the entry of table with the longest key that prefixes
+// the model's
+func lookup(table map[string]ModelInfo, model string) (ModelInfo, bool) {
+	key := modelKey(model)
+	best := ""
+	for prefix := range table {
+		if len(prefix) > len(best) && strings.HasPrefix(key, modelKey(prefix)) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return ModelInfo{}, false
+	}
+	return table[best], true
+}
+
+// KnownModel returns what agentflow knows of a model out of the box
+func KnownModel(model string) (ModelInfo, bool) {
+	return lookup(builtinModels, model)
+}
+
+// InfoFor returns what is known of a model: the provider's model_info
+// for it, then its preset's models, then the built-in table
+func (c Config) InfoFor(model string) ModelInfo {
+	info, _ := lookup(c.ModelInfo, model)
+	preset, _ := lookup(c.presetModels, model)
+	builtin, _ := KnownModel(model)
+	return info.or(preset).or(builtin)
+}
+
+// Describer is implemented by providers that know their models'
+// capabilities, including those set in the config
+type Describer interface {
+	ModelInfo(model string) ModelInfo
+}
+
+// ModelInfo returns what is known of one of the provider's models
+func (o *OpenAICompatProvider) ModelInfo(model string) ModelInfo {
+	return o.config.InfoFor(model)
+}
+
+// ModelInfo returns what is known of one of the provider's models
+func (o *OllamaProvider) ModelInfo(model string) ModelInfo {
+	return o.config.InfoFor(model)
+}
+
+// InfoFor returns what is known of a model served by p
+func InfoFor(p Provider, model string) ModelInfo {
+	if d, ok := p.(Describer); ok {
+		return d.ModelInfo(model)
+	}
+	info, _ := KnownModel(model)
+	return info
+}
@@ -0,0 +1,66 @@
+package provider
+
+import "testing"
+
+func TestKnownModel(t *testing.T) {
+	tests := []struct {
+		model  string
+		window int
+		tools  bool
+		vision bool
+	}{
+		{"llama3.3:latest", 131072, true, false},
+		{"llama3.2-vision:11b", 131072, false, true},
+		{"llama3:8b", 8192, false, false},
+		{"meta-llama/Llama-3.3-70B-Instruct-Turbo", 131072, true, false},
+		{"gpt-4o-mini", 128000, true, true},
+		{"grok-code-fast-1", 256000, true, false},
+		{"grok-4-0709", 256000, true, true},
+	}
+	for _, tt := range tests {
+		info, ok := KnownModel(tt.model)
+		if !ok || info.ContextWindow != tt.window || info.SupportsTools() != tt.tools || info.SupportsVision() != tt.vision {
+			t.Errorf("KnownModel(%q) = %+v, %v", tt.model, info, ok)
+		}
+	}
+
+	if info, ok := KnownModel("my-finetune"); ok || !info.SupportsTools() || info.SupportsVision() {
+		t.Errorf("unknown model: %+v, %v", info, ok)
+	}
+}
+
+func TestConfig_InfoFor(t *testing.T) {
+	vision := true
+	cfg := Config{ModelInfo: map[string]ModelInfo{
+		"llama3.3":    {ContextWindow: 32768},
+		"my-finetune": {ContextWindow: 4096, Vision: &vision, InputPrice: 1},
+	}}
+
+	// Overrides replace the fields they set and keep the built-in ones
+	if info := cfg.InfoFor("llama3.3:70b"); info.ContextWindow != 32768 || !info.SupportsTools() {
+		t.Errorf("llama3.3 = %+v", info)
+	}
+	info := cfg.InfoFor("my-finetune:q4")
+	if info.ContextWindow != 4096 || !info.SupportsVision() || info.Cost(2_000_000, 0) != 2 {
+		t.Errorf("my-finetune = %+v", info)
+	}
+}
+
+func TestPresetModels(t *testing.T) {
+	cerebras := NewCerebras(Config{ModelInfo: map[string]ModelInfo{"qwen-3": {ContextWindow: 131072}}})
+	if info := InfoFor(cerebras, "llama-3.3-70b"); info.ContextWindow != 65536 || info.InputPrice != 0.85 {
+		t.Errorf("cerebras llama-3.3-70b = %+v", info)
+	}
+	if info := InfoFor(cerebras, "qwen-3-32b"); info.ContextWindow != 131072 || info.InputPrice != 0.40 {
+		t.Errorf("model_info should win over the preset: %+v", info)
+	}
+
+	// Elsewhere, the same name keeps the built-in answer
+	vllm := NewOpenAICompat("vllm", Config{})
+	if info := InfoFor(vllm, "llama-3.3-70b"); info.ContextWindow == 65536 || info.InputPrice != 0 {
+		t.Errorf("vllm llama-3.3-70b = %+v", info)
+	}
+	if !NewXAI(Config{}).SupportsModel("grok-4") {
+		t.Error("xai should list its models by default")
+	}
+}
@@ -0,0 +1,24 @@
+package provider
+
+// cerebrasModels are the models Cerebras serves. They are kept out of the
+// built-in table: their names are those of open models that other servers
+// run with other context windows and prices.
+var cerebrasModels = map[string]ModelInfo{
+	"llama3.1-8b":   {ContextWindow: 32768, Tools: yes, Vision: no, InputPrice: 0.10, OutputPrice: 0.10},
+	"llama-3.3-70b": {ContextWindow: 65536, Tools: yes, Vision: no, InputPrice: 0.85, OutputPrice: 1.20},
+	"qwen-3-32b":    {ContextWindow: 65536, Tools: yes, Vision: no, InputPrice: 0.40, OutputPrice: 0.80},
+	"gpt-oss-120b":  {ContextWindow: 65536, Tools: yes, Vision: no, InputPrice: 0.35, OutputPrice: 0.75},
+}
+
+// NewCerebras creates a new Cerebras provider
+// Cerebras uses the OpenAI-compatible API format
+func NewCerebras(cfg Config) *OpenAICompatProvider {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.cerebras.ai/v1"
+	}
+	if len(cfg.Models) == 0 {
+		cfg.Models = []string{"llama-3.3-70b", "llama3.1-8b", "qwen-3-32b", "gpt-oss-120b"}
+	}
+	cfg.presetModels = cerebrasModels
+	return NewOpenAICompat("cerebras", cfg)
+}
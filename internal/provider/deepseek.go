@@ -0,0 +1,14 @@
+package provider
+
+// NewDeepSeek creates a new DeepSeek provider
+// DeepSeek uses the OpenAI-compatible API format; deepseek-reasoner
+// streams its reasoning as reasoning_content and doesn't call tools
+func NewDeepSeek(cfg Config) *OpenAICompatProvider {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.deepseek.com/v1"
+	}
+	if len(cfg.Models) == 0 {
+		cfg.Models = []string{"deepseek-chat", "deepseek-reasoner"}
+	}
+	return NewOpenAICompat("deepseek", cfg)
+}
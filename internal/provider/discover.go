@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// discoveryTTL is how long a server's model list is cached
+const discoveryTTL = time.Hour
+
+// discoveryTimeout is how long building the registry waits for servers
+// to list their models
+var discoveryTimeout = 3 * time.Second
+
+// ModelCacheDir is where discovered model lists are cached
+var ModelCacheDir = modelCacheDir()
+
+func modelCacheDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".agentflow", "cache")
+}
+
+var (
+	discoveredMu sync.Mutex
+	discovered   = make(map[string][]string) // By provider and base URL, for the process
+)
+
+// discoverer is implemented by providers that can fill in an empty model
+// list from their server
+type discoverer interface {
+	discover(ctx context.Context)
+}
+
+// DiscoverModels fills in the models of providers configured without any,
+// asking their servers in parallel. Lists are cached on disk for an hour
+// and in memory for the process; a server that doesn't answer in a few
+// seconds keeps its last cached list, or none.
+func DiscoverModels(providers []Provider) {
+	ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, p := range providers {
+		if d, ok := p.(discoverer); ok && len(p.Models()) == 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.discover(ctx)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// cachedModels is a model list as saved on disk
+type cachedModels struct {
+	Fetched time.Time `json:"fetched"`
+	Models  []string  `json:"models"`
+}
+
+func (o *OpenAICompatProvider) discover(ctx context.Context) {
+	key := o.name + " " + o.baseURL
+	discoveredMu.Lock()
+	models, ok := discovered[key]
+	discoveredMu.Unlock()
+	if ok {
+		o.models = models
+		return
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	path := filepath.Join(ModelCacheDir, "models-"+o.name+"-"+hex.EncodeToString(sum[:6])+".json")
+	var cached cachedModels
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &cached)
+	}
+	models = cached.Models
+	if time.Since(cached.Fetched) >= discoveryTTL {
+		if fetched, err := o.ListModels(ctx); err == nil {
+			models = fetched
+			if data, err := json.Marshal(cachedModels{Fetched: time.Now(), Models: models}); err == nil {
+				if os.MkdirAll(ModelCacheDir, 0755) == nil {
+					os.WriteFile(path, data, 0644)
+				}
+			}
+		}
+	}
+
+	discoveredMu.Lock()
+	discovered[key] = models
+	discoveredMu.Unlock()
+	o.models = models
+}
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiscoverModels(t *testing.T) {
+	ModelCacheDir = t.TempDir()
+	asked := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		asked++
+		fmt.Fprint(w, `{"data":[{"id":"qwen2.5-coder-32b"},{"id":"llama-3.3-70b"}]}`)
+	}))
+	defer srv.Close()
+
+	empty := NewOpenAICompat("vllm", Config{BaseURL: srv.URL})
+	configured := NewOpenAICompat("other", Config{BaseURL: srv.URL, Models: []string{"mine"}})
+	DiscoverModels([]Provider{empty, configured})
+	if !slices.Equal(empty.Models(), []string{"llama-3.3-70b", "qwen2.5-coder-32b"}) || asked != 1 {
+		t.Errorf("models = %v after %d requests", empty.Models(), asked)
+	}
+	if !slices.Equal(configured.Models(), []string{"mine"}) {
+		t.Errorf("configured models = %v", configured.Models())
+	}
+
+	// The same server again, in this process and in a new one
+	again := NewOpenAICompat("vllm", Config{BaseURL: srv.URL})
+	DiscoverModels([]Provider{again})
+	discoveredMu.Lock()
+	clear(discovered)
+	discoveredMu.Unlock()
+	fresh := NewOpenAICompat("vllm", Config{BaseURL: srv.URL})
+	DiscoverModels([]Provider{fresh})
+	if len(again.Models()) != 2 || len(fresh.Models()) != 2 || asked != 1 {
+		t.Errorf("cached: %v, %v after %d requests", again.Models(), fresh.Models(), asked)
+	}
+}
+
+func TestDiscoverModels_Timeout(t *testing.T) {
+	ModelCacheDir = t.TempDir()
+	discoveryTimeout = 100 * time.Millisecond
+	defer func() { discoveryTimeout = 3 * time.Second }()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	p := NewOpenAICompat("slow", Config{BaseURL: srv.URL})
+	start := time.Now()
+	DiscoverModels([]Provider{p})
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond || len(p.Models()) != 0 {
+		t.Errorf("took %v, models %v", elapsed, p.Models())
+	}
+}
@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of provider errors, matched with errors.Is. Errors of these kinds
+// are *APIError values carrying the details.
+var (
+	ErrRateLimited    = errors.New("rate limited")
+	ErrContextTooLong = errors.New("context too long")
+	ErrAuth           = errors.New("authentication failed")
+	ErrModelNotFound  = errors.New("model not found")
+)
+
+// APIError is an error response from a provider
+type APIError struct {
+	Provider   string
+	Model      string
+	Status     int
+	Message    string        // The provider's own message, out of its JSON
+	Kind       error         // One of the Err* kinds; nil when unclassified
+	RetryAfter time.Duration // When a rate limit lifts, if the provider said
+}
+
+func (e *APIError) Error() string {
+	spec := e.Provider + "/" + e.Model
+	switch e.Kind {
+	case ErrRateLimited:
+		if e.RetryAfter > 0 {
+			return fmt.Sprintf("%s is rate limited, retry in %s: %s", spec, e.RetryAfter.Round(100*time.Millisecond), e.Message)
+		}
+		return fmt.Sprintf("%s is rate limited, retry later or use another model: %s", spec, e.Message)
+	case ErrAuth:
+		return fmt.Sprintf("%s rejected the API key (%d), check providers.%s.api_key: %s", e.Provider, e.Status, e.Provider, e.Message)
+	case ErrModelNotFound:
+		if e.Provider == "ollama" {
+			return fmt.Sprintf("model %s is not pulled, run `ollama pull %s`: %s", e.Model, e.Model, e.Message)
+		}
+		return fmt.Sprintf("%s has no model %s, see `agentflow providers`: %s", e.Provider, e.Model, e.Message)
+	case ErrContextTooLong:
+		return fmt.Sprintf("the conversation is too long for %s, /compact or /clear it, or set context.max_tokens: %s", spec, e.Message)
+	}
+	return fmt.Sprintf("%s error %d: %s", e.Provider, e.Status, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// RetryAfter returns how long to wait before retrying a rate limited
+// request, when the provider said
+func RetryAfter(err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Kind == ErrRateLimited && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// newAPIError classifies an error response from its status, headers and
+// body
+func newAPIError(provider, model string, resp *http.Response, body []byte) *APIError {
+	e := &APIError{
+		Provider: provider,
+		Model:    model,
+		Status:   resp.StatusCode,
+		Message:  errorMessage(body),
+	}
+	lower := strings.ToLower(e.Message + " " + string(body))
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		e.Kind = ErrRateLimited
+		e.RetryAfter = retryAfter(resp.Header, e.Message)
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		e.Kind = ErrAuth
+	case containsAny(lower, "context length", "context_length_exceeded", "context window", "maximum context",
+		"too many tokens", "prompt is too long", "reduce the length", "input is too long"):
+		e.Kind = ErrContextTooLong
+	case strings.Contains(lower, "model_not_found"),
+		strings.Contains(lower, "model") && containsAny(lower, "not found", "does not exist", "try pulling"):
+		e.Kind = ErrModelNotFound
+	}
+	return e
+}
+
+// errorMessage pulls the message out of an error body: OpenAI-compatible
+// servers send {"error": {"message": ...}}, Ollama {"error": "..."}
+func errorMessage(body []byte) string {
+	var parsed struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		var text string
+		if json.Unmarshal(parsed.Error, &text) == nil && text != "" {
+			return text
+		}
+		var detail struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(parsed.Error, &detail) == nil && detail.Message != "" {
+			return detail.Message
+		}
+		if parsed.Message != "" {
+			return parsed.Message
+		}
+	}
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > 500 {
+		msg = msg[:500] + "..."
+	}
+	return msg
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// tryAgainIn matches the wait in messages such as Groq's "Please try
+// again in 7.66s" or "in 1m2.5s"
+var tryAgainIn = regexp.MustCompile(`try again in ((?:\d+(?:\.\d+)?[hms]+)+)`)
+
+// retryAfter reads when a rate limit lifts from the Retry-After header
+// (seconds or a date), the reset headers some providers send, or the
+// message
+func retryAfter(header http.Header, message string) time.Duration {
+	if v := header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil {
+			return time.Duration(secs * float64(time.Second))
+		}
+		if at, err := http.ParseTime(v); err == nil {
+			return max(time.Until(at), 0)
+		}
+	}
+	// Not knowing which limit was hit, wait for the later reset
+	var reset time.Duration
+	for _, name := range []string{"X-Ratelimit-Reset-Requests", "X-Ratelimit-Reset-Tokens"} {
+		if d, err := time.ParseDuration(header.Get(name)); err == nil {
+			reset = max(reset, d)
+		}
+	}
+	if reset > 0 {
+		return reset
+	}
+	if m := tryAgainIn.FindStringSubmatch(message); m != nil {
+		if d, err := time.ParseDuration(m[1]); err == nil {
+			return d
+		}
+	}
+	return 0
+}
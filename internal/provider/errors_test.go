@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewAPIError_Kinds(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   error
+	}{
+		{"rate limit", 429, `{"error":{"message":"Rate limit reached"}}`, ErrRateLimited},
+		{"auth", 401, `{"error":{"message":"Invalid API Key"}}`, ErrAuth},
+		{"openai context", 400, `{"error":{"message":"This model's maximum context length is 8192 tokens","code":"context_length_exceeded"}}`, ErrContextTooLong},
+		{"ollama model", 404, `{"error":"model \"llama9\" not found, try pulling it first"}`, ErrModelNotFound},
+		{"openai model", 404, `{"error":{"message":"The model foo does not exist","code":"model_not_found"}}`, ErrModelNotFound},
+		{"wrong path", 404, `404 page not found`, nil},
+		{"server", 500, `{"error":"boom"}`, nil},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		err := newAPIError("groq", "m", resp, []byte(tt.body))
+		if err.Kind != tt.kind {
+			t.Errorf("%s: kind = %v, want %v", tt.name, err.Kind, tt.kind)
+		}
+		if tt.kind != nil && !errors.Is(err, tt.kind) {
+			t.Errorf("%s: errors.Is failed", tt.name)
+		}
+		if strings.Contains(err.Error(), "{") {
+			t.Errorf("%s: raw JSON in message: %s", tt.name, err)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header  http.Header
+		message string
+		want    time.Duration
+	}{
+		{http.Header{"Retry-After": {"3"}}, "", 3 * time.Second},
+		{http.Header{"X-Ratelimit-Reset-Requests": {"2s"}, "X-Ratelimit-Reset-Tokens": {"7.5s"}}, "", 7500 * time.Millisecond},
+		{http.Header{}, "Rate limit reached. Please try again in 1m2.5s. Visit ...", 62500 * time.Millisecond},
+		{http.Header{}, "slow down", 0},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: 429, Header: tt.header}
+		err := newAPIError("groq", "m", resp, []byte(`{"error":{"message":"`+tt.message+`"}}`))
+		got, ok := RetryAfter(err)
+		if got != tt.want || ok != (tt.want > 0) {
+			t.Errorf("RetryAfter(%v, %q) = %v, %v; want %v", tt.header, tt.message, got, ok, tt.want)
+		}
+	}
+}
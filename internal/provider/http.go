@@ -0,0 +1,296 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default timeouts, used for what a provider's config leaves unset
+const (
+	DefaultConnectTimeout = 30 * time.Second
+	DefaultReadTimeout    = 5 * time.Minute
+)
+
+// Default connection pool. Go keeps only 2 idle connections per host,
+// which makes parallel subagents reconnect to a local server on every
+// request.
+const (
+	DefaultMaxIdle     = 32
+	DefaultIdleTimeout = 90 * time.Second
+	DefaultKeepAlive   = 30 * time.Second
+)
+
+// Pool tunes how connections to a provider are reused
+type Pool struct {
+	MaxIdle     int           `yaml:"max_idle,omitempty"`     // Idle connections kept open per host
+	IdleTimeout time.Duration `yaml:"idle_timeout,omitempty"` // How long they stay open unused
+	KeepAlive   time.Duration `yaml:"keep_alive,omitempty"`   // TCP keep-alive interval; negative disables it
+
+	// HTTP/2 is negotiated over TLS by default. false sticks to HTTP/1.1;
+	// true also speaks it over plain http:// (h2c), for servers that do.
+	HTTP2 *bool `yaml:"http2,omitempty"`
+}
+
+// transportKey identifies the settings a transport is built from, so
+// providers configured alike share connections
+type transportKey struct {
+	connect                        time.Duration
+	proxy, caCert, clientCert, key string
+	insecure                       bool
+	maxIdle                        int
+	idleTimeout, keepAlive         time.Duration
+	http2                          string
+}
+
+var (
+	clientsMu sync.Mutex
+	clients   = make(map[transportKey]*http.Client)
+)
+
+// Timeouts bound a provider's requests. There is no limit on a whole
+// response: a model that keeps streaming may take as long as it needs.
+type Timeouts struct {
+	Connect time.Duration `yaml:"connect,omitempty"` // Dialing and the TLS handshake
+	Read    time.Duration `yaml:"read,omitempty"`    // Waiting for the response to start, or for its next chunk
+}
+
+// or returns t with unset fields taken from d
+func (t Timeouts) or(d Timeouts) Timeouts {
+	if t.Connect <= 0 {
+		t.Connect = d.Connect
+	}
+	if t.Read <= 0 {
+		t.Read = d.Read
+	}
+	return t
+}
+
+// TimeoutsFor returns the timeouts for a model: its own, then the
+// provider's, then the defaults
+func (c Config) TimeoutsFor(model string) Timeouts {
+	return c.ModelTimeouts[model].or(c.Timeouts).or(Timeouts{Connect: DefaultConnectTimeout, Read: DefaultReadTimeout})
+}
+
+// TimeoutError reports a model that went quiet for longer than its read
+// timeout
+type TimeoutError struct {
+	Model string
+	After time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("no response from %s for %s (raise timeouts.read for slow models)", e.Model, e.After)
+}
+
+// newHTTPClient creates the client for a provider. Read timeouts depend on
+// the model, so they are enforced per request by a watchdog rather than
+// by the client. A proxy or TLS setting that can't be used fails every
+// request with the reason, so one broken provider doesn't stop the others.
+// Clients are shared by every provider built from the same network
+// settings, as each registry build creates new providers.
+func newHTTPClient(cfg Config) *http.Client {
+	http2 := ""
+	if cfg.Pool.HTTP2 != nil {
+		http2 = fmt.Sprint(*cfg.Pool.HTTP2)
+	}
+	key := transportKey{
+		connect: cfg.Timeouts.Connect, proxy: cfg.HTTPProxy,
+		caCert: cfg.CACert, clientCert: cfg.ClientCert, key: cfg.ClientKey, insecure: cfg.InsecureSkipVerify,
+		maxIdle: cfg.Pool.MaxIdle, idleTimeout: cfg.Pool.IdleTimeout, keepAlive: cfg.Pool.KeepAlive, http2: http2,
+	}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if client, ok := clients[key]; ok {
+		return client
+	}
+	transport, err := NewTransport(cfg)
+	if err != nil {
+		return &http.Client{Transport: failingTransport{err}}
+	}
+	client := &http.Client{Transport: transport}
+	clients[key] = client
+	return client
+}
+
+// NewTransport builds the HTTP transport for a provider from its connect
+// timeout, connection pool, proxy and TLS settings. Without http_proxy,
+// the standard HTTPS_PROXY, HTTP_PROXY and NO_PROXY variables apply.
+func NewTransport(cfg Config) (*http.Transport, error) {
+	connect := cfg.Timeouts.or(Timeouts{Connect: DefaultConnectTimeout}).Connect
+	pool := cfg.Pool
+	if pool.MaxIdle <= 0 {
+		pool.MaxIdle = DefaultMaxIdle
+	}
+	if pool.IdleTimeout <= 0 {
+		pool.IdleTimeout = DefaultIdleTimeout
+	}
+	if pool.KeepAlive == 0 {
+		pool.KeepAlive = DefaultKeepAlive
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: connect, KeepAlive: pool.KeepAlive}).DialContext
+	transport.TLSHandshakeTimeout = connect
+	transport.MaxIdleConnsPerHost = pool.MaxIdle
+	transport.MaxIdleConns = max(transport.MaxIdleConns, pool.MaxIdle)
+	transport.IdleConnTimeout = pool.IdleTimeout
+
+	transport.Protocols = new(http.Protocols)
+	transport.Protocols.SetHTTP1(true)
+	if pool.HTTP2 == nil || *pool.HTTP2 {
+		transport.Protocols.SetHTTP2(true)
+	}
+	if pool.HTTP2 != nil && *pool.HTTP2 {
+		transport.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	if cfg.HTTPProxy != "" {
+		proxy, err := url.Parse(cfg.HTTPProxy)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid http_proxy %q", cfg.HTTPProxy)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	tlsConfig, err := tlsConfigFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// tlsConfigFor returns the TLS settings of a provider, or nil for the
+// defaults
+func tlsConfigFor(cfg Config) (*tls.Config, error) {
+	if cfg.CACert == "" && cfg.ClientCert == "" && cfg.ClientKey == "" && !cfg.InsecureSkipVerify {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+
+	if cfg.CACert != "" {
+		pem, err := os.ReadFile(expandHome(cfg.CACert))
+		if err != nil {
+			return nil, fmt.Errorf("read ca_cert: %w", err)
+		}
+		// Trust the system roots too, so a proxy's CA can be added alone
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca_cert %s has no PEM certificates", cfg.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		if cfg.ClientCert == "" || cfg.ClientKey == "" {
+			return nil, fmt.Errorf("client_cert and client_key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(expandHome(cfg.ClientCert), expandHome(cfg.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// expandHome expands a leading ~ in a file path
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[1:])
+	}
+	return path
+}
+
+// failingTransport fails every request with the error that prevented
+// building the real transport
+type failingTransport struct {
+	err error
+}
+
+func (t failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, t.err
+}
+
+// watchdog follows a request from start to finish: it cancels the request
+// when the response doesn't start, or stops arriving, within the read
+// timeout, and records it in the provider's metrics
+type watchdog struct {
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	timer   *time.Timer
+	timeout time.Duration
+	finish  func(status int, failed bool)
+	status  int
+	failed  bool
+}
+
+// startWatchdog returns a context for a request to a provider's model,
+// cancelled when it goes quiet for longer than timeout
+func startWatchdog(ctx context.Context, provider, model string, timeout time.Duration) *watchdog {
+	ctx, cancel := context.WithCancelCause(ctx)
+	w := &watchdog{ctx: ctx, cancel: cancel, timeout: timeout, finish: startRequest(provider)}
+	w.timer = time.AfterFunc(timeout, func() {
+		cancel(&TimeoutError{Model: provider + "/" + model, After: timeout})
+	})
+	return w
+}
+
+// Stop releases the watchdog once the response has been read
+func (w *watchdog) Stop() {
+	w.timer.Stop()
+	w.cancel(nil)
+	w.finish(w.status, w.failed || w.status == 0 || w.status >= 400)
+}
+
+// Respond records the response's status, and restarts the timeout whenever
+// data arrives
+func (w *watchdog) Respond(resp *http.Response) {
+	w.status = resp.StatusCode
+	w.timer.Reset(w.timeout)
+	resp.Body = &watchedBody{ReadCloser: resp.Body, w: w}
+}
+
+// Err marks the request failed, replacing the error with the timeout if
+// the watchdog cancelled it
+func (w *watchdog) Err(err error) error {
+	w.failed = true
+	var timeout *TimeoutError
+	if errors.As(context.Cause(w.ctx), &timeout) {
+		return timeout
+	}
+	return err
+}
+
+type watchedBody struct {
+	io.ReadCloser
+	w *watchdog
+}
+
+func (b *watchedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.w.timer.Reset(b.w.timeout)
+	}
+	return n, err
+}
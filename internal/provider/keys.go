@@ -0,0 +1,277 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/lockfile"
+)
+
+// Key reminders: keys older than RotateAfter are due for rotation, and
+// keys expiring within ExpiryWarning are about to stop working
+const (
+	RotateAfter   = 90 * 24 * time.Hour
+	ExpiryWarning = 14 * 24 * time.Hour
+)
+
+// rateLimitCooldown is how long a rate limited key is passed over when
+// the provider doesn't say when the limit lifts
+const rateLimitCooldown = time.Minute
+
+// Key is one of a provider's API keys. With several, requests use the
+// first usable one and move to the next when it is rate limited.
+type Key struct {
+	Label   string `yaml:"label"`
+	Key     string `yaml:"key"`
+	Added   string `yaml:"added,omitempty"`   // YYYY-MM-DD, for rotation reminders
+	Expires string `yaml:"expires,omitempty"` // YYYY-MM-DD; expired keys are skipped
+}
+
+// AddedAt returns when the key was added, or zero when unknown
+func (k Key) AddedAt() (time.Time, error) {
+	return parseDate(k.Added)
+}
+
+// ExpiresAt returns when the key expires, or zero when it doesn't
+func (k Key) ExpiresAt() (time.Time, error) {
+	return parseDate(k.Expires)
+}
+
+// Expired reports whether the key expired before now
+func (k Key) Expired(now time.Time) bool {
+	expires, err := k.ExpiresAt()
+	return err == nil && !expires.IsZero() && now.After(expires.Add(24*time.Hour))
+}
+
+// Reminder returns what needs doing about the key at now — it expired,
+// expires soon or is due for rotation — or ""
+func (k Key) Reminder(now time.Time) string {
+	expires, err := k.ExpiresAt()
+	if err != nil {
+		return fmt.Sprintf("invalid expires date %q (want YYYY-MM-DD)", k.Expires)
+	}
+	added, err := k.AddedAt()
+	if err != nil {
+		return fmt.Sprintf("invalid added date %q (want YYYY-MM-DD)", k.Added)
+	}
+	switch {
+	case k.Expired(now):
+		return "expired on " + k.Expires + ", replace it"
+	case !expires.IsZero() && expires.Sub(now) < ExpiryWarning:
+		return fmt.Sprintf("expires in %d day(s), replace it", int(expires.Sub(now).Hours()/24)+1)
+	case !added.IsZero() && now.Sub(added) > RotateAfter:
+		return fmt.Sprintf("%d days old, rotate it", int(now.Sub(added).Hours()/24))
+	}
+	return ""
+}
+
+func parseDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(time.DateOnly, date, time.Local)
+}
+
+// AllKeys returns a provider's keys: api_key, labelled "default", then keys
+func (c Config) AllKeys() []Key {
+	var keys []Key
+	if c.APIKey != "" {
+		keys = append(keys, Key{Label: "default", Key: c.APIKey})
+	}
+	for _, k := range c.Keys {
+		if k.Key != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// keyRing hands out a provider's keys, passing over rate limited and
+// expired ones
+type keyRing struct {
+	provider string
+	keys     []Key
+	record   bool // Save when keys are used, when there are labelled keys
+
+	mu      sync.Mutex
+	current int
+	limited []time.Time // Until when each key is rate limited
+}
+
+func newKeyRing(name string, cfg Config) *keyRing {
+	keys := cfg.AllKeys()
+	return &keyRing{provider: name, keys: keys, record: len(cfg.Keys) > 0, limited: make([]time.Time, len(keys))}
+}
+
+// key returns the key to use, "" when there is none
+func (r *keyRing) key() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.keys) == 0 {
+		return ""
+	}
+	return r.keys[r.current].Key
+}
+
+// rateLimited marks a key as rate limited for wait and moves to the next
+// usable key, returning it, or false when there is none
+func (r *keyRing) rateLimited(key string, wait time.Duration) (string, bool) {
+	if wait <= 0 {
+		wait = rateLimitCooldown
+	}
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, k := range r.keys {
+		if k.Key == key {
+			r.limited[i] = now.Add(wait)
+			if r.record {
+				recordKeyUse(r.provider, k.Label, true)
+			}
+		}
+	}
+	for step := 1; step < len(r.keys); step++ {
+		i := (r.current + step) % len(r.keys)
+		if now.After(r.limited[i]) && !r.keys[i].Expired(now) {
+			r.current = i
+			return r.keys[i].Key, true
+		}
+	}
+	return "", false
+}
+
+// used records that a key was used
+func (r *keyRing) used(key string) {
+	if !r.record {
+		return
+	}
+	for _, k := range r.keys {
+		if k.Key == key {
+			recordKeyUse(r.provider, k.Label, false)
+		}
+	}
+}
+
+// firstUsable moves the ring past expired keys, unless they all are
+func (r *keyRing) firstUsable() *keyRing {
+	now := time.Now()
+	for i, k := range r.keys {
+		if !k.Expired(now) {
+			r.current = i
+			break
+		}
+	}
+	return r
+}
+
+// do sends a request, again with the provider's next key each time the
+// current one is rate limited
+func (r *keyRing) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	for {
+		key := r.key()
+		resp, err := client.Do(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || req.GetBody == nil {
+			if err == nil {
+				r.used(key)
+			}
+			return resp, err
+		}
+		next, ok := r.rateLimited(key, retryAfter(resp.Header, ""))
+		if !ok {
+			return resp, nil
+		}
+		resp.Body.Close()
+		req = req.Clone(req.Context())
+		if req.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+next)
+	}
+}
+
+// KeyUse is when a labelled key was last used and rate limited
+type KeyUse struct {
+	LastUsed        time.Time `json:"last_used,omitzero"`
+	LastRateLimited time.Time `json:"last_rate_limited,omitzero"`
+}
+
+// KeyUsagePath is where key use is recorded, by "provider/label"
+var KeyUsagePath = keyUsagePath()
+
+func keyUsagePath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".agentflow", "key-usage.json")
+}
+
+// keyUseInterval limits how often the use of a key is saved
+const keyUseInterval = time.Minute
+
+var (
+	keyUsageMu sync.Mutex
+	keySaved   = make(map[string]time.Time) // When each key's use was last saved
+)
+
+// recordKeyUse saves that a key was used or rate limited, at most once a
+// minute per key for plain use, under the file's lock and renaming a new
+// file over it so a crash never leaves it half written
+func recordKeyUse(provider, label string, rateLimited bool) {
+	id := provider + "/" + label
+	now := time.Now()
+	keyUsageMu.Lock()
+	defer keyUsageMu.Unlock()
+	if !rateLimited && now.Sub(keySaved[id]) < keyUseInterval {
+		return
+	}
+	keySaved[id] = now
+
+	// Other agentflows record their keys in the same file
+	if os.MkdirAll(filepath.Dir(KeyUsagePath), 0700) != nil {
+		return
+	}
+	unlock, err := lockfile.Lock(KeyUsagePath)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	usage, _ := readKeyUsage()
+	if usage == nil {
+		usage = make(map[string]KeyUse)
+	}
+	use := usage[id]
+	use.LastUsed = now
+	if rateLimited {
+		use.LastRateLimited = now
+	}
+	usage[id] = use
+	data, _ := json.MarshalIndent(usage, "", "  ")
+	lockfile.WriteAtomic(KeyUsagePath, data, 0600)
+}
+
+// KeyUsage returns when labelled keys were last used, by "provider/label"
+func KeyUsage() (map[string]KeyUse, error) {
+	keyUsageMu.Lock()
+	defer keyUsageMu.Unlock()
+	return readKeyUsage()
+}
+
+func readKeyUsage() (map[string]KeyUse, error) {
+	data, err := os.ReadFile(KeyUsagePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var usage map[string]KeyUse
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return nil, fmt.Errorf("%s: %w", KeyUsagePath, err)
+	}
+	return usage, nil
+}
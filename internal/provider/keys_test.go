@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/lockfile"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+func TestKeys_RotateOnRateLimit(t *testing.T) {
+	KeyUsagePath = filepath.Join(t.TempDir(), "key-usage.json")
+	var used []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		used = append(used, key)
+		if key == "k1" {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"message":"slow down"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	p := NewOpenAICompat("groq", Config{BaseURL: srv.URL, Keys: []Key{
+		{Label: "old", Key: "k0", Expires: "2020-01-01"},
+		{Label: "personal", Key: "k1"},
+		{Label: "work", Key: "k2"},
+	}})
+	for range 2 {
+		resp, err := p.Complete(context.Background(), types.CompletionRequest{Model: "m"})
+		if err != nil || resp.Content != "ok" {
+			t.Fatalf("Complete = %+v, %v", resp, err)
+		}
+	}
+	// The expired key is skipped, and the rate limited one until it lifts
+	if got := strings.Join(used, " "); got != "k1 k2 k2" {
+		t.Errorf("keys used = %q", got)
+	}
+
+	usage, err := KeyUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage["groq/personal"].LastRateLimited.IsZero() || usage["groq/work"].LastUsed.IsZero() {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestRecordKeyUse_Lock(t *testing.T) {
+	dir := t.TempDir()
+	KeyUsagePath = filepath.Join(dir, "key-usage.json")
+
+	// Another agentflow holds the lock while it records its own key
+	unlock, err := lockfile.Lock(KeyUsagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		recordKeyUse("groq", "work", true)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("recordKeyUse didn't wait for the lock")
+	default:
+	}
+	if err := os.WriteFile(KeyUsagePath, []byte(`{"openai/personal":{"last_used":"2026-01-02T00:00:00Z"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	<-done
+
+	usage, err := KeyUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage["openai/personal"].LastUsed.IsZero() || usage["groq/work"].LastRateLimited.IsZero() {
+		t.Errorf("usage = %+v", usage)
+	}
+	info, err := os.Stat(KeyUsagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if e.Name() != "key-usage.json" && e.Name() != "key-usage.json.lock" {
+			t.Errorf("left %s behind", e.Name())
+		}
+	}
+}
+
+func TestKeys_AllRateLimited(t *testing.T) {
+	KeyUsagePath = filepath.Join(t.TempDir(), "key-usage.json")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	p := NewOpenAICompat("groq", Config{BaseURL: srv.URL, APIKey: "k0", Keys: []Key{{Label: "work", Key: "k1"}}})
+	_, err := p.Complete(context.Background(), types.CompletionRequest{Model: "m"})
+	if _, ok := RetryAfter(err); ok || !strings.Contains(err.Error(), "rate limited") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestKey_Reminder(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		key  Key
+		want string
+	}{
+		{Key{}, ""},
+		{Key{Added: "2026-05-01"}, ""},
+		{Key{Added: "2026-01-01"}, "151 days old, rotate it"},
+		{Key{Expires: "2026-06-05"}, "expires in 4 day(s), replace it"},
+		{Key{Expires: "2026-06-01"}, "expires in 1 day(s), replace it"},
+		{Key{Expires: "2026-05-30"}, "expired on 2026-05-30, replace it"},
+		{Key{Expires: "soon"}, `invalid expires date "soon" (want YYYY-MM-DD)`},
+	}
+	for _, tt := range tests {
+		if got := tt.key.Reminder(now); got != tt.want {
+			t.Errorf("Reminder(%+v) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyWeight is how much each request moves the average latency, so
+// it follows recent requests rather than the whole process lifetime
+const latencyWeight = 0.2
+
+// Metrics are live request counts for one provider
+type Metrics struct {
+	Provider    string        `json:"provider"`
+	InFlight    int           `json:"in_flight"` // Requests waiting for or streaming a response
+	Queued      int           `json:"queued"`    // Subagent tasks waiting for a free slot
+	Requests    int           `json:"requests"`  // Finished requests
+	Errors      int           `json:"errors"`
+	RateLimited int           `json:"rate_limited"` // Requests refused with 429
+	Latency     time.Duration `json:"latency"`      // Recent average time to a full response
+}
+
+var (
+	metricsMu sync.Mutex
+	metrics   = make(map[string]*Metrics)
+)
+
+// metricsFor returns the metrics of a provider, adding them if needed.
+// metricsMu must be held.
+func metricsFor(name string) *Metrics {
+	m, ok := metrics[name]
+	if !ok {
+		m = &Metrics{Provider: name}
+		metrics[name] = m
+	}
+	return m
+}
+
+// startRequest counts a request to a provider as in flight. The returned
+// function records how it ended: the HTTP status, 0 when there was no
+// response, and whether it failed.
+func startRequest(name string) func(status int, failed bool) {
+	start := time.Now()
+	metricsMu.Lock()
+	metricsFor(name).InFlight++
+	metricsMu.Unlock()
+
+	var once sync.Once
+	return func(status int, failed bool) {
+		once.Do(func() {
+			metricsMu.Lock()
+			defer metricsMu.Unlock()
+			m := metricsFor(name)
+			m.InFlight--
+			m.Requests++
+			if failed {
+				m.Errors++
+			}
+			if status == http.StatusTooManyRequests {
+				m.RateLimited++
+			}
+			if !failed {
+				elapsed := time.Since(start)
+				if m.Latency == 0 {
+					m.Latency = elapsed
+				} else {
+					m.Latency += time.Duration(latencyWeight * float64(elapsed-m.Latency))
+				}
+			}
+		})
+	}
+}
+
+// TrackQueued adds delta to the number of tasks waiting to use a provider
+func TrackQueued(name string, delta int) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	metricsFor(name).Queued += delta
+}
+
+// MetricsFor returns the current metrics of a provider
+func MetricsFor(name string) Metrics {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	if m, ok := metrics[name]; ok {
+		return *m
+	}
+	return Metrics{Provider: name}
+}
+
+// AllMetrics returns the metrics of every provider used so far, by name
+func AllMetrics() []Metrics {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	out := make([]Metrics, 0, len(metrics))
+	for _, m := range metrics {
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out
+}
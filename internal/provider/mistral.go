@@ -0,0 +1,19 @@
+package provider
+
+// NewMistral creates a new Mistral provider
+// Mistral uses the OpenAI-compatible API format, but rejects fields it
+// doesn't know and calls the seed random_seed
+func NewMistral(cfg Config) *OpenAICompatProvider {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.mistral.ai/v1"
+	}
+	if len(cfg.Models) == 0 {
+		cfg.Models = []string{"mistral-large-latest", "mistral-small-latest", "codestral-latest"}
+	}
+	p := NewOpenAICompat("mistral", cfg)
+	p.adapt = func(r *openAIRequest) {
+		r.Seed, r.RandomSeed = nil, r.Seed
+		r.StreamOptions = nil // Usage comes with the last chunk anyway
+	}
+	return p
+}
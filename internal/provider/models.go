@@ -0,0 +1,157 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// ModelLister is implemented by providers that can list the models their
+// server offers, which also tests the connection and the API key
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]string, error)
+}
+
+// CheckModel reports an error unless the provider has the model: it asks
+// the server when the provider can list its models, and SupportsModel
+// otherwise. An empty model (the provider picks) is always fine.
+func CheckModel(ctx context.Context, p Provider, model string) error {
+	if model == "" {
+		return nil
+	}
+	lister, ok := p.(ModelLister)
+	if !ok {
+		if !p.SupportsModel(model) {
+			return fmt.Errorf("%s does not support model %s", p.Name(), model)
+		}
+		return nil
+	}
+	models, err := lister.ListModels(ctx)
+	if err != nil {
+		return fmt.Errorf("check model %s: %w", model, err)
+	}
+	for _, m := range models {
+		if m == model || m == model+":latest" {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s has no model %s (it has %d; see agentflow providers --verbose)", p.Name(), model, len(models))
+}
+
+// ListModels returns the models the server offers, from GET /models
+func (o *OpenAICompatProvider) ListModels(ctx context.Context) ([]string, error) {
+	req, err := o.newRequest(ctx, "GET", "/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := getJSON(o.client, req, o.name, &list); err != nil {
+		return nil, err
+	}
+	models := make([]string, 0, len(list.Data))
+	for _, m := range list.Data {
+		models = append(models, m.ID)
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+// ListModels returns the models pulled on the Ollama server
+func (o *OllamaProvider) ListModels(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", o.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := getJSON(o.client, req, "ollama", &tags); err != nil {
+		return nil, err
+	}
+	models := make([]string, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		models = append(models, m.Name)
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+// getJSON sends a request and decodes its JSON response
+func getJSON(client *http.Client, req *http.Request, name string, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return newAPIError(name, "", resp, body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// PullProgress reports a model download
+type PullProgress struct {
+	Status    string `json:"status"` // "pulling manifest", "downloading", "success", ...
+	Total     int64  `json:"total"`
+	Completed int64  `json:"completed"`
+}
+
+// Pull downloads a model onto the Ollama server, reporting progress as
+// it goes
+func (o *OllamaProvider) Pull(ctx context.Context, model string, progress func(PullProgress)) error {
+	body, err := json.Marshal(map[string]any{"model": model, "stream": true})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/pull", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("ollama error %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var line struct {
+			PullProgress
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+		if line.Error != "" {
+			return fmt.Errorf("pull %s: %s", model, line.Error)
+		}
+		if progress != nil {
+			progress(line.PullProgress)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("pull %s: %w", model, err)
+	}
+	return nil
+}
@@ -3,11 +3,11 @@ package provider
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
 
 	"github.com/agentflow/agentflow/pkg/types"
 )
@@ -16,6 +16,7 @@ import (
 type OllamaProvider struct {
 	baseURL string
 	models  []string
+	config  Config
 	client  *http.Client
 }
 
@@ -28,9 +29,8 @@ func NewOllama(cfg Config) *OllamaProvider {
 	return &OllamaProvider{
 		baseURL: baseURL,
 		models:  cfg.Models,
-		client: &http.Client{
-			Timeout: 5 * time.Minute, // Long timeout for generation
-		},
+		config:  cfg,
+		client:  newHTTPClient(cfg),
 	}
 }
 
@@ -56,17 +56,90 @@ type ollamaRequest struct {
 	Model    string             `json:"model"`
 	Messages []ollamaMessage    `json:"messages"`
 	Stream   bool               `json:"stream"`
+	Think    bool               `json:"think,omitempty"`
 	Options  *ollamaOptions     `json:"options,omitempty"`
+	Tools    []openAITool       `json:"tools,omitempty"` // Same shape as OpenAI's
 }
 
 type ollamaMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"` // Base64-encoded
+
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"` // Tool that produced a "tool" message
+
+	Thinking string `json:"thinking,omitempty"` // Reasoning when think is set
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"` // An object, not a string
+	} `json:"function"`
+}
+
+// toOllamaMessages converts messages to Ollama format
+func toOllamaMessages(messages []types.Message) []ollamaMessage {
+	msgs := make([]ollamaMessage, len(messages))
+	for i, m := range messages {
+		msgs[i] = ollamaMessage{Role: m.Role, Content: m.Content}
+		if m.Role == "tool" {
+			msgs[i].ToolName = m.Name
+		}
+		for _, tc := range m.ToolCalls {
+			var call ollamaToolCall
+			call.Function.Name = tc.Name
+			call.Function.Arguments = json.RawMessage(tc.Arguments)
+			if !json.Valid(call.Function.Arguments) {
+				call.Function.Arguments = json.RawMessage("{}")
+			}
+			msgs[i].ToolCalls = append(msgs[i].ToolCalls, call)
+		}
+		for _, a := range m.Attachments {
+			if a.IsImage() {
+				msgs[i].Images = append(msgs[i].Images, base64.StdEncoding.EncodeToString(a.Data))
+			}
+		}
+	}
+	return msgs
+}
+
+// fromOllamaToolCalls converts tool calls from a response. Ollama does not
+// assign IDs, so calls are numbered.
+func fromOllamaToolCalls(calls []ollamaToolCall, offset int) []types.ToolCall {
+	var out []types.ToolCall
+	for i, c := range calls {
+		out = append(out, types.ToolCall{
+			ID:        fmt.Sprintf("call_%d", offset+i+1),
+			Name:      c.Function.Name,
+			Arguments: string(c.Function.Arguments),
+		})
+	}
+	return out
 }
 
 type ollamaOptions struct {
-	Temperature float64 `json:"temperature,omitempty"`
-	NumPredict  int     `json:"num_predict,omitempty"`
+	Temperature float64  `json:"temperature,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+	TopP        float64  `json:"top_p,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+// ollamaOptionsFor maps request parameters to Ollama options, or nil when
+// all are defaults
+func ollamaOptionsFor(req types.CompletionRequest) *ollamaOptions {
+	if req.Temperature == 0 && req.MaxTokens == 0 && req.TopP == 0 && req.Seed == nil && len(req.Stop) == 0 {
+		return nil
+	}
+	return &ollamaOptions{
+		Temperature: req.Temperature,
+		NumPredict:  req.MaxTokens,
+		TopP:        req.TopP,
+		Seed:        req.Seed,
+		Stop:        req.Stop,
+	}
 }
 
 // ollamaResponse is the Ollama API response format
@@ -80,23 +153,13 @@ type ollamaResponse struct {
 }
 
 func (o *OllamaProvider) Complete(ctx context.Context, req types.CompletionRequest) (*types.CompletionResponse, error) {
-	// Convert messages to Ollama format
-	msgs := make([]ollamaMessage, len(req.Messages))
-	for i, m := range req.Messages {
-		msgs[i] = ollamaMessage{Role: m.Role, Content: m.Content}
-	}
-
 	ollamaReq := ollamaRequest{
 		Model:    req.Model,
-		Messages: msgs,
+		Messages: toOllamaMessages(req.Messages),
 		Stream:   false,
-	}
-
-	if req.Temperature > 0 || req.MaxTokens > 0 {
-		ollamaReq.Options = &ollamaOptions{
-			Temperature: req.Temperature,
-			NumPredict:  req.MaxTokens,
-		}
+		Think:    req.Think,
+		Options:  ollamaOptionsFor(req),
+		Tools:    toOpenAITools(req.Tools),
 	}
 
 	body, err := json.Marshal(ollamaReq)
@@ -104,7 +167,10 @@ func (o *OllamaProvider) Complete(ctx context.Context, req types.CompletionReque
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/chat", bytes.NewReader(body))
+	watch := startWatchdog(ctx, "ollama", req.Model, o.config.TimeoutsFor(req.Model).Read)
+	defer watch.Stop()
+
+	httpReq, err := http.NewRequestWithContext(watch.ctx, "POST", o.baseURL+"/api/chat", bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -112,46 +178,44 @@ func (o *OllamaProvider) Complete(ctx context.Context, req types.CompletionReque
 
 	resp, err := o.client.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("send request: %w", err)
+		return nil, fmt.Errorf("send request: %w", watch.Err(err))
 	}
+	watch.Respond(resp)
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ollama error %d: %s", resp.StatusCode, string(respBody))
+		return nil, newAPIError("ollama", req.Model, resp, respBody)
 	}
 
 	var ollamaResp ollamaResponse
 	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+		return nil, fmt.Errorf("decode response: %w", watch.Err(err))
+	}
+
+	content, reasoning := splitThink(ollamaResp.Message.Content)
+	if ollamaResp.Message.Thinking != "" {
+		reasoning = ollamaResp.Message.Thinking
 	}
 
 	return &types.CompletionResponse{
-		Content:      ollamaResp.Message.Content,
+		Content:      content,
+		Reasoning:    reasoning,
 		Model:        ollamaResp.Model,
 		FinishReason: ollamaResp.DoneReason,
 		TokensUsed:   ollamaResp.PromptEvalCount + ollamaResp.EvalCount,
+		ToolCalls:    fromOllamaToolCalls(ollamaResp.Message.ToolCalls, 0),
 	}, nil
 }
 
 func (o *OllamaProvider) Stream(ctx context.Context, req types.CompletionRequest) (<-chan types.StreamChunk, error) {
-	// Convert messages to Ollama format
-	msgs := make([]ollamaMessage, len(req.Messages))
-	for i, m := range req.Messages {
-		msgs[i] = ollamaMessage{Role: m.Role, Content: m.Content}
-	}
-
 	ollamaReq := ollamaRequest{
 		Model:    req.Model,
-		Messages: msgs,
+		Messages: toOllamaMessages(req.Messages),
 		Stream:   true,
-	}
-
-	if req.Temperature > 0 || req.MaxTokens > 0 {
-		ollamaReq.Options = &ollamaOptions{
-			Temperature: req.Temperature,
-			NumPredict:  req.MaxTokens,
-		}
+		Think:    req.Think,
+		Options:  ollamaOptionsFor(req),
+		Tools:    toOpenAITools(req.Tools),
 	}
 
 	body, err := json.Marshal(ollamaReq)
@@ -159,40 +223,67 @@ func (o *OllamaProvider) Stream(ctx context.Context, req types.CompletionRequest
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/chat", bytes.NewReader(body))
+	watch := startWatchdog(ctx, "ollama", req.Model, o.config.TimeoutsFor(req.Model).Read)
+	httpReq, err := http.NewRequestWithContext(watch.ctx, "POST", o.baseURL+"/api/chat", bytes.NewReader(body))
 	if err != nil {
+		watch.Stop()
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	resp, err := o.client.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("send request: %w", err)
+		watch.Stop()
+		return nil, fmt.Errorf("send request: %w", watch.Err(err))
 	}
+	watch.Respond(resp)
 
 	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("ollama error: status %d", resp.StatusCode)
+		watch.Stop()
+		return nil, newAPIError("ollama", req.Model, resp, respBody)
 	}
 
 	chunks := make(chan types.StreamChunk)
 	go func() {
 		defer close(chunks)
+		defer watch.Stop()
 		defer resp.Body.Close()
 
+		// Tool calls arrive whole, usually in a chunk before the last
+		var think thinkParser
+		var toolCalls []types.ToolCall
 		decoder := json.NewDecoder(resp.Body)
 		for {
 			var chunk ollamaResponse
 			if err := decoder.Decode(&chunk); err != nil {
 				if err != io.EOF {
-					chunks <- types.StreamChunk{Error: err}
+					chunks <- types.StreamChunk{Error: watch.Err(err)}
 				}
 				return
 			}
-			chunks <- types.StreamChunk{
-				Content: chunk.Message.Content,
-				Done:    chunk.Done,
+			toolCalls = append(toolCalls, fromOllamaToolCalls(chunk.Message.ToolCalls, len(toolCalls))...)
+			content, reasoning := think.Feed(chunk.Message.Content)
+			if chunk.Done {
+				c, r := think.Flush()
+				content, reasoning = content+c, reasoning+r
+			}
+			out := types.StreamChunk{
+				Content:   content,
+				Reasoning: chunk.Message.Thinking + reasoning,
+				Done:      chunk.Done,
+			}
+			if chunk.Done {
+				out.FinishReason = chunk.DoneReason
+				out.ToolCalls = toolCalls
+				out.Usage = &types.Usage{
+					PromptTokens:     chunk.PromptEvalCount,
+					CompletionTokens: chunk.EvalCount,
+					TotalTokens:      chunk.PromptEvalCount + chunk.EvalCount,
+				}
 			}
+			chunks <- out
 			if chunk.Done {
 				return
 			}
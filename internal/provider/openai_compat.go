@@ -1,15 +1,14 @@
 package provider
 
 import (
-	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/agentflow/agentflow/pkg/types"
 )
@@ -18,9 +17,11 @@ import (
 type OpenAICompatProvider struct {
 	name    string
 	baseURL string
-	apiKey  string
+	keys    *keyRing
 	models  []string
+	config  Config
 	client  *http.Client
+	adapt   func(*openAIRequest) // Request quirks of a preset, nil for none
 }
 
 // NewOpenAICompat creates a generic OpenAI-compatible provider
@@ -28,11 +29,10 @@ func NewOpenAICompat(name string, cfg Config) *OpenAICompatProvider {
 	return &OpenAICompatProvider{
 		name:    name,
 		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
-		apiKey:  cfg.APIKey,
+		keys:    newKeyRing(name, cfg).firstUsable(),
 		models:  cfg.Models,
-		client: &http.Client{
-			Timeout: 5 * time.Minute,
-		},
+		config:  cfg,
+		client:  newHTTPClient(cfg),
 	}
 }
 
@@ -59,12 +59,140 @@ type openAIRequest struct {
 	Messages    []openAIMessage `json:"messages"`
 	Temperature float64         `json:"temperature,omitempty"`
 	MaxTokens   int             `json:"max_tokens,omitempty"`
+	TopP        float64         `json:"top_p,omitempty"`
+	Seed        *int            `json:"seed,omitempty"`
+	RandomSeed  *int            `json:"random_seed,omitempty"` // Mistral's name for seed
+	Stop        []string        `json:"stop,omitempty"`
 	Stream      bool            `json:"stream,omitempty"`
+	Tools       []openAITool    `json:"tools,omitempty"`
+
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+	Provider      map[string]any       `json:"provider,omitempty"` // OpenRouter routing preferences
+}
+
+type openAITool struct {
+	Type     string               `json:"type"` // always "function"
+	Function types.ToolDefinition `json:"function"`
+}
+
+// toOpenAITools wraps tool definitions as OpenAI function tools
+func toOpenAITools(defs []types.ToolDefinition) []openAITool {
+	var tools []openAITool
+	for _, d := range defs {
+		tools = append(tools, openAITool{Type: "function", Function: d})
+	}
+	return tools
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type openAIMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	Name       string           `json:"name,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	Images     []string         `json:"-"` // Data URLs, sent as content parts
+
+	// Reasoning output: DeepSeek and vLLM use reasoning_content, others reasoning
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	Reasoning        string `json:"reasoning,omitempty"`
+}
+
+type openAIToolCall struct {
+	Index    int    `json:"index,omitempty"` // Position of a streamed call
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// fromOpenAIToolCalls converts tool calls from a response
+func fromOpenAIToolCalls(calls []openAIToolCall) []types.ToolCall {
+	var out []types.ToolCall
+	for _, c := range calls {
+		out = append(out, types.ToolCall{ID: c.ID, Name: c.Function.Name, Arguments: c.Function.Arguments})
+	}
+	return out
+}
+
+// toolCallBuilder assembles tool calls streamed as fragments: the first
+// delta for an index carries the ID and name, later ones append arguments
+type toolCallBuilder struct {
+	calls []openAIToolCall
+}
+
+func (b *toolCallBuilder) Feed(deltas []openAIToolCall) {
+	for _, d := range deltas {
+		for len(b.calls) <= d.Index {
+			b.calls = append(b.calls, openAIToolCall{})
+		}
+		call := &b.calls[d.Index]
+		if d.ID != "" {
+			call.ID = d.ID
+		}
+		if d.Function.Name != "" {
+			call.Function.Name = d.Function.Name
+		}
+		call.Function.Arguments += d.Function.Arguments
+	}
+}
+
+func (b *toolCallBuilder) Calls() []types.ToolCall {
+	return fromOpenAIToolCalls(b.calls)
+}
+
+// reasoning returns the message's reasoning from whichever field is set
+func (m openAIMessage) reasoning() string {
+	if m.ReasoningContent != "" {
+		return m.ReasoningContent
+	}
+	return m.Reasoning
+}
+
+// MarshalJSON sends messages with images as an array of content parts
+func (m openAIMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Images) == 0 {
+		type plain openAIMessage
+		return json.Marshal(plain(m))
+	}
+
+	parts := []map[string]any{{"type": "text", "text": m.Content}}
+	for _, url := range m.Images {
+		parts = append(parts, map[string]any{
+			"type":      "image_url",
+			"image_url": map[string]string{"url": url},
+		})
+	}
+	msg := map[string]any{"role": m.Role, "content": parts}
+	if m.Name != "" {
+		msg["name"] = m.Name
+	}
+	return json.Marshal(msg)
+}
+
+// toOpenAIMessages converts messages to OpenAI format
+func toOpenAIMessages(messages []types.Message) []openAIMessage {
+	msgs := make([]openAIMessage, len(messages))
+	for i, m := range messages {
+		msgs[i] = openAIMessage{Role: m.Role, Content: m.Content, Name: m.Name, ToolCallID: m.ToolCallID}
+		for _, tc := range m.ToolCalls {
+			call := openAIToolCall{ID: tc.ID, Type: "function"}
+			call.Function.Name = tc.Name
+			call.Function.Arguments = tc.Arguments
+			msgs[i].ToolCalls = append(msgs[i].ToolCalls, call)
+		}
+		for _, a := range m.Attachments {
+			if a.IsImage() {
+				msgs[i].Images = append(msgs[i].Images, "data:"+a.MimeType+";base64,"+base64.StdEncoding.EncodeToString(a.Data))
+			}
+		}
+	}
+	return msgs
 }
 
 type openAIResponse struct {
@@ -76,138 +204,205 @@ type openAIResponse struct {
 		Delta        openAIMessage `json:"delta"`
 		FinishReason string        `json:"finish_reason"`
 	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	Usage *types.Usage `json:"usage"`
 }
 
-func (o *OpenAICompatProvider) Complete(ctx context.Context, req types.CompletionRequest) (*types.CompletionResponse, error) {
-	msgs := make([]openAIMessage, len(req.Messages))
-	for i, m := range req.Messages {
-		msgs[i] = openAIMessage{Role: m.Role, Content: m.Content}
+// newRequest creates an API request, a chat completion when body is set,
+// with the configured headers and query parameters added last so they can
+// replace the defaults
+func (o *OpenAICompatProvider) newRequest(ctx context.Context, method, path string, body []byte) (*http.Request, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, method, o.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
 	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if key := o.keys.key(); key != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+key)
+	}
+	for name, value := range o.config.Headers {
+		httpReq.Header.Set(name, value)
+	}
+	if len(o.config.Query) > 0 {
+		query := httpReq.URL.Query()
+		for name, value := range o.config.Query {
+			query.Set(name, value)
+		}
+		httpReq.URL.RawQuery = query.Encode()
+	}
+	return httpReq, nil
+}
 
+func (o *OpenAICompatProvider) Complete(ctx context.Context, req types.CompletionRequest) (*types.CompletionResponse, error) {
 	oaiReq := openAIRequest{
 		Model:       req.Model,
-		Messages:    msgs,
+		Messages:    toOpenAIMessages(req.Messages),
 		Temperature: req.Temperature,
 		MaxTokens:   req.MaxTokens,
+		TopP:        req.TopP,
+		Seed:        req.Seed,
+		Stop:        req.Stop,
 		Stream:      false,
+		Tools:       toOpenAITools(req.Tools),
+		Provider:    o.config.Routing,
 	}
 
+	if o.adapt != nil {
+		o.adapt(&oaiReq)
+	}
 	body, err := json.Marshal(oaiReq)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/chat/completions", bytes.NewReader(body))
+	watch := startWatchdog(ctx, o.name, req.Model, o.config.TimeoutsFor(req.Model).Read)
+	defer watch.Stop()
+
+	httpReq, err := o.newRequest(watch.ctx, "POST", "/chat/completions", body)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	if o.apiKey != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
-	}
 
-	resp, err := o.client.Do(httpReq)
+	resp, err := o.keys.do(o.client, httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("send request: %w", err)
+		return nil, fmt.Errorf("send request: %w", watch.Err(err))
 	}
+	watch.Respond(resp)
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("%s error %d: %s", o.name, resp.StatusCode, string(respBody))
+		return nil, newAPIError(o.name, req.Model, resp, respBody)
 	}
 
 	var oaiResp openAIResponse
 	if err := json.NewDecoder(resp.Body).Decode(&oaiResp); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+		return nil, fmt.Errorf("decode response: %w", watch.Err(err))
 	}
 
 	if len(oaiResp.Choices) == 0 {
 		return nil, fmt.Errorf("no choices in response")
 	}
 
+	msg := oaiResp.Choices[0].Message
+	content, reasoning := splitThink(msg.Content)
+	if r := msg.reasoning(); r != "" {
+		reasoning = r
+	}
+
+	tokens := 0
+	if oaiResp.Usage != nil {
+		tokens = oaiResp.Usage.TotalTokens
+	}
+
 	return &types.CompletionResponse{
-		Content:      oaiResp.Choices[0].Message.Content,
+		Content:      content,
+		Reasoning:    reasoning,
 		Model:        oaiResp.Model,
 		FinishReason: oaiResp.Choices[0].FinishReason,
-		TokensUsed:   oaiResp.Usage.TotalTokens,
+		TokensUsed:   tokens,
+		ToolCalls:    fromOpenAIToolCalls(msg.ToolCalls),
 	}, nil
 }
 
 func (o *OpenAICompatProvider) Stream(ctx context.Context, req types.CompletionRequest) (<-chan types.StreamChunk, error) {
-	msgs := make([]openAIMessage, len(req.Messages))
-	for i, m := range req.Messages {
-		msgs[i] = openAIMessage{Role: m.Role, Content: m.Content}
-	}
-
 	oaiReq := openAIRequest{
 		Model:       req.Model,
-		Messages:    msgs,
+		Messages:    toOpenAIMessages(req.Messages),
 		Temperature: req.Temperature,
 		MaxTokens:   req.MaxTokens,
+		TopP:        req.TopP,
+		Seed:        req.Seed,
+		Stop:        req.Stop,
 		Stream:      true,
+		Tools:       toOpenAITools(req.Tools),
+		Provider:    o.config.Routing,
+		// Usage arrives in a final chunk with no choices
+		StreamOptions: &openAIStreamOptions{IncludeUsage: true},
 	}
 
+	if o.adapt != nil {
+		o.adapt(&oaiReq)
+	}
 	body, err := json.Marshal(oaiReq)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/chat/completions", bytes.NewReader(body))
+	watch := startWatchdog(ctx, o.name, req.Model, o.config.TimeoutsFor(req.Model).Read)
+	httpReq, err := o.newRequest(watch.ctx, "POST", "/chat/completions", body)
 	if err != nil {
+		watch.Stop()
 		return nil, fmt.Errorf("create request: %w", err)
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Accept", "text/event-stream")
-	if o.apiKey != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
-	}
 
-	resp, err := o.client.Do(httpReq)
+	resp, err := o.keys.do(o.client, httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("send request: %w", err)
+		watch.Stop()
+		return nil, fmt.Errorf("send request: %w", watch.Err(err))
 	}
+	watch.Respond(resp)
 
 	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("%s error: status %d", o.name, resp.StatusCode)
+		watch.Stop()
+		return nil, newAPIError(o.name, req.Model, resp, respBody)
 	}
 
 	chunks := make(chan types.StreamChunk)
 	go func() {
 		defer close(chunks)
+		defer watch.Stop()
 		defer resp.Body.Close()
 
-		scanner := bufio.NewScanner(resp.Body)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if !strings.HasPrefix(line, "data: ") {
-				continue
-			}
-			data := strings.TrimPrefix(line, "data: ")
-			if data == "[DONE]" {
-				chunks <- types.StreamChunk{Done: true}
-				return
+		// Done is sent once, after [DONE] or the end of the stream, so the
+		// usage chunk that follows finish_reason is included
+		var think thinkParser
+		var tools toolCallBuilder
+		var finishReason string
+		var usage *types.Usage
+		err := readSSE(resp.Body, func(data []byte) bool {
+			if string(data) == "[DONE]" {
+				return false
 			}
 
 			var chunk openAIResponse
-			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
-				continue
+			if err := json.Unmarshal(data, &chunk); err != nil {
+				return true
+			}
+			if chunk.Usage != nil {
+				usage = chunk.Usage
 			}
 			if len(chunk.Choices) > 0 {
-				chunks <- types.StreamChunk{
-					Content: chunk.Choices[0].Delta.Content,
-					Done:    chunk.Choices[0].FinishReason != "",
+				delta := chunk.Choices[0].Delta
+				if r := chunk.Choices[0].FinishReason; r != "" {
+					finishReason = r
+				}
+				tools.Feed(delta.ToolCalls)
+				content, reasoning := think.Feed(delta.Content)
+				reasoning = delta.reasoning() + reasoning
+				if content != "" || reasoning != "" {
+					chunks <- types.StreamChunk{Content: content, Reasoning: reasoning}
 				}
 			}
+			return true
+		})
+		if err != nil {
+			chunks <- types.StreamChunk{Error: watch.Err(err)}
+			return
 		}
-		if err := scanner.Err(); err != nil {
-			chunks <- types.StreamChunk{Error: err}
+
+		content, reasoning := think.Flush()
+		chunks <- types.StreamChunk{
+			Content:      content,
+			Reasoning:    reasoning,
+			Done:         true,
+			FinishReason: finishReason,
+			Usage:        usage,
+			ToolCalls:    tools.Calls(),
 		}
 	}()
 
@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// catalogTTL is how long OpenRouter's model catalog is cached
+const catalogTTL = 24 * time.Hour
+
+// OpenRouterProvider is the OpenAI-compatible API of OpenRouter, whose
+// model catalog supplies context windows, tool and image support, and
+// prices
+type OpenRouterProvider struct {
+	*OpenAICompatProvider
+	cachePath string
+
+	mu        sync.Mutex
+	catalog   map[string]ModelInfo // By model ID
+	fetched   time.Time
+	refreshed bool // A background fetch was started
+}
+
+// NewOpenRouter creates an OpenRouter provider. Routing preferences in
+// the config are sent as each request's provider field.
+func NewOpenRouter(cfg Config) *OpenRouterProvider {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://openrouter.ai/api/v1"
+	}
+	// OpenRouter's app attribution, unless the config sets its own
+	headers := map[string]string{"HTTP-Referer": "https://github.com/agentflow/agentflow", "X-Title": "AgentFlow"}
+	for name, value := range cfg.Headers {
+		headers[name] = value
+	}
+	cfg.Headers = headers
+
+	home, _ := os.UserHomeDir()
+	return &OpenRouterProvider{
+		OpenAICompatProvider: NewOpenAICompat("openrouter", cfg),
+		cachePath:            filepath.Join(home, ".agentflow", "cache", "openrouter-models.json"),
+	}
+}
+
+// openRouterModel is a model in OpenRouter's catalog
+type openRouterModel struct {
+	ID            string `json:"id"`
+	ContextLength int    `json:"context_length"`
+	Pricing       struct {
+		Prompt     string `json:"prompt"` // USD per token
+		Completion string `json:"completion"`
+	} `json:"pricing"`
+	Architecture struct {
+		InputModalities []string `json:"input_modalities"`
+	} `json:"architecture"`
+	SupportedParameters []string `json:"supported_parameters"`
+}
+
+// info converts a catalog entry
+func (m openRouterModel) info() ModelInfo {
+	perMillion := func(price string) float64 {
+		f, _ := strconv.ParseFloat(price, 64)
+		return f * 1e6
+	}
+	info := ModelInfo{
+		ContextWindow: m.ContextLength,
+		InputPrice:    perMillion(m.Pricing.Prompt),
+		OutputPrice:   perMillion(m.Pricing.Completion),
+	}
+	if m.SupportedParameters != nil {
+		info.Tools = no
+		if slices.Contains(m.SupportedParameters, "tools") {
+			info.Tools = yes
+		}
+	}
+	if m.Architecture.InputModalities != nil {
+		info.Vision = no
+		if slices.Contains(m.Architecture.InputModalities, "image") {
+			info.Vision = yes
+		}
+	}
+	return info
+}
+
+// cachedCatalog is the catalog as saved on disk
+type cachedCatalog struct {
+	Fetched time.Time            `json:"fetched"`
+	Models  map[string]ModelInfo `json:"models"`
+}
+
+// Cataloger is implemented by providers with a catalog describing their
+// models, which ModelInfo only reads once fetched
+type Cataloger interface {
+	Catalog(ctx context.Context) (map[string]ModelInfo, error)
+}
+
+// Catalog returns OpenRouter's model catalog, by model ID, fetching it
+// when the cached copy is older than a day
+func (o *OpenRouterProvider) Catalog(ctx context.Context) (map[string]ModelInfo, error) {
+	o.mu.Lock()
+	o.loadCache()
+	catalog, fresh := o.catalog, time.Since(o.fetched) < catalogTTL
+	o.mu.Unlock()
+	if fresh {
+		return catalog, nil
+	}
+	return o.fetchCatalog(ctx)
+}
+
+func (o *OpenRouterProvider) fetchCatalog(ctx context.Context) (map[string]ModelInfo, error) {
+	req, err := o.newRequest(ctx, "GET", "/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Data []openRouterModel `json:"data"`
+	}
+	if err := getJSON(o.client, req, o.name, &list); err != nil {
+		return nil, err
+	}
+	catalog := make(map[string]ModelInfo, len(list.Data))
+	for _, m := range list.Data {
+		catalog[m.ID] = m.info()
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.catalog, o.fetched = catalog, time.Now()
+	if data, err := json.Marshal(cachedCatalog{Fetched: o.fetched, Models: catalog}); err == nil {
+		if os.MkdirAll(filepath.Dir(o.cachePath), 0755) == nil {
+			os.WriteFile(o.cachePath, data, 0644)
+		}
+	}
+	return catalog, nil
+}
+
+// loadCache reads the cached catalog, once. o.mu must be held.
+func (o *OpenRouterProvider) loadCache() {
+	if o.catalog != nil {
+		return
+	}
+	o.catalog = make(map[string]ModelInfo)
+	data, err := os.ReadFile(o.cachePath)
+	if err != nil {
+		return
+	}
+	var cached cachedCatalog
+	if json.Unmarshal(data, &cached) == nil && cached.Models != nil {
+		o.catalog, o.fetched = cached.Models, cached.Fetched
+	}
+}
+
+// ModelInfo returns what is known of a model: its model_info in the
+// config, then the catalog, then the built-in table. A missing or stale
+// catalog is fetched in the background, so the catalog's answer may come
+// on a later call. It is tried once per process.
+func (o *OpenRouterProvider) ModelInfo(model string) ModelInfo {
+	o.mu.Lock()
+	o.loadCache()
+	info := o.catalog[model]
+	if time.Since(o.fetched) >= catalogTTL && !o.refreshed {
+		o.refreshed = true
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			o.fetchCatalog(ctx)
+		}()
+	}
+	o.mu.Unlock()
+
+	configured, _ := lookup(o.config.ModelInfo, model)
+	builtin, _ := KnownModel(model)
+	return configured.or(info).or(builtin)
+}
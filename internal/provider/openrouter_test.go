@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+func TestOpenRouter(t *testing.T) {
+	var got openAIRequest
+	var title string
+	catalogFetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/models":
+			catalogFetches++
+			fmt.Fprint(w, `{"data":[
+				{"id":"anthropic/claude-3.5-sonnet","context_length":200000,
+				 "pricing":{"prompt":"0.000003","completion":"0.000015"},
+				 "architecture":{"input_modalities":["text","image"]},
+				 "supported_parameters":["tools","temperature"]},
+				{"id":"meta-llama/llama-3.1-8b-instruct","context_length":131072,
+				 "pricing":{"prompt":"0.00000002","completion":"0.00000005"},
+				 "architecture":{"input_modalities":["text"]},
+				 "supported_parameters":["temperature"]}]}`)
+		case "/chat/completions":
+			title = r.Header.Get("X-Title")
+			json.NewDecoder(r.Body).Decode(&got)
+			fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewOpenRouter(Config{
+		BaseURL:   srv.URL,
+		Routing:   map[string]any{"order": []string{"anthropic"}, "allow_fallbacks": false},
+		ModelInfo: map[string]ModelInfo{"llama-3.1-8b": {ContextWindow: 8192}},
+	})
+	p.cachePath = filepath.Join(t.TempDir(), "openrouter-models.json")
+
+	if _, err := p.Catalog(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	info := p.ModelInfo("anthropic/claude-3.5-sonnet")
+	if info.ContextWindow != 200000 || info.InputPrice != 3 || info.OutputPrice != 15 || !info.SupportsTools() || !info.SupportsVision() {
+		t.Errorf("sonnet = %+v", info)
+	}
+	if cost := InfoFor(p, "anthropic/claude-3.5-sonnet").Cost(1_000_000, 0); cost != 3 {
+		t.Errorf("cost = %v", cost)
+	}
+	if info := p.ModelInfo("meta-llama/llama-3.1-8b-instruct"); info.ContextWindow != 8192 || info.SupportsTools() {
+		t.Errorf("model_info should win over the catalog: %+v", info)
+	}
+
+	// A new process reads the cached catalog
+	again := NewOpenRouter(Config{BaseURL: srv.URL})
+	again.cachePath = p.cachePath
+	if _, err := again.Catalog(context.Background()); err != nil || catalogFetches != 1 {
+		t.Errorf("cached catalog: %d fetches, %v", catalogFetches, err)
+	}
+
+	if _, err := p.Complete(context.Background(), types.CompletionRequest{Model: "anthropic/claude-3.5-sonnet"}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Provider["allow_fallbacks"] != false || title != "AgentFlow" {
+		t.Errorf("provider = %v, X-Title = %q", got.Provider, title)
+	}
+}
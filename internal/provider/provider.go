@@ -27,9 +27,31 @@ type Provider interface {
 
 // Config holds provider configuration
 type Config struct {
-	BaseURL string   `yaml:"base_url"`
-	APIKey  string   `yaml:"api_key"`
-	Models  []string `yaml:"models"`
+	BaseURL       string               `yaml:"base_url"`
+	APIKey        string               `yaml:"api_key"`
+	Keys          []Key                `yaml:"keys,omitempty"` // More keys, used in turn when one is rate limited
+	Models        []string             `yaml:"models"`
+	Timeouts      Timeouts             `yaml:"timeouts,omitempty"`
+	ModelTimeouts map[string]Timeouts  `yaml:"model_timeouts,omitempty"` // By model name, without the provider
+	ModelInfo     map[string]ModelInfo `yaml:"model_info,omitempty"`     // By model name prefix, over the built-in table
+
+	// Added to every request, for gateways such as OpenRouter or Azure
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Query   map[string]string `yaml:"query,omitempty"`
+
+	// OpenRouter's provider routing preferences (order, allow_fallbacks,
+	// sort, ...), sent as each request's provider field
+	Routing map[string]any `yaml:"routing,omitempty"`
+
+	// Network
+	Pool               Pool   `yaml:"pool,omitempty"`
+	HTTPProxy          string `yaml:"http_proxy,omitempty"`  // Overrides the *_PROXY variables
+	CACert             string `yaml:"ca_cert,omitempty"`     // PEM file trusted besides the system roots
+	ClientCert         string `yaml:"client_cert,omitempty"` // PEM certificate for mTLS
+	ClientKey          string `yaml:"client_key,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"` // Accept any server certificate
+
+	presetModels map[string]ModelInfo // A preset's own models, below ModelInfo
 }
 
 // Registry holds all registered providers
@@ -64,8 +86,12 @@ func (r *Registry) List() []string {
 	return names
 }
 
-// ResolveModel parses "provider/model" format and returns the provider and model
+// ResolveModel parses "provider/model" format and returns the provider and model.
+// A bare provider name, such as "auto", resolves with no model.
 func (r *Registry) ResolveModel(spec string) (Provider, string, bool) {
+	if p, ok := r.providers[spec]; ok {
+		return p, "", true
+	}
 	for i := 0; i < len(spec); i++ {
 		if spec[i] == '/' {
 			providerName := spec[:i]
@@ -1,7 +1,20 @@
 package provider
 
 import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/agentflow/agentflow/pkg/types"
 )
 
 func TestNewRegistry(t *testing.T) {
@@ -129,3 +142,526 @@ func TestOpenAICompatProvider_SupportsModel(t *testing.T) {
 		t.Error("expected model-c to not be supported")
 	}
 }
+
+func TestImageAttachments(t *testing.T) {
+	msgs := []types.Message{
+		{Role: "user", Content: "plain"},
+		{Role: "user", Content: "what's this?", Attachments: []types.Attachment{
+			{Type: "image", MimeType: "image/png", Data: []byte("png")},
+		}},
+	}
+
+	ollama := toOllamaMessages(msgs)
+	if len(ollama[0].Images) != 0 {
+		t.Errorf("unexpected images on plain message: %v", ollama[0].Images)
+	}
+	if len(ollama[1].Images) != 1 || ollama[1].Images[0] != "cG5n" {
+		t.Errorf("ollama images = %v", ollama[1].Images)
+	}
+
+	data, err := json.Marshal(toOpenAIMessages(msgs))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	body := string(data)
+	if !strings.Contains(body, `{"role":"user","content":"plain"}`) {
+		t.Errorf("plain message not sent as string content: %s", body)
+	}
+	if !strings.Contains(body, `"image_url":{"url":"data:image/png;base64,cG5n"}`) {
+		t.Errorf("image not sent as content part: %s", body)
+	}
+}
+
+func TestOpenAICompat_StreamStopAndDone(t *testing.T) {
+	var got openAIRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"héllo\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\" wörld\"},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":3,\"total_tokens\":15}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	p := NewOpenAICompat("test", Config{BaseURL: srv.URL})
+	seed := 42
+	chunks, err := p.Stream(context.Background(), types.CompletionRequest{Model: "m", Stop: []string{"\nUser:"}, Seed: &seed})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	var content string
+	var last types.StreamChunk
+	dones := 0
+	for c := range chunks {
+		if c.Error != nil {
+			t.Fatalf("chunk error: %v", c.Error)
+		}
+		content += c.Content
+		if c.Done {
+			dones++
+			last = c
+		}
+	}
+
+	if last.FinishReason != "stop" {
+		t.Errorf("finish reason = %q", last.FinishReason)
+	}
+	if last.Usage == nil || last.Usage.PromptTokens != 12 || last.Usage.CompletionTokens != 3 {
+		t.Errorf("usage = %+v", last.Usage)
+	}
+	if got.StreamOptions == nil || !got.StreamOptions.IncludeUsage {
+		t.Error("expected stream_options.include_usage")
+	}
+
+	if content != "héllo wörld" {
+		t.Errorf("content = %q", content)
+	}
+	if dones != 1 {
+		t.Errorf("got %d done chunks, want 1", dones)
+	}
+	if len(got.Stop) != 1 || got.Stop[0] != "\nUser:" {
+		t.Errorf("stop = %q", got.Stop)
+	}
+	if got.Seed == nil || *got.Seed != 42 {
+		t.Errorf("seed = %v, want 42", got.Seed)
+	}
+}
+
+func TestOllama_StopOption(t *testing.T) {
+	if opts := ollamaOptionsFor(types.CompletionRequest{}); opts != nil {
+		t.Errorf("expected nil options for defaults, got %+v", opts)
+	}
+
+	opts := ollamaOptionsFor(types.CompletionRequest{Stop: []string{"</answer>"}})
+	if opts == nil || len(opts.Stop) != 1 || opts.Stop[0] != "</answer>" {
+		t.Errorf("options = %+v", opts)
+	}
+
+	// A seed of 0 is still a seed
+	seed := 0
+	if opts := ollamaOptionsFor(types.CompletionRequest{Seed: &seed}); opts == nil || opts.Seed == nil || *opts.Seed != 0 {
+		t.Errorf("seed not sent: %+v", opts)
+	}
+}
+
+func TestOpenAICompat_StreamReasoning(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"reasoning_content\":\"hmm\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"<think>more</think>\\n\\nok\"},\"finish_reason\":\"stop\"}]}\n\n")
+	}))
+	defer srv.Close()
+
+	p := NewOpenAICompat("test", Config{BaseURL: srv.URL})
+	chunks, err := p.Stream(context.Background(), types.CompletionRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	var content, reasoning string
+	for c := range chunks {
+		content += c.Content
+		reasoning += c.Reasoning
+	}
+	if content != "ok" || reasoning != "hmmmore" {
+		t.Errorf("content = %q, reasoning = %q", content, reasoning)
+	}
+}
+
+func TestToolCallMessages(t *testing.T) {
+	msgs := []types.Message{
+		{Role: "assistant", ToolCalls: []types.ToolCall{{ID: "call_1", Name: "read_file", Arguments: `{"path":"go.mod"}`}}},
+		{Role: "tool", Name: "read_file", ToolCallID: "call_1", Content: "module x"},
+	}
+
+	data, _ := json.Marshal(toOpenAIMessages(msgs))
+	body := string(data)
+	if !strings.Contains(body, `"tool_calls":[{"id":"call_1","type":"function","function":{"name":"read_file","arguments":"{\"path\":\"go.mod\"}"}}]`) {
+		t.Errorf("openai tool calls = %s", body)
+	}
+	if !strings.Contains(body, `"tool_call_id":"call_1"`) {
+		t.Errorf("openai tool result = %s", body)
+	}
+
+	data, _ = json.Marshal(toOllamaMessages(msgs))
+	body = string(data)
+	if !strings.Contains(body, `"arguments":{"path":"go.mod"}`) {
+		t.Errorf("ollama tool calls = %s", body)
+	}
+	if !strings.Contains(body, `"tool_name":"read_file"`) {
+		t.Errorf("ollama tool result = %s", body)
+	}
+}
+
+func TestOpenAICompat_StreamToolCalls(t *testing.T) {
+	var got openAIRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"go_definition\",\"arguments\":\"\"}}]}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"{\\\"symbol\\\":\"}}]}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"\\\"New\\\"}\"}}]},\"finish_reason\":\"tool_calls\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	p := NewOpenAICompat("test", Config{BaseURL: srv.URL})
+	tools := []types.ToolDefinition{{Name: "go_definition", Description: "Find a definition", Parameters: map[string]any{"type": "object"}}}
+	chunks, err := p.Stream(context.Background(), types.CompletionRequest{Model: "m", Tools: tools})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	var last types.StreamChunk
+	for c := range chunks {
+		last = c
+	}
+	if len(got.Tools) != 1 || got.Tools[0].Type != "function" || got.Tools[0].Function.Name != "go_definition" {
+		t.Errorf("tools sent = %+v", got.Tools)
+	}
+	if len(last.ToolCalls) != 1 {
+		t.Fatalf("tool calls = %+v", last.ToolCalls)
+	}
+	call := last.ToolCalls[0]
+	if call.ID != "call_1" || call.Name != "go_definition" || call.Arguments != `{"symbol":"New"}` {
+		t.Errorf("tool call = %+v", call)
+	}
+}
+
+func TestOllama_CompleteToolCalls(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"model":"m","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"go_package","arguments":{"package":"./internal/agent"}}}]},"done":true}`)
+	}))
+	defer srv.Close()
+
+	p := NewOllama(Config{BaseURL: srv.URL})
+	resp, err := p.Complete(context.Background(), types.CompletionRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("tool calls = %+v", resp.ToolCalls)
+	}
+	call := resp.ToolCalls[0]
+	if call.ID != "call_1" || call.Name != "go_package" || call.Arguments != `{"package":"./internal/agent"}` {
+		t.Errorf("tool call = %+v", call)
+	}
+}
+
+func TestConfig_TimeoutsFor(t *testing.T) {
+	cfg := Config{
+		Timeouts:      Timeouts{Read: time.Minute},
+		ModelTimeouts: map[string]Timeouts{"llama3.3:70b": {Read: 10 * time.Minute}},
+	}
+
+	if got := cfg.TimeoutsFor("llama3.3:70b"); got.Read != 10*time.Minute || got.Connect != DefaultConnectTimeout {
+		t.Errorf("model timeouts = %+v", got)
+	}
+	if got := cfg.TimeoutsFor("other"); got.Read != time.Minute {
+		t.Errorf("provider timeouts = %+v", got)
+	}
+	if got := (Config{}).TimeoutsFor("m"); got.Read != DefaultReadTimeout {
+		t.Errorf("default timeouts = %+v", got)
+	}
+}
+
+func TestOpenAICompat_StreamReadTimeout(t *testing.T) {
+	stalled := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		// Chunks slower than the timeout overall, but each in time
+		for _, word := range []string{"slow", " but", " steady"} {
+			time.Sleep(60 * time.Millisecond)
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", word)
+			w.(http.Flusher).Flush()
+		}
+		// Then nothing
+		<-stalled
+	}))
+	defer srv.Close()
+	defer close(stalled)
+
+	p := NewOpenAICompat("test", Config{BaseURL: srv.URL, Timeouts: Timeouts{Read: 150 * time.Millisecond}})
+	chunks, err := p.Stream(context.Background(), types.CompletionRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	var content string
+	var streamErr error
+	for c := range chunks {
+		content += c.Content
+		if c.Error != nil {
+			streamErr = c.Error
+		}
+	}
+	if content != "slow but steady" {
+		t.Errorf("content = %q", content)
+	}
+	var timeout *TimeoutError
+	if !errors.As(streamErr, &timeout) || timeout.Model != "test/m" {
+		t.Errorf("error = %v, want a read timeout", streamErr)
+	}
+}
+
+func TestOllama_CompleteReadTimeout(t *testing.T) {
+	stalled := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stalled
+	}))
+	defer srv.Close()
+	defer close(stalled)
+
+	p := NewOllama(Config{BaseURL: srv.URL, ModelTimeouts: map[string]Timeouts{"m": {Read: 50 * time.Millisecond}}})
+	_, err := p.Complete(context.Background(), types.CompletionRequest{Model: "m"})
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) {
+		t.Errorf("error = %v, want a read timeout", err)
+	}
+}
+
+func TestNewTransport_CACert(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"model":"m","message":{"content":"ok"},"done":true}`)
+	}))
+	defer srv.Close()
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(ca, cert, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		ok   bool
+	}{
+		{"untrusted", Config{BaseURL: srv.URL}, false},
+		{"ca_cert", Config{BaseURL: srv.URL, CACert: ca}, true},
+		{"insecure_skip_verify", Config{BaseURL: srv.URL, InsecureSkipVerify: true}, true},
+		{"missing ca_cert", Config{BaseURL: srv.URL, CACert: ca + ".missing"}, false},
+		{"key without cert", Config{BaseURL: srv.URL, ClientKey: ca}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewOllama(tt.cfg).Complete(context.Background(), types.CompletionRequest{Model: "m"})
+			if (err == nil) != tt.ok {
+				t.Errorf("err = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNewTransport_HTTPProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		fmt.Fprint(w, `{"model":"m","message":{"content":"ok"},"done":true}`)
+	}))
+	defer proxy.Close()
+
+	p := NewOllama(Config{BaseURL: "http://gpu-server.invalid:11434", HTTPProxy: proxy.URL})
+	if _, err := p.Complete(context.Background(), types.CompletionRequest{Model: "m"}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if proxied != "http://gpu-server.invalid:11434/api/chat" {
+		t.Errorf("proxied %q", proxied)
+	}
+
+	if _, err := NewTransport(Config{HTTPProxy: "::bad"}); err == nil {
+		t.Error("expected an error for an invalid proxy")
+	}
+}
+
+func TestOpenAICompat_HeadersAndQuery(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		fmt.Fprint(w, `{"model":"m","choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	p := NewOpenAICompat("gateway", Config{
+		BaseURL: srv.URL,
+		APIKey:  "key",
+		Headers: map[string]string{"HTTP-Referer": "https://agentflow.dev", "X-Title": "AgentFlow"},
+		Query:   map[string]string{"api-version": "2024-06-01"},
+	})
+	if _, err := p.Complete(context.Background(), types.CompletionRequest{Model: "m"}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	if got.Header.Get("HTTP-Referer") != "https://agentflow.dev" || got.Header.Get("X-Title") != "AgentFlow" {
+		t.Errorf("headers = %v", got.Header)
+	}
+	if got.Header.Get("Authorization") != "Bearer key" {
+		t.Errorf("authorization = %q", got.Header.Get("Authorization"))
+	}
+	if got.URL.Path != "/chat/completions" || got.URL.Query().Get("api-version") != "2024-06-01" {
+		t.Errorf("url = %s", got.URL)
+	}
+}
+
+func TestNewHTTPClient_Shared(t *testing.T) {
+	a := NewOllama(Config{BaseURL: "http://gpu:11434"})
+	b := NewOpenAICompat("vllm", Config{BaseURL: "http://gpu:8000/v1"})
+	if a.client != b.client {
+		t.Error("providers with the same network settings should share a client")
+	}
+	c := NewOllama(Config{Pool: Pool{MaxIdle: 4}})
+	if c.client == a.client {
+		t.Error("different pool settings should get their own client")
+	}
+}
+
+func TestNewTransport_Pool(t *testing.T) {
+	transport, err := NewTransport(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.MaxIdleConnsPerHost != DefaultMaxIdle || transport.IdleConnTimeout != DefaultIdleTimeout {
+		t.Errorf("default pool: %d idle per host, %s", transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+	if !transport.Protocols.HTTP2() || transport.Protocols.UnencryptedHTTP2() {
+		t.Errorf("default protocols = %v", transport.Protocols)
+	}
+
+	off, on := false, true
+	transport, _ = NewTransport(Config{Pool: Pool{MaxIdle: 64, IdleTimeout: time.Minute, HTTP2: &off}})
+	if transport.MaxIdleConnsPerHost != 64 || transport.IdleConnTimeout != time.Minute {
+		t.Errorf("pool: %d idle per host, %s", transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+	if transport.Protocols.HTTP2() {
+		t.Error("http2: false should stick to HTTP/1.1")
+	}
+	transport, _ = NewTransport(Config{Pool: Pool{HTTP2: &on}})
+	if !transport.Protocols.UnencryptedHTTP2() {
+		t.Error("http2: true should allow h2c")
+	}
+}
+
+func TestMetrics_RecordsRequests(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"model":"m","choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	p := NewOpenAICompat("metrics", Config{BaseURL: srv.URL})
+	if _, err := p.Complete(context.Background(), types.CompletionRequest{Model: "m"}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	status = http.StatusTooManyRequests
+	if _, err := p.Complete(context.Background(), types.CompletionRequest{Model: "m"}); err == nil {
+		t.Fatal("expected an error for 429")
+	}
+
+	m := MetricsFor("metrics")
+	if m.Requests != 2 || m.Errors != 1 || m.RateLimited != 1 || m.InFlight != 0 {
+		t.Errorf("metrics = %+v", m)
+	}
+	if m.Latency <= 0 {
+		t.Errorf("latency = %s", m.Latency)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			fmt.Fprint(w, `{"models":[{"name":"qwen2.5:7b"},{"name":"llama3.3:latest"}]}`)
+		case "/models":
+			if r.Header.Get("Authorization") != "Bearer key" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"data":[{"id":"b"},{"id":"a"}]}`)
+		}
+	}))
+	defer srv.Close()
+
+	models, err := NewOllama(Config{BaseURL: srv.URL}).ListModels(context.Background())
+	if err != nil || strings.Join(models, ",") != "llama3.3:latest,qwen2.5:7b" {
+		t.Errorf("ollama: %v, %v", models, err)
+	}
+	models, err = NewOpenAICompat("x", Config{BaseURL: srv.URL, APIKey: "key"}).ListModels(context.Background())
+	if err != nil || strings.Join(models, ",") != "a,b" {
+		t.Errorf("openai: %v, %v", models, err)
+	}
+	if _, err := NewOpenAICompat("x", Config{BaseURL: srv.URL}).ListModels(context.Background()); err == nil {
+		t.Error("expected error without API key")
+	}
+}
+
+func TestCheckModel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"models":[{"name":"qwen2.5:7b"},{"name":"llama3.3:latest"}]}`)
+	}))
+	defer srv.Close()
+
+	ollama := NewOllama(Config{BaseURL: srv.URL})
+	for _, model := range []string{"qwen2.5:7b", "llama3.3", ""} {
+		if err := CheckModel(context.Background(), ollama, model); err != nil {
+			t.Errorf("%q: %v", model, err)
+		}
+	}
+	if err := CheckModel(context.Background(), ollama, "mistral"); err == nil {
+		t.Error("expected an error for a model the server lacks")
+	}
+}
+
+func TestOllama_Pull(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"status":"pulling manifest"}`)
+		fmt.Fprintln(w, `{"status":"downloading","total":100,"completed":50}`)
+		fmt.Fprintln(w, `{"status":"success"}`)
+	}))
+	defer srv.Close()
+
+	var statuses []string
+	err := NewOllama(Config{BaseURL: srv.URL}).Pull(context.Background(), "m", func(p PullProgress) {
+		statuses = append(statuses, p.Status)
+	})
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if strings.Join(statuses, ",") != "pulling manifest,downloading,success" {
+		t.Errorf("statuses = %v", statuses)
+	}
+}
+
+func TestPresets(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	seed := 7
+	mistral := NewMistral(Config{BaseURL: srv.URL})
+	chunks, err := mistral.Stream(context.Background(), types.CompletionRequest{Model: "mistral-small-latest", Seed: &seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range chunks {
+	}
+	if _, ok := body["seed"]; ok || body["random_seed"] != float64(7) || body["stream_options"] != nil {
+		t.Errorf("mistral request = %v", body)
+	}
+
+	deepseek := NewDeepSeek(Config{})
+	if deepseek.baseURL != "https://api.deepseek.com/v1" || !deepseek.SupportsModel("deepseek-reasoner") {
+		t.Errorf("deepseek = %s %v", deepseek.baseURL, deepseek.Models())
+	}
+	if InfoFor(deepseek, "deepseek-reasoner").SupportsTools() {
+		t.Error("deepseek-reasoner doesn't call tools")
+	}
+	if !NewMistral(Config{}).SupportsModel("codestral-latest") {
+		t.Error("mistral should list its models by default")
+	}
+}
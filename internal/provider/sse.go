@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// readSSE reads a server-sent event stream, calling fn with the data of
+// each event until fn returns false or the stream ends. Unlike
+// bufio.Scanner it has no line length limit, so large events (long
+// completions, tool arguments) are never cut off.
+func readSSE(r io.Reader, fn func(data []byte) bool) error {
+	reader := bufio.NewReader(r)
+	var data []byte
+	hasData := false
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			line = bytes.TrimRight(line, "\r\n")
+
+			switch {
+			case len(line) == 0:
+				// Blank line ends the event
+				if hasData {
+					if !fn(data) {
+						return nil
+					}
+					data, hasData = nil, false
+				}
+			case bytes.HasPrefix(line, []byte("data:")):
+				value := bytes.TrimPrefix(line[len("data:"):], []byte(" "))
+				if hasData {
+					data = append(data, '\n')
+				}
+				data = append(data, value...)
+				hasData = true
+			}
+			// Comments (":") and other fields (event, id, retry) are ignored
+		}
+
+		if err != nil {
+			if hasData {
+				fn(data)
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
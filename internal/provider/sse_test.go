@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadSSE(t *testing.T) {
+	long := strings.Repeat("x", 200*1024) // Beyond bufio.Scanner's 64KB default
+	stream := ": keep-alive\r\n" +
+		"data: {\"a\":1}\r\n\r\n" +
+		"event: message\n" +
+		"data:first\n" +
+		"data: second\n\n" +
+		"data: " + long + "\n\n" +
+		"data: [DONE]" // No trailing newline
+
+	var events []string
+	if err := readSSE(strings.NewReader(stream), func(data []byte) bool {
+		events = append(events, string(data))
+		return true
+	}); err != nil {
+		t.Fatalf("readSSE: %v", err)
+	}
+
+	want := []string{`{"a":1}`, "first\nsecond", long, "[DONE]"}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %.40q, want %.40q", i, events[i], want[i])
+		}
+	}
+}
+
+func TestReadSSE_Stop(t *testing.T) {
+	count := 0
+	readSSE(strings.NewReader("data: 1\n\ndata: 2\n\n"), func(data []byte) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Errorf("fn called %d times after returning false", count)
+	}
+}
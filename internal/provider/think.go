@@ -0,0 +1,103 @@
+package provider
+
+import "strings"
+
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// thinkParser splits <think>...</think> reasoning blocks (DeepSeek-R1,
+// QwQ) out of streamed content. Tags may be split across chunks, so a
+// possible partial tag is held back until the next chunk.
+type thinkParser struct {
+	inThink     bool
+	seenContent bool // A block only opens before any answer text
+	trimNext    bool // Drop the whitespace that follows </think>
+	pending     string
+}
+
+// Feed consumes a chunk of content and returns its answer and reasoning parts
+func (p *thinkParser) Feed(s string) (content, reasoning string) {
+	s = p.pending + s
+	p.pending = ""
+
+	var c, r strings.Builder
+	emit := func(text string) {
+		if p.inThink {
+			r.WriteString(text)
+			return
+		}
+		if p.trimNext {
+			text = strings.TrimLeft(text, " \t\r\n")
+			if text == "" {
+				return
+			}
+			p.trimNext = false
+		}
+		if strings.TrimSpace(text) != "" {
+			p.seenContent = true
+		}
+		c.WriteString(text)
+	}
+
+	for s != "" {
+		tag := thinkOpen
+		if p.inThink {
+			tag = thinkClose
+		} else if p.seenContent {
+			emit(s)
+			break
+		}
+
+		i := strings.Index(s, tag)
+		if i >= 0 && !p.inThink && strings.TrimSpace(s[:i]) != "" {
+			i = -1 // Answer text comes first; the tag is just text
+		}
+		if i >= 0 {
+			emit(s[:i])
+			s = s[i+len(tag):]
+			if p.inThink {
+				p.trimNext = true
+			}
+			p.inThink = !p.inThink
+			continue
+		}
+
+		keep := partialSuffix(s, tag)
+		emit(s[:len(s)-keep])
+		p.pending = s[len(s)-keep:]
+		break
+	}
+
+	return c.String(), r.String()
+}
+
+// Flush returns any held-back text at the end of the stream
+func (p *thinkParser) Flush() (content, reasoning string) {
+	s := p.pending
+	p.pending = ""
+	if p.inThink {
+		return "", s
+	}
+	return s, ""
+}
+
+// splitThink separates reasoning from a complete response
+func splitThink(s string) (content, reasoning string) {
+	var p thinkParser
+	content, reasoning = p.Feed(s)
+	c, r := p.Flush()
+	return content + c, reasoning + r
+}
+
+// partialSuffix returns the length of the longest suffix of s that is a
+// proper prefix of tag
+func partialSuffix(s, tag string) int {
+	for n := len(tag) - 1; n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
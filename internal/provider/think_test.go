@@ -0,0 +1,44 @@
+package provider
+
+import "testing"
+
+func TestThinkParser_SplitTags(t *testing.T) {
+	var p thinkParser
+	var content, reasoning string
+	for _, chunk := range []string{"<thi", "nk>Let me ", "consider.</th", "ink>\n\nThe ", "answer is 4."} {
+		c, r := p.Feed(chunk)
+		content += c
+		reasoning += r
+	}
+	c, r := p.Flush()
+	content += c
+	reasoning += r
+
+	if reasoning != "Let me consider." {
+		t.Errorf("reasoning = %q", reasoning)
+	}
+	if content != "The answer is 4." {
+		t.Errorf("content = %q", content)
+	}
+}
+
+func TestThinkParser_TagAfterContent(t *testing.T) {
+	content, reasoning := splitThink("Use a <think> tag like this.")
+	if reasoning != "" || content != "Use a <think> tag like this." {
+		t.Errorf("content = %q, reasoning = %q", content, reasoning)
+	}
+}
+
+func TestThinkParser_Unterminated(t *testing.T) {
+	content, reasoning := splitThink("<think>still going</thi")
+	if content != "" || reasoning != "still going</thi" {
+		t.Errorf("content = %q, reasoning = %q", content, reasoning)
+	}
+}
+
+func TestThinkParser_PlainContent(t *testing.T) {
+	content, reasoning := splitThink("no reasoning here <")
+	if content != "no reasoning here <" || reasoning != "" {
+		t.Errorf("content = %q, reasoning = %q", content, reasoning)
+	}
+}
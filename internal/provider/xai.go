@@ -0,0 +1,13 @@
+package provider
+
+// NewXAI creates a new xAI provider for the Grok models
+// xAI uses the OpenAI-compatible API format
+func NewXAI(cfg Config) *OpenAICompatProvider {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.x.ai/v1"
+	}
+	if len(cfg.Models) == 0 {
+		cfg.Models = []string{"grok-4", "grok-3", "grok-3-mini", "grok-code-fast-1"}
+	}
+	return NewOpenAICompat("xai", cfg)
+}
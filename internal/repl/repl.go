@@ -3,17 +3,27 @@ package repl
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/artifact"
 	"github.com/agentflow/agentflow/internal/config"
+	"github.com/agentflow/agentflow/internal/fetch"
+	"github.com/agentflow/agentflow/internal/i18n"
+	"github.com/agentflow/agentflow/internal/input"
+	"github.com/agentflow/agentflow/internal/instance"
+	"github.com/agentflow/agentflow/internal/pager"
 	"github.com/agentflow/agentflow/internal/provider"
 	"github.com/agentflow/agentflow/internal/session"
 	"github.com/agentflow/agentflow/internal/skill"
+	"github.com/agentflow/agentflow/internal/tool"
 	"github.com/agentflow/agentflow/pkg/types"
 	"github.com/fatih/color"
 )
@@ -30,6 +40,13 @@ type REPL struct {
 	session        *session.Session
 	sessionManager *session.Manager
 	autoSave       bool
+	readOnly       bool   // Another instance saves this session
+	shared         string // What to do with sessions open elsewhere
+	notice         string // Shown under the welcome message
+	resend         string // Message a command sends again, see rewind
+	instance       *instance.Handle
+	plain          bool
+	onCommand      func(cmd string, args []string) (string, bool)
 }
 
 // Options configures REPL behavior
@@ -37,6 +54,8 @@ type Options struct {
 	ContinueLast bool   // Continue last session for current workdir
 	ResumeID     string // Resume specific session by ID or name
 	ForkSession  bool   // Fork instead of continuing
+	Plain        bool   // Screen-reader friendly output: no colors or box drawing
+	Shared       string // Session open in another instance: instance.SharedFork (default), SharedReadOnly or SharedWarn
 }
 
 // New creates a new REPL instance
@@ -61,16 +80,26 @@ func NewWithOptions(cfg *config.Config, opts Options) (*REPL, error) {
 	}
 
 	// Load skills
-	skillLoader := skill.NewLoader(cfg.Skills.Paths)
+	skillLoader := cfg.SkillLoader()
 	if err := skillLoader.Load(); err != nil {
 		return nil, fmt.Errorf("load skills: %w", err)
 	}
 
 	// Create agent
 	ag := agent.New(agent.Config{
-		Provider: prov,
-		Model:    model,
-		Skills:   skillLoader,
+		Provider:     prov,
+		Model:        model,
+		Skills:       skillLoader,
+		ResolveModel: cfg.ModelResolver(registry),
+		SystemPrompt: cfg.Language.AnswerInstruction(),
+		Tools:        cfg.BuildTools(),
+		Budget:       cfg.ContextBudget(),
+		Guard:        cfg.InjectionGuard(),
+		MaxCost:      cfg.Defaults.MaxCost,
+		Params:       cfg.GenerationParams(),
+		SkillStats:   skill.NewStats(""),
+		Style:        cfg.DefaultStyle(),
+		Verify:       cfg.Verifier(),
 	})
 
 	// Initialize session manager
@@ -83,6 +112,8 @@ func NewWithOptions(cfg *config.Config, opts Options) (*REPL, error) {
 	// Handle session options
 	var sess *session.Session
 	var err error
+	var readOnly bool
+	var notice string
 	if opts.ResumeID != "" {
 		// Resume specific session
 		sess, err = sessMgr.GetByNameOrID(opts.ResumeID)
@@ -91,6 +122,8 @@ func NewWithOptions(cfg *config.Config, opts Options) (*REPL, error) {
 		}
 		if opts.ForkSession {
 			sess = sess.Clone()
+		} else {
+			sess, readOnly, notice = instance.Claim(instance.DefaultDir(), sess, opts.Shared)
 		}
 	} else if opts.ContinueLast {
 		// Continue last session for this workdir
@@ -100,6 +133,8 @@ func NewWithOptions(cfg *config.Config, opts Options) (*REPL, error) {
 			sess = session.New(workdir, providerName, model)
 		} else if opts.ForkSession {
 			sess = sess.Clone()
+		} else {
+			sess, readOnly, notice = instance.Claim(instance.DefaultDir(), sess, opts.Shared)
 		}
 	} else {
 		// New session
@@ -107,9 +142,7 @@ func NewWithOptions(cfg *config.Config, opts Options) (*REPL, error) {
 	}
 
 	// Restore messages to agent
-	for _, msg := range sess.Messages {
-		ag.AddMessage(msg.Role, msg.Content)
-	}
+	restoreSession(cfg, ag, sess)
 
 	return &REPL{
 		config:         cfg,
@@ -122,55 +155,175 @@ func NewWithOptions(cfg *config.Config, opts Options) (*REPL, error) {
 		session:        sess,
 		sessionManager: sessMgr,
 		autoSave:       true,
+		readOnly:       readOnly,
+		shared:         opts.Shared,
+		notice:         notice,
+		plain:          opts.Plain,
 	}, nil
 }
 
-// Run starts the interactive REPL session
+// writingCommand returns the first command that may change things in a
+// read-only session
+func (r *REPL) writingCommand(commands []string) (string, bool) {
+	if !r.config.ReadOnly {
+		return "", false
+	}
+	for _, command := range commands {
+		if input.Writes(command) {
+			return command, true
+		}
+	}
+	return "", false
+}
+
+// Agent returns the agent the REPL talks to
+func (r *REPL) Agent() *agent.Agent {
+	return r.agent
+}
+
+// AddFeedback stores a rating in the current session and saves it
+func (r *REPL) AddFeedback(f session.Feedback) error {
+	if r.session == nil {
+		return fmt.Errorf("no active session")
+	}
+	r.session.AddFeedback(f)
+	return r.sessionManager.Save(r.session)
+}
+
+// SetOnCommand sets a handler for slash commands the REPL doesn't know.
+// It returns the text to show and whether the command was handled.
+func (r *REPL) SetOnCommand(fn func(cmd string, args []string) (string, bool)) {
+	r.onCommand = fn
+}
+
+// Run starts the interactive REPL session. On SIGINT or SIGTERM the
+// response being streamed is cancelled, kept as far as it got, and the
+// session saved before returning.
 func (r *REPL) Run(ctx context.Context) error {
 	r.running = true
+	if r.plain {
+		color.NoColor = true
+	}
 
-	// Handle Ctrl+C gracefully
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-sigChan
-		fmt.Println("\n\nSession ended. Goodbye!")
-		r.running = false
-		os.Exit(0)
-	}()
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	defer r.shutdown()
+
+	// Let other instances see which session this one saves to
+	workdir, _ := os.Getwd()
+	r.instance, _ = instance.Register(instance.DefaultDir(), instance.Instance{
+		Command:  "repl",
+		Workdir:  workdir,
+		Session:  r.session.ID,
+		Model:    r.session.Provider + "/" + r.model,
+		ReadOnly: r.readOnly,
+	})
+	defer r.instance.Close()
 
 	// Print welcome message
 	r.printWelcome()
 
+	// Read lines in the background, so a signal isn't stuck behind stdin
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				readErr <- err
+				return
+			}
+			lines <- line
+		}
+	}()
+
 	// Main REPL loop
-	reader := bufio.NewReader(os.Stdin)
 	for r.running {
 		// Print prompt
 		r.printPrompt()
 
 		// Read user input
-		input, err := reader.ReadString('\n')
-		if err != nil {
-			if err.Error() == "EOF" {
-				fmt.Println("\nSession ended. Goodbye!")
-				break
+		var line string
+		select {
+		case line = <-lines:
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
 			}
 			return fmt.Errorf("failed to read input: %w", err)
+		case <-ctx.Done():
+			return nil
 		}
 
-		input = strings.TrimSpace(input)
-		if input == "" {
+		line = strings.TrimSpace(line)
+		if line == "" {
 			continue
 		}
 
 		// Handle special commands
-		if r.handleCommand(input) {
-			continue
+		if r.handleCommand(line) {
+			if r.resend == "" {
+				continue
+			}
+			line, r.resend = r.resend, ""
+		}
+
+		// Commands embedded as !`command` run once confirmed
+		if commands := input.Substitutions(line); len(commands) > 0 {
+			if command, ok := r.writingCommand(commands); ok {
+				color.Yellow("%s", i18n.T("msg.read_only_refused", command))
+				continue
+			}
+			fmt.Printf("%s [y/N] ", i18n.T("msg.confirm_substitution", "$ "+strings.Join(commands, "\n$ ")))
+			select {
+			case answer := <-lines:
+				if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+					fmt.Println(i18n.T("msg.not_sent"))
+					continue
+				}
+			case <-ctx.Done():
+				return nil
+			}
+			for _, command := range commands {
+				if input.Writes(command) {
+					r.agent.MarkChanged()
+				}
+			}
+			line = input.ExpandSubstitutions(ctx, line)
+		}
+
+		// Files mentioned as @path go along with the message
+		line, mentions := input.ExpandMentions(line)
+		for _, mn := range mentions {
+			color.New(color.FgHiBlack).Println(mn.Chip())
+			if !mn.Dir {
+				r.agent.TrackFile(mn.Path)
+			}
+		}
+
+		// Pasted URLs are fetched into context, once confirmed unless set to auto
+		if urls := input.URLs(line); len(urls) > 0 && r.config.Tools.URLMode() != fetch.Off {
+			fetchThem := true
+			if r.config.Tools.URLMode() == fetch.Ask {
+				fmt.Printf("%s [y/N] ", i18n.T("msg.confirm_fetch", strings.Join(urls, "\n")))
+				select {
+				case answer := <-lines:
+					fetchThem = strings.EqualFold(strings.TrimSpace(answer), "y")
+				case <-ctx.Done():
+					return nil
+				}
+			}
+			if fetchThem {
+				for _, chip := range fetch.AddToContext(ctx, r.agent, urls) {
+					color.New(color.FgHiBlack).Println(chip)
+				}
+			}
 		}
 
 		// Process the input with the agent
-		if err := r.processInput(ctx, input); err != nil {
-			color.Red("Error: %v", err)
+		if err := r.processInput(ctx, line); err != nil && ctx.Err() == nil {
+			color.Red("%s", i18n.T("msg.error", err))
 		}
 
 		// Auto-save session after each exchange
@@ -185,26 +338,34 @@ func (r *REPL) printWelcome() {
 	cyan := color.New(color.FgCyan, color.Bold)
 	gray := color.New(color.FgHiBlack)
 
-	fmt.Println()
-	cyan.Println("╭─────────────────────────────────────────────────────────────╮")
-	cyan.Println("│                    AgentFlow v0.1.0                         │")
-	cyan.Println("│           Superpowers for everyone 🚀                       │")
-	cyan.Println("╰─────────────────────────────────────────────────────────────╯")
-	fmt.Println()
+	if r.plain {
+		fmt.Println("AgentFlow v0.1.0")
+	} else {
+		fmt.Println()
+		cyan.Println("╭─────────────────────────────────────────────────────────────╮")
+		cyan.Println("│                    AgentFlow v0.1.0                         │")
+		cyan.Println("│           Superpowers for everyone 🚀                       │")
+		cyan.Println("╰─────────────────────────────────────────────────────────────╯")
+		fmt.Println()
+	}
 
-	gray.Printf("Provider: %s | Model: %s\n", r.session.Provider, r.model)
+	gray.Printf("%s: %s | %s: %s\n", i18n.T("status.provider"), r.session.Provider, i18n.T("status.model"), r.model)
 
 	// Show session info
 	if r.session != nil {
 		if len(r.session.Messages) > 0 {
 			yellow := color.New(color.FgYellow)
-			yellow.Printf("Resumed session: %s (%d messages)\n", r.session.ID, len(r.session.Messages))
+			yellow.Println(i18n.T("msg.resumed", r.session.ID, len(r.session.Messages)))
 		} else {
 			gray.Printf("Session: %s\n", r.session.ID)
 		}
 	}
 
-	gray.Println("Type /help for commands, /quit to exit")
+	if r.notice != "" {
+		color.Yellow("%s", r.notice)
+	}
+
+	gray.Println(i18n.T("msg.welcome_hint"))
 	fmt.Println()
 }
 
@@ -225,7 +386,6 @@ func (r *REPL) handleCommand(input string) bool {
 
 	switch cmd {
 	case "/quit", "/exit", "/q":
-		fmt.Println("Session ended. Goodbye!")
 		r.running = false
 		return true
 
@@ -237,7 +397,7 @@ func (r *REPL) handleCommand(input string) bool {
 		r.agent.ClearHistory()
 		r.session.Messages = nil
 		r.autoSaveSession()
-		fmt.Println("Conversation cleared.")
+		fmt.Println(i18n.T("msg.cleared"))
 		return true
 
 	case "/skills":
@@ -248,7 +408,7 @@ func (r *REPL) handleCommand(input string) bool {
 		if len(parts) > 1 {
 			r.changeModel(parts[1])
 		} else {
-			fmt.Printf("Current model: %s\n", r.model)
+			fmt.Println(i18n.T("msg.model_current", r.model))
 		}
 		return true
 
@@ -256,6 +416,10 @@ func (r *REPL) handleCommand(input string) bool {
 		r.printHistory()
 		return true
 
+	case "/less":
+		r.page(len(parts) > 1 && parts[1] == "all")
+		return true
+
 	case "/compact":
 		fmt.Println("Compacting conversation history...")
 		// TODO: Implement conversation compaction
@@ -290,8 +454,41 @@ func (r *REPL) handleCommand(input string) bool {
 		r.saveSession()
 		return true
 
+	case "/retry":
+		r.rewind(parts[1:], "")
+		return true
+
+	case "/execute":
+		wd, _ := os.Getwd()
+		prompt, err := r.agent.ExecutePlan(wd)
+		if err != nil {
+			color.Red("%s", i18n.T("msg.error", err))
+			return true
+		}
+		color.Green("%s", i18n.T("msg.executing_plan"))
+		r.resend = prompt
+		return true
+
+	case "/edit-last":
+		text := strings.TrimSpace(input[len(parts[0]):])
+		if text == "" {
+			if prompt, _, ok := r.agent.LastExchange(); ok {
+				fmt.Println(prompt)
+			}
+			color.Yellow("Usage: /edit-last <new message> replaces the last message and resends it.")
+			return true
+		}
+		r.rewind(nil, text)
+		return true
+
 	default:
-		color.Yellow("Unknown command: %s (type /help for available commands)", cmd)
+		if r.onCommand != nil {
+			if out, ok := r.onCommand(cmd, parts[1:]); ok {
+				fmt.Println(out)
+				return true
+			}
+		}
+		color.Yellow("%s", i18n.T("msg.unknown_command", cmd))
 		return true
 	}
 }
@@ -302,25 +499,63 @@ func (r *REPL) printHelp() {
 	gray := color.New(color.FgHiBlack)
 
 	fmt.Println()
-	cyan.Println("Available Commands:")
+	cyan.Println(i18n.T("help.commands") + ":")
 	fmt.Println()
-	fmt.Println("  /help, /h        Show this help message")
-	fmt.Println("  /quit, /exit, /q Exit the session")
-	fmt.Println("  /clear           Clear conversation history")
-	fmt.Println("  /skills          List available skills")
-	fmt.Println("  /model [name]    Show or change current model")
-	fmt.Println("  /history         Show conversation history")
-	fmt.Println("  /compact         Compact conversation to save context")
+	rows := [][2]string{
+		{"/help, /h", i18n.T("help.help")},
+		{"/quit, /exit, /q", i18n.T("help.quit")},
+		{"/clear", i18n.T("help.clear")},
+		{"/skills", i18n.T("help.skills")},
+		{"/model [name]", i18n.T("help.model")},
+		{"/history", i18n.T("help.history")},
+		{"/less [all]", i18n.T("help.less")},
+		{"/compact", i18n.T("help.compact")},
+	}
+	if r.onCommand != nil {
+		rows = append(rows,
+			[2]string{"/template [name]", i18n.T("help.template")},
+			[2]string{"/examples on|off", i18n.T("help.examples")},
+			[2]string{"/pin [file|n]", i18n.T("help.pin")},
+			[2]string{"/unpin <file|n>", i18n.T("help.unpin")},
+			[2]string{"/refresh [file]", i18n.T("help.refresh")},
+			[2]string{"/map", i18n.T("help.map")},
+			[2]string{"/swap", i18n.T("help.swap")},
+			[2]string{"/retry [model] [temp]", i18n.T("help.retry")},
+			[2]string{"/edit-last <text>", i18n.T("help.edit_last")},
+			[2]string{"/system show|set|append", i18n.T("help.system")},
+			[2]string{"/set <param> <value>", i18n.T("help.set")},
+			[2]string{"/settings", i18n.T("help.settings")},
+			[2]string{"/style [name]", i18n.T("help.style")},
+			[2]string{"/concise, /verbose", i18n.T("help.concise")},
+			[2]string{"/plan [on|off]", i18n.T("help.plan")},
+			[2]string{"/execute", i18n.T("help.execute")},
+			[2]string{"/show [n]", i18n.T("help.show")},
+			[2]string{"/copy-msg [n]", i18n.T("help.copy_msg")},
+			[2]string{"/artifacts", i18n.T("help.artifacts")},
+			[2]string{"/who", i18n.T("help.who")},
+			[2]string{"/context save|load", i18n.T("help.context")},
+			[2]string{"/preview [message]", i18n.T("help.preview")},
+			[2]string{"/tokens [text]", i18n.T("help.tokens")},
+			[2]string{"/skill use|off|auto", i18n.T("help.skill")},
+			[2]string{"/good|/bad [note]", i18n.T("help.feedback")})
+	}
+	for _, row := range rows {
+		fmt.Printf("  %-16s %s\n", row[0], row[1])
+	}
 	fmt.Println()
-	cyan.Println("Session Commands:")
+	cyan.Println(i18n.T("help.session_commands") + ":")
 	fmt.Println()
-	fmt.Println("  /sessions        List recent sessions")
-	fmt.Println("  /session         Show current session info")
-	fmt.Println("  /resume [id]     Resume a session (picker if no id)")
-	fmt.Println("  /rename <name>   Rename current session")
-	fmt.Println("  /save            Force save current session")
+	for _, row := range [][2]string{
+		{"/sessions", i18n.T("help.sessions")},
+		{"/session", i18n.T("help.session")},
+		{"/resume [id]", i18n.T("help.resume")},
+		{"/rename <name>", i18n.T("help.rename")},
+		{"/save", i18n.T("help.save")},
+	} {
+		fmt.Printf("  %-16s %s\n", row[0], row[1])
+	}
 	fmt.Println()
-	gray.Println("  Tip: Just type naturally to start working!")
+	gray.Println("  " + i18n.T("help.tip"))
 	fmt.Println()
 }
 
@@ -345,27 +580,62 @@ func (r *REPL) listSkills() {
 func (r *REPL) printHistory() {
 	messages := r.agent.Messages()
 	if len(messages) == 0 {
-		fmt.Println("No conversation history.")
+		fmt.Println(i18n.T("msg.no_history"))
 		return
 	}
 
 	fmt.Println()
 	for _, msg := range messages {
 		if msg.Role == "user" {
-			color.Green("You: %s", truncate(msg.Content, 100))
+			color.Green("%s: %s", i18n.T("ui.you"), truncate(msg.Content, 100))
 		} else if msg.Role == "assistant" {
-			color.Cyan("Agent: %s", truncate(msg.Content, 100))
+			color.Cyan("%s: %s", i18n.T("ui.agent"), truncate(msg.Content, 100))
 		}
 	}
 	fmt.Println()
 }
 
+// page shows the last answer, or with all the conversation, in $PAGER
+func (r *REPL) page(all bool) {
+	var sb strings.Builder
+	for _, msg := range r.agent.Messages() {
+		shown := msg.Role == "assistant" || all && msg.Role == "user"
+		if !shown || msg.Content == "" {
+			continue
+		}
+		if !all {
+			sb.Reset()
+		}
+		if msg.Role == "user" {
+			sb.WriteString(color.GreenString("%s:", i18n.T("ui.you")))
+		} else {
+			sb.WriteString(color.CyanString("%s:", i18n.T("ui.agent")))
+		}
+		sb.WriteString("\n" + msg.Content + "\n\n")
+	}
+	if sb.Len() == 0 {
+		fmt.Println(i18n.T("msg.nothing_to_page"))
+		return
+	}
+
+	cmd := pager.Command(sb.String())
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		color.Red("%s", i18n.T("msg.error", err))
+	}
+}
+
 // processInput processes user input and generates a response
 func (r *REPL) processInput(ctx context.Context, input string) error {
-	// Match skill
-	matchedSkills := r.skills.Match(input)
-	if len(matchedSkills) > 0 {
-		color.HiBlack("\n[Skill: %s]\n", matchedSkills[0].Name)
+	if acts := r.agent.ActivateSkills(input); len(acts) > 0 {
+		names := make([]string, len(acts))
+		for i, act := range acts {
+			names[i] = act.String()
+			if r.session != nil {
+				r.session.RecordSkill(act.Skill, act.Reason)
+			}
+		}
+		color.HiBlack("\n[Skill: %s]\n", strings.Join(names, " + "))
 	}
 
 	// Generate response with streaming
@@ -386,8 +656,23 @@ func (r *REPL) processInput(ctx context.Context, input string) error {
 		if chunk.Error != nil {
 			return chunk.Error
 		}
+		if chunk.Notice != "" {
+			color.HiBlack("%s\n", chunk.Notice)
+			continue
+		}
+		if chunk.Replace {
+			// The terminal can't take back the streamed answer; print the
+			// one replacing it below
+			fmt.Println()
+			fullResponse.Reset()
+		}
 		fmt.Print(chunk.Content)
 		fullResponse.WriteString(chunk.Content)
+		if !chunk.Done {
+			for _, call := range chunk.ToolCalls {
+				color.HiBlack("\n🔧 %s", tool.Describe(call))
+			}
+		}
 	}
 	fmt.Println()
 	fmt.Println()
@@ -402,21 +687,78 @@ func (r *REPL) changeModel(modelSpec string) {
 		color.Red("Unknown model: %s", modelSpec)
 		return
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := provider.CheckModel(ctx, prov, model); err != nil {
+		color.Red("%s", i18n.T("msg.error", err))
+		return
+	}
 
+	// The history stays with the agent
 	r.provider = prov
 	r.model = model
-	r.agent = agent.New(agent.Config{
-		Provider: prov,
-		Model:    model,
-		Skills:   r.skills,
+	r.agent.SetModel(prov, model)
+	r.session.Provider, r.session.Model = prov.Name(), model
+	r.instance.Update(func(inst *instance.Instance) {
+		inst.Model = prov.Name() + "/" + model
 	})
 
-	// Restore messages
-	for _, msg := range r.session.Messages {
-		r.agent.AddMessage(msg.Role, msg.Content)
+	fmt.Println(i18n.T("msg.model_changed", model))
+}
+
+// rewind takes back the last exchange and queues its message to be sent
+// again, to the model or at the temperature in args, or text instead
+func (r *REPL) rewind(args []string, text string) {
+	retry, err := agent.ParseRetry(args, r.registry.ResolveModel)
+	if err != nil {
+		color.Red("%s", i18n.T("msg.error", err))
+		return
+	}
+	prompt, ok := r.agent.Rewind()
+	if !ok {
+		color.Red("No message to take back yet.")
+		return
+	}
+	r.agent.RetryWith(retry)
+	if len(args) > 0 {
+		fmt.Println(i18n.T("msg.retrying", strings.Join(args, " ")))
 	}
+	if text != "" {
+		prompt = text
+	}
+	r.resend = prompt
+}
 
-	fmt.Printf("Model changed to: %s\n", model)
+// restoreSession adds a session's messages and activity to the agent,
+// with the system prompt, parameters and style it was given with
+// /system, /set and /style
+func restoreSession(cfg *config.Config, ag *agent.Agent, sess *session.Session) {
+	if prompt := sess.SystemPrompt(); prompt != "" {
+		ag.SetSystemPrompt(prompt)
+	}
+	params := sess.Params()
+	if params.Seed == nil {
+		params.Seed = ag.Params().Seed // From --seed or the config
+	}
+	ag.SetParams(params)
+	if style, ok := cfg.Style(sess.Style()); ok {
+		ag.SetStyle(style)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		ag.SetArtifacts(artifact.Open(wd, sess.ID))
+	}
+	log, err := cfg.AuditLog(sess.ID)
+	if err != nil {
+		color.Yellow("Warning: %v", err)
+	}
+	ag.SetAudit(log)
+	for _, msg := range sess.Messages {
+		if msg.Role == "system" && msg.Content == ag.SystemPrompt() {
+			continue // Already there
+		}
+		ag.AddMessage(msg.Role, msg.Content)
+	}
+	ag.SetActivity(sess.Activity())
 }
 
 // truncate truncates a string to maxLen characters
@@ -436,7 +778,7 @@ func (r *REPL) listSessions() {
 	}
 
 	if len(sessions) == 0 {
-		fmt.Println("No saved sessions.")
+		fmt.Println(i18n.T("msg.no_sessions"))
 		return
 	}
 
@@ -517,27 +859,34 @@ func (r *REPL) resumeSession(idOrName string) {
 		return
 	}
 
+	sess, r.readOnly, r.notice = instance.Claim(instance.DefaultDir(), sess, r.shared)
 	r.session = sess
+	r.instance.Update(func(inst *instance.Instance) {
+		inst.Session = sess.ID
+		inst.ReadOnly = r.readOnly
+	})
 
 	// Restore to agent
 	r.agent.ClearHistory()
-	for _, msg := range sess.Messages {
-		r.agent.AddMessage(msg.Role, msg.Content)
-	}
+	r.agent.SetSystemPrompt(r.config.Language.AnswerInstruction())
+	restoreSession(r.config, r.agent, sess)
 
 	color.Green("Resumed session %s (%d messages)", sess.ID, len(sess.Messages))
+	if r.notice != "" {
+		color.Yellow("%s", r.notice)
+	}
 }
 
 // showSessionPicker shows an interactive session picker
 func (r *REPL) showSessionPicker() {
 	sessions, err := r.sessionManager.List()
 	if err != nil {
-		color.Red("Error: %v", err)
+		color.Red("%s", i18n.T("msg.error", err))
 		return
 	}
 
 	if len(sessions) == 0 {
-		fmt.Println("No saved sessions.")
+		fmt.Println(i18n.T("msg.no_sessions"))
 		return
 	}
 
@@ -599,6 +948,10 @@ func (r *REPL) saveSession() {
 		color.Red("No active session.")
 		return
 	}
+	if r.readOnly {
+		color.Red("Session %s is read-only here: another agentflow saves it.", r.session.ID)
+		return
+	}
 
 	if err := r.sessionManager.Save(r.session); err != nil {
 		color.Red("Error saving session: %v", err)
@@ -608,9 +961,21 @@ func (r *REPL) saveSession() {
 	color.Green("Session saved: %s", r.session.ID)
 }
 
+// shutdown saves the session, including an exchange cut short, and says
+// where it went
+func (r *REPL) shutdown() {
+	r.running = false
+	fmt.Println("\n" + i18n.T("msg.goodbye"))
+	if !r.autoSave || r.readOnly || r.session == nil || len(r.agent.Messages()) == 0 {
+		return
+	}
+	r.autoSaveSession()
+	color.HiBlack("%s", i18n.T("msg.session_saved_to", r.sessionManager.Path(r.session.ID)))
+}
+
 // autoSaveSession saves after each exchange
 func (r *REPL) autoSaveSession() {
-	if !r.autoSave || r.session == nil {
+	if !r.autoSave || r.readOnly || r.session == nil {
 		return
 	}
 
@@ -620,6 +985,19 @@ func (r *REPL) autoSaveSession() {
 		r.session.Messages = append(r.session.Messages, msg)
 	}
 	r.session.UpdatedAt = r.session.LastActivity()
+	r.session.SetActivity(r.agent.Activity())
+
+	// Keep a prompt changed with /system, and parameters and style
+	// changed with /set and /style, for when the session is resumed
+	r.session.SetParams(r.agent.Params())
+	r.session.SetStyle(r.agent.Style().Name)
+	if prompt := r.agent.SystemPrompt(); prompt != r.config.Language.AnswerInstruction() {
+		r.session.SetSystemPrompt(prompt)
+	} else {
+		r.session.SetSystemPrompt("")
+	}
 
-	r.sessionManager.Save(r.session)
+	if err := r.sessionManager.Save(r.session); errors.Is(err, session.ErrConflict) {
+		color.Red("%s", i18n.T("msg.error", err))
+	}
 }
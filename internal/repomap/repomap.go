@@ -0,0 +1,411 @@
+// Package repomap builds a condensed project map: the directory tree with
+// a one-line description of each file (size, language and, for Go, its
+// exported symbols). Maps are cached and rebuilt incrementally.
+package repomap
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/watch"
+)
+
+// SmallRepoFiles is the size below which a map is worth adding to
+// context automatically at session start
+const SmallRepoFiles = 150
+
+// maxSymbols caps the symbols listed per Go file
+const maxSymbols = 8
+
+// File describes one file of the project
+type File struct {
+	Path    string    `json:"path"` // slash-separated, relative to the root
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Lang    string    `json:"lang,omitempty"`
+	Summary string    `json:"summary,omitempty"`
+}
+
+// Map is a scanned project
+type Map struct {
+	Root  string `json:"root"`
+	Files []File `json:"files"`
+}
+
+// Build scans root, reusing the descriptions in prev (which may be nil)
+// for files whose size and modification time are unchanged
+func Build(root string, prev *Map) (*Map, error) {
+	cached := make(map[string]File)
+	if prev != nil {
+		for _, f := range prev.Files {
+			cached[f.Path] = f
+		}
+	}
+
+	m := &Map{Root: root}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Files can vanish mid-walk
+		}
+		if d.IsDir() {
+			if path != root && watch.SkipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if old, ok := cached[rel]; ok && old.Size == info.Size() && old.ModTime.Equal(info.ModTime()) {
+			m.Files = append(m.Files, old)
+			return nil
+		}
+		m.Files = append(m.Files, describe(path, rel, info))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan %s: %w", root, err)
+	}
+
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	return m, nil
+}
+
+// IsSmall reports whether root is a project (a git or Go module root)
+// with at most SmallRepoFiles files. It stops counting early, so it is
+// cheap to call on large trees.
+func IsSmall(root string) bool {
+	if !exists(filepath.Join(root, ".git")) && !exists(filepath.Join(root, "go.mod")) {
+		return false
+	}
+
+	count := 0
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && watch.SkipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if count++; count > SmallRepoFiles {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return count <= SmallRepoFiles
+}
+
+// exists reports whether path exists
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Update builds the map for root using its cache, then saves the cache
+func Update(root string) (*Map, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := CachePath(abs)
+	prev, _ := Load(cache) // A missing or corrupt cache means a full scan
+	m, err := Build(abs, prev)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Save(cache); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CachePath returns where the map of root is cached
+func CachePath(root string) string {
+	home, _ := os.UserHomeDir()
+	sum := sha1.Sum([]byte(root))
+	return filepath.Join(home, ".agentflow", "cache", "map-"+hex.EncodeToString(sum[:6])+".json")
+}
+
+// Load reads a cached map
+func Load(path string) (*Map, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Map
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse map cache: %w", err)
+	}
+	return &m, nil
+}
+
+// Save writes the map to path
+func (m *Map) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("marshal map: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write map cache: %w", err)
+	}
+	return nil
+}
+
+// Size returns the total size of the files
+func (m *Map) Size() int64 {
+	var total int64
+	for _, f := range m.Files {
+		total += f.Size
+	}
+	return total
+}
+
+// String renders the map as an indented tree, one line per file
+func (m *Map) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s/ (%d files, %s)\n", filepath.Base(m.Root), len(m.Files), formatSize(m.Size()))
+
+	var dirs []string // directories of the previous file
+	for _, f := range m.Files {
+		parts := strings.Split(f.Path, "/")
+		fileDirs := parts[:len(parts)-1]
+
+		// Print the directories this file enters
+		common := 0
+		for common < len(dirs) && common < len(fileDirs) && dirs[common] == fileDirs[common] {
+			common++
+		}
+		for i := common; i < len(fileDirs); i++ {
+			fmt.Fprintf(&sb, "%s%s/\n", strings.Repeat("  ", i+1), fileDirs[i])
+		}
+		dirs = fileDirs
+
+		fmt.Fprintf(&sb, "%s%s  %s", strings.Repeat("  ", len(fileDirs)+1), parts[len(parts)-1], formatSize(f.Size))
+		if f.Lang != "" {
+			sb.WriteString(" " + f.Lang)
+		}
+		if f.Summary != "" {
+			sb.WriteString(" — " + f.Summary)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// manifests are the files that say what a project is and depends on
+var manifests = map[string]bool{
+	"go.mod": true, "package.json": true, "Cargo.toml": true, "pyproject.toml": true,
+	"setup.py": true, "pom.xml": true, "build.gradle": true, "Gemfile": true, "Makefile": true,
+}
+
+// KeyFiles returns the files most worth reading to understand the
+// project, most telling first: READMEs, manifests, entry points and
+// files named after their directory, then other source shallowest first,
+// then tests. Files without a known language come last.
+func (m *Map) KeyFiles() []File {
+	rank := func(f File) int {
+		name := filepath.Base(f.Path)
+		stem := strings.TrimSuffix(name, filepath.Ext(name))
+		dir := filepath.Base(filepath.Dir(filepath.FromSlash(f.Path)))
+		if dir == "." {
+			dir = filepath.Base(m.Root)
+		}
+		switch {
+		case strings.EqualFold(stem, "readme"):
+			return 0
+		case manifests[name]:
+			return 1
+		case stem == "main" || stem == "doc" || stem == "index" || stem == dir:
+			return 2
+		case strings.Contains(stem, "test") || strings.Contains(stem, "spec"):
+			return 4
+		case f.Lang != "" && f.Lang != "Go checksums":
+			return 3
+		}
+		return 5
+	}
+
+	files := append([]File(nil), m.Files...)
+	sort.SliceStable(files, func(i, j int) bool {
+		ri, rj := rank(files[i]), rank(files[j])
+		if ri != rj {
+			return ri < rj
+		}
+		di, dj := strings.Count(files[i].Path, "/"), strings.Count(files[j].Path, "/")
+		if di != dj {
+			return di < dj
+		}
+		return files[i].Size < files[j].Size
+	})
+	return files
+}
+
+// languages maps file extensions to language names
+var languages = map[string]string{
+	".go": "Go", ".py": "Python", ".js": "JavaScript", ".ts": "TypeScript", ".tsx": "TypeScript",
+	".jsx": "JavaScript", ".rs": "Rust", ".java": "Java", ".kt": "Kotlin", ".rb": "Ruby",
+	".c": "C", ".h": "C", ".cpp": "C++", ".cc": "C++", ".cs": "C#", ".swift": "Swift",
+	".php": "PHP", ".sh": "Shell", ".md": "Markdown", ".yaml": "YAML", ".yml": "YAML",
+	".json": "JSON", ".toml": "TOML", ".html": "HTML", ".css": "CSS", ".sql": "SQL",
+	".proto": "Protobuf", ".mod": "Go module", ".sum": "Go checksums",
+}
+
+// describe builds the entry for a file that isn't cached
+func describe(path, rel string, info fs.FileInfo) File {
+	f := File{
+		Path:    rel,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Lang:    languages[strings.ToLower(filepath.Ext(path))],
+	}
+	if name := filepath.Base(path); f.Lang == "" && (name == "Makefile" || name == "Dockerfile") {
+		f.Lang = name
+	}
+
+	// Don't parse huge files; generated code rarely needs describing
+	if info.Size() > 1<<20 {
+		return f
+	}
+	switch f.Lang {
+	case "Go":
+		f.Summary = goSummary(path)
+	case "Markdown":
+		f.Summary = markdownTitle(path)
+	}
+	return f
+}
+
+// goSummary lists the package and exported symbols of a Go file, or the
+// number of tests for a test file
+func goSummary(path string) string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return ""
+	}
+
+	// Types and functions say the most about a file; methods come last
+	var types, funcs, values, methods []string
+	tests := 0
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if strings.HasPrefix(d.Name.Name, "Test") && d.Recv == nil {
+				tests++
+				continue
+			}
+			if !d.Name.IsExported() {
+				continue
+			}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				if recv := receiverName(d.Recv.List[0].Type); recv != "" && ast.IsExported(recv) {
+					methods = append(methods, recv+"."+d.Name.Name)
+				}
+				continue
+			}
+			funcs = append(funcs, d.Name.Name+"()")
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						types = append(types, "type "+s.Name.Name)
+					}
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.IsExported() {
+							values = append(values, name.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	summary := "package " + file.Name.Name
+	if strings.HasSuffix(path, "_test.go") {
+		if tests == 1 {
+			return summary + ": 1 test"
+		}
+		return fmt.Sprintf("%s: %d tests", summary, tests)
+	}
+
+	symbols := append(append(append(types, funcs...), values...), methods...)
+	if len(symbols) > maxSymbols {
+		symbols = append(symbols[:maxSymbols], fmt.Sprintf("+%d more", len(symbols)-maxSymbols))
+	}
+	if len(symbols) > 0 {
+		summary += ": " + strings.Join(symbols, ", ")
+	}
+	return summary
+}
+
+// receiverName returns the type name of a method receiver
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	}
+	return ""
+}
+
+// markdownTitle returns the first heading of a Markdown file
+func markdownTitle(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "#") {
+			return strings.TrimSpace(strings.TrimLeft(line, "#"))
+		}
+	}
+	return ""
+}
+
+// formatSize renders a byte count compactly
+func formatSize(n int64) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	}
+}
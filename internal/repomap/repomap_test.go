@@ -0,0 +1,150 @@
+package repomap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "README.md"), "# Demo project\n\nText")
+	writeFile(t, filepath.Join(root, "pkg", "api", "api.go"), `package api
+
+type Client struct{}
+
+func (c *Client) Do() {}
+
+func New() *Client { return nil }
+
+func helper() {}
+
+const Version = "1"
+`)
+	writeFile(t, filepath.Join(root, "pkg", "api", "api_test.go"), "package api\n\nimport \"testing\"\n\nfunc TestNew(t *testing.T) {}\n")
+	writeFile(t, filepath.Join(root, ".git", "HEAD"), "ref")
+	writeFile(t, filepath.Join(root, "node_modules", "x.js"), "")
+
+	m, err := Build(root, nil)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if len(m.Files) != 3 {
+		t.Fatalf("got %d files, want 3: %+v", len(m.Files), m.Files)
+	}
+
+	out := m.String()
+	for _, want := range []string{
+		"README.md  20 B Markdown — Demo project",
+		"  pkg/\n    api/\n      api.go",
+		"package api: type Client, New(), Version, Client.Do",
+		"api_test.go  ",
+		"package api: 1 test",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("map missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "helper") {
+		t.Error("unexported symbols should not be listed")
+	}
+}
+
+func TestBuild_Incremental(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "main.go")
+	writeFile(t, path, "package main\n\nfunc Run() {}\n")
+
+	first, err := Build(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A cached summary is reused while size and mtime match
+	first.Files[0].Summary = "cached"
+	second, _ := Build(root, first)
+	if second.Files[0].Summary != "cached" {
+		t.Errorf("unchanged file was re-parsed: %q", second.Files[0].Summary)
+	}
+
+	writeFile(t, path, "package main\n\nfunc Start() {}\n")
+	os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	third, _ := Build(root, second)
+	if third.Files[0].Summary != "package main: Start()" {
+		t.Errorf("changed file not re-described: %q", third.Files[0].Summary)
+	}
+}
+
+func TestUpdate_Cache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "hello")
+
+	m, err := Update(root)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	cached, err := Load(CachePath(m.Root))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cached.Files) != 1 || cached.Files[0].Path != "a.txt" {
+		t.Errorf("cached map = %+v", cached)
+	}
+}
+
+func TestIsSmall(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "")
+	if IsSmall(root) {
+		t.Error("a directory that isn't a project should not be mapped")
+	}
+
+	writeFile(t, filepath.Join(root, "go.mod"), "module x")
+	if !IsSmall(root) {
+		t.Error("expected a small project")
+	}
+
+	for i := 0; i <= SmallRepoFiles; i++ {
+		writeFile(t, filepath.Join(root, "gen", fmt.Sprintf("f%d.go", i)), "")
+	}
+	if IsSmall(root) {
+		t.Error("expected a large project")
+	}
+}
+
+func TestKeyFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "agent", "agent_test.go"), "package agent")
+	writeFile(t, filepath.Join(root, "agent", "tools.go"), "package agent")
+	writeFile(t, filepath.Join(root, "agent", "agent.go"), "package agent")
+	writeFile(t, filepath.Join(root, "notes.txt"), "")
+	writeFile(t, filepath.Join(root, "go.mod"), "module x")
+	writeFile(t, filepath.Join(root, "README.md"), "# X")
+
+	m, err := Build(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range m.KeyFiles() {
+		got = append(got, f.Path)
+	}
+	want := "README.md go.mod agent/agent.go agent/tools.go agent/agent_test.go notes.txt"
+	if strings.Join(got, " ") != want {
+		t.Errorf("KeyFiles = %v, want %s", got, want)
+	}
+}
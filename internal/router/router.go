@@ -0,0 +1,260 @@
+// Package router provides the "auto" provider, which classifies each
+// request with a cheap model and sends it to the model of the matching
+// tier
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// Name is the provider name of the router, used as the model spec "auto"
+const Name = "auto"
+
+// Tasks requests are classified as
+const (
+	TaskQA        = "qa"        // Short questions and explanations
+	TaskCode      = "code"      // Writing or changing code
+	TaskSummarize = "summarize" // Condensing text, logs or diffs
+)
+
+// Tiers models are configured in
+const (
+	TierFast   = "fast"
+	TierCheap  = "cheap"
+	TierStrong = "strong"
+)
+
+// DefaultRoutes maps each task to a tier when the config doesn't
+var DefaultRoutes = map[string]string{
+	TaskQA:        TierFast,
+	TaskCode:      TierStrong,
+	TaskSummarize: TierCheap,
+}
+
+// fallback is the order tiers are tried in when a route's tier has no
+// model
+var fallback = []string{TierStrong, TierCheap, TierFast}
+
+// Config sets up a router
+type Config struct {
+	Classifier string            // "provider/model" classifying requests; the fast tier when empty
+	Tiers      map[string]string // Tier → "provider/model"
+	Routes     map[string]string // Task → tier, over DefaultRoutes
+}
+
+// Resolver finds the provider and model of a "provider/model" spec
+type Resolver func(spec string) (provider.Provider, string, bool)
+
+// Decision records where one request was sent and why
+type Decision struct {
+	Time      time.Time
+	Task      string
+	Tier      string
+	Model     string        // "provider/model"
+	Heuristic bool          // The classifier failed and keywords decided
+	Classify  time.Duration // Time spent classifying
+}
+
+// MaxDecisions is how many recent decisions Recent keeps
+const MaxDecisions = 20
+
+var (
+	decisionsMu sync.Mutex
+	decisions   []Decision
+)
+
+// Recent returns the latest routing decisions, oldest first
+func Recent() []Decision {
+	decisionsMu.Lock()
+	defer decisionsMu.Unlock()
+	return append([]Decision(nil), decisions...)
+}
+
+func record(d Decision) {
+	decisionsMu.Lock()
+	defer decisionsMu.Unlock()
+	decisions = append(decisions, d)
+	if len(decisions) > MaxDecisions {
+		decisions = decisions[len(decisions)-MaxDecisions:]
+	}
+}
+
+// Router is a provider that picks the model for each request
+type Router struct {
+	cfg     Config
+	resolve Resolver
+
+	mu   sync.Mutex
+	last Decision // Tool rounds continue the task they belong to
+}
+
+// New creates a router resolving tier models with resolve
+func New(cfg Config, resolve Resolver) *Router {
+	return &Router{cfg: cfg, resolve: resolve}
+}
+
+func (r *Router) Name() string {
+	return Name
+}
+
+func (r *Router) Models() []string {
+	return nil
+}
+
+// SupportsModel is true for any model: the router picks it
+func (r *Router) SupportsModel(model string) bool {
+	return true
+}
+
+func (r *Router) Complete(ctx context.Context, req types.CompletionRequest) (*types.CompletionResponse, error) {
+	p, req, err := r.route(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.Complete(ctx, req)
+}
+
+func (r *Router) Stream(ctx context.Context, req types.CompletionRequest) (<-chan types.StreamChunk, error) {
+	p, req, err := r.route(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.Stream(ctx, req)
+}
+
+// route decides the task of a request and returns the provider and the
+// request for its tier's model
+func (r *Router) route(ctx context.Context, req types.CompletionRequest) (provider.Provider, types.CompletionRequest, error) {
+	r.mu.Lock()
+	last := r.last
+	r.mu.Unlock()
+
+	var d Decision
+	if n := len(req.Messages); n > 0 && req.Messages[n-1].Role == "tool" && last.Task != "" {
+		d = last
+	} else {
+		d = r.classify(ctx, lastUserMessage(req.Messages))
+	}
+	d.Time = time.Now()
+
+	tier, spec := r.tier(d.Task)
+	if spec == "" {
+		return nil, req, fmt.Errorf("router: no model configured for any tier (set router.tiers)")
+	}
+	p, model, ok := r.resolve(spec)
+	if !ok || p.Name() == Name {
+		return nil, req, fmt.Errorf("router: unknown model %s for tier %s", spec, tier)
+	}
+	d.Tier, d.Model = tier, spec
+
+	r.mu.Lock()
+	r.last = d
+	r.mu.Unlock()
+	record(d)
+
+	req.Model = model
+	return p, req, nil
+}
+
+// tier returns the tier and model for a task, falling back to the other
+// tiers when its own has no model
+func (r *Router) tier(task string) (string, string) {
+	tier := r.cfg.Routes[task]
+	if tier == "" {
+		tier = DefaultRoutes[task]
+	}
+	if spec := r.cfg.Tiers[tier]; spec != "" {
+		return tier, spec
+	}
+	for _, t := range fallback {
+		if spec := r.cfg.Tiers[t]; spec != "" {
+			return t, spec
+		}
+	}
+	return tier, ""
+}
+
+const classifyPrompt = `Classify the user's request as exactly one of:
+qa - a question, explanation or short answer
+code - writing, changing, fixing or reviewing code
+summarize - summarizing or condensing text, logs or diffs
+Answer with the one word only.`
+
+// maxClassifyChars bounds how much of a message is sent to the classifier
+const maxClassifyChars = 2000
+
+// classify asks the classifier model for the task of a message, falling
+// back to keywords when it fails or answers something else
+func (r *Router) classify(ctx context.Context, message string) Decision {
+	start := time.Now()
+	spec := r.cfg.Classifier
+	if spec == "" {
+		spec = r.cfg.Tiers[TierFast]
+	}
+	if p, model, ok := r.resolve(spec); ok && p.Name() != Name {
+		if len(message) > maxClassifyChars {
+			message = message[:maxClassifyChars]
+		}
+		resp, err := p.Complete(ctx, types.CompletionRequest{
+			Model: model,
+			Messages: []types.Message{
+				{Role: "system", Content: classifyPrompt},
+				{Role: "user", Content: message},
+			},
+			MaxTokens: 5,
+		})
+		if err == nil {
+			if task := parseTask(resp.Content); task != "" {
+				return Decision{Task: task, Classify: time.Since(start)}
+			}
+		}
+	}
+	return Decision{Task: Heuristic(message), Heuristic: true, Classify: time.Since(start)}
+}
+
+// parseTask finds the task named in a classifier's answer
+func parseTask(answer string) string {
+	answer = strings.ToLower(answer)
+	for _, task := range []string{TaskCode, TaskSummarize, TaskQA} {
+		if strings.Contains(answer, task) {
+			return task
+		}
+	}
+	return ""
+}
+
+// Heuristic classifies a message by keywords, for when no classifier
+// answers
+func Heuristic(message string) string {
+	lower := strings.ToLower(message)
+	for _, word := range []string{"summarize", "summarise", "summary", "tl;dr", "tldr", "condense"} {
+		if strings.Contains(lower, word) {
+			return TaskSummarize
+		}
+	}
+	if strings.Contains(message, "```") {
+		return TaskCode
+	}
+	for _, word := range []string{"implement", "write a", "write the", "refactor", "fix ", "function", "add a test", "bug", "code"} {
+		if strings.Contains(lower, word) {
+			return TaskCode
+		}
+	}
+	return TaskQA
+}
+
+func lastUserMessage(messages []types.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
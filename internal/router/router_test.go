@@ -0,0 +1,129 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// fakeProvider answers with its own name, and classifies with answer
+type fakeProvider struct {
+	name   string
+	answer string
+	fail   bool
+	models []string // Models requests were sent to
+}
+
+func (p *fakeProvider) Name() string                    { return p.name }
+func (p *fakeProvider) Models() []string                { return nil }
+func (p *fakeProvider) SupportsModel(model string) bool { return true }
+func (p *fakeProvider) Stream(ctx context.Context, req types.CompletionRequest) (<-chan types.StreamChunk, error) {
+	return nil, errors.New("not used")
+}
+
+func (p *fakeProvider) Complete(ctx context.Context, req types.CompletionRequest) (*types.CompletionResponse, error) {
+	p.models = append(p.models, req.Model)
+	if p.fail {
+		return nil, errors.New("unavailable")
+	}
+	if req.Messages[0].Content == classifyPrompt {
+		return &types.CompletionResponse{Content: p.answer}, nil
+	}
+	return &types.CompletionResponse{Content: p.name + "/" + req.Model}, nil
+}
+
+func newTestRouter(classifier *fakeProvider, tiers map[string]string) *Router {
+	registry := provider.NewRegistry()
+	registry.Register(classifier)
+	registry.Register(&fakeProvider{name: "local"})
+	registry.Register(&fakeProvider{name: "remote"})
+	return New(Config{Classifier: "classifier/tiny", Tiers: tiers}, registry.ResolveModel)
+}
+
+var tiers = map[string]string{
+	TierFast:   "local/small",
+	TierStrong: "remote/large",
+}
+
+func ask(t *testing.T, r *Router, messages ...types.Message) string {
+	t.Helper()
+	resp, err := r.Complete(context.Background(), types.CompletionRequest{Messages: messages})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	return resp.Content
+}
+
+func TestRouter_RoutesByClassification(t *testing.T) {
+	classifier := &fakeProvider{name: "classifier", answer: "Code."}
+	r := newTestRouter(classifier, tiers)
+
+	if got := ask(t, r, types.Message{Role: "user", Content: "what is a goroutine?"}); got != "remote/large" {
+		t.Errorf("code went to %q", got)
+	}
+	classifier.answer = "qa"
+	if got := ask(t, r, types.Message{Role: "user", Content: "what is a goroutine?"}); got != "local/small" {
+		t.Errorf("qa went to %q", got)
+	}
+
+	// Summaries have no cheap model configured and fall back to strong
+	classifier.answer = "summarize"
+	if got := ask(t, r, types.Message{Role: "user", Content: "sum up"}); got != "remote/large" {
+		t.Errorf("summary went to %q", got)
+	}
+
+	d := Recent()[len(Recent())-1]
+	if d.Task != TaskSummarize || d.Tier != TierStrong || d.Model != "remote/large" || d.Heuristic {
+		t.Errorf("decision = %+v", d)
+	}
+}
+
+func TestRouter_ToolRoundsKeepTheirModel(t *testing.T) {
+	classifier := &fakeProvider{name: "classifier", answer: "code"}
+	r := newTestRouter(classifier, tiers)
+	ask(t, r, types.Message{Role: "user", Content: "fix the build"})
+
+	classifier.answer = "qa"
+	got := ask(t, r,
+		types.Message{Role: "user", Content: "fix the build"},
+		types.Message{Role: "assistant", ToolCalls: []types.ToolCall{{ID: "1", Name: "build"}}},
+		types.Message{Role: "tool", Content: "ok"},
+	)
+	if got != "remote/large" || len(classifier.models) != 1 {
+		t.Errorf("tool round went to %q after %d classifications", got, len(classifier.models))
+	}
+}
+
+func TestRouter_HeuristicWhenClassifierFails(t *testing.T) {
+	r := newTestRouter(&fakeProvider{name: "classifier", fail: true}, tiers)
+	if got := ask(t, r, types.Message{Role: "user", Content: "Implement a retry loop"}); got != "remote/large" {
+		t.Errorf("got %q", got)
+	}
+	if d := Recent()[len(Recent())-1]; !d.Heuristic || d.Task != TaskCode {
+		t.Errorf("decision = %+v", d)
+	}
+}
+
+func TestRouter_NoTiers(t *testing.T) {
+	r := newTestRouter(&fakeProvider{name: "classifier"}, nil)
+	if _, err := r.Complete(context.Background(), types.CompletionRequest{}); err == nil {
+		t.Error("expected an error without tiers")
+	}
+}
+
+func TestHeuristic(t *testing.T) {
+	tests := map[string]string{
+		"Can you summarize this log?":      TaskSummarize,
+		"```go\nfunc main() {}\n```\nwhy?": TaskCode,
+		"Refactor the parser":              TaskCode,
+		"What time zone is CET?":           TaskQA,
+	}
+	for message, want := range tests {
+		if got := Heuristic(message); got != want {
+			t.Errorf("Heuristic(%q) = %s, want %s", message, got, want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// SpeculativeName is the provider name of speculative dispatch, used as
+// the model spec "speculative"
+const SpeculativeName = "speculative"
+
+// Ways the strong model's answer is used once it arrives
+const (
+	SpeculativeSwap  = "swap"  // Replace the fast answer
+	SpeculativeOffer = "offer" // Keep the fast answer and offer the other with /swap
+)
+
+// DefaultSpeculativeWait is how long a finished fast answer waits for the
+// strong one
+const DefaultSpeculativeWait = 30 * time.Second
+
+// SpeculativeConfig sets up speculative dispatch
+type SpeculativeConfig struct {
+	Fast   string        // "provider/model" streamed at once, usually local
+	Strong string        // "provider/model" asked at the same time, usually remote
+	Mode   string        // SpeculativeSwap (default) or SpeculativeOffer
+	Wait   time.Duration // How long the fast answer waits for the strong one
+}
+
+// Speculative is a provider that sends each request to a fast model and
+// a strong one at once: the fast answer streams right away, and the
+// strong one replaces or is offered next to it when it arrives in time.
+// Requests offering tools go to the strong model, and to the fast one
+// when it fails, as tool calls can't be swapped after they ran.
+type Speculative struct {
+	cfg     SpeculativeConfig
+	resolve Resolver
+}
+
+// NewSpeculative creates a speculative provider resolving its models
+// with resolve
+func NewSpeculative(cfg SpeculativeConfig, resolve Resolver) *Speculative {
+	if cfg.Wait <= 0 {
+		cfg.Wait = DefaultSpeculativeWait
+	}
+	return &Speculative{cfg: cfg, resolve: resolve}
+}
+
+func (s *Speculative) Name() string {
+	return SpeculativeName
+}
+
+func (s *Speculative) Models() []string {
+	return nil
+}
+
+// SupportsModel is true for any model: the config picks them
+func (s *Speculative) SupportsModel(model string) bool {
+	return true
+}
+
+// target is one of the two models
+type target struct {
+	spec     string
+	provider provider.Provider
+	model    string
+}
+
+func (s *Speculative) targets() (target, target, error) {
+	var out [2]target
+	for i, spec := range []string{s.cfg.Fast, s.cfg.Strong} {
+		p, model, ok := s.resolve(spec)
+		if !ok || p.Name() == SpeculativeName || p.Name() == Name {
+			return target{}, target{}, fmt.Errorf("speculative: unknown model %q (set speculative.fast and speculative.strong)", spec)
+		}
+		out[i] = target{spec: spec, provider: p, model: model}
+	}
+	return out[0], out[1], nil
+}
+
+func (t target) complete(ctx context.Context, req types.CompletionRequest) (*types.CompletionResponse, error) {
+	req.Model = t.model
+	return t.provider.Complete(ctx, req)
+}
+
+func (t target) stream(ctx context.Context, req types.CompletionRequest) (<-chan types.StreamChunk, error) {
+	req.Model = t.model
+	return t.provider.Stream(ctx, req)
+}
+
+type result struct {
+	resp *types.CompletionResponse
+	err  error
+}
+
+// askStrong starts the strong request; its result arrives on the channel
+func askStrong(ctx context.Context, strong target, req types.CompletionRequest) <-chan result {
+	done := make(chan result, 1)
+	go func() {
+		req.Stream = false
+		resp, err := strong.complete(ctx, req)
+		done <- result{resp, err}
+	}()
+	return done
+}
+
+// Complete returns the strong answer when it comes at most Wait after
+// the fast one, and the fast answer otherwise
+func (s *Speculative) Complete(ctx context.Context, req types.CompletionRequest) (*types.CompletionResponse, error) {
+	fast, strong, err := s.targets()
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Tools) > 0 {
+		resp, err := strong.complete(ctx, req)
+		if err != nil && ctx.Err() == nil {
+			return fast.complete(ctx, req)
+		}
+		return resp, err
+	}
+
+	strongDone := askStrong(ctx, strong, req)
+	fastResp, fastErr := fast.complete(ctx, req)
+	r, ok := s.await(ctx, strongDone, fastErr != nil)
+	if ok && r.err == nil {
+		return r.resp, nil
+	}
+	if fastErr != nil && ok {
+		return nil, fmt.Errorf("%s: %v; %s: %w", fast.spec, fastErr, strong.spec, r.err)
+	}
+	return fastResp, fastErr
+}
+
+// await waits for the strong result, for at most Wait unless the fast
+// model failed. ok is false when it didn't come.
+func (s *Speculative) await(ctx context.Context, done <-chan result, forever bool) (result, bool) {
+	var timeout <-chan time.Time
+	if !forever {
+		timer := time.NewTimer(s.cfg.Wait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case r := <-done:
+		return r, true
+	case <-timeout:
+		return result{}, false
+	case <-ctx.Done():
+		return result{}, false
+	}
+}
+
+// Stream streams the fast answer, then swaps in or offers the strong one
+func (s *Speculative) Stream(ctx context.Context, req types.CompletionRequest) (<-chan types.StreamChunk, error) {
+	fast, strong, err := s.targets()
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Tools) > 0 {
+		chunks, err := strong.stream(ctx, req)
+		if err != nil && ctx.Err() == nil {
+			return fast.stream(ctx, req)
+		}
+		return chunks, err
+	}
+
+	strongDone := askStrong(ctx, strong, req)
+	fastChunks, fastErr := fast.stream(ctx, req)
+
+	output := make(chan types.StreamChunk)
+	go func() {
+		defer close(output)
+		var answer strings.Builder
+		var done types.StreamChunk
+		if fastErr == nil {
+			for chunk := range fastChunks {
+				if chunk.Error != nil {
+					fastErr = chunk.Error
+					break
+				}
+				if chunk.Done {
+					done = chunk
+					answer.WriteString(chunk.Content)
+					break
+				}
+				answer.WriteString(chunk.Content)
+				output <- chunk
+			}
+		}
+		done.Done = true
+
+		r, ok := s.await(ctx, strongDone, fastErr != nil)
+		switch {
+		case ctx.Err() != nil:
+			output <- types.StreamChunk{Error: ctx.Err()}
+		case fastErr != nil && (!ok || r.err != nil):
+			output <- types.StreamChunk{Error: fmt.Errorf("%s: %v; %s: %v", fast.spec, fastErr, strong.spec, r.err)}
+		case fastErr != nil:
+			output <- types.StreamChunk{Notice: fmt.Sprintf("%s failed (%v); answered by %s.", fast.spec, fastErr, strong.spec)}
+			output <- types.StreamChunk{Replace: true, Content: r.resp.Content, Done: true, FinishReason: r.resp.FinishReason}
+		case !ok:
+			output <- types.StreamChunk{Notice: fmt.Sprintf("%s did not answer within %s; kept the answer of %s.", strong.spec, s.cfg.Wait, fast.spec)}
+			output <- done
+		case r.err != nil:
+			output <- types.StreamChunk{Notice: fmt.Sprintf("%s failed (%v); kept the answer of %s.", strong.spec, r.err, fast.spec)}
+			output <- done
+		case strings.TrimSpace(r.resp.Content) == strings.TrimSpace(answer.String()):
+			output <- done
+		case s.cfg.Mode == SpeculativeOffer:
+			output <- types.StreamChunk{Notice: fmt.Sprintf("%s answered too; /swap to use its answer.", strong.spec)}
+			done.Alternative = r.resp.Content
+			output <- done
+		default:
+			output <- types.StreamChunk{Notice: fmt.Sprintf("Swapped in the answer of %s; /swap to go back to %s.", strong.spec, fast.spec)}
+			output <- types.StreamChunk{Replace: true, Content: r.resp.Content, Done: true, FinishReason: r.resp.FinishReason, Alternative: answer.String()}
+		}
+	}()
+	return output, nil
+}
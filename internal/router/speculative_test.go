@@ -0,0 +1,124 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// streamProvider answers with content after delay, streamed word by word
+type streamProvider struct {
+	name    string
+	content string
+	delay   time.Duration
+	fail    bool
+}
+
+func (p *streamProvider) Name() string                    { return p.name }
+func (p *streamProvider) Models() []string                { return nil }
+func (p *streamProvider) SupportsModel(model string) bool { return true }
+
+func (p *streamProvider) Complete(ctx context.Context, req types.CompletionRequest) (*types.CompletionResponse, error) {
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if p.fail {
+		return nil, errors.New("connection reset")
+	}
+	return &types.CompletionResponse{Content: p.content}, nil
+}
+
+func (p *streamProvider) Stream(ctx context.Context, req types.CompletionRequest) (<-chan types.StreamChunk, error) {
+	if p.fail {
+		return nil, errors.New("connection refused")
+	}
+	ch := make(chan types.StreamChunk)
+	go func() {
+		defer close(ch)
+		for _, word := range strings.SplitAfter(p.content, " ") {
+			ch <- types.StreamChunk{Content: word}
+		}
+		ch <- types.StreamChunk{Done: true}
+	}()
+	return ch, nil
+}
+
+func newTestSpeculative(fast, strong *streamProvider, mode string, wait time.Duration) *Speculative {
+	registry := provider.NewRegistry()
+	registry.Register(fast)
+	registry.Register(strong)
+	return NewSpeculative(SpeculativeConfig{Fast: fast.name + "/m", Strong: strong.name + "/m", Mode: mode, Wait: wait}, registry.ResolveModel)
+}
+
+// drain returns the streamed content, notices and the final chunk
+func drain(t *testing.T, s *Speculative) (string, string, types.StreamChunk) {
+	t.Helper()
+	chunks, err := s.Stream(context.Background(), types.CompletionRequest{Messages: []types.Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var content, notices strings.Builder
+	var last types.StreamChunk
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			t.Fatalf("chunk error: %v", chunk.Error)
+		}
+		if chunk.Replace {
+			content.Reset()
+		}
+		content.WriteString(chunk.Content)
+		notices.WriteString(chunk.Notice)
+		last = chunk
+	}
+	return content.String(), notices.String(), last
+}
+
+func TestSpeculative_SwapsInStrongAnswer(t *testing.T) {
+	s := newTestSpeculative(&streamProvider{name: "local", content: "quick answer"},
+		&streamProvider{name: "remote", content: "better answer", delay: 10 * time.Millisecond}, "", time.Second)
+
+	content, notices, last := drain(t, s)
+	if content != "better answer" || !last.Done || last.Alternative != "quick answer" {
+		t.Errorf("content = %q, last = %+v", content, last)
+	}
+	if !strings.Contains(notices, "Swapped in the answer of remote/m") {
+		t.Errorf("notices = %q", notices)
+	}
+}
+
+func TestSpeculative_OffersStrongAnswer(t *testing.T) {
+	s := newTestSpeculative(&streamProvider{name: "local", content: "quick answer"},
+		&streamProvider{name: "remote", content: "better answer"}, SpeculativeOffer, time.Second)
+
+	content, notices, last := drain(t, s)
+	if content != "quick answer" || last.Alternative != "better answer" || !strings.Contains(notices, "/swap") {
+		t.Errorf("content = %q, notices = %q, last = %+v", content, notices, last)
+	}
+}
+
+func TestSpeculative_KeepsFastAnswerWhenStrongIsLate(t *testing.T) {
+	s := newTestSpeculative(&streamProvider{name: "local", content: "quick answer"},
+		&streamProvider{name: "remote", content: "better answer", delay: time.Second}, "", 10*time.Millisecond)
+
+	content, notices, last := drain(t, s)
+	if content != "quick answer" || !last.Done || last.Alternative != "" || !strings.Contains(notices, "did not answer") {
+		t.Errorf("content = %q, notices = %q, last = %+v", content, notices, last)
+	}
+}
+
+func TestSpeculative_StrongAnswersWhenFastFails(t *testing.T) {
+	s := newTestSpeculative(&streamProvider{name: "local", fail: true},
+		&streamProvider{name: "remote", content: "better answer", delay: 20 * time.Millisecond}, "", time.Millisecond)
+
+	content, notices, _ := drain(t, s)
+	if content != "better answer" || !strings.Contains(notices, "local/m failed") {
+		t.Errorf("content = %q, notices = %q", content, notices)
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// sessionSummary is the list view of a session
+type sessionSummary struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Workdir   string    `json:"workdir"`
+	Model     string    `json:"model"`
+	Messages  int       `json:"messages"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// skillSummary is the list view of a skill
+type skillSummary struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		writeJSON(w, http.StatusOK, []sessionSummary{})
+		return
+	}
+
+	sessions, err := s.sessions.List()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	out := make([]sessionSummary, 0, len(sessions))
+	for _, sess := range sessions {
+		out = append(out, sessionSummary{
+			ID:        sess.ID,
+			Name:      sess.DisplayName(),
+			Workdir:   sess.Workdir,
+			Model:     sess.Model,
+			Messages:  len(sess.Messages),
+			UpdatedAt: sess.UpdatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "sessions disabled"})
+		return
+	}
+
+	sess, err := s.sessions.Get(r.PathValue("id"))
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "session not found"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		ID       string          `json:"id"`
+		Name     string          `json:"name"`
+		Messages []types.Message `json:"messages"`
+	}{sess.ID, sess.DisplayName(), sess.Messages})
+}
+
+func (s *Server) handleSkills(w http.ResponseWriter, r *http.Request) {
+	out := make([]skillSummary, 0)
+	if s.skills != nil {
+		for _, sk := range s.skills.List() {
+			out = append(out, skillSummary{Name: sk.Name, Description: sk.Description, Tags: sk.Tags})
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/agentflow/agentflow/internal/provider"
+)
+
+// metric is one provider metric in the Prometheus text format
+type metric struct {
+	name, kind, help string
+	value            func(provider.Metrics) float64
+}
+
+var providerMetrics = []metric{
+	{"agentflow_provider_in_flight", "gauge", "Provider requests waiting for or streaming a response",
+		func(m provider.Metrics) float64 { return float64(m.InFlight) }},
+	{"agentflow_provider_queued", "gauge", "Subagent tasks waiting for a free slot",
+		func(m provider.Metrics) float64 { return float64(m.Queued) }},
+	{"agentflow_provider_requests_total", "counter", "Finished provider requests",
+		func(m provider.Metrics) float64 { return float64(m.Requests) }},
+	{"agentflow_provider_errors_total", "counter", "Failed provider requests",
+		func(m provider.Metrics) float64 { return float64(m.Errors) }},
+	{"agentflow_provider_rate_limited_total", "counter", "Provider requests refused with 429",
+		func(m provider.Metrics) float64 { return float64(m.RateLimited) }},
+	{"agentflow_provider_latency_seconds", "gauge", "Recent average time to a full response",
+		func(m provider.Metrics) float64 { return m.Latency.Seconds() }},
+}
+
+// handleMetrics serves provider metrics in the Prometheus text format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	all := provider.AllMetrics()
+
+	var sb strings.Builder
+	for _, metric := range providerMetrics {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+		for _, m := range all {
+			fmt.Fprintf(&sb, "%s{provider=%q} %g\n", metric.name, m.Provider, metric.value(m))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(sb.String()))
+}
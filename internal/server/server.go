@@ -0,0 +1,123 @@
+// Package server exposes agents over HTTP and WebSocket for serve mode
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/agentflow/agentflow/internal/skill"
+)
+
+// Config holds server configuration
+type Config struct {
+	Addr     string
+	NewAgent func() *agent.Agent // Creates an agent for each new conversation
+	Skills   *skill.Loader
+	Sessions *session.Manager
+	Workdir  string
+	Provider string
+	Model    string
+	UI       bool // Serve the embedded web UI at /
+}
+
+// Server serves the agent API
+type Server struct {
+	addr     string
+	newAgent func() *agent.Agent
+	skills   *skill.Loader
+	sessions *session.Manager
+	workdir  string
+	provider string
+	model    string
+	mux      *http.ServeMux
+}
+
+// New creates a new server
+func New(cfg Config) *Server {
+	if cfg.Addr == "" {
+		cfg.Addr = "127.0.0.1:8080"
+	}
+	s := &Server{
+		addr:     cfg.Addr,
+		newAgent: cfg.NewAgent,
+		skills:   cfg.Skills,
+		sessions: cfg.Sessions,
+		workdir:  cfg.Workdir,
+		provider: cfg.Provider,
+		model:    cfg.Model,
+		mux:      http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /health", s.handleHealth)
+	s.mux.HandleFunc("GET /metrics", s.handleMetrics)
+	s.mux.HandleFunc("GET /ws", s.handleWS)
+	s.mux.HandleFunc("GET /api/sessions", s.handleSessions)
+	s.mux.HandleFunc("GET /api/sessions/{id}", s.handleSession)
+	s.mux.HandleFunc("GET /api/skills", s.handleSkills)
+
+	if cfg.UI {
+		s.mux.Handle("GET /", uiHandler())
+	}
+
+	return s
+}
+
+// Handler returns the HTTP handler for the server
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Addr returns the listen address
+func (s *Server) Addr() string {
+	return s.addr
+}
+
+// ListenAndServe serves until ctx is cancelled, then shuts down gracefully
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":   "ok",
+		"provider": s.provider,
+		"model":    s.model,
+	})
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/agentflow/agentflow/pkg/providertest"
+	"github.com/gorilla/websocket"
+)
+
+func newTestServer(t *testing.T, p *providertest.Provider) (*httptest.Server, *session.Manager) {
+	t.Helper()
+	mgr := session.NewManager(t.TempDir())
+	s := New(Config{
+		NewAgent: func() *agent.Agent { return agent.New(agent.Config{Provider: p, Model: "test-model"}) },
+		Sessions: mgr,
+		Model:    "test-model",
+	})
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return ts, mgr
+}
+
+func dial(t *testing.T, ts *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws" + query
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func TestHealth(t *testing.T) {
+	ts, _ := newTestServer(t, &providertest.Provider{})
+	resp, err := http.Get(ts.URL + "/health")
+	if err != nil {
+		t.Fatalf("GET /health: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d", resp.StatusCode)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	provider.TrackQueued("metrics-test", 2)
+	defer provider.TrackQueued("metrics-test", -2)
+
+	ts, _ := newTestServer(t, &providertest.Provider{})
+	resp, err := http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	for _, want := range []string{
+		"# TYPE agentflow_provider_in_flight gauge",
+		`agentflow_provider_queued{provider="metrics-test"} 2`,
+		`agentflow_provider_requests_total{provider="metrics-test"} 0`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestWS_StreamsResponse(t *testing.T) {
+	ts, mgr := newTestServer(t, providertest.Streamed(0, "Hello", " world"))
+	conn := dial(t, ts, "")
+
+	var f Frame
+	if err := conn.ReadJSON(&f); err != nil || f.Type != FrameSession {
+		t.Fatalf("expected session frame, got %+v (%v)", f, err)
+	}
+	sessionID := f.SessionID
+
+	conn.WriteJSON(Frame{Type: FrameMessage, Content: "hi"})
+
+	var content strings.Builder
+	for {
+		if err := conn.ReadJSON(&f); err != nil {
+			t.Fatalf("ReadJSON: %v", err)
+		}
+		if f.Type == FrameChunk {
+			content.WriteString(f.Content)
+		}
+		if f.Type == FrameDone {
+			break
+		}
+		if f.Type == FrameError {
+			t.Fatalf("error frame: %s", f.Error)
+		}
+	}
+
+	if content.String() != "Hello world" {
+		t.Errorf("content = %q", content.String())
+	}
+	if f.Tokens != 2 {
+		t.Errorf("tokens = %d, want 2", f.Tokens)
+	}
+
+	// The session is saved once the turn completes
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		sess, err := mgr.Get(sessionID)
+		if err == nil && len(sess.Messages) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session not saved with 2 messages: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWS_Cancel(t *testing.T) {
+	p := providertest.Streamed(200*time.Millisecond, "a", "b", "c")
+	ts, _ := newTestServer(t, p)
+	conn := dial(t, ts, "")
+
+	var f Frame
+	conn.ReadJSON(&f) // session
+
+	conn.WriteJSON(Frame{Type: FrameMessage, Content: "long task"})
+	conn.WriteJSON(Frame{Type: FrameCancel})
+
+	for {
+		if err := conn.ReadJSON(&f); err != nil {
+			t.Fatalf("ReadJSON: %v", err)
+		}
+		if f.Type == FrameDone {
+			t.Fatal("expected cancellation, got done")
+		}
+		if f.Type == FrameError {
+			break
+		}
+	}
+	if f.Error != "cancelled" {
+		t.Errorf("error = %q, want cancelled", f.Error)
+	}
+}
+
+func TestWS_UnknownFrame(t *testing.T) {
+	ts, _ := newTestServer(t, &providertest.Provider{})
+	conn := dial(t, ts, "")
+
+	var f Frame
+	conn.ReadJSON(&f) // session
+
+	conn.WriteJSON(Frame{Type: "bogus"})
+	if err := conn.ReadJSON(&f); err != nil {
+		t.Fatalf("ReadJSON: %v", err)
+	}
+	if f.Type != FrameError {
+		t.Errorf("type = %q, want error", f.Type)
+	}
+}
+
+func TestAPI_Sessions(t *testing.T) {
+	ts, mgr := newTestServer(t, &providertest.Provider{})
+
+	sess := session.New("/work", "test", "test-model")
+	sess.AddMessage("user", "Build a REST API")
+	mgr.Save(sess)
+
+	resp, err := http.Get(ts.URL + "/api/sessions")
+	if err != nil {
+		t.Fatalf("GET /api/sessions: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var list []sessionSummary
+	json.NewDecoder(resp.Body).Decode(&list)
+	if len(list) != 1 || list[0].Name != "Build a REST API" {
+		t.Errorf("sessions = %+v", list)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/sessions/" + sess.ID)
+	if err != nil {
+		t.Fatalf("GET /api/sessions/{id}: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d", resp.StatusCode)
+	}
+
+	resp, _ = http.Get(ts.URL + "/api/sessions/missing")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing session status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestUI(t *testing.T) {
+	withUI := httptest.NewServer(New(Config{UI: true}).Handler())
+	defer withUI.Close()
+
+	resp, err := http.Get(withUI.URL + "/")
+	if err != nil {
+		t.Fatalf("GET /: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "AgentFlow") {
+		t.Errorf("UI not served: status %d", resp.StatusCode)
+	}
+
+	withoutUI := httptest.NewServer(New(Config{}).Handler())
+	defer withoutUI.Close()
+
+	resp, _ = http.Get(withoutUI.URL + "/")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status without --ui = %d, want 404", resp.StatusCode)
+	}
+}
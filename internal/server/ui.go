@@ -0,0 +1,18 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// webFS holds the single-page chat UI served with --ui
+//
+//go:embed web
+var webFS embed.FS
+
+// uiHandler serves the embedded web UI
+func uiHandler() http.Handler {
+	sub, _ := fs.Sub(webFS, "web")
+	return http.FileServer(http.FS(sub))
+}
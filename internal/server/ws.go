@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/session"
+	"github.com/gorilla/websocket"
+)
+
+// Frame types sent by the server
+const (
+	FrameSession = "session" // Conversation bound to a session
+	FrameSkill   = "skill"   // A skill matched the message; one frame per skill
+	FrameChunk   = "chunk"   // Streamed response content
+	FrameDone    = "done"    // Response complete, with token count
+	FrameError   = "error"   // Turn failed or request was invalid
+)
+
+// Frame types sent by the client
+const (
+	FrameMessage = "message" // User message to send to the agent
+	FrameCancel  = "cancel"  // Cancel the in-flight response
+)
+
+// Frame is a typed JSON message on the WebSocket
+type Frame struct {
+	Type      string `json:"type"`
+	Content   string `json:"content,omitempty"`
+	Skill     string `json:"skill,omitempty"`
+	Reason    string `json:"reason,omitempty"` // Why the skill was activated
+	SessionID string `json:"session_id,omitempty"`
+	Tokens    int    `json:"tokens,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+}
+
+// wsConn is one WebSocket conversation; gorilla connections allow only one
+// concurrent writer, so writes go through a mutex
+type wsConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	agent   *agent.Agent
+	session *session.Session
+	cancel  context.CancelFunc // Cancels the in-flight turn, nil when idle
+}
+
+func (c *wsConn) send(f Frame) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return c.conn.WriteJSON(f)
+}
+
+// handleWS upgrades to a WebSocket bound to a new session, or to an existing
+// one with ?session=<id|name>
+func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade already wrote the HTTP error
+	}
+	defer conn.Close()
+
+	c := &wsConn{conn: conn, agent: s.newAgent()}
+	if id := r.URL.Query().Get("session"); id != "" && s.sessions != nil {
+		sess, err := s.sessions.GetByNameOrID(id)
+		if err != nil {
+			c.send(Frame{Type: FrameError, Error: err.Error()})
+			return
+		}
+		c.session = sess
+		for _, m := range sess.Messages {
+			c.agent.AddMessage(m.Role, m.Content)
+		}
+		c.agent.SetActivity(sess.Activity())
+	} else {
+		c.session = session.New(s.workdir, s.provider, s.model)
+	}
+	c.send(Frame{Type: FrameSession, SessionID: c.session.ID})
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	var turns sync.WaitGroup
+	defer turns.Wait()
+
+	for {
+		var f Frame
+		if err := conn.ReadJSON(&f); err != nil {
+			return
+		}
+
+		switch f.Type {
+		case FrameMessage:
+			c.mu.Lock()
+			if c.cancel != nil {
+				c.mu.Unlock()
+				c.send(Frame{Type: FrameError, Error: "a response is already in progress"})
+				continue
+			}
+			turnCtx, turnCancel := context.WithCancel(ctx)
+			c.cancel = turnCancel
+			c.mu.Unlock()
+
+			turns.Add(1)
+			go func(content string) {
+				defer turns.Done()
+				s.runTurn(turnCtx, c, content)
+				c.mu.Lock()
+				c.cancel = nil
+				c.mu.Unlock()
+				turnCancel()
+			}(f.Content)
+
+		case FrameCancel:
+			c.mu.Lock()
+			if c.cancel != nil {
+				c.cancel()
+			}
+			c.mu.Unlock()
+
+		default:
+			c.send(Frame{Type: FrameError, Error: fmt.Sprintf("unknown frame type: %q", f.Type)})
+		}
+	}
+}
+
+// runTurn streams one agent response over the connection
+func (s *Server) runTurn(ctx context.Context, c *wsConn, content string) {
+	for _, act := range c.agent.ActivateSkills(content) {
+		c.send(Frame{Type: FrameSkill, Skill: act.Skill, Reason: act.Reason})
+		c.session.RecordSkill(act.Skill, act.Reason)
+	}
+
+	chunks, err := c.agent.Stream(ctx, content)
+	if err != nil {
+		c.send(Frame{Type: FrameError, Error: err.Error()})
+		return
+	}
+
+	// Each streamed chunk is roughly one token for both Ollama and
+	// OpenAI-compatible servers; reported usage replaces the estimate
+	tokens := 0
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			if ctx.Err() != nil {
+				c.send(Frame{Type: FrameError, Error: "cancelled"})
+			} else {
+				c.send(Frame{Type: FrameError, Error: chunk.Error.Error()})
+			}
+			continue // Drain so the provider goroutine can exit
+		}
+		if chunk.Content != "" {
+			tokens++
+			c.send(Frame{Type: FrameChunk, Content: chunk.Content})
+		}
+		if chunk.Done {
+			if chunk.Usage != nil {
+				tokens = chunk.Usage.CompletionTokens
+			}
+			c.send(Frame{Type: FrameDone, Tokens: tokens})
+		}
+	}
+
+	if s.sessions != nil {
+		c.session.Messages = append(c.session.Messages[:0], c.agent.Messages()...)
+		c.session.SetActivity(c.agent.Activity())
+		c.session.UpdatedAt = time.Now()
+		s.sessions.Save(c.session)
+	}
+}
@@ -0,0 +1,152 @@
+package session
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArchiveDir is the subdirectory of the sessions directory archived
+// sessions are kept in, gzipped. List leaves them out.
+const ArchiveDir = "archive"
+
+// archivePath returns the file an archived session is kept in
+func (m *Manager) archivePath(id string) string {
+	return filepath.Join(m.dir, ArchiveDir, id+".json.gz")
+}
+
+// Archive gzips the sessions not updated for olderThan into the archive
+// directory and returns their IDs
+func (m *Manager) Archive(olderThan time.Duration) ([]string, error) {
+	sessions, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(m.dir, ArchiveDir), 0755); err != nil {
+		return nil, fmt.Errorf("create archive dir: %w", err)
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	var archived []string
+	for _, s := range sessions {
+		if !s.UpdatedAt.Before(cutoff) {
+			continue
+		}
+		if err := m.archive(s.ID); err != nil {
+			return archived, err
+		}
+		archived = append(archived, s.ID)
+	}
+	return archived, nil
+}
+
+// archive compresses one session into the archive and removes it
+func (m *Manager) archive(id string) error {
+	data, err := os.ReadFile(m.sessionPath(id))
+	if err != nil {
+		return fmt.Errorf("read session: %w", err)
+	}
+
+	path := m.archivePath(id)
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+id+"-*")
+	if err != nil {
+		return fmt.Errorf("archive session %s: %w", id, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := gzip.NewWriter(tmp)
+	zw.Name = id + ".json"
+	if _, err := zw.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("archive session %s: %w", id, err)
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("archive session %s: %w", id, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("archive session %s: %w", id, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("archive session %s: %w", id, err)
+	}
+	return os.Remove(m.sessionPath(id))
+}
+
+// ListArchived returns the archived sessions sorted by last update
+// (newest first)
+func (m *Manager) ListArchived() ([]*Session, error) {
+	entries, err := os.ReadDir(filepath.Join(m.dir, ArchiveDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read archive dir: %w", err)
+	}
+
+	var sessions []*Session
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json.gz") {
+			continue
+		}
+		s, err := loadArchived(filepath.Join(m.dir, ArchiveDir, entry.Name()))
+		if err != nil {
+			continue // Skip invalid sessions
+		}
+		sessions = append(sessions, s)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
+
+// loadArchived loads a gzipped session
+func loadArchived(path string) (*Session, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read session: %w", err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("read session: %w", err)
+	}
+	defer zr.Close()
+
+	var s Session
+	if err := json.NewDecoder(zr).Decode(&s); err != nil {
+		return nil, fmt.Errorf("unmarshal session: %w", err)
+	}
+	s.saved = s.UpdatedAt
+	return &s, nil
+}
+
+// Size returns the bytes a session takes on disk, archived or not
+func (m *Manager) Size(id string) int64 {
+	for _, path := range []string{m.sessionPath(id), m.archivePath(id)} {
+		if info, err := os.Stat(path); err == nil {
+			return info.Size()
+		}
+	}
+	return 0
+}
+
+// FormatSize formats a byte count for session listings
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
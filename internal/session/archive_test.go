@@ -0,0 +1,47 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManager_Archive(t *testing.T) {
+	mgr := NewManager(t.TempDir())
+
+	old := New("/repo", "ollama", "llama3")
+	old.Name = "old work"
+	old.AddMessage("user", "hello")
+	mgr.Save(old)
+	old.UpdatedAt = time.Now().Add(-40 * 24 * time.Hour)
+	mgr.Save(old)
+
+	recent := New("/repo", "ollama", "llama3")
+	mgr.Save(recent)
+
+	ids, err := mgr.Archive(30 * 24 * time.Hour)
+	if err != nil || len(ids) != 1 || ids[0] != old.ID {
+		t.Fatalf("Archive = %v, %v", ids, err)
+	}
+
+	live, _ := mgr.List()
+	if len(live) != 1 || live[0].ID != recent.ID {
+		t.Errorf("List = %d sessions, want only the recent one", len(live))
+	}
+	archived, err := mgr.ListArchived()
+	if err != nil || len(archived) != 1 || len(archived[0].Messages) != 1 {
+		t.Fatalf("ListArchived = %+v, %v", archived, err)
+	}
+	if mgr.Size(old.ID) == 0 {
+		t.Error("archived session has no size")
+	}
+
+	// Archived sessions are still found, and saving one makes it live
+	s, err := mgr.GetByNameOrID("old work")
+	if err != nil || s.ID != old.ID {
+		t.Fatalf("GetByNameOrID = %v, %v", s, err)
+	}
+	mgr.Save(s)
+	if archived, _ := mgr.ListArchived(); len(archived) != 0 {
+		t.Errorf("saved session still archived")
+	}
+}
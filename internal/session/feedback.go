@@ -0,0 +1,53 @@
+package session
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Ratings for Feedback
+const (
+	RatingGood = "good"
+	RatingBad  = "bad"
+)
+
+// Feedback is a rating of one exchange, kept with the exchange so rated
+// sessions can be exported as eval cases
+type Feedback struct {
+	Rating   string    `json:"rating"`
+	Note     string    `json:"note,omitempty"`
+	Prompt   string    `json:"prompt"`
+	Response string    `json:"response"`
+	Skills   []string  `json:"skills,omitempty"` // Active when the response was written
+	Model    string    `json:"model,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// Feedback returns the ratings in the "feedback" metadata
+func (s *Session) Feedback() []Feedback {
+	// Loaded sessions hold the list as decoded JSON
+	data, err := json.Marshal(s.Metadata["feedback"])
+	if err != nil {
+		return nil
+	}
+	var feedback []Feedback
+	json.Unmarshal(data, &feedback)
+	return feedback
+}
+
+// AddFeedback adds a rating to the "feedback" metadata, replacing an
+// earlier rating of the same exchange
+func (s *Session) AddFeedback(f Feedback) {
+	if s.Metadata == nil {
+		s.Metadata = make(map[string]any)
+	}
+	feedback := s.Feedback()
+	for i, old := range feedback {
+		if old.Prompt == f.Prompt && old.Response == f.Response {
+			feedback = append(feedback[:i], feedback[i+1:]...)
+			break
+		}
+	}
+	s.Metadata["feedback"] = append(feedback, f)
+	s.UpdatedAt = time.Now()
+}
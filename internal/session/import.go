@@ -0,0 +1,280 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// Import formats accepted by Import
+const (
+	FormatClaudeCode = "claude-code"
+	FormatAider      = "aider"
+)
+
+// Import converts another tool's conversation export into sessions.
+// workdir is used when the export doesn't record one.
+func Import(format string, r io.Reader, workdir string) ([]*Session, error) {
+	switch format {
+	case FormatClaudeCode:
+		s, err := importClaudeCode(r, workdir)
+		if err != nil {
+			return nil, err
+		}
+		return []*Session{s}, nil
+	case FormatAider:
+		return importAider(r, workdir)
+	default:
+		return nil, fmt.Errorf("unknown import format: %s (want %s or %s)", format, FormatClaudeCode, FormatAider)
+	}
+}
+
+// imported creates an empty session tagged with its source
+func imported(format, workdir string) *Session {
+	s := New(workdir, "", "")
+	s.Metadata["imported_from"] = format
+	return s
+}
+
+// claudeEntry is a line of a Claude Code transcript (.jsonl)
+type claudeEntry struct {
+	Type      string    `json:"type"`
+	IsMeta    bool      `json:"isMeta"`
+	Summary   string    `json:"summary"`
+	Cwd       string    `json:"cwd"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   struct {
+		Role    string          `json:"role"`
+		Model   string          `json:"model"`
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// claudeBlock is a content block of a Claude Code message
+type claudeBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+}
+
+// importClaudeCode converts a Claude Code transcript, one JSON entry per line
+func importClaudeCode(r io.Reader, workdir string) (*Session, error) {
+	s := imported(FormatClaudeCode, workdir)
+	s.Provider = "anthropic"
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var e claudeEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("parse transcript: %w", err)
+		}
+
+		if e.Type == "summary" && s.Name == "" {
+			s.Name = e.Summary
+			continue
+		}
+		if (e.Type != "user" && e.Type != "assistant") || e.IsMeta {
+			continue
+		}
+
+		if e.Cwd != "" {
+			s.Workdir = e.Cwd
+		}
+		if e.Message.Model != "" {
+			s.Model = e.Message.Model
+		}
+		s.Messages = append(s.Messages, claudeMessages(e)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read transcript: %w", err)
+	}
+
+	stamp(s)
+	return s, nil
+}
+
+// claudeMessages converts an entry into messages: text and tool calls
+// stay on the user/assistant message, tool results become tool messages
+func claudeMessages(e claudeEntry) []types.Message {
+	msg := types.Message{Role: e.Message.Role, Timestamp: e.Timestamp}
+
+	// Content is either a plain string or a list of blocks
+	var text string
+	if err := json.Unmarshal(e.Message.Content, &text); err == nil {
+		msg.Content = text
+		return []types.Message{msg}
+	}
+
+	var blocks []claudeBlock
+	if err := json.Unmarshal(e.Message.Content, &blocks); err != nil {
+		return nil
+	}
+
+	var parts []string
+	var results []types.Message
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			parts = append(parts, b.Text)
+		case "tool_use":
+			msg.ToolCalls = append(msg.ToolCalls, types.ToolCall{
+				ID:        b.ID,
+				Name:      b.Name,
+				Arguments: string(b.Input),
+			})
+		case "tool_result":
+			results = append(results, types.Message{
+				Role:       "tool",
+				ToolCallID: b.ToolUseID,
+				Content:    claudeResultText(b.Content),
+				Timestamp:  e.Timestamp,
+			})
+		}
+	}
+	msg.Content = strings.Join(parts, "\n\n")
+
+	if msg.Content == "" && len(msg.ToolCalls) == 0 {
+		return results
+	}
+	return append([]types.Message{msg}, results...)
+}
+
+// claudeResultText flattens a tool result, which is a string or text blocks
+func claudeResultText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var blocks []claudeBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return string(raw)
+	}
+	var parts []string
+	for _, b := range blocks {
+		if b.Type == "text" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+var (
+	aiderStartRegex = regexp.MustCompile(`^# aider chat started at (.+)$`)
+	aiderModelRegex = regexp.MustCompile(`^> (?:Main model|Model|Models): (\S+)`)
+)
+
+// importAider converts an aider chat history (.aider.chat.history.md).
+// Each "aider chat started" heading begins a new session; "#### " lines
+// are user messages, "> " lines are tool output and the rest is the
+// assistant's reply.
+func importAider(r io.Reader, workdir string) ([]*Session, error) {
+	var sessions []*Session
+	var cur *Session
+	var role string
+	var buf []string
+
+	flush := func() {
+		content := strings.Trim(strings.Join(buf, "\n"), "\n")
+		buf = nil
+		if cur == nil || content == "" {
+			return
+		}
+		cur.Messages = append(cur.Messages, types.Message{Role: role, Content: content})
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := aiderStartRegex.FindStringSubmatch(line); m != nil {
+			flush()
+			cur = imported(FormatAider, workdir)
+			if t, err := time.ParseInLocation("2006-01-02 15:04:05", strings.TrimSpace(m[1]), time.Local); err == nil {
+				cur.CreatedAt = t
+				cur.UpdatedAt = t
+			}
+			sessions = append(sessions, cur)
+			role = ""
+			continue
+		}
+		if cur == nil {
+			// Text before the first heading: treat as one session
+			cur = imported(FormatAider, workdir)
+			sessions = append(sessions, cur)
+		}
+
+		var lineRole string
+		switch {
+		case strings.HasPrefix(line, "#### "):
+			lineRole = "user"
+			line = strings.TrimPrefix(line, "#### ")
+		case line == ">" || strings.HasPrefix(line, "> "):
+			lineRole = "tool"
+			if m := aiderModelRegex.FindStringSubmatch(line); m != nil && cur.Model == "" {
+				cur.Model = m[1]
+			}
+			line = strings.TrimPrefix(strings.TrimPrefix(line, ">"), " ")
+		case strings.TrimSpace(line) == "" && role != "":
+			// Blank lines belong to the current message
+			buf = append(buf, line)
+			continue
+		default:
+			lineRole = "assistant"
+		}
+
+		if lineRole != role {
+			flush()
+			role = lineRole
+		}
+		buf = append(buf, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	flush()
+
+	// Drop sessions that only hold aider's startup output
+	kept := sessions[:0]
+	for _, s := range sessions {
+		for _, msg := range s.Messages {
+			if msg.Role == "user" {
+				kept = append(kept, s)
+				break
+			}
+		}
+	}
+	return kept, nil
+}
+
+// stamp sets the session times from its first and last messages
+func stamp(s *Session) {
+	for _, msg := range s.Messages {
+		if !msg.Timestamp.IsZero() {
+			s.CreatedAt = msg.Timestamp
+			break
+		}
+	}
+	for i := len(s.Messages) - 1; i >= 0; i-- {
+		if !s.Messages[i].Timestamp.IsZero() {
+			s.UpdatedAt = s.Messages[i].Timestamp
+			break
+		}
+	}
+}
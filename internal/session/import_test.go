@@ -0,0 +1,94 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImport_ClaudeCode(t *testing.T) {
+	transcript := `{"type":"summary","summary":"Fix login bug"}
+{"type":"user","cwd":"/src/app","timestamp":"2025-06-01T10:00:00Z","message":{"role":"user","content":"why does login fail?"}}
+{"type":"assistant","timestamp":"2025-06-01T10:00:05Z","message":{"role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"Let me look."},{"type":"tool_use","id":"tu_1","name":"Read","input":{"file":"auth.go"}}]}}
+{"type":"user","timestamp":"2025-06-01T10:00:06Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tu_1","content":[{"type":"text","text":"package auth"}]}]}}
+{"type":"user","isMeta":true,"message":{"role":"user","content":"<command>"}}
+{"type":"file-history-snapshot"}
+`
+	sessions, err := Import(FormatClaudeCode, strings.NewReader(transcript), "/fallback")
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	s := sessions[0]
+
+	if s.Name != "Fix login bug" || s.Workdir != "/src/app" || s.Model != "claude-sonnet-4" {
+		t.Errorf("session = %q %q %q", s.Name, s.Workdir, s.Model)
+	}
+	if len(s.Messages) != 3 {
+		t.Fatalf("got %d messages, want 3: %+v", len(s.Messages), s.Messages)
+	}
+	if call := s.Messages[1].ToolCalls; len(call) != 1 || call[0].Name != "Read" || call[0].Arguments != `{"file":"auth.go"}` {
+		t.Errorf("tool calls = %+v", call)
+	}
+	if msg := s.Messages[2]; msg.Role != "tool" || msg.ToolCallID != "tu_1" || msg.Content != "package auth" {
+		t.Errorf("tool result = %+v", msg)
+	}
+	if s.CreatedAt.Hour() != 10 || s.Metadata["imported_from"] != FormatClaudeCode {
+		t.Errorf("created = %v, metadata = %v", s.CreatedAt, s.Metadata)
+	}
+}
+
+func TestImport_Aider(t *testing.T) {
+	history := `
+# aider chat started at 2025-06-01 09:00:00
+
+> Main model: gpt-4o with diff edit format
+> Git repo: .git
+
+# aider chat started at 2025-06-02 14:30:00
+
+> Main model: gpt-4o with diff edit format
+
+#### add a --verbose flag
+#### to the CLI
+
+I'll add the flag.
+
+main.go
+` + "```go\nvar verbose bool\n```" + `
+
+> Applied edit to main.go
+
+#### thanks
+`
+	sessions, err := Import(FormatAider, strings.NewReader(history), "/src/app")
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want 1 (startup-only sessions are dropped)", len(sessions))
+	}
+	s := sessions[0]
+
+	if s.Model != "gpt-4o" || s.Workdir != "/src/app" || s.CreatedAt.Day() != 2 {
+		t.Errorf("session = %q %q %v", s.Model, s.Workdir, s.CreatedAt)
+	}
+
+	var roles []string
+	for _, msg := range s.Messages {
+		roles = append(roles, msg.Role)
+	}
+	if got := strings.Join(roles, ","); got != "tool,user,assistant,tool,user" {
+		t.Fatalf("roles = %s", got)
+	}
+	if s.Messages[1].Content != "add a --verbose flag\nto the CLI" {
+		t.Errorf("user message = %q", s.Messages[1].Content)
+	}
+	if !strings.Contains(s.Messages[2].Content, "var verbose bool") {
+		t.Errorf("assistant message = %q", s.Messages[2].Content)
+	}
+}
+
+func TestImport_UnknownFormat(t *testing.T) {
+	if _, err := Import("cursor", strings.NewReader(""), ""); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
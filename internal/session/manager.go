@@ -2,11 +2,15 @@ package session
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/lockfile"
 )
 
 const (
@@ -47,21 +51,59 @@ func (m *Manager) sessionPath(id string) string {
 	return filepath.Join(m.dir, id+".json")
 }
 
-// Save persists a session to disk
+// ErrConflict is returned by Save when another process saved the session
+// since it was loaded; the error is a *ConflictError
+var ErrConflict = errors.New("session changed on disk")
+
+// ConflictError reports a session saved elsewhere since it was loaded
+type ConflictError struct {
+	ID     string
+	Loaded time.Time // UpdatedAt when this copy was loaded or last saved
+	OnDisk time.Time // UpdatedAt of the copy on disk
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("session %s was saved by another agentflow at %s, after this one loaded it at %s; "+
+		"resume it with --fork-session to keep both, or reload it", e.ID, e.OnDisk.Format("15:04:05"), e.Loaded.Format("15:04:05"))
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// Save persists a session to disk. It holds a lock on the session while
+// writing, writes a temporary file renamed over the old one so readers
+// never see half a session, and refuses with a *ConflictError to
+// overwrite changes another process saved since s was loaded.
 func (m *Manager) Save(s *Session) error {
 	if err := m.ensureDir(); err != nil {
 		return fmt.Errorf("create sessions dir: %w", err)
 	}
 
+	path := m.sessionPath(s.ID)
+	unlock, err := lockfile.Lock(path)
+	if err != nil {
+		return fmt.Errorf("lock session: %w", err)
+	}
+	defer unlock()
+
+	if !s.saved.IsZero() {
+		if disk, err := m.loadFromPath(path); err == nil && !disk.UpdatedAt.Equal(s.saved) {
+			return &ConflictError{ID: s.ID, Loaded: s.saved, OnDisk: disk.UpdatedAt}
+		}
+	}
+
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal session: %w", err)
 	}
-
-	path := m.sessionPath(s.ID)
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := lockfile.WriteAtomic(path, data, 0644); err != nil {
 		return fmt.Errorf("write session: %w", err)
 	}
+	s.saved = s.UpdatedAt
+
+	// A resumed archived session is live again
+	os.Remove(m.archivePath(s.ID))
 
 	// Cleanup old sessions
 	m.cleanup()
@@ -75,12 +117,18 @@ func (m *Manager) Get(id string) (*Session, error) {
 	return m.loadFromPath(path)
 }
 
-// GetByNameOrID finds a session by name or ID prefix
+// GetByNameOrID finds a session by name or ID prefix, in the archive
+// when no live session matches
 func (m *Manager) GetByNameOrID(query string) (*Session, error) {
 	sessions, err := m.List()
 	if err != nil {
 		return nil, err
 	}
+	archived, err := m.ListArchived()
+	if err != nil {
+		return nil, err
+	}
+	sessions = append(sessions, archived...)
 
 	query = strings.ToLower(query)
 	for _, s := range sessions {
@@ -103,9 +151,10 @@ func (m *Manager) GetByNameOrID(query string) (*Session, error) {
 
 // Delete removes a session
 func (m *Manager) Delete(id string) error {
-	path := m.sessionPath(id)
-	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("delete session: %w", err)
+	for _, path := range []string{m.sessionPath(id), m.sessionPath(id) + ".lock", m.archivePath(id)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("delete session: %w", err)
+		}
 	}
 	return nil
 }
@@ -191,6 +240,7 @@ func (m *Manager) loadFromPath(path string) (*Session, error) {
 	if err := json.Unmarshal(data, &s); err != nil {
 		return nil, fmt.Errorf("unmarshal session: %w", err)
 	}
+	s.saved = s.UpdatedAt
 
 	return &s, nil
 }
@@ -204,7 +254,7 @@ func (m *Manager) cleanup() {
 
 	// Delete oldest sessions
 	for _, s := range sessions[m.maxSessions:] {
-		os.Remove(m.sessionPath(s.ID))
+		m.Delete(s.ID)
 	}
 }
 
@@ -221,3 +271,8 @@ func (m *Manager) Count() (int, error) {
 func (m *Manager) Dir() string {
 	return m.dir
 }
+
+// Path returns the file a session is saved in
+func (m *Manager) Path(id string) string {
+	return m.sessionPath(id)
+}
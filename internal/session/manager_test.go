@@ -1,9 +1,14 @@
 package session
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/agentflow/agentflow/pkg/types"
 )
 
 func TestSessionManager(t *testing.T) {
@@ -187,3 +192,146 @@ func TestSession(t *testing.T) {
 		}
 	})
 }
+
+func TestSession_Feedback(t *testing.T) {
+	mgr := NewManager(t.TempDir())
+	s := New("/tmp", "ollama", "llama3")
+	s.AddFeedback(Feedback{Rating: RatingBad, Prompt: "q", Response: "a"})
+	s.AddFeedback(Feedback{Rating: RatingGood, Prompt: "q2", Response: "a2", Skills: []string{"plan"}})
+	if err := mgr.Save(s); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := mgr.Get(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Rating an exchange again replaces the first rating
+	loaded.AddFeedback(Feedback{Rating: RatingGood, Note: "on reflection", Prompt: "q", Response: "a"})
+	feedback := loaded.Feedback()
+	if len(feedback) != 2 || feedback[0].Prompt != "q2" || feedback[0].Skills[0] != "plan" || feedback[1].Note != "on reflection" {
+		t.Errorf("feedback = %+v", feedback)
+	}
+}
+
+func TestSession_SystemPromptAndParams(t *testing.T) {
+	mgr := NewManager(t.TempDir())
+	s := New("/tmp", "ollama", "llama3")
+	s.SetSystemPrompt("Answer in haiku.")
+	s.SetParams(types.GenerationParams{Temperature: 0.2, MaxTokens: 512})
+	if err := mgr.Save(s); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := mgr.Get(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.SystemPrompt(); got != "Answer in haiku." {
+		t.Errorf("SystemPrompt = %q", got)
+	}
+	if got := loaded.Params(); got.Temperature != 0.2 || got.MaxTokens != 512 {
+		t.Errorf("Params = %+v", got)
+	}
+	loaded.SetSystemPrompt("")
+	if _, ok := loaded.Metadata["system_prompt"]; ok {
+		t.Error("empty prompt kept in metadata")
+	}
+}
+
+func TestSession_Activity(t *testing.T) {
+	mgr := NewManager(t.TempDir())
+	s := New("/tmp", "ollama", "llama3")
+	s.SetActivity([]types.Activity{
+		{Tool: "read_files", Kind: "read", Subject: "go.mod"},
+		{Tool: "write_file", Kind: "edit", Subject: "main.go", Error: "denied"},
+	})
+	if err := mgr.Save(s); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := mgr.Get(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Activity(); len(got) != 2 || got[0].Subject != "go.mod" || got[1].Error != "denied" {
+		t.Errorf("Activity = %+v", got)
+	}
+	loaded.SetActivity(nil)
+	if _, ok := loaded.Metadata["activity"]; ok {
+		t.Error("no activity kept in metadata")
+	}
+}
+
+func TestReplaySteps(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := New("/test", "ollama", "llama3")
+	s.Messages = []types.Message{
+		{Role: "user", Content: "hi", Timestamp: start},
+		{Role: "assistant", Content: "", ToolCalls: []types.ToolCall{{ID: "1", Name: "go_package"}}, Timestamp: start.Add(time.Second)},
+		{Role: "tool", Content: "result", Timestamp: start.Add(time.Second)},
+		{Role: "assistant", Content: "hello there\n\nfriend", Timestamp: start.Add(4 * time.Second)},
+		{Role: "user", Content: "later", Timestamp: start.Add(time.Hour)},
+		{Role: "assistant", Content: "ok"},
+	}
+
+	steps := ReplaySteps(s, 2)
+	var kinds []string
+	for _, step := range steps {
+		kinds = append(kinds, step.Kind+":"+step.Content)
+	}
+	want := "user:hi|tool_calls:|chunk:hello |chunk:there\n\n|chunk:friend|done:|user:later|chunk:ok|done:"
+	if strings.Join(kinds, "|") != want {
+		t.Errorf("steps = %q", strings.Join(kinds, "|"))
+	}
+
+	// The 3s answer streams in 1.5s at 2x; the hour's pause is capped
+	if steps[2].Delay != 500*time.Millisecond {
+		t.Errorf("chunk delay = %v", steps[2].Delay)
+	}
+	if steps[6].Delay != MaxReplayPause/2 {
+		t.Errorf("pause = %v", steps[6].Delay)
+	}
+}
+
+func TestManager_SaveConflict(t *testing.T) {
+	mgr := NewManager(t.TempDir())
+	s := New("/repo", "ollama", "llama3")
+	if err := mgr.Save(s); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Two processes load the same session; the second save loses
+	first, _ := mgr.Get(s.ID)
+	second, _ := mgr.Get(s.ID)
+	first.AddMessage("user", "from the first terminal")
+	if err := mgr.Save(first); err != nil {
+		t.Fatalf("Save first: %v", err)
+	}
+	second.AddMessage("user", "from the second terminal")
+	err := mgr.Save(second)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, ErrConflict) || conflict.ID != s.ID {
+		t.Fatalf("Save second = %v, want a conflict", err)
+	}
+
+	// The first save is intact, and saving again from it still works
+	loaded, _ := mgr.Get(s.ID)
+	if len(loaded.Messages) != 1 || loaded.Messages[0].Content != "from the first terminal" {
+		t.Errorf("on disk: %+v", loaded.Messages)
+	}
+	first.AddMessage("assistant", "ok")
+	if err := mgr.Save(first); err != nil {
+		t.Errorf("Save first again: %v", err)
+	}
+
+	// Only the session and its lock are left in the directory
+	entries, _ := os.ReadDir(mgr.Dir())
+	if len(entries) != 2 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("sessions dir = %v", names)
+	}
+}
@@ -0,0 +1,104 @@
+package session
+
+import (
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// Replay pacing. Answers stream over the time they originally took, and
+// pauses between turns are shown in full up to MaxReplayPause, both
+// divided by the speed.
+const (
+	MaxReplayPause  = 2 * time.Second  // Longest wait between turns
+	MaxReplayAnswer = 20 * time.Second // Longest time one answer streams over
+	replayChunkRate = 40               // Chunks per second without timestamps
+)
+
+// Replay step kinds
+const (
+	StepUser      = "user"       // A user message
+	StepChunk     = "chunk"      // Part of an answer
+	StepToolCalls = "tool_calls" // Tools the model called
+	StepDone      = "done"       // An answer finished
+)
+
+// ReplayStep is one thing to show during a replay, after Delay
+type ReplayStep struct {
+	Kind      string
+	Delay     time.Duration
+	Content   string           // User message or answer chunk
+	ToolCalls []types.ToolCall // StepToolCalls
+}
+
+// ReplaySteps paces a session's messages for replay at speed (2 is twice
+// as fast). Answers are split into word chunks; tool results are skipped,
+// as the calls are shown.
+func ReplaySteps(s *Session, speed float64) []ReplayStep {
+	if speed <= 0 {
+		speed = 1
+	}
+	scale := func(d time.Duration) time.Duration {
+		return time.Duration(float64(d) / speed)
+	}
+
+	var steps []ReplayStep
+	var last time.Time // When the previous message was written
+	answering := false
+	for _, m := range s.Messages {
+		switch m.Role {
+		case "user":
+			if answering {
+				steps = append(steps, ReplayStep{Kind: StepDone})
+			}
+			delay := MaxReplayPause / 2
+			if !last.IsZero() && !m.Timestamp.IsZero() {
+				delay = min(m.Timestamp.Sub(last), MaxReplayPause)
+			}
+			steps = append(steps, ReplayStep{Kind: StepUser, Delay: scale(max(delay, 0)), Content: m.Content})
+			answering = true
+
+		case "assistant":
+			chunks := splitChunks(m.Content)
+			total := time.Duration(len(chunks)) * time.Second / replayChunkRate
+			if !last.IsZero() && !m.Timestamp.IsZero() && m.Timestamp.After(last) {
+				total = min(m.Timestamp.Sub(last), MaxReplayAnswer)
+			}
+			for _, chunk := range chunks {
+				steps = append(steps, ReplayStep{Kind: StepChunk, Delay: scale(total / time.Duration(len(chunks))), Content: chunk})
+			}
+			if len(m.ToolCalls) > 0 {
+				steps = append(steps, ReplayStep{Kind: StepToolCalls, ToolCalls: m.ToolCalls})
+			}
+		}
+		if !m.Timestamp.IsZero() {
+			last = m.Timestamp
+		}
+	}
+	if answering {
+		steps = append(steps, ReplayStep{Kind: StepDone})
+	}
+	return steps
+}
+
+// splitChunks splits text into words with their trailing space, as a
+// model streams them
+func splitChunks(text string) []string {
+	var chunks []string
+	for len(text) > 0 {
+		i := strings.IndexAny(text, " \n")
+		if i < 0 {
+			chunks = append(chunks, text)
+			break
+		}
+		// Keep runs of whitespace with the word before them
+		j := i + 1
+		for j < len(text) && (text[j] == ' ' || text[j] == '\n') {
+			j++
+		}
+		chunks = append(chunks, text[:j])
+		text = text[j:]
+	}
+	return chunks
+}
@@ -4,6 +4,7 @@ package session
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"time"
 
 	"github.com/agentflow/agentflow/pkg/types"
@@ -20,6 +21,8 @@ type Session struct {
 	CreatedAt time.Time       `json:"created_at"`
 	UpdatedAt time.Time       `json:"updated_at"`
 	Metadata  map[string]any  `json:"metadata,omitempty"`
+
+	saved time.Time // UpdatedAt when loaded or last saved, to detect conflicting saves
 }
 
 // New creates a new session
@@ -99,3 +102,103 @@ func (s *Session) MessageCount() int {
 func (s *Session) LastActivity() time.Time {
 	return s.UpdatedAt
 }
+
+// RecordSkill adds a skill activation to the "skills" metadata list
+func (s *Session) RecordSkill(skill, reason string) {
+	if s.Metadata == nil {
+		s.Metadata = make(map[string]any)
+	}
+	// Loaded sessions hold the list as decoded JSON
+	list, _ := s.Metadata["skills"].([]any)
+	s.Metadata["skills"] = append(list, map[string]any{
+		"skill":  skill,
+		"reason": reason,
+		"at":     time.Now().Format(time.RFC3339),
+	})
+}
+
+// SystemPrompt returns the system prompt set with /system, stored in the
+// "system_prompt" metadata; empty when the configured one is used
+func (s *Session) SystemPrompt() string {
+	prompt, _ := s.Metadata["system_prompt"].(string)
+	return prompt
+}
+
+// SetSystemPrompt stores the system prompt set with /system; empty goes
+// back to the configured one
+func (s *Session) SetSystemPrompt(prompt string) {
+	if s.Metadata == nil {
+		s.Metadata = make(map[string]any)
+	}
+	if prompt == "" {
+		delete(s.Metadata, "system_prompt")
+		return
+	}
+	s.Metadata["system_prompt"] = prompt
+}
+
+// Params returns the sampling parameters set with /set, stored in the
+// "params" metadata
+func (s *Session) Params() types.GenerationParams {
+	// Loaded sessions hold them as decoded JSON
+	var p types.GenerationParams
+	if data, err := json.Marshal(s.Metadata["params"]); err == nil {
+		json.Unmarshal(data, &p)
+	}
+	return p
+}
+
+// SetParams stores the sampling parameters set with /set; the zero value
+// removes them
+func (s *Session) SetParams(p types.GenerationParams) {
+	if s.Metadata == nil {
+		s.Metadata = make(map[string]any)
+	}
+	if p == (types.GenerationParams{}) {
+		delete(s.Metadata, "params")
+		return
+	}
+	s.Metadata["params"] = p
+}
+
+// Style returns the name of the response style chosen with /style,
+// stored in the "style" metadata
+func (s *Session) Style() string {
+	style, _ := s.Metadata["style"].(string)
+	return style
+}
+
+// SetStyle stores the name of the response style
+func (s *Session) SetStyle(name string) {
+	if s.Metadata == nil {
+		s.Metadata = make(map[string]any)
+	}
+	if name == "" {
+		delete(s.Metadata, "style")
+		return
+	}
+	s.Metadata["style"] = name
+}
+
+// Activity returns the tool calls run in the session, stored in the
+// "activity" metadata, oldest first
+func (s *Session) Activity() []types.Activity {
+	// Loaded sessions hold them as decoded JSON
+	var activity []types.Activity
+	if data, err := json.Marshal(s.Metadata["activity"]); err == nil {
+		json.Unmarshal(data, &activity)
+	}
+	return activity
+}
+
+// SetActivity stores the tool calls run in the session
+func (s *Session) SetActivity(activity []types.Activity) {
+	if s.Metadata == nil {
+		s.Metadata = make(map[string]any)
+	}
+	if len(activity) == 0 {
+		delete(s.Metadata, "activity")
+		return
+	}
+	s.Metadata["activity"] = activity
+}
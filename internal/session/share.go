@@ -0,0 +1,222 @@
+package session
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//go:embed share.html
+var shareHTML string
+
+var shareTemplate = template.Must(template.New("share").Parse(shareHTML))
+
+// fenceRegex matches fenced code blocks and their language
+var fenceRegex = regexp.MustCompile("(?s)```([\\w+#.-]*)[^\\n]*\\n(.*?)```")
+
+// block is a run of prose or a fenced code block
+type block struct {
+	Code bool
+	Lang string
+	Text string
+}
+
+// splitBlocks splits message content into prose and code blocks
+func splitBlocks(content string) []block {
+	var blocks []block
+	last := 0
+	for _, m := range fenceRegex.FindAllStringSubmatchIndex(content, -1) {
+		if text := strings.Trim(content[last:m[0]], "\n"); text != "" {
+			blocks = append(blocks, block{Text: text})
+		}
+		blocks = append(blocks, block{
+			Code: true,
+			Lang: content[m[2]:m[3]],
+			Text: strings.TrimSuffix(content[m[4]:m[5]], "\n"),
+		})
+		last = m[1]
+	}
+	if text := strings.Trim(content[last:], "\n"); text != "" {
+		blocks = append(blocks, block{Text: text})
+	}
+	return blocks
+}
+
+// collapsed reports whether a role is shown folded: tool output and
+// injected context are usually long and secondary to the conversation
+func collapsed(role string) bool {
+	switch role {
+	case "tool", "system", "context", "bash":
+		return true
+	}
+	return false
+}
+
+// shareMessage is a message prepared for the HTML template
+type shareMessage struct {
+	Role      string
+	Label     string
+	Time      string
+	Collapsed bool
+	Blocks    []template.HTML
+}
+
+// RenderHTML renders the session as a self-contained HTML page with
+// highlighted code and collapsible tool output
+func RenderHTML(s *Session) ([]byte, error) {
+	msgs := make([]shareMessage, 0, len(s.Messages))
+	for _, msg := range s.Messages {
+		sm := shareMessage{
+			Role:      msg.Role,
+			Label:     roleLabel(msg.Role, msg.Name),
+			Collapsed: collapsed(msg.Role),
+		}
+		if !msg.Timestamp.IsZero() {
+			sm.Time = msg.Timestamp.Format("15:04")
+		}
+		for _, b := range splitBlocks(msg.Content) {
+			if b.Code {
+				sm.Blocks = append(sm.Blocks, template.HTML(fmt.Sprintf(
+					`<pre><code class="language-%s">%s</code></pre>`,
+					template.HTMLEscapeString(b.Lang), highlight(b.Text, b.Lang))))
+			} else {
+				sm.Blocks = append(sm.Blocks, template.HTML(
+					`<p>`+template.HTMLEscapeString(b.Text)+`</p>`))
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			sm.Blocks = append(sm.Blocks, template.HTML(fmt.Sprintf(
+				`<details class="call"><summary>🔧 %s</summary><pre><code>%s</code></pre></details>`,
+				template.HTMLEscapeString(call.Name), template.HTMLEscapeString(call.Arguments))))
+		}
+		msgs = append(msgs, sm)
+	}
+
+	var buf bytes.Buffer
+	err := shareTemplate.Execute(&buf, map[string]any{
+		"Title":    s.DisplayName(),
+		"Session":  s,
+		"Created":  s.CreatedAt.Format(time.RFC1123),
+		"Messages": msgs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("render session: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderMarkdown renders the session as Markdown, with tool output in
+// <details> blocks; GitHub renders this when the session is shared as a gist
+func RenderMarkdown(s *Session) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", s.DisplayName())
+	fmt.Fprintf(&sb, "*%s/%s · %s · %d messages*\n\n", s.Provider, s.Model,
+		s.CreatedAt.Format("Jan 2, 2006 15:04"), len(s.Messages))
+
+	for _, msg := range s.Messages {
+		label := roleLabel(msg.Role, msg.Name)
+		if collapsed(msg.Role) {
+			fmt.Fprintf(&sb, "<details>\n<summary>%s</summary>\n\n%s\n\n</details>\n\n", label, msg.Content)
+		} else {
+			fmt.Fprintf(&sb, "### %s\n\n%s\n\n", label, msg.Content)
+		}
+		for _, call := range msg.ToolCalls {
+			fmt.Fprintf(&sb, "<details>\n<summary>🔧 %s</summary>\n\n```json\n%s\n```\n\n</details>\n\n", call.Name, call.Arguments)
+		}
+	}
+	return sb.String()
+}
+
+// roleLabel returns the heading shown for a message
+func roleLabel(role, name string) string {
+	var label string
+	switch role {
+	case "user":
+		label = "You"
+	case "assistant":
+		label = "Agent"
+	case "tool":
+		label = "Tool output"
+	case "system":
+		label = "System"
+	default:
+		label = role
+	}
+	if name != "" {
+		label += " (" + name + ")"
+	}
+	return label
+}
+
+// Token patterns: a comment, a string, a number or a word, in that order
+const (
+	stringPattern = "\"(?:[^\"\\\\\\n]|\\\\.)*\"|'(?:[^'\\\\\\n]|\\\\.)*'|`[^`]*`"
+	tokenPattern  = "(%s)|(" + stringPattern + ")|\\b(\\d+(?:\\.\\d+)?)\\b|\\b([A-Za-z_]\\w*)\\b"
+)
+
+var (
+	slashTokens = regexp.MustCompile(fmt.Sprintf(tokenPattern, `//[^\n]*|/\*(?s:.*?)\*/`))
+	hashTokens  = regexp.MustCompile(fmt.Sprintf(tokenPattern, `#[^\n]*`))
+)
+
+// hashComments are languages whose comments start with #
+var hashComments = map[string]bool{
+	"sh": true, "bash": true, "shell": true, "zsh": true, "python": true, "py": true,
+	"ruby": true, "rb": true, "yaml": true, "yml": true, "toml": true, "dockerfile": true,
+	"makefile": true, "make": true, "perl": true, "r": true,
+}
+
+// keywords are highlighted across the common languages
+var keywords = map[string]bool{}
+
+func init() {
+	for _, kw := range strings.Fields(`
+		break case chan const continue default defer else fallthrough for func go goto
+		if import interface map package range return select struct switch type var
+		nil true false
+		class def elif except finally from in is lambda not or and pass raise try while with yield
+		None True False self async await
+		function let new this throw typeof instanceof catch export extends static null undefined
+		fn impl mut pub use mod trait enum match loop where crate
+		then fi do done esac echo local`) {
+		keywords[kw] = true
+	}
+}
+
+// highlight escapes code and wraps tokens in spans styled by share.html
+func highlight(code, lang string) template.HTML {
+	tokens := slashTokens
+	if hashComments[strings.ToLower(lang)] {
+		tokens = hashTokens
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range tokens.FindAllStringSubmatchIndex(code, -1) {
+		class := ""
+		switch {
+		case m[2] >= 0:
+			class = "c"
+		case m[4] >= 0:
+			class = "s"
+		case m[6] >= 0:
+			class = "n"
+		case keywords[code[m[0]:m[1]]]:
+			class = "k"
+		}
+		if class == "" {
+			continue
+		}
+		sb.WriteString(template.HTMLEscapeString(code[last:m[0]]))
+		sb.WriteString(`<span class="` + class + `">`)
+		sb.WriteString(template.HTMLEscapeString(code[m[0]:m[1]]))
+		sb.WriteString(`</span>`)
+		last = m[1]
+	}
+	sb.WriteString(template.HTMLEscapeString(code[last:]))
+	return template.HTML(sb.String())
+}
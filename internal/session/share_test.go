@@ -0,0 +1,75 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitBlocks(t *testing.T) {
+	blocks := splitBlocks("Try this:\n```go\nfmt.Println(1)\n```\nDone.")
+	if len(blocks) != 3 {
+		t.Fatalf("got %d blocks, want 3: %+v", len(blocks), blocks)
+	}
+	if !blocks[1].Code || blocks[1].Lang != "go" || blocks[1].Text != "fmt.Println(1)" {
+		t.Errorf("code block = %+v", blocks[1])
+	}
+	if blocks[2].Text != "Done." {
+		t.Errorf("trailing block = %+v", blocks[2])
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	got := string(highlight(`return "a<b" // done`, "go"))
+	want := `<span class="k">return</span> <span class="s">&#34;a&lt;b&#34;</span> <span class="c">// done</span>`
+	if got != want {
+		t.Errorf("highlight() =\n%s\nwant\n%s", got, want)
+	}
+
+	// # only starts a comment in languages that use it
+	if got := string(highlight("x = 1 # note", "python")); !strings.Contains(got, `<span class="c"># note</span>`) {
+		t.Errorf("python comment not highlighted: %s", got)
+	}
+	if got := string(highlight("#include <stdio.h>", "c")); strings.Contains(got, `class="c"`) {
+		t.Errorf("# should not be a comment in C: %s", got)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	s := New("/work", "ollama", "llama3")
+	s.AddMessage("user", "Fix <this>")
+	s.AddMessage("tool", "exit status 1")
+	s.AddMessage("assistant", "```go\nfunc main() {}\n```")
+
+	out, err := RenderHTML(s)
+	if err != nil {
+		t.Fatalf("RenderHTML() error = %v", err)
+	}
+	html := string(out)
+
+	for _, want := range []string{
+		"Fix &lt;this&gt;",
+		`<details class="msg tool">`,
+		`<span class="k">func</span>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+	if strings.Contains(html, "<this>") {
+		t.Error("message content was not escaped")
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	s := New("/work", "ollama", "llama3")
+	s.AddMessage("user", "Hello")
+	s.AddMessage("tool", "output")
+
+	md := RenderMarkdown(s)
+	if !strings.Contains(md, "### You\n\nHello") {
+		t.Errorf("missing user message:\n%s", md)
+	}
+	if !strings.Contains(md, "<details>\n<summary>Tool output</summary>\n\noutput") {
+		t.Errorf("tool output not collapsible:\n%s", md)
+	}
+}
@@ -0,0 +1,59 @@
+package skill
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxTranscript caps the transcript sent for distilling, about 24k tokens
+const maxTranscript = 96 << 10
+
+// DistillPrompt asks the model to turn the transcript of a session that
+// went well into a reusable skill. name, when set, is the skill's name;
+// otherwise the model picks one.
+func DistillPrompt(transcript, name string) string {
+	if len(transcript) > maxTranscript {
+		// The request and the end of the session matter most
+		head := transcript[:maxTranscript/4]
+		tail := transcript[len(transcript)-maxTranscript*3/4:]
+		transcript = fmt.Sprintf("%s\n\n... (%d bytes of the session left out) ...\n\n%s", head, len(transcript)-maxTranscript, tail)
+	}
+	naming := "a short lowercase name joined by hyphens, such as go-race-fix"
+	if name != "" {
+		naming = name
+	}
+
+	return fmt.Sprintf(`Below is the transcript of a coding session that succeeded. Distill it into a reusable skill for an AI coding assistant: the workflow that worked, the decisions that mattered and why, the checks that confirmed the result, and the dead ends to avoid. Generalize from this one task to the kind of task it is; leave out names and details that only applied here.
+
+Answer in exactly this form:
+NAME: %s
+DESCRIPTION: one line saying when to use the skill
+---
+then the skill's body, starting with "## When to Use", then "## Process" with numbered steps, then "## Pitfalls". No front-matter and no top-level heading.
+
+Transcript:
+
+%s`, naming, transcript)
+}
+
+// ParseDistilled reads the answer to DistillPrompt
+func ParseDistilled(answer string) (name, description, body string, err error) {
+	header, body, ok := strings.Cut(strings.TrimSpace(answer), "\n---")
+	if !ok {
+		return "", "", "", errors.New("the model's answer has no NAME/DESCRIPTION header")
+	}
+	for _, line := range strings.Split(header, "\n") {
+		key, value, _ := strings.Cut(line, ":")
+		switch strings.ToUpper(strings.Trim(strings.TrimSpace(key), "*")) {
+		case "NAME":
+			name = strings.Trim(strings.TrimSpace(value), "`*")
+		case "DESCRIPTION":
+			description = strings.TrimSpace(value)
+		}
+	}
+	if !ValidName(name) {
+		return "", "", "", fmt.Errorf("the model suggested an invalid skill name %q", name)
+	}
+	return name, description, strings.TrimSpace(strings.TrimPrefix(body, "-")), nil
+}
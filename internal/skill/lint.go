@@ -0,0 +1,196 @@
+package skill
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultMaxTokens is the skill size above which lint warns: every
+// token of a skill is sent with each message that uses it
+const DefaultMaxTokens = 4000
+
+// Lint severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is a problem found in a skill file
+type Issue struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+func (i Issue) String() string {
+	loc := i.Path
+	if i.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", i.Path, i.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", loc, i.Severity, i.Message, i.Rule)
+}
+
+// knownKeys are the front-matter keys skills may use
+var knownKeys = map[string]bool{
+	"name": true, "description": true, "tags": true, "examples": true,
+	"includes": true, "triggers": true, "priority": true, "auto": true,
+	"model": true,
+}
+
+var (
+	nameFormat = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	linkRegex  = regexp.MustCompile(`\]\(([^)\s]+)\)`)
+)
+
+// Lint checks the skill files under paths: front-matter that doesn't
+// parse or has unknown keys, missing names and descriptions, invalid
+// triggers, names used by more than one file, includes and relative links
+// to files that don't exist, and content over maxTokens. Issues are
+// sorted by path and line.
+func Lint(paths []string, maxTokens int) ([]Issue, error) {
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+	files, err := Discover(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	byName := make(map[string][]string)
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read skill %s: %w", path, err)
+		}
+		name, fileIssues := lintFile(path, string(data), maxTokens)
+		issues = append(issues, fileIssues...)
+		if name != "" {
+			byName[name] = append(byName[name], path)
+		}
+	}
+
+	for name, paths := range byName {
+		if len(paths) < 2 {
+			continue
+		}
+		// The loader keeps the last one, silently shadowing the others
+		for _, path := range paths[:len(paths)-1] {
+			issues = append(issues, Issue{Path: path, Severity: SeverityError, Rule: "duplicate-name",
+				Message: fmt.Sprintf("name %q is also used by %s, which replaces this skill", name, paths[len(paths)-1])})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Path != issues[j].Path {
+			return issues[i].Path < issues[j].Path
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues, nil
+}
+
+// lintFile checks one skill file, returning its name when it has one
+func lintFile(path, content string, maxTokens int) (string, []Issue) {
+	var issues []Issue
+	add := func(line int, severity, rule, format string, args ...any) {
+		issues = append(issues, Issue{Path: path, Line: line, Severity: severity, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	matches := frontMatterRegex.FindStringSubmatch(content)
+	if matches == nil {
+		add(1, SeverityError, "front-matter", "no front-matter; start the file with --- name: ... ---")
+		return "", issues
+	}
+
+	// Front-matter lines are offset by the opening ---
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(matches[1]), &doc); err != nil {
+		add(1, SeverityError, "front-matter", "invalid YAML: %v", err)
+		return "", issues
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		add(2, SeverityError, "front-matter", "front-matter must be a mapping of keys")
+		return "", issues
+	}
+	keyLine := make(map[string]int)
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i]
+		keyLine[key.Value] = key.Line + 1
+		if !knownKeys[key.Value] {
+			add(key.Line+1, SeverityWarning, "front-matter", "unknown key %q", key.Value)
+		}
+	}
+
+	var sk Skill
+	if err := root.Decode(&sk); err != nil {
+		add(1, SeverityError, "front-matter", "%v", err)
+		return "", issues
+	}
+	sk.Content = strings.TrimSpace(matches[2])
+
+	switch {
+	case sk.Name == "":
+		add(1, SeverityError, "name", "missing name")
+	case !nameFormat.MatchString(sk.Name):
+		add(keyLine["name"], SeverityWarning, "name", "name %q should be lowercase words joined by hyphens", sk.Name)
+	}
+	if strings.TrimSpace(sk.Description) == "" {
+		add(max(keyLine["description"], 1), SeverityError, "description", "missing or empty description; it is how skills are matched and listed")
+	}
+	if sk.Content == "" {
+		add(0, SeverityError, "content", "no instructions after the front-matter")
+	}
+	for i, ex := range sk.Examples {
+		if strings.TrimSpace(ex.User) == "" || strings.TrimSpace(ex.Assistant) == "" {
+			add(keyLine["examples"], SeverityError, "examples", "example %d needs both user and assistant", i+1)
+		}
+	}
+	for _, trigger := range sk.Triggers {
+		if _, err := compileTrigger(trigger); err != nil {
+			add(keyLine["triggers"], SeverityError, "triggers", "%v", err)
+		}
+	}
+	if sk.Auto != nil && *sk.Auto && len(sk.Triggers) == 0 {
+		add(keyLine["auto"], SeverityWarning, "triggers", "auto: true has no effect without triggers")
+	}
+
+	dir := filepath.Dir(path)
+	size := len(sk.Content)
+	for _, include := range sk.Includes {
+		info, err := os.Stat(filepath.Join(dir, include))
+		if err != nil || info.IsDir() {
+			add(keyLine["includes"], SeverityError, "include", "include %s not found", include)
+			continue
+		}
+		size += int(info.Size())
+	}
+
+	// Relative links in the body should point at files shipped with it
+	bodyStart := strings.Count(content[:len(content)-len(matches[2])], "\n") + 1
+	for n, line := range strings.Split(matches[2], "\n") {
+		for _, m := range linkRegex.FindAllStringSubmatch(line, -1) {
+			target, _, _ := strings.Cut(m[1], "#")
+			if target == "" || strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(dir, target)); err != nil {
+				add(bodyStart+n, SeverityError, "include", "link to %s, which doesn't exist", target)
+			}
+		}
+	}
+
+	if tokens := (size + 3) / 4; tokens > maxTokens {
+		add(0, SeverityWarning, "size", "about %d tokens (limit %d); every use sends all of it", tokens, maxTokens)
+	}
+	return sk.Name, issues
+}
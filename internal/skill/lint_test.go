@@ -0,0 +1,101 @@
+package skill
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	other := t.TempDir()
+	write := func(dir, name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(dir, "good/SKILL.md", "---\nname: good\ndescription: Fine\nincludes: [ref.md]\n---\n\nSee [the reference](ref.md) and [docs](https://example.com).\n")
+	write(dir, "good/ref.md", "Reference.\n")
+	write(dir, "bad.md", "---\nname: Bad Name\ndescription: \"\"\ncolor: red\nincludes: [missing.md]\n---\n\nRead [this](nope.md).\n")
+	write(dir, "plain.md", "Just text.\n")
+	write(dir, "big.md", "---\nname: big\ndescription: Large\n---\n\n"+strings.Repeat("word ", 200)+"\n")
+	write(other, "good.md", "---\nname: good\ndescription: Shadows the first\n---\n\nOther.\n")
+
+	issues, err := Lint([]string{dir, other}, 100)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+
+	var got []string
+	for _, i := range issues {
+		got = append(got, strings.TrimPrefix(strings.TrimPrefix(i.String(), dir+"/"), other+"/"))
+	}
+	want := []string{
+		"bad.md:2: warning: name \"Bad Name\" should be lowercase words joined by hyphens (name)",
+		"bad.md:3: error: missing or empty description; it is how skills are matched and listed (description)",
+		"bad.md:4: warning: unknown key \"color\" (front-matter)",
+		"bad.md:5: error: include missing.md not found (include)",
+		"bad.md:8: error: link to nope.md, which doesn't exist (include)",
+		"big.md: warning: about 250 tokens (limit 100); every use sends all of it (size)",
+		"good/SKILL.md: error: name \"good\" is also used by " + filepath.Join(other, "good.md") + ", which replaces this skill (duplicate-name)",
+		"plain.md:1: error: no front-matter; start the file with --- name: ... --- (front-matter)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLoader_Includes(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "s.md"), []byte("---\nname: s\nincludes: [extra.txt]\n---\n\nMain.\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "extra.txt"), []byte("Extra.\n"), 0644)
+
+	loader := NewLoader([]string{dir})
+	if err := loader.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if sk, _ := loader.Get("s"); sk == nil || sk.Content != "Main.\n\nExtra." {
+		t.Errorf("skill = %+v", sk)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	for _, body := range []string{"", "## When to Use\n\nAlways."} {
+		s, err := Parse(Template("code-review", `Review "diffs"`, body))
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		if s.Name != "code-review" || s.Description != `Review "diffs"` || !strings.HasPrefix(s.Content, "# Code Review\n\n## When to Use") {
+			t.Errorf("skill = %+v", s)
+		}
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(Template("x", "", "")), 0644)
+	if issues, err := Lint([]string{dir}, 0); err != nil || len(issues) != 0 {
+		t.Errorf("template lint = %v, %v", issues, err)
+	}
+}
+
+func TestDistill(t *testing.T) {
+	prompt := DistillPrompt(strings.Repeat("x", maxTranscript*2), "")
+	if !strings.Contains(prompt, "bytes of the session left out") || len(prompt) > maxTranscript+2000 {
+		t.Errorf("prompt is %d bytes", len(prompt))
+	}
+
+	name, description, body, err := ParseDistilled("NAME: **flaky-test-fix**\nDESCRIPTION: When a test fails only sometimes\n---\n## When to Use\n\nFlaky tests.")
+	if err != nil || name != "flaky-test-fix" || description != "When a test fails only sometimes" || body != "## When to Use\n\nFlaky tests." {
+		t.Errorf("ParseDistilled = %q, %q, %q, %v", name, description, body, err)
+	}
+	if _, _, _, err := ParseDistilled("NAME: Not Valid\n---\nbody"); err == nil {
+		t.Error("expected an error for an invalid name")
+	}
+	if _, _, _, err := ParseDistilled("just a body"); err == nil {
+		t.Error("expected an error without a header")
+	}
+}
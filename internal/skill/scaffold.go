@@ -0,0 +1,74 @@
+package skill
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValidName reports whether name is lowercase words joined by hyphens,
+// the form skill names and directories use
+func ValidName(name string) bool {
+	return nameFormat.MatchString(name)
+}
+
+// Template returns a new SKILL.md for name. body replaces the placeholder
+// sections after the heading when it isn't empty.
+func Template(name, description, body string) string {
+	if description == "" {
+		description = "When to use this skill"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	fmt.Fprintf(&sb, "name: %s\n", name)
+	fmt.Fprintf(&sb, "description: %s\n", strconv.Quote(description))
+	sb.WriteString("triggers:\n")
+	sb.WriteString("  - \"" + strings.ReplaceAll(name, "-", " ") + "\"\n")
+	sb.WriteString("priority: 50\n")
+	sb.WriteString("# Example exchanges sent with the skill:\n")
+	sb.WriteString("# examples:\n")
+	sb.WriteString("#   - user: \"...\"\n")
+	sb.WriteString("#     assistant: \"...\"\n")
+	sb.WriteString("---\n\n")
+
+	fmt.Fprintf(&sb, "# %s\n\n", title(name))
+	body = strings.TrimSpace(body)
+	if body == "" {
+		body = `## When to Use
+
+Describe the requests this skill is for, and the words that should
+trigger it (list them under ` + "`triggers:`" + ` above).
+
+## Process
+
+1. First step
+2. Second step
+3. How to check the result
+
+## Examples
+
+Show a short request and the response this skill should produce.`
+	}
+	sb.WriteString(body + "\n")
+	return sb.String()
+}
+
+// DraftPrompt asks a model for the body of a skill from a one-line
+// description
+func DraftPrompt(name, description string) string {
+	return fmt.Sprintf(`Write the instructions for an AI coding assistant skill named %q: %s
+
+The skill's markdown is sent to the assistant whenever the skill is used. Write only the body, starting with "## When to Use", then "## Process" with numbered steps, then "## Examples" with one short example request and the expected response. Be specific and concise; no front-matter and no top-level heading.`, name, description)
+}
+
+// title turns a skill name into a heading: "code-review" -> "Code Review"
+func title(name string) string {
+	words := strings.Split(name, "-")
+	for i, w := range words {
+		if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -2,28 +2,49 @@
 package skill
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/agentflow/agentflow/pkg/types"
 	"gopkg.in/yaml.v3"
 )
 
 // Skill represents a loaded skill definition
 type Skill struct {
-	Name        string   `yaml:"name"`
-	Description string   `yaml:"description"`
-	Tags        []string `yaml:"tags"`
-	Content     string   `yaml:"-"` // The markdown content after front-matter
-	Path        string   `yaml:"-"` // Source file path
+	Name        string          `yaml:"name"`
+	Description string          `yaml:"description"`
+	Tags        []string        `yaml:"tags"`
+	Examples    []types.Example `yaml:"examples"` // Few-shot exchanges used with the skill
+	Includes    []string        `yaml:"includes"` // Files appended to the content, relative to the skill
+	Triggers    []string        `yaml:"triggers"` // Keywords, or /regexps/, that activate the skill
+	Priority    int             `yaml:"priority"` // Wins when several skills match
+	Auto        *bool           `yaml:"auto"`     // Activate from triggers; default true
+	Model       string          `yaml:"model"`    // "provider/model" or alias answering while the skill is active
+	Content     string          `yaml:"-"`        // The markdown content after front-matter
+	Path        string          `yaml:"-"`        // Source file path
+
+	matchers []*regexp.Regexp // Compiled triggers
+}
+
+// BuiltinPrefix starts the Path of skills embedded in the binary
+const BuiltinPrefix = "builtin:"
+
+// Builtin reports whether the skill is embedded in the binary
+func (s *Skill) Builtin() bool {
+	return strings.HasPrefix(s.Path, BuiltinPrefix)
 }
 
 // Loader handles skill discovery and loading
 type Loader struct {
-	paths  []string
-	skills map[string]*Skill
+	paths   []string
+	builtin fs.FS // Loaded before paths, so skills there override it
+	skills  map[string]*Skill
 }
 
 // NewLoader creates a new skill loader
@@ -37,9 +58,38 @@ func NewLoader(paths []string) *Loader {
 // frontMatterRegex matches YAML front-matter between --- delimiters
 var frontMatterRegex = regexp.MustCompile(`(?s)^---\n(.+?)\n---\n(.*)$`)
 
-// Load discovers and loads all skills from configured paths
+// SetBuiltin adds the skills in fsys, one SKILL.md per directory, as
+// built-in skills that skills of the same name in the paths override
+func (l *Loader) SetBuiltin(fsys fs.FS) {
+	l.builtin = fsys
+}
+
+// Load discovers and loads all skills from the built-in skills and the
+// configured paths
 func (l *Loader) Load() error {
-	for _, basePath := range l.paths {
+	if l.builtin != nil {
+		if err := l.loadBuiltin(); err != nil {
+			return err
+		}
+	}
+	files, err := Discover(l.paths)
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		if err := l.loadFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Discover returns the skill files under paths, in load order: .md files
+// and SKILL.md files one directory down for directories, and .md files
+// named directly. Paths that don't exist are skipped.
+func Discover(paths []string) ([]string, error) {
+	var files []string
+	for _, basePath := range paths {
 		// Expand ~ in path
 		if strings.HasPrefix(basePath, "~") {
 			if home, err := os.UserHomeDir(); err == nil {
@@ -53,45 +103,30 @@ func (l *Loader) Load() error {
 			continue // Skip non-existent paths
 		}
 
-		if info.IsDir() {
-			// Load all SKILL.md files in directory
-			if err := l.loadDir(basePath); err != nil {
-				return err
-			}
-		} else if strings.HasSuffix(basePath, ".md") {
-			// Load single file
-			if err := l.loadFile(basePath); err != nil {
-				return err
+		if !info.IsDir() {
+			if strings.HasSuffix(basePath, ".md") {
+				files = append(files, basePath)
 			}
+			continue
 		}
-	}
-
-	return nil
-}
-
-func (l *Loader) loadDir(dir string) error {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return fmt.Errorf("read skills dir %s: %w", dir, err)
-	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			// Check for SKILL.md in subdirectory
-			skillPath := filepath.Join(dir, entry.Name(), "SKILL.md")
-			if _, err := os.Stat(skillPath); err == nil {
-				if err := l.loadFile(skillPath); err != nil {
-					return err
+		entries, err := os.ReadDir(basePath)
+		if err != nil {
+			return nil, fmt.Errorf("read skills dir %s: %w", basePath, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				// Check for SKILL.md in subdirectory
+				skillPath := filepath.Join(basePath, entry.Name(), "SKILL.md")
+				if _, err := os.Stat(skillPath); err == nil {
+					files = append(files, skillPath)
 				}
-			}
-		} else if strings.HasSuffix(entry.Name(), ".md") {
-			if err := l.loadFile(filepath.Join(dir, entry.Name())); err != nil {
-				return err
+			} else if strings.HasSuffix(entry.Name(), ".md") {
+				files = append(files, filepath.Join(basePath, entry.Name()))
 			}
 		}
 	}
-
-	return nil
+	return files, nil
 }
 
 func (l *Loader) loadFile(path string) error {
@@ -99,13 +134,54 @@ func (l *Loader) loadFile(path string) error {
 	if err != nil {
 		return fmt.Errorf("read skill %s: %w", path, err)
 	}
+	return l.add(data, path, func(include string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(filepath.Dir(path), include))
+	})
+}
 
+// loadBuiltin loads the SKILL.md of each directory of the built-in skills
+func (l *Loader) loadBuiltin() error {
+	entries, err := fs.ReadDir(l.builtin, ".")
+	if err != nil {
+		return fmt.Errorf("read built-in skills: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := path.Join(entry.Name(), "SKILL.md")
+		data, err := fs.ReadFile(l.builtin, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("read skill %s: %w", name, err)
+		}
+		err = l.add(data, BuiltinPrefix+name, func(include string) ([]byte, error) {
+			return fs.ReadFile(l.builtin, path.Join(entry.Name(), include))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add parses a skill and its includes, replacing any skill of that name
+func (l *Loader) add(data []byte, path string, readInclude func(string) ([]byte, error)) error {
 	skill, err := Parse(string(data))
 	if err != nil {
 		return fmt.Errorf("parse skill %s: %w", path, err)
 	}
 
 	skill.Path = path
+	for _, include := range skill.Includes {
+		data, err := readInclude(include)
+		if err != nil {
+			return fmt.Errorf("skill %s: include %s: %w", path, include, err)
+		}
+		skill.Content += "\n\n" + strings.TrimSpace(string(data))
+	}
 	l.skills[skill.Name] = skill
 	return nil
 }
@@ -125,6 +201,9 @@ func Parse(content string) (*Skill, error) {
 	if err := yaml.Unmarshal([]byte(matches[1]), &skill); err != nil {
 		return nil, fmt.Errorf("parse front-matter: %w", err)
 	}
+	if err := skill.compileTriggers(); err != nil {
+		return nil, err
+	}
 
 	skill.Content = strings.TrimSpace(matches[2])
 	return &skill, nil
@@ -3,7 +3,9 @@ package skill
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestParse_WithFrontMatter(t *testing.T) {
@@ -42,6 +44,29 @@ Always think before you code.
 	}
 }
 
+func TestParse_Examples(t *testing.T) {
+	content := `---
+name: commit-messages
+examples:
+  - user: "Fixed the login bug"
+    assistant: "fix(auth): reject expired sessions on login"
+---
+
+Write conventional commit messages.
+`
+
+	skill, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(skill.Examples) != 1 {
+		t.Fatalf("examples len = %d, want 1", len(skill.Examples))
+	}
+	if skill.Examples[0].Assistant != "fix(auth): reject expired sessions on login" {
+		t.Errorf("example = %+v", skill.Examples[0])
+	}
+}
+
 func TestParse_WithoutFrontMatter(t *testing.T) {
 	content := `# Simple Skill
 
@@ -224,3 +249,104 @@ func TestLoader_NonExistentPath(t *testing.T) {
 		t.Error("expected no skills")
 	}
 }
+
+func TestLoader_Select(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, frontMatter string) {
+		os.WriteFile(filepath.Join(dir, name+".md"), []byte("---\nname: "+name+"\n"+frontMatter+"---\n\nDo it.\n"), 0644)
+	}
+	write("planning", "triggers: [plan, \"break down\"]\npriority: 90\n")
+	write("debugging", "triggers: [\"/(fails?|panic)\\\\b/\", plan]\npriority: 50\n")
+	write("manual", "triggers: [deploy]\nauto: false\n")
+	write("c-plus-plus", "triggers: [c++]\n")
+	write("another", "triggers: [plan]\npriority: 50\n")
+
+	loader := NewLoader([]string{dir})
+	if err := loader.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tests := []struct {
+		input   string
+		skills  string
+		trigger string
+	}{
+		{"Break Down this feature", "planning", "break down"},
+		{"make a plan", "planning another debugging", "plan"},
+		{"the test fails", "debugging", "/(fails?|panic)\\b/"},
+		{"a planet", "", ""},
+		{"deploy it", "", ""},
+		{"port it to C++", "c-plus-plus", "c++"},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, sk := range loader.Select(tt.input) {
+			names = append(names, sk.Name)
+		}
+		if strings.Join(names, " ") != tt.skills {
+			t.Errorf("Select(%q) = %v, want %s", tt.input, names, tt.skills)
+			continue
+		}
+		if len(names) > 0 {
+			if sk, _ := loader.Get(names[0]); sk.Trigger(tt.input) != tt.trigger {
+				t.Errorf("Trigger(%q) = %q, want %q", tt.input, sk.Trigger(tt.input), tt.trigger)
+			}
+		}
+	}
+
+	if _, err := Parse("---\nname: x\ntriggers: [\"/(/\"]\n---\n"); err == nil {
+		t.Error("expected an error for an invalid trigger regexp")
+	}
+}
+
+func TestStats(t *testing.T) {
+	stats := NewStats(filepath.Join(t.TempDir(), "stats.json"))
+	if usage, err := stats.Load(); err != nil || len(usage) != 0 {
+		t.Fatalf("Load = %v, %v", usage, err)
+	}
+
+	stats.Update(func(usage map[string]*Usage) { Get(usage, "plan").Activations++ })
+	stats.Rate([]string{"plan", "review"}, true)
+	stats.Rate([]string{"plan"}, false)
+
+	usage, err := stats.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := usage["plan"]; u == nil || u.Activations != 1 || u.Good != 1 || u.Bad != 1 {
+		t.Errorf("plan = %+v", u)
+	}
+	if u := usage["review"]; u == nil || u.Good != 1 {
+		t.Errorf("review = %+v", u)
+	}
+}
+
+func TestLoader_BuiltinOverridden(t *testing.T) {
+	builtin := fstest.MapFS{
+		"debugging/SKILL.md": {Data: []byte("---\nname: debugging\ndescription: Built-in\nincludes: [steps.md]\n---\nBuilt-in content.")},
+		"debugging/steps.md": {Data: []byte("Step one.")},
+		"planning/SKILL.md":  {Data: []byte("---\nname: planning\ndescription: Built-in\n---\nPlan.")},
+	}
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "planning"), 0755)
+	os.WriteFile(filepath.Join(dir, "planning", "SKILL.md"), []byte("---\nname: planning\ndescription: Project\n---\nOurs."), 0644)
+
+	loader := NewLoader([]string{dir})
+	loader.SetBuiltin(builtin)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	debugging, ok := loader.Get("debugging")
+	if !ok || !debugging.Builtin() {
+		t.Fatalf("built-in skill not loaded: %+v", debugging)
+	}
+	if !strings.Contains(debugging.Content, "Step one.") {
+		t.Errorf("include not read from built-in skills: %q", debugging.Content)
+	}
+	planning, _ := loader.Get("planning")
+	if planning.Description != "Project" || planning.Builtin() {
+		t.Errorf("project skill should override the built-in one: %+v", planning)
+	}
+}
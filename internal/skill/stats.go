@@ -0,0 +1,96 @@
+package skill
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Usage is what is known about how a skill has been working out
+type Usage struct {
+	Activations int       `json:"activations"`
+	Retries     int       `json:"retries"` // The user pushed back on the answer
+	Good        int       `json:"good"`
+	Bad         int       `json:"bad"`
+	Tokens      int       `json:"tokens"` // Estimated instruction tokens sent
+	LastUsed    time.Time `json:"last_used"`
+}
+
+// Stats records skill usage in a JSON file shared by every session.
+// Each update re-reads the file, so concurrent sessions add up.
+type Stats struct {
+	path string
+}
+
+// DefaultStatsPath is where usage is kept, under the home directory
+func DefaultStatsPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".agentflow", "skill-stats.json")
+}
+
+// NewStats records usage in path; "" uses DefaultStatsPath
+func NewStats(path string) *Stats {
+	if path == "" {
+		path = DefaultStatsPath()
+	}
+	return &Stats{path: path}
+}
+
+// Load returns the usage of every skill recorded so far
+func (s *Stats) Load() (map[string]*Usage, error) {
+	usage := make(map[string]*Usage)
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return usage, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return nil, fmt.Errorf("parse skill stats: %w", err)
+	}
+	return usage, nil
+}
+
+// Update applies fn to the recorded usage and saves it
+func (s *Stats) Update(fn func(usage map[string]*Usage)) error {
+	usage, err := s.Load()
+	if err != nil {
+		return err
+	}
+	fn(usage)
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("create stats dir: %w", err)
+	}
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// Get returns the usage of a skill in usage, adding it if needed
+func Get(usage map[string]*Usage, name string) *Usage {
+	u, ok := usage[name]
+	if !ok {
+		u = &Usage{}
+		usage[name] = u
+	}
+	return u
+}
+
+// Rate records a thumbs up or down for skills
+func (s *Stats) Rate(names []string, good bool) error {
+	return s.Update(func(usage map[string]*Usage) {
+		for _, name := range names {
+			if good {
+				Get(usage, name).Good++
+			} else {
+				Get(usage, name).Bad++
+			}
+		}
+	})
+}
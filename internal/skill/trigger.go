@@ -0,0 +1,85 @@
+package skill
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AutoActivates reports whether the skill is activated by its triggers;
+// a skill with auto: false is only used when selected by name
+func (s *Skill) AutoActivates() bool {
+	return (s.Auto == nil || *s.Auto) && len(s.Triggers) > 0
+}
+
+// compileTriggers compiles the trigger patterns. A trigger written as
+// /pattern/ is a case-insensitive regexp; any other is a keyword or
+// phrase matched as whole words, ignoring case.
+func (s *Skill) compileTriggers() error {
+	s.matchers = s.matchers[:0]
+	for _, trigger := range s.Triggers {
+		re, err := compileTrigger(trigger)
+		if err != nil {
+			return err
+		}
+		s.matchers = append(s.matchers, re)
+	}
+	return nil
+}
+
+func compileTrigger(trigger string) (*regexp.Regexp, error) {
+	if len(trigger) > 2 && strings.HasPrefix(trigger, "/") && strings.HasSuffix(trigger, "/") {
+		re, err := regexp.Compile("(?i)" + trigger[1:len(trigger)-1])
+		if err != nil {
+			return nil, fmt.Errorf("trigger %s: %w", trigger, err)
+		}
+		return re, nil
+	}
+
+	keyword := strings.TrimSpace(trigger)
+	if keyword == "" {
+		return nil, fmt.Errorf("empty trigger")
+	}
+	pattern := regexp.QuoteMeta(keyword)
+	// \b only holds next to word characters: "c++" can't end with one
+	if isWordByte(keyword[0]) {
+		pattern = `\b` + pattern
+	}
+	if isWordByte(keyword[len(keyword)-1]) {
+		pattern += `\b`
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// Trigger returns the first trigger that matches input, or ""
+func (s *Skill) Trigger(input string) string {
+	for i, re := range s.matchers {
+		if re.MatchString(input) {
+			return s.Triggers[i]
+		}
+	}
+	return ""
+}
+
+// Select returns the skills that auto-activate and have a trigger
+// matching input, highest priority first, ties in name order
+func (l *Loader) Select(input string) []*Skill {
+	var matched []*Skill
+	for _, s := range l.skills {
+		if s.AutoActivates() && s.Trigger(input) != "" {
+			matched = append(matched, s)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].Priority != matched[j].Priority {
+			return matched[i].Priority > matched[j].Priority
+		}
+		return matched[i].Name < matched[j].Name
+	})
+	return matched
+}
@@ -0,0 +1,153 @@
+// Package statusline renders the TUI status bar from a template, or from
+// an external command given the session as JSON, the way shell prompt
+// frameworks draw prompts
+package statusline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// commandTimeout bounds one run of the external command
+const commandTimeout = 2 * time.Second
+
+// refreshEvery is how long a command's output or the git branch is kept
+// when nothing else changed
+const refreshEvery = 10 * time.Second
+
+// Info is what the status line can show. Templates use the field names,
+// as in {{.Model}}, and dollars to format Cost; commands receive it as
+// JSON on stdin.
+type Info struct {
+	Provider      string  `json:"provider"`
+	Model         string  `json:"model"`
+	Workdir       string  `json:"workdir"`
+	Branch        string  `json:"branch,omitempty"`
+	Messages      int     `json:"messages"`
+	Tokens        int     `json:"tokens"`         // Used this session, prompts and answers
+	ContextTokens int     `json:"context_tokens"` // Size of the conversation sent with the next message
+	ContextWindow int     `json:"context_window,omitempty"`
+	Context       int     `json:"context_percent"` // ContextTokens as a percentage of ContextWindow, 0 when unknown
+	Cost          float64 `json:"cost"`            // Dollars spent this session, from the models' prices
+	Skill         string  `json:"skill,omitempty"` // Last skill activated
+	Streaming     bool    `json:"streaming"`
+}
+
+// Line renders status lines. Commands run in the background; until the
+// first run finishes, and when it fails, the template is used.
+type Line struct {
+	format  *template.Template
+	command string
+
+	mu       sync.Mutex
+	input    []byte // What the cached output was made from
+	output   string
+	ran      time.Time
+	running  bool
+	branch   string
+	branchAt time.Time
+}
+
+// New returns a Line rendering format, a text/template over Info, or
+// running command with sh when set. Both empty is an error.
+func New(format, command string) (*Line, error) {
+	if format == "" && command == "" {
+		return nil, errors.New("statusline: needs a format or a command")
+	}
+	l := &Line{command: command}
+	if format != "" {
+		t, err := template.New("statusline").Funcs(funcs).Parse(format)
+		if err != nil {
+			return nil, fmt.Errorf("statusline format: %w", err)
+		}
+		l.format = t
+	}
+	return l, nil
+}
+
+// funcs are the functions templates can call besides the built-in ones
+var funcs = template.FuncMap{
+	"dollars": func(amount float64) string { return fmt.Sprintf("$%.2f", amount) },
+}
+
+// Render returns the status line for info, filling in the git branch of
+// info.Workdir. When the command's cached output is stale, it is run
+// again in the background and refreshed is called once it has finished,
+// to redraw. The result is "" when there is nothing to show yet.
+func (l *Line) Render(info Info, refreshed func()) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if time.Since(l.branchAt) > refreshEvery {
+		l.branch, l.branchAt = Branch(info.Workdir), time.Now()
+	}
+	info.Branch = l.branch
+
+	if l.command != "" {
+		input, _ := json.Marshal(info)
+		if !l.running && (!bytes.Equal(input, l.input) || time.Since(l.ran) > refreshEvery) {
+			l.running = true
+			go l.run(input, info.Workdir, refreshed)
+		}
+		if l.output != "" || l.format == nil {
+			return l.output
+		}
+	}
+	return l.execute(info)
+}
+
+// execute renders the template, or the error when it fails
+func (l *Line) execute(info Info) string {
+	var b strings.Builder
+	if err := l.format.Execute(&b, info); err != nil {
+		return err.Error()
+	}
+	return firstLine(b.String())
+}
+
+// run runs the command on input and caches the first line it prints;
+// a failure is cached as "" so the template is used
+func (l *Line) run(input []byte, dir string, refreshed func()) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", l.command)
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(input)
+	out, err := cmd.Output()
+
+	l.mu.Lock()
+	l.input, l.ran, l.running = input, time.Now(), false
+	l.output = ""
+	if err == nil {
+		l.output = firstLine(string(out))
+	}
+	l.mu.Unlock()
+	if refreshed != nil {
+		refreshed()
+	}
+}
+
+// Branch returns the git branch checked out in dir, or "" outside a
+// repository
+func Branch(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimRight(s, "\r")
+}
@@ -0,0 +1,47 @@
+package statusline
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLine_Format(t *testing.T) {
+	l, err := New(`{{.Model}} · {{.Context}}% · {{dollars .Cost}}`, "")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	got := l.Render(Info{Model: "gpt-4o", Context: 42, Cost: 0.126, Workdir: t.TempDir()}, nil)
+	if got != "gpt-4o · 42% · $0.13" {
+		t.Errorf("Render = %q", got)
+	}
+
+	if _, err := New("{{.Model", ""); err == nil {
+		t.Error("a broken template was accepted")
+	}
+	if _, err := New("", ""); err == nil {
+		t.Error("a Line with neither format nor command was accepted")
+	}
+}
+
+func TestLine_Command(t *testing.T) {
+	l, err := New("{{.Model}}", `grep -o '"model":"[^"]*"'; echo ignored`)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	done := make(chan struct{}, 1)
+	info := Info{Model: "llama3", Workdir: t.TempDir()}
+
+	// The template shows until the command has run
+	if got := l.Render(info, func() { done <- struct{}{} }); got != "llama3" {
+		t.Errorf("before the command: %q", got)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the command didn't finish")
+	}
+	if got := l.Render(info, nil); !strings.Contains(got, `"model":"llama3"`) || strings.Contains(got, "ignored") {
+		t.Errorf("after the command: %q", got)
+	}
+}
@@ -0,0 +1,48 @@
+package subagent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultPendingPath is where interrupted tasks are kept, under the home
+// directory
+func DefaultPendingPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".agentflow", "pending-tasks.json")
+}
+
+// SavePending writes tasks to run later to path, adding them to those
+// already saved there
+func SavePending(path string, tasks []Task) error {
+	saved, err := LoadPending(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create pending dir: %w", err)
+	}
+	data, err := json.MarshalIndent(append(saved, tasks...), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadPending reads the tasks saved in path; none when it doesn't exist
+func LoadPending(path string) ([]Task, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tasks []Task
+	if err := json.Unmarshal(data, &tasks); err != nil {
+		return nil, fmt.Errorf("parse pending tasks: %w", err)
+	}
+	return tasks, nil
+}
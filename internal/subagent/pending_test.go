@@ -0,0 +1,43 @@
+package subagent
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agentflow/agentflow/pkg/providertest"
+)
+
+func TestPool_InterruptedTasksSaved(t *testing.T) {
+	p := &providertest.Provider{ProviderName: "pending-test", Latency: time.Second}
+	pool := NewPool(PoolConfig{Provider: p, Model: "test", MaxAgents: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	pool.SpawnBatch(ctx, []Task{{ID: "running", Message: "a"}, {ID: "queued", Message: "b"}})
+
+	interrupted := pool.Interrupted()
+	if len(interrupted) != 2 {
+		t.Fatalf("interrupted = %+v, want both tasks", interrupted)
+	}
+
+	path := filepath.Join(t.TempDir(), "pending.json")
+	if err := SavePending(path, interrupted[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := SavePending(path, interrupted[1:]); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPending(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 2 || loaded[0].Message == "" {
+		t.Errorf("loaded = %+v", loaded)
+	}
+
+	if tasks, err := LoadPending(filepath.Join(t.TempDir(), "none.json")); err != nil || tasks != nil {
+		t.Errorf("missing file: %v, %v", tasks, err)
+	}
+}
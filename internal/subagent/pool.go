@@ -15,11 +15,11 @@ import (
 
 // Task represents a task to be executed by a subagent
 type Task struct {
-	ID          string
-	Description string
-	SkillName   string
-	Message     string
-	Metadata    map[string]string
+	ID          string            `json:"id"`
+	Description string            `json:"description,omitempty"`
+	SkillName   string            `json:"skill,omitempty"`
+	Message     string            `json:"message"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
 }
 
 // Result represents the result of a subagent task
@@ -39,9 +39,17 @@ type Pool struct {
 	model       string
 	skills      *skill.Loader
 	maxAgents   int
+	limit       int // Agents allowed at once, below maxAgents when adaptive
 	activeCount int
+	queued      int
+	wake        chan struct{} // Closed when a slot frees up
 	results     map[string]*Result
+	interrupted []Task // Cancelled before finishing, to run again
 	systemPrompt string
+
+	adaptive    bool
+	fastest     time.Duration // Quickest task seen, the latency baseline
+	rateLimited int           // Provider 429s already reacted to
 }
 
 // PoolConfig holds pool configuration
@@ -51,6 +59,11 @@ type PoolConfig struct {
 	Skills       *skill.Loader
 	MaxAgents    int
 	SystemPrompt string
+
+	// Adaptive starts at half of MaxAgents and sizes the pool from what
+	// the provider allows: it halves when the provider rate limits, and
+	// grows by one per task while latencies stay under twice the fastest.
+	Adaptive bool
 }
 
 // NewPool creates a new subagent pool
@@ -58,31 +71,105 @@ func NewPool(cfg PoolConfig) *Pool {
 	if cfg.MaxAgents <= 0 {
 		cfg.MaxAgents = 5
 	}
+	limit := cfg.MaxAgents
+	if cfg.Adaptive {
+		limit = max(cfg.MaxAgents/2, 1)
+	}
 	return &Pool{
 		provider:     cfg.Provider,
 		model:        cfg.Model,
 		skills:       cfg.Skills,
 		maxAgents:    cfg.MaxAgents,
+		limit:        limit,
+		wake:         make(chan struct{}),
 		results:      make(map[string]*Result),
 		systemPrompt: cfg.SystemPrompt,
+		adaptive:     cfg.Adaptive,
+		rateLimited:  provider.MetricsFor(cfg.Provider.Name()).RateLimited,
 	}
 }
 
-// Spawn creates a new subagent and executes a task
+// Spawn creates a new subagent and executes a task, failing if the pool
+// is full
 func (p *Pool) Spawn(ctx context.Context, task Task) (*Result, error) {
 	p.mu.Lock()
-	if p.activeCount >= p.maxAgents {
+	if p.activeCount >= p.limit {
 		p.mu.Unlock()
-		return nil, fmt.Errorf("pool exhausted: max %d agents", p.maxAgents)
+		return nil, fmt.Errorf("pool exhausted: max %d agents", p.limit)
 	}
 	p.activeCount++
 	p.mu.Unlock()
 
+	return p.run(ctx, task)
+}
+
+// wait takes a slot in the pool, queueing until one is free
+func (p *Pool) wait(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.activeCount < p.limit {
+		p.activeCount++
+		return nil
+	}
+
+	p.queued++
+	provider.TrackQueued(p.provider.Name(), 1)
 	defer func() {
-		p.mu.Lock()
-		p.activeCount--
-		p.mu.Unlock()
+		p.queued--
+		provider.TrackQueued(p.provider.Name(), -1)
 	}()
+	for p.activeCount >= p.limit {
+		wake := p.wake
+		p.mu.Unlock()
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			p.mu.Lock()
+			return ctx.Err()
+		}
+		p.mu.Lock()
+	}
+	p.activeCount++
+	return nil
+}
+
+// release frees a slot, resizing the pool from the task's result when
+// adaptive, and wakes the queued tasks
+func (p *Pool) release(result *Result) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.activeCount--
+	if p.adaptive && result != nil {
+		p.adapt(result)
+	}
+	close(p.wake)
+	p.wake = make(chan struct{})
+}
+
+// adapt resizes an adaptive pool after a task. p.mu must be held.
+func (p *Pool) adapt(result *Result) {
+	limited := provider.MetricsFor(p.provider.Name()).RateLimited
+	switch {
+	case limited > p.rateLimited:
+		p.rateLimited = limited
+		p.limit /= 2
+	case result.Error != nil:
+		// Failures other than rate limits say nothing about capacity
+	case p.fastest == 0 || result.Duration < p.fastest:
+		p.fastest = result.Duration
+		p.limit++
+	case result.Duration <= 2*p.fastest:
+		p.limit++
+	default:
+		// Slowing down: the provider is saturated
+		p.limit--
+	}
+	p.limit = min(max(p.limit, 1), p.maxAgents)
+}
+
+// run executes a task in a slot already taken, freeing it after
+func (p *Pool) run(ctx context.Context, task Task) (result *Result, err error) {
+	defer func() { p.release(result) }()
 
 	// Create fresh agent for this task
 	agentID := fmt.Sprintf("subagent-%s-%d", task.ID, time.Now().UnixNano())
@@ -104,15 +191,17 @@ func (p *Pool) Spawn(ctx context.Context, task Task) (*Result, error) {
 	startedAt := time.Now()
 	
 	var resp *types.CompletionResponse
-	var err error
-
 	if task.SkillName != "" {
 		resp, err = a.RunWithSkill(ctx, task.SkillName, task.Message)
 	} else {
 		resp, err = a.Run(ctx, task.Message)
 	}
 
-	result := &Result{
+	if err != nil && ctx.Err() != nil {
+		p.interrupt(task)
+	}
+
+	result = &Result{
 		TaskID:    task.ID,
 		AgentID:   agentID,
 		Response:  resp,
@@ -140,7 +229,8 @@ func (p *Pool) SpawnAsync(ctx context.Context, task Task) <-chan *Result {
 	return ch
 }
 
-// SpawnBatch spawns multiple subagents for parallel execution
+// SpawnBatch spawns multiple subagents for parallel execution. Tasks
+// beyond the pool's size wait for a free slot.
 func (p *Pool) SpawnBatch(ctx context.Context, tasks []Task) []*Result {
 	var wg sync.WaitGroup
 	results := make([]*Result, len(tasks))
@@ -149,7 +239,12 @@ func (p *Pool) SpawnBatch(ctx context.Context, tasks []Task) []*Result {
 		wg.Add(1)
 		go func(idx int, t Task) {
 			defer wg.Done()
-			result, _ := p.Spawn(ctx, t)
+			if err := p.wait(ctx); err != nil {
+				p.interrupt(t)
+				results[idx] = &Result{TaskID: t.ID, Error: err}
+				return
+			}
+			result, _ := p.run(ctx, t)
 			results[idx] = result
 		}(i, task)
 	}
@@ -158,6 +253,21 @@ func (p *Pool) SpawnBatch(ctx context.Context, tasks []Task) []*Result {
 	return results
 }
 
+// interrupt records a task cancelled before it finished
+func (p *Pool) interrupt(task Task) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.interrupted = append(p.interrupted, task)
+}
+
+// Interrupted returns the tasks that were cancelled, while running or
+// waiting for a slot, before they finished
+func (p *Pool) Interrupted() []Task {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]Task(nil), p.interrupted...)
+}
+
 // GetResult retrieves a stored result by task ID
 func (p *Pool) GetResult(taskID string) (*Result, bool) {
 	p.mu.RLock()
@@ -183,6 +293,8 @@ func (p *Pool) ClearResults() {
 // Stats returns pool statistics
 type Stats struct {
 	Active    int
+	Queued    int // Batch tasks waiting for a slot
+	Limit     int // Current size; below MaxAgents when adaptive
 	MaxAgents int
 	Results   int
 }
@@ -192,6 +304,8 @@ func (p *Pool) Stats() Stats {
 	defer p.mu.RUnlock()
 	return Stats{
 		Active:    p.activeCount,
+		Queued:    p.queued,
+		Limit:     p.limit,
 		MaxAgents: p.maxAgents,
 		Results:   len(p.results),
 	}
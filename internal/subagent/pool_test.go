@@ -3,51 +3,14 @@ package subagent
 import (
 	"context"
 	"errors"
-	"sync/atomic"
 	"testing"
 	"time"
 
-	"github.com/agentflow/agentflow/pkg/types"
+	"github.com/agentflow/agentflow/pkg/providertest"
 )
 
-// mockProvider implements provider.Provider for testing
-type mockProvider struct {
-	name     string
-	response string
-	delay    time.Duration
-	err      error
-	calls    int32
-}
-
-func (m *mockProvider) Name() string         { return m.name }
-func (m *mockProvider) Models() []string     { return []string{"test-model"} }
-func (m *mockProvider) SupportsModel(string) bool { return true }
-
-func (m *mockProvider) Complete(ctx context.Context, req types.CompletionRequest) (*types.CompletionResponse, error) {
-	atomic.AddInt32(&m.calls, 1)
-	if m.delay > 0 {
-		select {
-		case <-time.After(m.delay):
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		}
-	}
-	if m.err != nil {
-		return nil, m.err
-	}
-	return &types.CompletionResponse{
-		Content:      m.response,
-		Model:        req.Model,
-		FinishReason: "stop",
-	}, nil
-}
-
-func (m *mockProvider) Stream(ctx context.Context, req types.CompletionRequest) (<-chan types.StreamChunk, error) {
-	return nil, errors.New("not implemented")
-}
-
 func TestNewPool(t *testing.T) {
-	p := &mockProvider{name: "test"}
+	p := &providertest.Provider{}
 	pool := NewPool(PoolConfig{
 		Provider:  p,
 		Model:     "test-model",
@@ -68,7 +31,7 @@ func TestNewPool(t *testing.T) {
 }
 
 func TestPool_DefaultMaxAgents(t *testing.T) {
-	p := &mockProvider{name: "test"}
+	p := &providertest.Provider{}
 	pool := NewPool(PoolConfig{
 		Provider: p,
 		Model:    "test",
@@ -81,7 +44,7 @@ func TestPool_DefaultMaxAgents(t *testing.T) {
 }
 
 func TestPool_Spawn(t *testing.T) {
-	p := &mockProvider{name: "test", response: "Task completed!"}
+	p := providertest.New("Task completed!")
 	pool := NewPool(PoolConfig{
 		Provider:  p,
 		Model:     "test-model",
@@ -115,7 +78,7 @@ func TestPool_Spawn(t *testing.T) {
 
 func TestPool_SpawnWithError(t *testing.T) {
 	expectedErr := errors.New("provider error")
-	p := &mockProvider{name: "test", err: expectedErr}
+	p := providertest.Failing(expectedErr)
 	pool := NewPool(PoolConfig{Provider: p, Model: "test"})
 
 	task := Task{ID: "error-task", Message: "This will fail"}
@@ -134,7 +97,7 @@ func TestPool_SpawnWithError(t *testing.T) {
 }
 
 func TestPool_MaxAgentsLimit(t *testing.T) {
-	p := &mockProvider{name: "test", response: "ok", delay: 100 * time.Millisecond}
+	p := &providertest.Provider{Latency: 100 * time.Millisecond}
 	pool := NewPool(PoolConfig{
 		Provider:  p,
 		Model:     "test",
@@ -169,7 +132,7 @@ func TestPool_MaxAgentsLimit(t *testing.T) {
 }
 
 func TestPool_SpawnAsync(t *testing.T) {
-	p := &mockProvider{name: "test", response: "async result"}
+	p := providertest.New("async result")
 	pool := NewPool(PoolConfig{Provider: p, Model: "test"})
 
 	task := Task{ID: "async-1", Message: "async task"}
@@ -185,7 +148,7 @@ func TestPool_SpawnAsync(t *testing.T) {
 }
 
 func TestPool_SpawnBatch(t *testing.T) {
-	p := &mockProvider{name: "test", response: "batch result"}
+	p := providertest.New("batch result")
 	pool := NewPool(PoolConfig{Provider: p, Model: "test", MaxAgents: 10})
 
 	tasks := []Task{
@@ -210,13 +173,13 @@ func TestPool_SpawnBatch(t *testing.T) {
 		}
 	}
 
-	if atomic.LoadInt32(&p.calls) != 3 {
-		t.Errorf("expected 3 provider calls, got %d", p.calls)
+	if p.Calls() != 3 {
+		t.Errorf("expected 3 provider calls, got %d", p.Calls())
 	}
 }
 
 func TestPool_GetResult(t *testing.T) {
-	p := &mockProvider{name: "test", response: "stored"}
+	p := providertest.New("stored")
 	pool := NewPool(PoolConfig{Provider: p, Model: "test"})
 
 	task := Task{ID: "store-1", Message: "store this"}
@@ -238,7 +201,7 @@ func TestPool_GetResult(t *testing.T) {
 }
 
 func TestPool_ClearResults(t *testing.T) {
-	p := &mockProvider{name: "test", response: "ok"}
+	p := providertest.New("ok")
 	pool := NewPool(PoolConfig{Provider: p, Model: "test"})
 
 	pool.Spawn(context.Background(), Task{ID: "clear-1", Message: "a"})
@@ -258,7 +221,7 @@ func TestPool_ClearResults(t *testing.T) {
 }
 
 func TestPool_ActiveCount(t *testing.T) {
-	p := &mockProvider{name: "test", response: "ok", delay: 50 * time.Millisecond}
+	p := &providertest.Provider{Latency: 50 * time.Millisecond}
 	pool := NewPool(PoolConfig{Provider: p, Model: "test", MaxAgents: 5})
 
 	// Initially zero
@@ -283,7 +246,7 @@ func TestPool_ActiveCount(t *testing.T) {
 }
 
 func TestPool_ContextCancellation(t *testing.T) {
-	p := &mockProvider{name: "test", response: "ok", delay: 1 * time.Second}
+	p := &providertest.Provider{Latency: 1 * time.Second}
 	pool := NewPool(PoolConfig{Provider: p, Model: "test"})
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -304,3 +267,44 @@ func TestPool_ContextCancellation(t *testing.T) {
 		t.Error("task should have been cancelled")
 	}
 }
+
+func TestPool_SpawnBatchQueues(t *testing.T) {
+	p := &providertest.Provider{ProviderName: "queue-test", Latency: 30 * time.Millisecond}
+	pool := NewPool(PoolConfig{Provider: p, Model: "test", MaxAgents: 2})
+
+	tasks := []Task{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}, {ID: "5"}}
+	results := pool.SpawnBatch(context.Background(), tasks)
+	for i, result := range results {
+		if result == nil || result.Error != nil {
+			t.Errorf("result[%d] = %+v, want it run after waiting", i, result)
+		}
+	}
+	if stats := pool.Stats(); stats.Queued != 0 || stats.Active != 0 {
+		t.Errorf("stats after batch = %+v", stats)
+	}
+}
+
+func TestPool_Adaptive(t *testing.T) {
+	p := &providertest.Provider{ProviderName: "adaptive-test", Latency: 10 * time.Millisecond}
+	pool := NewPool(PoolConfig{Provider: p, Model: "test", MaxAgents: 8, Adaptive: true})
+	if got := pool.Stats().Limit; got != 4 {
+		t.Fatalf("initial limit = %d, want 4", got)
+	}
+
+	// Steady latencies grow the pool up to MaxAgents
+	for i := 0; i < 6; i++ {
+		pool.Spawn(context.Background(), Task{ID: "t"})
+	}
+	if got := pool.Stats().Limit; got != 8 {
+		t.Errorf("limit after fast tasks = %d, want 8", got)
+	}
+
+	// A much slower task shrinks it
+	pool.mu.Lock()
+	pool.adapt(&Result{Duration: 10 * pool.fastest})
+	limit := pool.limit
+	pool.mu.Unlock()
+	if limit != 7 {
+		t.Errorf("limit after a slow task = %d, want 7", limit)
+	}
+}
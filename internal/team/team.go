@@ -0,0 +1,228 @@
+// Package team keeps a team's shared configuration and skills in sync: a
+// git repository, cloned to ~/.agentflow/team, whose config.yaml is read
+// below the user's config and whose skills/ directory is loaded below the
+// user's skills. The clone is read-only; changes go through the team
+// repository.
+package team
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dir is where the team repository is cloned, ~/.agentflow/team
+func Dir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".agentflow", "team")
+}
+
+// ConfigPath returns the team config in a clone, or "" when it has none
+func ConfigPath(dir string) string {
+	for _, name := range []string{"config.yaml", "config.yml"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// SkillsDir returns the team skills directory of a clone
+func SkillsDir(dir string) string {
+	return filepath.Join(dir, "skills")
+}
+
+// Result is what a sync did
+type Result struct {
+	Cloned bool
+	Before string // Commit before pulling, "" when cloned
+	After  string
+}
+
+// Commits returns how many commits a pull brought in
+func (r Result) Commits(ctx context.Context, dir string) int {
+	if r.Cloned || r.Before == r.After {
+		return 0
+	}
+	out, _ := git(ctx, dir, "rev-list", "--count", r.Before+".."+r.After)
+	n, _ := strconv.Atoi(out)
+	return n
+}
+
+// Sync clones repo into dir, or fast-forwards an existing clone of it.
+// branch is the remote's default branch when empty. It refuses to touch
+// a clone with local changes or of another repository.
+func Sync(ctx context.Context, dir, repo, branch string) (Result, error) {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		args := []string{"clone", "--quiet"}
+		if branch != "" {
+			args = append(args, "--branch", branch)
+		}
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return Result{}, err
+		}
+		if _, err := git(ctx, "", append(args, repo, dir)...); err != nil {
+			return Result{}, err
+		}
+		after, _ := git(ctx, dir, "rev-parse", "HEAD")
+		return Result{Cloned: true, After: after}, nil
+	}
+
+	if origin, _ := git(ctx, dir, "remote", "get-url", "origin"); origin != repo {
+		return Result{}, fmt.Errorf("%s is a clone of %s, not %s: remove it to switch", dir, origin, repo)
+	}
+	if modified, err := Modified(ctx, dir); err != nil {
+		return Result{}, err
+	} else if len(modified) > 0 {
+		return Result{}, fmt.Errorf("%s has local changes (%s): the team layer is read-only, so change the team repository instead and discard them", dir, strings.Join(modified, ", "))
+	}
+
+	before, err := git(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return Result{}, err
+	}
+	args := []string{"pull", "--quiet", "--ff-only", "origin"}
+	if branch != "" {
+		args = append(args, branch)
+	}
+	if _, err := git(ctx, dir, args...); err != nil {
+		return Result{}, err
+	}
+	after, _ := git(ctx, dir, "rev-parse", "HEAD")
+	return Result{Before: before, After: after}, nil
+}
+
+// Status is how a clone compares with its repository
+type Status struct {
+	Commit   string
+	Behind   int      // Commits on the remote not pulled yet
+	Modified []string // Files changed in the clone
+}
+
+// Check fetches the remote and reports how far the clone is behind and
+// what was changed in it
+func Check(ctx context.Context, dir string) (Status, error) {
+	var s Status
+	var err error
+	if s.Commit, err = git(ctx, dir, "rev-parse", "HEAD"); err != nil {
+		return s, fmt.Errorf("no team repository in %s: agentflow sync clones it", dir)
+	}
+	if _, err := git(ctx, dir, "fetch", "--quiet", "origin"); err != nil {
+		return s, err
+	}
+	out, _ := git(ctx, dir, "rev-list", "--count", "HEAD..@{upstream}")
+	s.Behind, _ = strconv.Atoi(out)
+	s.Modified, err = Modified(ctx, dir)
+	return s, err
+}
+
+// Modified returns the files changed in a clone
+func Modified(ctx context.Context, dir string) ([]string, error) {
+	out, err := git(ctx, dir, "status", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, line := range strings.Split(out, "\n") {
+		// "XY path", with the first line's leading space trimmed
+		if _, path, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+			files = append(files, strings.TrimSpace(path))
+		}
+	}
+	return files, nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Settings flattens a YAML config into its settings by dotted key
+// ("defaults.main"), each rendered as YAML. Lists are single settings.
+func Settings(data []byte) (map[string]string, error) {
+	var root map[string]any
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	settings := make(map[string]string)
+	flatten("", root, settings)
+	return settings, nil
+}
+
+func flatten(prefix string, m map[string]any, into map[string]string) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if sub, ok := v.(map[string]any); ok && len(sub) > 0 {
+			flatten(key, sub, into)
+			continue
+		}
+		out, _ := yaml.Marshal(v)
+		into[key] = strings.TrimSpace(string(out))
+	}
+}
+
+// Override is a team setting the user's config changes
+type Override struct {
+	Key  string
+	Team string
+	User string
+}
+
+// Drift returns the team settings that the user's settings override,
+// sorted by key. A user value where the team has a section, or the
+// reverse, counts for each team setting it replaces.
+func Drift(team, user map[string]string) []Override {
+	var drift []Override
+	for key, value := range team {
+		if mine, ok := lookup(user, key); ok && mine != value {
+			drift = append(drift, Override{Key: key, Team: value, User: mine})
+		}
+	}
+	sort.Slice(drift, func(i, j int) bool { return drift[i].Key < drift[j].Key })
+	return drift
+}
+
+// lookup finds the value replacing a setting: the setting itself, a
+// value set where it has a section, or a section set where it has a value
+func lookup(settings map[string]string, key string) (string, bool) {
+	for k := key; ; {
+		if v, ok := settings[k]; ok {
+			return v, true
+		}
+		i := strings.LastIndex(k, ".")
+		if i < 0 {
+			break
+		}
+		k = k[:i]
+	}
+	for k, v := range settings {
+		if strings.HasPrefix(k, key+".") {
+			return v, true
+		}
+	}
+	return "", false
+}
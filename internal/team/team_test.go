@@ -0,0 +1,102 @@
+package team
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// repo creates a team repository with a config, returning a function that
+// commits a new version of it
+func repo(t *testing.T) (string, func(config string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "--quiet", "--initial-branch=main")
+	commit := func(config string) {
+		os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0644)
+		run("add", "-A")
+		run("commit", "--quiet", "-m", "config")
+	}
+	commit("defaults:\n  main: ollama/llama3\n")
+	return dir, commit
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	remote, commit := repo(t)
+	dir := filepath.Join(t.TempDir(), "team")
+
+	r, err := Sync(ctx, dir, remote, "")
+	if err != nil || !r.Cloned || ConfigPath(dir) == "" {
+		t.Fatalf("clone = %+v, %v", r, err)
+	}
+
+	commit("defaults:\n  main: ollama/qwen\n")
+	if s, err := Check(ctx, dir); err != nil || s.Behind != 1 {
+		t.Errorf("status = %+v, %v", s, err)
+	}
+	r, err = Sync(ctx, dir, remote, "main")
+	if err != nil || r.Cloned || r.Before == r.After || r.Commits(ctx, dir) != 1 {
+		t.Fatalf("pull = %+v, %v", r, err)
+	}
+	if data, _ := os.ReadFile(ConfigPath(dir)); !strings.Contains(string(data), "qwen") {
+		t.Errorf("config = %s", data)
+	}
+
+	os.WriteFile(ConfigPath(dir), []byte("edited"), 0644)
+	if _, err := Sync(ctx, dir, remote, ""); err == nil || !strings.Contains(err.Error(), "local changes (config.yaml)") {
+		t.Errorf("a modified clone should be refused: %v", err)
+	}
+	if _, err := Sync(ctx, dir, remote+"-other", ""); err == nil || !strings.Contains(err.Error(), "remove it to switch") {
+		t.Errorf("another repository should be refused: %v", err)
+	}
+}
+
+func TestDrift(t *testing.T) {
+	teamSettings, err := Settings([]byte(`
+defaults:
+  main: ollama/llama3
+  reviewer: ollama/qwen
+providers:
+  ollama:
+    base_url: http://gpu:11434
+    models: [llama3, qwen]
+skills:
+  paths: [skills]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	userSettings, _ := Settings([]byte(`
+defaults:
+  main: openai/gpt-4o
+  reviewer: ollama/qwen
+providers:
+  ollama:
+    models: [llama3]
+skills: none
+`))
+
+	var keys []string
+	for _, d := range Drift(teamSettings, userSettings) {
+		keys = append(keys, d.Key+"="+d.User)
+	}
+	want := "defaults.main=openai/gpt-4o providers.ollama.models=- llama3 skills.paths=none"
+	if got := strings.Join(keys, " "); got != want {
+		t.Errorf("drift = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+// Package tmux integrates with the tmux terminal multiplexer
+package tmux
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// InSession reports whether the process runs inside a tmux session
+func InSession() bool {
+	return os.Getenv("TMUX") != ""
+}
+
+// Capture returns the visible contents of a tmux pane. An empty target
+// means the last active pane in the current window, which is usually the
+// one next to the agentflow pane.
+func Capture(target string) (string, error) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return "", fmt.Errorf("tmux not found in PATH")
+	}
+	if target == "" {
+		target = "{last}"
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("tmux", "capture-pane", "-p", "-J", "-t", target)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("tmux capture-pane: %s", msg)
+		}
+		return "", fmt.Errorf("tmux capture-pane: %w", err)
+	}
+
+	return Clean(string(out)), nil
+}
+
+// Clean strips trailing whitespace from each line and drops the blank
+// lines tmux pads the bottom of a pane with
+func Clean(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// FormatContext wraps captured pane contents for inclusion in a conversation
+func FormatContext(target, content string) string {
+	if target == "" {
+		target = "last pane"
+	}
+	return fmt.Sprintf("Contents of tmux pane (%s):\n```\n%s\n```", target, content)
+}
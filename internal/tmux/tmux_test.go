@@ -0,0 +1,24 @@
+package tmux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	got := Clean("$ go test   \nFAIL\t\n\n\n\n")
+	if got != "$ go test\nFAIL" {
+		t.Errorf("Clean() = %q", got)
+	}
+}
+
+func TestFormatContext(t *testing.T) {
+	got := FormatContext("%3", "panic: boom")
+	if !strings.Contains(got, "(%3)") || !strings.Contains(got, "```\npanic: boom\n```") {
+		t.Errorf("FormatContext() = %q", got)
+	}
+
+	if got := FormatContext("", "x"); !strings.Contains(got, "(last pane)") {
+		t.Errorf("FormatContext() = %q", got)
+	}
+}
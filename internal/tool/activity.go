@@ -0,0 +1,85 @@
+package tool
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// Kinds of activity, what a call does as the activity feed shows it
+const (
+	KindRead   = "read"
+	KindSearch = "search"
+	KindFetch  = "fetch"
+	KindRun    = "run"
+	KindEdit   = "edit"
+)
+
+// Reporter is implemented by tools that say what kind of activity their
+// calls are; others count as KindEdit when they change things and
+// KindRead otherwise
+type Reporter interface {
+	Kind() string
+}
+
+// subjectKeys are the arguments naming what a call acts on, by preference
+var subjectKeys = []string{"command", "paths", "path", "file", "url", "symbol", "package", "query", "name"}
+
+// maxSubject is how many characters a subject may have before it is cut
+const maxSubject = 80
+
+// Report describes a call of t, nil for a tool the registry doesn't
+// have, as activity: its kind and the files, symbol, URL or command it
+// acts on, from its arguments. Error is set when result is an error.
+func Report(t Tool, call types.ToolCall, result types.Message) types.Activity {
+	a := types.Activity{Time: time.Now(), Tool: call.Name, Kind: KindRead}
+	if r, ok := t.(Reporter); ok && r.Kind() != "" {
+		a.Kind = r.Kind()
+	} else if t != nil && Writes(t) {
+		a.Kind = KindEdit
+	}
+
+	a.Subject, _ = Subject(call)
+	if runes := []rune(a.Subject); len(runes) > maxSubject {
+		a.Subject = string(runes[:maxSubject-3]) + "..."
+	}
+	if msg, failed := strings.CutPrefix(result.Content, "error: "); failed {
+		a.Error, _, _ = strings.Cut(msg, "\n")
+	}
+	return a
+}
+
+// Subject returns what a call acts on, from its arguments: the files,
+// symbol, URL or command, and the argument it comes from; "" for both
+// when it has none of them
+func Subject(call types.ToolCall) (string, string) {
+	var args map[string]any
+	if json.Unmarshal([]byte(call.Arguments), &args) == nil {
+		for _, key := range subjectKeys {
+			if s := subject(args[key]); s != "" {
+				return s, key
+			}
+		}
+	}
+	return "", ""
+}
+
+// subject renders an argument as a subject: a string as it is, a list of
+// strings joined with commas
+func subject(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []any:
+		var parts []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	return ""
+}
@@ -0,0 +1,132 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxReadBytes caps what one read_files call returns, about 16k tokens
+const maxReadBytes = 64 << 10
+
+// ReadFiles returns the read_files tool, which reads files under root.
+// It is how the model follows up on a directory summary or a project map
+// without the user mentioning each file.
+func ReadFiles(root string) Tool {
+	return &Func{
+		ToolName: "read_files",
+		Desc:     "Read files of the project, whole or as a line range written path:10-80. Use it to open the files a directory summary or project map lists when you need their contents. Long output is cut.",
+		Params: Object(map[string]any{
+			"paths": map[string]any{
+				"type":        "array",
+				"items":       String("Path relative to the project root, optionally with :from-to lines"),
+				"description": "Files to read",
+			},
+		}, "paths"),
+		Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+			var in struct {
+				Paths []string `json:"paths"`
+			}
+			if err := json.Unmarshal(args, &in); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			if len(in.Paths) == 0 {
+				return "", errors.New("paths is required")
+			}
+
+			var sb strings.Builder
+			for _, spec := range in.Paths {
+				if sb.Len() >= maxReadBytes {
+					fmt.Fprintf(&sb, "\n## %s\n(not read: output limit reached, ask again for it)\n", spec)
+					continue
+				}
+				content, err := readFile(root, spec)
+				if err != nil {
+					fmt.Fprintf(&sb, "\n## %s\nerror: %v\n", spec, err)
+					continue
+				}
+				if left := maxReadBytes - sb.Len(); len(content) > left {
+					content = content[:strings.LastIndex(content[:left], "\n")+1] + "... (cut, ask for a line range to see more)"
+				}
+				fmt.Fprintf(&sb, "\n## %s\n```\n%s\n```\n", spec, content)
+			}
+			return strings.TrimSpace(sb.String()), nil
+		},
+	}
+}
+
+// within reports whether path is root or under it
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// InProject resolves a path against root and follows its symlinks,
+// refusing paths outside root, as they are or once symlinks are followed
+func InProject(root, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	if !within(root, path) {
+		return "", errors.New("outside the project")
+	}
+	// A symlink in the project may point out of it
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", errors.New("no such file")
+	}
+	if realRoot, err := filepath.EvalSymlinks(root); err != nil || !within(realRoot, resolved) {
+		return "", errors.New("outside the project")
+	}
+	return resolved, nil
+}
+
+// readFile reads a path:from-to spec, refusing paths outside root, as
+// they are or once symlinks are followed
+func readFile(root, spec string) (string, error) {
+	path, from, to := spec, 0, 0
+	if i := strings.LastIndex(spec, ":"); i > 0 {
+		lines := strings.SplitN(spec[i+1:], "-", 2)
+		if f, err := strconv.Atoi(lines[0]); err == nil {
+			path, from, to = spec[:i], f, f
+			if len(lines) == 2 {
+				if to, err = strconv.Atoi(lines[1]); err != nil {
+					return "", fmt.Errorf("invalid line range %q", spec[i+1:])
+				}
+			}
+		}
+	}
+
+	path, err := InProject(root, path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", errors.New("no such file")
+	}
+	if info.IsDir() {
+		return "", errors.New("is a directory")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", errors.New("binary file")
+	}
+
+	content := strings.TrimRight(string(data), "\n")
+	if from > 0 {
+		lines := strings.Split(content, "\n")
+		from, to = min(from, len(lines)), min(max(to, from), len(lines))
+		content = strings.Join(lines[from-1:to], "\n")
+	}
+	return content, nil
+}
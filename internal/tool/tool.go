@@ -0,0 +1,231 @@
+// Package tool defines the tools a model can call and the registry that
+// runs them
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+// Tool is something the model can ask the agent to run
+type Tool interface {
+	// Name is the identifier the model calls the tool by
+	Name() string
+
+	// Description tells the model when and how to use the tool
+	Description() string
+
+	// Parameters is the JSON Schema of the arguments object
+	Parameters() map[string]any
+
+	// Run executes the tool with JSON-encoded arguments and returns the
+	// text sent back to the model
+	Run(ctx context.Context, args json.RawMessage) (string, error)
+}
+
+// Writer is implemented by tools that can change files or other state
+// outside the conversation; plan mode doesn't offer those whose Writes
+// is true
+type Writer interface {
+	Writes() bool
+}
+
+// Writes reports whether a tool can change things
+func Writes(t Tool) bool {
+	w, ok := t.(Writer)
+	return ok && w.Writes()
+}
+
+// Func adapts a function to the Tool interface
+type Func struct {
+	ToolName string
+	Desc     string
+	Params   map[string]any
+	Fn       func(ctx context.Context, args json.RawMessage) (string, error)
+	Changes  bool   // Changes files or other state
+	Activity string // What its calls are in the activity feed, e.g. KindSearch; by Changes when empty
+}
+
+func (f *Func) Name() string               { return f.ToolName }
+func (f *Func) Description() string        { return f.Desc }
+func (f *Func) Parameters() map[string]any { return f.Params }
+func (f *Func) Writes() bool               { return f.Changes }
+func (f *Func) Kind() string               { return f.Activity }
+
+func (f *Func) Run(ctx context.Context, args json.RawMessage) (string, error) {
+	return f.Fn(ctx, args)
+}
+
+// Object builds a JSON Schema object from property schemas, with the
+// listed properties required
+func Object(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// String builds a JSON Schema string property
+func String(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+// Registry holds the tools offered to the model
+type Registry struct {
+	tools    map[string]Tool
+	names    []string          // Registration order, so definitions are stable
+	allow    func(string) bool // Tools that may be registered; nil for all
+	noWrites bool              // Tools that change things are refused
+}
+
+// NewRegistry creates an empty tool registry
+func NewRegistry() *Registry {
+	return &Registry{
+		tools: make(map[string]Tool),
+	}
+}
+
+// Register adds a tool, replacing any tool with the same name, unless
+// the registry is restricted from offering it
+func (r *Registry) Register(t Tool) {
+	if r.allow != nil && !r.allow(t.Name()) || r.noWrites && Writes(t) {
+		return
+	}
+	if _, ok := r.tools[t.Name()]; !ok {
+		r.names = append(r.names, t.Name())
+	}
+	r.tools[t.Name()] = t
+}
+
+// Restrict limits the registry to the tools allow accepts by name,
+// removing the others and ignoring them when registered later. Further
+// restrictions add to it.
+func (r *Registry) Restrict(allow func(name string) bool) {
+	if prev, next := r.allow, allow; prev != nil {
+		allow = func(name string) bool { return prev(name) && next(name) }
+	}
+	r.allow = allow
+	names := r.names[:0]
+	for _, name := range r.names {
+		if allow(name) {
+			names = append(names, name)
+		} else {
+			delete(r.tools, name)
+		}
+	}
+	r.names = names
+}
+
+// RefuseWrites limits the registry to tools that don't change anything,
+// removing the others and ignoring them when registered later
+func (r *Registry) RefuseWrites() {
+	r.noWrites = true
+	names := r.names[:0]
+	for _, name := range r.names {
+		if Writes(r.tools[name]) {
+			delete(r.tools, name)
+		} else {
+			names = append(names, name)
+		}
+	}
+	r.names = names
+}
+
+// Get retrieves a tool by name
+func (r *Registry) Get(name string) (Tool, bool) {
+	t, ok := r.tools[name]
+	return t, ok
+}
+
+// List returns the registered tool names in registration order
+func (r *Registry) List() []string {
+	return append([]string(nil), r.names...)
+}
+
+// ReadOnly returns a registry of the tools that don't change anything
+func (r *Registry) ReadOnly() *Registry {
+	out := NewRegistry()
+	out.allow = r.allow
+	for _, name := range r.names {
+		if !Writes(r.tools[name]) {
+			out.Register(r.tools[name])
+		}
+	}
+	return out
+}
+
+// Definitions describes the registered tools for a completion request
+func (r *Registry) Definitions() []types.ToolDefinition {
+	defs := make([]types.ToolDefinition, 0, len(r.names))
+	for _, name := range r.names {
+		t := r.tools[name]
+		defs = append(defs, types.ToolDefinition{
+			Name:        t.Name(),
+			Description: t.Description(),
+			Parameters:  t.Parameters(),
+		})
+	}
+	return defs
+}
+
+// Call runs a tool call and returns the "tool" message answering it.
+// Failures are reported to the model in the message rather than
+// returned, so it can correct its arguments and try again.
+func (r *Registry) Call(ctx context.Context, call types.ToolCall) types.Message {
+	msg := types.Message{
+		Role:       "tool",
+		Name:       call.Name,
+		ToolCallID: call.ID,
+		Timestamp:  time.Now(),
+	}
+
+	t, ok := r.tools[call.Name]
+	if !ok {
+		msg.Content = fmt.Sprintf("error: unknown tool %q", call.Name)
+		return msg
+	}
+
+	args := json.RawMessage(call.Arguments)
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	out, err := t.Run(ctx, args)
+	if err != nil {
+		msg.Content = "error: " + err.Error()
+		return msg
+	}
+	msg.Content = out
+	return msg
+}
+
+// Describe renders a call compactly for display, e.g.
+// go_definition(symbol: "New")
+func Describe(call types.ToolCall) string {
+	var args map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+		return call.Name + "(" + call.Arguments + ")"
+	}
+
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		value := string(args[k])
+		if len(value) > 60 {
+			value = value[:57] + "..."
+		}
+		parts[i] = k + ": " + value
+	}
+	return call.Name + "(" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,184 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+func echoTool() *Func {
+	return &Func{
+		ToolName: "echo",
+		Desc:     "Echo the text back",
+		Params:   Object(map[string]any{"text": String("Text to echo")}, "text"),
+		Fn: func(ctx context.Context, args json.RawMessage) (string, error) {
+			var in struct{ Text string }
+			if err := json.Unmarshal(args, &in); err != nil {
+				return "", err
+			}
+			if in.Text == "" {
+				return "", errors.New("text is required")
+			}
+			return in.Text, nil
+		},
+	}
+}
+
+func TestRegistry_Definitions(t *testing.T) {
+	r := NewRegistry()
+	r.Register(echoTool())
+	r.Register(&Func{ToolName: "noop", Params: Object(nil)})
+
+	defs := r.Definitions()
+	if len(defs) != 2 || defs[0].Name != "echo" || defs[1].Name != "noop" {
+		t.Fatalf("definitions = %+v", defs)
+	}
+	if defs[0].Parameters["required"].([]string)[0] != "text" {
+		t.Errorf("parameters = %+v", defs[0].Parameters)
+	}
+
+	// Re-registering replaces without duplicating
+	r.Register(echoTool())
+	if names := r.List(); len(names) != 2 {
+		t.Errorf("names = %v", names)
+	}
+}
+
+func TestRegistry_Call(t *testing.T) {
+	r := NewRegistry()
+	r.Register(echoTool())
+
+	msg := r.Call(context.Background(), types.ToolCall{ID: "call_1", Name: "echo", Arguments: `{"text":"hi"}`})
+	if msg.Role != "tool" || msg.ToolCallID != "call_1" || msg.Name != "echo" || msg.Content != "hi" {
+		t.Errorf("message = %+v", msg)
+	}
+
+	msg = r.Call(context.Background(), types.ToolCall{ID: "call_2", Name: "echo"})
+	if msg.Content != "error: text is required" {
+		t.Errorf("error content = %q", msg.Content)
+	}
+
+	msg = r.Call(context.Background(), types.ToolCall{ID: "call_3", Name: "missing"})
+	if msg.Content != `error: unknown tool "missing"` {
+		t.Errorf("unknown tool content = %q", msg.Content)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	got := Describe(types.ToolCall{Name: "go_references", Arguments: `{"symbol":"agent.New","all":true}`})
+	if got != `go_references(all: true, symbol: "agent.New")` {
+		t.Errorf("Describe = %q", got)
+	}
+	if got := Describe(types.ToolCall{Name: "x", Arguments: "oops"}); got != "x(oops)" {
+		t.Errorf("Describe invalid = %q", got)
+	}
+}
+
+func TestReadFiles(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "a.go"), []byte("one\ntwo\nthree\n"), 0644)
+	os.WriteFile(filepath.Join(root, "bin"), []byte{0, 1}, 0644)
+	secret := filepath.Join(t.TempDir(), "secret")
+	os.WriteFile(secret, []byte("key"), 0644)
+	os.Symlink(secret, filepath.Join(root, "link"))
+	os.Symlink("a.go", filepath.Join(root, "alias.go"))
+
+	out, err := ReadFiles(root).Run(context.Background(), json.RawMessage(`{"paths":["a.go:2-3","bin","../x","gone.go","link","alias.go:1"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## a.go:2-3\n```\ntwo\nthree\n```", "## bin\nerror: binary file", "## ../x\nerror: outside the project", "## gone.go\nerror: no such file",
+		"## link\nerror: outside the project", "## alias.go:1\n```\none\n```"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, missing %q", out, want)
+		}
+	}
+
+	if _, err := ReadFiles(root).Run(context.Background(), json.RawMessage(`{}`)); err == nil {
+		t.Error("expected an error without paths")
+	}
+}
+
+func TestRegistry_ReadOnly(t *testing.T) {
+	r := NewRegistry()
+	r.Register(echoTool())
+	r.Register(&Func{ToolName: "write", Changes: true})
+
+	if names := r.ReadOnly().List(); len(names) != 1 || names[0] != "echo" {
+		t.Errorf("read-only tools = %v", names)
+	}
+	if !Writes(&Func{Changes: true}) || Writes(echoTool()) {
+		t.Error("Writes disagrees with Changes")
+	}
+}
+
+func TestRegistry_Restrict(t *testing.T) {
+	r := NewRegistry()
+	r.Register(echoTool())
+	r.Register(&Func{ToolName: "fetch_url"})
+	r.Restrict(func(name string) bool { return name != "fetch_url" })
+	r.Register(&Func{ToolName: "fetch_url"})
+	r.Register(&Func{ToolName: "read_files"})
+	r.Restrict(func(name string) bool { return name != "read_files" })
+
+	if names := r.List(); len(names) != 1 || names[0] != "echo" {
+		t.Errorf("tools = %v", names)
+	}
+	if msg := r.Call(context.Background(), types.ToolCall{Name: "fetch_url"}); !strings.Contains(msg.Content, "unknown tool") {
+		t.Errorf("restricted call = %q", msg.Content)
+	}
+	ro := r.ReadOnly()
+	if ro.Register(&Func{ToolName: "fetch_url"}); len(ro.List()) != 1 {
+		t.Error("the read-only registry should keep the restriction")
+	}
+}
+
+func TestRegistry_RefuseWrites(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&Func{ToolName: "write", Changes: true})
+	r.Register(echoTool())
+	r.RefuseWrites()
+	r.Register(&Func{ToolName: "edit", Changes: true})
+
+	if names := r.List(); len(names) != 1 || names[0] != "echo" {
+		t.Errorf("tools = %v", names)
+	}
+	if msg := r.Call(context.Background(), types.ToolCall{Name: "write"}); !strings.Contains(msg.Content, "unknown tool") {
+		t.Errorf("refused call = %q", msg.Content)
+	}
+}
+
+func TestReport(t *testing.T) {
+	read := ReadFiles(t.TempDir())
+	call := types.ToolCall{Name: "read_files", Arguments: `{"paths": ["go.mod", "main.go:1-20"]}`}
+	if a := Report(read, call, types.Message{Content: "..."}); a.Kind != KindRead || a.Subject != "go.mod, main.go:1-20" || a.Error != "" {
+		t.Errorf("read_files = %+v", a)
+	}
+
+	search := &Func{ToolName: "go_definition", Activity: KindSearch}
+	call = types.ToolCall{Name: "go_definition", Arguments: `{"symbol": "agent.New"}`}
+	if a := Report(search, call, types.Message{Content: "error: no such symbol\nsee go_package"}); a.String() != "search agent.New (failed: no such symbol)" {
+		t.Errorf("go_definition = %q", a)
+	}
+
+	write := &Func{ToolName: "write_file", Changes: true}
+	call = types.ToolCall{Name: "write_file", Arguments: `{"path": "` + strings.Repeat("d/", 50) + `x.go", "content": "package x"}`}
+	if a := Report(write, call, types.Message{}); a.Kind != KindEdit || len(a.Subject) != maxSubject || !strings.HasSuffix(a.Subject, "...") {
+		t.Errorf("write_file = %+v", a)
+	}
+	// Long subjects are cut between characters, not inside one
+	call = types.ToolCall{Name: "run_command", Arguments: `{"command": "echo ` + strings.Repeat("é", 100) + `"}`}
+	if a := Report(nil, call, types.Message{}); !utf8.ValidString(a.Subject) || utf8.RuneCountInString(a.Subject) != maxSubject {
+		t.Errorf("run_command = %q", a.Subject)
+	}
+	if a := Report(nil, types.ToolCall{Name: "gone", Arguments: "{}"}, types.Message{}); a.Kind != KindRead || a.Subject != "" {
+		t.Errorf("unknown tool = %+v", a)
+	}
+}
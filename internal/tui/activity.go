@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/agentflow/agentflow/internal/i18n"
+	"github.com/agentflow/agentflow/internal/tool"
+	"github.com/agentflow/agentflow/pkg/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	activityWidth    = 38  // Columns of the activity sidebar, border included
+	activityMinWidth = 110 // Narrower windows leave it out; /activity prints the feed instead
+)
+
+var (
+	activityBoxStyle   = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(mutedColor).Padding(0, 1)
+	activityTitleStyle = lipgloss.NewStyle().Bold(true).Foreground(primaryColor)
+	activityFailStyle  = lipgloss.NewStyle().Foreground(errorColor)
+)
+
+// activityIcons mark the kinds of activity in the feed
+var activityIcons = map[string]string{
+	tool.KindRead:   "📖",
+	tool.KindSearch: "🔍",
+	tool.KindFetch:  "🌐",
+	tool.KindRun:    "▶",
+	tool.KindEdit:   "✏",
+}
+
+// activityMsg is a tool call the agent ran
+type activityMsg types.Activity
+
+// SendActivity adds a tool call the agent ran to the activity feed
+func SendActivity(a types.Activity) tea.Cmd {
+	return func() tea.Msg {
+		return activityMsg(a)
+	}
+}
+
+// LoadActivity fills the activity feed, as when a session is resumed
+func (m *Model) LoadActivity(activity []types.Activity) {
+	m.activity = append([]types.Activity(nil), activity...)
+}
+
+// addActivity adds an entry to the feed, making room for the sidebar
+// when it first appears
+func (m Model) addActivity(a types.Activity) Model {
+	shown := m.sidebarWidth() > 0
+	m.activity = append(m.activity, a)
+	if !shown && m.sidebarWidth() > 0 {
+		m = m.layout()
+	}
+	return m
+}
+
+// toggleActivity shows or hides the sidebar, or prints the feed when the
+// window has no room for it
+func (m Model) toggleActivity() Model {
+	if m.compact || m.width < activityMinWidth {
+		if len(m.activity) == 0 {
+			return m.notice(i18n.T("msg.activity_none"))
+		}
+		lines := make([]string, len(m.activity))
+		for i, a := range m.activity {
+			lines[i] = activityLine(a)
+		}
+		return m.notice(i18n.T("msg.activity") + "\n" + strings.Join(lines, "\n"))
+	}
+	m.hideActivity = !m.hideActivity
+	return m.layout()
+}
+
+// sidebarWidth returns the columns the activity sidebar takes, 0 when
+// it isn't shown: hidden with /activity, nothing to show yet, or no room
+func (m Model) sidebarWidth() int {
+	if m.hideActivity || m.compact || len(m.activity) == 0 || m.width < activityMinWidth {
+		return 0
+	}
+	return activityWidth
+}
+
+// layout fits the conversation next to the sidebar
+func (m Model) layout() Model {
+	m.viewport.Width = m.width - m.sidebarWidth()
+	m.viewport.SetContent(m.renderMessages())
+	return m
+}
+
+// withActivity puts the activity sidebar to the right of the
+// conversation when it is shown, with the latest entries that fit
+func (m Model) withActivity(content string) string {
+	width := m.sidebarWidth()
+	if width == 0 {
+		return content
+	}
+	inner := width - 4 // Border and padding
+	rows := max(m.viewport.Height-3, 1)
+	entries := m.activity[max(len(m.activity)-rows, 0):]
+	lines := []string{activityTitleStyle.Render(i18n.T("msg.activity"))}
+	for _, a := range entries {
+		line := activityLine(a)
+		if lipgloss.Width(line) > inner {
+			runes := []rune(line)
+			for len(runes) > 0 && lipgloss.Width(string(runes))+1 > inner {
+				runes = runes[:len(runes)-1]
+			}
+			line = string(runes) + "…"
+		}
+		if a.Error != "" {
+			line = activityFailStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	box := activityBoxStyle.Width(width - 2).Height(m.viewport.Height - 2).Render(strings.Join(lines, "\n"))
+	return lipgloss.JoinHorizontal(lipgloss.Top, content, box)
+}
+
+// activityLine renders an entry for the feed: time, kind and subject
+func activityLine(a types.Activity) string {
+	icon, ok := activityIcons[a.Kind]
+	if !ok {
+		icon = "•"
+	}
+	return fmt.Sprintf("%s %s %s", a.Time.Local().Format("15:04"), icon, a)
+}
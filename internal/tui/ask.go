@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/agentflow/agentflow/internal/i18n"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// question is the one-line prompt of agentflow ask
+type question struct {
+	input textinput.Model
+	model string
+	done  bool // Enter was pressed, rather than Esc
+}
+
+// AskQuestion shows a one-line prompt for a question to model, inline
+// rather than full screen, and returns it; ok is false when the user
+// gave up with Esc or Ctrl+C
+func AskQuestion(model string) (text string, ok bool, err error) {
+	in := textinput.New()
+	in.Placeholder = i18n.T("ask.placeholder")
+	in.Prompt = "❯ "
+	in.PromptStyle = userStyle
+	in.Focus()
+
+	final, err := tea.NewProgram(question{input: in, model: model}).Run()
+	if err != nil {
+		return "", false, err
+	}
+	q := final.(question)
+	text = strings.TrimSpace(q.input.Value())
+	return text, q.done && text != "", nil
+}
+
+func (q question) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (q question) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.Type {
+		case tea.KeyEnter:
+			q.done = true
+			return q, tea.Quit
+		case tea.KeyEsc, tea.KeyCtrlC:
+			return q, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	q.input, cmd = q.input.Update(msg)
+	return q, cmd
+}
+
+func (q question) View() string {
+	if q.done {
+		// Left on screen above the answer
+		return q.input.Prompt + q.input.Value() + "\n"
+	}
+	return mutedStyle.Render(i18n.T("ask.header", q.model)) + "\n" + q.input.View() + "\n"
+}
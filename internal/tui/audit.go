@@ -0,0 +1,27 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/audit"
+	"github.com/agentflow/agentflow/internal/input"
+)
+
+// SetAudit sets the log recording the shell commands run from the input
+func (m *Model) SetAudit(log *audit.Log) {
+	m.audit = log
+}
+
+// auditBash records a shell command run from the input
+func (m Model) auditBash(result input.BashResult, how string) {
+	e := audit.Entry{
+		Kind:   audit.Bash,
+		Action: result.Command,
+		Detail: fmt.Sprintf("%s, exit %d in %s", how, result.ExitCode, result.Duration.Round(time.Millisecond)),
+	}
+	if result.ExitCode != 0 {
+		e.Error = result.Error
+	}
+	m.audit.Record(e)
+}
@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/agentflow/agentflow/internal/i18n"
+)
+
+// collapseLines is how long bash output can be before it is shown
+// collapsed
+const collapseLines = 12
+
+// collapsible reports whether a message is shown as a one-line summary
+// until expanded: context added outside the chat, and long bash output
+func collapsible(msg ChatMessage) bool {
+	return msg.Role == "context" || (msg.Role == "bash" && lineCount(msg.Content) > collapseLines)
+}
+
+// lineCount counts the lines of text, ignoring a final newline
+func lineCount(text string) int {
+	return strings.Count(strings.TrimRight(text, "\n"), "\n") + 1
+}
+
+// renderBlock renders a collapsible message as "▸ [n] title, 212 lines",
+// followed by its content when expanded. n numbers the blocks for
+// /expand.
+func renderBlock(msg ChatMessage, n int, render func(string) string) string {
+	title := msg.Title
+	if title == "" {
+		title = msg.Role
+	}
+	summary := i18n.T("ui.block_summary", n, title, lineCount(msg.Content))
+	if !msg.Expanded {
+		return mutedStyle.Render("▸ "+summary+"  "+i18n.T("ui.block_hint", n)) + "\n"
+	}
+	return mutedStyle.Render("▾ "+summary) + "\n" + render(strings.TrimRight(msg.Content, "\n")) + "\n"
+}
+
+// expand handles /expand: it toggles block n, the last one without n,
+// and with "all" expands every block, or collapses them once all are
+func (m Model) expand(arg string) string {
+	var blocks []int
+	allExpanded := true
+	for i, msg := range m.messages {
+		if collapsible(msg) {
+			blocks = append(blocks, i)
+			allExpanded = allExpanded && msg.Expanded
+		}
+	}
+	if len(blocks) == 0 {
+		return i18n.T("msg.nothing_to_expand")
+	}
+
+	switch arg {
+	case "all":
+		for _, i := range blocks {
+			m.messages[i].Expanded = !allExpanded
+		}
+		return ""
+	case "":
+		arg = strconv.Itoa(len(blocks))
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(blocks) {
+		return i18n.T("msg.expand_usage", len(blocks))
+	}
+	msg := &m.messages[blocks[n-1]]
+	msg.Expanded = !msg.Expanded
+	return ""
+}
+
+// paneTitle names a tmux pane capture
+func paneTitle(target string) string {
+	if target == "" {
+		return "tmux pane"
+	}
+	return fmt.Sprintf("tmux pane %s", target)
+}
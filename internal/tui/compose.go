@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/agentflow/agentflow/internal/i18n"
+	"github.com/agentflow/agentflow/pkg/tokens"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	previewHeadingStyle = lipgloss.NewStyle().Bold(true).Foreground(primaryColor)
+	previewCodeStyle    = lipgloss.NewStyle().Foreground(secondaryColor)
+	previewQuoteStyle   = lipgloss.NewStyle().Foreground(mutedColor).Italic(true)
+)
+
+// inputHeight is the input's height outside compose mode
+const inputHeight = 3
+
+// setComposing enters or leaves compose mode, where the input takes half
+// the screen above a preview of the draft, for long prompts written with
+// care. The draft is kept either way.
+func (m Model) setComposing(on bool) Model {
+	m.composing = on
+	m.input.SetComposing(on)
+	m.input.SetHeight(m.composeInputHeight())
+	if !on {
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+	}
+	return m
+}
+
+// composeInputHeight returns the input's height: half the screen when
+// composing
+func (m Model) composeInputHeight() int {
+	if !m.composing || m.height == 0 {
+		return inputHeight
+	}
+	return max(inputHeight, m.height/2-2)
+}
+
+// renderCompose lays out compose mode: the preview of the draft, the
+// input and a line with its size and keys
+func (m Model) renderCompose(header string) string {
+	draft := m.input.Value()
+	status := statusTextStyle.Render(i18n.T("ui.compose_status",
+		tokens.Tokens(m.model, draft), strings.Count(draft, "\n")+1, len(strings.Fields(draft))))
+	inputBox := borderStyle.Render(m.input.View())
+
+	// The preview fills what is left, showing the end of long drafts
+	height := max(1, m.height-lipgloss.Height(header)-lipgloss.Height(inputBox)-2)
+	lines := strings.Split(markdownPreview(draft, m.width-2), "\n")
+	if len(lines) > height {
+		lines = lines[len(lines)-height:]
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return fmt.Sprintf("%s\n%s\n%s\n%s", header, strings.Join(lines, "\n"), inputBox, status)
+}
+
+// markdownPreview renders Markdown for the terminal: headings, code
+// blocks, quotes and list bullets stand out, the rest wraps at width
+func markdownPreview(text string, width int) string {
+	if strings.TrimSpace(text) == "" {
+		return mutedStyle.Render(i18n.T("ui.compose_empty"))
+	}
+	wrap := lipgloss.NewStyle().Width(max(width, 20))
+
+	var out []string
+	inCode := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			inCode = !inCode
+			out = append(out, previewCodeStyle.Render(strings.Repeat("─", 3)+" "+strings.TrimPrefix(trimmed, "```")))
+		case inCode:
+			out = append(out, previewCodeStyle.Render("  "+line))
+		case strings.HasPrefix(trimmed, "#"):
+			out = append(out, previewHeadingStyle.Render(strings.TrimSpace(strings.TrimLeft(trimmed, "#"))))
+		case strings.HasPrefix(trimmed, "> "):
+			out = append(out, previewQuoteStyle.Render(wrap.Render("┃ "+trimmed[2:])))
+		case strings.HasPrefix(trimmed, "- "), strings.HasPrefix(trimmed, "* "):
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			out = append(out, wrap.Render(indent+"• "+trimmed[2:]))
+		default:
+			out = append(out, wrap.Render(line))
+		}
+	}
+	return strings.Join(out, "\n")
+}
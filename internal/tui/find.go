@@ -0,0 +1,168 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/agentflow/agentflow/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	findMatchStyle   = lipgloss.NewStyle().Background(accentColor).Foreground(lipgloss.Color("#000000"))
+	findCurrentStyle = lipgloss.NewStyle().Background(secondaryColor).Foreground(lipgloss.Color("#000000")).Bold(true)
+)
+
+// transcriptSearch is a search of the conversation, opened with Ctrl+F,
+// /find or / in vim's normal mode. Matches stay highlighted until Esc.
+type transcriptSearch struct {
+	typing  bool // The prompt is open and keys edit the query
+	query   string
+	matches []int // Transcript lines containing the query, top to bottom
+	current int   // Index in matches of the one shown
+}
+
+// startFind opens the search prompt, where it was when reopened, or
+// searches for query at once when given
+func (m Model) startFind(query string) Model {
+	if query == "" && m.search != nil {
+		s := *m.search
+		s.typing = true
+		m.search = &s
+		return m
+	}
+	m.search = &transcriptSearch{typing: query == "", query: query}
+	return m.runFind()
+}
+
+// findKey handles keys while the search prompt is open: typing searches
+// as it goes, showing the latest match, Up and Ctrl+F go to earlier
+// matches and Down to later ones, Enter closes the prompt keeping the
+// matches, and Esc clears them
+func (m Model) findKey(msg tea.KeyMsg) Model {
+	s := *m.search
+	switch msg.String() {
+	case "esc":
+		m.search = nil
+		m.viewport.SetContent(m.renderMessages())
+		return m
+	case "enter":
+		s.typing = false
+		m.search = &s
+		return m
+	case "up", "ctrl+p", "ctrl+f":
+		m.jumpToMatch(-1)
+		return m
+	case "down", "ctrl+n":
+		m.jumpToMatch(1)
+		return m
+	case "backspace":
+		r := []rune(s.query)
+		if len(r) == 0 {
+			return m
+		}
+		s.query = string(r[:len(r)-1])
+	default:
+		if msg.Type != tea.KeyRunes && msg.Type != tea.KeySpace {
+			return m
+		}
+		s.query += string(msg.Runes)
+	}
+	m.search = &s
+	return m.runFind()
+}
+
+// runFind searches the transcript for the query and shows the latest
+// match
+func (m Model) runFind() Model {
+	s := *m.search
+	s.matches = findLines(m.renderMessages(), s.query)
+	s.current = len(s.matches)
+	m.search = &s
+	m.viewport.SetContent(m.renderMessages())
+	m.jumpToMatch(-1)
+	return m
+}
+
+// jumpToMatch scrolls to the match step away from the one shown,
+// negative for earlier ones; it stops at the first and last
+func (m *Model) jumpToMatch(step int) {
+	if m.search == nil || len(m.search.matches) == 0 {
+		return
+	}
+	s := *m.search
+	s.current = min(max(s.current+step, 0), len(s.matches)-1)
+	m.search = &s
+	m.viewport.SetContent(m.renderMessages())
+	m.viewport.SetYOffset(max(s.matches[s.current]-m.viewport.Height/2, 0))
+}
+
+// findLines returns the lines of rendered text containing query, case
+// aside
+func findLines(rendered, query string) []int {
+	query = strings.ToLower(query)
+	if query == "" {
+		return nil
+	}
+	var lines []int
+	for i, line := range strings.Split(ansi.Strip(rendered), "\n") {
+		if strings.Contains(strings.ToLower(line), query) {
+			lines = append(lines, i)
+		}
+	}
+	return lines
+}
+
+// highlightMatches marks the search's matches in the rendered
+// transcript, the one shown apart. Lines with a match lose their other
+// styling.
+func (m Model) highlightMatches(rendered string) string {
+	if m.search == nil || m.search.query == "" {
+		return rendered
+	}
+	current := -1
+	if c := m.search.current; c >= 0 && c < len(m.search.matches) {
+		current = m.search.matches[c]
+	}
+	query := strings.ToLower(m.search.query)
+
+	lines := strings.Split(rendered, "\n")
+	for i, line := range lines {
+		plain := ansi.Strip(line)
+		lower := strings.ToLower(plain)
+		if len(lower) != len(plain) || !strings.Contains(lower, query) {
+			continue
+		}
+		style := findMatchStyle
+		if i == current {
+			style = findCurrentStyle
+		}
+		var b strings.Builder
+		for {
+			at := strings.Index(lower, query)
+			if at < 0 {
+				break
+			}
+			b.WriteString(plain[:at])
+			b.WriteString(style.Render(plain[at : at+len(query)]))
+			plain, lower = plain[at+len(query):], lower[at+len(query):]
+		}
+		b.WriteString(plain)
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// findHeader describes the search for the header, or "" without one
+func (m Model) findHeader() string {
+	switch {
+	case m.search == nil:
+		return ""
+	case m.search.typing:
+		return i18n.T("header.find", m.search.query, len(m.search.matches))
+	case len(m.search.matches) == 0:
+		return i18n.T("header.find_none", m.search.query)
+	}
+	return i18n.T("header.find_matches", m.search.current+1, len(m.search.matches), m.search.query)
+}
@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/agentflow/agentflow/internal/guard"
+	"github.com/agentflow/agentflow/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SetGuard sets the guard screening command output and mentioned files
+// for prompt injection before they are sent; in ask mode the user says
+// what happens to suspicious lines
+func (m *Model) SetGuard(g *guard.Guard) {
+	m.guard = g
+}
+
+// screen passes content from source through the guard on its way to
+// then: flagged or withheld as configured, with a notice, or as the user
+// answers in ask mode
+func (m Model) screen(source, content string, then func(Model, string) (tea.Model, tea.Cmd)) (tea.Model, tea.Cmd) {
+	findings := m.guard.Scan(content)
+	if len(findings) == 0 {
+		return then(m, content)
+	}
+	summary := guard.Summary(source, findings)
+	flagged := func(m Model) (tea.Model, tea.Cmd) {
+		return then(m, guard.Mark(source, content, findings))
+	}
+	withheld := func(m Model) (tea.Model, tea.Cmd) {
+		return then(m, guard.Withhold(source, content, findings))
+	}
+
+	switch m.guard.Mode() {
+	case guard.Ask:
+		return m.ask(i18n.T("msg.guard_ask", summary), confirmation{yes: flagged, no: withheld}), nil
+	case guard.Flag:
+		m.messages = append(m.messages, ChatMessage{Role: "system", Content: i18n.T("msg.guard_flagged", summary), Timestamp: time.Now()})
+		return flagged(m)
+	}
+	m.messages = append(m.messages, ChatMessage{Role: "system", Content: i18n.T("msg.guard_withheld", summary), Timestamp: time.Now()})
+	return withheld(m)
+}
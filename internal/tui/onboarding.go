@@ -0,0 +1,388 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/i18n"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// OnboardingProvider is a provider offered on first run
+type OnboardingProvider struct {
+	Name      string
+	Label     string
+	BaseURL   string
+	KeyEnv    string   // Environment variable holding the API key; empty for keyless servers
+	Suggested []string // Models offered to pull when the server can pull them
+}
+
+// OnboardingHooks connect the onboarding flow to the providers
+type OnboardingHooks struct {
+	// ListModels tests the connection and returns the models offered
+	ListModels func(ctx context.Context, p OnboardingProvider, baseURL, apiKey string) ([]string, error)
+
+	// Pull downloads a model, for providers with Suggested models
+	Pull func(ctx context.Context, baseURL, model string, progress func(string)) error
+}
+
+// OnboardingResult is what the user chose during onboarding
+type OnboardingResult struct {
+	Provider      OnboardingProvider
+	BaseURL       string
+	APIKey        string
+	Model         string
+	InstallSkills bool
+	Done          bool // False when the user quit before the end
+}
+
+type onboardingStep int
+
+const (
+	stepProvider onboardingStep = iota
+	stepURL
+	stepKey
+	stepTesting
+	stepModel
+	stepPulling
+	stepSkills
+)
+
+// Onboarding messages
+type (
+	modelsMsg struct {
+		models []string
+		err    error
+	}
+	pullProgressMsg string
+	pullDoneMsg     struct{ err error }
+)
+
+// modelWindow is how many models the picker shows at once
+const modelWindow = 10
+
+// Onboarding is the first-run flow: pick a provider, test the connection,
+// pick (or pull) a model and install the starter skills
+type Onboarding struct {
+	providers []OnboardingProvider
+	hooks     OnboardingHooks
+	step      onboardingStep
+	cursor    int
+
+	url     textinput.Model
+	key     textinput.Model
+	spinner spinner.Model
+
+	models    []string // Offered by the server
+	choices   []string // Models shown: the server's, then suggestions to pull
+	err       error
+	progress  string
+	pullEvent chan tea.Msg
+
+	result OnboardingResult
+}
+
+// NewOnboarding creates the first-run flow for the given providers
+func NewOnboarding(providers []OnboardingProvider, hooks OnboardingHooks) Onboarding {
+	url := textinput.New()
+	url.Prompt = "  "
+	key := textinput.New()
+	key.Prompt = "  "
+	key.EchoMode = textinput.EchoPassword
+
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+	sp.Style = lipgloss.NewStyle().Foreground(primaryColor)
+
+	return Onboarding{providers: providers, hooks: hooks, url: url, key: key, spinner: sp}
+}
+
+// Result returns the choices made, once the program has exited
+func (o Onboarding) Result() OnboardingResult {
+	return o.result
+}
+
+// Init starts the spinner
+func (o Onboarding) Init() tea.Cmd {
+	return o.spinner.Tick
+}
+
+// Update handles messages
+func (o Onboarding) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return o, tea.Quit
+		case "esc":
+			return o.back()
+		}
+		return o.handleKey(msg)
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		o.spinner, cmd = o.spinner.Update(msg)
+		return o, cmd
+
+	case modelsMsg:
+		if o.step != stepTesting {
+			return o, nil
+		}
+		if msg.err != nil {
+			o.err = msg.err
+			o.step = stepURL
+			return o, o.url.Focus()
+		}
+		o.models = msg.models
+		o.choices = append([]string(nil), msg.models...)
+		if o.hooks.Pull != nil {
+			for _, s := range o.provider().Suggested {
+				if !slices.Contains(o.choices, s) {
+					o.choices = append(o.choices, s)
+				}
+			}
+		}
+		if len(o.choices) == 0 {
+			o.err = fmt.Errorf("%s", i18n.T("onboard.no_models"))
+			o.step = stepURL
+			return o, o.url.Focus()
+		}
+		o.step = stepModel
+		o.cursor = 0
+		return o, nil
+
+	case pullProgressMsg:
+		o.progress = string(msg)
+		return o, o.waitPull()
+
+	case pullDoneMsg:
+		if msg.err != nil {
+			o.err = msg.err
+			o.step = stepModel
+			return o, nil
+		}
+		o.models = append(o.models, o.result.Model)
+		o.step = stepSkills
+		return o, nil
+	}
+
+	return o.updateInputs(msg)
+}
+
+// handleKey moves through the current step
+func (o Onboarding) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch o.step {
+	case stepProvider:
+		switch msg.String() {
+		case "up", "k":
+			o.cursor = max(o.cursor-1, 0)
+		case "down", "j":
+			o.cursor = min(o.cursor+1, len(o.providers)-1)
+		case "enter":
+			p := o.providers[o.cursor]
+			o.result.Provider = p
+			o.url.SetValue(p.BaseURL)
+			o.url.CursorEnd()
+			o.err = nil
+			o.step = stepURL
+			return o, o.url.Focus()
+		}
+		return o, nil
+
+	case stepURL:
+		if msg.String() != "enter" {
+			return o.updateInputs(msg)
+		}
+		o.result.BaseURL = strings.TrimSpace(o.url.Value())
+		o.url.Blur()
+		if o.provider().KeyEnv == "" {
+			return o.test()
+		}
+		if o.key.Value() == "" {
+			o.key.SetValue(os.Getenv(o.provider().KeyEnv))
+		}
+		o.step = stepKey
+		return o, o.key.Focus()
+
+	case stepKey:
+		if msg.String() != "enter" {
+			return o.updateInputs(msg)
+		}
+		o.result.APIKey = strings.TrimSpace(o.key.Value())
+		o.key.Blur()
+		return o.test()
+
+	case stepModel:
+		switch msg.String() {
+		case "up", "k":
+			o.cursor = max(o.cursor-1, 0)
+		case "down", "j":
+			o.cursor = min(o.cursor+1, len(o.choices)-1)
+		case "enter":
+			o.result.Model = o.choices[o.cursor]
+			o.err = nil
+			if !slices.Contains(o.models, o.result.Model) {
+				return o.pull()
+			}
+			o.step = stepSkills
+		}
+		return o, nil
+
+	case stepSkills:
+		switch strings.ToLower(msg.String()) {
+		case "y", "enter":
+			o.result.InstallSkills = true
+		case "n":
+			o.result.InstallSkills = false
+		default:
+			return o, nil
+		}
+		o.result.Done = true
+		return o, tea.Quit
+	}
+	return o, nil
+}
+
+// back returns to the previous step, quitting from the first
+func (o Onboarding) back() (tea.Model, tea.Cmd) {
+	o.err = nil
+	switch o.step {
+	case stepProvider:
+		return o, tea.Quit
+	case stepURL, stepTesting:
+		o.url.Blur()
+		o.step = stepProvider
+		return o, nil
+	case stepKey:
+		o.key.Blur()
+		o.step = stepURL
+		return o, o.url.Focus()
+	case stepModel, stepSkills:
+		o.step = stepModel
+		return o, nil
+	}
+	return o, nil
+}
+
+// test lists the server's models in the background
+func (o Onboarding) test() (tea.Model, tea.Cmd) {
+	o.step = stepTesting
+	o.err = nil
+	p, url, key := o.provider(), o.result.BaseURL, o.result.APIKey
+	list := o.hooks.ListModels
+	return o, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		models, err := list(ctx, p, url, key)
+		return modelsMsg{models: models, err: err}
+	}
+}
+
+// pull downloads the chosen model, streaming its progress
+func (o Onboarding) pull() (tea.Model, tea.Cmd) {
+	o.step = stepPulling
+	o.progress = ""
+	o.pullEvent = make(chan tea.Msg)
+	events, url, model, pull := o.pullEvent, o.result.BaseURL, o.result.Model, o.hooks.Pull
+	go func() {
+		err := pull(context.Background(), url, model, func(status string) {
+			events <- pullProgressMsg(status)
+		})
+		events <- pullDoneMsg{err: err}
+	}()
+	return o, o.waitPull()
+}
+
+// waitPull waits for the next pull event
+func (o Onboarding) waitPull() tea.Cmd {
+	events := o.pullEvent
+	return func() tea.Msg { return <-events }
+}
+
+// updateInputs passes a message to the focused text input
+func (o Onboarding) updateInputs(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch o.step {
+	case stepURL:
+		o.url, cmd = o.url.Update(msg)
+	case stepKey:
+		o.key, cmd = o.key.Update(msg)
+	}
+	return o, cmd
+}
+
+func (o Onboarding) provider() OnboardingProvider {
+	return o.result.Provider
+}
+
+// View renders the current step
+func (o Onboarding) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("🚀 " + i18n.T("onboard.title")))
+	b.WriteString("\n")
+
+	switch o.step {
+	case stepProvider:
+		b.WriteString(i18n.T("onboard.pick_provider") + "\n\n")
+		for i, p := range o.providers {
+			b.WriteString(pickerLine(i == o.cursor, p.Label) + "\n")
+		}
+
+	case stepURL:
+		b.WriteString(i18n.T("onboard.base_url", o.provider().Label) + "\n\n")
+		b.WriteString(o.url.View() + "\n")
+
+	case stepKey:
+		b.WriteString(i18n.T("onboard.api_key", o.provider().Label) + "\n")
+		b.WriteString(mutedStyle.Render(i18n.T("onboard.api_key_env", o.provider().KeyEnv)) + "\n\n")
+		b.WriteString(o.key.View() + "\n")
+
+	case stepTesting:
+		b.WriteString(o.spinner.View() + " " + i18n.T("onboard.testing", o.result.BaseURL) + "\n")
+
+	case stepModel:
+		b.WriteString(i18n.T("onboard.pick_model") + "\n\n")
+		start := min(max(o.cursor-modelWindow/2, 0), max(len(o.choices)-modelWindow, 0))
+		for i := start; i < min(start+modelWindow, len(o.choices)); i++ {
+			label := o.choices[i]
+			if !slices.Contains(o.models, label) {
+				label += mutedStyle.Render(" (" + i18n.T("onboard.pull") + ")")
+			}
+			b.WriteString(pickerLine(i == o.cursor, label) + "\n")
+		}
+		if len(o.choices) > modelWindow {
+			b.WriteString(mutedStyle.Render(fmt.Sprintf("  %d/%d", o.cursor+1, len(o.choices))) + "\n")
+		}
+
+	case stepPulling:
+		b.WriteString(o.spinner.View() + " " + i18n.T("onboard.pulling", o.result.Model) + "\n")
+		if o.progress != "" {
+			b.WriteString(mutedStyle.Render("  "+o.progress) + "\n")
+		}
+
+	case stepSkills:
+		b.WriteString(i18n.T("onboard.connected", o.provider().Label, o.result.Model) + "\n\n")
+		b.WriteString(i18n.T("onboard.install_skills") + " [Y/n]\n")
+	}
+
+	if o.err != nil {
+		b.WriteString("\n" + errorStyle.Render("✗ "+o.err.Error()) + "\n")
+	}
+	b.WriteString("\n" + helpStyle.Render(i18n.T("onboard.keys")) + "\n")
+	return b.String()
+}
+
+// pickerLine renders one row of a list, highlighted when selected
+func pickerLine(selected bool, label string) string {
+	if selected {
+		return assistantStyle.Render("› ") + label
+	}
+	return "  " + label
+}
@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/i18n"
+	"github.com/agentflow/agentflow/internal/permission"
+	"github.com/agentflow/agentflow/pkg/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// permissionRequest is a tool call waiting for the user to allow it
+type permissionRequest struct {
+	call    types.ToolCall
+	reply   chan<- permission.Answer
+	editing bool   // The arguments are in the input, being edited
+	draft   string // What the input held before
+}
+
+// permissionMsg asks the user about a tool call
+type permissionMsg struct {
+	call   types.ToolCall
+	detail string
+	reply  chan<- permission.Answer
+}
+
+// SendPermissionRequest asks the user whether a tool call may run,
+// showing detail; the answer goes to reply, which must have room for it
+func SendPermissionRequest(call types.ToolCall, detail string, reply chan<- permission.Answer) tea.Cmd {
+	return func() tea.Msg {
+		return permissionMsg{call: call, detail: detail, reply: reply}
+	}
+}
+
+// askPermission shows a tool call and the choices for it, holding keys
+// back for the answer
+func (m Model) askPermission(msg permissionMsg) Model {
+	if m.permit != nil {
+		// A call still waiting is given up for the new one
+		m.permit.reply <- permission.Answer{Choice: permission.Deny}
+	}
+	m.permit = &permissionRequest{call: msg.call, reply: msg.reply}
+	rule := permission.Rule{Tool: msg.call.Name, Pattern: permission.Suggest(msg.call)}
+	return m.notice(i18n.T("msg.permission_ask", msg.detail, rule))
+}
+
+// answerPermission takes a for allow once (or Enter), A for always, d
+// (or n, Esc) for deny and e to edit the arguments in the input, which
+// Enter then runs
+func (m Model) answerPermission(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.permit
+	if p.editing {
+		switch msg.String() {
+		case "enter":
+			args := strings.TrimSpace(m.input.Value())
+			var v any
+			if err := json.Unmarshal([]byte(args), &v); err != nil {
+				return m.notice(i18n.T("msg.permission_invalid", err)), nil
+			}
+			call := p.call
+			call.Arguments = args
+			m.input.SetValue(p.draft)
+			return m.decide(permission.Answer{Choice: permission.Once, Call: call}), nil
+		case "esc":
+			p.editing = false
+			m.input.SetValue(p.draft)
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "a", "y", "enter":
+		return m.decide(permission.Answer{Choice: permission.Once}), nil
+	case "A":
+		pattern := permission.Suggest(p.call)
+		m = m.decide(permission.Answer{Choice: permission.Always, Pattern: pattern})
+		return m.notice(i18n.T("msg.permission_always", permission.Rule{Tool: p.call.Name, Pattern: pattern})), nil
+	case "d", "n", "esc", "ctrl+c":
+		m = m.decide(permission.Answer{Choice: permission.Deny})
+		return m.notice(i18n.T("msg.permission_denied", p.call.Name)), nil
+	case "e":
+		p.editing, p.draft = true, m.input.Value()
+		m.input.SetValue(p.call.Arguments)
+		return m.notice(i18n.T("msg.permission_edit")), nil
+	}
+	return m, nil
+}
+
+// decide sends the answer to the waiting call
+func (m Model) decide(answer permission.Answer) Model {
+	m.permit.reply <- answer
+	m.permit = nil
+	return m
+}
+
+// notice shows a system message at the bottom of the conversation
+func (m Model) notice(text string) Model {
+	m.messages = append(m.messages, ChatMessage{Role: "system", Content: text, Timestamp: time.Now()})
+	m.viewport.SetContent(m.renderMessages())
+	m.viewport.GotoBottom()
+	return m
+}
@@ -0,0 +1,27 @@
+package tui
+
+import (
+	"github.com/agentflow/agentflow/internal/i18n"
+	"github.com/agentflow/agentflow/internal/input"
+)
+
+// SetReadOnly refuses shell commands from the input that may change
+// things, for sessions started with --read-only or under a policy
+// that requires it
+func (m *Model) SetReadOnly(on bool) {
+	m.readOnly = on
+}
+
+// refuseWrites tells the user, keeping their input, when a read-only
+// session would run a command that changes things
+func (m Model) refuseWrites(commands []string) (Model, bool) {
+	if !m.readOnly {
+		return m, false
+	}
+	for _, command := range commands {
+		if input.Writes(command) {
+			return m.notice(i18n.T("msg.read_only_refused", command)), true
+		}
+	}
+	return m, false
+}
@@ -0,0 +1,265 @@
+package tui
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/i18n"
+	"github.com/agentflow/agentflow/internal/input"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	tabStyle       = lipgloss.NewStyle().Foreground(mutedColor).Padding(0, 1)
+	activeTabStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Background(primaryColor).Padding(0, 1)
+)
+
+// Tabs runs several conversations in one TUI, each a Model with its own
+// agent and model, showing one at a time. The others keep streaming in
+// the background.
+type Tabs struct {
+	tabs   []tab
+	active int
+	nextID int
+	size   tea.WindowSizeMsg
+
+	onNew func(id int, spec string) (Model, error) // Builds a tab's conversation
+}
+
+// tab is one conversation
+type tab struct {
+	id    int
+	model Model
+}
+
+// tabMsg carries a message to the tab it belongs to, wherever the user is
+type tabMsg struct {
+	id  int
+	msg tea.Msg
+}
+
+// NewTabs shows first as the only tab, with ID 0. Without SetOnNewTab no
+// other tab can be opened and it behaves like first alone.
+func NewTabs(first Model) Tabs {
+	return Tabs{tabs: []tab{{model: first}}, nextID: 1}
+}
+
+// SetOnNewTab sets the callback building the conversation of a tab
+// opened with /tab new. It receives the tab's ID, for messages sent with
+// ToTab, and the model asked for, "" for the default.
+func (t *Tabs) SetOnNewTab(fn func(id int, spec string) (Model, error)) {
+	t.onNew = fn
+}
+
+// ToTab addresses a message sent from outside the program, such as a
+// streamed chunk, to a tab
+func ToTab(id int, msg tea.Msg) tea.Msg {
+	return tabMsg{id: id, msg: msg}
+}
+
+// Init initializes the first tab
+func (t Tabs) Init() tea.Cmd {
+	return tagged(t.tabs[0].id, t.tabs[0].model.Init())
+}
+
+// tagged addresses the messages a tab's command produces to the tab.
+// Those meant for the program itself, such as tea.Quit or running a
+// pager, go to it untouched, with the commands of a batch still tagged.
+func tagged(id int, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			cmds := make(tea.BatchMsg, len(batch))
+			for i, c := range batch {
+				cmds[i] = tagged(id, c)
+			}
+			return cmds
+		}
+		if msg == nil || reflect.TypeOf(msg).PkgPath() == teaPackage {
+			return msg
+		}
+		return tabMsg{id: id, msg: msg}
+	}
+}
+
+// teaPackage is where the messages the program handles itself are defined
+var teaPackage = reflect.TypeOf(tea.QuitMsg{}).PkgPath()
+
+// Update routes messages to their tab and handles switching tabs
+func (t Tabs) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tabMsg:
+		return t.route(msg)
+
+	case tea.WindowSizeMsg:
+		t.size = msg
+		return t, t.resize()
+
+	case tea.KeyMsg:
+		if m := t.tabs[t.active].model; len(t.tabs) > 1 && m.confirm == nil && m.permit == nil {
+			switch key := msg.String(); {
+			case key == "ctrl+pgdown":
+				return t.switchTo((t.active + 1) % len(t.tabs)), nil
+			case key == "ctrl+pgup":
+				return t.switchTo((t.active + len(t.tabs) - 1) % len(t.tabs)), nil
+			case len(key) == 5 && strings.HasPrefix(key, "alt+") && key[4] >= '1' && key[4] <= '9':
+				if n := int(key[4] - '1'); n < len(t.tabs) {
+					return t.switchTo(n), nil
+				}
+			}
+		}
+	}
+
+	// Keys and anything not addressed go to the tab on screen
+	return t.route(tabMsg{id: t.tabs[t.active].id, msg: msg})
+}
+
+// route updates the tab a message is for; messages for a closed tab are
+// dropped
+func (t Tabs) route(msg tabMsg) (tea.Model, tea.Cmd) {
+	i := t.index(msg.id)
+	if i < 0 {
+		return t, nil
+	}
+	if inner, ok := msg.msg.(input.SubmitMsg); ok {
+		if fields := strings.Fields(inner.Value); !inner.IsBash && len(fields) > 0 && (fields[0] == "/tab" || fields[0] == "/tabs") {
+			t.tabs[i].model.input.Reset()
+			return t.command(i, fields[1:])
+		}
+	}
+
+	updated, cmd := t.tabs[i].model.Update(msg.msg)
+	t.tabs[i].model = updated.(Model)
+	return t, tagged(msg.id, cmd)
+}
+
+// command handles /tab: new [model], close, a tab's number, or nothing
+// to list them. from is the tab it was typed in, which close closes.
+func (t Tabs) command(from int, args []string) (tea.Model, tea.Cmd) {
+	var sub string
+	if len(args) > 0 {
+		sub = args[0]
+	}
+	switch sub {
+	case "":
+		return t.notify(from, t.list()), nil
+
+	case "new":
+		if t.onNew == nil {
+			return t.notify(from, i18n.T("msg.tab_unavailable")), nil
+		}
+		spec := ""
+		if len(args) > 1 {
+			spec = args[1]
+		}
+		m, err := t.onNew(t.nextID, spec)
+		if err != nil {
+			return t.notify(from, i18n.T("msg.error", err)), nil
+		}
+		m.compact = t.tabs[0].model.compact
+		t.tabs = append(t.tabs, tab{id: t.nextID, model: m})
+		t.nextID++
+		t = t.switchTo(len(t.tabs) - 1)
+		return t, tea.Batch(tagged(t.tabs[t.active].id, m.Init()), t.resize())
+
+	case "close":
+		if from == 0 {
+			return t.notify(from, i18n.T("msg.tab_main")), nil
+		}
+		if m := t.tabs[from].model; m.streaming && m.onCancel != nil {
+			m.onCancel()
+		}
+		t.tabs = append(t.tabs[:from], t.tabs[from+1:]...)
+		return t.switchTo(min(t.active, len(t.tabs)-1)), t.resize()
+	}
+
+	if n, err := strconv.Atoi(sub); err == nil && n >= 1 && n <= len(t.tabs) {
+		return t.switchTo(n - 1), nil
+	}
+	return t.notify(from, i18n.T("msg.tab_usage")), nil
+}
+
+// list describes the open tabs
+func (t Tabs) list() string {
+	lines := []string{i18n.T("msg.tabs")}
+	for i, tb := range t.tabs {
+		mark := " "
+		if i == t.active {
+			mark = "›"
+		}
+		lines = append(lines, fmt.Sprintf("%s %d  %s/%s  %s", mark, i+1, tb.model.provider, tb.model.model, i18n.T("ui.msgs", len(tb.model.messages))))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// notify shows a notice in a tab
+func (t Tabs) notify(i int, text string) Tabs {
+	m := &t.tabs[i].model
+	m.messages = append(m.messages, ChatMessage{Role: "system", Content: text, Timestamp: time.Now()})
+	m.viewport.SetContent(m.renderMessages())
+	m.viewport.GotoBottom()
+	return t
+}
+
+// switchTo shows tab i
+func (t Tabs) switchTo(i int) Tabs {
+	t.active = i
+	m := &t.tabs[i].model
+	m.viewport.SetContent(m.renderMessages())
+	return t
+}
+
+// index returns the position of the tab with an ID, or -1
+func (t Tabs) index(id int) int {
+	for i, tb := range t.tabs {
+		if tb.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// resize gives every tab the window, less the tab bar when it shows
+func (t Tabs) resize() tea.Cmd {
+	if t.size.Width == 0 {
+		return nil
+	}
+	size := t.size
+	if len(t.tabs) > 1 {
+		size.Height--
+	}
+	var cmds []tea.Cmd
+	for _, tb := range t.tabs {
+		id := tb.id
+		cmds = append(cmds, func() tea.Msg { return tabMsg{id: id, msg: size} })
+	}
+	return tea.Batch(cmds...)
+}
+
+// View shows the tab bar above the tab on screen, once there are two
+func (t Tabs) View() string {
+	view := t.tabs[t.active].model.View()
+	if len(t.tabs) == 1 {
+		return view
+	}
+	var bar strings.Builder
+	for i, tb := range t.tabs {
+		label := fmt.Sprintf("%d %s", i+1, tb.model.model)
+		if tb.model.streaming {
+			label += " " + tb.model.spinner.View()
+		}
+		if i == t.active {
+			bar.WriteString(activeTabStyle.Render(label))
+		} else {
+			bar.WriteString(tabStyle.Render(label))
+		}
+	}
+	return bar.String() + "\n" + view
+}
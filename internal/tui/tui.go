@@ -7,7 +7,17 @@ import (
 	"strings"
 	"time"
 
+	"github.com/agentflow/agentflow/internal/agent"
+	"github.com/agentflow/agentflow/internal/audit"
+	"github.com/agentflow/agentflow/internal/clipboard"
+	"github.com/agentflow/agentflow/internal/guard"
+	"github.com/agentflow/agentflow/internal/i18n"
 	"github.com/agentflow/agentflow/internal/input"
+	"github.com/agentflow/agentflow/internal/pager"
+	"github.com/agentflow/agentflow/internal/statusline"
+	"github.com/agentflow/agentflow/internal/tmux"
+	"github.com/agentflow/agentflow/internal/voice"
+	"github.com/agentflow/agentflow/pkg/types"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -74,12 +84,28 @@ var (
 type (
 	responseMsg       string
 	streamChunkMsg    string
+	streamReplaceMsg  string // Replaces the answer streamed so far
+	reasoningChunkMsg string
 	streamDoneMsg     struct{}
 	errorMsg          error
-	skillMatchedMsg   string
+	skillMatchedMsg   []agent.SkillActivation
 	tokensUpdatedMsg  int
+	streamStatsMsg    *agent.StreamStats
 	clearMsg          struct{}
+	noticeMsg         string
+	updateMsg         string   // Newer release available
+	expandedMsg       string   // A held back message, ready to send
+	fetchedMsg        []string // Chips for the pages fetched for the last message
+	modelSwitchedMsg  struct {
+		Spec string
+		Err  error
+	}
+	toolCallsMsg      []string
+	messageIDsMsg     [2]int // Numbers of the last user message and answer
+	redrawMsg         struct{}
+	userMessageMsg    string
 	bashResultMsg     struct {
+		Command string
 		Display string
 		Context string
 	}
@@ -106,19 +132,74 @@ type Model struct {
 	sessionStart  time.Time
 	lastSkill     string
 	requestCount  int
+	lastStats     *agent.StreamStats // Performance of the last response
+	streamStart   time.Time          // When the current response was requested
+	lastChunk     time.Time          // When the model last sent something
+	readTimeout   time.Duration      // How long the model may go quiet, for the countdown
+	newVersion    string             // Newer release, shown in the footer
 
 	// Config
 	provider string
 	model    string
+	compact  bool // Narrow layout for side panes
+
+	showReasoning bool // Expand reasoning instead of a one-line summary
+	composing     bool // Compose mode; see setComposing
+	showIDs       bool // Number messages as /show and /pin count them
+	vimG          bool // A g was typed in vim's normal mode, waiting for gg
+	search        *transcriptSearch
+
+	voice        voice.Config     // How /voice records and transcribes
+	recording    *voice.Recording // Speech being recorded for the input
+	transcribing bool
+	speaker      *voice.Speaker // Speaks answers while speaking is on
+	speaking     bool
+
+	confirm *confirmation      // Yes/no question holding back a message
+	permit  *permissionRequest // Tool call waiting for the user to allow it
+	guard   *guard.Guard       // Screens command output and mentioned files
+	audit   *audit.Log         // Records shell commands
+
+	readOnly bool // Shell commands that change things are refused
+
+	activity     []types.Activity // Tool calls the agent ran, for the sidebar
+	hideActivity bool             // Sidebar hidden with /activity
 
 	// Callbacks
-	onSubmit func(string) tea.Cmd
+	onSubmit  func(string) tea.Cmd
+	onContext func(string) // Receives context added outside the chat (bash, panes)
+	onShell   func(string) // Told of each shell command the user runs, ! or substituted
+	onAttach  func(types.Attachment)
+	onCommand func(cmd string, args []string) (string, bool) // Commands handled by the host
+	onStatus  func() string                                  // Extra /status sections from the host
+	onCancel  func()                                         // Stops the response being streamed
+	onRewind  func(retry []string) (string, error)           // Takes back the last exchange
+	onModel   func(spec string) error                        // Switches the agent to "provider/model"
+	onMention func([]input.Mention)                          // Files sent with a message
+	onFetch   func(urls []string) []string                   // Fetches pasted URLs into context, returning chips
+	onExecute func() (string, error)                         // Ends plan mode, returning the plan to send
+	askFetch  bool                                           // Confirm before onFetch
+
+	checkUpdate func() string                // Returns a newer release, run in the background
+	statusLine  func(statusline.Info) string // Custom status bar; "" shows the default
+}
+
+// confirmation is a yes/no question about a message before it is sent
+type confirmation struct {
+	message string                           // Put back in the input on no
+	yes     func(Model) (tea.Model, tea.Cmd) // Run on yes
+	no      func(Model) (tea.Model, tea.Cmd) // Run on no instead of putting the message back
 }
 
 // ChatMessage represents a message in the conversation
 type ChatMessage struct {
-	Role      string // "user", "assistant", "system", "skill"
+	Role      string // "user", "assistant", "system", "skill", "bash", "context"
 	Content   string
+	Reasoning string   // Model thinking, shown collapsed
+	Chips     []string // Files and the like sent along, in short
+	ID        int      // Number in the agent's history, 0 when unknown
+	Title     string   // Names a collapsible block, as "git diff"
+	Expanded  bool     // Shows a collapsible block in full; see /expand
 	Timestamp time.Time
 }
 
@@ -129,7 +210,7 @@ func New(provider, model string) Model {
 
 	// Create enhanced input
 	inp := input.New(workdir)
-	inp.SetPlaceholder("Type a message... (Enter to send, /help for commands, ! for bash)")
+	inp.SetPlaceholder(i18n.T("input.placeholder"))
 
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
@@ -151,10 +232,12 @@ func New(provider, model string) Model {
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(
-		m.input.Init(),
-		m.spinner.Tick,
-	)
+	cmds := []tea.Cmd{m.input.Init(), m.spinner.Tick}
+	if m.checkUpdate != nil {
+		check := m.checkUpdate
+		cmds = append(cmds, func() tea.Msg { return updateMsg(check()) })
+	}
+	return tea.Batch(cmds...)
 }
 
 // Update handles messages
@@ -164,10 +247,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.permit != nil {
+			return m.answerPermission(msg)
+		}
+		if m.confirm != nil {
+			return m.answerConfirm(msg)
+		}
+		if m.recording != nil {
+			return m.recordingKey(msg)
+		}
+		if m.search != nil && m.search.typing {
+			return m.findKey(msg), nil
+		}
+		if m.input.VimNormal() && m.input.Mode() == input.ModeNormal {
+			if updated, ok := m.vimKey(msg); ok {
+				return updated, nil
+			}
+		}
 		switch msg.String() {
 		case "ctrl+c":
 			if m.streaming {
 				m.streaming = false
+				m.silence()
+				if m.onCancel != nil {
+					m.onCancel()
+				}
+				return m, nil
+			}
+			if m.silence() {
 				return m, nil
 			}
 			// Let input handle ctrl+c in non-normal modes
@@ -178,17 +285,50 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 
 		case "esc":
+			// In vim mode Esc first leaves insert mode
+			if m.input.VimInsert() && m.input.Mode() == input.ModeNormal {
+				m.input, cmd = m.input.Update(msg)
+				return m, cmd
+			}
 			if m.streaming {
 				m.streaming = false
+				m.silence()
+				if m.onCancel != nil {
+					m.onCancel()
+				}
+				return m, nil
+			}
+			if m.silence() {
 				return m, nil
 			}
+			if m.search != nil {
+				m.search = nil
+				m.viewport.SetContent(m.renderMessages())
+				return m, nil
+			}
+			if m.composing && m.input.Mode() == input.ModeNormal {
+				return m.setComposing(false), nil
+			}
 			// Let input handle esc in non-normal modes
 			if m.input.Mode() != input.ModeNormal {
 				m.input, cmd = m.input.Update(msg)
 				return m, cmd
 			}
+			if m.input.VimNormal() {
+				return m, nil
+			}
 			return m, tea.Quit
 
+		case "ctrl+f":
+			if m.input.Mode() == input.ModeNormal {
+				return m.startFind(""), nil
+			}
+
+		case "ctrl+o":
+			if !m.streaming {
+				return m.setComposing(!m.composing), nil
+			}
+
 		case "ctrl+l":
 			m.messages = make([]ChatMessage, 0)
 			m.viewport.SetContent("")
@@ -202,6 +342,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case input.SubmitMsg:
 		return m.handleInputSubmit(msg)
 
+	case expandedMsg:
+		return m.submit(string(msg))
+
+	case thenMsg:
+		updated, cmd := m.Update(msg.msg)
+		return updated, tea.Batch(cmd, msg.next)
+
+	case fetchedMsg:
+		for i := len(m.messages) - 1; i >= 0; i-- {
+			if m.messages[i].Role == "user" {
+				m.messages[i].Chips = append(m.messages[i].Chips, msg...)
+				break
+			}
+		}
+		m.viewport.SetContent(m.renderMessages())
+		return m, nil
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
@@ -209,49 +366,144 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		headerHeight := 3
 		footerHeight := 8 // Increased for autocomplete popup
+		if m.compact {
+			headerHeight = 1
+		}
 		verticalMargin := headerHeight + footerHeight
 
-		m.viewport.Width = msg.Width
+		m.viewport.Width = msg.Width - m.sidebarWidth()
 		m.viewport.Height = msg.Height - verticalMargin
 		m.input.SetWidth(msg.Width - 4)
+		m.input.SetHeight(m.composeInputHeight())
 
 		m.viewport.SetContent(m.renderMessages())
 		return m, nil
 
 	case streamChunkMsg:
+		m.lastChunk = time.Now()
 		m.currentResp.WriteString(string(msg))
+		if m.speaking {
+			m.speaker.Write(string(msg))
+		}
 		m.updateLastAssistantMessage(m.currentResp.String())
 		m.viewport.SetContent(m.renderMessages())
 		m.viewport.GotoBottom()
 		return m, nil
 
+	case streamReplaceMsg:
+		m.lastChunk = time.Now()
+		m.currentResp.Reset()
+		m.currentResp.WriteString(string(msg))
+		if m.speaking {
+			m.speaker.Stop()
+			m.speaker.Write(string(msg))
+		}
+		m.updateLastAssistantMessage(m.currentResp.String())
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+		return m, nil
+
+	case updateMsg:
+		m.newVersion = string(msg)
+		return m, nil
+
+	case modelSwitchedMsg:
+		content := i18n.T("msg.error", msg.Err)
+		if msg.Err == nil {
+			m.provider, m.model = msg.Spec, msg.Spec
+			if i := strings.Index(msg.Spec, "/"); i > 0 {
+				m.provider, m.model = msg.Spec[:i], msg.Spec[i+1:]
+			}
+			content = i18n.T("msg.model_changed", msg.Spec)
+		}
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "system",
+			Content:   content,
+			Timestamp: time.Now(),
+		})
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+		return m, nil
+
+	case reasoningChunkMsg:
+		m.lastChunk = time.Now()
+		for i := len(m.messages) - 1; i >= 0; i-- {
+			if m.messages[i].Role == "assistant" {
+				m.messages[i].Reasoning += string(msg)
+				break
+			}
+		}
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+		return m, nil
+
 	case streamDoneMsg:
 		m.streaming = false
 		m.requestCount++
+		if m.speaking {
+			m.speaker.Flush()
+		}
+		return m, nil
+
+	case redrawMsg:
+		return m, nil
+
+	case voiceTextMsg:
+		return m.heard(msg), nil
+
+	case messageIDsMsg:
+		for i, role := range []string{"user", "assistant"} {
+			for j := len(m.messages) - 1; j >= 0 && msg[i] > 0; j-- {
+				if m.messages[j].Role == role {
+					m.messages[j].ID = msg[i]
+					break
+				}
+			}
+		}
+		m.viewport.SetContent(m.renderMessages())
 		return m, nil
 
 	case bashResultMsg:
-		// Add bash result to conversation
+		// Add bash result to conversation; what the model is sent is the
+		// same output, so it isn't shown again
+		if m.onShell != nil {
+			m.onShell(msg.Command)
+		}
 		m.messages = append(m.messages, ChatMessage{
 			Role:      "bash",
 			Content:   msg.Display,
+			Title:     "$ " + msg.Command,
 			Timestamp: time.Now(),
 		})
-		// Also add to context as a system message
-		m.messages = append(m.messages, ChatMessage{
-			Role:      "context",
-			Content:   msg.Context,
-			Timestamp: time.Now(),
+		model, cmd := m.screen("The output of `"+msg.Command+"`", msg.Context, func(m Model, content string) (tea.Model, tea.Cmd) {
+			if m.onContext != nil {
+				m.onContext(content)
+			}
+			return m, nil
 		})
+		m = model.(Model)
 		m.viewport.SetContent(m.renderMessages())
 		m.viewport.GotoBottom()
-		return m, nil
+		return m, cmd
 
 	case skillMatchedMsg:
-		m.lastSkill = string(msg)
+		// One banner for all the skills a message activated
+		names := make([]string, len(msg))
+		for i, act := range msg {
+			names[i] = act.Skill
+		}
+		m.lastSkill = strings.Join(names, " + ")
+		content := i18n.T("msg.skill_activated", msg[0].Skill, msg[0].Reason)
+		if len(msg) > 1 {
+			acts := make([]string, len(msg))
+			for i, act := range msg {
+				acts[i] = act.String()
+			}
+			content = i18n.T("msg.skills_activated", strings.Join(acts, ", "))
+		}
 		m.messages = append(m.messages, ChatMessage{
 			Role:      "skill",
-			Content:   fmt.Sprintf("Skill activated: %s", msg),
+			Content:   content,
 			Timestamp: time.Now(),
 		})
 		m.viewport.SetContent(m.renderMessages())
@@ -261,12 +513,62 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.totalTokens += int(msg)
 		return m, nil
 
+	case streamStatsMsg:
+		m.lastStats = msg
+		m.totalTokens += msg.PromptTokens + msg.Tokens
+		return m, nil
+
+	case userMessageMsg:
+		// A message sent from outside the input, as in a replay
+		m.messages = append(m.messages,
+			ChatMessage{Role: "user", Content: string(msg), Timestamp: time.Now()},
+			ChatMessage{Role: "assistant", Timestamp: time.Now()})
+		m.streaming = true
+		m.streamStart, m.lastChunk = time.Now(), time.Now()
+		m.currentResp.Reset()
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+		return m, nil
+
+	case activityMsg:
+		return m.addActivity(types.Activity(msg)), nil
+
+	case permissionMsg:
+		return m.askPermission(msg), nil
+
+	case noticeMsg:
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "system",
+			Content:   string(msg),
+			Timestamp: time.Now(),
+		})
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+		return m, nil
+
+	case toolCallsMsg:
+		// Close the current response (dropping it if the model only
+		// called tools), show the calls, and stream the rest below them
+		if last := len(m.messages) - 1; last >= 0 && m.messages[last].Role == "assistant" &&
+			strings.TrimSpace(m.messages[last].Content) == "" && m.messages[last].Reasoning == "" {
+			m.messages = m.messages[:last]
+		}
+		for _, call := range msg {
+			m.messages = append(m.messages, ChatMessage{Role: "system", Content: "🔧 " + call, Timestamp: time.Now()})
+		}
+		m.lastChunk = time.Now()
+		m.messages = append(m.messages, ChatMessage{Role: "assistant", Timestamp: time.Now()})
+		m.currentResp.Reset()
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+		return m, nil
+
 	case errorMsg:
 		m.err = msg
 		m.streaming = false
 		m.messages = append(m.messages, ChatMessage{
 			Role:      "system",
-			Content:   fmt.Sprintf("Error: %v", msg),
+			Content:   i18n.T("msg.error", msg),
 			Timestamp: time.Now(),
 		})
 		m.viewport.SetContent(m.renderMessages())
@@ -305,9 +607,15 @@ func (m Model) handleInputSubmit(msg input.SubmitMsg) (tea.Model, tea.Cmd) {
 	if inputValue == "" {
 		return m, nil
 	}
+	if m.composing {
+		m = m.setComposing(false)
+	}
 
 	// Handle bash commands
 	if msg.IsBash {
+		if m, refused := m.refuseWrites([]string{inputValue}); refused {
+			return m, nil
+		}
 		return m.handleBashCommand(inputValue)
 	}
 
@@ -316,12 +624,122 @@ func (m Model) handleInputSubmit(msg input.SubmitMsg) (tea.Model, tea.Cmd) {
 		return m.handleCommand(inputValue)
 	}
 
-	// Add user message
+	// Commands embedded as !`command` run once confirmed
+	if commands := input.Substitutions(inputValue); len(commands) > 0 {
+		if m, refused := m.refuseWrites(commands); refused {
+			return m, nil
+		}
+		m.input.Reset()
+		return m.ask(i18n.T("msg.confirm_substitution", "$ "+strings.Join(commands, "\n$ ")), confirmation{
+			message: inputValue,
+			yes: func(m Model) (tea.Model, tea.Cmd) {
+				if m.onShell != nil {
+					for _, command := range commands {
+						m.onShell(command)
+					}
+				}
+				return m, func() tea.Msg {
+					for _, command := range commands {
+						m.audit.Record(audit.Entry{Kind: audit.Bash, Action: command, Detail: "substituted into a message"})
+					}
+					return expandedMsg(input.ExpandSubstitutions(context.Background(), inputValue))
+				}
+			},
+		}), nil
+	}
+
+	return m.submit(inputValue)
+}
+
+// ask holds a message back behind a yes/no question
+func (m Model) ask(question string, c confirmation) Model {
+	m.confirm = &c
 	m.messages = append(m.messages, ChatMessage{
-		Role:      "user",
-		Content:   inputValue,
+		Role:      "system",
+		Content:   question + " [y/n]",
 		Timestamp: time.Now(),
 	})
+	m.viewport.SetContent(m.renderMessages())
+	m.viewport.GotoBottom()
+	return m
+}
+
+// answerConfirm takes y (or Enter) and n (or Esc) for the pending
+// question; unless the question says otherwise, n puts the message back
+// in the input
+func (m Model) answerConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.confirm
+	switch strings.ToLower(msg.String()) {
+	case "y", "enter":
+		m.confirm = nil
+		return c.yes(m)
+	case "n", "esc", "ctrl+c":
+		m.confirm = nil
+		if c.no != nil {
+			return c.no(m)
+		}
+		m.input.SetValue(c.message)
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "system",
+			Content:   i18n.T("msg.not_sent"),
+			Timestamp: time.Now(),
+		})
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+	}
+	return m, nil
+}
+
+// submit shows a message with an empty answer to stream into and sends it
+func (m Model) submit(inputValue string) (tea.Model, tea.Cmd) {
+	// Files mentioned as @path go along with the message, shown as chips
+	sent, mentions := input.ExpandMentions(inputValue)
+	msg := ChatMessage{Role: "user", Content: inputValue, Timestamp: time.Now()}
+	for _, mn := range mentions {
+		msg.Chips = append(msg.Chips, mn.Chip())
+	}
+	if len(mentions) > 0 && m.onMention != nil {
+		m.onMention(mentions)
+	}
+	if len(mentions) == 0 {
+		return m.fetchAndSend(msg, sent)
+	}
+
+	// Mentioned files are screened for prompt injection; what the user
+	// typed isn't
+	m.input.Reset()
+	files := strings.TrimPrefix(sent[len(inputValue):], "\n\n")
+	return m.screen("The mentioned files", files, func(m Model, files string) (tea.Model, tea.Cmd) {
+		return m.fetchAndSend(msg, inputValue+"\n\n"+files)
+	})
+}
+
+// fetchAndSend sends a message, fetching the URLs pasted in it first
+func (m Model) fetchAndSend(msg ChatMessage, sent string) (tea.Model, tea.Cmd) {
+	inputValue := msg.Content
+
+	// Pasted URLs are fetched first, once confirmed unless set to auto
+	urls := input.URLs(inputValue)
+	if len(urls) == 0 || m.onFetch == nil {
+		return m.send(msg, sent)
+	}
+	fetch := func() tea.Msg { return fetchedMsg(m.onFetch(urls)) }
+	if !m.askFetch {
+		return m.send(msg, sent, fetch)
+	}
+	m.input.Reset()
+	return m.ask(i18n.T("msg.confirm_fetch", strings.Join(urls, "\n")), confirmation{
+		message: inputValue,
+		yes:     func(m Model) (tea.Model, tea.Cmd) { return m.send(msg, sent, fetch) },
+		no:      func(m Model) (tea.Model, tea.Cmd) { return m.send(msg, sent) },
+	}), nil
+}
+
+// send shows a user message with an empty answer to stream into, and
+// sends text for it once any before commands have run
+func (m Model) send(msg ChatMessage, text string, before ...tea.Cmd) (tea.Model, tea.Cmd) {
+	// Add user message
+	m.messages = append(m.messages, msg)
 
 	// Add empty assistant message for streaming
 	m.messages = append(m.messages, ChatMessage{
@@ -332,18 +750,40 @@ func (m Model) handleInputSubmit(msg input.SubmitMsg) (tea.Model, tea.Cmd) {
 
 	m.input.Reset()
 	m.streaming = true
+	m.streamStart, m.lastChunk = time.Now(), time.Now()
 	m.currentResp.Reset()
 	m.viewport.SetContent(m.renderMessages())
 	m.viewport.GotoBottom()
 
 	// Trigger the submit callback
 	if m.onSubmit != nil {
-		return m, m.onSubmit(inputValue)
+		if len(before) > 0 {
+			return m, then(append(before, m.onSubmit(text))...)
+		}
+		return m, m.onSubmit(text)
 	}
 
 	return m, nil
 }
 
+// thenMsg is the result of a command, delivered before the next one
+// runs
+type thenMsg struct {
+	msg  tea.Msg
+	next tea.Cmd
+}
+
+// then runs commands one after another like tea.Sequence, but as plain
+// messages, which Tabs can route to the tab they belong to
+func then(cmds ...tea.Cmd) tea.Cmd {
+	if len(cmds) == 1 {
+		return cmds[0]
+	}
+	return func() tea.Msg {
+		return thenMsg{msg: cmds[0](), next: then(cmds[1:]...)}
+	}
+}
+
 // handleBashCommand executes a bash command and adds output to context
 func (m Model) handleBashCommand(command string) (tea.Model, tea.Cmd) {
 	m.input.Reset()
@@ -352,7 +792,9 @@ func (m Model) handleBashCommand(command string) (tea.Model, tea.Cmd) {
 	// Execute bash command asynchronously
 	return m, func() tea.Msg {
 		result := input.ExecuteBash(context.Background(), command)
+		m.auditBash(result, "run with !")
 		return bashResultMsg{
+			Command: command,
 			Display: input.FormatBashResult(result),
 			Context: input.FormatBashResultForContext(result),
 		}
@@ -380,39 +822,52 @@ func (m Model) handleCommand(input string) (tea.Model, tea.Cmd) {
 		m.messages = make([]ChatMessage, 0)
 
 	case "/model":
+		if len(parts) > 1 && m.onModel != nil {
+			m.input.Reset()
+			return m, m.switchModel(parts[1])
+		}
 		if len(parts) > 1 {
 			m.model = parts[1]
 			m.messages = append(m.messages, ChatMessage{
 				Role:      "system",
-				Content:   fmt.Sprintf("Model changed to: %s", m.model),
+				Content:   i18n.T("msg.model_changed", m.model),
 				Timestamp: time.Now(),
 			})
 		} else {
 			m.messages = append(m.messages, ChatMessage{
 				Role:      "system",
-				Content:   fmt.Sprintf("Current model: %s", m.model),
+				Content:   i18n.T("msg.model_current", m.model),
 				Timestamp: time.Now(),
 			})
 		}
 
 	case "/provider":
+		if len(parts) > 1 && m.onModel != nil {
+			m.input.Reset()
+			return m, m.switchModel(parts[1] + "/" + m.model)
+		}
 		if len(parts) > 1 {
 			m.provider = parts[1]
 			m.messages = append(m.messages, ChatMessage{
 				Role:      "system",
-				Content:   fmt.Sprintf("Provider changed to: %s", m.provider),
+				Content:   i18n.T("msg.provider_changed", m.provider),
 				Timestamp: time.Now(),
 			})
 		} else {
 			m.messages = append(m.messages, ChatMessage{
 				Role:      "system",
-				Content:   fmt.Sprintf("Current provider: %s", m.provider),
+				Content:   i18n.T("msg.provider_current", m.provider),
 				Timestamp: time.Now(),
 			})
 		}
 
 	case "/status":
 		status := m.renderStatus()
+		if m.onStatus != nil {
+			if extra := m.onStatus(); extra != "" {
+				status += "\n\n" + extra
+			}
+		}
 		m.messages = append(m.messages, ChatMessage{
 			Role:      "system",
 			Content:   status,
@@ -422,28 +877,188 @@ func (m Model) handleCommand(input string) (tea.Model, tea.Cmd) {
 	case "/skills":
 		m.messages = append(m.messages, ChatMessage{
 			Role:      "system",
-			Content:   "Available skills:\n• brainstorming\n• writing-plans\n• subagent-driven-development\n• test-driven-development\n• systematic-debugging\n• verification-before-completion",
+			Content:   i18n.T("msg.skills_available") + "\n• brainstorming\n• writing-plans\n• subagent-driven-development\n• test-driven-development\n• systematic-debugging\n• verification-before-completion",
 			Timestamp: time.Now(),
 		})
 
 	case "/compact":
 		m.messages = append(m.messages, ChatMessage{
 			Role:      "system",
-			Content:   "Conversation compacted (not yet implemented)",
+			Content:   i18n.T("msg.compacted"),
+			Timestamp: time.Now(),
+		})
+
+	case "/pane":
+		target := ""
+		if len(parts) > 1 {
+			target = parts[1]
+		}
+		content, err := tmux.Capture(target)
+		if err != nil {
+			m.messages = append(m.messages, ChatMessage{
+				Role:      "system",
+				Content:   i18n.T("msg.error", err),
+				Timestamp: time.Now(),
+			})
+			break
+		}
+		paneContext := tmux.FormatContext(target, content)
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "context",
+			Content:   paneContext,
+			Title:     paneTitle(target),
+			Timestamp: time.Now(),
+		})
+		if m.onContext != nil {
+			m.onContext(paneContext)
+		}
+
+	case "/paste-image":
+		img, err := clipboard.ReadImage()
+		if err != nil || m.onAttach == nil {
+			if err == nil {
+				err = fmt.Errorf("attachments are not supported here")
+			}
+			m.messages = append(m.messages, ChatMessage{
+				Role:      "system",
+				Content:   i18n.T("msg.no_image", err),
+				Timestamp: time.Now(),
+			})
+			break
+		}
+		m.onAttach(types.Attachment{Type: "image", MimeType: "image/png", Name: "clipboard", Data: img})
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "system",
+			Content:   i18n.T("msg.image_attached", (len(img)+1023)/1024),
+			Timestamp: time.Now(),
+		})
+
+	case "/thinking":
+		m.showReasoning = !m.showReasoning
+		state := i18n.T("msg.reasoning_collapsed")
+		if m.showReasoning {
+			state = i18n.T("msg.reasoning_expanded")
+		}
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "system",
+			Content:   state,
+			Timestamp: time.Now(),
+		})
+
+	case "/activity":
+		return m.toggleActivity(), nil
+
+	case "/ids":
+		m.showIDs = !m.showIDs
+		state := i18n.T("msg.ids_hidden")
+		if m.showIDs {
+			state = i18n.T("msg.ids_shown")
+		}
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "system",
+			Content:   state,
+			Timestamp: time.Now(),
+		})
+
+	case "/speak":
+		arg := ""
+		if len(parts) > 1 {
+			arg = strings.ToLower(parts[1])
+		}
+		m.messages = append(m.messages, ChatMessage{Role: "system", Content: m.speak(arg), Timestamp: time.Now()})
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+		return m, nil
+
+	case "/voice":
+		m.input.Reset()
+		m = m.startVoice()
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+		return m, nil
+
+	case "/expand":
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		if out := m.expand(arg); out != "" {
+			m.messages = append(m.messages, ChatMessage{
+				Role:      "system",
+				Content:   out,
+				Timestamp: time.Now(),
+			})
+		} else {
+			m.input.Reset()
+			m.viewport.SetContent(m.renderMessages())
+			return m, nil
+		}
+
+	case "/find":
+		if query := strings.TrimSpace(strings.TrimPrefix(input, parts[0])); query != "" {
+			m.input.Reset()
+			return m.startFind(query), nil
+		}
+		m.input.Reset()
+		return m.startFind(""), nil
+
+	case "/less":
+		if text := m.pagerText(len(parts) > 1 && parts[1] == "all"); text != "" {
+			m.input.Reset()
+			return m, tea.ExecProcess(pager.Command(text), func(err error) tea.Msg {
+				if err != nil {
+					return errorMsg(err)
+				}
+				return nil
+			})
+		}
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "system",
+			Content:   i18n.T("msg.nothing_to_page"),
 			Timestamp: time.Now(),
 		})
 
 	case "/history":
 		m.messages = append(m.messages, ChatMessage{
 			Role:      "system",
-			Content:   fmt.Sprintf("Conversation has %d messages", len(m.messages)),
+			Content:   i18n.T("msg.history_count", len(m.messages)),
+			Timestamp: time.Now(),
+		})
+
+	case "/execute":
+		if m.onExecute != nil {
+			return m.execute()
+		}
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "system",
+			Content:   i18n.T("msg.unknown_command", cmd),
+			Timestamp: time.Now(),
+		})
+
+	case "/retry", "/edit-last":
+		if m.onRewind != nil {
+			return m.rewind(cmd, parts[1:], strings.TrimSpace(input[len(parts[0]):]))
+		}
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "system",
+			Content:   i18n.T("msg.unknown_command", cmd),
 			Timestamp: time.Now(),
 		})
 
 	default:
+		if m.onCommand != nil {
+			if out, ok := m.onCommand(cmd, parts[1:]); ok {
+				m.messages = append(m.messages, ChatMessage{
+					Role:      "system",
+					Content:   out,
+					Timestamp: time.Now(),
+				})
+				break
+			}
+		}
 		m.messages = append(m.messages, ChatMessage{
 			Role:      "system",
-			Content:   fmt.Sprintf("Unknown command: %s (type /help for available commands)", cmd),
+			Content:   i18n.T("msg.unknown_command", cmd),
 			Timestamp: time.Now(),
 		})
 	}
@@ -454,6 +1069,83 @@ func (m Model) handleCommand(input string) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// switchModel asks the host to move the conversation to another model,
+// in the background as it checks the model exists
+func (m Model) switchModel(spec string) tea.Cmd {
+	onModel := m.onModel
+	return func() tea.Msg {
+		return modelSwitchedMsg{Spec: spec, Err: onModel(spec)}
+	}
+}
+
+// rewind takes back the last exchange: /retry sends the message again,
+// with the model or temperature in args, and /edit-last sends text
+// instead, or puts the message back in the input to edit when text is
+// empty
+func (m Model) rewind(cmd string, args []string, text string) (tea.Model, tea.Cmd) {
+	var retry []string
+	if cmd == "/retry" {
+		retry = args
+	}
+	prompt, err := m.onRewind(retry)
+	m.input.Reset()
+	if err != nil {
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "system",
+			Content:   i18n.T("msg.error", err),
+			Timestamp: time.Now(),
+		})
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+		return m, nil
+	}
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Role == "user" {
+			m.messages = m.messages[:i]
+			break
+		}
+	}
+	switch {
+	case cmd == "/retry" && len(args) > 0:
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "system",
+			Content:   i18n.T("msg.retrying", strings.Join(args, " ")),
+			Timestamp: time.Now(),
+		})
+	case cmd == "/edit-last" && text == "":
+		m.input.SetValue(prompt)
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "system",
+			Content:   i18n.T("msg.edit_last"),
+			Timestamp: time.Now(),
+		})
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+		return m, nil
+	case cmd == "/edit-last":
+		prompt = text
+	}
+	return m.send(ChatMessage{Role: "user", Content: prompt, Timestamp: time.Now()}, prompt)
+}
+
+// execute sends the plan made in plan mode to be carried out
+func (m Model) execute() (tea.Model, tea.Cmd) {
+	prompt, err := m.onExecute()
+	m.input.Reset()
+	if err != nil {
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "system",
+			Content:   i18n.T("msg.error", err),
+			Timestamp: time.Now(),
+		})
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+		return m, nil
+	}
+	return m.send(ChatMessage{Role: "user", Content: i18n.T("msg.executing_plan"), Timestamp: time.Now()}, prompt)
+}
+
 // updateLastAssistantMessage updates the last assistant message
 func (m *Model) updateLastAssistantMessage(content string) {
 	for i := len(m.messages) - 1; i >= 0; i-- {
@@ -476,23 +1168,42 @@ var bashOutputStyle = lipgloss.NewStyle().
 func (m Model) renderMessages() string {
 	var sb strings.Builder
 
-	for _, msg := range m.messages {
+	// Side panes are too narrow for the viewport's truncation; wrap instead
+	wrap := func(s string) string { return s }
+	if m.compact && m.viewport.Width > 0 {
+		wrapStyle := lipgloss.NewStyle().Width(m.viewport.Width)
+		wrap = func(s string) string { return wrapStyle.Render(s) }
+	}
+
+	blocks := 0
+	for i, msg := range m.messages {
+		if collapsible(msg) {
+			blocks++
+		}
 		switch msg.Role {
 		case "user":
-			sb.WriteString(userStyle.Render("You") + " ")
-			sb.WriteString(mutedStyle.Render(msg.Timestamp.Format("15:04")))
+			sb.WriteString(userStyle.Render(i18n.T("ui.you")) + " ")
+			sb.WriteString(mutedStyle.Render(m.messageID(msg) + msg.Timestamp.Format("15:04")))
+			sb.WriteString("\n")
+			sb.WriteString(wrap(msg.Content))
+			sb.WriteString("\n")
+			for _, chip := range msg.Chips {
+				sb.WriteString(mutedStyle.Render(chip) + "\n")
+			}
 			sb.WriteString("\n")
-			sb.WriteString(msg.Content)
-			sb.WriteString("\n\n")
 
 		case "assistant":
-			sb.WriteString(assistantStyle.Render("Agent") + " ")
-			sb.WriteString(mutedStyle.Render(msg.Timestamp.Format("15:04")))
-			if m.streaming && msg == m.messages[len(m.messages)-1] {
+			sb.WriteString(assistantStyle.Render(i18n.T("ui.agent")) + " ")
+			sb.WriteString(mutedStyle.Render(m.messageID(msg) + msg.Timestamp.Format("15:04")))
+			if m.streaming && i == len(m.messages)-1 {
 				sb.WriteString(" " + m.spinner.View())
 			}
 			sb.WriteString("\n")
-			sb.WriteString(msg.Content)
+			if msg.Reasoning != "" {
+				sb.WriteString(m.renderReasoning(msg.Reasoning, wrap))
+				sb.WriteString("\n")
+			}
+			sb.WriteString(wrap(msg.Content))
 			sb.WriteString("\n\n")
 
 		case "skill":
@@ -503,105 +1214,235 @@ func (m Model) renderMessages() string {
 			sb.WriteString(bashStyle.Render("🔧 Bash") + " ")
 			sb.WriteString(mutedStyle.Render(msg.Timestamp.Format("15:04")))
 			sb.WriteString("\n")
-			sb.WriteString(bashOutputStyle.Render(msg.Content))
+			if collapsible(msg) {
+				sb.WriteString(renderBlock(msg, blocks, func(s string) string { return bashOutputStyle.Render(s) }))
+			} else {
+				sb.WriteString(bashOutputStyle.Render(msg.Content))
+			}
 			sb.WriteString("\n")
 
 		case "context":
-			// Context messages are hidden from display but included in conversation
-			continue
+			// Context added outside the chat, as a summary to expand
+			sb.WriteString(renderBlock(msg, blocks, func(s string) string { return mutedStyle.Render(wrap(s)) }))
+			sb.WriteString("\n")
 
 		case "system":
-			sb.WriteString(helpStyle.Render(msg.Content))
+			sb.WriteString(helpStyle.Render(wrap(msg.Content)))
 			sb.WriteString("\n\n")
 		}
 	}
 
-	return sb.String()
+	return m.highlightMatches(sb.String())
+}
+
+// pagerText renders the last answer, or with all the transcript, for
+// /less; "" when there is none
+func (m Model) pagerText(all bool) string {
+	m.streaming = false
+	m.search = nil
+	if !all {
+		var last []ChatMessage
+		for i := len(m.messages) - 1; i >= 0; i-- {
+			if m.messages[i].Role == "assistant" && m.messages[i].Content != "" {
+				last = m.messages[i : i+1]
+				break
+			}
+		}
+		m.messages = last
+	}
+	return m.renderMessages()
+}
+
+// messageID returns "#n " for a numbered message when /ids is on
+func (m Model) messageID(msg ChatMessage) string {
+	if !m.showIDs || msg.ID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("#%d ", msg.ID)
+}
+
+// renderReasoning renders a reasoning block, collapsed to one line unless
+// expanded with /thinking
+func (m Model) renderReasoning(reasoning string, wrap func(string) string) string {
+	reasoning = strings.TrimSpace(reasoning)
+	if m.showReasoning {
+		return mutedStyle.Italic(true).Render(wrap("💭 " + reasoning))
+	}
+	lines := strings.Count(reasoning, "\n") + 1
+	return mutedStyle.Italic(true).Render(fmt.Sprintf("💭 Thought for %d lines (/thinking to expand)", lines))
 }
 
 // renderHelp renders help text
 func (m Model) renderHelp() string {
-	return `
-╭───────────────────────────────────────────────────────────────╮
-│                      Available Commands                        │
-├───────────────────────────────────────────────────────────────┤
-│  /help, /h, /?     Show this help message                     │
-│  /quit, /exit, /q  Exit the session                           │
-│  /clear, /c        Clear conversation history                 │
-│  /model [name]     Show or change current model               │
-│  /provider [name]  Show or change provider                    │
-│  /status           Show session statistics                    │
-│  /skills           List available skills                      │
-│  /compact          Compact conversation history               │
-│  /history          Show conversation stats                    │
-├───────────────────────────────────────────────────────────────┤
-│                        Keyboard Shortcuts                      │
-├───────────────────────────────────────────────────────────────┤
-│  Enter             Send message                               │
-│  Ctrl+L            Clear screen                               │
-│  Ctrl+C / Esc      Cancel / Exit                              │
-│  PgUp/PgDown       Scroll history                             │
-│  ↑/↓               Navigate command history                   │
-│  Ctrl+R            Reverse search history                     │
-│  Tab               Autocomplete commands/files                │
-│  Alt+Enter         Insert newline (multiline input)           │
-│  \ + Enter         Continue on next line                      │
-├───────────────────────────────────────────────────────────────┤
-│                          Bash Mode                             │
-├───────────────────────────────────────────────────────────────┤
-│  !command          Execute bash command directly              │
-│                    e.g., !git status, !ls -la                 │
-│                    Output is added to conversation context    │
-├───────────────────────────────────────────────────────────────┤
-│                         Autocomplete                           │
-├───────────────────────────────────────────────────────────────┤
-│  /...              Complete slash commands                    │
-│  @...              Complete file paths                        │
-╰───────────────────────────────────────────────────────────────╯`
+	sections := []struct {
+		title string
+		rows  [][2]string
+	}{
+		{i18n.T("help.commands"), [][2]string{
+			{"/help, /h, /?", i18n.T("help.help")},
+			{"/quit, /exit, /q", i18n.T("help.quit")},
+			{"/clear, /c", i18n.T("help.clear")},
+			{"/model [name]", i18n.T("help.model")},
+			{"/provider [name]", i18n.T("help.provider")},
+			{"/status", i18n.T("help.status")},
+			{"/skills", i18n.T("help.skills")},
+			{"/compact", i18n.T("help.compact")},
+			{"/history", i18n.T("help.history")},
+			{"/less [all]", i18n.T("help.less")},
+			{"/find [text]", i18n.T("help.find")},
+			{"/expand [n|all]", i18n.T("help.expand")},
+			{"/voice", i18n.T("help.voice")},
+			{"/speak [on|off]", i18n.T("help.speak")},
+			{"/pane [target]", i18n.T("help.pane")},
+			{"/paste-image", i18n.T("help.paste_image")},
+			{"/thinking", i18n.T("help.thinking")},
+			{"/template [name]", i18n.T("help.template")},
+			{"/examples on|off", i18n.T("help.examples")},
+			{"/pin [file|n]", i18n.T("help.pin")},
+			{"/unpin <file|n>", i18n.T("help.unpin")},
+			{"/refresh [file]", i18n.T("help.refresh")},
+			{"/map", i18n.T("help.map")},
+			{"/swap", i18n.T("help.swap")},
+			{"/retry [model] [temp]", i18n.T("help.retry")},
+			{"/edit-last [text]", i18n.T("help.edit_last")},
+			{"/system show|set|append", i18n.T("help.system")},
+			{"/set <param> <value>", i18n.T("help.set")},
+			{"/settings", i18n.T("help.settings")},
+			{"/style [name]", i18n.T("help.style")},
+			{"/concise, /verbose", i18n.T("help.concise")},
+			{"/plan [on|off]", i18n.T("help.plan")},
+			{"/execute", i18n.T("help.execute")},
+			{"/tab [new|close|n]", i18n.T("help.tab")},
+			{"/ids", i18n.T("help.ids")},
+			{"/activity", i18n.T("help.activity")},
+			{"/show [n]", i18n.T("help.show")},
+			{"/copy-msg [n]", i18n.T("help.copy_msg")},
+			{"/artifacts", i18n.T("help.artifacts")},
+			{"/who", i18n.T("help.who")},
+			{"/context save|load", i18n.T("help.context")},
+			{"/preview [message]", i18n.T("help.preview")},
+			{"/tokens [text]", i18n.T("help.tokens")},
+			{"/skill use|off|auto", i18n.T("help.skill")},
+			{"/good|/bad [note]", i18n.T("help.feedback")},
+		}},
+		{i18n.T("help.shortcuts"), [][2]string{
+			{"Enter", i18n.T("help.key_send")},
+			{"Ctrl+L", i18n.T("help.key_clear")},
+			{"Ctrl+C / Esc", i18n.T("help.key_cancel")},
+			{"PgUp/PgDown", i18n.T("help.key_scroll")},
+			{"↑/↓", i18n.T("help.key_history")},
+			{"Ctrl+PgDn/PgUp", i18n.T("help.key_tabs")},
+			{"Ctrl+O", i18n.T("help.key_compose")},
+			{"Ctrl+R", i18n.T("help.key_search")},
+			{"Ctrl+F", i18n.T("help.key_find")},
+			{"Tab", i18n.T("help.key_complete")},
+			{"Alt+Enter", i18n.T("help.key_newline")},
+			{"\\ + Enter", i18n.T("help.key_continue")},
+		}},
+		{i18n.T("help.bash"), [][2]string{
+			{"!command", i18n.T("help.bash_run")},
+			{"", i18n.T("help.bash_example")},
+			{"", i18n.T("help.bash_context")},
+		}},
+		{i18n.T("help.autocomplete"), [][2]string{
+			{"/...", i18n.T("help.complete_commands")},
+			{"@...", i18n.T("help.complete_files")},
+		}},
+	}
+
+	// Size the box to the longest line so translations stay aligned
+	width := 0
+	for _, sec := range sections {
+		width = max(width, lipgloss.Width(sec.title))
+		for _, row := range sec.rows {
+			width = max(width, 19+lipgloss.Width(row[1]))
+		}
+	}
+	width += 2
+
+	line := func(left, mid, right string) string {
+		return left + strings.Repeat(mid, width) + right + "\n"
+	}
+	pad := func(s string) string {
+		return s + strings.Repeat(" ", width-lipgloss.Width(s))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n" + line("╭", "─", "╮"))
+	for i, sec := range sections {
+		if i > 0 {
+			sb.WriteString(line("├", "─", "┤"))
+		}
+		left := (width - lipgloss.Width(sec.title)) / 2
+		sb.WriteString("│" + pad(strings.Repeat(" ", left)+sec.title) + "│\n")
+		sb.WriteString(line("├", "─", "┤"))
+		for _, row := range sec.rows {
+			sb.WriteString("│" + pad(fmt.Sprintf("  %-17s %s", row[0], row[1])) + "│\n")
+		}
+	}
+	sb.WriteString(line("╰", "─", "╯"))
+	return strings.TrimSuffix(sb.String(), "\n")
 }
 
 // renderStatus renders session status
 func (m Model) renderStatus() string {
 	duration := time.Since(m.sessionStart).Round(time.Second)
-	return fmt.Sprintf(`
-Session Status
-──────────────
-Provider: %s
-Model: %s
-Duration: %s
-Requests: %d
-Tokens: ~%d
-Last Skill: %s
-Messages: %d`,
-		m.provider,
-		m.model,
-		duration,
-		m.requestCount,
-		m.totalTokens,
-		m.lastSkill,
-		len(m.messages),
+	title := i18n.T("status.title")
+	return fmt.Sprintf("\n%s\n%s\n%s: %s\n%s: %s\n%s: %s\n%s: %d\n%s: ~%d\n%s: %s\n%s: %d",
+		title, strings.Repeat("─", lipgloss.Width(title)),
+		i18n.T("status.provider"), m.provider,
+		i18n.T("status.model"), m.model,
+		i18n.T("status.duration"), duration,
+		i18n.T("status.requests"), m.requestCount,
+		i18n.T("status.tokens"), m.totalTokens,
+		i18n.T("status.last_skill"), m.lastSkill,
+		i18n.T("status.messages"), len(m.messages),
 	)
 }
 
 // View renders the UI
 func (m Model) View() string {
 	if !m.ready {
-		return "\n  Initializing..."
+		return "\n  " + i18n.T("ui.initializing")
+	}
+
+	if m.compact {
+		if m.composing {
+			return m.renderCompose(titleStyle.Copy().MarginBottom(0).Render("🚀 " + m.model))
+		}
+		return fmt.Sprintf("%s\n%s\n%s\n%s",
+			titleStyle.Copy().MarginBottom(0).Render("🚀 "+m.model),
+			m.viewport.View(),
+			borderStyle.Render(m.input.View()),
+			m.renderCompactStatusBar())
 	}
 
 	// Header with mode indicator
 	header := titleStyle.Render("🚀 AgentFlow") + "  "
 	switch m.input.Mode() {
 	case input.ModeReverseSearch:
-		header += helpStyle.Render("Ctrl+R: search • Tab: accept • Esc: cancel")
+		header += helpStyle.Render(i18n.T("header.search"))
 	case input.ModeAutocomplete:
-		header += helpStyle.Render("Tab/↓: next • Enter: accept • Esc: cancel")
+		header += helpStyle.Render(i18n.T("header.autocomplete"))
 	default:
-		header += helpStyle.Render("Enter: send • /help • !cmd: bash • Ctrl+R: search")
+		if m.composing {
+			header += helpStyle.Render(i18n.T("header.compose"))
+		} else if rec := m.voiceHeader(); rec != "" {
+			header += helpStyle.Render(rec)
+		} else if find := m.findHeader(); find != "" {
+			header += helpStyle.Render(find)
+		} else if vim := m.vimHeader(); vim != "" {
+			header += helpStyle.Render(vim)
+		} else {
+			header += helpStyle.Render(i18n.T("header.default"))
+		}
+	}
+	if m.composing {
+		return m.renderCompose(header)
 	}
 
-	// Main content
-	content := m.viewport.View()
+	// Main content, with the activity sidebar
+	content := m.withActivity(m.viewport.View())
 
 	// Input area
 	inputBox := borderStyle.Render(m.input.View())
@@ -615,20 +1456,35 @@ func (m Model) View() string {
 
 // renderStatusBar renders the bottom status bar
 func (m Model) renderStatusBar() string {
+	if custom := m.customStatus(); custom != "" {
+		return statusBarStyle.Width(m.width).Render(statusTextStyle.Render(custom))
+	}
+
 	// Left side: provider/model
 	left := statusItemStyle.Render(fmt.Sprintf(" %s/%s ", m.provider, m.model))
 
 	// Center: streaming indicator or skill
 	var center string
 	if m.streaming {
-		center = statusTextStyle.Render(m.spinner.View() + " Generating...")
+		center = statusTextStyle.Render(m.spinner.View() + " " + i18n.T("ui.generating") + " " + m.streamTimer())
 	} else if m.lastSkill != "" {
 		center = statusTextStyle.Render("⚡ " + m.lastSkill)
 	}
 
 	// Right side: stats
 	duration := time.Since(m.sessionStart).Round(time.Second)
-	right := statusTextStyle.Render(fmt.Sprintf("↑%d msgs • %s", len(m.messages), duration))
+	stats := fmt.Sprintf("↑%s • %s", i18n.T("ui.msgs", len(m.messages)), duration)
+	if m.lastStats != nil && !m.streaming {
+		stats = fmt.Sprintf("⏱ %s TTFT • %.1f tok/s • %s • %s",
+			m.lastStats.TTFT().Round(10*time.Millisecond),
+			m.lastStats.TokensPerSecond(),
+			m.lastStats.Duration().Round(100*time.Millisecond),
+			stats)
+	}
+	if m.newVersion != "" && !m.streaming {
+		stats = i18n.T("ui.update_available", m.newVersion) + " • " + stats
+	}
+	right := statusTextStyle.Render(stats)
 
 	// Calculate padding
 	totalWidth := m.width
@@ -643,6 +1499,171 @@ func (m Model) renderStatusBar() string {
 	return statusBarStyle.Width(m.width).Render(left + spacer + center + spacer + right)
 }
 
+// renderCompactStatusBar renders a one-item status bar for narrow panes
+func (m Model) renderCompactStatusBar() string {
+	if rec := m.voiceHeader(); rec != "" {
+		return statusBarStyle.Width(m.width).Render(statusTextStyle.Render(rec))
+	}
+	if m.search != nil {
+		return statusBarStyle.Width(m.width).Render(statusTextStyle.Render(m.findHeader()))
+	}
+	if custom := m.customStatus(); custom != "" {
+		return statusBarStyle.Width(m.width).Render(statusTextStyle.Render(custom))
+	}
+	status := i18n.T("ui.msgs", len(m.messages))
+	if m.streaming {
+		status = m.spinner.View() + " " + m.streamTimer() + " • " + status
+	} else if m.lastSkill != "" {
+		status = "⚡" + m.lastSkill + " • " + status
+	}
+	if m.lastStats != nil && !m.streaming {
+		status += fmt.Sprintf(" • %.1f tok/s", m.lastStats.TokensPerSecond())
+	}
+	return statusBarStyle.Width(m.width).Render(statusTextStyle.Render(status))
+}
+
+// customStatus returns the status line set with SetStatusLine, behind
+// the spinner while streaming, or "" for the default one
+func (m Model) customStatus() string {
+	if m.statusLine == nil {
+		return ""
+	}
+	workdir, _ := os.Getwd()
+	line := m.statusLine(statusline.Info{
+		Provider:  m.provider,
+		Model:     m.model,
+		Workdir:   workdir,
+		Messages:  len(m.messages),
+		Tokens:    m.totalTokens,
+		Skill:     m.lastSkill,
+		Streaming: m.streaming,
+	})
+	if line != "" && m.streaming {
+		line = m.spinner.View() + " " + m.streamTimer() + " • " + line
+	}
+	return line
+}
+
+// SetStatusLine replaces the status bar with what fn returns for the
+// session, such as a user's template; "" keeps the default. fn runs on
+// every redraw, so slow work belongs in the background, followed by
+// SendRedraw.
+func (m *Model) SetStatusLine(fn func(statusline.Info) string) {
+	m.statusLine = fn
+}
+
+// quietCountdown is how long a model can go quiet before the status bar
+// counts down to its read timeout
+const quietCountdown = 10 * time.Second
+
+// streamTimer shows how long the current response has taken, and the time
+// left before the read timeout once the model has gone quiet
+func (m Model) streamTimer() string {
+	timer := time.Since(m.streamStart).Round(time.Second).String()
+	quiet := time.Since(m.lastChunk)
+	if m.readTimeout > 0 && quiet >= quietCountdown {
+		timer += " (" + i18n.T("ui.timeout_in", max(m.readTimeout-quiet, 0).Round(time.Second)) + ")"
+	}
+	return timer
+}
+
+// SetReadTimeout sets the model's read timeout, counted down in the status
+// bar while it is quiet
+func (m *Model) SetReadTimeout(d time.Duration) {
+	m.readTimeout = d
+}
+
+// SetUpdateCheck sets a check for a newer release, run in the background
+// at startup; a non-empty version is shown in the footer
+func (m *Model) SetUpdateCheck(fn func() string) {
+	m.checkUpdate = fn
+}
+
+// SetVim turns on vim keybindings: modal editing in the input, and in
+// normal mode j, k, gg, G and / to move through the transcript
+func (m *Model) SetVim(on bool) {
+	m.input.SetVim(on)
+}
+
+// SetCompact switches to the narrow layout used by `agentflow pane`
+func (m *Model) SetCompact(compact bool) {
+	m.compact = compact
+}
+
+// LoadHistory shows the messages of a resumed conversation
+func (m *Model) LoadHistory(messages []ChatMessage) {
+	m.messages = append(m.messages, messages...)
+	m.viewport.SetContent(m.renderMessages())
+	m.viewport.GotoBottom()
+}
+
+// SetOnContext sets the callback for context added outside the chat,
+// such as bash output or captured tmux panes
+func (m *Model) SetOnContext(fn func(string)) {
+	m.onContext = fn
+}
+
+// SetOnShell sets the callback told of each shell command the user runs,
+// with ! or substituted into a message
+func (m *Model) SetOnShell(fn func(command string)) {
+	m.onShell = fn
+}
+
+// SetOnAttach sets the callback for attachments such as pasted images
+func (m *Model) SetOnAttach(fn func(types.Attachment)) {
+	m.onAttach = fn
+}
+
+// SetOnCommand sets a handler for slash commands the TUI doesn't know.
+// It returns the text to show and whether the command was handled.
+func (m *Model) SetOnCommand(fn func(cmd string, args []string) (string, bool)) {
+	m.onCommand = fn
+}
+
+// SetOnStatus sets a callback adding host sections (e.g. pinned items)
+// to /status
+func (m *Model) SetOnStatus(fn func() string) {
+	m.onStatus = fn
+}
+
+// SetOnCancel sets the callback stopping the response being streamed,
+// called on Esc or Ctrl+C
+func (m *Model) SetOnCancel(fn func()) {
+	m.onCancel = fn
+}
+
+// SetOnRewind sets the callback taking back the last exchange for /retry
+// and /edit-last. It receives the /retry arguments (a model, a
+// temperature) and returns the user message to send again.
+func (m *Model) SetOnRewind(fn func(retry []string) (string, error)) {
+	m.onRewind = fn
+}
+
+// SetOnModel sets the callback switching the conversation to another
+// model for /model and /provider; without it they only change the label
+func (m *Model) SetOnModel(fn func(spec string) error) {
+	m.onModel = fn
+}
+
+// SetOnMentions sets the callback told of the files a message mentions
+// with @, once their contents are added to it
+func (m *Model) SetOnMentions(fn func([]input.Mention)) {
+	m.onMention = fn
+}
+
+// SetOnFetch sets the callback fetching the URLs pasted in a message
+// before it is sent; with ask, the user confirms first and may send the
+// message without them
+func (m *Model) SetOnFetch(ask bool, fn func(urls []string) []string) {
+	m.askFetch, m.onFetch = ask, fn
+}
+
+// SetOnExecute sets the callback for /execute, which ends plan mode and
+// returns the message handing the plan over
+func (m *Model) SetOnExecute(fn func() (string, error)) {
+	m.onExecute = fn
+}
+
 // SetOnSubmit sets the callback for message submission
 func (m *Model) SetOnSubmit(fn func(string) tea.Cmd) {
 	m.onSubmit = fn
@@ -655,6 +1676,21 @@ func SendStreamChunk(chunk string) tea.Cmd {
 	}
 }
 
+// SendStreamReplace replaces the answer streamed so far, as when a
+// stronger model's answer arrives
+func SendStreamReplace(content string) tea.Cmd {
+	return func() tea.Msg {
+		return streamReplaceMsg(content)
+	}
+}
+
+// SendReasoningChunk sends a chunk of model reasoning to the TUI
+func SendReasoningChunk(chunk string) tea.Cmd {
+	return func() tea.Msg {
+		return reasoningChunkMsg(chunk)
+	}
+}
+
 // SendStreamDone signals streaming is complete
 func SendStreamDone() tea.Cmd {
 	return func() tea.Msg {
@@ -669,10 +1705,54 @@ func SendError(err error) tea.Cmd {
 	}
 }
 
-// SendSkillMatched signals a skill was matched
-func SendSkillMatched(skill string) tea.Cmd {
+// SendNotice shows a system notice, such as a file changing on disk
+func SendNotice(text string) tea.Cmd {
+	return func() tea.Msg {
+		return noticeMsg(text)
+	}
+}
+
+// SendToolCalls shows the tools the model called mid-response
+func SendToolCalls(calls []string) tea.Cmd {
+	return func() tea.Msg {
+		return toolCallsMsg(calls)
+	}
+}
+
+// SendUserMessage shows a user message and starts streaming its answer
+func SendUserMessage(content string) tea.Cmd {
+	return func() tea.Msg {
+		return userMessageMsg(content)
+	}
+}
+
+// SendRedraw redraws the screen, after something shown changed outside
+// the program
+func SendRedraw() tea.Cmd {
+	return func() tea.Msg {
+		return redrawMsg{}
+	}
+}
+
+// SendMessageIDs numbers the last user message and its answer with
+// their places in the agent's history; 0 leaves one unnumbered
+func SendMessageIDs(user, answer int) tea.Cmd {
+	return func() tea.Msg {
+		return messageIDsMsg{user, answer}
+	}
+}
+
+// SendSkillsMatched signals the skills activated for a message
+func SendSkillsMatched(acts []agent.SkillActivation) tea.Cmd {
+	return func() tea.Msg {
+		return skillMatchedMsg(acts)
+	}
+}
+
+// SendStreamStats reports the performance of a finished response
+func SendStreamStats(stats *agent.StreamStats) tea.Cmd {
 	return func() tea.Msg {
-		return skillMatchedMsg(skill)
+		return streamStatsMsg(stats)
 	}
 }
 
@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"github.com/agentflow/agentflow/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// vimKey handles the keys vim's normal mode leaves to the transcript: j
+// and k scroll a line, gg and G go to the top and bottom, / searches the
+// conversation, and n and N go to earlier and later matches. ok is false
+// for keys that are the input's.
+func (m Model) vimKey(msg tea.KeyMsg) (Model, bool) {
+	key := msg.String()
+	if m.vimG {
+		m.vimG = false
+		if key == "g" {
+			m.viewport.GotoTop()
+			return m, true
+		}
+	}
+	switch key {
+	case "j":
+		m.viewport.LineDown(1)
+	case "k":
+		m.viewport.LineUp(1)
+	case "g":
+		m.vimG = true
+	case "G":
+		m.viewport.GotoBottom()
+	case "/":
+		m.search = nil
+		return m.startFind(""), true
+	case "n":
+		m.jumpToMatch(-1)
+	case "N":
+		m.jumpToMatch(1)
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// vimHeader describes vim mode for the header, or "" outside it
+func (m Model) vimHeader() string {
+	switch {
+	case m.input.VimNormal():
+		return i18n.T("header.vim_normal")
+	case m.input.VimInsert():
+		return i18n.T("header.vim_insert")
+	}
+	return ""
+}
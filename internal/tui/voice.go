@@ -0,0 +1,152 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/i18n"
+	"github.com/agentflow/agentflow/internal/voice"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// transcribeTimeout bounds one transcription
+const transcribeTimeout = 2 * time.Minute
+
+// voiceTextMsg is what was heard in a recording
+type voiceTextMsg struct {
+	text string
+	err  error
+}
+
+// SetVoice sets how /voice records and transcribes, and how /speak
+// speaks answers; without it, sox or arecord record, a local whisper.cpp
+// server transcribes and say or espeak speak
+func (m *Model) SetVoice(cfg voice.Config) {
+	m.voice = cfg
+	if cfg.Speak.Enabled {
+		if err := m.startSpeaking(); err != nil {
+			m.messages = append(m.messages, ChatMessage{Role: "system", Content: i18n.T("msg.error", err), Timestamp: time.Now()})
+		}
+	}
+}
+
+// startSpeaking turns spoken answers on
+func (m *Model) startSpeaking() error {
+	if m.speaker == nil {
+		speaker, err := m.voice.Speaker()
+		if err != nil {
+			return err
+		}
+		m.speaker = speaker
+	}
+	m.speaking = true
+	return nil
+}
+
+// speak handles /speak, turning spoken answers on or off, or toggling
+// them without an argument
+func (m *Model) speak(arg string) string {
+	on := !m.speaking
+	switch arg {
+	case "on":
+		on = true
+	case "off":
+		on = false
+	case "":
+	default:
+		return i18n.T("msg.speak_usage")
+	}
+	if !on {
+		if m.speaker != nil {
+			m.speaker.Stop()
+		}
+		m.speaking = false
+		return i18n.T("msg.speak_off")
+	}
+	if err := m.startSpeaking(); err != nil {
+		return i18n.T("msg.error", err)
+	}
+	return i18n.T("msg.speak_on")
+}
+
+// silence stops speaking, reporting whether anything was being said
+func (m Model) silence() bool {
+	if m.speaker == nil || !m.speaker.Busy() {
+		return false
+	}
+	m.speaker.Stop()
+	return true
+}
+
+// startVoice handles /voice, recording until Enter
+func (m Model) startVoice() Model {
+	rec, err := m.voice.Start()
+	if err != nil {
+		m.messages = append(m.messages, ChatMessage{Role: "system", Content: i18n.T("msg.error", err), Timestamp: time.Now()})
+		return m
+	}
+	m.recording = rec
+	return m
+}
+
+// recordingKey handles keys while recording: Enter transcribes what was
+// said into the input, to edit before sending, and Esc throws it away
+func (m Model) recordingKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	rec := m.recording
+	switch msg.String() {
+	case "enter":
+		m.recording = nil
+		m.transcribing = true
+		cfg := m.voice
+		return m, func() tea.Msg {
+			path, err := rec.Stop()
+			if err != nil {
+				return voiceTextMsg{err: err}
+			}
+			defer rec.Remove()
+			ctx, cancel := context.WithTimeout(context.Background(), transcribeTimeout)
+			defer cancel()
+			text, err := cfg.Transcribe(ctx, path)
+			return voiceTextMsg{text: text, err: err}
+		}
+	case "esc", "ctrl+c":
+		m.recording = nil
+		return m, func() tea.Msg {
+			rec.Cancel()
+			return nil
+		}
+	}
+	return m, nil
+}
+
+// heard puts a transcription at the end of the input
+func (m Model) heard(msg voiceTextMsg) Model {
+	m.transcribing = false
+	switch {
+	case msg.err != nil:
+		m.messages = append(m.messages, ChatMessage{Role: "system", Content: i18n.T("msg.error", msg.err), Timestamp: time.Now()})
+	case msg.text == "":
+		m.messages = append(m.messages, ChatMessage{Role: "system", Content: i18n.T("msg.voice_nothing"), Timestamp: time.Now()})
+	default:
+		draft := strings.TrimRight(m.input.Value(), " ")
+		if draft != "" {
+			draft += " "
+		}
+		m.input.SetValue(draft + msg.text)
+	}
+	m.viewport.SetContent(m.renderMessages())
+	m.viewport.GotoBottom()
+	return m
+}
+
+// voiceHeader describes recording for the header, or "" when not
+func (m Model) voiceHeader() string {
+	switch {
+	case m.recording != nil:
+		return i18n.T("header.voice_recording")
+	case m.transcribing:
+		return i18n.T("header.voice_transcribing")
+	}
+	return ""
+}
@@ -0,0 +1,340 @@
+// Package update checks for newer agentflow releases and replaces the
+// running binary with one
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/github"
+)
+
+// Repo is where agentflow releases are published
+const Repo = "agentflow/agentflow"
+
+// Release assets produced by goreleaser
+const (
+	ChecksumsAsset = "checksums.txt"
+	SignatureAsset = "checksums.txt.sig"
+)
+
+// maxAssetSize caps how much of a release asset is downloaded
+const maxAssetSize = 200 << 20
+
+// CheckInterval is how often the TUI looks for a newer release
+const CheckInterval = 24 * time.Hour
+
+// Release is a newer agentflow release
+type Release struct {
+	Version string // Without the leading "v"
+	URL     string // Release notes
+	release *github.Release
+}
+
+// Latest returns the newest release when it is newer than current, or
+// nil when current is up to date. Development builds are never out of
+// date.
+func Latest(ctx context.Context, client *github.Client, current string) (*Release, error) {
+	release, err := client.LatestRelease(ctx, Repo)
+	if err != nil {
+		return nil, err
+	}
+	latest := strings.TrimPrefix(release.TagName, "v")
+	if !Newer(latest, current) {
+		return nil, nil
+	}
+	return &Release{Version: latest, URL: release.HTMLURL, release: release}, nil
+}
+
+// Newer reports whether version a is newer than b. Versions are dotted
+// numbers with an optional "v" prefix and "-pre" suffix; b values that
+// are not versions, like "dev", are never older.
+func Newer(a, b string) bool {
+	pa, prea, ok := parseVersion(a)
+	if !ok {
+		return false
+	}
+	pb, preb, ok := parseVersion(b)
+	if !ok {
+		return false
+	}
+	for i := range max(len(pa), len(pb)) {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	// A release is newer than its own prereleases
+	switch {
+	case prea == "":
+		return preb != ""
+	case preb == "":
+		return false
+	default:
+		return prea > preb
+	}
+}
+
+// parseVersion splits "v1.2.3-rc1" into [1 2 3] and "rc1"
+func parseVersion(v string) ([]int, string, bool) {
+	v = strings.TrimPrefix(v, "v")
+	v, pre, _ := strings.Cut(v, "-")
+	if v == "" {
+		return nil, "", false
+	}
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, "", false
+		}
+		parts = append(parts, n)
+	}
+	return parts, pre, true
+}
+
+// AssetName returns the archive goreleaser builds for a platform
+func AssetName(version, goos, goarch string) string {
+	ext := "tar.gz"
+	if goos == "windows" {
+		ext = "zip"
+	}
+	return fmt.Sprintf("agentflow_%s_%s_%s.%s", version, goos, goarch, ext)
+}
+
+// Options control how an update is verified
+type Options struct {
+	// PublicKey is a base64 ed25519 key checksums.txt must be signed
+	// with; signatures are not checked when it is empty
+	PublicKey string
+}
+
+// Download fetches the release's archive for this platform, verifies it
+// against the release checksums (and their signature when a key is
+// configured) and returns the agentflow binary inside
+func Download(ctx context.Context, client *github.Client, r *Release, opts Options) ([]byte, error) {
+	name := AssetName(r.Version, runtime.GOOS, runtime.GOARCH)
+	asset, ok := r.release.Asset(name)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no build for %s/%s", r.Version, runtime.GOOS, runtime.GOARCH)
+	}
+	sumsAsset, ok := r.release.Asset(ChecksumsAsset)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s", r.Version, ChecksumsAsset)
+	}
+
+	sums, err := client.Download(ctx, sumsAsset, 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	if opts.PublicKey != "" {
+		sigAsset, ok := r.release.Asset(SignatureAsset)
+		if !ok {
+			return nil, fmt.Errorf("release %s is not signed", r.Version)
+		}
+		sig, err := client.Download(ctx, sigAsset, 4096)
+		if err != nil {
+			return nil, err
+		}
+		if err := VerifySignature(sums, sig, opts.PublicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	archive, err := client.Download(ctx, asset, maxAssetSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyChecksum(archive, name, sums); err != nil {
+		return nil, err
+	}
+	return Extract(archive, name)
+}
+
+// VerifyChecksum checks data against its line in a sha256sum-style
+// checksums file
+func VerifyChecksum(data []byte, name string, sums []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != strings.ToLower(fields[0]) {
+			return fmt.Errorf("checksum mismatch for %s", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("no checksum for %s", name)
+}
+
+// VerifySignature checks an ed25519 signature, raw or base64, of the
+// checksums file
+func VerifySignature(sums, sig []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("invalid update public key")
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err == nil {
+		sig = decoded
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), sums, sig) {
+		return fmt.Errorf("bad signature on %s", ChecksumsAsset)
+	}
+	return nil
+}
+
+// Extract returns the agentflow binary from a release archive
+func Extract(archive []byte, name string) ([]byte, error) {
+	binary := "agentflow"
+	if strings.HasSuffix(name, ".zip") {
+		binary += ".exe"
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", name, err)
+		}
+		for _, f := range zr.File {
+			if filepath.Base(f.Name) != binary {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("open %s: %w", f.Name, err)
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxAssetSize))
+		}
+		return nil, fmt.Errorf("%s not found in %s", binary, name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not found in %s", binary, name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		if hdr.Typeflag == tar.TypeReg && filepath.Base(hdr.Name) == binary {
+			return io.ReadAll(io.LimitReader(tr, maxAssetSize))
+		}
+	}
+}
+
+// Replace swaps the binary at path for a new one. The new binary is
+// written next to it and renamed over it, so a failure leaves the old one
+// in place.
+func Replace(path string, binary []byte) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".agentflow-update-*")
+	if err != nil {
+		return fmt.Errorf("write update: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write update: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write update: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("write update: %w", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		// A running executable can't be overwritten, only renamed away
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("replace %s: %w", path, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// checkState is the cached result of the last background check
+type checkState struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+}
+
+// DefaultStatePath returns where background check results are cached
+func DefaultStatePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".agentflow", "update-check.json")
+	}
+	return filepath.Join(home, ".agentflow", "update-check.json")
+}
+
+// Check returns the newest version when it is newer than current, or ""
+// when up to date. It asks GitHub at most once per CheckInterval, caching
+// the answer at statePath, and fails quietly: it is only a notice.
+func Check(ctx context.Context, client *github.Client, current, statePath string) string {
+	if _, _, ok := parseVersion(current); !ok {
+		return ""
+	}
+
+	var state checkState
+	if data, err := os.ReadFile(statePath); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	if time.Since(state.CheckedAt) >= CheckInterval {
+		release, err := client.LatestRelease(ctx, Repo)
+		if err != nil {
+			return ""
+		}
+		state = checkState{CheckedAt: time.Now(), Latest: strings.TrimPrefix(release.TagName, "v")}
+		if data, err := json.Marshal(state); err == nil {
+			os.MkdirAll(filepath.Dir(statePath), 0755)
+			os.WriteFile(statePath, data, 0644)
+		}
+	}
+
+	if Newer(state.Latest, current) {
+		return state.Latest
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+package update
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentflow/agentflow/internal/github"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1.2.0", "1.1.9", true},
+		{"v1.10.0", "1.9.0", true},
+		{"1.2.0", "1.2.0", false},
+		{"1.2.0", "1.3.0", false},
+		{"1.2", "1.2.0", false},
+		{"1.2.0", "1.2.0-rc1", true},
+		{"1.2.0-rc1", "1.2.0", false},
+		{"1.2.0", "dev", false},
+		{"", "1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.a, tt.b); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAssetName(t *testing.T) {
+	if got := AssetName("1.2.0", "linux", "amd64"); got != "agentflow_1.2.0_linux_amd64.tar.gz" {
+		t.Errorf("got %q", got)
+	}
+	if got := AssetName("1.2.0", "windows", "arm64"); got != "agentflow_1.2.0_windows_arm64.zip" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("archive")
+	sum := sha256.Sum256(data)
+	sums := []byte(fmt.Sprintf("%s  other.tar.gz\n%s  agentflow.tar.gz\n", hex.EncodeToString(make([]byte, 32)), hex.EncodeToString(sum[:])))
+
+	if err := VerifyChecksum(data, "agentflow.tar.gz", sums); err != nil {
+		t.Errorf("valid checksum: %v", err)
+	}
+	if err := VerifyChecksum([]byte("tampered"), "agentflow.tar.gz", sums); err == nil {
+		t.Error("expected mismatch error")
+	}
+	if err := VerifyChecksum(data, "missing.tar.gz", sums); err == nil {
+		t.Error("expected missing checksum error")
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := base64.StdEncoding.EncodeToString(pub)
+	sums := []byte("abc  agentflow.tar.gz\n")
+	sig := ed25519.Sign(priv, sums)
+
+	if err := VerifySignature(sums, sig, key); err != nil {
+		t.Errorf("raw signature: %v", err)
+	}
+	if err := VerifySignature(sums, []byte(base64.StdEncoding.EncodeToString(sig)), key); err != nil {
+		t.Errorf("base64 signature: %v", err)
+	}
+	if err := VerifySignature([]byte("tampered"), sig, key); err == nil {
+		t.Error("expected bad signature error")
+	}
+	if err := VerifySignature(sums, sig, "not a key"); err == nil {
+		t.Error("expected invalid key error")
+	}
+}
+
+func tarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestExtract(t *testing.T) {
+	archive := tarball(t, map[string]string{"README.md": "docs", "agentflow": "binary"})
+	got, err := Extract(archive, "agentflow_1.0.0_linux_amd64.tar.gz")
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if string(got) != "binary" {
+		t.Errorf("got %q", got)
+	}
+
+	if _, err := Extract(tarball(t, map[string]string{"README.md": "docs"}), "x.tar.gz"); err == nil {
+		t.Error("expected error for archive without binary")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agentflow")
+	if err := os.WriteFile(path, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(path, []byte("new")); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("got %q", data)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("mode = %v, want 0755", info.Mode().Perm())
+	}
+}
+
+func TestCheck_Cached(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"tag_name": "v1.3.0"}`)
+	}))
+	defer srv.Close()
+	client := github.NewClient(github.Config{APIURL: srv.URL})
+	state := filepath.Join(t.TempDir(), "update-check.json")
+
+	if got := Check(context.Background(), client, "1.2.0", state); got != "1.3.0" {
+		t.Errorf("Check = %q, want 1.3.0", got)
+	}
+	if got := Check(context.Background(), client, "1.3.0", state); got != "" {
+		t.Errorf("up to date: Check = %q", got)
+	}
+	if calls != 1 {
+		t.Errorf("GitHub called %d times, want 1", calls)
+	}
+	if got := Check(context.Background(), client, "dev", state); got != "" {
+		t.Errorf("dev build: Check = %q", got)
+	}
+}
@@ -0,0 +1,297 @@
+package voice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// OpenAISpeechURL is OpenAI's text-to-speech endpoint
+const OpenAISpeechURL = "https://api.openai.com/v1/audio/speech"
+
+// ErrNoSpeaker is returned when no text-to-speech tool is installed
+var ErrNoSpeaker = errors.New("no text-to-speech tool found (install espeak-ng, or set voice.speak.command)")
+
+// SpeakConfig says how answers are spoken aloud
+type SpeakConfig struct {
+	Enabled  bool   `yaml:"enabled,omitempty"`  // Speak answers from the start; /speak toggles it
+	Backend  string `yaml:"backend,omitempty"`  // say, espeak or openai; say on macOS and espeak elsewhere when empty
+	Command  string `yaml:"command,omitempty"`  // Speaks {text}, or the text on stdin without {text}; over backend
+	Endpoint string `yaml:"endpoint,omitempty"` // For openai, OpenAI's when empty
+	Model    string `yaml:"model,omitempty"`    // For openai, tts-1 when empty
+	Voice    string `yaml:"voice,omitempty"`    // For openai, alloy when empty
+	Player   string `yaml:"player,omitempty"`   // For openai, plays {file}; detected when empty
+}
+
+// Speaker speaks streamed text aloud a sentence at a time, as sentences
+// are completed, skipping code blocks
+type Speaker struct {
+	say   func(ctx context.Context, text string) error
+	queue chan sentence
+
+	mu      sync.Mutex
+	pending string // Text after the last complete sentence
+	inCode  bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	busy    int // Sentences queued or being spoken
+}
+
+// Speaker returns a speaker for the configured backend
+func (c Config) Speaker() (*Speaker, error) {
+	s := c.Speak
+	if s.Command != "" {
+		return newSpeaker(commandSay(strings.Fields(s.Command))), nil
+	}
+	backend := s.Backend
+	if backend == "" && runtime.GOOS == "darwin" {
+		backend = "say"
+	}
+	switch backend {
+	case "say":
+		return newSpeaker(commandSay([]string{"say", "{text}"})), nil
+	case "", "espeak":
+		for _, name := range []string{"espeak-ng", "espeak"} {
+			if _, err := exec.LookPath(name); err == nil {
+				return newSpeaker(commandSay([]string{name, "{text}"})), nil
+			}
+		}
+		return nil, ErrNoSpeaker
+	case "openai":
+		if c.APIKey == "" && s.Endpoint == "" {
+			return nil, errors.New("voice.speak: the openai backend needs voice.api_key")
+		}
+		player, err := s.player()
+		if err != nil {
+			return nil, err
+		}
+		return newSpeaker(c.openAISay(player)), nil
+	}
+	return nil, fmt.Errorf("voice.speak: unknown backend %q (say, espeak or openai)", backend)
+}
+
+// player returns the command playing a WAV file: the configured one, or
+// the first tool installed
+func (s SpeakConfig) player() ([]string, error) {
+	if s.Player != "" {
+		return strings.Fields(s.Player), nil
+	}
+	for _, args := range [][]string{{"afplay", "{file}"}, {"paplay", "{file}"}, {"aplay", "-q", "{file}"}, {"ffplay", "-nodisp", "-autoexit", "-loglevel", "error", "{file}"}} {
+		if _, err := exec.LookPath(args[0]); err == nil {
+			return args, nil
+		}
+	}
+	return nil, errors.New("no audio player found (install alsa-utils, or set voice.speak.player)")
+}
+
+// commandSay speaks with a command, putting the text in place of {text},
+// or on stdin when no argument has it
+func commandSay(args []string) func(ctx context.Context, text string) error {
+	return func(ctx context.Context, text string) error {
+		argv := make([]string, len(args))
+		onStdin := true
+		for i, arg := range args {
+			argv[i] = strings.ReplaceAll(arg, "{text}", text)
+			onStdin = onStdin && argv[i] == arg
+		}
+		cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+		if onStdin {
+			cmd.Stdin = strings.NewReader(text)
+		}
+		return cmd.Run()
+	}
+}
+
+// openAISay speaks with OpenAI's speech API, playing the audio with player
+func (c Config) openAISay(player []string) func(ctx context.Context, text string) error {
+	s := c.Speak
+	endpoint, model, voice := s.Endpoint, s.Model, s.Voice
+	if endpoint == "" {
+		endpoint = OpenAISpeechURL
+	}
+	if model == "" {
+		model = "tts-1"
+	}
+	if voice == "" {
+		voice = "alloy"
+	}
+	return func(ctx context.Context, text string) error {
+		body, _ := json.Marshal(map[string]string{"model": model, "voice": voice, "input": text, "response_format": "wav"})
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if c.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+c.APIKey)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("speak: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			data, _ := io.ReadAll(resp.Body)
+			return fmt.Errorf("speak: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+		}
+
+		dir, err := os.MkdirTemp("", "agentflow-speak-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "speech.wav")
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, resp.Body)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("speak: %w", err)
+		}
+
+		argv := make([]string, len(player))
+		for i, arg := range player {
+			argv[i] = strings.ReplaceAll(arg, "{file}", path)
+		}
+		return exec.CommandContext(ctx, argv[0], argv[1:]...).Run()
+	}
+}
+
+// sentence is a sentence waiting to be spoken, until ctx is canceled
+type sentence struct {
+	text string
+	ctx  context.Context
+}
+
+// newSpeaker starts a speaker speaking with say
+func newSpeaker(say func(ctx context.Context, text string) error) *Speaker {
+	s := &Speaker{say: say, queue: make(chan sentence, 256)}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	go s.run()
+	return s
+}
+
+// run speaks queued sentences in order
+func (s *Speaker) run() {
+	for next := range s.queue {
+		if next.ctx.Err() == nil {
+			s.say(next.ctx, next.text) // A failed sentence shouldn't stop the rest
+		}
+		s.mu.Lock()
+		s.busy--
+		s.mu.Unlock()
+	}
+}
+
+// Write adds streamed text, queueing the sentences it completes
+func (s *Speaker) Write(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending += text
+	for {
+		i := sentenceEnd(s.pending)
+		if i < 0 {
+			return
+		}
+		s.add(s.pending[:i])
+		s.pending = s.pending[i:]
+	}
+}
+
+// Flush queues what is left once the answer is complete
+func (s *Speaker) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(s.pending)
+	s.pending = ""
+	s.inCode = false
+}
+
+// Stop silences the speaker, dropping what is queued
+func (s *Speaker) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancel()
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.pending = ""
+	s.inCode = false
+	for {
+		select {
+		case <-s.queue:
+			s.busy--
+		default:
+			return
+		}
+	}
+}
+
+// Busy reports whether anything is being spoken or waits to be
+func (s *Speaker) Busy() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.busy > 0
+}
+
+// add queues a piece of the answer, unless it is code or has nothing to
+// say. The caller holds mu.
+func (s *Speaker) add(piece string) {
+	if strings.HasPrefix(strings.TrimSpace(piece), "```") {
+		s.inCode = !s.inCode
+		return
+	}
+	if s.inCode {
+		return
+	}
+	text := speakable(piece)
+	if !strings.ContainsFunc(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+		return
+	}
+	select {
+	case s.queue <- sentence{text: text, ctx: s.ctx}:
+		s.busy++
+	default: // Too far behind; drop rather than block the UI
+	}
+}
+
+// sentenceEnd returns where the first complete sentence or line of text
+// ends, or -1 when there is none yet
+func sentenceEnd(text string) int {
+	for i, r := range text {
+		switch r {
+		case '\n':
+			return i + 1
+		case '.', '!', '?':
+			if next := i + 1; next < len(text) && (text[next] == ' ' || text[next] == '\n') {
+				return next
+			}
+		}
+	}
+	return -1
+}
+
+var (
+	mdLink   = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdMarker = regexp.MustCompile("^\\s*(#+|[-*+]|>|\\d+\\.)\\s+")
+)
+
+// speakable strips markdown that would be read out as symbols
+func speakable(text string) string {
+	text = mdLink.ReplaceAllString(text, "$1")
+	text = mdMarker.ReplaceAllString(text, "")
+	text = strings.NewReplacer("**", "", "__", "", "`", "", "*", "").Replace(text)
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,216 @@
+// Package voice records speech from the microphone with a platform tool
+// and transcribes it with a whisper.cpp server or OpenAI's audio API, and
+// speaks answers aloud with say, espeak or OpenAI's speech API
+package voice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Default endpoints: a local whisper.cpp server, and OpenAI's when an
+// API key is set
+const (
+	WhisperCppURL = "http://127.0.0.1:8080/inference"
+	OpenAIURL     = "https://api.openai.com/v1/audio/transcriptions"
+)
+
+// ErrNoRecorder is returned when no recording tool is installed
+var ErrNoRecorder = errors.New("no audio recorder found (install sox or alsa-utils, or set voice.record)")
+
+// Config says how to record and where to transcribe
+type Config struct {
+	Record   string `yaml:"record,omitempty"`   // Recording command, with {file} for the WAV file to write; detected when empty
+	Endpoint string `yaml:"endpoint,omitempty"` // Transcription URL; whisper.cpp's local server, or OpenAI's with an API key, when empty
+	APIKey   string `yaml:"api_key,omitempty"`
+	Model    string `yaml:"model,omitempty"`    // Sent as the model field (whisper-1 for OpenAI when empty)
+	Language string `yaml:"language,omitempty"` // ISO 639-1 code of the speech, detected when empty
+
+	Speak SpeakConfig `yaml:"speak,omitempty"`
+}
+
+// endpoint returns the transcription URL
+func (c Config) endpoint() string {
+	switch {
+	case c.Endpoint != "":
+		return c.Endpoint
+	case c.APIKey != "":
+		return OpenAIURL
+	}
+	return WhisperCppURL
+}
+
+// Recording is speech being recorded to a temporary WAV file
+type Recording struct {
+	cmd  *exec.Cmd
+	path string
+	errs bytes.Buffer
+}
+
+// Start records from the default microphone until Stop or Cancel
+func (c Config) Start() (*Recording, error) {
+	dir, err := os.MkdirTemp("", "agentflow-voice-*")
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "speech.wav")
+
+	args, err := c.recorder(path)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	r := &Recording{cmd: exec.Command(args[0], args[1:]...), path: path}
+	r.cmd.Stderr = &r.errs
+	if err := r.cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("record: %w", err)
+	}
+	return r, nil
+}
+
+// recorder returns the command recording 16 kHz mono WAV to path: the
+// configured one, or the first tool installed
+func (c Config) recorder(path string) ([]string, error) {
+	if c.Record != "" {
+		args := strings.Fields(c.Record)
+		for i, arg := range args {
+			args[i] = strings.ReplaceAll(arg, "{file}", path)
+		}
+		return args, nil
+	}
+	for _, args := range recorderCommands(path) {
+		if _, err := exec.LookPath(args[0]); err == nil {
+			return args, nil
+		}
+	}
+	return nil, ErrNoRecorder
+}
+
+// recorderCommands lists the recording tools for the platform, in order
+// of preference
+func recorderCommands(path string) [][]string {
+	sox := []string{"rec", "-q", "-c", "1", "-r", "16000", "-b", "16", path}
+	if runtime.GOOS == "darwin" {
+		return [][]string{sox, {"ffmpeg", "-loglevel", "error", "-f", "avfoundation", "-i", ":0", "-ac", "1", "-ar", "16000", "-y", path}}
+	}
+	return [][]string{sox, {"arecord", "-q", "-f", "S16_LE", "-r", "16000", "-c", "1", path}, {"ffmpeg", "-loglevel", "error", "-f", "pulse", "-i", "default", "-ac", "1", "-ar", "16000", "-y", path}}
+}
+
+// Stop ends the recording and returns the WAV file, which the caller
+// removes with Remove
+func (r *Recording) Stop() (string, error) {
+	r.interrupt()
+	if info, err := os.Stat(r.path); err != nil || info.Size() == 0 {
+		r.Remove()
+		if msg := strings.TrimSpace(r.errs.String()); msg != "" {
+			return "", fmt.Errorf("record: %s", msg)
+		}
+		return "", errors.New("record: nothing was recorded")
+	}
+	return r.path, nil
+}
+
+// Cancel ends the recording and throws it away
+func (r *Recording) Cancel() {
+	r.interrupt()
+	r.Remove()
+}
+
+// Remove deletes the recording
+func (r *Recording) Remove() {
+	os.RemoveAll(filepath.Dir(r.path))
+}
+
+// interrupt stops the recorder as Ctrl+C would, so it finishes the file,
+// killing it when it doesn't stop
+func (r *Recording) interrupt() {
+	if err := r.cmd.Process.Signal(os.Interrupt); err != nil {
+		r.cmd.Process.Kill()
+	}
+	done := make(chan struct{})
+	go func() {
+		r.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		r.cmd.Process.Kill()
+		<-done
+	}
+}
+
+// Transcribe sends the audio file at path to the endpoint and returns
+// the text heard
+func (c Config) Transcribe(ctx context.Context, path string) (string, error) {
+	audio, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer audio.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, audio); err != nil {
+		return "", err
+	}
+	model := c.Model
+	if model == "" && c.endpoint() == OpenAIURL {
+		model = "whisper-1"
+	}
+	fields := map[string]string{"response_format": "json", "model": model, "language": c.Language}
+	for name, value := range fields {
+		if value != "" {
+			form.WriteField(name, value)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(), &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("transcribe: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("transcribe: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("transcribe: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("transcribe: unexpected response: %w", err)
+	}
+	return strings.TrimSpace(result.Text), nil
+}
@@ -0,0 +1,116 @@
+package voice
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTranscribe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("no file: %v", err)
+			return
+		}
+		audio, _ := io.ReadAll(file)
+		if string(audio) != "RIFF" || r.FormValue("language") != "fr" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("audio %q, language %q, auth %q", audio, r.FormValue("language"), r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"text": " Bonjour le monde. "}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "speech.wav")
+	os.WriteFile(path, []byte("RIFF"), 0o644)
+
+	c := Config{Endpoint: srv.URL, APIKey: "key", Language: "fr"}
+	text, err := c.Transcribe(context.Background(), path)
+	if err != nil || text != "Bonjour le monde." {
+		t.Errorf("Transcribe = %q, %v", text, err)
+	}
+}
+
+func TestConfig_Endpoint(t *testing.T) {
+	for c, want := range map[Config]string{
+		{}:              WhisperCppURL,
+		{APIKey: "key"}: OpenAIURL,
+		{Endpoint: "http://gpu:9000/inference", APIKey: "key"}: "http://gpu:9000/inference",
+	} {
+		if got := c.endpoint(); got != want {
+			t.Errorf("%+v: endpoint %q, want %q", c, got, want)
+		}
+	}
+}
+
+func TestConfig_Recorder(t *testing.T) {
+	args, err := Config{Record: "arecord -q {file}"}.recorder("/tmp/x.wav")
+	if err != nil || len(args) != 3 || args[2] != "/tmp/x.wav" {
+		t.Errorf("recorder = %v, %v", args, err)
+	}
+}
+
+func TestSpeaker(t *testing.T) {
+	spoken := make(chan string, 10)
+	s := newSpeaker(func(ctx context.Context, text string) error {
+		spoken <- text
+		return nil
+	})
+
+	for _, chunk := range []string{"Run the **tests** fi", "rst. Then:\n", "```go\nx := 1. y\n```\n", "- see [the docs](http://x) for more"} {
+		s.Write(chunk)
+	}
+	s.Flush()
+
+	for _, want := range []string{"Run the tests first.", "Then:", "see the docs for more"} {
+		if got := <-spoken; got != want {
+			t.Errorf("spoke %q, want %q", got, want)
+		}
+	}
+	select {
+	case got := <-spoken:
+		t.Errorf("spoke %q after the answer", got)
+	default:
+	}
+}
+
+func TestSentenceEnd(t *testing.T) {
+	for text, want := range map[string]int{
+		"Done. Next":     5,
+		"Version 1.2 is": -1,
+		"Line\nmore":     5,
+		"Really?":        -1,
+	} {
+		if got := sentenceEnd(text); got != want {
+			t.Errorf("sentenceEnd(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestSpeaker_Stop(t *testing.T) {
+	release := make(chan struct{})
+	spoken := make(chan string, 10)
+	s := newSpeaker(func(ctx context.Context, text string) error {
+		spoken <- text
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return nil
+	})
+
+	s.Write("One. Two. Three. ")
+	if got := <-spoken; got != "One." {
+		t.Fatalf("spoke %q first", got)
+	}
+	s.Stop()
+	s.Write("Four. ")
+	close(release)
+	if got := <-spoken; got != "Four." {
+		t.Errorf("spoke %q after Stop, want Four.", got)
+	}
+}
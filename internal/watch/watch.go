@@ -0,0 +1,222 @@
+// Package watch polls the filesystem for changes to files matching glob patterns
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultInterval is how often the tree is rescanned
+	DefaultInterval = 500 * time.Millisecond
+
+	// DefaultDebounce is how long the tree must stay quiet before a batch is emitted
+	DefaultDebounce = 300 * time.Millisecond
+)
+
+// Config holds watcher configuration
+type Config struct {
+	Root     string        // Directory to watch (default ".")
+	Patterns []string      // Glob patterns relative to Root, "**" matches any number of directories
+	Interval time.Duration // Polling interval
+	Debounce time.Duration // Quiet period before emitting changes
+}
+
+// Watcher detects file changes by periodically scanning the tree.
+// Polling keeps it dependency-free and portable; projects are small enough
+// that a stat walk every half second is cheap.
+type Watcher struct {
+	root     string
+	patterns []string
+	interval time.Duration
+	debounce time.Duration
+}
+
+// New creates a new watcher
+func New(cfg Config) *Watcher {
+	if cfg.Root == "" {
+		cfg.Root = "."
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.Debounce <= 0 {
+		cfg.Debounce = DefaultDebounce
+	}
+	return &Watcher{
+		root:     cfg.Root,
+		patterns: cfg.Patterns,
+		interval: cfg.Interval,
+		debounce: cfg.Debounce,
+	}
+}
+
+// Snapshot returns the modification time of every matching file
+func (w *Watcher) Snapshot() (map[string]time.Time, error) {
+	files := make(map[string]time.Time)
+	err := filepath.WalkDir(w.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Files can vanish mid-walk
+		}
+		if d.IsDir() {
+			if path != w.root && SkipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(w.root, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if !w.matches(rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files[rel] = info.ModTime()
+		return nil
+	})
+	return files, err
+}
+
+// Run watches until ctx is cancelled, sending each debounced batch of changed paths
+func (w *Watcher) Run(ctx context.Context) (<-chan []string, error) {
+	prev, err := w.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan []string)
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		pending := make(map[string]bool)
+		var lastChange time.Time
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			curr, err := w.Snapshot()
+			if err != nil {
+				continue
+			}
+			for _, path := range Diff(prev, curr) {
+				pending[path] = true
+				lastChange = time.Now()
+			}
+			prev = curr
+
+			if len(pending) == 0 || time.Since(lastChange) < w.debounce {
+				continue
+			}
+
+			batch := make([]string, 0, len(pending))
+			for path := range pending {
+				batch = append(batch, path)
+			}
+			sort.Strings(batch)
+			pending = make(map[string]bool)
+
+			select {
+			case out <- batch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// matches reports whether a slash-separated relative path matches any pattern
+func (w *Watcher) matches(rel string) bool {
+	if len(w.patterns) == 0 {
+		return true
+	}
+	for _, p := range w.patterns {
+		if Match(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns the sorted paths that were added, removed, or modified between snapshots
+func Diff(prev, curr map[string]time.Time) []string {
+	var changed []string
+	for path, mod := range curr {
+		if old, ok := prev[path]; !ok || !old.Equal(mod) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range prev {
+		if _, ok := curr[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Match reports whether a slash-separated path matches a glob pattern.
+// "**" matches zero or more directories; a pattern without a slash is
+// matched against the file's base name, like .gitignore.
+func Match(pattern, path string) bool {
+	pattern = filepath.ToSlash(pattern)
+	path = filepath.ToSlash(path)
+
+	if !strings.Contains(pattern, "/") {
+		ok, _ := filepath.Match(pattern, filepath.Base(path))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(path, "/"))
+}
+
+func matchSegments(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(path); i++ {
+				if matchSegments(rest, path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		path = path[1:]
+	}
+	return len(path) == 0
+}
+
+// SkipDir reports whether a directory should never be scanned: hidden
+// directories and dependency trees
+func SkipDir(name string) bool {
+	switch name {
+	case "node_modules", "vendor":
+		return true
+	}
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
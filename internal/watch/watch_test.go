@@ -0,0 +1,113 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "internal/agent/agent.go", true},
+		{"**/*.go", "README.md", false},
+		{"*.go", "internal/agent/agent.go", true},
+		{"internal/*.go", "internal/agent/agent.go", false},
+		{"internal/**/*_test.go", "internal/agent/agent_test.go", true},
+		{"internal/**", "internal/agent/agent.go", true},
+		{"cmd/**/main.go", "internal/main.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"|"+tt.path, func(t *testing.T) {
+			if got := Match(tt.pattern, tt.path); got != tt.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	now := time.Now()
+	prev := map[string]time.Time{
+		"a.go": now,
+		"b.go": now,
+	}
+	curr := map[string]time.Time{
+		"a.go": now,
+		"b.go": now.Add(time.Second),
+		"c.go": now,
+	}
+
+	changed := Diff(prev, curr)
+	if len(changed) != 2 || changed[0] != "b.go" || changed[1] != "c.go" {
+		t.Errorf("changed = %v, want [b.go c.go]", changed)
+	}
+
+	removed := Diff(curr, prev)
+	if len(removed) != 2 || removed[0] != "b.go" || removed[1] != "c.go" {
+		t.Errorf("removed = %v, want [b.go c.go]", removed)
+	}
+}
+
+func TestWatcher_Snapshot(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "pkg"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, ".git"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "pkg", "lib.go"), []byte("package pkg"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "notes.md"), []byte("# notes"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, ".git", "config.go"), []byte("ignored"), 0644)
+
+	w := New(Config{Root: tmpDir, Patterns: []string{"**/*.go"}})
+	files, err := w.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	if len(files) != 2 {
+		t.Errorf("expected 2 files, got %d: %v", len(files), files)
+	}
+	if _, ok := files["pkg/lib.go"]; !ok {
+		t.Error("expected pkg/lib.go in snapshot")
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "main.go")
+	os.WriteFile(path, []byte("package main"), 0644)
+
+	w := New(Config{
+		Root:     tmpDir,
+		Patterns: []string{"*.go"},
+		Interval: 10 * time.Millisecond,
+		Debounce: 20 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	changes, err := w.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, "new.go"), []byte("package main"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "ignored.txt"), []byte("text"), 0644)
+
+	select {
+	case batch := <-changes:
+		if len(batch) != 1 || batch[0] != "new.go" {
+			t.Errorf("batch = %v, want [new.go]", batch)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change")
+	}
+}
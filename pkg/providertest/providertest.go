@@ -0,0 +1,231 @@
+// Package providertest is a fake LLM provider for tests: scripted answers
+// streamed in chunks, latency, failures, and a context window that
+// refuses long requests the way OpenAI-compatible servers do
+package providertest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/pkg/tokens"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+var _ provider.Provider = (*Provider)(nil)
+
+// Provider is a fake provider. Its zero value answers "ok" to everything;
+// the fields script it and are read at each request.
+type Provider struct {
+	ProviderName string   // "test" when empty
+	ModelList    []string // {"test-model"} when empty
+
+	Responses []Response // Answers in turn, the last one repeating; "ok" when empty
+
+	Latency    time.Duration // Before answering
+	ChunkDelay time.Duration // Before each streamed chunk
+
+	// ContextWindow refuses requests over this many tokens, at four
+	// characters per token, with an error naming the window; 0 for none
+	ContextWindow int
+
+	mu       sync.Mutex
+	requests []types.CompletionRequest
+}
+
+// Response is one scripted answer
+type Response struct {
+	Content   string
+	Chunks    []string // Streamed in these pieces; Content in one when nil
+	Reasoning string
+	ToolCalls []types.ToolCall // Sent on the Done chunk, as providers do
+	Usage     *types.Usage
+
+	Err       error // The request fails with it
+	StreamErr error // Streaming fails with it after the chunks, as when a connection drops
+}
+
+// New returns a provider answering with answers in turn
+func New(answers ...string) *Provider {
+	p := &Provider{}
+	for _, answer := range answers {
+		p.Responses = append(p.Responses, Response{Content: answer})
+	}
+	return p
+}
+
+// Streamed returns a provider streaming its answer in chunks, waiting
+// delay before each
+func Streamed(delay time.Duration, chunks ...string) *Provider {
+	return &Provider{ChunkDelay: delay, Responses: []Response{{Chunks: chunks}}}
+}
+
+// Failing returns a provider failing every request with err
+func Failing(err error) *Provider {
+	return &Provider{Responses: []Response{{Err: err}}}
+}
+
+// ContextTooLong is the error a provider with a window of window tokens
+// returns for a request of used tokens
+func ContextTooLong(name, model string, window, used int) error {
+	return &provider.APIError{
+		Provider: name, Model: model, Status: http.StatusBadRequest, Kind: provider.ErrContextTooLong,
+		Message: fmt.Sprintf("This model's maximum context length is %d tokens, however you requested %d tokens", window, used),
+	}
+}
+
+// RateLimited is the error of a provider limiting requests, lifted after
+// retryAfter when it is set
+func RateLimited(name, model string, retryAfter time.Duration) error {
+	return &provider.APIError{
+		Provider: name, Model: model, Status: http.StatusTooManyRequests, Kind: provider.ErrRateLimited,
+		Message: "Rate limit reached", RetryAfter: retryAfter,
+	}
+}
+
+func (p *Provider) Name() string {
+	if p.ProviderName == "" {
+		return "test"
+	}
+	return p.ProviderName
+}
+
+func (p *Provider) Models() []string {
+	if len(p.ModelList) == 0 {
+		return []string{"test-model"}
+	}
+	return p.ModelList
+}
+
+func (p *Provider) SupportsModel(string) bool { return true }
+
+func (p *Provider) Complete(ctx context.Context, req types.CompletionRequest) (*types.CompletionResponse, error) {
+	resp, err := p.answer(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StreamErr != nil {
+		return nil, resp.StreamErr
+	}
+	out := &types.CompletionResponse{
+		Content: resp.content(), Reasoning: resp.Reasoning, ToolCalls: resp.ToolCalls,
+		Model: req.Model, FinishReason: "stop",
+	}
+	if resp.Usage != nil {
+		out.TokensUsed = resp.Usage.TotalTokens
+	}
+	return out, nil
+}
+
+func (p *Provider) Stream(ctx context.Context, req types.CompletionRequest) (<-chan types.StreamChunk, error) {
+	resp, err := p.answer(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	chunks := resp.Chunks
+	if chunks == nil {
+		chunks = []string{resp.Content}
+	}
+
+	ch := make(chan types.StreamChunk)
+	go func() {
+		defer close(ch)
+		if resp.Reasoning != "" {
+			ch <- types.StreamChunk{Reasoning: resp.Reasoning}
+		}
+		for i, content := range chunks {
+			if err := p.wait(ctx, p.ChunkDelay); err != nil {
+				ch <- types.StreamChunk{Error: err}
+				return
+			}
+			chunk := types.StreamChunk{Content: content}
+			if i == len(chunks)-1 && resp.StreamErr == nil {
+				chunk.Done, chunk.FinishReason, chunk.Usage, chunk.ToolCalls = true, "stop", resp.Usage, resp.ToolCalls
+			}
+			ch <- chunk
+		}
+		if resp.StreamErr != nil {
+			ch <- types.StreamChunk{Error: resp.StreamErr}
+		}
+	}()
+	return ch, nil
+}
+
+// Requests returns the requests made so far, in order
+func (p *Provider) Requests() []types.CompletionRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]types.CompletionRequest(nil), p.requests...)
+}
+
+// Calls returns how many requests were made
+func (p *Provider) Calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.requests)
+}
+
+// Prompts returns the last message of each request, which is usually
+// what the user asked
+func (p *Provider) Prompts() []string {
+	var prompts []string
+	for _, req := range p.Requests() {
+		if n := len(req.Messages); n > 0 {
+			prompts = append(prompts, req.Messages[n-1].Content)
+		}
+	}
+	return prompts
+}
+
+// answer records a request and returns its scripted response, after the
+// latency, or the error refusing it
+func (p *Provider) answer(ctx context.Context, req types.CompletionRequest) (Response, error) {
+	p.mu.Lock()
+	n := len(p.requests)
+	p.requests = append(p.requests, req)
+	p.mu.Unlock()
+
+	if err := p.wait(ctx, p.Latency); err != nil {
+		return Response{}, err
+	}
+	if p.ContextWindow > 0 {
+		used := 0
+		for _, msg := range req.Messages {
+			used += tokens.Estimate(msg.Content)
+		}
+		if used > p.ContextWindow {
+			return Response{}, ContextTooLong(p.Name(), req.Model, p.ContextWindow, used)
+		}
+	}
+
+	resp := Response{Content: "ok"}
+	if len(p.Responses) > 0 {
+		resp = p.Responses[min(n, len(p.Responses)-1)]
+	}
+	return resp, resp.Err
+}
+
+// wait sleeps for d, or until ctx is done
+func (p *Provider) wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// content is the whole answer
+func (r Response) content() string {
+	if r.Chunks == nil {
+		return r.Content
+	}
+	return strings.Join(r.Chunks, "")
+}
@@ -0,0 +1,115 @@
+package providertest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentflow/agentflow/internal/provider"
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+func request(content string) types.CompletionRequest {
+	return types.CompletionRequest{Model: "test-model", Messages: []types.Message{{Role: "user", Content: content}}}
+}
+
+// collect reads a stream, returning its content and the error it ended with
+func collect(t *testing.T, ch <-chan types.StreamChunk) (string, []types.StreamChunk, error) {
+	t.Helper()
+	var content strings.Builder
+	var chunks []types.StreamChunk
+	for chunk := range ch {
+		chunks = append(chunks, chunk)
+		if chunk.Error != nil {
+			return content.String(), chunks, chunk.Error
+		}
+		content.WriteString(chunk.Content)
+	}
+	return content.String(), chunks, nil
+}
+
+func TestProvider_Responses(t *testing.T) {
+	p := New("one", "two")
+	for _, want := range []string{"one", "two", "two"} {
+		resp, err := p.Complete(context.Background(), request("hi"))
+		if err != nil || resp.Content != want {
+			t.Errorf("Complete = %v, %v; want %q", resp, err, want)
+		}
+	}
+	if p.Calls() != 3 || p.Prompts()[0] != "hi" {
+		t.Errorf("Calls = %d, Prompts = %q", p.Calls(), p.Prompts())
+	}
+
+	var zero Provider
+	if resp, _ := zero.Complete(context.Background(), request("hi")); resp.Content != "ok" || zero.Name() != "test" {
+		t.Errorf("zero value answered %q as %q", resp.Content, zero.Name())
+	}
+}
+
+func TestProvider_Stream(t *testing.T) {
+	p := Streamed(time.Millisecond, "Hello", ", ", "world")
+	p.Responses[0].ToolCalls = []types.ToolCall{{ID: "1", Name: "read"}}
+	ch, err := p.Stream(context.Background(), request("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, chunks, err := collect(t, ch)
+	if err != nil || content != "Hello, world" || len(chunks) != 3 {
+		t.Fatalf("streamed %q in %d chunks, %v", content, len(chunks), err)
+	}
+	if last := chunks[2]; !last.Done || len(last.ToolCalls) != 1 || chunks[0].Done {
+		t.Errorf("Done and tool calls should come on the last chunk only: %+v", chunks)
+	}
+}
+
+func TestProvider_Failures(t *testing.T) {
+	failed := errors.New("boom")
+	if _, err := Failing(failed).Stream(context.Background(), request("hi")); err != failed {
+		t.Errorf("Stream err = %v", err)
+	}
+
+	dropped := errors.New("connection reset")
+	p := &Provider{Responses: []Response{{Chunks: []string{"partial"}, StreamErr: dropped}}}
+	ch, _ := p.Stream(context.Background(), request("hi"))
+	if content, _, err := collect(t, ch); content != "partial" || err != dropped {
+		t.Errorf("streamed %q, %v; want partial then the error", content, err)
+	}
+
+	err := RateLimited("test", "test-model", time.Second)
+	if !errors.Is(err, provider.ErrRateLimited) {
+		t.Errorf("RateLimited isn't classified: %v", err)
+	}
+}
+
+func TestProvider_ContextWindow(t *testing.T) {
+	p := &Provider{ContextWindow: 10}
+	if _, err := p.Complete(context.Background(), request("short")); err != nil {
+		t.Errorf("short request refused: %v", err)
+	}
+	_, err := p.Complete(context.Background(), request(strings.Repeat("word ", 20)))
+	if !errors.Is(err, provider.ErrContextTooLong) || !strings.Contains(err.Error(), "maximum context length is 10 tokens") {
+		t.Errorf("long request: %v", err)
+	}
+}
+
+func TestProvider_Cancel(t *testing.T) {
+	p := Streamed(time.Second, "a", "b")
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := p.Stream(ctx, request("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if _, _, err := collect(t, ch); err != nil && !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want canceled", err)
+	}
+
+	p = &Provider{Latency: time.Second}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Complete(ctx, request("hi")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Complete err = %v, want the deadline", err)
+	}
+}
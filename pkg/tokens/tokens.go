@@ -0,0 +1,113 @@
+// Package tokens counts the tokens a model's tokenizer splits text into:
+// exactly with OpenAI's tiktoken encodings for the models that use them,
+// and approximately for the others
+package tokens
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/agentflow/agentflow/pkg/types"
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// Encodings Tokens counts exactly with
+const (
+	O200kBase  = "o200k_base"
+	Cl100kBase = "cl100k_base"
+)
+
+// messageOverhead is what OpenAI's chat format adds around each message
+const messageOverhead = 3
+
+func init() {
+	// The encodings are built in, rather than downloaded on first use
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// encodingPrefixes maps model name prefixes to their encoding, longest
+// prefixes first so gpt-4o isn't taken for gpt-4
+var encodingPrefixes = []struct{ prefix, encoding string }{
+	{"gpt-4o", O200kBase},
+	{"gpt-4.1", O200kBase},
+	{"gpt-4.5", O200kBase},
+	{"gpt-5", O200kBase},
+	{"gpt-oss", O200kBase},
+	{"chatgpt-", O200kBase},
+	{"o1", O200kBase},
+	{"o3", O200kBase},
+	{"o4", O200kBase},
+	{"gpt-4", Cl100kBase},
+	{"gpt-3.5", Cl100kBase},
+	{"text-embedding-", Cl100kBase},
+}
+
+var (
+	mu       sync.Mutex
+	encoders = map[string]*tiktoken.Tiktoken{} // Loaded on first use, taking a moment
+)
+
+// Encoding returns the tiktoken encoding of model, or "" when Tokens
+// estimates it. Providers' prefixes such as "openai/" are ignored.
+func Encoding(model string) string {
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	model = strings.ToLower(model)
+	for _, e := range encodingPrefixes {
+		if strings.HasPrefix(model, e.prefix) {
+			return e.encoding
+		}
+	}
+	return ""
+}
+
+// Tokens counts the tokens in text for model: exactly when it uses a
+// known encoding, and otherwise at four characters per token, close
+// enough for English and code with most tokenizers
+func Tokens(model, text string) int {
+	if text == "" {
+		return 0
+	}
+	if enc := encoder(Encoding(model)); enc != nil {
+		return len(enc.EncodeOrdinary(text))
+	}
+	return Estimate(text)
+}
+
+// Estimate approximates the tokens in text at four characters per token
+func Estimate(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// Messages counts the tokens a conversation takes for model, with the
+// few each message adds to the content in OpenAI's chat format
+func Messages(model string, msgs []types.Message) int {
+	n := 0
+	for _, msg := range msgs {
+		n += Tokens(model, msg.Content) + messageOverhead
+		for _, call := range msg.ToolCalls {
+			n += Tokens(model, call.Name) + Tokens(model, call.Arguments)
+		}
+	}
+	if len(msgs) > 0 {
+		n += messageOverhead // The reply is primed with the assistant's role
+	}
+	return n
+}
+
+// encoder returns the encoder for an encoding, or nil when there is none
+func encoder(name string) *tiktoken.Tiktoken {
+	if name == "" {
+		return nil
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	enc, ok := encoders[name]
+	if !ok {
+		enc, _ = tiktoken.GetEncoding(name) // nil on failure, estimating instead
+		encoders[name] = enc
+	}
+	return enc
+}
@@ -0,0 +1,49 @@
+package tokens
+
+import (
+	"testing"
+
+	"github.com/agentflow/agentflow/pkg/types"
+)
+
+func TestEncoding(t *testing.T) {
+	for model, want := range map[string]string{
+		"gpt-4o-mini":        O200kBase,
+		"openai/gpt-4.1":     O200kBase,
+		"o3-mini":            O200kBase,
+		"gpt-4-turbo":        Cl100kBase,
+		"gpt-3.5-turbo-0125": Cl100kBase,
+		"claude-sonnet-4":    "",
+		"llama3.2:3b":        "",
+	} {
+		if got := Encoding(model); got != want {
+			t.Errorf("Encoding(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	// Counts from OpenAI's tiktoken
+	if got := Tokens("gpt-4", "tiktoken is great!"); got != 6 {
+		t.Errorf("cl100k_base: %d tokens, want 6", got)
+	}
+	if got := Tokens("gpt-4o", "hello world"); got != 2 {
+		t.Errorf("o200k_base: %d tokens, want 2", got)
+	}
+	if got := Tokens("llama3", "12345678"); got != 2 {
+		t.Errorf("estimate: %d tokens, want 2", got)
+	}
+	if got := Tokens("gpt-4o", ""); got != 0 {
+		t.Errorf("empty: %d tokens", got)
+	}
+}
+
+func TestMessages(t *testing.T) {
+	msgs := []types.Message{{Role: "user", Content: "hello world"}, {Role: "assistant", Content: "hello world"}}
+	if got := Messages("gpt-4o", msgs); got != 2*(2+messageOverhead)+messageOverhead {
+		t.Errorf("Messages = %d", got)
+	}
+	if got := Messages("gpt-4o", nil); got != 0 {
+		t.Errorf("Messages(nil) = %d", got)
+	}
+}
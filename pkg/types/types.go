@@ -1,10 +1,79 @@
 // Package types defines shared types for AgentFlow
 package types
 
-// Message represents a chat message
+import "time"
+
+// Message represents a chat message. Only Role and Content are required;
+// the other fields are omitted from JSON when unset, so older sessions
+// load unchanged.
 type Message struct {
-	Role    string `json:"role"`    // system, user, assistant
-	Content string `json:"content"` // message content
+	Role        string       `json:"role"`                   // system, user, assistant, tool
+	Content     string       `json:"content"`                // message content
+	Name        string       `json:"name,omitempty"`         // participant or tool name
+	ToolCallID  string       `json:"tool_call_id,omitempty"` // the call a tool message answers
+	ToolCalls   []ToolCall   `json:"tool_calls,omitempty"`   // tools requested by the assistant
+	Timestamp   time.Time    `json:"timestamp,omitzero"`     // when the message was added
+	TokenCount  int          `json:"token_count,omitempty"`  // tokens, when reported by the provider
+	Attachments []Attachment `json:"attachments,omitempty"`  // images and files sent with the message
+	Pinned      bool         `json:"pinned,omitempty"`       // kept through compaction and truncation
+}
+
+// ToolCall is a tool invocation requested by the model
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON-encoded arguments
+}
+
+// ToolDefinition describes a tool the model may call
+type ToolDefinition struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"` // JSON Schema for the arguments
+}
+
+// Activity is what a tool call did, in a few words, for the activity
+// feed: reading a file, searching the code, editing a file, and so on
+type Activity struct {
+	Time    time.Time `json:"time"`
+	Tool    string    `json:"tool"`
+	Kind    string    `json:"kind"`              // read, search, fetch, run or edit
+	Subject string    `json:"subject,omitempty"` // The files, symbol, URL or command acted on
+	Error   string    `json:"error,omitempty"`   // Why the call failed
+	Cached  bool      `json:"cached,omitempty"`  // Answered from an earlier identical call
+}
+
+func (a Activity) String() string {
+	s := a.Kind
+	if a.Subject != "" {
+		s += " " + a.Subject
+	}
+	switch {
+	case a.Error != "":
+		s += " (failed: " + a.Error + ")"
+	case a.Cached:
+		s += " (cached)"
+	}
+	return s
+}
+
+// Attachment is binary content sent alongside a message
+type Attachment struct {
+	Type     string `json:"type"`           // "image"
+	MimeType string `json:"mime_type"`      // e.g. "image/png"
+	Name     string `json:"name,omitempty"` // source, e.g. "clipboard"
+	Data     []byte `json:"data"`           // raw bytes (base64 in JSON)
+}
+
+// IsImage reports whether the attachment is an image
+func (a Attachment) IsImage() bool {
+	return a.Type == "image"
+}
+
+// Example is a few-shot exchange shown to the model before the conversation
+type Example struct {
+	User      string `json:"user" yaml:"user"`
+	Assistant string `json:"assistant" yaml:"assistant"`
 }
 
 // CompletionRequest is sent to providers
@@ -13,22 +82,64 @@ type CompletionRequest struct {
 	Messages    []Message `json:"messages"`
 	Temperature float64   `json:"temperature,omitempty"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
+	TopP        float64   `json:"top_p,omitempty"`
+	Seed        *int      `json:"seed,omitempty"` // makes sampling repeatable, where supported
+	Stop        []string  `json:"stop,omitempty"` // sequences that end generation
 	Stream      bool      `json:"stream,omitempty"`
+	Think       bool      `json:"think,omitempty"` // ask reasoning models to think (Ollama)
+
+	Tools []ToolDefinition `json:"tools,omitempty"` // tools the model may call
+}
+
+// GenerationParams are sampling settings sent with every request; zero
+// values leave the provider's defaults
+type GenerationParams struct {
+	Temperature float64 `json:"temperature,omitempty"`
+	MaxTokens   int     `json:"max_tokens,omitempty"`
+	TopP        float64 `json:"top_p,omitempty"`
+	Seed        *int    `json:"seed,omitempty"`
 }
 
 // CompletionResponse from providers
 type CompletionResponse struct {
 	Content      string `json:"content"`
+	Reasoning    string `json:"reasoning,omitempty"` // thinking, kept apart from the answer
 	Model        string `json:"model"`
 	FinishReason string `json:"finish_reason"`
 	TokensUsed   int    `json:"tokens_used"`
+
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"` // tools the model wants run
 }
 
 // StreamChunk for streaming responses
 type StreamChunk struct {
-	Content string
-	Done    bool
-	Error   error
+	Content   string
+	Reasoning string // thinking tokens, streamed separately from content
+	Done      bool
+	Error     error
+	Notice    string // For the user about the request, such as context cut to fit
+
+	// Replace makes Content replace the answer streamed so far, such as a
+	// stronger model's answer arriving after a fast one
+	Replace bool
+	// Alternative is another answer to the same request, set on the Done
+	// chunk, that the user may swap in
+	Alternative string
+
+	// Set on the final (Done) chunk when the provider reports them
+	FinishReason string
+	Usage        *Usage
+
+	// ToolCalls are tools the model wants run. The agent forwards them on
+	// a chunk that is not Done, then streams the answer after running them.
+	ToolCalls []ToolCall
+}
+
+// Usage reports token counts for a completion
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
 }
 
 // ProviderType identifies the LLM provider
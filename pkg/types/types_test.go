@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/json"
 	"testing"
+	"time"
 )
 
 func TestMessage_JSON(t *testing.T) {
@@ -29,6 +30,53 @@ func TestMessage_JSON(t *testing.T) {
 	}
 }
 
+func TestMessage_OmitEmptyMetadata(t *testing.T) {
+	data, err := json.Marshal(Message{Role: "user", Content: "hi"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(data) != `{"role":"user","content":"hi"}` {
+		t.Errorf("Marshal = %s, want only role and content", data)
+	}
+}
+
+func TestMessage_Metadata(t *testing.T) {
+	msg := Message{
+		Role:       "assistant",
+		ToolCalls:  []ToolCall{{ID: "call_1", Name: "read_file", Arguments: `{"path":"go.mod"}`}},
+		Timestamp:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		TokenCount: 12,
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var decoded Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(decoded.ToolCalls) != 1 || decoded.ToolCalls[0].Arguments != `{"path":"go.mod"}` {
+		t.Errorf("ToolCalls = %+v", decoded.ToolCalls)
+	}
+	if !decoded.Timestamp.Equal(msg.Timestamp) {
+		t.Errorf("Timestamp = %v", decoded.Timestamp)
+	}
+	if decoded.TokenCount != 12 {
+		t.Errorf("TokenCount = %d", decoded.TokenCount)
+	}
+
+	// Sessions saved before these fields existed still load
+	var old Message
+	if err := json.Unmarshal([]byte(`{"role":"user","content":"hi"}`), &old); err != nil {
+		t.Fatalf("Unmarshal old: %v", err)
+	}
+	if old.Content != "hi" || !old.Timestamp.IsZero() {
+		t.Errorf("old message = %+v", old)
+	}
+}
+
 func TestCompletionRequest_JSON(t *testing.T) {
 	req := CompletionRequest{
 		Model: "llama3.3",
@@ -0,0 +1,44 @@
+// Package skills embeds the starter skills shipped with agentflow
+package skills
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Starter holds the canonical skills, one SKILL.md per directory
+//
+//go:embed brainstorming writing-plans test-driven-development systematic-debugging verification-before-completion
+var Starter embed.FS
+
+// Install copies the starter skills into dir, keeping any skill already
+// there, and returns the names of the skills it wrote
+func Install(dir string) ([]string, error) {
+	entries, err := fs.ReadDir(Starter, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var installed []string
+	for _, entry := range entries {
+		dest := filepath.Join(dir, entry.Name(), "SKILL.md")
+		if _, err := os.Stat(dest); err == nil {
+			continue
+		}
+		data, err := fs.ReadFile(Starter, entry.Name()+"/SKILL.md")
+		if err != nil {
+			return installed, err
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return installed, fmt.Errorf("install skill %s: %w", entry.Name(), err)
+		}
+		if err := os.WriteFile(dest, data, 0644); err != nil {
+			return installed, fmt.Errorf("install skill %s: %w", entry.Name(), err)
+		}
+		installed = append(installed, entry.Name())
+	}
+	return installed, nil
+}
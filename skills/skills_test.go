@@ -0,0 +1,28 @@
+package skills
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstall(t *testing.T) {
+	dir := t.TempDir()
+	custom := filepath.Join(dir, "brainstorming", "SKILL.md")
+	os.MkdirAll(filepath.Dir(custom), 0755)
+	os.WriteFile(custom, []byte("mine"), 0644)
+
+	installed, err := Install(dir)
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if len(installed) != 4 {
+		t.Errorf("installed %v, want the 4 skills not already there", installed)
+	}
+	if data, _ := os.ReadFile(custom); string(data) != "mine" {
+		t.Errorf("existing skill overwritten: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "writing-plans", "SKILL.md")); err != nil {
+		t.Errorf("writing-plans not installed: %v", err)
+	}
+}